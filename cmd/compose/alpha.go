@@ -32,6 +32,10 @@ func alphaCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Back
 		vizCommand(p, dockerCli, backendOptions),
 		publishCommand(p, dockerCli, backendOptions),
 		generateCommand(p, dockerCli, backendOptions),
+		dnsCommand(p, dockerCli, backendOptions),
+		exportSpecsCommand(p, dockerCli, backendOptions),
+		checkpointCommand(p, dockerCli, backendOptions),
+		restoreCommand(p, dockerCli, backendOptions),
 	)
 	return cmd
 }
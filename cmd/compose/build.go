@@ -18,17 +18,23 @@ package compose
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/cli"
 	"github.com/compose-spec/compose-go/v2/types"
+	dockercli "github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	cliopts "github.com/docker/cli/opts"
 	"github.com/spf13/cobra"
 
 	"github.com/docker/compose/v5/cmd/display"
+	"github.com/docker/compose/v5/cmd/formatter"
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/compose"
 )
@@ -41,27 +47,43 @@ type buildOptions struct {
 	args       []string
 	noCache    bool
 	memory     cliopts.MemBytes
-	ssh        string
+	ssh        []string
+	secrets    []string
 	builder    string
 	deps       bool
 	print      bool
 	check      bool
 	sbom       string
 	provenance string
+	// checkChanges reports the services which would be rebuilt, without
+	// building them
+	checkChanges bool
+	exitCode     bool
 }
 
 func (opts buildOptions) toAPIBuildOptions(services []string) (api.BuildOptions, error) {
 	var SSHKeys []types.SSHKey
-	if opts.ssh != "" {
-		id, path, found := strings.Cut(opts.ssh, "=")
+	for _, ssh := range opts.ssh {
+		if ssh == "" {
+			ssh = "default"
+		}
+		id, path, found := strings.Cut(ssh, "=")
 		if !found && id != "default" {
-			return api.BuildOptions{}, fmt.Errorf("invalid ssh key %q", opts.ssh)
+			return api.BuildOptions{}, fmt.Errorf("invalid ssh key %q", ssh)
 		}
 		SSHKeys = append(SSHKeys, types.SSHKey{
 			ID:   id,
 			Path: path,
 		})
 	}
+	var secrets []api.BuildSecret
+	for _, value := range opts.secrets {
+		secret, err := parseBuildSecret(value)
+		if err != nil {
+			return api.BuildOptions{}, err
+		}
+		secrets = append(secrets, secret)
+	}
 	builderName := opts.builder
 	if builderName == "" {
 		builderName = os.Getenv("BUILDX_BUILDER")
@@ -85,12 +107,82 @@ func (opts buildOptions) toAPIBuildOptions(services []string) (api.BuildOptions,
 		Print:      opts.print,
 		Check:      opts.check,
 		SSHs:       SSHKeys,
+		Secrets:    secrets,
 		Builder:    builderName,
 		SBOM:       opts.sbom,
 		Provenance: opts.provenance,
 	}, nil
 }
 
+// parseBuildSecret parses a secret passed on the command line in the format
+// buildx accepts: id=ID[,type=file|env][,src=PATH|,env=VAR]. A secret only
+// declaring its id is read from the environment variable of the same name
+// when set, otherwise from the file of the same name.
+func parseBuildSecret(value string) (api.BuildSecret, error) {
+	fields, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return api.BuildSecret{}, fmt.Errorf("invalid secret %q: %w", value, err)
+	}
+	var secret api.BuildSecret
+	typ := ""
+	for _, field := range fields {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return api.BuildSecret{}, fmt.Errorf("invalid secret %q: field %q must be a key=value pair", value, field)
+		}
+		switch strings.ToLower(key) {
+		case "id":
+			secret.ID = val
+		case "type":
+			if val != "file" && val != "env" {
+				return api.BuildSecret{}, fmt.Errorf("invalid secret %q: unsupported type %q", value, val)
+			}
+			typ = val
+		case "src", "source":
+			secret.File = val
+		case "env":
+			secret.Env = val
+		default:
+			return api.BuildSecret{}, fmt.Errorf("invalid secret %q: unexpected key %q", value, key)
+		}
+	}
+	if secret.ID == "" {
+		return api.BuildSecret{}, fmt.Errorf("invalid secret %q: id is required", value)
+	}
+	if secret.File != "" && secret.Env != "" {
+		return api.BuildSecret{}, fmt.Errorf("invalid secret %q: src and env are mutually exclusive", value)
+	}
+	switch typ {
+	case "env":
+		if secret.Env == "" {
+			secret.Env, secret.File = secret.File, ""
+		}
+	case "file":
+		if secret.File == "" {
+			secret.File = secret.ID
+		}
+	}
+	if secret.File == "" && secret.Env == "" {
+		if _, ok := os.LookupEnv(secret.ID); ok {
+			secret.Env = secret.ID
+		} else {
+			secret.File = secret.ID
+		}
+	}
+	if secret.File == "" {
+		return secret, nil
+	}
+	if path, ok := strings.CutPrefix(secret.File, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return api.BuildSecret{}, err
+		}
+		secret.File = filepath.Join(home, path)
+	}
+	secret.File, err = filepath.Abs(secret.File)
+	return secret, err
+}
+
 func buildCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
 	opts := buildOptions{
 		ProjectOptions: p,
@@ -110,10 +202,7 @@ func buildCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Back
 			return nil
 		}),
 		RunE: AdaptCmd(func(ctx context.Context, cmd *cobra.Command, args []string) error {
-			if cmd.Flags().Changed("ssh") && opts.ssh == "" {
-				opts.ssh = "default"
-			}
-			if cmd.Flags().Changed("progress") && opts.ssh == "" {
+			if cmd.Flags().Changed("progress") && len(opts.ssh) == 0 {
 				fmt.Fprint(os.Stderr, "--progress is a global compose flag, better use `docker compose --progress xx build ...\n")
 			}
 			return runBuild(ctx, dockerCli, backendOptions, opts, args)
@@ -125,7 +214,8 @@ func buildCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Back
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress the build output")
 	flags.BoolVar(&opts.pull, "pull", false, "Always attempt to pull a newer version of the image")
 	flags.StringArrayVar(&opts.args, "build-arg", []string{}, "Set build-time variables for services")
-	flags.StringVar(&opts.ssh, "ssh", "", "Set SSH authentications used when building service images. (use 'default' for using your default SSH Agent)")
+	flags.StringArrayVar(&opts.ssh, "ssh", []string{}, "Set SSH authentications used when building service images. (use 'default' for using your default SSH Agent)")
+	flags.StringArrayVar(&opts.secrets, "secret", []string{}, `Expose a secret to the builds of all services, overriding the one they declare with the same id (format: "id=mysecret[,src=/local/secret]" or "id=mysecret,env=VAR")`)
 	flags.StringVar(&opts.builder, "builder", "", "Set builder to use")
 	flags.BoolVar(&opts.deps, "with-dependencies", false, "Also build dependencies (transitively)")
 	flags.StringVar(&opts.provenance, "provenance", "", `Add a provenance attestation`)
@@ -145,6 +235,8 @@ func buildCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Back
 	flags.MarkHidden("progress") //nolint:errcheck
 	flags.BoolVar(&opts.print, "print", false, "Print equivalent bake file")
 	flags.BoolVar(&opts.check, "check", false, "Check build configuration")
+	flags.BoolVar(&opts.checkChanges, "check-changes", false, "Report which services would be rebuilt as their build context, Dockerfile, args or target changed, without building")
+	flags.BoolVar(&opts.exitCode, "exit-code", false, "With --check-changes, exit with status 1 when a service would be rebuilt")
 
 	return cmd
 }
@@ -174,5 +266,37 @@ func runBuild(ctx context.Context, dockerCli command.Cli, backendOptions *Backen
 	}
 	apiBuildOptions.Attestations = true
 
+	if opts.checkChanges {
+		return runBuildChanges(ctx, dockerCli, backend, project, apiBuildOptions, opts.exitCode)
+	}
+	if opts.exitCode {
+		return errors.New("--exit-code only applies with --check-changes")
+	}
 	return backend.Build(ctx, project, apiBuildOptions)
 }
+
+// runBuildChanges prints whether each service would be rebuilt, and why
+func runBuildChanges(ctx context.Context, dockerCli command.Cli, backend api.Compose, project *types.Project, options api.BuildOptions, exitCode bool) error {
+	changes, err := backend.BuildChanges(ctx, project, options)
+	if err != nil {
+		return err
+	}
+	rebuild := false
+	err = formatter.Print(changes, formatter.TABLE, dockerCli.Out(), func(w io.Writer) {
+		for _, change := range changes {
+			status := "up to date"
+			if change.Rebuild {
+				status = "would rebuild"
+				rebuild = true
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", change.Service, change.Image, status, change.Reason)
+		}
+	}, "SERVICE", "IMAGE", "STATUS", "REASON")
+	if err != nil {
+		return err
+	}
+	if rebuild && exitCode {
+		return dockercli.StatusError{StatusCode: 1}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright 2023 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestParseBuildSecret(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("NPM_TOKEN", "secret")
+	abs, err := filepath.Abs("npmrc")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		value string
+		want  api.BuildSecret
+		err   string
+	}{
+		{value: "id=npmrc,src=~/.npmrc", want: api.BuildSecret{ID: "npmrc", File: filepath.Join(home, ".npmrc")}},
+		{value: "id=npmrc,type=file,source=npmrc", want: api.BuildSecret{ID: "npmrc", File: abs}},
+		{value: "id=npmrc,type=file", want: api.BuildSecret{ID: "npmrc", File: abs}},
+		{value: "id=token,env=NPM_TOKEN", want: api.BuildSecret{ID: "token", Env: "NPM_TOKEN"}},
+		{value: "id=token,type=env,src=NPM_TOKEN", want: api.BuildSecret{ID: "token", Env: "NPM_TOKEN"}},
+		{value: "id=NPM_TOKEN", want: api.BuildSecret{ID: "NPM_TOKEN", Env: "NPM_TOKEN"}},
+		{value: "id=npmrc", want: api.BuildSecret{ID: "npmrc", File: abs}},
+		{value: "src=npmrc", err: `invalid secret "src=npmrc": id is required`},
+		{value: "id=npmrc,type=ssh", err: `invalid secret "id=npmrc,type=ssh": unsupported type "ssh"`},
+		{value: "id=npmrc,mode=0400", err: `invalid secret "id=npmrc,mode=0400": unexpected key "mode"`},
+		{value: "id=npmrc,src=npmrc,env=NPM_TOKEN", err: `invalid secret "id=npmrc,src=npmrc,env=NPM_TOKEN": src and env are mutually exclusive`},
+		{value: "npmrc", err: `invalid secret "npmrc": field "npmrc" must be a key=value pair`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			secret, err := parseBuildSecret(tt.value)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, secret, tt.want)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/compose"
+)
+
+type checkpointOptions struct {
+	*ProjectOptions
+	id  string
+	dir string
+}
+
+func checkpointCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := checkpointOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "checkpoint [OPTIONS]",
+		Short: "EXPERIMENTAL - Snapshot the project containers and volumes",
+		Long: `EXPERIMENTAL - Snapshot the project containers and volumes
+
+The project containers are paused while each service container is committed to
+an image tagged with the snapshot ID, and each volume of the project is archived
+along with a manifest in the snapshots directory. A scaled service is
+snapshotted from its lowest numbered container. The snapshot ID is printed on
+completion, for "alpha restore" to recreate the project from the snapshot.`,
+		RunE: p.WithProject(func(ctx context.Context, project *types.Project) error {
+			return runCheckpoint(ctx, dockerCli, backendOptions, opts, project)
+		}, dockerCli),
+		Args: cli.NoArgs,
+	}
+	cmd.Flags().StringVar(&opts.id, "id", "", "ID of the snapshot (default: the current time)")
+	cmd.Flags().StringVar(&opts.dir, "dir", "", "Directory to store snapshots in (default: compose/snapshots in the docker CLI configuration directory)")
+	return cmd
+}
+
+func runCheckpoint(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts checkpointOptions, project *types.Project) error {
+	var snapshot api.Snapshot
+	err := withBackend(dockerCli, backendOptions, func(backend api.Compose) error {
+		var err error
+		snapshot, err = backend.Checkpoint(ctx, project, api.CheckpointOptions{
+			ID:  opts.id,
+			Dir: opts.dir,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(dockerCli.Out(), snapshot.ID)
+	return err
+}
+
+type restoreOptions struct {
+	*ProjectOptions
+	dir string
+}
+
+func restoreCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := restoreOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "restore [OPTIONS] SNAPSHOT",
+		Short: "EXPERIMENTAL - Recreate the project from a snapshot",
+		Long: `EXPERIMENTAL - Recreate the project from a snapshot
+
+The volumes of the snapshot are created and populated from their archives, then
+the project is brought up with its services running the images their containers
+were committed to. The volumes must not exist: remove them with "down --volumes"
+before restoring a snapshot.`,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runRestore(ctx, dockerCli, backendOptions, opts, args[0])
+		}),
+		Args: cli.ExactArgs(1),
+	}
+	cmd.Flags().StringVar(&opts.dir, "dir", "", "Directory snapshots are stored in (default: compose/snapshots in the docker CLI configuration directory)")
+	return cmd
+}
+
+func runRestore(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts restoreOptions, id string) error {
+	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
+	if err != nil {
+		return err
+	}
+
+	project, _, err := opts.ToProject(ctx, dockerCli, backend, nil)
+	if err != nil {
+		return err
+	}
+
+	services := project.ServiceNames()
+	return backend.Restore(ctx, project, api.RestoreOptions{
+		ID:  id,
+		Dir: opts.dir,
+		Create: api.CreateOptions{
+			Services:             services,
+			Recreate:             api.RecreateDiverged,
+			RecreateDependencies: api.RecreateDiverged,
+		},
+		Start: api.StartOptions{
+			Project:  project,
+			Services: services,
+		},
+	})
+}
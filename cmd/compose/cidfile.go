@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cidFile appends the IDs of the containers created by a command to a file,
+// one service=container_id line per container. Each line is written and
+// synced as the container is created, so the file lists the containers
+// created so far even if compose doesn't complete.
+type cidFile struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+func openCIDFile(path string) (*cidFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cidfile: %w", err)
+	}
+	return &cidFile{file: f}, nil
+}
+
+// add records a created container. It is meant to be set as
+// api.CreateOptions.ContainerCreated, a failure is reported by Close.
+func (c *cidFile) add(service string, containerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	_, err := fmt.Fprintf(c.file, "%s=%s\n", service, containerID)
+	if err == nil {
+		err = c.file.Sync()
+	}
+	if err != nil {
+		logrus.Warnf("failed to write to cidfile: %v", err)
+		c.err = fmt.Errorf("failed to write to cidfile: %w", err)
+	}
+}
+
+// Close closes the file, returning the error met writing to it, if any
+func (c *cidFile) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return errors.Join(c.err, c.file.Close())
+}
+
+// withCIDFile opens the cidfile at path, when set, and returns the function
+// recording created containers to it along with the one closing it.
+func withCIDFile(path string) (func(string, string), func() error, error) {
+	if path == "" {
+		return nil, func() error { return nil }, nil
+	}
+	f, err := openCIDFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f.add, f.Close, nil
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cids")
+	assert.NilError(t, os.WriteFile(path, []byte("db=previous\n"), 0o644))
+	content := func() string {
+		b, err := os.ReadFile(path)
+		assert.NilError(t, err)
+		return string(b)
+	}
+
+	add, closeFile, err := withCIDFile(path)
+	assert.NilError(t, err)
+
+	// each container is written as soon as it is created, after the ones
+	// listed by earlier commands
+	add("db", "id1")
+	assert.Equal(t, content(), "db=previous\ndb=id1\n")
+	add("web", "id2")
+	assert.Equal(t, content(), "db=previous\ndb=id1\nweb=id2\n")
+
+	assert.NilError(t, closeFile())
+	assert.Equal(t, content(), "db=previous\ndb=id1\nweb=id2\n")
+}
+
+func TestCIDFileNotSet(t *testing.T) {
+	add, closeFile, err := withCIDFile("")
+	assert.NilError(t, err)
+	assert.Assert(t, add == nil)
+	assert.NilError(t, closeFile())
+}
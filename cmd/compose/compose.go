@@ -70,6 +70,10 @@ const (
 	ComposeMenu = "COMPOSE_MENU"
 	// ComposeProgress defines type of progress output, if --progress isn't used
 	ComposeProgress = "COMPOSE_PROGRESS"
+	// ComposeShortNames displays containers without the project prefix in the progress output, except for --progress json
+	ComposeShortNames = "COMPOSE_SHORT_NAMES"
+	// ComposeImageVerifier defines the command images are verified with, when --verify-images is set
+	ComposeImageVerifier = "COMPOSE_IMAGE_VERIFIER"
 )
 
 // rawEnv load a dot env file using docker/cli key=value parser, without attempt to interpolate or evaluate values
@@ -150,6 +154,9 @@ type ProjectOptions struct {
 	All                   bool
 	insecureRegistries    []string
 	remoteLoadersOverride []loader.ResourceLoader
+	// redactDecrypted hides the environment values decrypted while loading
+	// the project, for it to be rendered
+	redactDecrypted bool
 }
 
 // ProjectFunc does stuff within a types.Project
@@ -346,6 +353,7 @@ func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, b
 		Offline:           o.Offline,
 		All:               o.All,
 		Compatibility:     o.Compatibility,
+		RedactDecrypted:   o.redactDecrypted,
 		ProjectOptionsFns: po,
 		LoadListeners:     []api.LoadListener{metricsListener},
 		OCI:               o.ociOptions(),
@@ -356,6 +364,9 @@ func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, b
 		return nil, metrics, err
 	}
 
+	if utils.StringToBool(project.Environment[ComposeShortNames]) {
+		display.ShortNames(project.Name)
+	}
 	return project, metrics, nil
 }
 
@@ -586,6 +597,8 @@ func RootCommand(dockerCli command.Cli, backendOptions *BackendOptions) *cobra.C
 		eventsCommand(&opts, dockerCli, backendOptions),
 		portCommand(&opts, dockerCli, backendOptions),
 		imagesCommand(&opts, dockerCli, backendOptions),
+		inspectCommand(&opts, dockerCli, backendOptions),
+		diffCommand(&opts, dockerCli, backendOptions),
 		versionCommand(dockerCli),
 		buildCommand(&opts, dockerCli, backendOptions),
 		pushCommand(&opts, dockerCli, backendOptions),
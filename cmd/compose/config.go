@@ -62,6 +62,7 @@ type configOptions struct {
 	variables           bool
 	environment         bool
 	lockImageDigests    bool
+	noRedact            bool
 }
 
 func (o *configOptions) ToProject(ctx context.Context, dockerCli command.Cli, backend api.Compose, services []string) (*types.Project, error) {
@@ -159,6 +160,7 @@ func configCommand(p *ProjectOptions, dockerCli command.Cli) *cobra.Command {
 	flags.BoolVar(&opts.noResolvePath, "no-path-resolution", false, "Don't resolve file paths")
 	flags.BoolVar(&opts.noConsistency, "no-consistency", false, "Don't check model consistency - warning: may produce invalid Compose output")
 	flags.BoolVar(&opts.noResolveEnv, "no-env-resolution", false, "Don't resolve service env files")
+	flags.BoolVar(&opts.noRedact, "no-redact", false, "Render the environment values encrypted at rest as ENC[...] decrypted")
 
 	flags.BoolVar(&opts.services, "services", false, "Print the service names, one per line.")
 	flags.BoolVar(&opts.volumes, "volumes", false, "Print the volume names, one per line.")
@@ -209,6 +211,7 @@ func runConfigInterpolate(ctx context.Context, dockerCli command.Cli, opts confi
 		return nil, err
 	}
 
+	opts.redactDecrypted = !opts.noRedact
 	project, err := opts.ToProject(ctx, dockerCli, backend, services)
 	if err != nil {
 		return nil, err
@@ -229,7 +232,7 @@ func runConfigInterpolate(ctx context.Context, dockerCli command.Cli, opts confi
 	}
 
 	if !opts.noConsistency {
-		err := project.CheckContainerNameUnicity()
+		err := compose.CheckContainerNames(project)
 		if err != nil {
 			return nil, err
 		}
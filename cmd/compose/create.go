@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"fmt"
+	"os"
 	"slices"
 	"strconv"
 	"strings"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/cli/cli/command"
+	"github.com/mattn/go-shellwords"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -35,21 +37,40 @@ import (
 )
 
 type createOptions struct {
-	Build         bool
-	noBuild       bool
-	Pull          string
-	pullChanged   bool
-	removeOrphans bool
-	ignoreOrphans bool
-	forceRecreate bool
-	noRecreate    bool
-	recreateDeps  bool
-	noInherit     bool
-	timeChanged   bool
-	timeout       int
-	quietPull     bool
-	scale         []string
-	AssumeYes     bool
+	Build               bool
+	noBuild             bool
+	Pull                string
+	pullChanged         bool
+	removeOrphans       bool
+	ignoreOrphans       bool
+	forceRecreate       bool
+	noRecreate          bool
+	recreateDeps        bool
+	recreateDependents  bool
+	noInherit           bool
+	timeChanged         bool
+	timeout             int
+	quietPull           bool
+	pipelinePull        bool
+	scale               []string
+	AssumeYes           bool
+	maxAge              time.Duration
+	recreateRestarting  time.Duration
+	keepScaledDown      bool
+	lazyResources       bool
+	duplicateNumbers    string
+	resourcePreflight   string
+	verifyImages        string
+	recreateOrder       string
+	restartExhausted    string
+	scaleDownReferenced string
+	createHostPaths     bool
+	skipIPv6Check       bool
+	ignoreHealthcheck   bool
+	preservePaused      bool
+	skipPlacementCheck  bool
+	validateReferences  bool
+	cidFile             string
 }
 
 func createCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -68,7 +89,37 @@ func createCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Bac
 			if opts.forceRecreate && opts.noRecreate {
 				return fmt.Errorf("--force-recreate and --no-recreate are incompatible")
 			}
-			return nil
+			if opts.recreateDependents && opts.noRecreate {
+				return fmt.Errorf("--recreate-dependents and --no-recreate are incompatible")
+			}
+			if opts.maxAge != 0 && opts.noRecreate {
+				return fmt.Errorf("--max-age and --no-recreate are incompatible")
+			}
+			if opts.maxAge < 0 {
+				return fmt.Errorf("--max-age must be a positive duration")
+			}
+			if opts.recreateRestarting != 0 && opts.noRecreate {
+				return fmt.Errorf("--recreate-restarting and --no-recreate are incompatible")
+			}
+			if opts.recreateRestarting < 0 {
+				return fmt.Errorf("--recreate-restarting must be a positive duration")
+			}
+			if err := opts.validateDuplicateNumbers(); err != nil {
+				return err
+			}
+			if err := opts.validateResourcePreflight(); err != nil {
+				return err
+			}
+			if err := validateVerifyImages(opts.verifyImages); err != nil {
+				return err
+			}
+			if err := opts.validateRecreateOrder(); err != nil {
+				return err
+			}
+			if err := opts.validateRestartExhausted(); err != nil {
+				return err
+			}
+			return validateScaleDownReferenced(opts.scaleDownReferenced)
 		}),
 		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
 			return runCreate(ctx, dockerCli, backendOptions, opts, buildOpts, project, services)
@@ -80,11 +131,29 @@ func createCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Bac
 	flags.BoolVar(&opts.noBuild, "no-build", false, "Don't build an image, even if it's policy")
 	flags.StringVar(&opts.Pull, "pull", "policy", `Pull image before running ("always"|"missing"|"never"|"build")`)
 	flags.BoolVar(&opts.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.BoolVar(&opts.pipelinePull, "pipeline-pull", false, "Create the containers of services whose image is present while the images of the others are pulled")
 	flags.BoolVar(&opts.forceRecreate, "force-recreate", false, "Recreate containers even if their configuration and image haven't changed")
 	flags.BoolVar(&opts.noRecreate, "no-recreate", false, "If containers already exist, don't recreate them. Incompatible with --force-recreate.")
+	flags.BoolVar(&opts.recreateDependents, "recreate-dependents", false, "Recreate the services depending on a recreated service with restart: true, rather than restarting them")
+	flags.StringVar(&opts.recreateOrder, "recreate-order", api.RecreateOrderDependenciesFirst, recreateOrderUsage)
 	flags.BoolVar(&opts.removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
-	flags.StringArrayVar(&opts.scale, "scale", []string{}, "Scale SERVICE to NUM instances. Overrides the `scale` setting in the Compose file if present.")
+	flags.StringArrayVar(&opts.scale, "scale", []string{}, "Scale SERVICE to NUM instances, or by +NUM/-NUM instances relatively to the running ones. Overrides the `scale` setting in the Compose file if present.")
 	flags.BoolVarP(&opts.AssumeYes, "yes", "y", false, `Assume "yes" as answer to all prompts and run non-interactively`)
+	flags.DurationVar(&opts.maxAge, "max-age", 0, "Recreate containers created longer ago than this duration, even if their configuration and image haven't changed")
+	flags.BoolVar(&opts.keepScaledDown, "keep-scaled-down", false, "Stop the containers of services scaled down rather than removing them, and restart them when scaling back up")
+	flags.BoolVar(&opts.lazyResources, "lazy-resources", false, "Only create the missing networks and volumes once a container using them is created or started")
+	flags.DurationVar(&opts.recreateRestarting, "recreate-restarting", 0, "Recreate containers stuck restarting, restarted by the engine for longer than this duration")
+	flags.StringVar(&opts.restartExhausted, "restart-exhausted", api.RestartExhaustedRestart, restartExhaustedUsage)
+	flags.BoolVar(&opts.createHostPaths, "create-host-paths", false, "Create the missing sources of bind mounts as directories owned by the current user, rather than letting the engine create them owned by root")
+	flags.BoolVar(&opts.skipIPv6Check, "skip-ipv6-check", false, "Skip the check of the IPv6 configuration of networks and published ports against the engine capabilities")
+	flags.BoolVar(&opts.skipPlacementCheck, "skip-placement-check", false, "Create containers ignoring the deploy.placement.constraints the engine can't honor, rather than failing")
+	flags.BoolVar(&opts.validateReferences, "validate-references", false, "Check the secrets, configs and external volumes referenced by the services exist before creating any container")
+	flags.BoolVar(&opts.ignoreHealthcheck, "ignore-healthcheck-changes", false, "Don't recreate containers when only the healthcheck of their service changed")
+	flags.BoolVar(&opts.preservePaused, "preserve-paused", true, "Pause the replacements of recreated paused containers once started")
+	flags.StringVar(&opts.duplicateNumbers, "duplicate-numbers", api.DuplicateNumbersKeepNewest, "How to handle service containers sharing a number. Values: [keep-newest | error]")
+	flags.StringVar(&opts.scaleDownReferenced, "scale-down-referenced", api.ScaleDownReferencedWarn, scaleDownReferencedUsage)
+	flags.StringVar(&opts.resourcePreflight, "resource-preflight", "", "Check the host CPUs and memory can accommodate the resources reserved by the project before scaling up. Values: [warn | error]")
+	flags.StringVar(&opts.verifyImages, "verify-images", api.VerifyImagesOff, verifyImagesUsage)
 	flags.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
 		// assumeYes was introduced by mistake as `--y`
 		if name == "y" {
@@ -100,6 +169,10 @@ func runCreate(ctx context.Context, dockerCli command.Cli, backendOptions *Backe
 	if err := createOpts.Apply(project); err != nil {
 		return err
 	}
+	delta, err := scaleDelta(project, createOpts.scale)
+	if err != nil {
+		return err
+	}
 
 	var build *api.BuildOptions
 	if !createOpts.noBuild {
@@ -113,21 +186,43 @@ func runCreate(ctx context.Context, dockerCli command.Cli, backendOptions *Backe
 	if createOpts.AssumeYes {
 		backendOptions.Options = append(backendOptions.Options, compose.WithPrompt(compose.AlwaysOkPrompt()))
 	}
+	if err := withImageVerifier(backendOptions, createOpts.verifyImages); err != nil {
+		return err
+	}
 
 	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
 	if err != nil {
 		return err
 	}
 	return backend.Create(ctx, project, api.CreateOptions{
-		Build:                build,
-		Services:             services,
-		RemoveOrphans:        createOpts.removeOrphans,
-		IgnoreOrphans:        createOpts.ignoreOrphans,
-		Recreate:             createOpts.recreateStrategy(),
-		RecreateDependencies: createOpts.dependenciesRecreateStrategy(),
-		Inherit:              !createOpts.noInherit,
-		Timeout:              createOpts.GetTimeout(),
-		QuietPull:            createOpts.quietPull,
+		Build:                    build,
+		Services:                 services,
+		RemoveOrphans:            createOpts.removeOrphans,
+		IgnoreOrphans:            createOpts.ignoreOrphans,
+		Recreate:                 createOpts.recreateStrategy(),
+		RecreateDependencies:     createOpts.dependenciesRecreateStrategy(),
+		RecreateDependents:       createOpts.recreateDependents,
+		RecreateOrder:            createOpts.recreateOrder,
+		RestartExhausted:         createOpts.restartExhausted,
+		Inherit:                  !createOpts.noInherit,
+		Timeout:                  createOpts.GetTimeout(),
+		QuietPull:                createOpts.quietPull,
+		PipelinePull:             createOpts.pipelinePull,
+		MaxAge:                   createOpts.maxAge,
+		RecreateRestarting:       createOpts.recreateRestarting,
+		KeepScaledDown:           createOpts.keepScaledDown,
+		LazyResources:            createOpts.lazyResources,
+		ScaleDelta:               delta,
+		DuplicateNumbers:         createOpts.duplicateNumbers,
+		ResourcePreflight:        createOpts.resourcePreflight,
+		VerifyImages:             createOpts.verifyImages,
+		ScaleDownReferenced:      createOpts.scaleDownReferenced,
+		CreateHostPaths:          createOpts.createHostPaths,
+		SkipIPv6Check:            createOpts.skipIPv6Check,
+		SkipPlacementCheck:       createOpts.skipPlacementCheck,
+		ValidateReferences:       createOpts.validateReferences,
+		IgnoreHealthcheckChanges: createOpts.ignoreHealthcheck,
+		PreservePaused:           createOpts.preservePaused,
 	})
 }
 
@@ -154,6 +249,100 @@ func (opts createOptions) dependenciesRecreateStrategy() string {
 	return api.RecreateDiverged
 }
 
+func (opts createOptions) validateDuplicateNumbers() error {
+	switch opts.duplicateNumbers {
+	case api.DuplicateNumbersKeepNewest, api.DuplicateNumbersError:
+		return nil
+	default:
+		return fmt.Errorf("invalid --duplicate-numbers option %q. Should be %s or %s", opts.duplicateNumbers, api.DuplicateNumbersKeepNewest, api.DuplicateNumbersError)
+	}
+}
+
+func (opts createOptions) validateResourcePreflight() error {
+	switch opts.resourcePreflight {
+	case "", api.ResourcePreflightWarn, api.ResourcePreflightError:
+		return nil
+	default:
+		return fmt.Errorf("invalid --resource-preflight option %q. Should be %s or %s", opts.resourcePreflight, api.ResourcePreflightWarn, api.ResourcePreflightError)
+	}
+}
+
+// verifyImagesUsage is the usage of the --verify-images flag
+const verifyImagesUsage = "Verify the images with the command set as " + ComposeImageVerifier + " before creating containers. Values: [off | warn | enforce]"
+
+func validateVerifyImages(policy string) error {
+	switch policy {
+	case api.VerifyImagesOff, api.VerifyImagesWarn, api.VerifyImagesEnforce:
+		return nil
+	default:
+		return fmt.Errorf("invalid --verify-images option %q. Should be %s, %s or %s", policy, api.VerifyImagesOff, api.VerifyImagesWarn, api.VerifyImagesEnforce)
+	}
+}
+
+// withImageVerifier sets the command COMPOSE_IMAGE_VERIFIER holds as the
+// image verifier of the backend, when policy requires images to be verified
+func withImageVerifier(backendOptions *BackendOptions, policy string) error {
+	if policy == api.VerifyImagesOff {
+		return nil
+	}
+	command, err := shellwords.Parse(os.Getenv(ComposeImageVerifier))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", ComposeImageVerifier, err)
+	}
+	if len(command) == 0 {
+		return fmt.Errorf("--verify-images %s requires %s to be set to the command verifying an image", policy, ComposeImageVerifier)
+	}
+	backendOptions.Add(compose.WithImageVerifier(compose.NewCommandImageVerifier(command)))
+	return nil
+}
+
+// recreateOrderUsage is the usage of the --recreate-order flag
+const recreateOrderUsage = "Order to recreate the containers of services in, relatively to their dependencies. Values: [dependencies-first | dependents-first]"
+
+func (opts createOptions) validateRecreateOrder() error {
+	switch opts.recreateOrder {
+	case api.RecreateOrderDependenciesFirst:
+		return nil
+	case api.RecreateOrderDependentsFirst:
+		if opts.recreateDependents {
+			return fmt.Errorf("--recreate-order %s and --recreate-dependents are incompatible", api.RecreateOrderDependentsFirst)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --recreate-order option %q. Should be %s or %s", opts.recreateOrder, api.RecreateOrderDependenciesFirst, api.RecreateOrderDependentsFirst)
+	}
+}
+
+// restartExhaustedUsage is the usage of the --restart-exhausted flag
+const restartExhaustedUsage = "What to do with the containers left exited once the retries of their on-failure restart policy are exhausted. Values: [restart | recreate | ignore]"
+
+func (opts createOptions) validateRestartExhausted() error {
+	switch opts.restartExhausted {
+	case api.RestartExhaustedRestart, api.RestartExhaustedIgnore:
+		return nil
+	case api.RestartExhaustedRecreate:
+		if opts.noRecreate {
+			return fmt.Errorf("--restart-exhausted %s and --no-recreate are incompatible", api.RestartExhaustedRecreate)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --restart-exhausted option %q. Should be %s, %s or %s", opts.restartExhausted, api.RestartExhaustedRestart, api.RestartExhaustedRecreate, api.RestartExhaustedIgnore)
+	}
+}
+
+// scaleDownReferencedUsage is the usage of the --scale-down-referenced flag
+const scaleDownReferencedUsage = "How to scale down a container other containers share namespaces or volumes with. Values: [warn | reselect | error | recreate]"
+
+func validateScaleDownReferenced(value string) error {
+	switch value {
+	case api.ScaleDownReferencedWarn, api.ScaleDownReferencedReselect, api.ScaleDownReferencedError, api.ScaleDownReferencedRecreate:
+		return nil
+	default:
+		return fmt.Errorf("invalid --scale-down-referenced option %q. Should be one of %s, %s, %s or %s", value,
+			api.ScaleDownReferencedWarn, api.ScaleDownReferencedReselect, api.ScaleDownReferencedError, api.ScaleDownReferencedRecreate)
+	}
+}
+
 func (opts createOptions) GetTimeout() *time.Duration {
 	if opts.timeChanged {
 		t := time.Duration(opts.timeout) * time.Second
@@ -198,14 +387,13 @@ func (opts createOptions) Apply(project *types.Project) error {
 
 func applyScaleOpts(project *types.Project, opts []string) error {
 	for _, scale := range opts {
-		name, val, ok := strings.Cut(scale, "=")
-		if !ok || val == "" {
-			return fmt.Errorf("invalid --scale option %q. Should be SERVICE=NUM", scale)
-		}
-		replicas, err := strconv.Atoi(val)
+		name, replicas, relative, err := parseScaleOpt(scale)
 		if err != nil {
 			return err
 		}
+		if relative {
+			continue
+		}
 		err = setServiceScale(project, name, replicas)
 		if err != nil {
 			return err
@@ -214,6 +402,41 @@ func applyScaleOpts(project *types.Project, opts []string) error {
 	return nil
 }
 
+// scaleDelta collects the relative --scale options, as SERVICE=+NUM or
+// SERVICE=-NUM, which the backend resolves against the current replicas.
+func scaleDelta(project *types.Project, opts []string) (map[string]int, error) {
+	var deltas map[string]int
+	for _, scale := range opts {
+		name, delta, relative, err := parseScaleOpt(scale)
+		if err != nil {
+			return nil, err
+		}
+		if !relative {
+			continue
+		}
+		if _, err := project.GetService(name); err != nil {
+			return nil, err
+		}
+		if deltas == nil {
+			deltas = map[string]int{}
+		}
+		deltas[name] = delta
+	}
+	return deltas, nil
+}
+
+func parseScaleOpt(scale string) (string, int, bool, error) {
+	name, val, ok := strings.Cut(scale, "=")
+	if !ok || val == "" {
+		return "", 0, false, fmt.Errorf("invalid --scale option %q. Should be SERVICE=NUM, SERVICE=+NUM or SERVICE=-NUM", scale)
+	}
+	replicas, err := strconv.Atoi(val)
+	if err != nil {
+		return "", 0, false, err
+	}
+	return name, replicas, val[0] == '+' || val[0] == '-', nil
+}
+
 func (opts createOptions) isPullPolicyValid() bool {
 	pullPolicies := []string{
 		types.PullPolicyAlways, types.PullPolicyNever, types.PullPolicyBuild,
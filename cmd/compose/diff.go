@@ -0,0 +1,81 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v5/cmd/formatter"
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/compose"
+)
+
+type diffOptions struct {
+	*ProjectOptions
+	format string
+}
+
+func diffCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := diffOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "diff [OPTIONS] [SERVICE...]",
+		Short: "Show what up would change in the running project, without applying it",
+		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
+			return runDiff(ctx, dockerCli, backendOptions, opts, project)
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	cmd.Flags().StringVar(&opts.format, "format", "table", "Format the output. Values: [table | json]")
+	return cmd
+}
+
+func runDiff(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts diffOptions, project *types.Project) error {
+	var drifts []api.Drift
+	// diff only plans, so can run with inspect and list permissions: make
+	// sure it never attempts anything else
+	planOnly := &BackendOptions{Options: append(slices.Clone(backendOptions.Options), compose.WithPlanOnly)}
+	err := withBackend(dockerCli, planOnly, func(backend api.Compose) error {
+		var err error
+		drifts, err = backend.Diff(ctx, project)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(drifts) == 0 && opts.format != formatter.JSON {
+		_, _ = fmt.Fprintln(stdinfo(dockerCli), "No difference, the project is up to date")
+		return nil
+	}
+	return formatter.Print(drifts, opts.format, dockerCli.Out(), func(w io.Writer) {
+		for _, drift := range drifts {
+			service := drift.Service
+			if service == "" {
+				service = "-"
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", service, drift.Kind, drift.Detail)
+		}
+	}, "SERVICE", "KIND", "DETAIL")
+}
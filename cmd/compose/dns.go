@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v5/cmd/formatter"
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+type dnsOptions struct {
+	*ProjectOptions
+	probe  string
+	format string
+}
+
+func dnsCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := dnsOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "dns [OPTIONS] [SERVICE...]",
+		Short: "EXPERIMENTAL - Compare the network aliases of service containers with the ones reported by the engine",
+		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
+			return runDNS(ctx, dockerCli, backendOptions, opts, project, services)
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	cmd.Flags().StringVar(&opts.probe, "probe", "", "Resolve the expected aliases with getent from a container of this service")
+	cmd.Flags().StringVar(&opts.format, "format", "table", "Format the output. Values: [table | json]")
+	return cmd
+}
+
+func runDNS(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts dnsOptions, project *types.Project, services []string) error {
+	if opts.probe != "" {
+		if _, err := project.GetService(opts.probe); err != nil {
+			return err
+		}
+	}
+	var reports []api.NetworkDNS
+	err := withBackend(dockerCli, backendOptions, func(backend api.Compose) error {
+		var err error
+		reports, err = backend.DNS(ctx, project, api.DNSOptions{
+			Services: services,
+			Probe:    opts.probe,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return formatter.Print(reports, opts.format, dockerCli.Out(), func(w io.Writer) {
+		for _, report := range reports {
+			for _, ctr := range report.Containers {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", report.Network, ctr.Container,
+					strings.Join(ctr.Expected, ","), strings.Join(ctr.Actual, ","), dnsStatus(ctr))
+			}
+		}
+	}, "NETWORK", "CONTAINER", "EXPECTED", "ACTUAL", "STATUS")
+}
+
+// dnsStatus flags the mismatches of a container, or returns "ok".
+func dnsStatus(ctr api.ContainerDNS) string {
+	if !ctr.Mismatch() {
+		return "ok"
+	}
+	var problems []string
+	if !ctr.Attached {
+		problems = append(problems, "not attached")
+	}
+	if len(ctr.Missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(ctr.Missing, ","))
+	}
+	var unresolved []string
+	for _, probe := range ctr.Probes {
+		if !probe.Resolved {
+			unresolved = append(unresolved, probe.Alias)
+		}
+	}
+	if len(unresolved) > 0 {
+		problems = append(problems, "unresolved "+strings.Join(unresolved, ","))
+	}
+	return strings.Join(problems, "; ")
+}
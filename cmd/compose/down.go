@@ -37,7 +37,8 @@ type downOptions struct {
 	removeOrphans bool
 	timeChanged   bool
 	timeout       int
-	volumes       bool
+	volumes       string
+	forceVolumes  bool
 	images        string
 }
 
@@ -55,6 +56,14 @@ func downCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 					return fmt.Errorf("invalid value for --rmi: %q", opts.images)
 				}
 			}
+			switch opts.volumes {
+			case "", "false", "true", api.VolumesScopeNamed, api.VolumesScopeAnonymous, api.VolumesScopeAll:
+			default:
+				return fmt.Errorf("invalid value for --volumes: %q", opts.volumes)
+			}
+			if opts.forceVolumes && !opts.removesVolumes() {
+				return fmt.Errorf("--force-volumes requires --volumes")
+			}
 			return nil
 		}),
 		RunE: Adapt(func(ctx context.Context, args []string) error {
@@ -66,7 +75,9 @@ func downCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	removeOrphans := utils.StringToBool(os.Getenv(ComposeRemoveOrphans))
 	flags.BoolVar(&opts.removeOrphans, "remove-orphans", removeOrphans, "Remove containers for services not defined in the Compose file")
 	flags.IntVarP(&opts.timeout, "timeout", "t", 0, "Specify a shutdown timeout in seconds")
-	flags.BoolVarP(&opts.volumes, "volumes", "v", false, `Remove named volumes declared in the "volumes" section of the Compose file and anonymous volumes attached to containers`)
+	flags.StringVarP(&opts.volumes, "volumes", "v", "", `Remove named volumes declared in the "volumes" section of the Compose file and anonymous volumes attached to containers, or only the "named" or "anonymous" ones ("named"|"anonymous"|"all")`)
+	flags.Lookup("volumes").NoOptDefVal = api.VolumesScopeAll
+	flags.BoolVar(&opts.forceVolumes, "force-volumes", false, "Remove the volumes protected with "+api.VolumeProtectLabel+"=true or volumes protect too")
 	flags.StringVar(&opts.images, "rmi", "", `Remove images used by services. "local" remove only images that don't have a custom tag ("local"|"all")`)
 	flags.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
 		if name == "volume" {
@@ -98,7 +109,26 @@ func runDown(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 		Project:       project,
 		Timeout:       timeout,
 		Images:        opts.images,
-		Volumes:       opts.volumes,
+		Volumes:       opts.removesVolumes(),
+		VolumesScope:  opts.volumesScope(),
+		ForceVolumes:  opts.forceVolumes,
 		Services:      services,
 	})
 }
+
+// removesVolumes tells whether --volumes is set, "false" being accepted for
+// compatibility with the former boolean flag
+func (opts downOptions) removesVolumes() bool {
+	return opts.volumes != "" && opts.volumes != "false"
+}
+
+func (opts downOptions) volumesScope() string {
+	switch {
+	case !opts.removesVolumes():
+		return ""
+	case opts.volumes == "true":
+		return api.VolumesScopeAll
+	default:
+		return opts.volumes
+	}
+}
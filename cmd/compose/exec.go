@@ -41,12 +41,13 @@ type execOpts struct {
 	environment []string
 	workingDir  string
 
-	noTty       bool
-	user        string
-	detach      bool
-	index       int
-	privileged  bool
-	interactive bool
+	noTty        bool
+	user         string
+	detach       bool
+	index        int
+	privileged   bool
+	interactive  bool
+	noServiceEnv bool
 }
 
 func execCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -85,6 +86,7 @@ func execCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	runCmd.Flags().StringVarP(&opts.user, "user", "u", "", "Run the command as this user")
 	runCmd.Flags().BoolVarP(&opts.noTty, "no-tty", "T", !dockerCli.Out().IsTerminal(), "Disable pseudo-TTY allocation. By default 'docker compose exec' allocates a TTY.")
 	runCmd.Flags().StringVarP(&opts.workingDir, "workdir", "w", "", "Path to workdir directory for this command")
+	runCmd.Flags().BoolVar(&opts.noServiceEnv, "no-service-env", false, "Run the command with the environment of the image, without the variables set by the service")
 
 	runCmd.Flags().BoolVarP(&opts.interactive, "interactive", "i", true, "Keep STDIN open even if not attached")
 	runCmd.Flags().MarkHidden("interactive") //nolint:errcheck
@@ -115,16 +117,17 @@ func runExec(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 		return v, ok
 	}
 	execOpts := api.RunOptions{
-		Service:     opts.service,
-		Command:     opts.command,
-		Environment: compose.ToMobyEnv(types.NewMappingWithEquals(opts.environment).Resolve(lookupFn)),
-		Tty:         !opts.noTty,
-		User:        opts.user,
-		Privileged:  opts.privileged,
-		Index:       opts.index,
-		Detach:      opts.detach,
-		WorkingDir:  opts.workingDir,
-		Interactive: opts.interactive,
+		Service:      opts.service,
+		Command:      opts.command,
+		Environment:  compose.ToMobyEnv(types.NewMappingWithEquals(opts.environment).Resolve(lookupFn)),
+		Tty:          !opts.noTty,
+		User:         opts.user,
+		Privileged:   opts.privileged,
+		Index:        opts.index,
+		Detach:       opts.detach,
+		WorkingDir:   opts.workingDir,
+		Interactive:  opts.interactive,
+		NoServiceEnv: opts.noServiceEnv,
 	}
 
 	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
@@ -0,0 +1,76 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+type exportSpecsOptions struct {
+	*ProjectOptions
+	format string
+}
+
+func exportSpecsCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := exportSpecsOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "export-specs [OPTIONS] [SERVICE...]",
+		Short: "EXPERIMENTAL - Print the configurations submitted to the engine to create the service containers",
+		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
+			return runExportSpecs(ctx, dockerCli, backendOptions, opts, project, services)
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	cmd.Flags().StringVar(&opts.format, "format", "json", "Format the output. Values: [json]")
+	return cmd
+}
+
+func runExportSpecs(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts exportSpecsOptions, project *types.Project, services []string) error {
+	if opts.format != "json" {
+		return fmt.Errorf("unsupported format %q", opts.format)
+	}
+	var specs []api.ContainerSpec
+	err := withBackend(dockerCli, backendOptions, func(backend api.Compose) error {
+		var err error
+		specs, err = backend.ExportSpecs(ctx, project, api.ExportSpecsOptions{
+			Services: services,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if specs == nil {
+		specs = []api.ContainerSpec{}
+	}
+	content, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(dockerCli.Out(), string(content))
+	return err
+}
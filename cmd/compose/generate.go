@@ -19,7 +19,10 @@ package compose
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/docker/cli/cli/command"
 	"github.com/spf13/cobra"
@@ -30,7 +33,8 @@ import (
 
 type generateOptions struct {
 	*ProjectOptions
-	Format string
+	Format     string
+	Containers []string
 }
 
 func generateCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -45,13 +49,14 @@ func generateCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *B
 			return nil
 		}),
 		RunE: Adapt(func(ctx context.Context, args []string) error {
-			return runGenerate(ctx, dockerCli, backendOptions, opts, args)
+			return runGenerate(ctx, dockerCli, backendOptions, opts, append(opts.Containers, args...))
 		}),
 	}
 
 	cmd.Flags().StringVar(&opts.ProjectName, "name", "", "Project name to set in the Compose file")
 	cmd.Flags().StringVar(&opts.ProjectDir, "project-dir", "", "Directory to use for the project")
 	cmd.Flags().StringVar(&opts.Format, "format", "yaml", "Format the output. Values: [yaml | json]")
+	cmd.Flags().StringSliceVar(&opts.Containers, "containers", nil, "Comma-separated list of containers to generate services from")
 	return cmd
 }
 
@@ -73,6 +78,16 @@ func runGenerate(ctx context.Context, dockerCli command.Cli, backendOptions *Bac
 		return err
 	}
 
+	var notes map[string][]string
+	if opts.Format == "yaml" {
+		// YAML output reports unsupported settings as comments rather than
+		// as a project extension
+		if _, err := project.Extensions.Get(compose.GenerateNotesExtension, &notes); err != nil {
+			return err
+		}
+		delete(project.Extensions, compose.GenerateNotesExtension)
+	}
+
 	var content []byte
 	switch opts.Format {
 	case "json":
@@ -85,7 +100,20 @@ func runGenerate(ctx context.Context, dockerCli command.Cli, backendOptions *Bac
 	if err != nil {
 		return err
 	}
+	fmt.Print(generateNotesComments(notes))
 	fmt.Println(string(content))
 
 	return nil
 }
+
+// generateNotesComments renders the settings Generate couldn't translate as
+// YAML comments, sorted by service.
+func generateNotesComments(notes map[string][]string) string {
+	var b strings.Builder
+	for _, service := range slices.Sorted(maps.Keys(notes)) {
+		for _, note := range notes[service] {
+			_, _ = fmt.Fprintf(&b, "# service %s: %s is not supported by Compose and was ignored\n", service, note)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,93 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/compose"
+)
+
+type inspectOptions struct {
+	*ProjectOptions
+	index  int
+	format string
+}
+
+func inspectCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	opts := inspectOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "inspect [OPTIONS] SERVICE",
+		Short: "Display a service's configuration merged with its container runtime state",
+		Args:  cobra.ExactArgs(1),
+		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
+			return runInspect(ctx, dockerCli, backendOptions, opts, project, services[0])
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	cmd.Flags().IntVar(&opts.index, "index", 0, "Index of the container if service has multiple replicas")
+	cmd.Flags().StringVar(&opts.format, "format", "yaml", "Format the output. Values: [yaml | json]")
+	return cmd
+}
+
+func runInspect(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts inspectOptions, project *types.Project, service string) error {
+	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
+	if err != nil {
+		return err
+	}
+	result, err := backend.Inspect(ctx, project, api.InspectOptions{
+		Service: service,
+		Index:   opts.index,
+	})
+	if err != nil {
+		return err
+	}
+
+	content, err := formatInspect(result, opts.format)
+	if err != nil {
+		return err
+	}
+	_, err = dockerCli.Out().Write(content)
+	return err
+}
+
+func formatInspect(result api.ServiceInspect, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		content, err := json.MarshalIndent(result, "", "  ")
+		return append(content, '\n'), err
+	case "yaml":
+		buf := bytes.NewBuffer([]byte{})
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		err := encoder.Encode(result)
+		return buf.Bytes(), err
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
@@ -20,11 +20,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/docker/cli/cli/command"
 	"github.com/spf13/cobra"
 
+	"github.com/docker/compose/v5/cmd/formatter"
+	"github.com/docker/compose/v5/cmd/prompt"
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/utils"
 )
@@ -33,6 +39,7 @@ type killOptions struct {
 	*ProjectOptions
 	removeOrphans bool
 	signal        string
+	assumeYes     bool
 }
 
 func killCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -51,28 +58,166 @@ func killCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	flags := cmd.Flags()
 	removeOrphans := utils.StringToBool(os.Getenv(ComposeRemoveOrphans))
 	flags.BoolVar(&opts.removeOrphans, "remove-orphans", removeOrphans, "Remove containers for services not defined in the Compose file")
-	flags.StringVarP(&opts.signal, "signal", "s", "SIGKILL", "SIGNAL to send to the container")
+	flags.StringVarP(&opts.signal, "signal", "s", "SIGKILL", "SIGNAL to send to the container, or per service as SERVICE=SIGNAL[,SERVICE=SIGNAL...]")
+	flags.BoolVarP(&opts.assumeYes, "yes", "y", false, `Assume "yes" as answer to all prompts and run non-interactively`)
 
 	return cmd
 }
 
 func runKill(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts killOptions, services []string) error {
+	signal, signals, err := parseKillSignals(opts.signal)
+	if err != nil {
+		return err
+	}
+	services, err = killTargets(services, signals)
+	if err != nil {
+		return err
+	}
+
 	project, name, err := opts.projectOrName(ctx, dockerCli, services...)
 	if err != nil {
 		return err
 	}
 
-	return withBackend(dockerCli, backendOptions, func(backend api.Compose) error {
-		err := backend.Kill(ctx, name, api.KillOptions{
+	targets := services
+	if len(targets) == 0 && project != nil {
+		targets = project.ServiceNames()
+	}
+	if err := confirmKill(dockerCli, targets, signal, signals, opts.assumeYes); err != nil {
+		return err
+	}
+
+	var results []api.KillResult
+	err = withBackend(dockerCli, backendOptions, func(backend api.Compose) error {
+		return backend.Kill(ctx, name, api.KillOptions{
 			RemoveOrphans: opts.removeOrphans,
 			Project:       project,
 			Services:      services,
-			Signal:        opts.signal,
+			Signal:        signal,
+			Signals:       signals,
+			Report: func(result api.KillResult) {
+				results = append(results, result)
+			},
 		})
-		if errors.Is(err, api.ErrNoResources) {
-			_, _ = fmt.Fprintln(stdinfo(dockerCli), "No container to kill")
-			return nil
+	})
+	if len(results) > 0 {
+		if err := printKillResults(dockerCli.Out(), results); err != nil {
+			return err
 		}
+	}
+	if errors.Is(err, api.ErrNoResources) {
+		_, _ = fmt.Fprintln(stdinfo(dockerCli), "No container to kill")
+		return nil
+	}
+	return err
+}
+
+// parseKillSignals parses the --signal flag, which is either a single signal
+// or a comma separated list of SERVICE=SIGNAL pairs, optionally mixed with a
+// single default signal for the other services.
+func parseKillSignals(value string) (string, map[string]string, error) {
+	signal := ""
+	signals := map[string]string{}
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		service, sig, ok := strings.Cut(entry, "=")
+		if !ok {
+			if entry == "" {
+				return "", nil, fmt.Errorf("invalid signal %q: empty entry", value)
+			}
+			if signal != "" {
+				return "", nil, fmt.Errorf("invalid signal %q: more than one default signal", value)
+			}
+			signal = entry
+			continue
+		}
+		service, sig = strings.TrimSpace(service), strings.TrimSpace(sig)
+		if service == "" || sig == "" {
+			return "", nil, fmt.Errorf("invalid signal %q: expected SERVICE=SIGNAL", entry)
+		}
+		if _, exists := signals[service]; exists {
+			return "", nil, fmt.Errorf("invalid signal %q: service %q is set more than once", value, service)
+		}
+		signals[service] = sig
+	}
+	if signal == "" {
+		signal = "SIGKILL"
+	}
+	if len(signals) == 0 {
+		signals = nil
+	}
+	return signal, signals, nil
+}
+
+// killTargets returns the services to kill: the ones passed as arguments, or
+// those a per-service signal was set for.
+func killTargets(services []string, signals map[string]string) ([]string, error) {
+	if len(services) == 0 {
+		return slices.Sorted(maps.Keys(signals)), nil
+	}
+	for service := range signals {
+		if !slices.Contains(services, service) {
+			return nil, fmt.Errorf("signal set for service %q which is not selected", service)
+		}
+	}
+	return services, nil
+}
+
+// isKillSignal tells if signal is SIGKILL, in any of the forms the Engine accepts
+func isKillSignal(signal string) bool {
+	signal = strings.TrimPrefix(strings.ToUpper(signal), "SIG")
+	return signal == "KILL" || signal == "9"
+}
+
+// killConfirmation tells if sending the signals requires confirmation, as
+// SIGKILL would be sent to more than one service, and describes those. An empty
+// list of targets stands for all services of a project we could not load, so
+// is handled as more than one.
+func killConfirmation(targets []string, signal string, signals map[string]string) (string, bool) {
+	if len(targets) == 0 {
+		return "all services", isKillSignal(signal)
+	}
+	var killed []string
+	for _, service := range targets {
+		sig, ok := signals[service]
+		if !ok {
+			sig = signal
+		}
+		if isKillSignal(sig) {
+			killed = append(killed, service)
+		}
+	}
+	return "services " + strings.Join(killed, ", "), len(killed) > 1
+}
+
+// confirmKill asks for confirmation before SIGKILL is sent to more than one
+// service, and refuses to proceed when it can't ask.
+func confirmKill(dockerCli command.Cli, targets []string, signal string, signals map[string]string, assumeYes bool) error {
+	services, required := killConfirmation(targets, signal, signals)
+	if !required || assumeYes {
+		return nil
+	}
+	if !dockerCli.In().IsTerminal() {
+		return fmt.Errorf("refusing to send SIGKILL to %s without confirmation, use --yes to proceed", services)
+	}
+	msg := fmt.Sprintf("SIGKILL will be sent to all containers of %s. Do you want to continue? [y/N]: ", services)
+	confirmed, err := prompt.NewPrompt(dockerCli.In(), dockerCli.Out()).Confirm(msg, false)
+	if err != nil {
 		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("operation cancelled by user")
+	}
+	return nil
+}
+
+func printKillResults(out io.Writer, results []api.KillResult) error {
+	slices.SortFunc(results, func(a, b api.KillResult) int {
+		return strings.Compare(a.Container, b.Container)
 	})
+	return formatter.Print(results, formatter.TABLE, out, func(w io.Writer) {
+		for _, result := range results {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Container, result.Service, result.Signal, result.Status)
+		}
+	}, "CONTAINER", "SERVICE", "SIGNAL", "STATUS")
 }
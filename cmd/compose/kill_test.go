@@ -0,0 +1,164 @@
+/*
+   Copyright 2023 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/docker/cli/cli/streams"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/mocks"
+)
+
+func TestParseKillSignals(t *testing.T) {
+	tests := []struct {
+		value   string
+		signal  string
+		signals map[string]string
+		err     string
+	}{
+		{value: "SIGKILL", signal: "SIGKILL"},
+		{value: "HUP", signal: "HUP"},
+		{value: "web=HUP,worker=TERM", signal: "SIGKILL", signals: map[string]string{"web": "HUP", "worker": "TERM"}},
+		{value: "TERM, web=HUP", signal: "TERM", signals: map[string]string{"web": "HUP"}},
+		{value: "web=HUP,web=TERM", err: `invalid signal "web=HUP,web=TERM": service "web" is set more than once`},
+		{value: "TERM,HUP", err: `invalid signal "TERM,HUP": more than one default signal`},
+		{value: "web=", err: `invalid signal "web=": expected SERVICE=SIGNAL`},
+		{value: "=HUP", err: `invalid signal "=HUP": expected SERVICE=SIGNAL`},
+		{value: "web=HUP,", err: `invalid signal "web=HUP,": empty entry`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			signal, signals, err := parseKillSignals(tt.value)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, signal, tt.signal)
+			assert.DeepEqual(t, signals, tt.signals)
+		})
+	}
+}
+
+func TestKillTargets(t *testing.T) {
+	targets, err := killTargets(nil, map[string]string{"worker": "TERM", "web": "HUP"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targets, []string{"web", "worker"})
+
+	targets, err = killTargets([]string{"web", "db"}, map[string]string{"web": "HUP"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, targets, []string{"web", "db"})
+
+	_, err = killTargets([]string{"db"}, map[string]string{"web": "HUP"})
+	assert.Error(t, err, `signal set for service "web" which is not selected`)
+}
+
+func TestKillConfirmation(t *testing.T) {
+	tests := []struct {
+		name     string
+		targets  []string
+		signal   string
+		signals  map[string]string
+		services string
+		required bool
+	}{
+		{
+			name:    "single service",
+			targets: []string{"web"},
+			signal:  "SIGKILL",
+		},
+		{
+			name:    "other signal",
+			targets: []string{"web", "worker"},
+			signal:  "SIGTERM",
+		},
+		{
+			name:    "only one service killed",
+			targets: []string{"web", "worker"},
+			signal:  "SIGTERM",
+			signals: map[string]string{"web": "9"},
+		},
+		{
+			name:     "default signal",
+			targets:  []string{"web", "worker"},
+			signal:   "SIGKILL",
+			services: "services web, worker",
+			required: true,
+		},
+		{
+			name:     "per service signals",
+			targets:  []string{"db", "web", "worker"},
+			signal:   "SIGTERM",
+			signals:  map[string]string{"web": "KILL", "worker": "kill"},
+			services: "services web, worker",
+			required: true,
+		},
+		{
+			name:     "unknown services",
+			signal:   "SIGKILL",
+			services: "all services",
+			required: true,
+		},
+		{
+			name:   "unknown services with other signal",
+			signal: "HUP",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			services, required := killConfirmation(tt.targets, tt.signal, tt.signals)
+			assert.Equal(t, required, tt.required)
+			if required {
+				assert.Equal(t, services, tt.services)
+			}
+		})
+	}
+}
+
+func TestConfirmKill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(ctrl)
+	cli.EXPECT().In().Return(streams.NewIn(io.NopCloser(&bytes.Buffer{}))).AnyTimes()
+
+	err := confirmKill(cli, []string{"web", "worker"}, "SIGKILL", nil, true)
+	assert.NilError(t, err)
+
+	err = confirmKill(cli, []string{"web", "worker"}, "SIGTERM", nil, false)
+	assert.NilError(t, err)
+
+	err = confirmKill(cli, []string{"web", "worker"}, "SIGKILL", nil, false)
+	assert.Error(t, err, "refusing to send SIGKILL to services web, worker without confirmation, use --yes to proceed")
+}
+
+func TestPrintKillResults(t *testing.T) {
+	out := new(bytes.Buffer)
+	err := printKillResults(out, []api.KillResult{
+		{Container: "demo-worker-1", Service: "worker", Signal: "TERM", Status: api.KillStatusSkipped},
+		{Container: "demo-web-1", Service: "web", Signal: "HUP", Status: api.KillStatusKilled},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, out.String(), `CONTAINER           SERVICE             SIGNAL              STATUS
+demo-web-1          web                 HUP                 killed
+demo-worker-1       worker              TERM                skipped
+`)
+}
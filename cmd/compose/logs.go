@@ -19,6 +19,13 @@ package compose
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/docker/cli-docs-tool/annotation"
 	"github.com/docker/cli/cli/command"
@@ -32,14 +39,21 @@ import (
 type logsOptions struct {
 	*ProjectOptions
 	composeOptions
-	follow     bool
-	index      int
-	tail       string
-	since      string
-	until      string
-	noColor    bool
-	noPrefix   bool
-	timestamps bool
+	follow         bool
+	index          int
+	tail           string
+	since          string
+	until          string
+	noColor        bool
+	noPrefix       bool
+	timestamps     bool
+	logFilter      string
+	colorLevels    bool
+	levelPatterns  []string
+	maxPrefixWidth int
+	grep           string
+	grepContext    int
+	grepInvert     bool
 }
 
 func logsCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -56,6 +70,12 @@ func logsCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 			if opts.index > 0 && len(args) != 1 {
 				return errors.New("--index requires one service to be selected")
 			}
+			if opts.grep == "" && (opts.grepContext != 0 || opts.grepInvert) {
+				return errors.New("--grep-context and --grep-invert require --grep")
+			}
+			if opts.grepContext < 0 {
+				return fmt.Errorf("invalid --grep-context %d: must be positive", opts.grepContext)
+			}
 			return nil
 		},
 		ValidArgsFunction: completeServiceNames(dockerCli, p),
@@ -72,6 +92,13 @@ func logsCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	flags.BoolVar(&opts.noPrefix, "no-log-prefix", false, "Don't print prefix in logs")
 	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
 	flags.SetAnnotation("timestamps", annotation.ExternalURL, []string{"https://docs.docker.com/reference/cli/docker/container/logs/#timestamps"}) //nolint:errcheck
+	flags.StringVar(&opts.logFilter, "log-filter", "", "Pipe the log lines of each container through a process running this shell command, before they are rendered")
+	flags.BoolVar(&opts.colorLevels, "color-levels", false, "Render log lines with an intensity based on their detected log level")
+	flags.StringArrayVar(&opts.levelPatterns, "level-pattern", nil, "Regular expression detecting the log level of the lines of a service, as SERVICE=REGEX. Implies --color-levels")
+	flags.IntVar(&opts.maxPrefixWidth, "max-prefix-width", 0, "Truncate the container names in the log prefix to this width (0 for no limit)")
+	flags.StringVar(&opts.grep, "grep", "", "Only show the log lines matching this regular expression (RE2 syntax)")
+	flags.IntVar(&opts.grepContext, "grep-context", 0, "Number of lines to show before and after each line matching --grep")
+	flags.BoolVar(&opts.grepInvert, "grep-invert", false, "Only show the log lines not matching --grep")
 	flags.StringVarP(&opts.tail, "tail", "n", "all", "Number of lines to show from the end of the logs for each container")
 	flags.SetAnnotation("tail", annotation.ExternalURL, []string{"https://docs.docker.com/reference/cli/docker/container/logs/#tail"}) //nolint:errcheck
 	return logsCmd
@@ -96,7 +123,26 @@ func runLogs(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 	if err != nil {
 		return err
 	}
-	consumer := formatter.NewLogConsumer(ctx, dockerCli.Out(), dockerCli.Err(), !opts.noColor, !opts.noPrefix, false)
+	consumerOptions, err := logConsumerOptions(opts.colorLevels, opts.levelPatterns, opts.maxPrefixWidth)
+	if err != nil {
+		return err
+	}
+	if project != nil {
+		formatter.AssignColors(project.ServiceNames())
+	}
+	consumer := formatter.NewLogConsumer(ctx, dockerCli.Out(), dockerCli.Err(), !opts.noColor, !opts.noPrefix, false, consumerOptions...)
+	if opts.logFilter != "" {
+		filter := formatter.NewLogFilter(ctx, consumer, dockerCli.Err(), opts.logFilter)
+		defer filter.Close()
+		consumer = filter
+	}
+	grep, summary, err := logGrep(opts.grep, opts.grepContext, opts.grepInvert)
+	if err != nil {
+		return err
+	}
+	if summary != nil {
+		defer summary.print(dockerCli.Err())
+	}
 	return backend.Logs(ctx, name, consumer, api.LogOptions{
 		Project:    project,
 		Services:   services,
@@ -106,9 +152,79 @@ func runLogs(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 		Since:      opts.since,
 		Until:      opts.until,
 		Timestamps: opts.timestamps,
+		Grep:       grep,
 	})
 }
 
+// logGrep returns the LogGrep selecting the log lines matching pattern, and
+// the summary counting the matching lines of each service, or nil when no
+// pattern is set
+func logGrep(pattern string, contextLines int, invert bool) (*api.LogGrep, *grepSummary, error) {
+	if pattern == "" {
+		return nil, nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --grep pattern %q: %w", pattern, err)
+	}
+	summary := &grepSummary{counts: map[string]int{}}
+	return &api.LogGrep{
+		Pattern: re,
+		Invert:  invert,
+		Context: contextLines,
+		Matched: summary.add,
+	}, summary, nil
+}
+
+// grepSummary counts the log lines matching --grep per service
+type grepSummary struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (g *grepSummary) add(service string, count int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.counts[service] += count
+}
+
+func (g *grepSummary) print(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, service := range slices.Sorted(maps.Keys(g.counts)) {
+		_, _ = fmt.Fprintf(w, "%s: %d matching lines\n", service, g.counts[service])
+	}
+}
+
+// logConsumerOptions returns the options rendering log lines with an
+// intensity based on their log level, detected by the SERVICE=REGEX patterns,
+// and truncating the names in the prefix to maxPrefixWidth
+func logConsumerOptions(colorLevels bool, levelPatterns []string, maxPrefixWidth int) ([]formatter.LogConsumerOption, error) {
+	var options []formatter.LogConsumerOption
+	if maxPrefixWidth < 0 {
+		return nil, fmt.Errorf("invalid --max-prefix-width %d: must be positive", maxPrefixWidth)
+	}
+	if maxPrefixWidth > 0 {
+		options = append(options, formatter.WithMaxPrefixWidth(maxPrefixWidth))
+	}
+	if !colorLevels && len(levelPatterns) == 0 {
+		return options, nil
+	}
+	patterns := map[string]string{}
+	for _, p := range levelPatterns {
+		service, pattern, ok := strings.Cut(p, "=")
+		if !ok || service == "" || pattern == "" {
+			return nil, fmt.Errorf("invalid --level-pattern %q: expected SERVICE=REGEX", p)
+		}
+		patterns[service] = pattern
+	}
+	levels, err := formatter.NewLevelDetector(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return append(options, formatter.WithLevelDetector(levels)), nil
+}
+
 var _ api.LogConsumer = &logConsumer{}
 
 type logConsumer struct {
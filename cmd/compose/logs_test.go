@@ -0,0 +1,71 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLogConsumerOptions(t *testing.T) {
+	options, err := logConsumerOptions(false, nil, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(options), 0)
+
+	options, err = logConsumerOptions(true, nil, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(options), 1)
+
+	options, err = logConsumerOptions(false, []string{`db=\[(\w+)\]`}, 0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(options), 1)
+
+	_, err = logConsumerOptions(false, []string{"=level=(\\w+)"}, 0)
+	assert.Error(t, err, `invalid --level-pattern "=level=(\\w+)": expected SERVICE=REGEX`)
+
+	_, err = logConsumerOptions(false, []string{`db=[`}, 0)
+	assert.ErrorContains(t, err, `invalid log level pattern for service "db"`)
+
+	options, err = logConsumerOptions(false, nil, 20)
+	assert.NilError(t, err)
+	assert.Equal(t, len(options), 1)
+
+	_, err = logConsumerOptions(false, nil, -1)
+	assert.Error(t, err, "invalid --max-prefix-width -1: must be positive")
+}
+
+func TestLogGrep(t *testing.T) {
+	grep, summary, err := logGrep("", 0, false)
+	assert.NilError(t, err)
+	assert.Assert(t, grep == nil && summary == nil)
+
+	_, _, err = logGrep("[", 0, false)
+	assert.ErrorContains(t, err, `invalid --grep pattern "["`)
+
+	grep, summary, err = logGrep("error", 2, true)
+	assert.NilError(t, err)
+	assert.Equal(t, grep.Context, 2)
+	assert.Assert(t, grep.Invert)
+	grep.Matched("web", 2)
+	grep.Matched("db", 0)
+	grep.Matched("web", 1)
+	var out strings.Builder
+	summary.print(&out)
+	assert.Equal(t, out.String(), "db: 0 matching lines\nweb: 3 matching lines\n")
+}
@@ -17,16 +17,21 @@
 package compose
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/cli/cli/command"
 	cliformatter "github.com/docker/cli/cli/command/formatter"
 	cliflags "github.com/docker/cli/cli/flags"
+	"github.com/morikuni/aec"
 	"github.com/spf13/cobra"
 
 	"github.com/docker/compose/v5/cmd/formatter"
@@ -40,29 +45,31 @@ type psOptions struct {
 	All      bool
 	Quiet    bool
 	Services bool
-	Filter   string
+	Filter   []string
 	Status   []string
 	noTrunc  bool
 	Orphans  bool
+	Watch    bool
+	Interval time.Duration
+
+	filters api.ContainerFilters
 }
 
 func (p *psOptions) parseFilter() error {
-	if p.Filter == "" {
-		return nil
-	}
-	key, val, ok := strings.Cut(p.Filter, "=")
-	if !ok {
-		return errors.New("arguments to --filter should be in form KEY=VAL")
-	}
-	switch key {
-	case "status":
-		p.Status = append(p.Status, val)
-		return nil
-	case "source":
-		return api.ErrNotImplemented
-	default:
-		return fmt.Errorf("unknown filter %s", key)
+	filters, err := api.ParseContainerFilters(p.Filter)
+	if err != nil {
+		return err
 	}
+	p.filters = filters
+	return nil
+}
+
+// filtersOnStatus reports whether a --filter selects containers by status,
+// which then requires stopped containers to be listed
+func (p *psOptions) filtersOnStatus() bool {
+	return slices.ContainsFunc(p.Filter, func(f string) bool {
+		return strings.HasPrefix(f, "status=") || strings.HasPrefix(f, "status!=")
+	})
 }
 
 func psCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -73,6 +80,12 @@ func psCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backend
 		Use:   "ps [OPTIONS] [SERVICE...]",
 		Short: "List containers",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Watch && (opts.Quiet || opts.Services) {
+				return errors.New("--watch can't be combined with --quiet or --services")
+			}
+			if opts.Interval <= 0 {
+				return fmt.Errorf("invalid --interval %s: must be positive", opts.Interval)
+			}
 			return opts.parseFilter()
 		},
 		RunE: Adapt(func(ctx context.Context, args []string) error {
@@ -82,13 +95,15 @@ func psCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backend
 	}
 	flags := psCmd.Flags()
 	flags.StringVar(&opts.Format, "format", "table", cliflags.FormatHelp)
-	flags.StringVar(&opts.Filter, "filter", "", "Filter services by a property (supported filters: status)")
+	flags.StringArrayVar(&opts.Filter, "filter", []string{}, "Filter containers by a property, as KEY=VALUE or KEY!=VALUE (supported filters: label, status, health, service, name, name~=REGEX)")
 	flags.StringArrayVar(&opts.Status, "status", []string{}, "Filter services by status. Values: [paused | restarting | removing | running | dead | created | exited]")
 	flags.BoolVarP(&opts.Quiet, "quiet", "q", false, "Only display IDs")
 	flags.BoolVar(&opts.Services, "services", false, "Display services")
 	flags.BoolVar(&opts.Orphans, "orphans", true, "Include orphaned services (not declared by project)")
 	flags.BoolVarP(&opts.All, "all", "a", false, "Show all stopped containers (including those created by the run command)")
 	flags.BoolVar(&opts.noTrunc, "no-trunc", false, "Don't truncate output")
+	flags.BoolVarP(&opts.Watch, "watch", "w", false, "Refresh the list of containers as they change, highlighting the updated ones")
+	flags.DurationVar(&opts.Interval, "interval", 2*time.Second, "With --watch, interval between refreshes when no container event is received")
 	return psCmd
 }
 
@@ -116,23 +131,17 @@ func runPs(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOp
 	if err != nil {
 		return err
 	}
-	containers, err := backend.Ps(ctx, name, api.PsOptions{
-		Project:  project,
-		All:      opts.All || len(opts.Status) != 0,
-		Services: services,
-	})
+	if opts.Format == "" {
+		opts.Format = dockerCli.ConfigFile().PsFormat
+	}
+	if opts.Watch {
+		return watchPs(ctx, dockerCli, backend, name, project, services, opts)
+	}
+	containers, err := psContainers(ctx, backend, name, project, services, opts)
 	if err != nil {
 		return err
 	}
 
-	if len(opts.Status) != 0 {
-		containers = filterByStatus(containers, opts.Status)
-	}
-
-	sort.Slice(containers, func(i, j int) bool {
-		return containers[i].Name < containers[j].Name
-	})
-
 	if opts.Quiet {
 		for _, c := range containers {
 			_, _ = fmt.Fprintln(dockerCli.Out(), c.ID)
@@ -152,18 +161,152 @@ func runPs(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOp
 		return nil
 	}
 
-	if opts.Format == "" {
-		opts.Format = dockerCli.ConfigFile().PsFormat
+	return writePs(dockerCli.Out(), containers, opts)
+}
+
+// psContainers lists the containers selected by the ps options, sorted by name
+func psContainers(ctx context.Context, backend api.Compose, name string, project *types.Project, services []string, opts psOptions) ([]api.ContainerSummary, error) {
+	containers, err := backend.Ps(ctx, name, api.PsOptions{
+		Project:  project,
+		All:      opts.All || len(opts.Status) != 0 || opts.filtersOnStatus(),
+		Services: services,
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	if len(opts.Status) != 0 {
+		containers = filterByStatus(containers, opts.Status)
+	}
+	containers = opts.filters.Apply(containers)
+
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	return containers, nil
+}
+
+func writePs(out io.Writer, containers []api.ContainerSummary, opts psOptions) error {
 	containerCtx := cliformatter.Context{
-		Output: dockerCli.Out(),
+		Output: out,
 		Format: formatter.NewContainerFormat(opts.Format, opts.Quiet, false),
 		Trunc:  !opts.noTrunc,
 	}
 	return formatter.ContainerWrite(containerCtx, containers)
 }
 
+// watchPs lists the containers again on each container event of the project,
+// or every interval, until interrupted. On a terminal, the list is rendered in
+// place with the containers which changed since the previous one highlighted,
+// otherwise it is printed again in full.
+func watchPs(ctx context.Context, dockerCli command.Cli, backend api.Compose, name string, project *types.Project, services []string, opts psOptions) error {
+	refresh := make(chan struct{}, 1)
+	go func() {
+		_ = backend.Events(ctx, name, api.EventsOptions{
+			Services: services,
+			Consumer: func(event api.Event) error {
+				if psRefreshedOn(event.Status) {
+					select {
+					case refresh <- struct{}{}:
+					default:
+					}
+				}
+				return nil
+			},
+		})
+	}()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	tty := dockerCli.Out().IsTerminal()
+	var previous []api.ContainerSummary
+	for first := true; ; first = false {
+		containers, err := psContainers(ctx, backend, name, project, services, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var buf bytes.Buffer
+		if err := writePs(&buf, containers, opts); err != nil {
+			return err
+		}
+		switch {
+		case tty:
+			var changed map[string]bool
+			if !first {
+				changed = changedContainers(previous, containers)
+			}
+			_, _ = fmt.Fprint(dockerCli.Out(), aec.Position(0, 0), aec.EraseDisplay(aec.EraseModes.All),
+				highlightRows(buf.String(), formatter.NewContainerFormat(opts.Format, false, false).IsTable(), containers, changed))
+		case first:
+			_, _ = fmt.Fprint(dockerCli.Out(), buf.String())
+		default:
+			_, _ = fmt.Fprint(dockerCli.Out(), "\n", buf.String())
+		}
+		previous = containers
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-refresh:
+		}
+	}
+}
+
+// psRefreshedOn reports whether a container event changes what ps lists
+func psRefreshedOn(action string) bool {
+	switch action {
+	case "create", "start", "die", "destroy", "pause", "unpause", "rename":
+		return true
+	}
+	return strings.HasPrefix(action, "health_status")
+}
+
+// changedContainers returns the IDs of the containers in current which are
+// new or whose state, health, exit code, image or ports differ in previous.
+// The status text isn't compared, as it tells the uptime.
+func changedContainers(previous, current []api.ContainerSummary) map[string]bool {
+	before := map[string]api.ContainerSummary{}
+	for _, c := range previous {
+		before[c.ID] = c
+	}
+	changed := map[string]bool{}
+	for _, c := range current {
+		p, ok := before[c.ID]
+		if !ok || p.State != c.State || p.Health != c.Health || p.ExitCode != c.ExitCode ||
+			p.Image != c.Image || !slices.Equal(p.Publishers, c.Publishers) {
+			changed[c.ID] = true
+		}
+	}
+	return changed
+}
+
+// highlightRows highlights the rows of the ps output rendering the changed
+// containers. The output is left as is when its lines can't be told to match
+// the containers, e.g. with a format spanning several lines per container.
+func highlightRows(output string, header bool, containers []api.ContainerSummary, changed map[string]bool) string {
+	if len(changed) == 0 {
+		return output
+	}
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	offset := 0
+	if header {
+		offset = 1
+	}
+	if len(lines) != len(containers)+offset {
+		return output
+	}
+	for i, c := range containers {
+		if changed[c.ID] {
+			lines[i+offset] = formatter.Highlight(lines[i+offset])
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
 func filterByStatus(containers []api.ContainerSummary, statuses []string) []api.ContainerSummary {
 	var filtered []api.ContainerSummary
 	for _, c := range containers {
@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/cmd/formatter"
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestChangedContainers(t *testing.T) {
+	web := api.ContainerSummary{ID: "web", State: container.StateRunning, Status: "Up 2 seconds", Health: container.Starting}
+	db := api.ContainerSummary{ID: "db", State: container.StateRunning, Status: "Up 2 seconds"}
+	worker := api.ContainerSummary{ID: "worker", State: container.StateRunning, Status: "Up 2 seconds"}
+	previous := []api.ContainerSummary{web, db, worker}
+
+	healthy := web
+	healthy.Health = container.Healthy
+	healthy.Status = "Up 4 seconds (healthy)"
+	uptime := db
+	uptime.Status = "Up 4 seconds"
+	exited := worker
+	exited.State = container.StateExited
+	exited.ExitCode = 1
+	cache := api.ContainerSummary{ID: "cache", State: container.StateCreated}
+
+	changed := changedContainers(previous, []api.ContainerSummary{healthy, uptime, exited, cache})
+	assert.DeepEqual(t, changed, map[string]bool{"web": true, "worker": true, "cache": true})
+}
+
+func TestHighlightRows(t *testing.T) {
+	containers := []api.ContainerSummary{{ID: "db"}, {ID: "web"}}
+	changed := map[string]bool{"web": true}
+
+	output := "NAME\tSTATUS\np-db-1\tUp\np-web-1\tUp\n"
+	assert.Equal(t, highlightRows(output, true, containers, changed),
+		"NAME\tSTATUS\np-db-1\tUp\n"+formatter.Highlight("p-web-1\tUp")+"\n")
+
+	output = "p-db-1 Up\np-web-1 Up\n"
+	assert.Equal(t, highlightRows(output, false, containers, changed),
+		"p-db-1 Up\n"+formatter.Highlight("p-web-1 Up")+"\n")
+
+	// rows can't be told apart with a format spanning several lines
+	output = "name: p-db-1\nstatus: Up\nname: p-web-1\nstatus: Up\n"
+	assert.Equal(t, highlightRows(output, false, containers, changed), output)
+
+	assert.Equal(t, highlightRows("p-web-1 Up\n", false, containers[1:], nil), "p-web-1 Up\n")
+}
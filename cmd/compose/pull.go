@@ -41,6 +41,7 @@ type pullOptions struct {
 	ignorePullFailures bool
 	noBuildable        bool
 	policy             string
+	verifyImages       string
 }
 
 func pullCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -57,7 +58,7 @@ func pullCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 			if cmd.Flags().Changed("parallel") {
 				fmt.Fprint(os.Stderr, aec.Apply("option '--parallel' is DEPRECATED and will be ignored.\n", aec.RedF))
 			}
-			return nil
+			return validateVerifyImages(opts.verifyImages)
 		},
 		RunE: Adapt(func(ctx context.Context, args []string) error {
 			return runPull(ctx, dockerCli, backendOptions, opts, args)
@@ -66,7 +67,7 @@ func pullCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	}
 	flags := cmd.Flags()
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Pull without printing progress information")
-	cmd.Flags().BoolVar(&opts.includeDeps, "include-deps", false, "Also pull services declared as dependencies")
+	cmd.Flags().BoolVar(&opts.includeDeps, "include-deps", false, "Also pull services declared as dependencies or referenced as additional build contexts")
 	cmd.Flags().BoolVar(&opts.parallel, "parallel", true, "DEPRECATED pull multiple images in parallel")
 	flags.MarkHidden("parallel") //nolint:errcheck
 	cmd.Flags().BoolVar(&opts.noParallel, "no-parallel", true, "DEPRECATED disable parallel pulling")
@@ -74,6 +75,7 @@ func pullCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 	cmd.Flags().BoolVar(&opts.ignorePullFailures, "ignore-pull-failures", false, "Pull what it can and ignores images with pull failures")
 	cmd.Flags().BoolVar(&opts.noBuildable, "ignore-buildable", false, "Ignore images that can be built")
 	cmd.Flags().StringVar(&opts.policy, "policy", "", `Apply pull policy ("missing"|"always")`)
+	cmd.Flags().StringVar(&opts.verifyImages, "verify-images", api.VerifyImagesOff, "Verify the images once pulled with the command set as "+ComposeImageVerifier+". Values: [off | warn | enforce]")
 	return cmd
 }
 
@@ -99,15 +101,29 @@ func (opts pullOptions) apply(project *types.Project, services []string) (*types
 }
 
 func runPull(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts pullOptions, services []string) error {
+	if err := withImageVerifier(backendOptions, opts.verifyImages); err != nil {
+		return err
+	}
 	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
 	if err != nil {
 		return err
 	}
 
-	project, _, err := opts.ToProject(ctx, dockerCli, backend, services, cli.WithoutEnvironmentResolution)
+	// services referenced as additional build contexts aren't dependencies
+	// the loader selects: load them all to select them along
+	loaded := services
+	if opts.includeDeps {
+		loaded = nil
+	}
+	project, _, err := opts.ToProject(ctx, dockerCli, backend, loaded, cli.WithoutEnvironmentResolution)
 	if err != nil {
 		return err
 	}
+	if opts.includeDeps {
+		if project, err = withBuildDependencies(dockerCli, project, services); err != nil {
+			return err
+		}
+	}
 
 	project, err = opts.apply(project, services)
 	if err != nil {
@@ -117,6 +133,7 @@ func runPull(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 	return backend.Pull(ctx, project, api.PullOptions{
 		Quiet:           opts.quiet,
 		IgnoreFailures:  opts.ignorePullFailures,
+		VerifyImages:    opts.verifyImages,
 		IgnoreBuildable: opts.noBuildable,
 	})
 }
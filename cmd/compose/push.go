@@ -18,6 +18,7 @@ package compose
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/cli/cli/command"
@@ -48,7 +49,7 @@ func pushCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backe
 		ValidArgsFunction: completeServiceNames(dockerCli, p),
 	}
 	pushCmd.Flags().BoolVar(&opts.Ignorefailures, "ignore-push-failures", false, "Push what it can and ignores images with push failures")
-	pushCmd.Flags().BoolVar(&opts.IncludeDeps, "include-deps", false, "Also push images of services declared as dependencies")
+	pushCmd.Flags().BoolVar(&opts.IncludeDeps, "include-deps", false, "Also push images of services declared as dependencies or referenced as additional build contexts")
 	pushCmd.Flags().BoolVarP(&opts.Quiet, "quiet", "q", false, "Push without printing progress information")
 
 	return pushCmd
@@ -60,16 +61,24 @@ func runPush(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 		return err
 	}
 
-	project, _, err := opts.ToProject(ctx, dockerCli, backend, services)
+	// services referenced as additional build contexts aren't dependencies
+	// the loader selects: load them all to select them along
+	loaded := services
+	if opts.IncludeDeps {
+		loaded = nil
+	}
+	project, _, err := opts.ToProject(ctx, dockerCli, backend, loaded)
 	if err != nil {
 		return err
 	}
 
-	if !opts.IncludeDeps {
+	if opts.IncludeDeps {
+		project, err = withBuildDependencies(dockerCli, project, services)
+	} else {
 		project, err = project.WithSelectedServices(services, types.IgnoreDependencies)
-		if err != nil {
-			return err
-		}
+	}
+	if err != nil {
+		return err
 	}
 
 	return backend.Push(ctx, project, api.PushOptions{
@@ -77,3 +86,16 @@ func runPush(ctx context.Context, dockerCli command.Cli, backendOptions *Backend
 		Quiet:          opts.Quiet,
 	})
 }
+
+// withBuildDependencies selects services in project with their dependencies,
+// including the services their builds reference, and tells about the latter
+func withBuildDependencies(dockerCli command.Cli, project *types.Project, services []string) (*types.Project, error) {
+	project, added, err := compose.WithBuildDependencies(project, services)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range added {
+		_, _ = fmt.Fprintf(dockerCli.Err(), "Including service %s, referenced as an additional build context\n", name)
+	}
+	return project, nil
+}
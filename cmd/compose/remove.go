@@ -30,9 +30,10 @@ import (
 
 type removeOptions struct {
 	*ProjectOptions
-	force   bool
-	stop    bool
-	volumes bool
+	force     bool
+	stop      bool
+	volumes   bool
+	staleOnly bool
 }
 
 func removeCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -47,7 +48,10 @@ func removeCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Bac
 By default, anonymous volumes attached to containers will not be removed. You
 can override this with -v. To list all volumes, use "docker volume ls".
 
-Any data which is not in a volume will be lost.`,
+Any data which is not in a volume will be lost.
+
+Containers left under a temporary name by an interrupted recreate are listed
+as stale recreate leftovers. Use --stale-only to only remove those.`,
 		RunE: Adapt(func(ctx context.Context, args []string) error {
 			return runRemove(ctx, dockerCli, backendOptions, opts, args)
 		}),
@@ -57,6 +61,7 @@ Any data which is not in a volume will be lost.`,
 	f.BoolVarP(&opts.force, "force", "f", false, "Don't ask to confirm removal")
 	f.BoolVarP(&opts.stop, "stop", "s", false, "Stop the containers, if required, before removing")
 	f.BoolVarP(&opts.volumes, "volumes", "v", false, "Remove any anonymous volumes attached to containers")
+	f.BoolVar(&opts.staleOnly, "stale-only", false, "Only remove the containers left under a temporary name by an interrupted recreate")
 	f.BoolP("all", "a", false, "Deprecated - no effect")
 	f.MarkHidden("all") //nolint:errcheck
 
@@ -74,13 +79,18 @@ func runRemove(ctx context.Context, dockerCli command.Cli, backendOptions *Backe
 		return err
 	}
 	err = backend.Remove(ctx, name, api.RemoveOptions{
-		Services: services,
-		Force:    opts.force,
-		Volumes:  opts.volumes,
-		Project:  project,
-		Stop:     opts.stop,
+		Services:  services,
+		Force:     opts.force,
+		Volumes:   opts.volumes,
+		Project:   project,
+		Stop:      opts.stop,
+		StaleOnly: opts.staleOnly,
 	})
 	if errors.Is(err, api.ErrNoResources) {
+		if opts.staleOnly {
+			_, _ = fmt.Fprintln(stdinfo(dockerCli), "No stale recreate leftovers")
+			return nil
+		}
 		_, _ = fmt.Fprintln(stdinfo(dockerCli), "No stopped containers")
 		return nil
 	}
@@ -68,6 +68,7 @@ type runOptions struct {
 	removeOrphans bool
 	quiet         bool
 	quietPull     bool
+	quietDeps     bool
 }
 
 func (options runOptions) apply(project *types.Project) (*types.Project, error) {
@@ -245,7 +246,10 @@ func runCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backen
 	flags.BoolVarP(&options.quiet, "quiet", "q", false, "Don't print anything to STDOUT")
 	flags.BoolVar(&buildOpts.quiet, "quiet-build", false, "Suppress progress output from the build process")
 	flags.BoolVar(&options.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.BoolVar(&options.quietDeps, "quiet-deps", false, "Only print the progress of starting dependencies if they fail to start")
 	flags.BoolVar(&createOpts.Build, "build", false, "Build image before starting container")
+	flags.BoolVar(&createOpts.createHostPaths, "create-host-paths", false, "Create the missing sources of bind mounts as directories owned by the current user, rather than letting the engine create them owned by root")
+	flags.StringVar(&createOpts.cidFile, "cidfile", "", "Append the IDs of the containers created to FILE, one service=container_id line per container")
 	flags.BoolVar(&options.removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
 
 	cmd.Flags().BoolVarP(&options.interactive, "interactive", "i", true, "Keep STDIN open even if not attached")
@@ -269,8 +273,8 @@ func normalizeRunFlags(f *pflag.FlagSet, name string) pflag.NormalizedName {
 	return pflag.NormalizedName(name)
 }
 
-func runRun(ctx context.Context, backend api.Compose, project *types.Project, options runOptions, createOpts createOptions, buildOpts buildOptions, dockerCli command.Cli) error {
-	project, err := options.apply(project)
+func runRun(ctx context.Context, backend api.Compose, project *types.Project, options runOptions, createOpts createOptions, buildOpts buildOptions, dockerCli command.Cli) (err error) {
+	project, err = options.apply(project)
 	if err != nil {
 		return err
 	}
@@ -307,13 +311,26 @@ func runRun(ctx context.Context, backend api.Compose, project *types.Project, op
 		return err
 	}
 
+	containerCreated, closeCIDFile, err := withCIDFile(createOpts.cidFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// keep err as is, its type may carry the exit code
+		if closeErr := closeCIDFile(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
 	// start container and attach to container streams
 	runOpts := api.RunOptions{
 		CreateOptions: api.CreateOptions{
-			Build:         buildForRun,
-			RemoveOrphans: options.removeOrphans,
-			IgnoreOrphans: options.ignoreOrphans,
-			QuietPull:     options.quietPull,
+			Build:            buildForRun,
+			RemoveOrphans:    options.removeOrphans,
+			IgnoreOrphans:    options.ignoreOrphans,
+			QuietPull:        options.quietPull,
+			CreateHostPaths:  createOpts.createHostPaths,
+			ContainerCreated: containerCreated,
 		},
 		Name:              options.name,
 		Service:           options.Service,
@@ -331,6 +348,7 @@ func runRun(ctx context.Context, backend api.Compose, project *types.Project, op
 		Labels:            labels,
 		UseNetworkAliases: options.useAliases,
 		NoDeps:            options.noDeps,
+		QuietDeps:         options.quietDeps,
 		Index:             0,
 	}
 
@@ -34,7 +34,9 @@ import (
 
 type scaleOptions struct {
 	*ProjectOptions
-	noDeps bool
+	noDeps              bool
+	keepScaledDown      bool
+	scaleDownReferenced string
 }
 
 func scaleCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
@@ -56,11 +58,16 @@ func scaleCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Back
 	}
 	flags := scaleCmd.Flags()
 	flags.BoolVar(&opts.noDeps, "no-deps", false, "Don't start linked services")
+	flags.BoolVar(&opts.keepScaledDown, "keep-scaled-down", false, "Stop the containers of services scaled down rather than removing them, and restart them when scaling back up")
+	flags.StringVar(&opts.scaleDownReferenced, "scale-down-referenced", api.ScaleDownReferencedWarn, scaleDownReferencedUsage)
 
 	return scaleCmd
 }
 
 func runScale(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, opts scaleOptions, serviceReplicaTuples map[string]int) error {
+	if err := validateScaleDownReferenced(opts.scaleDownReferenced); err != nil {
+		return err
+	}
 	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
 	if err != nil {
 		return err
@@ -87,7 +94,11 @@ func runScale(ctx context.Context, dockerCli command.Cli, backendOptions *Backen
 		project.Services[key] = service
 	}
 
-	return backend.Scale(ctx, project, api.ScaleOptions{Services: services})
+	return backend.Scale(ctx, project, api.ScaleOptions{
+		Services:            services,
+		KeepScaledDown:      opts.keepScaledDown,
+		ScaleDownReferenced: opts.scaleDownReferenced,
+	})
 }
 
 func parseServicesReplicasArgs(args []string) (map[string]int, error) {
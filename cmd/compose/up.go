@@ -18,10 +18,14 @@ package compose
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
@@ -38,6 +42,10 @@ import (
 	"github.com/docker/compose/v5/pkg/utils"
 )
 
+// defaultEarlyExitWindow is the duration --check-early-exit observes the
+// containers for when set without a value
+const defaultEarlyExitWindow = 500 * time.Millisecond
+
 // composeOptions hold options common to `up` and `run` to run compose project
 type composeOptions struct {
 	*ProjectOptions
@@ -51,17 +59,32 @@ type upOptions struct {
 	cascadeStop           bool
 	cascadeFail           bool
 	exitCodeFrom          string
+	exitMode              string
 	noColor               bool
 	noPrefix              bool
+	logFilter             string
+	colorLevels           bool
+	levelPatterns         []string
+	maxPrefixWidth        int
 	attachDependencies    bool
 	attach                []string
 	noAttach              []string
 	timestamp             bool
 	wait                  bool
 	waitTimeout           int
+	skipHealthWaits       bool
+	checkEarlyExit        time.Duration
+	selector              string
 	watch                 bool
 	navigationMenu        bool
 	navigationMenuChanged bool
+	report                string
+	profileStartup        bool
+	scaleSchedule         []string
+	reconcileInterval     time.Duration
+	daemonWait            time.Duration
+	verify                []string
+	verifyTimeout         time.Duration
 }
 
 func (opts upOptions) apply(project *types.Project, services []string) (*types.Project, error) {
@@ -83,6 +106,29 @@ func (opts upOptions) apply(project *types.Project, services []string) (*types.P
 	return project, nil
 }
 
+// selectServices restricts the project to the services matching the label
+// selector, and their dependencies.
+func (opts upOptions) selectServices(project *types.Project, services []string) (*types.Project, []string, error) {
+	if opts.selector == "" {
+		return project, services, nil
+	}
+	if len(services) > 0 {
+		return nil, nil, errors.New("--selector can't be combined with service names")
+	}
+	selected, err := compose.ServicesMatchingSelector(project, opts.selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(selected) == 0 {
+		return nil, nil, fmt.Errorf("no service matches selector %q", opts.selector)
+	}
+	project, err = project.WithSelectedServices(selected)
+	if err != nil {
+		return nil, nil, err
+	}
+	return project, selected, nil
+}
+
 func (opts *upOptions) validateNavigationMenu(dockerCli command.Cli) {
 	if !dockerCli.Out().IsTerminal() {
 		opts.navigationMenu = false
@@ -137,6 +183,11 @@ func upCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backend
 
 			up.validateNavigationMenu(dockerCli)
 
+			project, services, err := up.selectServices(project, services)
+			if err != nil {
+				return err
+			}
+
 			if !p.All && len(project.Services) == 0 {
 				return fmt.Errorf("no service selected")
 			}
@@ -151,30 +202,65 @@ func upCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Backend
 	flags.BoolVar(&create.noBuild, "no-build", false, "Don't build an image, even if it's policy")
 	flags.StringVar(&create.Pull, "pull", "policy", `Pull image before running ("always"|"missing"|"never")`)
 	flags.BoolVar(&create.removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
-	flags.StringArrayVar(&create.scale, "scale", []string{}, "Scale SERVICE to NUM instances. Overrides the `scale` setting in the Compose file if present.")
+	flags.StringArrayVar(&create.scale, "scale", []string{}, "Scale SERVICE to NUM instances, or by +NUM/-NUM instances relatively to the running ones. Overrides the `scale` setting in the Compose file if present.")
 	flags.BoolVar(&up.noColor, "no-color", false, "Produce monochrome output")
 	flags.BoolVar(&up.noPrefix, "no-log-prefix", false, "Don't print prefix in logs")
+	flags.StringVar(&up.logFilter, "log-filter", "", "Pipe the log lines of each attached container through a process running this shell command, before they are rendered")
+	flags.BoolVar(&up.colorLevels, "color-levels", false, "Render log lines with an intensity based on their detected log level")
+	flags.StringArrayVar(&up.levelPatterns, "level-pattern", nil, "Regular expression detecting the log level of the lines of a service, as SERVICE=REGEX. Implies --color-levels")
+	flags.IntVar(&up.maxPrefixWidth, "max-prefix-width", 0, "Truncate the container names in the log prefix to this width (0 for no limit)")
 	flags.BoolVar(&create.forceRecreate, "force-recreate", false, "Recreate containers even if their configuration and image haven't changed")
 	flags.BoolVar(&create.noRecreate, "no-recreate", false, "If containers already exist, don't recreate them. Incompatible with --force-recreate.")
 	flags.BoolVar(&up.noStart, "no-start", false, "Don't start the services after creating them")
 	flags.BoolVar(&up.cascadeStop, "abort-on-container-exit", false, "Stops all containers if any container was stopped. Incompatible with -d")
 	flags.BoolVar(&up.cascadeFail, "abort-on-container-failure", false, "Stops all containers if any container exited with failure. Incompatible with -d")
 	flags.StringVar(&up.exitCodeFrom, "exit-code-from", "", "Return the exit code of the selected service container. Implies --abort-on-container-exit")
+	flags.StringVar(&up.exitMode, "exit-mode", api.ExitModeStop, "What to do with the containers when an attached up terminates. Values: [detach | stop | down]")
 	flags.IntVarP(&create.timeout, "timeout", "t", 0, "Use this timeout in seconds for container shutdown when attached or when containers are already running")
+	flags.StringVar(&up.report, "report", "", "Write a JSON report of what up did to FILE once it completes")
+	flags.BoolVar(&up.profileStartup, "profile-startup", false, "Print the time spent bringing up each service, and the critical path through their dependencies, once up completes")
 	flags.BoolVar(&up.timestamp, "timestamps", false, "Show timestamps")
 	flags.BoolVar(&up.noDeps, "no-deps", false, "Don't start linked services")
 	flags.BoolVar(&create.recreateDeps, "always-recreate-deps", false, "Recreate dependent containers. Incompatible with --no-recreate.")
+	flags.BoolVar(&create.recreateDependents, "recreate-dependents", false, "Recreate the services depending on a recreated service with restart: true, rather than restarting them")
+	flags.StringVar(&create.recreateOrder, "recreate-order", api.RecreateOrderDependenciesFirst, recreateOrderUsage)
 	flags.BoolVarP(&create.noInherit, "renew-anon-volumes", "V", false, "Recreate anonymous volumes instead of retrieving data from the previous containers")
 	flags.BoolVar(&create.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.BoolVar(&create.pipelinePull, "pipeline-pull", false, "Create the containers of services whose image is present while the images of the others are pulled")
 	flags.BoolVar(&build.quiet, "quiet-build", false, "Suppress the build output")
 	flags.StringArrayVar(&up.attach, "attach", []string{}, "Restrict attaching to the specified services. Incompatible with --attach-dependencies.")
 	flags.StringArrayVar(&up.noAttach, "no-attach", []string{}, "Do not attach (stream logs) to the specified services")
 	flags.BoolVar(&up.attachDependencies, "attach-dependencies", false, "Automatically attach to log output of dependent services")
 	flags.BoolVar(&up.wait, "wait", false, "Wait for services to be running|healthy. Implies detached mode.")
 	flags.IntVar(&up.waitTimeout, "wait-timeout", 0, "Maximum duration in seconds to wait for the project to be running|healthy")
+	flags.StringArrayVar(&up.scaleSchedule, "scale-schedule", []string{}, "Change the scale of SERVICE over time while attached, as SERVICE=NUM@DURATION[,NUM@DURATION...] with durations since up started (experimental)")
+	flags.DurationVar(&up.daemonWait, "daemon-wait", 30*time.Second, "How long to wait for the Docker engine to come back when it restarts while converging the project, before resuming. 0 to fail right away")
+	flags.DurationVar(&up.reconcileInterval, "reconcile-interval", 0, "While attached, check the project for drift at this interval and converge the services diverging again, e.g. stopped or missing containers (experimental)")
+	flags.StringArrayVar(&up.verify, "verify", []string{}, "Once the project is converged, check this HTTP(S) URL responds with a 2xx status, failing otherwise. Can be repeated")
+	flags.DurationVar(&up.verifyTimeout, "verify-timeout", 30*time.Second, "Maximum duration to wait for each --verify URL to respond with a 2xx status")
+	flags.StringVar(&up.selector, "selector", "", "Only converge the services with labels matching the selector (e.g. tier=frontend,env!=prod), and their dependencies")
+	flags.BoolVar(&up.skipHealthWaits, "skip-health-waits", false, "Start services in dependency order without waiting for depends_on healthy or completed conditions")
+	flags.DurationVar(&up.checkEarlyExit, "check-early-exit", 0, "Report containers exiting with an error within this duration after being started as failed")
+	flags.Lookup("check-early-exit").NoOptDefVal = defaultEarlyExitWindow.String()
 	flags.BoolVarP(&up.watch, "watch", "w", false, "Watch source code and rebuild/refresh containers when files are updated.")
 	flags.BoolVar(&up.navigationMenu, "menu", false, "Enable interactive shortcuts when running attached. Incompatible with --detach. Can also be enable/disable by setting COMPOSE_MENU environment var.")
 	flags.BoolVarP(&create.AssumeYes, "yes", "y", false, `Assume "yes" as answer to all prompts and run non-interactively`)
+	flags.DurationVar(&create.maxAge, "max-age", 0, "Recreate containers created longer ago than this duration, even if their configuration and image haven't changed")
+	flags.BoolVar(&create.keepScaledDown, "keep-scaled-down", false, "Stop the containers of services scaled down rather than removing them, and restart them when scaling back up")
+	flags.BoolVar(&create.lazyResources, "lazy-resources", false, "Only create the missing networks and volumes once a container using them is created or started")
+	flags.DurationVar(&create.recreateRestarting, "recreate-restarting", 0, "Recreate containers stuck restarting, restarted by the engine for longer than this duration")
+	flags.StringVar(&create.restartExhausted, "restart-exhausted", api.RestartExhaustedRestart, restartExhaustedUsage)
+	flags.BoolVar(&create.createHostPaths, "create-host-paths", false, "Create the missing sources of bind mounts as directories owned by the current user, rather than letting the engine create them owned by root")
+	flags.BoolVar(&create.skipIPv6Check, "skip-ipv6-check", false, "Skip the check of the IPv6 configuration of networks and published ports against the engine capabilities")
+	flags.BoolVar(&create.skipPlacementCheck, "skip-placement-check", false, "Create containers ignoring the deploy.placement.constraints the engine can't honor, rather than failing")
+	flags.BoolVar(&create.validateReferences, "validate-references", false, "Check the secrets, configs and external volumes referenced by the services exist before creating any container")
+	flags.BoolVar(&create.ignoreHealthcheck, "ignore-healthcheck-changes", false, "Don't recreate containers when only the healthcheck of their service changed")
+	flags.BoolVar(&create.preservePaused, "preserve-paused", true, "Pause the replacements of recreated paused containers once started")
+	flags.StringVar(&create.cidFile, "cidfile", "", "Append the IDs of the containers created to FILE, one service=container_id line per container")
+	flags.StringVar(&create.duplicateNumbers, "duplicate-numbers", api.DuplicateNumbersKeepNewest, "How to handle service containers sharing a number. Values: [keep-newest | error]")
+	flags.StringVar(&create.scaleDownReferenced, "scale-down-referenced", api.ScaleDownReferencedWarn, scaleDownReferencedUsage)
+	flags.StringVar(&create.resourcePreflight, "resource-preflight", "", "Check the host CPUs and memory can accommodate the resources reserved by the project before scaling up. Values: [warn | error]")
+	flags.StringVar(&create.verifyImages, "verify-images", api.VerifyImagesOff, verifyImagesUsage)
 	flags.SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
 		// assumeYes was introduced by mistake as `--y`
 		if name == "y" {
@@ -206,6 +292,18 @@ func validateFlags(up *upOptions, create *createOptions) error {
 	if create.Build && create.noBuild {
 		return fmt.Errorf("--build and --no-build are incompatible")
 	}
+	if len(up.scaleSchedule) > 0 && (up.Detach || up.noStart) {
+		return fmt.Errorf("--scale-schedule applies while up runs attached, and can't be combined with --detach, --wait or --no-start")
+	}
+	if up.daemonWait < 0 {
+		return fmt.Errorf("--daemon-wait can't be negative")
+	}
+	if up.reconcileInterval < 0 {
+		return fmt.Errorf("--reconcile-interval can't be negative")
+	}
+	if up.reconcileInterval > 0 && (up.Detach || up.noStart) {
+		return fmt.Errorf("--reconcile-interval applies while up runs attached, and can't be combined with --detach, --wait or --no-start")
+	}
 	if up.Detach && (up.attachDependencies || up.cascadeStop || up.cascadeFail || len(up.attach) > 0 || up.watch) {
 		if up.wait {
 			return fmt.Errorf("--wait cannot be combined with --abort-on-container-exit, --abort-on-container-failure, --attach, --attach-dependencies or --watch")
@@ -222,9 +320,62 @@ func validateFlags(up *upOptions, create *createOptions) error {
 	if create.recreateDeps && create.noRecreate {
 		return fmt.Errorf("--always-recreate-deps and --no-recreate are incompatible")
 	}
+	if create.recreateDependents && create.noRecreate {
+		return fmt.Errorf("--recreate-dependents and --no-recreate are incompatible")
+	}
+	if create.maxAge != 0 && create.noRecreate {
+		return fmt.Errorf("--max-age and --no-recreate are incompatible")
+	}
+	if create.maxAge < 0 {
+		return fmt.Errorf("--max-age must be a positive duration")
+	}
+	if create.recreateRestarting != 0 && create.noRecreate {
+		return fmt.Errorf("--recreate-restarting and --no-recreate are incompatible")
+	}
+	if create.recreateRestarting < 0 {
+		return fmt.Errorf("--recreate-restarting must be a positive duration")
+	}
+	if err := create.validateDuplicateNumbers(); err != nil {
+		return err
+	}
+	if err := create.validateResourcePreflight(); err != nil {
+		return err
+	}
+	if err := validateVerifyImages(create.verifyImages); err != nil {
+		return err
+	}
+	if err := create.validateRecreateOrder(); err != nil {
+		return err
+	}
+	if err := create.validateRestartExhausted(); err != nil {
+		return err
+	}
+	if err := validateScaleDownReferenced(create.scaleDownReferenced); err != nil {
+		return err
+	}
+	switch up.exitMode {
+	case api.ExitModeDetach, api.ExitModeStop, api.ExitModeDown:
+	default:
+		return fmt.Errorf("invalid --exit-mode %q, must be one of %s, %s or %s", up.exitMode, api.ExitModeDetach, api.ExitModeStop, api.ExitModeDown)
+	}
 	if create.noBuild && up.watch {
 		return fmt.Errorf("--no-build and --watch are incompatible")
 	}
+	if up.report != "" && up.noStart {
+		return fmt.Errorf("--report and --no-start are incompatible")
+	}
+	if up.profileStartup && up.noStart {
+		return fmt.Errorf("--profile-startup and --no-start are incompatible")
+	}
+	if len(up.verify) > 0 && up.noStart {
+		return fmt.Errorf("--verify and --no-start are incompatible")
+	}
+	if up.verifyTimeout <= 0 {
+		return fmt.Errorf("--verify-timeout must be a positive duration")
+	}
+	if up.checkEarlyExit < 0 {
+		return fmt.Errorf("--check-early-exit must be a positive duration")
+	}
 	return nil
 }
 
@@ -238,12 +389,24 @@ func runUp(
 	buildOptions buildOptions,
 	project *types.Project,
 	services []string,
-) error {
+) (err error) {
 	if err := checksForRemoteStack(ctx, dockerCli, project, buildOptions, createOptions.AssumeYes, []string{}); err != nil {
 		return err
 	}
+	consumerOptions, err := logConsumerOptions(upOptions.colorLevels, upOptions.levelPatterns, upOptions.maxPrefixWidth)
+	if err != nil {
+		return err
+	}
 
-	err := createOptions.Apply(project)
+	err = createOptions.Apply(project)
+	if err != nil {
+		return err
+	}
+	delta, err := scaleDelta(project, createOptions.scale)
+	if err != nil {
+		return err
+	}
+	schedule, err := parseScaleSchedule(project, upOptions.scaleSchedule)
 	if err != nil {
 		return err
 	}
@@ -271,21 +434,55 @@ func runUp(
 		build = &bo
 	}
 
+	containerCreated, closeCIDFile, err := withCIDFile(createOptions.cidFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// keep err as is, its type may carry the exit code
+		if closeErr := closeCIDFile(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
 	create := api.CreateOptions{
-		Build:                build,
-		Services:             services,
-		RemoveOrphans:        createOptions.removeOrphans,
-		IgnoreOrphans:        createOptions.ignoreOrphans,
-		Recreate:             createOptions.recreateStrategy(),
-		RecreateDependencies: createOptions.dependenciesRecreateStrategy(),
-		Inherit:              !createOptions.noInherit,
-		Timeout:              createOptions.GetTimeout(),
-		QuietPull:            createOptions.quietPull,
+		Build:                    build,
+		Services:                 services,
+		RemoveOrphans:            createOptions.removeOrphans,
+		IgnoreOrphans:            createOptions.ignoreOrphans,
+		Recreate:                 createOptions.recreateStrategy(),
+		RecreateDependencies:     createOptions.dependenciesRecreateStrategy(),
+		RecreateDependents:       createOptions.recreateDependents,
+		RecreateOrder:            createOptions.recreateOrder,
+		RestartExhausted:         createOptions.restartExhausted,
+		Inherit:                  !createOptions.noInherit,
+		Timeout:                  createOptions.GetTimeout(),
+		QuietPull:                createOptions.quietPull,
+		PipelinePull:             createOptions.pipelinePull,
+		MaxAge:                   createOptions.maxAge,
+		RecreateRestarting:       createOptions.recreateRestarting,
+		KeepScaledDown:           createOptions.keepScaledDown,
+		LazyResources:            createOptions.lazyResources,
+		ScaleDelta:               delta,
+		DuplicateNumbers:         createOptions.duplicateNumbers,
+		ResourcePreflight:        createOptions.resourcePreflight,
+		VerifyImages:             createOptions.verifyImages,
+		ScaleDownReferenced:      createOptions.scaleDownReferenced,
+		CreateHostPaths:          createOptions.createHostPaths,
+		SkipIPv6Check:            createOptions.skipIPv6Check,
+		SkipPlacementCheck:       createOptions.skipPlacementCheck,
+		ValidateReferences:       createOptions.validateReferences,
+		IgnoreHealthcheckChanges: createOptions.ignoreHealthcheck,
+		PreservePaused:           createOptions.preservePaused,
+		ContainerCreated:         containerCreated,
 	}
 
 	if createOptions.AssumeYes {
 		backendOptions.Options = append(backendOptions.Options, compose.WithPrompt(compose.AlwaysOkPrompt()))
 	}
+	if err := withImageVerifier(backendOptions, createOptions.verifyImages); err != nil {
+		return err
+	}
 
 	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
 	if err != nil {
@@ -299,7 +496,13 @@ func runUp(
 	var consumer api.LogConsumer
 	var attach []string
 	if !upOptions.Detach {
-		consumer = formatter.NewLogConsumer(ctx, dockerCli.Out(), dockerCli.Err(), !upOptions.noColor, !upOptions.noPrefix, upOptions.timestamp)
+		formatter.AssignColors(project.ServiceNames())
+		consumer = formatter.NewLogConsumer(ctx, dockerCli.Out(), dockerCli.Err(), !upOptions.noColor, !upOptions.noPrefix, upOptions.timestamp, consumerOptions...)
+		if upOptions.logFilter != "" {
+			filter := formatter.NewLogFilter(ctx, consumer, dockerCli.Err(), upOptions.logFilter)
+			defer filter.Close()
+			consumer = filter
+		}
 
 		var attachSet utils.Set[string]
 		if len(upOptions.attach) != 0 {
@@ -335,21 +538,147 @@ func runUp(
 	if upOptions.waitTimeout > 0 {
 		timeout = time.Duration(upOptions.waitTimeout) * time.Second
 	}
-	return backend.Up(ctx, project, api.UpOptions{
+	var (
+		report    func(api.UpReport)
+		reportErr error
+	)
+	if upOptions.report != "" {
+		report = func(r api.UpReport) {
+			reportErr = writeUpReport(upOptions.report, r)
+		}
+	}
+	var profile func(api.StartupProfile)
+	if upOptions.profileStartup {
+		profile = func(p api.StartupProfile) {
+			printStartupProfile(dockerCli.Err(), p)
+		}
+	}
+	err = backend.Up(ctx, project, api.UpOptions{
 		Create: create,
 		Start: api.StartOptions{
-			Project:        project,
-			Attach:         consumer,
-			AttachTo:       attach,
-			ExitCodeFrom:   upOptions.exitCodeFrom,
-			OnExit:         upOptions.OnExit(),
-			Wait:           upOptions.wait,
-			WaitTimeout:    timeout,
-			Watch:          upOptions.watch,
-			Services:       services,
-			NavigationMenu: upOptions.navigationMenu && display.Mode != "plain" && dockerCli.In().IsTerminal(),
+			Project:             project,
+			Attach:              consumer,
+			AttachTo:            attach,
+			ExitCodeFrom:        upOptions.exitCodeFrom,
+			OnExit:              upOptions.OnExit(),
+			ExitMode:            upOptions.exitMode,
+			Wait:                upOptions.wait,
+			WaitTimeout:         timeout,
+			SkipDependencyWaits: upOptions.skipHealthWaits,
+			EarlyExitWindow:     upOptions.checkEarlyExit,
+			RestartExhausted:    create.RestartExhausted,
+			KeepScaledDown:      create.KeepScaledDown,
+			Watch:               upOptions.watch,
+			Services:            services,
+			NavigationMenu:      upOptions.navigationMenu && display.Mode != "plain" && dockerCli.In().IsTerminal(),
 		},
+		Report:            report,
+		Profile:           profile,
+		ScaleSchedule:     schedule,
+		ReconcileInterval: upOptions.reconcileInterval,
+		DaemonWait:        upOptions.daemonWait,
+		Verify:            upOptions.endpointChecks(),
 	})
+	if reportErr != nil {
+		if err != nil {
+			logrus.Warnf("failed to write report: %v", reportErr)
+			return err
+		}
+		return fmt.Errorf("failed to write report: %w", reportErr)
+	}
+	return err
+}
+
+// endpointChecks returns the checks of the --verify URLs
+func (opts upOptions) endpointChecks() []api.EndpointCheck {
+	checks := make([]api.EndpointCheck, len(opts.verify))
+	for i, u := range opts.verify {
+		checks[i] = api.EndpointCheck{URL: u, Timeout: opts.verifyTimeout}
+	}
+	return checks
+}
+
+func writeUpReport(file string, report api.UpReport) error {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0o644)
+}
+
+// printStartupProfile prints the slowest services of the profile, with the
+// time they spent being created, waiting for their dependencies and being
+// started, then the critical path through their dependencies
+func printStartupProfile(out io.Writer, profile api.StartupProfile) {
+	const top = 10
+	ms := func(v int64) string {
+		return (time.Duration(v) * time.Millisecond).String()
+	}
+	w := tabwriter.NewWriter(out, 4, 1, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tCREATE\tWAIT\tSTART\tTOTAL")
+	for _, s := range profile.Services[:min(top, len(profile.Services))] {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, ms(s.Create), ms(s.Wait), ms(s.Start), ms(s.Create+s.Wait+s.Start))
+	}
+	_ = w.Flush()
+	if len(profile.CriticalPath) > 0 {
+		_, _ = fmt.Fprintln(out, "\nCritical path:")
+		w = tabwriter.NewWriter(out, 4, 1, 2, ' ', 0)
+		for _, step := range profile.CriticalPath {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t(%s)\n", step.Service, ms(step.Duration), ms(step.Cumulative))
+		}
+		_ = w.Flush()
+	}
+	if len(profile.Waits) > 0 {
+		// waits are sorted by start time, offsets are relative to the first one
+		origin := profile.Waits[0].Started
+		_, _ = fmt.Fprintln(out, "\nDependency waits:")
+		w = tabwriter.NewWriter(out, 4, 1, 2, ' ', 0)
+		for _, wait := range profile.Waits {
+			outcome := wait.Outcome
+			if wait.State != "" {
+				outcome = fmt.Sprintf("%s (%s)", outcome, wait.State)
+			}
+			_, _ = fmt.Fprintf(w, "  %s -> %s\t%s\t+%s\t%s\t%s\n", wait.Service, wait.Dependency, wait.Condition,
+				wait.Started.Sub(origin).Round(time.Millisecond), ms(wait.Duration), outcome)
+		}
+		_ = w.Flush()
+	}
+}
+
+// parseScaleSchedule parses the --scale-schedule values, each as
+// SERVICE=NUM@DURATION[,NUM@DURATION...] with increasing durations
+func parseScaleSchedule(project *types.Project, values []string) ([]api.ScaleStep, error) {
+	var steps []api.ScaleStep
+	for _, value := range values {
+		name, schedule, ok := strings.Cut(value, "=")
+		if !ok || name == "" || schedule == "" {
+			return nil, fmt.Errorf("invalid --scale-schedule %q. Should be SERVICE=NUM@DURATION[,NUM@DURATION...]", value)
+		}
+		if _, err := project.GetService(name); err != nil {
+			return nil, err
+		}
+		last := time.Duration(-1)
+		for step := range strings.SplitSeq(schedule, ",") {
+			replicas, at, ok := strings.Cut(step, "@")
+			if !ok {
+				return nil, fmt.Errorf("invalid --scale-schedule step %q for service %s. Should be NUM@DURATION", step, name)
+			}
+			scale, err := strconv.Atoi(replicas)
+			if err != nil || scale < 0 {
+				return nil, fmt.Errorf("invalid --scale-schedule step %q for service %s: %q isn't a number of replicas", step, name, replicas)
+			}
+			d, err := time.ParseDuration(at)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid --scale-schedule step %q for service %s: %q isn't a positive duration", step, name, at)
+			}
+			if d <= last {
+				return nil, fmt.Errorf("invalid --scale-schedule for service %s: step %q isn't scheduled after the previous one", name, step)
+			}
+			last = d
+			steps = append(steps, api.ScaleStep{Service: name, Scale: scale, At: d})
+		}
+	}
+	return steps, nil
 }
 
 func setServiceScale(project *types.Project, name string, replicas int) error {
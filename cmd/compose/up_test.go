@@ -25,6 +25,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
@@ -80,6 +81,70 @@ func TestApplyScaleOpt(t *testing.T) {
 	assert.Equal(t, *bar.Deploy.Replicas, 3)
 }
 
+func TestScaleDelta(t *testing.T) {
+	p := types.Project{
+		Services: types.Services{
+			"foo": {Name: "foo"},
+			"bar": {Name: "bar"},
+			"baz": {Name: "baz"},
+		},
+	}
+	opts := []string{"foo=+3", "bar=-2", "baz=4"}
+	err := applyScaleOpts(&p, opts)
+	assert.NilError(t, err)
+	foo, err := p.GetService("foo")
+	assert.NilError(t, err)
+	assert.Check(t, foo.Scale == nil)
+	baz, err := p.GetService("baz")
+	assert.NilError(t, err)
+	assert.Equal(t, *baz.Scale, 4)
+
+	delta, err := scaleDelta(&p, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, delta, map[string]int{"foo": 3, "bar": -2})
+
+	_, err = scaleDelta(&p, []string{"qux=+1"})
+	assert.ErrorContains(t, err, "qux")
+	_, err = scaleDelta(&p, []string{"foo=+"})
+	assert.ErrorContains(t, err, "invalid syntax")
+	_, err = scaleDelta(&p, []string{"foo"})
+	assert.ErrorContains(t, err, "Should be SERVICE=NUM, SERVICE=+NUM or SERVICE=-NUM")
+}
+
+func TestParseScaleSchedule(t *testing.T) {
+	p := &types.Project{
+		Services: types.Services{
+			"worker": {Name: "worker"},
+			"web":    {Name: "web"},
+		},
+	}
+	steps, err := parseScaleSchedule(p, []string{"worker=2@0s,10@2m,2@10m", "web=3@1m"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, steps, []api.ScaleStep{
+		{Service: "worker", Scale: 2, At: 0},
+		{Service: "worker", Scale: 10, At: 2 * time.Minute},
+		{Service: "worker", Scale: 2, At: 10 * time.Minute},
+		{Service: "web", Scale: 3, At: time.Minute},
+	})
+
+	for _, tt := range []struct {
+		value string
+		err   string
+	}{
+		{value: "worker", err: `invalid --scale-schedule "worker". Should be SERVICE=NUM@DURATION[,NUM@DURATION...]`},
+		{value: "queue=2@0s", err: `no such service: queue`},
+		{value: "worker=2", err: `invalid --scale-schedule step "2" for service worker. Should be NUM@DURATION`},
+		{value: "worker=-1@0s", err: `invalid --scale-schedule step "-1@0s" for service worker: "-1" isn't a number of replicas`},
+		{value: "worker=2@soon", err: `invalid --scale-schedule step "2@soon" for service worker: "soon" isn't a positive duration`},
+		{value: "worker=2@1m,4@1m", err: `invalid --scale-schedule for service worker: step "4@1m" isn't scheduled after the previous one`},
+	} {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := parseScaleSchedule(p, []string{tt.value})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
 func TestUpOptions_OnExit(t *testing.T) {
 	tests := []struct {
 		name string
@@ -119,6 +184,32 @@ func TestUpOptions_OnExit(t *testing.T) {
 	}
 }
 
+func TestUpOptions_SelectServices(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web": {
+				Name:      "web",
+				Labels:    types.Labels{"tier": "frontend"},
+				DependsOn: types.DependsOnConfig{"api": {Condition: types.ServiceConditionStarted, Required: true}},
+			},
+			"api":    {Name: "api", Labels: types.Labels{"tier": "backend"}},
+			"worker": {Name: "worker", Labels: types.Labels{"tier": "backend"}},
+		},
+	}
+
+	selected, services, err := upOptions{selector: "tier=frontend"}.selectServices(project, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, services, []string{"web"})
+	// dependencies are still converged
+	assert.DeepEqual(t, selected.ServiceNames(), []string{"api", "web"})
+
+	_, _, err = upOptions{selector: "tier=database"}.selectServices(project, nil)
+	assert.Error(t, err, `no service matches selector "tier=database"`)
+
+	_, _, err = upOptions{selector: "tier=frontend"}.selectServices(project, []string{"worker"})
+	assert.Error(t, err, "--selector can't be combined with service names")
+}
+
 func TestRunUpAllowsTemplatedPortFieldsInRemoteStackPrompt(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -179,3 +270,46 @@ services:
 	assert.Assert(t, strings.Contains(output, "LXKNS_PORT"), output)
 	assert.Assert(t, !strings.Contains(fmt.Sprint(err), "invalid ip address"), fmt.Sprint(err))
 }
+
+func TestPrintStartupProfile(t *testing.T) {
+	var out bytes.Buffer
+	printStartupProfile(&out, api.StartupProfile{
+		Services: []api.ServiceProfile{
+			{Name: "web", Create: 50, Wait: 1200, Start: 300},
+			{Name: "db", Create: 40, Start: 1500},
+		},
+		CriticalPath: []api.CriticalPathStep{
+			{Service: "db", Duration: 1500, Cumulative: 1500},
+			{Service: "web", Duration: 1500, Cumulative: 3000},
+		},
+	})
+	assert.Equal(t, out.String(), `SERVICE  CREATE  WAIT  START  TOTAL
+web      50ms    1.2s  300ms  1.55s
+db       40ms    0s    1.5s   1.54s
+
+Critical path:
+  db   1.5s  (1.5s)
+  web  1.5s  (3s)
+`)
+}
+
+func TestPrintStartupProfileWaits(t *testing.T) {
+	var out bytes.Buffer
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	printStartupProfile(&out, api.StartupProfile{
+		Services: []api.ServiceProfile{
+			{Name: "web", Create: 50, Wait: 1200, Start: 300},
+		},
+		Waits: []api.DependencyWait{
+			{Service: "web", Dependency: "db", Condition: "service_healthy", Started: origin, Duration: 1200, Outcome: api.DependencyWaitSatisfied},
+			{Service: "web", Dependency: "cache", Condition: "service_healthy", Started: origin.Add(5 * time.Millisecond), Duration: 30000, Outcome: api.DependencyWaitTimeout, State: "cache-1: running (health: starting)"},
+		},
+	})
+	assert.Equal(t, out.String(), `SERVICE  CREATE  WAIT  START  TOTAL
+web      50ms    1.2s  300ms  1.55s
+
+Dependency waits:
+  web -> db     service_healthy  +0s   1.2s  satisfied
+  web -> cache  service_healthy  +5ms  30s   timeout (cache-1: running (health: starting))
+`)
+}
@@ -52,10 +52,37 @@ func volumesCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *Ba
 
 	cmd.Flags().BoolVarP(&options.Quiet, "quiet", "q", false, "Only display volume names")
 	cmd.Flags().StringVar(&options.Format, "format", "table", flags.FormatHelp)
+	cmd.AddCommand(volumesProtectCommand(p, dockerCli, backendOptions))
 
 	return cmd
 }
 
+func volumesProtectCommand(p *ProjectOptions, dockerCli command.Cli, backendOptions *BackendOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "protect VOLUME...",
+		Short: "Protect volumes from being removed by down --volumes",
+		Long: "Protect volumes from being removed by down --volumes, unless run with --force-volumes.\n" +
+			"The volumes not created yet are created with the " + api.VolumeProtectLabel + " label. As the engine can't change " +
+			"the labels of an existing volume, volumes already created are recorded as protected in the docker CLI configuration directory.",
+		Args: cobra.MinimumNArgs(1),
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runVolumesProtect(ctx, dockerCli, backendOptions, p, args)
+		}),
+	}
+}
+
+func runVolumesProtect(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, p *ProjectOptions, volumes []string) error {
+	backend, err := compose.NewComposeService(dockerCli, backendOptions.Options...)
+	if err != nil {
+		return err
+	}
+	project, _, err := p.ToProject(ctx, dockerCli, backend, nil)
+	if err != nil {
+		return err
+	}
+	return backend.ProtectVolumes(ctx, project, api.ProtectVolumesOptions{Volumes: volumes})
+}
+
 func runVol(ctx context.Context, dockerCli command.Cli, backendOptions *BackendOptions, services []string, options volumesOptions) error {
 	project, name, err := options.projectOrName(ctx, dockerCli, services...)
 	if err != nil {
@@ -30,10 +30,11 @@ var (
 	DoneColor    colorFunc = aec.BlueF.Apply
 	TimerColor   colorFunc = aec.BlueF.Apply
 	CountColor   colorFunc = aec.YellowF.Apply
-	WarningColor colorFunc = aec.YellowF.With(aec.Bold).Apply
+	WarningColor colorFunc = aec.YellowF.Apply
 	SuccessColor colorFunc = aec.GreenF.Apply
 	ErrorColor   colorFunc = aec.RedF.With(aec.Bold).Apply
 	PrefixColor  colorFunc = aec.CyanF.Apply
+	FaintColor   colorFunc = aec.Faint.Apply
 )
 
 func NoColor() {
@@ -44,4 +45,5 @@ func NoColor() {
 	SuccessColor = nocolor
 	ErrorColor = nocolor
 	PrefixColor = nocolor
+	FaintColor = nocolor
 }
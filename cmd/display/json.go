@@ -21,10 +21,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
 
+// JSON returns an event processor printing events to out as JSON lines. It is
+// safe for concurrent use, each event being written to out at once.
 func JSON(out io.Writer) api.EventProcessor {
 	return &jsonWriter{
 		out: out,
@@ -32,6 +35,7 @@ func JSON(out io.Writer) api.EventProcessor {
 }
 
 type jsonWriter struct {
+	mtx    sync.Mutex
 	out    io.Writer
 	dryRun bool
 }
@@ -42,11 +46,13 @@ type jsonMessage struct {
 	ID       string `json:"id,omitempty"`
 	ParentID string `json:"parent_id,omitempty"`
 	Status   string `json:"status,omitempty"`
+	Level    string `json:"level,omitempty"`
 	Text     string `json:"text,omitempty"`
 	Details  string `json:"details,omitempty"`
 	Current  int64  `json:"current,omitempty"`
 	Total    int64  `json:"total,omitempty"`
 	Percent  int    `json:"percent,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
 }
 
 func (p *jsonWriter) Start(ctx context.Context, operation string) {
@@ -58,12 +64,14 @@ func (p *jsonWriter) Event(e api.Resource) {
 		Tail:     false,
 		ID:       e.ID,
 		Status:   e.StatusText(),
+		Level:    level(e.Status),
 		Text:     e.Text,
 		Details:  e.Details,
 		ParentID: e.ParentID,
 		Current:  e.Current,
 		Total:    e.Total,
 		Percent:  e.Percent,
+		Optional: e.Optional,
 	}
 	marshal, err := json.Marshal(message)
 	if err == nil {
@@ -71,7 +79,22 @@ func (p *jsonWriter) Event(e api.Resource) {
 	}
 }
 
+// level maps the status of an event to a log level, so warnings can be told
+// apart from the progress of regular tasks.
+func level(status api.EventStatus) string {
+	switch status {
+	case api.Error:
+		return "error"
+	case api.Warning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
 func (p *jsonWriter) On(events ...api.Resource) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
 	for _, e := range events {
 		p.Event(e)
 	}
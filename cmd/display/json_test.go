@@ -54,9 +54,22 @@ func TestJsonWriter_Event(t *testing.T) {
 		ParentID: event.ParentID,
 		Text:     api.StatusCreating,
 		Status:   "Working",
+		Level:    "info",
 		Current:  event.Current,
 		Total:    event.Total,
 		Percent:  event.Percent,
 	}
 	assert.DeepEqual(t, expected, actual)
 }
+
+func TestJsonWriter_WarningLevel(t *testing.T) {
+	var out bytes.Buffer
+	w := &jsonWriter{out: &out}
+	w.Event(api.Resource{ID: "web", Status: api.Warning, Text: "Your kernel does not support swap limit capabilities"})
+
+	var actual jsonMessage
+	err := json.Unmarshal(out.Bytes(), &actual)
+	assert.NilError(t, err)
+	assert.Equal(t, actual.Status, "Warning")
+	assert.Equal(t, actual.Level, "warning")
+}
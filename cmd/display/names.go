@@ -0,0 +1,50 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package display
+
+import (
+	"strings"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// projectPrefix is stripped from the names of the containers displayed by the
+// tty and plain progress, set by ShortNames
+var projectPrefix string
+
+// ShortNames makes the tty and plain progress display the containers of
+// project without the project prefix, as web-1 rather than myproject-web-1.
+// The JSON progress, meant for machines, keeps the full names.
+func ShortNames(project string) {
+	projectPrefix = project + api.Separator
+}
+
+// displayID returns the ID of a resource as displayed to users
+func displayID(id string) string {
+	if projectPrefix == "" {
+		return id
+	}
+	name, ok := strings.CutPrefix(id, "Container ")
+	if !ok {
+		return id
+	}
+	short, ok := strings.CutPrefix(name, projectPrefix)
+	if !ok || short == "" {
+		return id
+	}
+	return "Container " + short
+}
@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
 
+// Plain returns an event processor printing events to out as plain lines of
+// text. It is safe for concurrent use, each event being written to out at
+// once.
 func Plain(out io.Writer) api.EventProcessor {
 	return &plainWriter{
 		out: out,
@@ -31,8 +35,10 @@ func Plain(out io.Writer) api.EventProcessor {
 }
 
 type plainWriter struct {
-	out    io.Writer
-	dryRun bool
+	mtx      sync.Mutex
+	out      io.Writer
+	dryRun   bool
+	warnings warnings
 }
 
 func (p *plainWriter) Start(ctx context.Context, operation string) {
@@ -43,14 +49,30 @@ func (p *plainWriter) Event(e api.Resource) {
 	if p.dryRun {
 		prefix = DRYRUN_PREFIX
 	}
-	_, _ = fmt.Fprintln(p.out, prefix, e.ID, e.Text, e.Details)
+	if e.Optional {
+		_, _ = fmt.Fprintln(p.out, prefix, displayID(e.ID), e.Text, e.Details, "optional=true")
+		return
+	}
+	if e.Status == api.Warning {
+		// repeated warnings are only counted, to be listed once by Done
+		if p.warnings.add(e) == 1 {
+			_, _ = fmt.Fprintln(p.out, prefix, displayID(e.ID), e.Text, e.Details, "level=warning")
+		}
+		return
+	}
+	_, _ = fmt.Fprintln(p.out, prefix, displayID(e.ID), e.Text, e.Details)
 }
 
 func (p *plainWriter) On(events ...api.Resource) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
 	for _, e := range events {
 		p.Event(e)
 	}
 }
 
 func (p *plainWriter) Done(_ string, _ bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.warnings.print(p.out, nocolor)
 }
@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestPlainWriterOptional(t *testing.T) {
+	var out bytes.Buffer
+	w := Plain(&out)
+	w.On(
+		api.Resource{ID: "Container db-1", Status: api.Working, Text: api.StatusWaiting, Optional: true},
+		api.Resource{ID: "Container cache-1", Status: api.Working, Text: api.StatusWaiting},
+	)
+	assert.Equal(t, out.String(), " Container db-1 Waiting  optional=true\n Container cache-1 Waiting \n")
+}
+
+func TestPlainWriterWarnings(t *testing.T) {
+	var out bytes.Buffer
+	w := Plain(&out)
+	w.On(
+		api.Resource{ID: "web", Status: api.Warning, Text: "swap limit discarded"},
+		api.Resource{ID: "web", Status: api.Warning, Text: "swap limit discarded"},
+		api.Resource{ID: "db", Status: api.Warning, Text: "swap limit discarded"},
+		api.Resource{ID: "Container web-1", Status: api.Done, Text: api.StatusCreated},
+	)
+	w.Done("up", true)
+	assert.Equal(t, out.String(), ` web swap limit discarded  level=warning
+ db swap limit discarded  level=warning
+ Container web-1 Created 
+Warnings (2)
+ ! web: swap limit discarded (x2)
+ ! db: swap limit discarded
+`)
+}
+
+func TestPlainWriterShortNames(t *testing.T) {
+	ShortNames("myproject")
+	t.Cleanup(func() { projectPrefix = "" })
+
+	var out bytes.Buffer
+	w := Plain(&out)
+	w.On(
+		api.Resource{ID: "Container myproject-web-1", Status: api.Done, Text: api.StatusCreated},
+		api.Resource{ID: "Container other-web-1", Status: api.Done, Text: api.StatusCreated},
+		api.Resource{ID: "Network myproject_default", Status: api.Done, Text: api.StatusCreated},
+	)
+	assert.Equal(t, out.String(), ` Container web-1 Created 
+ Container other-web-1 Created 
+ Network myproject_default Created 
+`)
+
+	// machine output keeps the full names
+	out.Reset()
+	JSON(&out).On(api.Resource{ID: "Container myproject-web-1", Status: api.Done, Text: api.StatusCreated})
+	assert.Assert(t, strings.Contains(out.String(), `"id":"Container myproject-web-1"`))
+}
@@ -0,0 +1,41 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package display
+
+import (
+	"io"
+	"sync"
+)
+
+// NewSynchronizedWriter returns a writer serializing the writes to w. The
+// event processors of this package serialize their own writes, but w needs
+// to be wrapped when it is also written to by other goroutines, as a buffer
+// an application shares between the progress of Compose and its own output.
+func NewSynchronizedWriter(w io.Writer) io.Writer {
+	return &synchronizedWriter{out: w}
+}
+
+type synchronizedWriter struct {
+	mtx sync.Mutex
+	out io.Writer
+}
+
+func (w *synchronizedWriter) Write(p []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.out.Write(p)
+}
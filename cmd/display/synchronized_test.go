@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// TestConcurrentEvents notifies events from many goroutines, as convergence
+// does, while they also write to the same buffer. Run with -race.
+func TestConcurrentEvents(t *testing.T) {
+	const goroutines = 50
+	const events = 20
+	processors := map[string]func(io.Writer) api.EventProcessor{
+		"plain": Plain,
+		"json":  JSON,
+	}
+	for name, processor := range processors {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := NewSynchronizedWriter(&buf)
+			w := processor(out)
+			w.Start(t.Context(), "up")
+
+			var wg sync.WaitGroup
+			for i := range goroutines {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := range events {
+						w.On(api.Resource{ID: fmt.Sprintf("Container web-%d", i), Status: api.Working, Text: api.StatusCreating})
+						w.On(api.Resource{ID: fmt.Sprintf("Container web-%d", i), Status: api.Warning, Text: "swap limit discarded"})
+						_, _ = fmt.Fprintf(out, "log %d %d\n", i, j)
+					}
+				}()
+			}
+			wg.Wait()
+			w.Done("up", true)
+
+			var logs int
+			for line := range strings.Lines(buf.String()) {
+				if strings.HasPrefix(line, "log ") {
+					logs++
+					continue
+				}
+				assert.Check(t, strings.Contains(line, "web-") || strings.HasPrefix(line, "Warnings ("), "garbled line %q", line)
+			}
+			assert.Equal(t, logs, goroutines*events)
+		})
+	}
+}
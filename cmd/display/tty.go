@@ -62,6 +62,10 @@ type ttyWriter struct {
 	suspended bool
 	info      io.Writer
 	detached  bool
+	// skipped keeps the reason optional dependencies were skipped, so it
+	// remains visible once the progress UI is done
+	skipped  []string
+	warnings warnings
 }
 
 type task struct {
@@ -76,6 +80,7 @@ type task struct {
 	current   int64
 	percent   int
 	total     int64
+	optional  bool
 	spinner   *Spinner
 }
 
@@ -90,6 +95,7 @@ func newTask(e api.Resource) task {
 		current:   e.Current,
 		percent:   e.Percent,
 		total:     e.Total,
+		optional:  e.Optional,
 		spinner:   NewSpinner(),
 	}
 	if e.ParentID != "" {
@@ -125,6 +131,7 @@ func (t *task) update(e api.Resource) {
 	t.status = e.Status
 	t.text = e.Text
 	t.details = e.Details
+	t.optional = e.Optional
 	// progress can only go up
 	if e.Total > t.total {
 		t.total = e.Total
@@ -183,6 +190,11 @@ func (w *ttyWriter) Done(operation string, success bool) {
 		w.ticker.Stop()
 	}
 	w.operation = ""
+	for _, skipped := range w.skipped {
+		_, _ = fmt.Fprintf(w.out, " %s %s\n", WarningColor(spinnerWarning), skipped)
+	}
+	w.skipped = nil
+	w.warnings.print(w.out, WarningColor)
 }
 
 func (w *ttyWriter) On(events ...api.Resource) {
@@ -214,6 +226,13 @@ func (w *ttyWriter) event(e api.Resource) {
 		}
 	}
 
+	if e.Optional && e.Status == api.Warning {
+		w.skipped = append(w.skipped, fmt.Sprintf("%s %s", displayID(e.ID), e.Text))
+	}
+	if count := w.warnings.add(e); count > 1 {
+		e.Text = withCount(e.Text, count)
+	}
+
 	if last, ok := w.tasks[e.ID]; ok {
 		last.update(e)
 	} else {
@@ -241,7 +260,11 @@ func (w *ttyWriter) printEvent(e api.Resource) {
 	case api.Error:
 		color = ErrorColor
 	}
-	_, _ = fmt.Fprintf(w.out, "%s %s %s\n", e.ID, color(e.Text), e.Details)
+	text := color(e.Text)
+	if e.Optional {
+		text = FaintColor(color("(optional) " + e.Text))
+	}
+	_, _ = fmt.Fprintf(w.out, "%s %s %s\n", displayID(e.ID), text, e.Details)
 }
 
 func (w *ttyWriter) parentTasks() iter.Seq[*task] {
@@ -597,14 +620,22 @@ func (w *ttyWriter) prepareLineData(t *task) lineData {
 		}
 	}
 
+	status, statusColor := t.text, colorFn(t.status)
+	if t.optional {
+		status = "(optional) " + status
+		statusColor = func(s string) string {
+			return FaintColor(colorFn(t.status)(s))
+		}
+	}
+
 	return lineData{
 		spinner:           spinner(t),
 		prefix:            prefix,
-		taskID:            t.ID,
+		taskID:            displayID(t.ID),
 		progress:          progress,
 		progressSizeBytes: progressSizeBytes,
-		status:            t.text,
-		statusColor:       colorFn(t.status),
+		status:            status,
+		statusColor:       statusColor,
 		details:           t.details,
 		timer:             fmt.Sprintf("%.1fs", elapsed),
 	}
@@ -668,3 +668,58 @@ func TestPrintWithDimensions_MultipleRendersFit(t *testing.T) {
 		}
 	}
 }
+
+func TestOptionalDependencyRendering(t *testing.T) {
+	w, buf := newTestWriter()
+	w.On(api.Resource{ID: "Container db-1", Status: api.Working, Text: api.StatusWaiting, Optional: true})
+	w.On(api.Resource{ID: "Container cache-1", Status: api.Working, Text: api.StatusWaiting})
+
+	w.printWithDimensions(80, 24)
+	var found bool
+	for _, line := range extractLines(buf) {
+		switch {
+		case strings.Contains(line, "db-1"):
+			found = true
+			assert.Assert(t, strings.Contains(line, FaintColor("(optional) Waiting")), "optional status should be faint: %q", line)
+		case strings.Contains(line, "cache-1"):
+			assert.Assert(t, !strings.Contains(line, "(optional)"), line)
+		}
+	}
+	assert.Assert(t, found)
+}
+
+func TestOptionalDependencySkipSummary(t *testing.T) {
+	w, buf := newTestWriter()
+	w.On(api.Resource{ID: "Container db-1", Status: api.Warning, Text: `Skipped: optional dependency "db" failed to start`, Optional: true})
+	// a later event for the same container replaces the skip in the task list
+	w.On(api.Resource{ID: "Container db-1", Status: api.Done, Text: api.StatusStopped})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w.Start(ctx, "up")
+	w.Done("up", true)
+
+	lines := extractLines(buf)
+	assert.Assert(t, strings.Contains(lines[len(lines)-1], `Container db-1 Skipped: optional dependency "db" failed to start`), lines[len(lines)-1])
+}
+
+func TestWarningsSummary(t *testing.T) {
+	w, buf := newTestWriter()
+	for range 3 {
+		w.On(api.Resource{ID: "web", Status: api.Warning, Text: "swap limit discarded"})
+	}
+	w.On(api.Resource{ID: "db", Status: api.Warning, Text: "memory swappiness discarded"})
+	assert.Equal(t, w.tasks["web"].text, "swap limit discarded (x3)")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	w.Start(ctx, "up")
+	w.Done("up", true)
+
+	lines := extractLines(buf)
+	assert.Assert(t, strings.HasSuffix(lines[len(lines)-3], WarningColor("Warnings (2)")), lines[len(lines)-3])
+	assert.DeepEqual(t, lines[len(lines)-2:], []string{
+		fmt.Sprintf(" %s web: %s", WarningColor("!"), WarningColor("swap limit discarded (x3)")),
+		fmt.Sprintf(" %s db: %s", WarningColor("!"), WarningColor("memory swappiness discarded")),
+	})
+}
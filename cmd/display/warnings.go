@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package display
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// warnings collects the warning events received during an operation, so
+// repeated identical ones are reported once and all of them are listed again
+// when the operation is done, where they can't be missed.
+type warnings struct {
+	entries []*warning
+}
+
+type warning struct {
+	id    string
+	text  string
+	count int
+}
+
+// add records a warning event and returns how many times it was received.
+// Optional dependencies are reported separately and not recorded.
+func (w *warnings) add(e api.Resource) int {
+	if e.Status != api.Warning || e.Optional {
+		return 0
+	}
+	for _, entry := range w.entries {
+		if entry.id == e.ID && entry.text == e.Text {
+			entry.count++
+			return entry.count
+		}
+	}
+	w.entries = append(w.entries, &warning{id: e.ID, text: e.Text, count: 1})
+	return 1
+}
+
+// print renders the summary of the recorded warnings and resets them.
+func (w *warnings) print(out io.Writer, color colorFunc) {
+	if len(w.entries) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(out, color(fmt.Sprintf("Warnings (%d)", len(w.entries))))
+	for _, entry := range w.entries {
+		_, _ = fmt.Fprintf(out, " %s %s: %s\n", color(spinnerWarning), displayID(entry.id), color(withCount(entry.text, entry.count)))
+	}
+	w.entries = nil
+}
+
+// withCount appends the number of occurrences to a repeated warning.
+func withCount(text string, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%s (x%d)", text, count)
+	}
+	return text
+}
@@ -18,6 +18,7 @@ package formatter
 
 import (
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/acarl005/stripansi"
 	"github.com/morikuni/aec"
@@ -90,9 +91,10 @@ func newLine() {
 	fmt.Print("\012")
 }
 
-// lenAnsi returns the visible length of s after stripping ANSI escape codes.
+// lenAnsi returns the visible length of s after stripping ANSI escape codes,
+// in characters.
 func lenAnsi(s string) int {
-	return len(stripansi.Strip(s))
+	return utf8.RuneCountInString(stripansi.Strip(s))
 }
 
 // OSC8Link wraps text in an OSC 8 terminal hyperlink escape sequence with
@@ -18,6 +18,7 @@ package formatter
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -65,8 +66,8 @@ const ansiColorOffset = 30
 // SetANSIMode configure formatter for colored output on ANSI-compliant console
 func SetANSIMode(streams command.Streams, ansi string) {
 	if !useAnsi(streams, ansi) {
-		nextColor = func() colorFunc {
-			return monochrome
+		nextColor = func() string {
+			return ""
 		}
 		disableAnsi = true
 	}
@@ -106,20 +107,94 @@ func ansiColorCode(code string, formatOpts ...string) string {
 	return sb.String()
 }
 
+// makeColorFunc returns the colorFunc rendering text with the color code,
+// monochrome for an empty one
 func makeColorFunc(code string) colorFunc {
+	if code == "" {
+		return monochrome
+	}
 	return func(s string) string {
 		return ansiColor(code, s)
 	}
 }
 
+// Highlight renders text in bold cyan, to draw attention to it, unless ANSI
+// output is disabled
+func Highlight(s string) string {
+	if disableAnsi {
+		return s
+	}
+	return ansiColor(CYAN, s, BOLD)
+}
+
+// levelFormats are the format codes log lines are rendered with by level,
+// from faint debug lines to bold errors
+var levelFormats = map[LogLevel][]string{
+	LogLevelTrace: {FAINT},
+	LogLevelDebug: {FAINT},
+	LogLevelInfo:  nil,
+	LogLevelWarn:  nil,
+	LogLevelError: {BOLD},
+	LogLevelFatal: {BOLD, UNDERLINE},
+}
+
+// colorLevel renders a log line in the color code of its service, with the
+// intensity of its log level. Lines of an unknown level are left as is.
+func colorLevel(code string, level LogLevel, line string) string {
+	formats, ok := levelFormats[level]
+	if !ok || disableAnsi {
+		return line
+	}
+	if code == "" {
+		if len(formats) == 0 {
+			return line
+		}
+		code = "39" // default foreground color
+	}
+	return ansiColor(code, line, formats...)
+}
+
 var (
 	nextColor    = rainbowColor
-	rainbow      []colorFunc
+	rainbow      []string
 	currentIndex = 0
 	mutex        sync.Mutex
 )
 
-func rainbowColor() colorFunc {
+// assignedColors holds the color codes set by AssignColors, by service name
+var assignedColors = map[string]string{}
+
+// AssignColors sets the colors the containers of the services are rendered
+// with, so each service gets the same color on every run rather than the next
+// one of the rainbow when its first container logs. The services are sorted
+// and given the colors of the rainbow in turn, which alternates hues so
+// services next to each other are told apart. Services which aren't assigned
+// a color still get the next one of the rainbow.
+func AssignColors(serviceNames []string) {
+	names := slices.Clone(serviceNames)
+	slices.Sort(names)
+	mutex.Lock()
+	defer mutex.Unlock()
+	assignedColors = map[string]string{}
+	for i, name := range slices.Compact(names) {
+		assignedColors[name] = rainbow[i%len(rainbow)]
+	}
+}
+
+// containerColor returns the color code of a container, the one assigned to
+// its service or else the next one of the rainbow
+func containerColor(container string) string {
+	mutex.Lock()
+	code, ok := lookupService(assignedColors, container)
+	mutex.Unlock()
+	if ok && !disableAnsi {
+		return code
+	}
+	return nextColor()
+}
+
+// rainbowColor returns the next color code of the rainbow
+func rainbowColor() string {
 	mutex.Lock()
 	defer mutex.Unlock()
 	result := rainbow[currentIndex]
@@ -128,12 +203,12 @@ func rainbowColor() colorFunc {
 }
 
 func init() {
-	colors := map[string]colorFunc{}
+	colors := map[string]string{}
 	for i, name := range names {
-		colors[name] = makeColorFunc(strconv.Itoa(ansiColorOffset + i))
-		colors["intense_"+name] = makeColorFunc(strconv.Itoa(ansiColorOffset+i) + ";1")
+		colors[name] = strconv.Itoa(ansiColorOffset + i)
+		colors["intense_"+name] = strconv.Itoa(ansiColorOffset+i) + ";1"
 	}
-	rainbow = []colorFunc{
+	rainbow = []string{
 		colors["cyan"],
 		colors["yellow"],
 		colors["green"],
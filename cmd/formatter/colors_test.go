@@ -0,0 +1,56 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAssignColors(t *testing.T) {
+	disableAnsi = false
+	t.Cleanup(func() {
+		disableAnsi = false
+		AssignColors(nil)
+	})
+
+	AssignColors([]string{"web", "db", "cache", "web"})
+	assert.DeepEqual(t, assignedColors, map[string]string{
+		"cache": rainbow[0],
+		"db":    rainbow[1],
+		"web":   rainbow[2],
+	})
+
+	// the replicas of a service share its color
+	assert.Equal(t, containerColor("web-1"), rainbow[2])
+	assert.Equal(t, containerColor("web-2"), rainbow[2])
+	assert.Equal(t, containerColor("db"), rainbow[1])
+	assert.Equal(t, containerColor("db-1 ->"), rainbow[1])
+
+	// services which aren't assigned a color get the next one of the rainbow
+	nextColor = func() string { return "92" }
+	t.Cleanup(func() { nextColor = rainbowColor })
+	assert.Equal(t, containerColor("other-1"), "92")
+
+	disableAnsi = true
+	assert.Equal(t, containerColor("web-1"), "92")
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, Highlight("myproject-web-1"), "\033[1;36mmyproject-web-1\033[0m")
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LogLevel is the severity of a log line, as detected from its content
+type LogLevel int
+
+const (
+	LogLevelUnknown LogLevel = iota
+	LogLevelTrace
+	LogLevelDebug
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelFatal
+)
+
+// DefaultLogLevelPattern detects the level of the log lines written by most
+// logging libraries, the first level name found in a line being its level
+const DefaultLogLevelPattern = `(?i)\b(trace|debug|info|notice|warn|warning|error|err|fatal|critical|crit|panic)\b`
+
+var defaultLogLevelPattern = regexp.MustCompile(DefaultLogLevelPattern)
+
+// ParseLogLevel returns the level a level name stands for, LogLevelUnknown
+// when not recognized
+func ParseLogLevel(name string) LogLevel {
+	switch strings.ToLower(name) {
+	case "trace":
+		return LogLevelTrace
+	case "debug", "dbg":
+		return LogLevelDebug
+	case "info", "note", "notice":
+		return LogLevelInfo
+	case "warn", "warning":
+		return LogLevelWarn
+	case "error", "err":
+		return LogLevelError
+	case "fatal", "critical", "crit", "panic":
+		return LogLevelFatal
+	default:
+		return LogLevelUnknown
+	}
+}
+
+// LevelDetector detects the log level of the lines of the services logs
+type LevelDetector struct {
+	patterns map[string]*regexp.Regexp
+}
+
+// NewLevelDetector creates a LevelDetector using DefaultLogLevelPattern, or
+// the regular expression set in patterns for the service. The level name is
+// captured by the first group of the expression, or is the whole match when
+// it has none.
+func NewLevelDetector(patterns map[string]string) (*LevelDetector, error) {
+	d := &LevelDetector{patterns: map[string]*regexp.Regexp{}}
+	for service, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level pattern for service %q: %w", service, err)
+		}
+		d.patterns[service] = re
+	}
+	return d, nil
+}
+
+// Detect returns the log level of a line logged by container, whose pattern
+// is looked up by its service name, the container name without its replica
+// number suffix
+func (d *LevelDetector) Detect(container, line string) LogLevel {
+	match := d.pattern(container).FindStringSubmatch(line)
+	switch len(match) {
+	case 0:
+		return LogLevelUnknown
+	case 1:
+		return ParseLogLevel(match[0])
+	default:
+		return ParseLogLevel(match[1])
+	}
+}
+
+func (d *LevelDetector) pattern(container string) *regexp.Regexp {
+	if re, ok := lookupService(d.patterns, container); ok {
+		return re
+	}
+	return defaultLogLevelPattern
+}
+
+// lookupService returns the value set in m for the service of a container,
+// keyed by the container name or its service name, the container name without
+// its replica number suffix
+func lookupService[T any](m map[string]T, container string) (T, bool) {
+	// lines of hooks are logged by "<container> ->"
+	container, _, _ = strings.Cut(container, " ")
+	if v, ok := m[container]; ok {
+		return v, true
+	}
+	if i := strings.LastIndex(container, "-"); i > 0 {
+		if _, err := strconv.Atoi(container[i+1:]); err == nil {
+			v, ok := m[container[:i]]
+			return v, ok
+		}
+	}
+	var zero T
+	return zero, false
+}
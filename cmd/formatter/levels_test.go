@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestLevelDetector(t *testing.T) {
+	d, err := NewLevelDetector(map[string]string{"db": `^\S+ \[(\w+)\]`})
+	assert.NilError(t, err)
+
+	tests := []struct {
+		container string
+		line      string
+		want      LogLevel
+	}{
+		{container: "web-1", line: "DEBUG connecting to db", want: LogLevelDebug},
+		{container: "web-1", line: `time=now level=warning msg="slow request"`, want: LogLevelWarn},
+		{container: "web-1", line: "[INFO] request failed with error 500", want: LogLevelInfo},
+		{container: "web-1", line: "listening on :8080", want: LogLevelUnknown},
+		{container: "web-1 ->", line: "ERROR hook failed", want: LogLevelError},
+		{container: "db-2", line: "12:00:00 [Note] debug logging is disabled", want: LogLevelInfo},
+		{container: "db-2", line: "12:00:00 [Error] table is corrupted", want: LogLevelError},
+		{container: "db-2", line: "DEBUG not at the expected position", want: LogLevelUnknown},
+		{container: "mydb", line: "DEBUG container_name doesn't match the service", want: LogLevelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, d.Detect(tt.container, tt.line), tt.want)
+		})
+	}
+
+	_, err = NewLevelDetector(map[string]string{"db": `[`})
+	assert.ErrorContains(t, err, `invalid log level pattern for service "db"`)
+}
+
+func TestColorLevel(t *testing.T) {
+	disableAnsi = false
+	t.Cleanup(func() { disableAnsi = false })
+
+	assert.Equal(t, colorLevel("36", LogLevelDebug, "debug"), "\x1b[2;36mdebug\x1b[0m")
+	assert.Equal(t, colorLevel("36", LogLevelInfo, "info"), "\x1b[36minfo\x1b[0m")
+	assert.Equal(t, colorLevel("36", LogLevelError, "error"), "\x1b[1;36merror\x1b[0m")
+	assert.Equal(t, colorLevel("36", LogLevelUnknown, "unknown"), "unknown")
+	assert.Equal(t, colorLevel("", LogLevelInfo, "info"), "info")
+	assert.Equal(t, colorLevel("", LogLevelFatal, "fatal"), "\x1b[1;4;39mfatal\x1b[0m")
+
+	disableAnsi = true
+	assert.Equal(t, colorLevel("36", LogLevelError, "error"), "error")
+}
+
+func TestLogConsumerLevels(t *testing.T) {
+	disableAnsi = false
+	t.Cleanup(func() { disableAnsi = false })
+	d, err := NewLevelDetector(nil)
+	assert.NilError(t, err)
+
+	var out bytes.Buffer
+	consumer := NewLogConsumer(t.Context(), &out, &out, true, false, false, WithLevelDetector(d))
+	p := consumer.(*logConsumer).register("web-1")
+	consumer.Log("web-1", "DEBUG starting\nready")
+	assert.Equal(t, out.String(), colorLevel(p.color, LogLevelDebug, "DEBUG starting")+"\nready\n")
+
+	out.Reset()
+	consumer = NewLogConsumer(t.Context(), &out, &out, false, false, false, WithLevelDetector(d))
+	consumer.Log("web-1", "DEBUG starting")
+	assert.Equal(t, out.String(), "DEBUG starting\n")
+}
@@ -0,0 +1,169 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// LogFilter is a LogConsumer piping the log lines of each container through
+// its own process running a user command, and passing the lines the process
+// outputs to the decorated LogConsumer, which renders them with the prefix and
+// color of the container. The command must flush each line it outputs (e.g.
+// grep --line-buffered) for logs to be rendered as they come. When the process
+// fails, the filter is disabled for the container with a warning, and its log
+// lines are passed as is.
+type LogFilter struct {
+	ctx       context.Context
+	decorated api.LogConsumer
+	stderr    io.Writer
+	command   string
+
+	mu        sync.Mutex
+	processes map[string]*filterProcess
+	wg        sync.WaitGroup
+}
+
+type filterProcess struct {
+	stdin io.WriteCloser
+	// disabled is set once the process failed, or exited
+	disabled bool
+}
+
+var _ api.LogConsumer = &LogFilter{}
+
+// NewLogFilter creates a LogFilter running command to filter the log lines
+// passed to consumer. The processes output to stderr is written to stderr.
+func NewLogFilter(ctx context.Context, consumer api.LogConsumer, stderr io.Writer, command string) *LogFilter {
+	return &LogFilter{
+		ctx:       ctx,
+		decorated: consumer,
+		stderr:    stderr,
+		command:   command,
+		processes: map[string]*filterProcess{},
+	}
+}
+
+// Log pipes the log lines of container through its filter process
+func (l *LogFilter) Log(container, message string) {
+	for line := range strings.SplitSeq(message, "\n") {
+		if !l.filter(container, line) {
+			l.decorated.Log(container, line)
+		}
+	}
+}
+
+// Err passes the error message as is
+func (l *LogFilter) Err(container, message string) {
+	l.decorated.Err(container, message)
+}
+
+// Status passes the status message as is
+func (l *LogFilter) Status(container, msg string) {
+	l.decorated.Status(container, msg)
+}
+
+// Close closes the input of the filter processes, and waits for the lines they
+// still output to be logged
+func (l *LogFilter) Close() {
+	l.mu.Lock()
+	for _, p := range l.processes {
+		_ = p.stdin.Close()
+	}
+	l.mu.Unlock()
+	l.wg.Wait()
+}
+
+// filter writes line to the filter process of container, started on first
+// use, and returns false when the filter is disabled
+func (l *LogFilter) filter(container, line string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.processes[container]
+	if !ok {
+		var err error
+		p, err = l.start(container)
+		if err != nil {
+			logrus.Warnf("failed to start log filter for %s, logs are not filtered: %v", container, err)
+			p = &filterProcess{disabled: true}
+		}
+		l.processes[container] = p
+	}
+	if p.disabled {
+		return false
+	}
+	if _, err := io.WriteString(p.stdin, line+"\n"); err != nil {
+		logrus.Warnf("log filter for %s failed, logs are no longer filtered: %v", container, err)
+		p.disabled = true
+		return false
+	}
+	return true
+}
+
+func (l *LogFilter) start(container string) (*filterProcess, error) {
+	cmd := shellCommand(l.ctx, l.command)
+	cmd.Stderr = l.stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &filterProcess{stdin: stdin}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			l.decorated.Log(container, scanner.Text())
+		}
+		err := cmd.Wait()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !p.disabled && l.ctx.Err() == nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				logrus.Warnf("log filter for %s exited with code %d, logs are no longer filtered", container, exitErr.ExitCode())
+			}
+		}
+		p.disabled = true
+	}()
+	return p, nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
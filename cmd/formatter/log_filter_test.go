@@ -0,0 +1,85 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+type recordingConsumer struct {
+	mu   sync.Mutex
+	logs map[string][]string
+}
+
+func (r *recordingConsumer) Log(container, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.logs == nil {
+		r.logs = map[string][]string{}
+	}
+	r.logs[container] = append(r.logs[container], message)
+}
+
+func (r *recordingConsumer) Err(container, message string) {}
+
+func (r *recordingConsumer) Status(container, msg string) {}
+
+func TestLogFilter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("filter command relies on sh")
+	}
+	consumer := &recordingConsumer{}
+	// fake processor dropping the lines starting with "drop"
+	filter := NewLogFilter(t.Context(), consumer, nil,
+		`while read -r line; do case "$line" in drop*) ;; *) echo "filtered $line" ;; esac; done`)
+	filter.Log("web-1", "hello\ndrop me\nworld")
+	filter.Log("db-1", "drop me too")
+	filter.Log("db-1", "ready")
+	filter.Close()
+
+	assert.DeepEqual(t, consumer.logs, map[string][]string{
+		"web-1": {"filtered hello", "filtered world"},
+		"db-1":  {"filtered ready"},
+	})
+}
+
+func TestLogFilterFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("filter command relies on sh")
+	}
+	consumer := &recordingConsumer{}
+	filter := NewLogFilter(t.Context(), consumer, nil, `read -r line; exit 3`)
+	filter.Log("web-1", "swallowed")
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		filter.mu.Lock()
+		defer filter.mu.Unlock()
+		if filter.processes["web-1"].disabled {
+			return poll.Success()
+		}
+		return poll.Continue("log filter still running")
+	})
+
+	// the filter exited, the log lines are passed as is
+	filter.Log("web-1", "hello")
+	filter.Close()
+	assert.DeepEqual(t, consumer.logs, map[string][]string{"web-1": {"hello"}})
+}
@@ -19,12 +19,14 @@ package formatter
 import (
 	"context"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/acarl005/stripansi"
 	"github.com/buger/goterm"
 	"github.com/moby/moby/client/pkg/jsonmessage"
 
@@ -41,11 +43,37 @@ type logConsumer struct {
 	color      bool
 	prefix     bool
 	timestamp  bool
+	levels     *LevelDetector
+	// maxPrefixWidth truncates the names in the prefixes, 0 for no limit
+	maxPrefixWidth int
+}
+
+// LogConsumerOption configures the LogConsumer created by NewLogConsumer
+type LogConsumerOption func(*logConsumer)
+
+// WithLevelDetector renders the log lines with an intensity based on their
+// log level, as detected by d, when colors are enabled
+func WithLevelDetector(d *LevelDetector) LogConsumerOption {
+	return func(l *logConsumer) {
+		l.levels = d
+	}
+}
+
+// WithMaxPrefixWidth truncates the container names in the log prefixes to
+// width characters, ellipsis included. Names truncated alike are told apart
+// by a short suffix derived from the full name.
+func WithMaxPrefixWidth(width int) LogConsumerOption {
+	return func(l *logConsumer) {
+		if width > 0 {
+			width = max(width, minPrefixWidth)
+		}
+		l.maxPrefixWidth = width
+	}
 }
 
 // NewLogConsumer creates a new LogConsumer
-func NewLogConsumer(ctx context.Context, stdout, stderr io.Writer, color, prefix, timestamp bool) api.LogConsumer {
-	return &logConsumer{
+func NewLogConsumer(ctx context.Context, stdout, stderr io.Writer, color, prefix, timestamp bool, options ...LogConsumerOption) api.LogConsumer {
+	l := &logConsumer{
 		ctx:        ctx,
 		presenters: sync.Map{},
 		width:      0,
@@ -55,6 +83,10 @@ func NewLogConsumer(ctx context.Context, stdout, stderr io.Writer, color, prefix
 		prefix:     prefix,
 		timestamp:  timestamp,
 	}
+	for _, option := range options {
+		option(l)
+	}
+	return l
 }
 
 func (l *logConsumer) register(name string) *presenter {
@@ -63,28 +95,30 @@ func (l *logConsumer) register(name string) *presenter {
 	if found {
 		parent := l.getPresenter(root)
 		p = &presenter{
+			color:  parent.color,
 			colors: parent.colors,
 			name:   name,
 			prefix: parent.prefix,
 		}
 	} else {
-		cf := monochrome
+		code := ""
 		if l.color {
 			switch name {
 			case "":
-				cf = monochrome
 			case api.WatchLogger:
-				cf = makeColorFunc("92")
+				code = "92"
 			default:
-				cf = nextColor()
+				code = containerColor(name)
 			}
 		}
 		p = &presenter{
-			colors: cf,
+			color:  code,
+			colors: makeColorFunc(code),
 			name:   name,
 		}
 	}
 	l.presenters.Store(name, p)
+	l.computeLabels()
 	l.computeWidth()
 	if l.prefix {
 		l.presenters.Range(func(key, value any) bool {
@@ -121,6 +155,9 @@ func (l *logConsumer) write(w io.Writer, container, message string) {
 	p := l.getPresenter(container)
 	timestamp := time.Now().Format(jsonmessage.RFC3339NanoFixed)
 	for line := range strings.SplitSeq(message, "\n") {
+		if l.levels != nil && l.color {
+			line = colorLevel(p.color, l.levels.Detect(p.name, line), line)
+		}
 		if l.timestamp {
 			_, _ = fmt.Fprintf(w, "%s%s %s\n", p.prefix, timestamp, line)
 		} else {
@@ -139,17 +176,57 @@ func (l *logConsumer) computeWidth() {
 	width := 0
 	l.presenters.Range(func(key, value any) bool {
 		p := value.(*presenter)
-		if len(p.name) > width {
-			width = len(p.name)
+		if w := lenAnsi(p.label); w > width {
+			width = w
 		}
 		return true
 	})
 	l.width = width + 1
 }
 
+// minPrefixWidth is the lowest width names are truncated to, leaving room
+// for a few characters of the name with the suffix telling it apart
+const minPrefixWidth = 8
+
+// computeLabels sets the names the presenters display in their prefix,
+// truncated to maxPrefixWidth
+func (l *logConsumer) computeLabels() {
+	byLabel := map[string][]*presenter{}
+	l.presenters.Range(func(key, value any) bool {
+		p := value.(*presenter)
+		p.label = truncateName(p.name, l.maxPrefixWidth)
+		byLabel[p.label] = append(byLabel[p.label], p)
+		return true
+	})
+	for _, presenters := range byLabel {
+		if len(presenters) < 2 {
+			continue
+		}
+		for _, p := range presenters {
+			if p.label == p.name {
+				continue
+			}
+			suffix := fmt.Sprintf("~%04x", crc32.ChecksumIEEE([]byte(p.name))&0xffff)
+			p.label = truncateName(p.name, l.maxPrefixWidth-len(suffix)) + suffix
+		}
+	}
+}
+
+// truncateName truncates name to width visible characters, ellipsis
+// included. A width of 0 leaves it as is.
+func truncateName(name string, width int) string {
+	if width <= 0 || lenAnsi(name) <= width {
+		return name
+	}
+	runes := []rune(stripansi.Strip(name))
+	return string(runes[:width-1]) + "…"
+}
+
 type presenter struct {
+	color  string // ANSI color code, empty when monochrome
 	colors colorFunc
 	name   string
+	label  string // name displayed in the prefix, possibly truncated
 	prefix string
 }
 
@@ -158,7 +235,7 @@ func (p *presenter) setPrefix(width int) {
 		p.prefix = p.colors(strings.Repeat(" ", width) + " ⦿ ")
 		return
 	}
-	p.prefix = p.colors(fmt.Sprintf("%-"+strconv.Itoa(width)+"s | ", p.name))
+	p.prefix = p.colors(fmt.Sprintf("%-"+strconv.Itoa(width)+"s | ", p.label))
 }
 
 type logDecorator struct {
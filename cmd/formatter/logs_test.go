@@ -0,0 +1,59 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTruncateName(t *testing.T) {
+	assert.Equal(t, truncateName("web-1", 0), "web-1")
+	assert.Equal(t, truncateName("web-1", 8), "web-1")
+	assert.Equal(t, truncateName("very-long-service-name-1", 10), "very-long…")
+	assert.Equal(t, truncateName("\x1b[36mvery-long-service-name-1\x1b[0m", 10), "very-long…")
+}
+
+func TestLogConsumerMaxPrefixWidth(t *testing.T) {
+	var out bytes.Buffer
+	consumer := NewLogConsumer(t.Context(), &out, &out, false, true, false, WithMaxPrefixWidth(12)).(*logConsumer)
+	consumer.register("web-1")
+	consumer.register("very-long-service-name-1")
+	consumer.register("very-long-service-name-2")
+	consumer.register("authentication-1")
+
+	labels := map[string]string{}
+	consumer.presenters.Range(func(key, value any) bool {
+		labels[key.(string)] = value.(*presenter).label
+		return true
+	})
+	assert.DeepEqual(t, labels, map[string]string{
+		"web-1":                    "web-1",
+		"very-long-service-name-1": "very-l…~09ff",
+		"very-long-service-name-2": "very-l…~5845",
+		"authentication-1":         "authenticat…",
+	})
+
+	consumer.Log("very-long-service-name-1", "hello")
+	assert.Equal(t, out.String(), "very-l…~09ff  | hello\n")
+
+	// names are never truncated below the minimum width
+	consumer = NewLogConsumer(t.Context(), &out, &out, false, true, false, WithMaxPrefixWidth(2)).(*logConsumer)
+	assert.Equal(t, consumer.maxPrefixWidth, minPrefixWidth)
+}
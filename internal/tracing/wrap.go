@@ -18,6 +18,7 @@ package tracing
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/acarl005/stripansi"
 	"go.opentelemetry.io/otel"
@@ -96,3 +97,13 @@ func AddAttributeToSpan(ctx context.Context, attr ...attribute.KeyValue) {
 	span := trace.SpanFromContext(ctx)
 	span.SetAttributes(attr...)
 }
+
+// TraceParent returns the span in ctx in the W3C traceparent format, or an
+// empty string when ctx isn't traced.
+func TraceParent(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID(), sc.SpanID(), sc.TraceFlags())
+}
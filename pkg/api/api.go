@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/containerd/platforms"
 	"github.com/docker/cli/opts"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/api/types/volume"
 )
 
@@ -60,6 +62,9 @@ type ProjectLoadOptions struct {
 	All bool
 	// Compatibility enables v1 compatibility mode
 	Compatibility bool
+	// RedactDecrypted replaces the environment values encrypted at rest
+	// with a placeholder once decrypted, to render the project without them
+	RedactDecrypted bool
 
 	// ProjectOptionsFns are compose-go project options to apply.
 	// Use cli.WithInterpolation(false), cli.WithNormalization(false), etc.
@@ -137,6 +142,8 @@ type Compose interface {
 	Viz(ctx context.Context, project *types.Project, options VizOptions) (string, error)
 	// Wait blocks until at least one of the services' container exits
 	Wait(ctx context.Context, projectName string, options WaitOptions) (int64, error)
+	// WaitReady blocks until the services' running containers satisfy a depends_on condition
+	WaitReady(ctx context.Context, project *types.Project, options WaitReadyOptions) ([]ServiceReadiness, error)
 	// Scale manages numbers of container instances running per service
 	Scale(ctx context.Context, project *types.Project, options ScaleOptions) error
 	// Export a service container's filesystem as a tar archive
@@ -147,6 +154,23 @@ type Compose interface {
 	Generate(ctx context.Context, options GenerateOptions) (*types.Project, error)
 	// Volumes executes the equivalent to a `docker volume ls`
 	Volumes(ctx context.Context, project string, options VolumesOptions) ([]VolumesSummary, error)
+	// ProtectVolumes labels or records project volumes as protected so down keeps them when removing volumes
+	ProtectVolumes(ctx context.Context, project *types.Project, options ProtectVolumesOptions) error
+	// Inspect merges a service's resolved configuration with the runtime state of one of its containers
+	Inspect(ctx context.Context, project *types.Project, options InspectOptions) (ServiceInspect, error)
+	// Diff reports how the running project differs from the Compose model, without applying any change
+	Diff(ctx context.Context, project *types.Project) ([]Drift, error)
+	// BuildChanges reports which services a `compose build` would rebuild as their build inputs changed,
+	// without building anything
+	BuildChanges(ctx context.Context, project *types.Project, options BuildOptions) ([]BuildChange, error)
+	// DNS compares the aliases service containers are expected to have on project networks with the ones they have
+	DNS(ctx context.Context, project *types.Project, options DNSOptions) ([]NetworkDNS, error)
+	// ExportSpecs returns the configurations up would submit to the engine to create the service containers
+	ExportSpecs(ctx context.Context, project *types.Project, options ExportSpecsOptions) ([]ContainerSpec, error)
+	// Checkpoint snapshots the project containers and volumes so Restore can recreate the project from them
+	Checkpoint(ctx context.Context, project *types.Project, options CheckpointOptions) (Snapshot, error)
+	// Restore recreates the project from the images and volumes of a snapshot
+	Restore(ctx context.Context, project *types.Project, options RestoreOptions) error
 	// LoadProject loads and validates a Compose project from configuration files.
 	LoadProject(ctx context.Context, options ProjectLoadOptions) (*types.Project, error)
 }
@@ -157,8 +181,205 @@ type VolumesOptions struct {
 
 type VolumesSummary = volume.Volume
 
+// ProtectVolumesOptions group options of the ProtectVolumes API
+type ProtectVolumesOptions struct {
+	// Volumes are the keys of the project volumes to protect
+	Volumes []string
+}
+
+// InspectOptions group options of the Inspect API
+type InspectOptions struct {
+	// Service to inspect
+	Service string
+	// Index of the service container to inspect
+	Index int
+}
+
+// ServiceInspect merges a service's resolved configuration with the runtime
+// state of one of its containers, and tells whether `up` would recreate it
+type ServiceInspect struct {
+	// Service is the resolved service configuration
+	Service types.ServiceConfig `json:"service" yaml:"service"`
+	// Container holds the runtime state of the inspected container, nil if none exists
+	Container *ContainerRuntime `json:"container,omitempty" yaml:"container,omitempty"`
+	// StoredConfigHash is the config hash recorded on the container at creation time
+	StoredConfigHash string `json:"stored_config_hash,omitempty" yaml:"stored_config_hash,omitempty"`
+	// ConfigHash is the config hash computed from the current service configuration
+	ConfigHash string `json:"config_hash" yaml:"config_hash"`
+	// Diverged is set when StoredConfigHash doesn't match ConfigHash
+	Diverged bool `json:"diverged" yaml:"diverged"`
+	// Recreate is set when `up` would recreate the container
+	Recreate bool `json:"recreate" yaml:"recreate"`
+	// RecreateReason explains why `up` would recreate the container
+	RecreateReason string `json:"recreate_reason,omitempty" yaml:"recreate_reason,omitempty"`
+	// Matched lists the checks the container passed, e.g. "config hash
+	// equal", confirming it matches the service configuration when `up`
+	// wouldn't recreate it
+	Matched []string `json:"matched,omitempty" yaml:"matched,omitempty"`
+}
+
+// Drift is a difference between the Compose model and the running project
+// which `up` would act upon
+type Drift struct {
+	// Service the drift applies to, empty for networks and volumes
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+	// Kind is one of DriftRecreate, DriftScale, DriftNetwork or DriftVolume
+	Kind string `json:"kind" yaml:"kind"`
+	// Detail explains the drift, e.g. why a container would be recreated
+	Detail string `json:"detail" yaml:"detail"`
+}
+
+const (
+	// DriftRecreate reports a container `up` would recreate
+	DriftRecreate = "recreate"
+	// DriftScale reports a service running a different number of containers than expected
+	DriftScale = "scale"
+	// DriftNetwork reports a network missing or configured differently
+	DriftNetwork = "network"
+	// DriftVolume reports a volume missing or configured differently
+	DriftVolume = "volume"
+	// DriftStopped reports a container stopped which is expected to run and
+	// the engine won't restart
+	DriftStopped = "stopped"
+)
+
+// DNSOptions group options of the DNS API
+type DNSOptions struct {
+	// Services to check, all if empty
+	Services []string
+	// Probe is the service to resolve the expected aliases from with `getent hosts`, none if empty
+	Probe string
+}
+
+// NetworkDNS reports the aliases of the service containers attached to a project network
+type NetworkDNS struct {
+	// Network is the network name in the Compose file
+	Network string `json:"network"`
+	// Name is the network name in the engine
+	Name       string         `json:"name"`
+	Containers []ContainerDNS `json:"containers"`
+}
+
+// ContainerDNS compares the aliases a container is expected to have on a
+// network, as set on creation, with the ones reported by the engine
+type ContainerDNS struct {
+	Container string `json:"container"`
+	Service   string `json:"service"`
+	// Attached is set when the network lists the container
+	Attached bool     `json:"attached"`
+	Expected []string `json:"expected"`
+	Actual   []string `json:"actual"`
+	// Missing lists the expected aliases not reported by the engine
+	Missing []string `json:"missing,omitempty"`
+	// Probes holds the resolution of the expected aliases from the probe service
+	Probes []DNSProbe `json:"probes,omitempty"`
+}
+
+// Mismatch reports whether the container isn't reachable by all of its expected aliases
+func (c ContainerDNS) Mismatch() bool {
+	if !c.Attached || len(c.Missing) > 0 {
+		return true
+	}
+	for _, probe := range c.Probes {
+		if !probe.Resolved {
+			return true
+		}
+	}
+	return false
+}
+
+// DNSProbe is the resolution of an alias from the probe service
+type DNSProbe struct {
+	Alias     string   `json:"alias"`
+	Resolved  bool     `json:"resolved"`
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// ExportSpecsOptions group options of the ExportSpecs API
+type ExportSpecsOptions struct {
+	// Services to export, all if empty
+	Services []string
+}
+
+// ContainerSpec holds the configurations submitted to the engine to create a
+// service container, with the value of secrets redacted
+type ContainerSpec struct {
+	Service          string                    `json:"service"`
+	Name             string                    `json:"name"`
+	Number           int                       `json:"number"`
+	Config           *container.Config         `json:"config"`
+	HostConfig       *container.HostConfig     `json:"host_config"`
+	NetworkingConfig *network.NetworkingConfig `json:"networking_config,omitempty"`
+}
+
+// CheckpointOptions group options of the Checkpoint API
+type CheckpointOptions struct {
+	// ID of the snapshot, generated from the current time if empty
+	ID string
+	// Dir is the directory snapshots are stored in
+	Dir string
+}
+
+// RestoreOptions group options of the Restore API
+type RestoreOptions struct {
+	// ID of the snapshot to restore
+	ID string
+	// Dir is the directory snapshots are stored in
+	Dir string
+	// Create and Start are the options of the up recreating the project
+	Create CreateOptions
+	Start  StartOptions
+}
+
+// Snapshot is the manifest of a project snapshot, stored along with the
+// archives of the volumes
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Project string    `json:"project"`
+	Created time.Time `json:"created"`
+	// Images maps the services to the image their container was committed to
+	Images map[string]string `json:"images"`
+	// Volumes lists the archives of the project volumes
+	Volumes []SnapshotVolume `json:"volumes,omitempty"`
+}
+
+// SnapshotVolume is the archive of a volume in a snapshot
+type SnapshotVolume struct {
+	// Volume is the volume name in the Compose file
+	Volume string `json:"volume"`
+	// Name is the volume name in the engine
+	Name string `json:"name"`
+	// Archive is the path of the tar archive, relative to the snapshot directory
+	Archive string `json:"archive"`
+}
+
+// ContainerRuntime holds the key runtime facts of a service container
+type ContainerRuntime struct {
+	ID          string                   `json:"id" yaml:"id"`
+	Name        string                   `json:"name" yaml:"name"`
+	State       container.ContainerState `json:"state" yaml:"state"`
+	Health      container.HealthStatus   `json:"health,omitempty" yaml:"health,omitempty"`
+	ImageDigest string                   `json:"image_digest,omitempty" yaml:"image_digest,omitempty"`
+	Networks    []NetworkAttachment      `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Mounts      []string                 `json:"mounts,omitempty" yaml:"mounts,omitempty"`
+}
+
+// NetworkAttachment describes a container connection to a network
+type NetworkAttachment struct {
+	Name        string   `json:"name" yaml:"name"`
+	IPAddress   string   `json:"ip_address,omitempty" yaml:"ip_address,omitempty"`
+	IPv6Address string   `json:"ipv6_address,omitempty" yaml:"ipv6_address,omitempty"`
+	Aliases     []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
 type ScaleOptions struct {
 	Services []string
+	// KeepScaledDown stops the containers of the services scaled down rather
+	// than removing them, see CreateOptions.KeepScaledDown
+	KeepScaledDown bool
+	// ScaleDownReferenced defines how the containers others depend on are
+	// scaled down, see CreateOptions.ScaleDownReferenced
+	ScaleDownReferenced string
 }
 
 type WaitOptions struct {
@@ -168,6 +389,30 @@ type WaitOptions struct {
 	DownProjectOnContainerExit bool
 }
 
+// WaitReadyOptions group options of the WaitReady API
+type WaitReadyOptions struct {
+	// Services to wait for, all the project services when empty
+	Services []string
+	// Condition the services must satisfy, service_healthy or
+	// service_completed_successfully. Defaults to the containers running, or
+	// being healthy when they declare a healthcheck
+	Condition string
+	// Timeout for each service to satisfy the condition, none when zero
+	Timeout time.Duration
+}
+
+// ServiceReadiness is the outcome of waiting for a service with WaitReady
+type ServiceReadiness struct {
+	Service string
+	// Ready is set once the service satisfied the condition
+	Ready bool
+	// TimedOut is set when the timeout expired before the service was ready
+	TimedOut bool
+	// Error explains why the service can't satisfy the condition, e.g. an
+	// unhealthy container or a job which failed
+	Error error
+}
+
 type VizOptions struct {
 	// IncludeNetworks if true, network names a container is attached to should appear in the graph node
 	IncludeNetworks bool
@@ -210,6 +455,9 @@ type BuildOptions struct {
 	Deps bool
 	// Ssh authentications passed in the command line
 	SSHs []types.SSHKey
+	// Secrets passed in the command line, used to build all the services
+	// in addition to the ones they declare, which they override by id
+	Secrets []BuildSecret
 	// Memory limit for the build container
 	Memory int64
 	// Builder name passed in the command line
@@ -228,6 +476,28 @@ type BuildOptions struct {
 	Out io.Writer
 }
 
+// BuildChange tells whether the image of a service would be rebuilt
+type BuildChange struct {
+	Service string `json:"service"`
+	Image   string `json:"image"`
+	// Rebuild is set when the build inputs of the service changed since its
+	// image was built, or can't be compared
+	Rebuild bool `json:"rebuild"`
+	// Reason explains why the image would be rebuilt
+	Reason string `json:"reason,omitempty"`
+}
+
+// BuildSecret is a secret exposed to builds, read from a file or an
+// environment variable
+type BuildSecret struct {
+	// ID identifies the secret in the Dockerfile
+	ID string
+	// File is the path of the file holding the secret
+	File string
+	// Env is the environment variable holding the secret
+	Env string
+}
+
 // Apply mutates project according to build options
 func (o BuildOptions) Apply(project *types.Project) error {
 	platform := project.Environment["DOCKER_DEFAULT_PLATFORM"]
@@ -272,14 +542,117 @@ type CreateOptions struct {
 	Recreate string
 	// RecreateDependencies define the strategy to apply on dependencies services
 	RecreateDependencies string
+	// RecreateDependents recreates the services depending on a recreated
+	// service with restart: true, rather than only restarting them
+	RecreateDependents bool
+	// RecreateOrder defines the order the containers of the services are
+	// recreated in, one of RecreateOrderDependenciesFirst (default) or
+	// RecreateOrderDependentsFirst
+	RecreateOrder string
 	// Inherit reuse anonymous volumes from previous container
 	Inherit bool
 	// Timeout set delay to wait for container to gracefully stop before sending SIGKILL
 	Timeout *time.Duration
 	// QuietPull makes the pulling process quiet
 	QuietPull bool
+	// PipelinePull creates the containers of the services whose image is
+	// present while the images of the others are pulled, rather than once all
+	// images are pulled. The containers of a service are created once its own
+	// image is pulled.
+	PipelinePull bool
 	// SkipProviders skips provider services during convergence (e.g. watch rebuild)
 	SkipProviders bool
+	// MaxAge recreates containers created longer ago than this duration, even
+	// if their configuration and image haven't changed. Zero disables it.
+	MaxAge time.Duration
+	// RecreateRestarting recreates containers stuck restarting, restarted by
+	// the engine for longer than this duration, as when the service is
+	// crash-looping. Zero disables it.
+	RecreateRestarting time.Duration
+	// RestartExhausted defines how the containers left exited with an error
+	// by the engine, once the retries of their on-failure restart policy are
+	// exhausted, are handled, one of RestartExhaustedRestart (default),
+	// RestartExhaustedRecreate or RestartExhaustedIgnore
+	RestartExhausted string
+	// KeepScaledDown stops the containers of a service scaled down rather than
+	// removing them, so they can be inspected, and restarts them when the
+	// service scales back up. Start must then be run with the same option.
+	KeepScaledDown bool
+	// LazyResources only creates the missing networks and volumes of the
+	// project once a container using them is created or started, rather than
+	// all of them before convergence
+	LazyResources bool
+	// ScaleDelta changes the number of replicas of services relatively to the
+	// containers currently running or created for them
+	ScaleDelta map[string]int
+	// DuplicateNumbers defines how service containers sharing a number are
+	// handled, one of DuplicateNumbersKeepNewest (default) or DuplicateNumbersError
+	DuplicateNumbers string
+	// ResourcePreflight checks, before containers are created to scale a
+	// service up, that the CPUs and memory of the host can accommodate the
+	// deploy.resources.reservations of the project containers, one of
+	// ResourcePreflightWarn or ResourcePreflightError. Empty disables it.
+	ResourcePreflight string
+	// VerifyImages runs the image verifier of the service on the images of
+	// the project before any container is created, one of VerifyImagesOff
+	// (default), VerifyImagesWarn or VerifyImagesEnforce
+	VerifyImages string
+	// ScaleDownReferenced defines how a scale down handles the containers
+	// other running containers share the network, IPC or PID namespace or the
+	// volumes of, one of ScaleDownReferencedWarn (default),
+	// ScaleDownReferencedReselect, ScaleDownReferencedError or
+	// ScaleDownReferencedRecreate
+	ScaleDownReferenced string
+	// IgnoreHealthcheckChanges doesn't recreate the containers whose
+	// configuration only differs from the service by the healthcheck, which
+	// then keep running with the healthcheck they were created with
+	IgnoreHealthcheckChanges bool
+	// PreservePaused pauses the replacements of recreated paused containers
+	// once they are started, rather than leaving them running
+	PreservePaused bool
+	// SkipIPv6Check skips the check of the IPv6 configuration of networks and
+	// published ports against the engine capabilities before creating anything
+	SkipIPv6Check bool
+	// SkipPlacementCheck creates the containers of services declaring
+	// deploy.placement.constraints the engine can't honor, ignoring them
+	SkipPlacementCheck bool
+	// ValidateReferences checks the secrets, configs and external volumes
+	// the services reference exist before any container is created, failing
+	// with all the missing ones rather than on the first container using one
+	ValidateReferences bool
+	// CreateHostPaths creates the missing sources of bind mounts as
+	// directories owned by the current user. Otherwise, only the ones declared
+	// with create_host_path: false make the creation of containers fail, the
+	// engine creating the others owned by root
+	CreateHostPaths bool
+	// ContainerCreated, when set, is called with the service and the ID of
+	// each container created, including the ones replacing a recreated
+	// container, as soon as it is created
+	ContainerCreated func(service string, containerID string)
+	// ConfirmCanary, when set, makes convergence recreate first a single
+	// container of the services with several containers to recreate, start
+	// it and wait for it to be healthy, then call ConfirmCanary with it. The
+	// other containers of the service are only recreated once it returns
+	// true: false or an error fail convergence, leaving the canary in place.
+	ConfirmCanary func(ctx context.Context, canary CanaryContainer) (bool, error)
+	// CanaryTimeout is how long a canary is given to get healthy, then
+	// ConfirmCanary to confirm it, before convergence fails, 0 for no limit
+	// other than the healthcheck of the service
+	CanaryTimeout time.Duration
+}
+
+// CanaryContainer is the container recreated first as a canary, passed to
+// CreateOptions.ConfirmCanary
+type CanaryContainer struct {
+	Service string
+	Number  int
+	ID      string
+	Name    string
+	// State is the state of the container, such as running
+	State string
+	// Health is the health status of the container, empty when it has no
+	// healthcheck
+	Health string
 }
 
 // StartOptions group options of the Start API
@@ -294,9 +667,26 @@ type StartOptions struct {
 	OnExit Cascade
 	// ExitCodeFrom return exit code from specified service
 	ExitCodeFrom string
+	// ExitMode defines what happens to the containers when an attached up terminates
+	ExitMode string
 	// Wait won't return until containers reached the running|healthy state
 	Wait        bool
 	WaitTimeout time.Duration
+	// SkipDependencyWaits starts services in dependency order without waiting
+	// for depends_on health or completion conditions to be met
+	SkipDependencyWaits bool
+	// KeepScaledDown doesn't start more containers than the scale of a
+	// service, the ones kept stopped by CreateOptions.KeepScaledDown staying
+	// stopped
+	KeepScaledDown bool
+	// EarlyExitWindow inspects the containers this long after they are
+	// started, and reports the ones which already exited with an error as
+	// failed, with their exit code and last log lines. Zero disables it.
+	EarlyExitWindow time.Duration
+	// RestartExhausted set to RestartExhaustedIgnore leaves the containers
+	// which exhausted the retries of their restart policy exited, see
+	// CreateOptions.RestartExhausted
+	RestartExhausted string
 	// Services passed in the command line to be started
 	Services       []string
 	Watch          bool
@@ -311,6 +701,15 @@ const (
 	CascadeFail   Cascade = iota
 )
 
+const (
+	// ExitModeDetach leaves the containers running when an attached up terminates
+	ExitModeDetach = "detach"
+	// ExitModeStop stops the containers when an attached up terminates
+	ExitModeStop = "stop"
+	// ExitModeDown stops and removes the containers and networks when an attached up terminates
+	ExitModeDown = "down"
+)
+
 // RestartOptions group options of the Restart API
 type RestartOptions struct {
 	// Project is the compose project used to define this app. Might be nil if user ran command just with project name
@@ -337,8 +736,177 @@ type StopOptions struct {
 type UpOptions struct {
 	Create CreateOptions
 	Start  StartOptions
+	// Report, when set, is called once up completes, successfully or not,
+	// with a summary of what it did
+	Report func(UpReport)
+	// Profile, when set, is called once up completes, successfully or not,
+	// with the time spent bringing up each service
+	Profile func(StartupProfile)
+	// ScaleSchedule changes the scale of services over time while up runs
+	// attached, until it is interrupted
+	ScaleSchedule []ScaleStep
+	// ReconcileInterval, when set, is how often up running attached checks
+	// the project for drift and converges the services diverging again
+	ReconcileInterval time.Duration
+	// DaemonWait, when set, is how long up waits for the Docker engine to
+	// come back when the connection is lost while converging the project,
+	// before resuming convergence from the containers it left behind
+	DaemonWait time.Duration
+	// Verify lists HTTP(S) endpoints checked once the project is converged,
+	// up failing unless each responds with a 2xx status within its timeout
+	Verify []EndpointCheck
+}
+
+// EndpointCheck is an HTTP(S) endpoint polled until it responds with a 2xx
+// status
+type EndpointCheck struct {
+	URL string
+	// Timeout is how long the endpoint is polled for, 30s when zero
+	Timeout time.Duration
+}
+
+// ScaleStep sets the scale of a service once up has been running for At
+type ScaleStep struct {
+	Service string
+	Scale   int
+	At      time.Duration
+}
+
+// UpReport summarizes what up did to the project containers. It is meant to
+// be consumed by tools as JSON, so its schema is kept stable.
+type UpReport struct {
+	// Success is false when up returned an error
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// StartedAt is when up started, in RFC 3339 format
+	StartedAt string `json:"started_at"`
+	// Duration is the wall time of up, in milliseconds
+	Duration int64           `json:"duration_ms"`
+	Phases   UpPhases        `json:"phases"`
+	Services []ServiceReport `json:"services"`
+	Warnings []string        `json:"warnings"`
+	// Profile is set when up was asked to profile the startup of the services
+	Profile *StartupProfile `json:"profile,omitempty"`
+}
+
+// UpPhases are the time spent in each phase of up, in milliseconds, from the
+// first to the last event of the phase
+type UpPhases struct {
+	Pull   int64 `json:"pull_ms"`
+	Create int64 `json:"create_ms"`
+	Start  int64 `json:"start_ms"`
+	Wait   int64 `json:"wait_ms"`
+}
+
+// ServiceReport is the part of an UpReport about a service
+type ServiceReport struct {
+	Name       string            `json:"name"`
+	Containers []ContainerReport `json:"containers"`
+}
+
+// ContainerReport is what up did to a container, and the state it left it in
+type ContainerReport struct {
+	Name string `json:"name"`
+	// Action is one of ReportActionCreated, ReportActionRecreated,
+	// ReportActionStarted or ReportActionUnchanged
+	Action string `json:"action"`
+	// Reason explains why a container was recreated
+	Reason string `json:"reason,omitempty"`
+	// Wait is the outcome of waiting for the container, as a dependency or
+	// with --wait
+	Wait   *WaitReport `json:"wait,omitempty"`
+	Error  string      `json:"error,omitempty"`
+	State  string      `json:"state"`
+	Health string      `json:"health,omitempty"`
 }
 
+// WaitReport is the outcome of waiting for a container
+type WaitReport struct {
+	// Outcome is the status the wait ended with, e.g. healthy, exited or skipped
+	Outcome  string `json:"outcome"`
+	Reason   string `json:"reason,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// StartupProfile is the time spent bringing up each service, and the chain of
+// dependencies which took the longest to start
+type StartupProfile struct {
+	// Services are sorted by decreasing total time
+	Services []ServiceProfile `json:"services"`
+	// CriticalPath lists the services of the longest chain of dependencies,
+	// from the first one started to the last
+	CriticalPath []CriticalPathStep `json:"critical_path"`
+	// Waits is the timeline of the waits for dependencies to meet their
+	// depends_on condition, sorted by start time. The waits of --wait have
+	// the project name as service.
+	Waits []DependencyWait `json:"waits"`
+}
+
+// DependencyWait is the wait of a service for a dependency to meet its
+// depends_on condition
+type DependencyWait struct {
+	Service    string    `json:"service"`
+	Dependency string    `json:"dependency"`
+	Condition  string    `json:"condition"`
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	// Duration is the time waited, in milliseconds
+	Duration int64 `json:"duration_ms"`
+	// Outcome is one of the DependencyWait* constants
+	Outcome string `json:"outcome"`
+	// State is the state of the dependency containers when the wait ended
+	// without the condition being met
+	State string `json:"state,omitempty"`
+}
+
+const (
+	// DependencyWaitSatisfied means the dependency met the condition
+	DependencyWaitSatisfied = "satisfied"
+	// DependencyWaitSkipped means the optional dependency failed, and isn't waited for
+	DependencyWaitSkipped = "skipped"
+	// DependencyWaitMissing means the dependency has no container to wait for
+	DependencyWaitMissing = "missing"
+	// DependencyWaitFailed means the dependency failed to meet the condition
+	DependencyWaitFailed = "failed"
+	// DependencyWaitTimeout means the condition wasn't met before the wait timed out
+	DependencyWaitTimeout = "timeout"
+	// DependencyWaitInterrupted means the wait was interrupted, e.g. by another dependency failing
+	DependencyWaitInterrupted = "interrupted"
+)
+
+// ServiceProfile is the time spent bringing up a service, in milliseconds
+type ServiceProfile struct {
+	Name string `json:"name"`
+	// Create is the time spent creating or recreating the service containers
+	Create int64 `json:"create_ms"`
+	// Wait is the time spent waiting for the dependencies of the service
+	Wait int64 `json:"wait_ms"`
+	// Start is the time spent starting the service containers, hooks included
+	Start int64 `json:"start_ms"`
+}
+
+// CriticalPathStep is a service of the critical path of the startup
+type CriticalPathStep struct {
+	Service string `json:"service"`
+	// Duration is the time from the service starting to wait for its
+	// dependencies to its containers being started, in milliseconds
+	Duration int64 `json:"duration_ms"`
+	// Cumulative is the time from the start of the critical path to the
+	// service being started, in milliseconds
+	Cumulative int64 `json:"cumulative_ms"`
+}
+
+const (
+	// ReportActionCreated means the container was created
+	ReportActionCreated = "created"
+	// ReportActionRecreated means the container was replaced by a new one
+	ReportActionRecreated = "recreated"
+	// ReportActionStarted means the existing container was started
+	ReportActionStarted = "started"
+	// ReportActionUnchanged means the container was left as it was
+	ReportActionUnchanged = "unchanged"
+)
+
 // DownOptions group options of the Down API
 type DownOptions struct {
 	// RemoveOrphans will cleanup containers that are not declared on the compose model but own the same labels
@@ -351,10 +919,24 @@ type DownOptions struct {
 	Images string
 	// Volumes remove volumes, both declared in the `volumes` section and anonymous ones
 	Volumes bool
+	// VolumesScope restricts the volumes removed by Volumes to the named or
+	// anonymous ones, all of them when empty
+	VolumesScope string
+	// ForceVolumes removes the volumes labeled with VolumeProtectLabel or recorded as protected too
+	ForceVolumes bool
 	// Services passed in the command line to be stopped
 	Services []string
 }
 
+const (
+	// VolumesScopeNamed only removes the volumes declared in the `volumes` section
+	VolumesScopeNamed = "named"
+	// VolumesScopeAnonymous only removes the anonymous volumes attached to containers
+	VolumesScopeAnonymous = "anonymous"
+	// VolumesScopeAll removes both named and anonymous volumes
+	VolumesScopeAll = "all"
+)
+
 // ConfigOptions group options of the Config API
 type ConfigOptions struct {
 	// Format define the output format used to dump converted application model (json|yaml)
@@ -377,6 +959,10 @@ type PullOptions struct {
 	Quiet           bool
 	IgnoreFailures  bool
 	IgnoreBuildable bool
+	// VerifyImages runs the image verifier of the service on the images once
+	// pulled, one of VerifyImagesOff (default), VerifyImagesWarn or
+	// VerifyImagesEnforce
+	VerifyImages string
 }
 
 // ImagesOptions group options of the Images API
@@ -394,10 +980,33 @@ type KillOptions struct {
 	Services []string
 	// Signal to send to containers
 	Signal string
+	// Signals overrides Signal for the containers of the listed services, keyed by service name
+	Signals map[string]string
 	// All can be set to true to try to kill all found containers, independently of their state
 	All bool
+	// Report, when set, is called with the outcome for each container, including
+	// the ones skipped because they were not running
+	Report func(KillResult)
+}
+
+// KillResult is the outcome of Kill for a single container
+type KillResult struct {
+	Container string
+	Service   string
+	Signal    string
+	// Status is one of KillStatusKilled, KillStatusSkipped or KillStatusError
+	Status string
 }
 
+const (
+	// KillStatusKilled means the signal was sent to the container
+	KillStatusKilled = "killed"
+	// KillStatusSkipped means the container was not running, so no signal was sent
+	KillStatusSkipped = "skipped"
+	// KillStatusError means sending the signal failed
+	KillStatusError = "error"
+)
+
 // RemoveOptions group options of the Remove API
 type RemoveOptions struct {
 	// Project is the compose project used to define this app. Might be nil if user ran command just with project name
@@ -410,6 +1019,9 @@ type RemoveOptions struct {
 	Force bool
 	// Services passed in the command line to be removed
 	Services []string
+	// StaleOnly only removes the containers left under their temporary name
+	// by an interrupted recreate
+	StaleOnly bool
 }
 
 // RunOptions group options of the Run API
@@ -434,8 +1046,14 @@ type RunOptions struct {
 	Privileged        bool
 	UseNetworkAliases bool
 	NoDeps            bool
+	// QuietDeps holds the progress of the dependencies being started, only
+	// displaying it if they fail to
+	QuietDeps bool
 	// used by exec
 	Index int
+	// NoServiceEnv runs exec with the environment of the image, without the
+	// variables set by the service. Environment still applies.
+	NoServiceEnv bool
 }
 
 // AttachOptions group options of the Attach API
@@ -630,8 +1248,31 @@ type LogOptions struct {
 	Until      string
 	Follow     bool
 	Timestamps bool
+	// LogProcessor, when set, processes each log line before it is passed to
+	// the LogConsumer
+	LogProcessor LogProcessor
+	// Grep, when set, only passes the log lines it selects to the LogConsumer
+	Grep *LogGrep
+}
+
+// LogGrep selects the log lines of each container matching a pattern
+type LogGrep struct {
+	// Pattern the selected lines match
+	Pattern *regexp.Regexp
+	// Invert selects the lines not matching Pattern
+	Invert bool
+	// Context is the number of lines to select before and after each
+	// matching line
+	Context int
+	// Matched, when set, is called with the service and the number of
+	// matching lines of each container once its logs are consumed
+	Matched func(service string, count int)
 }
 
+// LogProcessor processes a log line of a service container, returning the
+// line to log in place of it, and false to drop it
+type LogProcessor func(service, line string) (string, bool)
+
 // PauseOptions group options of the Pause API
 type PauseOptions struct {
 	// Services passed in the command line to be started
@@ -691,6 +1332,56 @@ const (
 	RecreateNever = "never"
 )
 
+const (
+	// RecreateOrderDependenciesFirst to recreate the containers of a service after the ones of its dependencies
+	RecreateOrderDependenciesFirst = "dependencies-first"
+	// RecreateOrderDependentsFirst to recreate the containers of a service after the ones of the services depending on it
+	RecreateOrderDependentsFirst = "dependents-first"
+)
+
+const (
+	// RestartExhaustedRestart to start the containers which exhausted the retries of their restart policy again
+	RestartExhaustedRestart = "restart"
+	// RestartExhaustedRecreate to recreate the containers which exhausted the retries of their restart policy
+	RestartExhaustedRecreate = "recreate"
+	// RestartExhaustedIgnore to leave the containers which exhausted the retries of their restart policy exited
+	RestartExhaustedIgnore = "ignore"
+)
+
+const (
+	// DuplicateNumbersKeepNewest to keep the newest of the service containers sharing a number, and remove the others
+	DuplicateNumbersKeepNewest = "keep-newest"
+	// DuplicateNumbersError to fail when service containers share a number
+	DuplicateNumbersError = "error"
+)
+
+const (
+	// ResourcePreflightWarn to warn when the host can't accommodate the resources reserved by the project
+	ResourcePreflightWarn = "warn"
+	// ResourcePreflightError to fail when the host can't accommodate the resources reserved by the project
+	ResourcePreflightError = "error"
+)
+
+const (
+	// VerifyImagesOff to skip the verification of images
+	VerifyImagesOff = "off"
+	// VerifyImagesWarn to warn about the images which fail verification
+	VerifyImagesWarn = "warn"
+	// VerifyImagesEnforce to fail when images fail verification
+	VerifyImagesEnforce = "enforce"
+)
+
+const (
+	// ScaleDownReferencedWarn to warn when scale down removes a container others depend on
+	ScaleDownReferencedWarn = "warn"
+	// ScaleDownReferencedReselect to remove other containers than the ones others depend on
+	ScaleDownReferencedReselect = "reselect"
+	// ScaleDownReferencedError to fail when scale down would remove a container others depend on
+	ScaleDownReferencedError = "error"
+	// ScaleDownReferencedRecreate to recreate the containers depending on a container removed by scale down
+	ScaleDownReferencedRecreate = "recreate"
+)
+
 // Stack holds the name and state of a compose application/stack
 type Stack struct {
 	ID          string
@@ -18,3 +18,18 @@ package api
 
 // ComposeCompatibility try to mimic compose v1 as much as possible
 const ComposeCompatibility = "COMPOSE_COMPATIBILITY"
+
+// ComposeRemoteRelativePaths accepts relative bind mounts in projects loaded
+// from a remote resource (OCI artifact, git repository) to be resolved
+// against the local cache directory the resource was downloaded to
+const ComposeRemoteRelativePaths = "COMPOSE_REMOTE_RELATIVE_PATHS"
+
+// ComposePathMap maps the host paths of bind mounts in projects loaded from a
+// remote resource to local paths, as a comma-separated list of SOURCE=TARGET
+// prefixes
+const ComposePathMap = "COMPOSE_PATH_MAP"
+
+// ComposeDecryptCmd is the command decrypting the environment values of
+// services encrypted at rest as ENC[ciphertext], run with the ciphertext on
+// stdin and writing the plain value to stdout
+const ComposeDecryptCmd = "COMPOSE_DECRYPT_CMD"
@@ -18,6 +18,7 @@ package api
 
 import (
 	"errors"
+	"fmt"
 )
 
 const (
@@ -86,3 +87,52 @@ func IsErrParsingFailed(err error) bool {
 func IsErrCanceled(err error) bool {
 	return errors.Is(err, ErrCanceled)
 }
+
+// DependencyMissingError is returned when a service can't be started as no
+// container of a required dependency exists
+type DependencyMissingError struct {
+	// Service is the service depending on Dependency
+	Service    string
+	Dependency string
+}
+
+func (e *DependencyMissingError) Error() string {
+	return fmt.Sprintf("%s is missing dependency %s", e.Service, e.Dependency)
+}
+
+// DependencyUnhealthyError is returned when a service can't be started as a
+// required dependency failed to meet the condition it depends on: being
+// healthy, completing successfully, or passing its probe
+type DependencyUnhealthyError struct {
+	// Service is the service depending on Dependency
+	Service    string
+	Dependency string
+	// Condition is the depends_on condition Dependency failed to meet
+	Condition string
+	// Err is the reason Dependency failed, or couldn't be checked, rendered
+	// as the error message
+	Err error
+}
+
+func (e *DependencyUnhealthyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DependencyUnhealthyError) Unwrap() error {
+	return e.Err
+}
+
+// ReferenceUnresolvableError is returned when a container can't be created as
+// it shares a volume or a namespace with a service which has no container
+type ReferenceUnresolvableError struct {
+	// Service is the service referencing Reference
+	Service   string
+	Reference string
+	// Kind is what is shared with Reference: volume, network namespace, IPC
+	// namespace or PID namespace
+	Kind string
+}
+
+func (e *ReferenceUnresolvableError) Error() string {
+	return fmt.Sprintf("cannot share %s with service %s: container missing", e.Kind, e.Reference)
+}
@@ -51,3 +51,17 @@ func TestIsUnknown(t *testing.T) {
 
 	assert.Assert(t, !IsUnknownError(errors.New("another error")))
 }
+
+func TestDependencyUnhealthyError(t *testing.T) {
+	cause := errors.New("container db-1 is unhealthy")
+	err := fmt.Errorf("starting web: %w", &DependencyUnhealthyError{Service: "web", Dependency: "db", Err: cause})
+	assert.Error(t, err, "starting web: container db-1 is unhealthy")
+
+	var unhealthy *DependencyUnhealthyError
+	assert.Assert(t, errors.As(err, &unhealthy))
+	assert.Equal(t, unhealthy.Dependency, "db")
+	assert.Assert(t, errors.Is(err, cause))
+
+	var missing *DependencyMissingError
+	assert.Assert(t, !errors.As(err, &missing))
+}
@@ -48,11 +48,13 @@ const (
 	StatusRestarting       = "Restarting"
 	StatusRestarted        = "Restarted"
 	StatusRunning          = "Running"
+	StatusUnchanged        = "Unchanged"
 	StatusCreated          = "Created"
 	StatusStopping         = "Stopping"
 	StatusStopped          = "Stopped"
 	StatusKilling          = "Killing"
 	StatusKilled           = "Killed"
+	StatusDraining         = "Draining"
 	StatusRemoving         = "Removing"
 	StatusRemoved          = "Removed"
 	StatusBuilding         = "Building"
@@ -81,6 +83,9 @@ type Resource struct {
 	Current  int64
 	Percent  int
 	Total    int64
+	// Optional marks events about an optional dependency (required: false),
+	// which writers render less prominently
+	Optional bool
 }
 
 func (e *Resource) StatusText() string {
@@ -96,7 +101,9 @@ func (e *Resource) StatusText() string {
 	}
 }
 
-// EventProcessor is notified about Compose operations and tasks
+// EventProcessor is notified about Compose operations and tasks.
+// Implementations must be safe for concurrent use: the containers of a
+// project are converged in parallel, each goroutine notifying its events.
 type EventProcessor interface {
 	// Start is triggered as a Compose operation is starting with context
 	Start(ctx context.Context, operation string)
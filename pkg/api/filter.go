@@ -0,0 +1,151 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ContainerFilter is a predicate on a container summary, parsed from a
+// `key=value` filter expression by ParseContainerFilters
+type ContainerFilter func(ContainerSummary) bool
+
+// ContainerFilters are combined with AND semantics: a container matches when
+// it matches all of them
+type ContainerFilters []ContainerFilter
+
+// Match reports whether the container matches all the filters
+func (f ContainerFilters) Match(ctr ContainerSummary) bool {
+	for _, filter := range f {
+		if !filter(ctr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the containers matching all the filters
+func (f ContainerFilters) Apply(containers []ContainerSummary) []ContainerSummary {
+	if len(f) == 0 {
+		return containers
+	}
+	var filtered []ContainerSummary
+	for _, ctr := range containers {
+		if f.Match(ctr) {
+			filtered = append(filtered, ctr)
+		}
+	}
+	return filtered
+}
+
+// ParseContainerFilters parses filter expressions, each as `key=value`, or
+// `key!=value` to negate it. Supported keys are:
+//   - label: the container has the label `name`, or `name=value`
+//   - status: the container is in the given state (running, exited, ...)
+//   - health: the container health status (healthy, unhealthy, starting), `none`
+//     for containers without a healthcheck
+//   - service: the container belongs to the service
+//   - name: the container has the name; `name~=regex` matches the name against a
+//     regular expression
+func ParseContainerFilters(expressions []string) (ContainerFilters, error) {
+	filters := make(ContainerFilters, 0, len(expressions))
+	for _, expr := range expressions {
+		filter, err := parseContainerFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func parseContainerFilter(expr string) (ContainerFilter, error) {
+	i := strings.IndexAny(expr, "!~=")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid filter %q: filters should be in form KEY=VALUE", expr)
+	}
+	key, rest := expr[:i], expr[i:]
+	var op string
+	for _, o := range []string{"!=", "~=", "="} {
+		if strings.HasPrefix(rest, o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid filter %q: filters should be in form KEY=VALUE", expr)
+	}
+	value := rest[len(op):]
+
+	var filter ContainerFilter
+	switch key {
+	case "label":
+		name, val, hasValue := strings.Cut(value, "=")
+		if name == "" {
+			return nil, fmt.Errorf("invalid filter %q: missing label name", expr)
+		}
+		filter = func(ctr ContainerSummary) bool {
+			v, ok := ctr.Labels[name]
+			return ok && (!hasValue || v == val)
+		}
+	case "status":
+		filter = func(ctr ContainerSummary) bool {
+			return string(ctr.State) == value
+		}
+	case "health":
+		filter = func(ctr ContainerSummary) bool {
+			if value == "none" {
+				return ctr.Health == ""
+			}
+			return string(ctr.Health) == value
+		}
+	case "service":
+		filter = func(ctr ContainerSummary) bool {
+			return ctr.Service == value
+		}
+	case "name":
+		if op == "~=" {
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+			}
+			return func(ctr ContainerSummary) bool {
+				return re.MatchString(ctr.Name)
+			}, nil
+		}
+		filter = func(ctr ContainerSummary) bool {
+			return ctr.Name == value
+		}
+	case "source":
+		return nil, ErrNotImplemented
+	default:
+		return nil, fmt.Errorf("unknown filter %s", key)
+	}
+
+	switch op {
+	case "~=":
+		return nil, errors.New("the ~= operator is only supported by the name filter")
+	case "!=":
+		return func(ctr ContainerSummary) bool {
+			return !filter(ctr)
+		}, nil
+	}
+	return filter, nil
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+)
+
+func TestContainerFilters(t *testing.T) {
+	containers := []ContainerSummary{
+		{
+			Name:    "myproject-web-1",
+			Service: "web",
+			State:   container.StateRunning,
+			Health:  container.Healthy,
+			Labels:  map[string]string{"tier": "front", "monitored": "true"},
+		},
+		{
+			Name:    "myproject-web-2",
+			Service: "web",
+			State:   container.StateRunning,
+			Health:  container.Unhealthy,
+			Labels:  map[string]string{"tier": "front"},
+		},
+		{
+			Name:    "myproject-db-1",
+			Service: "db",
+			State:   container.StateExited,
+			Labels:  map[string]string{"tier": "back"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{name: "none", want: []string{"myproject-web-1", "myproject-web-2", "myproject-db-1"}},
+		{name: "label", filters: []string{"label=monitored"}, want: []string{"myproject-web-1"}},
+		{name: "label value", filters: []string{"label=tier=back"}, want: []string{"myproject-db-1"}},
+		{name: "label negated", filters: []string{"label!=tier=front"}, want: []string{"myproject-db-1"}},
+		{name: "label missing negated", filters: []string{"label!=monitored"}, want: []string{"myproject-web-2", "myproject-db-1"}},
+		{name: "status", filters: []string{"status=exited"}, want: []string{"myproject-db-1"}},
+		{name: "status negated", filters: []string{"status!=exited"}, want: []string{"myproject-web-1", "myproject-web-2"}},
+		{name: "health", filters: []string{"health=unhealthy"}, want: []string{"myproject-web-2"}},
+		{name: "no healthcheck", filters: []string{"health=none"}, want: []string{"myproject-db-1"}},
+		{name: "service", filters: []string{"service=web"}, want: []string{"myproject-web-1", "myproject-web-2"}},
+		{name: "name", filters: []string{"name=myproject-web-2"}, want: []string{"myproject-web-2"}},
+		{name: "name regex", filters: []string{"name~=-1$"}, want: []string{"myproject-web-1", "myproject-db-1"}},
+		{name: "combined", filters: []string{"service=web", "health=healthy"}, want: []string{"myproject-web-1"}},
+		{name: "combined negation", filters: []string{"label=tier=front", "label!=monitored", "status=running"}, want: []string{"myproject-web-2"}},
+		{name: "no match", filters: []string{"service=db", "status=running"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := ParseContainerFilters(tt.filters)
+			assert.NilError(t, err)
+			var got []string
+			for _, ctr := range filters.Apply(containers) {
+				got = append(got, ctr.Name)
+			}
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseContainerFiltersErrors(t *testing.T) {
+	tests := []struct {
+		filter string
+		err    string
+	}{
+		{filter: "status", err: `invalid filter "status": filters should be in form KEY=VALUE`},
+		{filter: "=running", err: `invalid filter "=running": filters should be in form KEY=VALUE`},
+		{filter: "status!running", err: `invalid filter "status!running": filters should be in form KEY=VALUE`},
+		{filter: "label=", err: `invalid filter "label=": missing label name`},
+		{filter: "name~=(", err: "invalid filter \"name~=(\": error parsing regexp: missing closing ): `(`"},
+		{filter: "service~=web", err: "the ~= operator is only supported by the name filter"},
+		{filter: "image=nginx", err: "unknown filter image"},
+		{filter: "source=image", err: ErrNotImplemented.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := ParseContainerFilters([]string{tt.filter})
+			assert.Error(t, err, tt.err)
+		})
+	}
+}
@@ -29,10 +29,14 @@ const (
 	ServiceLabel = "com.docker.compose.service"
 	// ConfigHashLabel stores configuration hash for a compose service
 	ConfigHashLabel = "com.docker.compose.config-hash"
+	// HealthcheckExcludedHashLabel stores configuration hash for a compose service, healthcheck excluded
+	HealthcheckExcludedHashLabel = "com.docker.compose.config-hash.healthcheck-excluded"
 	// ContainerNumberLabel stores the container index of a replicated service
 	ContainerNumberLabel = "com.docker.compose.container-number"
 	// VolumeLabel allow to track resource related to a compose volume
 	VolumeLabel = "com.docker.compose.volume"
+	// VolumeProtectLabel marks a volume down keeps when removing volumes, unless forced
+	VolumeProtectLabel = "com.docker.compose.volume.protect"
 	// NetworkLabel allow to track resource related to a compose network
 	NetworkLabel = "com.docker.compose.network"
 	// WorkingDirLabel stores absolute path to compose project working directory
@@ -41,6 +45,8 @@ const (
 	ConfigFilesLabel = "com.docker.compose.project.config_files"
 	// EnvironmentFileLabel stores absolute path to compose project env file set by `--env-file`
 	EnvironmentFileLabel = "com.docker.compose.project.environment_file"
+	// EnvironmentHashLabel stores, on service containers, a hash of each variable interpolated in the service configuration
+	EnvironmentHashLabel = "com.docker.compose.project.environment_hash"
 	// OneoffLabel stores value 'True' for one-off containers created by `compose run`
 	OneoffLabel = "com.docker.compose.oneoff"
 	// SlugLabel stores unique slug used for one-off container identity
@@ -53,8 +59,46 @@ const (
 	VersionLabel = "com.docker.compose.version"
 	// ImageBuilderLabel stores the builder (classic or BuildKit) used to produce the image.
 	ImageBuilderLabel = "com.docker.compose.image.builder"
+	// BuildInputsLabel stores the hashes of the build context, Dockerfile, args and target an image was built from
+	BuildInputsLabel = "com.docker.compose.build.inputs"
 	// ContainerReplaceLabel is set when container is created to replace another container (recreated)
 	ContainerReplaceLabel = "com.docker.compose.replace"
+	// TemporaryNameLabel stores the temporary name a container replacing another one is created with, until renamed
+	TemporaryNameLabel = "com.docker.compose.replace.temporary_name"
+	// ReplacePausedLabel is set on a container replacing a paused one, which is paused once first started
+	ReplacePausedLabel = "com.docker.compose.replace.paused"
+	// ConvergenceLabel set to ConvergenceIgnore on a container leaves it out of convergence: it is never
+	// recreated, started, stopped or counted toward the scale of its service
+	ConvergenceLabel = "com.docker.compose.convergence"
+	// TraceIDLabel stores the W3C traceparent of the command which created the container, when traced
+	TraceIDLabel = "com.docker.compose.trace-id"
+)
+
+// ConvergenceIgnore is the value of ConvergenceLabel excluding a container from convergence
+const ConvergenceIgnore = "ignore"
+
+// AtomicGroupAnnotation is the service annotation declaring the atomic group
+// a service belongs to: when any service of the group must be recreated, all
+// of them are stopped together, then recreated, then started.
+const AtomicGroupAnnotation = "com.docker.compose.atomic-group"
+
+// SerializeStartAnnotation is the service annotation which, set to false,
+// lets its containers start concurrently with others. Containers are started
+// one at a time by default, so the engine doesn't assign the same port of a
+// published range to several of them.
+const SerializeStartAnnotation = "com.docker.compose.serialize-start"
+
+const (
+	// SidecarImageAnnotation is the service annotation declaring the image of
+	// a sidecar container created alongside each container of the service
+	SidecarImageAnnotation = "com.docker.compose.sidecar.image"
+	// SidecarShareAnnotation is the service annotation listing the namespaces
+	// the sidecar shares with the container it is paired with, as a comma
+	// separated list of network, pid and ipc. Defaults to network.
+	SidecarShareAnnotation = "com.docker.compose.sidecar.share"
+	// SidecarOfAnnotation is set on the services declared for sidecars, to the
+	// name of the service their containers are paired with
+	SidecarOfAnnotation = "com.docker.compose.sidecar.of"
 )
 
 // ComposeVersion is the compose tool version as declared by label VersionLabel
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+)
+
+// dependsOnMarkAbsentExtension is the depends_on extension opting a service
+// in to be told an optional dependency is absent, so the application can run
+// in a degraded mode rather than fail to reach it:
+//
+//	depends_on:
+//	  cache:
+//	    required: false
+//	    x-mark-absent: true
+//
+// The containers of the service are then created with
+// COMPOSE_ABSENT_CACHE=1 in their environment while the dependency has no
+// container running and none to be created by up. As the variable is part of
+// the service configuration, the containers are recreated once the
+// dependency is back.
+const dependsOnMarkAbsentExtension = "x-mark-absent"
+
+// absentDependencyPrefix prefixes the variable marking a dependency absent
+const absentDependencyPrefix = "COMPOSE_ABSENT_"
+
+// absentDependencyEnv returns the variable marking dep as absent
+func absentDependencyEnv(dep string) string {
+	return absentDependencyPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(dep))
+}
+
+// marksAbsent reports whether the dependent service asked to be told about
+// the absence of an optional dependency
+func marksAbsent(config types.ServiceDependency) bool {
+	if config.Required {
+		return false
+	}
+	mark, _ := config.Extensions[dependsOnMarkAbsentExtension].(bool)
+	return mark
+}
+
+// markAbsentDependencies sets the variables marking the absent optional
+// dependencies of the project services, a dependency being absent when the
+// project doesn't run any container for it and running reports none is.
+func markAbsentDependencies(project *types.Project, running func(dep string) bool) {
+	for name, service := range project.Services {
+		project.Services[name] = withAbsentDependencies(project, service, running)
+	}
+}
+
+// withAbsentDependencies returns service with the variables marking its absent
+// optional dependencies set.
+func withAbsentDependencies(project *types.Project, service types.ServiceConfig, running func(dep string) bool) types.ServiceConfig {
+	var env types.MappingWithEquals
+	for dep, config := range service.DependsOn {
+		if !marksAbsent(config) {
+			continue
+		}
+		if s, ok := project.Services[dep]; ok && s.GetScale() > 0 {
+			continue
+		}
+		if running(dep) {
+			continue
+		}
+		if env == nil {
+			env = types.MappingWithEquals{}.OverrideBy(service.Environment)
+		}
+		marker := "1"
+		env[absentDependencyEnv(dep)] = &marker
+	}
+	if env != nil {
+		service.Environment = env
+	}
+	return service
+}
+
+// observedRunning reports whether observed has a container running for a service
+func observedRunning(observed *ObservedState) func(string) bool {
+	return func(service string) bool {
+		for _, oc := range observed.Containers[service] {
+			if oc.State == container.StateRunning {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// containersRunning reports whether containers has one running for a service
+func containersRunning(containers Containers) func(string) bool {
+	return func(service string) bool {
+		return len(containers.filter(isService(service), isNotOneOff, isRunning)) > 0
+	}
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+)
+
+func TestMarkAbsentDependencies(t *testing.T) {
+	markAbsent := map[string]any{dependsOnMarkAbsentExtension: true}
+	debug := "true"
+	project := &types.Project{
+		Services: types.Services{
+			"web": {
+				Name:        "web",
+				Environment: types.MappingWithEquals{"DEBUG": &debug},
+				DependsOn: types.DependsOnConfig{
+					"db":           {Condition: types.ServiceConditionStarted, Required: false, Extensions: markAbsent},
+					"search-index": {Condition: types.ServiceConditionStarted, Required: false, Extensions: markAbsent},
+					"cache":        {Condition: types.ServiceConditionStarted, Required: false, Extensions: markAbsent},
+					"queue":        {Condition: types.ServiceConditionStarted, Required: false},
+					"auth":         {Condition: types.ServiceConditionStarted, Required: true, Extensions: markAbsent},
+				},
+			},
+			"db":    {Name: "db"},
+			"cache": {Name: "cache", Scale: intPtr(0)},
+		},
+		DisabledServices: types.Services{
+			"search-index": {Name: "search-index"},
+			"queue":        {Name: "queue"},
+			"auth":         {Name: "auth"},
+		},
+	}
+	observed := &ObservedState{Containers: map[string][]ObservedContainer{
+		"search-index": {{Name: "p-search-index-1", State: container.StateRunning}},
+		"cache":        {{Name: "p-cache-1", State: container.StateExited}},
+	}}
+	original := project.Services["web"].Environment
+
+	markAbsentDependencies(project, observedRunning(observed))
+
+	// db is created by up, search-index is running although disabled, queue
+	// and auth didn't opt in or are required
+	one := "1"
+	assert.DeepEqual(t, project.Services["web"].Environment, types.MappingWithEquals{
+		"DEBUG":                &debug,
+		"COMPOSE_ABSENT_CACHE": &one,
+	})
+	assert.DeepEqual(t, original, types.MappingWithEquals{"DEBUG": &debug})
+	assert.Equal(t, len(project.Services["db"].Environment), 0)
+}
+
+func TestAbsentDependencyEnv(t *testing.T) {
+	assert.Equal(t, absentDependencyEnv("search-index.v2"), "COMPOSE_ABSENT_SEARCH_INDEX_V2")
+}
@@ -23,12 +23,32 @@ const (
 	//
 	// New features in this version:
 	//  - ContainerCreate API accepts multiple EndpointsConfig entries
+	//  - Healthcheck start_interval
 	//
 	// Before this version:
 	//  - Only a single EndpointsConfig entry was accepted in ContainerCreate
 	//  - Extra networks must be connected individually after container creation via NetworkConnect
+	//  - start_interval was ignored, probes running every interval during the start period
 	apiVersion144 = "1.44"
 
+	// apiVersion146 represents Docker Engine API version 1.46 (Engine v27.0).
+	//
+	// New features in this version:
+	//  - IPv6 networks declaring no subnet get one allocated from a ULA prefix
+	//
+	// Before this version:
+	//  - IPv6 networks needed a subnet, declared or from an IPv6 default address pool
+	apiVersion146 = "1.46"
+
+	// apiVersion147 represents Docker Engine API version 1.47.
+	//
+	// New features in this version:
+	//  - Networks can be created with IPv4 disabled (enable_ipv4: false)
+	//
+	// Before this version:
+	//  - All networks had IPv4 enabled
+	apiVersion147 = "1.47"
+
 	// apiVersion148 represents Docker Engine API version 1.48 (Engine v28.0).
 	//
 	// New features in this version:
@@ -53,6 +73,9 @@ const (
 // Docker Engine version strings for user-facing error messages.
 // These should be used in error messages to provide clear version requirements.
 const (
+	// dockerEngineV25 is the major version string for Docker Engine 25.x
+	dockerEngineV25 = "v25"
+
 	// dockerEngineV28 is the major version string for Docker Engine 28.x
 	dockerEngineV28 = "v28"
 
@@ -46,7 +46,7 @@ func (s *composeService) Build(ctx context.Context, project *types.Project, opti
 				}
 				return err
 			})(ctx)
-	}, "build", s.events)
+	}, "build", s.events(ctx))
 }
 
 func (s *composeService) build(ctx context.Context, project *types.Project, options api.BuildOptions, localImages map[string]api.ImageSummary) (map[string]string, error) {
@@ -86,7 +86,11 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		if localImagePresent && service.PullPolicy != types.PullPolicyBuild {
 			return nil
 		}
-		serviceToBuild[serviceName] = *service
+		if !options.Print {
+			// project is a copy, the label doesn't change the one of the caller
+			project.Services[serviceName] = withBuildInputsLabel(*service)
+		}
+		serviceToBuild[serviceName] = project.Services[serviceName]
 		return nil
 	}, policy)
 	if err != nil {
@@ -97,6 +101,12 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		return imageIDs, nil
 	}
 
+	if !options.Print {
+		if err := checkBuildSources(project, serviceToBuild, options); err != nil {
+			return nil, err
+		}
+	}
+
 	bake, err := buildWithBake(s.dockerCli)
 	if err != nil {
 		return nil, err
@@ -108,24 +118,42 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 }
 
 func (s *composeService) ensureImagesExists(ctx context.Context, project *types.Project, buildOpts *api.BuildOptions, quietPull bool) error {
+	_, err := s.ensureImages(ctx, project, buildOpts, quietPull, false)
+	return err
+}
+
+// ensureImages pulls and builds the images of the project services. With
+// pipeline set, the images of the services which can be created as soon as
+// their own image is available are pulled in the background, and tracked by
+// the imagePulls returned.
+func (s *composeService) ensureImages(ctx context.Context, project *types.Project, buildOpts *api.BuildOptions, quietPull bool, pipeline bool) (*imagePulls, error) {
 	for name, service := range project.Services {
 		if service.Provider == nil && service.Image == "" && service.Build == nil {
-			return fmt.Errorf("invalid service %q. Must specify either image or build", name)
+			return nil, fmt.Errorf("invalid service %q. Must specify either image or build", name)
 		}
 	}
 
 	images, err := s.getLocalImagesDigests(ctx, project)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var pulls *imagePulls
+	if pipeline {
+		pulls, err = s.startImagePulls(ctx, project, images, quietPull)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err = tracing.SpanWrapFunc("project/pull", tracing.ProjectOptions(ctx, project),
 		func(ctx context.Context) error {
-			return s.pullRequiredImages(ctx, project, images, quietPull)
+			return s.pullRequiredImages(ctx, project, images, quietPull, pulls)
 		},
 	)(ctx)
 	if err != nil {
-		return err
+		pulls.stop()
+		return nil, err
 	}
 
 	if buildOpts != nil {
@@ -147,7 +175,8 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 			},
 		)(ctx)
 		if err != nil {
-			return err
+			pulls.stop()
+			return nil, err
 		}
 	}
 
@@ -155,7 +184,8 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 	for name, service := range project.Services {
 		image := api.GetImageNameOrDefault(service, project.Name)
 		img, ok := images[image]
-		if ok {
+		// the digest of images pulled in the background is set once pulled
+		if ok && !pulls.pending(name) {
 			service.CustomLabels.Add(api.ImageDigestLabel, img.ID)
 		}
 
@@ -163,7 +193,7 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 
 		project.Services[name] = service
 	}
-	return nil
+	return pulls, nil
 }
 
 func resolveImageVolumes(service *types.ServiceConfig, images map[string]api.ImageSummary, projectName string) {
@@ -283,6 +313,79 @@ func getImageBuildLabels(project *types.Project, service types.ServiceConfig) ty
 	return ret
 }
 
+// WithBuildDependencies selects services in project along with the services
+// they depend on, declared by depends_on or referenced as an additional build
+// context with the service: prefix, transitively. It also returns the
+// services selected only because a build references them, sorted.
+func WithBuildDependencies(project *types.Project, services []string) (*types.Project, []string, error) {
+	if len(services) == 0 {
+		return project, nil, nil
+	}
+	declared, err := project.WithSelectedServices(services)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected := services
+	for {
+		p, err := project.WithServicesEnabled(selected...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if p, err = p.WithSelectedServices(selected); err != nil {
+			return nil, nil, err
+		}
+		names := p.ServiceNames()
+		expanded := addBuildDependencies(names, project)
+		if len(expanded) > len(names) {
+			selected = expanded
+			continue
+		}
+		var added []string
+		for _, name := range names {
+			if _, ok := declared.Services[name]; !ok {
+				added = append(added, name)
+			}
+		}
+		return p, added, nil
+	}
+}
+
+// buildReferenceLevels sorts the services of project by levels, the services
+// of a level only referencing services of the previous levels as additional
+// build contexts.
+func buildReferenceLevels(project *types.Project) [][]string {
+	levels := map[string]int{}
+	var level func(name string, visiting utils.Set[string]) int
+	level = func(name string, visiting utils.Set[string]) int {
+		if l, ok := levels[name]; ok {
+			return l
+		}
+		service := project.Services[name]
+		l := 0
+		if service.Build != nil && !visiting.Has(name) {
+			visiting.Add(name)
+			for _, c := range service.Build.AdditionalContexts {
+				ref, found := strings.CutPrefix(c, types.ServicePrefix)
+				if _, ok := project.Services[ref]; found && ok {
+					l = max(l, level(ref, visiting)+1)
+				}
+			}
+			visiting.Remove(name)
+		}
+		levels[name] = l
+		return l
+	}
+	var sorted [][]string
+	for _, name := range project.ServiceNames() {
+		l := level(name, utils.NewSet[string]())
+		for len(sorted) <= l {
+			sorted = append(sorted, nil)
+		}
+		sorted[l] = append(sorted[l], name)
+	}
+	return sorted
+}
+
 func addBuildDependencies(services []string, project *types.Project) []string {
 	servicesWithDependencies := utils.NewSet(services...)
 	for _, service := range services {
@@ -213,7 +213,7 @@ func (s *composeService) doBuildBake(ctx context.Context, project *types.Project
 		// resolve), but only emit "Building" progress and track expected
 		// images for services we actually plan to build.
 		if _, ok := serviceToBeBuild[serviceName]; ok {
-			s.events.On(buildingEvent(image))
+			s.events(ctx).On(buildingEvent(image))
 			expectedImages[serviceName] = image
 		}
 
@@ -222,7 +222,7 @@ func (s *composeService) doBuildBake(ctx context.Context, project *types.Project
 
 		target := targets[serviceName]
 
-		secrets, env := toBakeSecrets(project, buildConfig.Secrets)
+		secrets, env := toBakeSecrets(project, buildSecrets(project, service, options))
 		secretsEnv = append(secretsEnv, env...)
 
 		cfg.Targets[target] = bakeTarget{
@@ -241,7 +241,7 @@ func (s *composeService) doBuildBake(ctx context.Context, project *types.Project
 			Platforms:     buildConfig.Platforms,
 			Target:        buildConfig.Target,
 			Secrets:       secrets,
-			SSH:           toBakeSSH(append(buildConfig.SSH, options.SSHs...)),
+			SSH:           toBakeSSH(buildSSH(service, options)),
 			Pull:          pull,
 			NoCache:       noCache,
 			ShmSize:       buildConfig.ShmSize,
@@ -326,7 +326,7 @@ func (s *composeService) doBuildBake(ctx context.Context, project *types.Project
 	logrus.Debugf("Executing bake with args: %v", args)
 
 	if s.dryRun {
-		return s.dryRunBake(cfg), nil
+		return s.dryRunBake(ctx, cfg), nil
 	}
 	cmd := exec.CommandContext(ctx, buildx.Path, args...)
 
@@ -414,7 +414,7 @@ func (s *composeService) doBuildBake(ctx context.Context, project *types.Project
 		}
 		results[image] = built.Digest
 		builtImages = append(builtImages, image)
-		s.events.On(builtEvent(image))
+		s.events(ctx).On(builtEvent(image))
 	}
 
 	// Bake reports the top-level attested image/index digest, which changes on
@@ -530,21 +530,16 @@ func toBakeSSH(ssh types.SSHConfig) []string {
 	return s
 }
 
-func toBakeSecrets(project *types.Project, secrets []types.ServiceSecretConfig) ([]string, []string) {
+func toBakeSecrets(project *types.Project, secrets []api.BuildSecret) ([]string, []string) {
 	var s []string
 	var env []string
-	for _, ref := range secrets {
-		def := project.Secrets[ref.Source]
-		target := ref.Target
-		if target == "" {
-			target = ref.Source
-		}
+	for _, secret := range secrets {
 		switch {
-		case def.Environment != "":
-			env = append(env, fmt.Sprintf("%s=%s", def.Environment, project.Environment[def.Environment]))
-			s = append(s, fmt.Sprintf("id=%s,type=env,env=%s", target, def.Environment))
-		case def.File != "":
-			s = append(s, fmt.Sprintf("id=%s,type=file,src=%s", target, def.File))
+		case secret.Env != "":
+			env = append(env, fmt.Sprintf("%s=%s", secret.Env, project.Environment[secret.Env]))
+			s = append(s, fmt.Sprintf("id=%s,type=env,env=%s", secret.ID, secret.Env))
+		case secret.File != "":
+			s = append(s, fmt.Sprintf("id=%s,type=file,src=%s", secret.ID, secret.File))
 		}
 	}
 	return s, env
@@ -596,26 +591,26 @@ func dockerFilePath(ctxName string, dockerfile string) string {
 	return dockerfile
 }
 
-func (s *composeService) dryRunBake(cfg bakeConfig) map[string]string {
+func (s *composeService) dryRunBake(ctx context.Context, cfg bakeConfig) map[string]string {
 	bakeResponse := map[string]string{}
 	for name, target := range cfg.Targets {
 		dryRunUUID := fmt.Sprintf("dryRun-%x", sha1.Sum([]byte(name)))
-		s.displayDryRunBuildEvent(name, dryRunUUID, target.Tags[0])
+		s.displayDryRunBuildEvent(ctx, name, dryRunUUID, target.Tags[0])
 		bakeResponse[name] = dryRunUUID
 	}
 	for name := range bakeResponse {
-		s.events.On(builtEvent(name))
+		s.events(ctx).On(builtEvent(name))
 	}
 	return bakeResponse
 }
 
-func (s *composeService) displayDryRunBuildEvent(name, dryRunUUID, tag string) {
-	s.events.On(api.Resource{
+func (s *composeService) displayDryRunBuildEvent(ctx context.Context, name, dryRunUUID, tag string) {
+	s.events(ctx).On(api.Resource{
 		ID:     name + " ==>",
 		Status: api.Done,
 		Text:   fmt.Sprintf("==> writing image %s", dryRunUUID),
 	})
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     name + " ==> ==>",
 		Status: api.Done,
 		Text:   fmt.Sprintf(`naming to %s`, tag),
@@ -0,0 +1,311 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/command/image/build"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/watch"
+)
+
+// buildInputs holds the hashes of the inputs of a service build, stored on
+// the image built as api.BuildInputsLabel
+type buildInputs struct {
+	context    string
+	dockerfile string
+	args       string
+	target     string
+}
+
+// String returns the value of the label buildInputs are stored as
+func (b buildInputs) String() string {
+	return fmt.Sprintf("context=%s,dockerfile=%s,args=%s,target=%s", b.context, b.dockerfile, b.args, b.target)
+}
+
+// parseBuildInputs parses the value of api.BuildInputsLabel
+func parseBuildInputs(label string) (buildInputs, bool) {
+	var b buildInputs
+	fields := map[string]*string{"context": &b.context, "dockerfile": &b.dockerfile, "args": &b.args, "target": &b.target}
+	for field := range strings.SplitSeq(label, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || fields[key] == nil {
+			return buildInputs{}, false
+		}
+		*fields[key] = value
+		delete(fields, key)
+	}
+	return b, len(fields) == 0
+}
+
+// changed returns the names of the inputs which differ from previous
+func (b buildInputs) changed(previous buildInputs) []string {
+	var names []string
+	if b.context != previous.context {
+		names = append(names, "context")
+	}
+	if b.dockerfile != previous.dockerfile {
+		names = append(names, "dockerfile")
+	}
+	if b.args != previous.args {
+		names = append(names, "args")
+	}
+	if b.target != previous.target {
+		names = append(names, "target")
+	}
+	return names
+}
+
+// hashBuildInputs hashes the inputs of the build of a service: the files of
+// its local build context not excluded by its .dockerignore, its Dockerfile,
+// build args and target
+func hashBuildInputs(config *types.BuildConfig) (buildInputs, error) {
+	contextType, err := build.DetectContextType(config.Context)
+	if err != nil {
+		return buildInputs{}, err
+	}
+	if contextType != build.ContextTypeLocal {
+		return buildInputs{}, fmt.Errorf("build context %s isn't a local directory", config.Context)
+	}
+	contextHash, err := hashBuildContext(config)
+	if err != nil {
+		return buildInputs{}, err
+	}
+	dockerfileHash, err := hashDockerfile(config)
+	if err != nil {
+		return buildInputs{}, err
+	}
+	args := sha256.New()
+	for _, key := range slices.Sorted(maps.Keys(config.Args)) {
+		if value := config.Args[key]; value != nil {
+			_, _ = fmt.Fprintf(args, "%s=%s\x00", key, *value)
+		} else {
+			_, _ = fmt.Fprintf(args, "%s\x00", key)
+		}
+	}
+	target := sha256.Sum256([]byte(config.Target))
+	return buildInputs{
+		context:    contextHash,
+		dockerfile: dockerfileHash,
+		args:       hex.EncodeToString(args.Sum(nil)),
+		target:     hex.EncodeToString(target[:]),
+	}, nil
+}
+
+// hashBuildContext hashes the path, mode and content of the files of the
+// build context, as sent to the builder. The Dockerfile is hashed on its own.
+func hashBuildContext(config *types.BuildConfig) (string, error) {
+	root, err := filepath.Abs(config.Context)
+	if err != nil {
+		return "", err
+	}
+	ignore, err := watch.LoadDockerIgnore(config)
+	if err != nil {
+		return "", err
+	}
+	dockerfile, err := filepath.Abs(dockerfilePath(config))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if entry.IsDir() {
+			excluded, err := ignore.MatchesEntireDir(path)
+			if err != nil {
+				return err
+			}
+			if excluded {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == dockerfile {
+			return nil
+		}
+		excluded, err := ignore.Matches(path)
+		if err != nil || excluded {
+			return err
+		}
+		return hashContextFile(h, root, path, entry)
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashContextFile writes the path relative to root, mode and content of a
+// file of the build context to h, the target of a symbolic link as content
+func hashContextFile(h hash.Hash, root, path string, entry fs.DirEntry) error {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00", filepath.ToSlash(rel), info.Mode())
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		_, _ = io.WriteString(h, target)
+	case info.Mode().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+	}
+	_, _ = h.Write([]byte{0})
+	return nil
+}
+
+// hashDockerfile hashes the inline Dockerfile of the build, or the content
+// of its Dockerfile, which is sent to the builder even when ignored
+func hashDockerfile(config *types.BuildConfig) (string, error) {
+	content := []byte(config.DockerfileInline)
+	if config.DockerfileInline == "" {
+		var err error
+		content, err = os.ReadFile(dockerfilePath(config))
+		if err != nil {
+			return "", err
+		}
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dockerfilePath returns the path of the Dockerfile of a local build context
+func dockerfilePath(config *types.BuildConfig) string {
+	dockerfile := config.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if !filepath.IsAbs(dockerfile) {
+		dockerfile = filepath.Join(config.Context, dockerfile)
+	}
+	return dockerfile
+}
+
+// withBuildInputsLabel returns service with its build labelled with the
+// hashes of its inputs, so a later check can tell whether they changed. The
+// build of the project is left unchanged.
+func withBuildInputsLabel(service types.ServiceConfig) types.ServiceConfig {
+	inputs, err := hashBuildInputs(service.Build)
+	if err != nil {
+		logrus.Debugf("not labelling the image of service %s with its build inputs: %v", service.Name, err)
+		return service
+	}
+	config := *service.Build
+	config.Labels = maps.Clone(config.Labels)
+	if config.Labels == nil {
+		config.Labels = types.Labels{}
+	}
+	config.Labels[api.BuildInputsLabel] = inputs.String()
+	service.Build = &config
+	return service
+}
+
+// BuildChanges implements api.Compose.BuildChanges
+func (s *composeService) BuildChanges(ctx context.Context, project *types.Project, options api.BuildOptions) ([]api.BuildChange, error) {
+	if err := options.Apply(project); err != nil {
+		return nil, err
+	}
+	services := options.Services
+	if len(services) == 0 {
+		services = project.ServiceNames()
+	}
+	var changes []api.BuildChange
+	for _, name := range slices.Sorted(slices.Values(services)) {
+		service, err := project.GetService(name)
+		if err != nil {
+			return nil, err
+		}
+		if service.Build == nil {
+			continue
+		}
+		change, err := s.buildChange(ctx, project, service)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// buildChange compares the build inputs of service with the ones its image
+// was built from
+func (s *composeService) buildChange(ctx context.Context, project *types.Project, service types.ServiceConfig) (api.BuildChange, error) {
+	change := api.BuildChange{Service: service.Name, Image: api.GetImageNameOrDefault(service, project.Name), Rebuild: true}
+	inputs, err := hashBuildInputs(service.Build)
+	if err != nil {
+		change.Reason = err.Error()
+		return change, nil
+	}
+	inspect, err := s.apiClient().ImageInspect(ctx, change.Image)
+	if errdefs.IsNotFound(err) {
+		change.Reason = "image not found"
+		return change, nil
+	}
+	if err != nil {
+		return change, err
+	}
+	var label string
+	if inspect.Config != nil {
+		label = inspect.Config.Labels[api.BuildInputsLabel]
+	}
+	previous, ok := parseBuildInputs(label)
+	if !ok {
+		change.Reason = "image built without build inputs label"
+		return change, nil
+	}
+	if changed := inputs.changed(previous); len(changed) > 0 {
+		change.Reason = strings.Join(changed, ", ") + " changed"
+		return change, nil
+	}
+	change.Rebuild = false
+	return change, nil
+}
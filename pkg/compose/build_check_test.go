@@ -0,0 +1,194 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/client"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// writeBuildContext writes files, by path relative to the returned build
+// context directory
+func writeBuildContext(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		writeContextFile(t, dir, path, content)
+	}
+	return dir
+}
+
+func writeContextFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	path = filepath.Join(dir, path)
+	assert.NilError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestHashBuildContext(t *testing.T) {
+	dir := writeBuildContext(t, map[string]string{
+		"Dockerfile":            "FROM alpine\nCOPY . /app\n",
+		".dockerignore":         "*.log\nnode_modules\n!keep.log\n",
+		"main.go":               "package main",
+		"debug.log":             "started",
+		"keep.log":              "kept",
+		"node_modules/left-pad": "module.exports",
+		"cmd/tool/tool.go":      "package tool",
+	})
+	config := &types.BuildConfig{Context: dir, Dockerfile: "Dockerfile"}
+	hashed, err := hashBuildContext(config)
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		changed bool
+	}{
+		{name: "ignored file", path: "debug.log"},
+		{name: "Dockerfile, hashed on its own", path: "Dockerfile"},
+		{name: "ignored directory", path: "node_modules/left-pad"},
+		{name: "new ignored file", path: "node_modules/is-odd"},
+		{name: "file", path: "main.go", changed: true},
+		{name: "nested file", path: "cmd/tool/tool.go", changed: true},
+		{name: "exception to an ignore rule", path: "keep.log", changed: true},
+		{name: "new file", path: "README.md", changed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeContextFile(t, dir, tt.path, "changed")
+			got, err := hashBuildContext(config)
+			assert.NilError(t, err)
+			assert.Equal(t, got != hashed, tt.changed)
+			hashed = got
+		})
+	}
+}
+
+func TestHashBuildContextDockerfileIgnore(t *testing.T) {
+	dir := writeBuildContext(t, map[string]string{
+		"app.Dockerfile":              "FROM alpine\n",
+		"app.Dockerfile.dockerignore": "docs\n",
+		".dockerignore":               "src\n",
+		"docs/README.md":              "docs",
+		"src/main.go":                 "package main",
+	})
+	config := &types.BuildConfig{Context: dir, Dockerfile: "app.Dockerfile"}
+	hashed, err := hashBuildContext(config)
+	assert.NilError(t, err)
+
+	// the ignore file of the Dockerfile applies instead of the default one
+	writeContextFile(t, dir, "docs/README.md", "changed")
+	got, err := hashBuildContext(config)
+	assert.NilError(t, err)
+	assert.Equal(t, got, hashed)
+
+	writeContextFile(t, dir, "src/main.go", "changed")
+	got, err = hashBuildContext(config)
+	assert.NilError(t, err)
+	assert.Assert(t, got != hashed)
+}
+
+func TestHashBuildInputs(t *testing.T) {
+	dir := writeBuildContext(t, map[string]string{"Dockerfile": "FROM alpine\n"})
+	version := "1.0"
+	config := &types.BuildConfig{Context: dir, Dockerfile: "Dockerfile", Args: types.MappingWithEquals{"VERSION": &version}}
+	inputs, err := hashBuildInputs(config)
+	assert.NilError(t, err)
+
+	// the label the inputs are stored as round-trips
+	parsed, ok := parseBuildInputs(inputs.String())
+	assert.Assert(t, ok)
+	assert.Equal(t, parsed, inputs)
+	for _, label := range []string{"", "context=a,dockerfile=b", "context=a,dockerfile=b,args=c,target=d,extra=e", "context"} {
+		_, ok := parseBuildInputs(label)
+		assert.Assert(t, !ok, label)
+	}
+
+	upgraded := "2.0"
+	changed := *config
+	changed.Args = types.MappingWithEquals{"VERSION": &upgraded}
+	changed.Target = "prod"
+	writeContextFile(t, dir, "Dockerfile", "FROM alpine:3\n")
+	got, err := hashBuildInputs(&changed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got.changed(inputs), []string{"dockerfile", "args", "target"})
+
+	_, err = hashBuildInputs(&types.BuildConfig{Context: "https://github.com/docker/compose.git"})
+	assert.Error(t, err, "build context https://github.com/docker/compose.git isn't a local directory")
+}
+
+func TestWithBuildInputsLabel(t *testing.T) {
+	dir := writeBuildContext(t, map[string]string{"Dockerfile": "FROM alpine\n"})
+	labels := types.Labels{"owner": "team"}
+	service := types.ServiceConfig{Name: "web", Build: &types.BuildConfig{Context: dir, Dockerfile: "Dockerfile", Labels: labels}}
+
+	labelled := withBuildInputsLabel(service)
+	inputs, err := hashBuildInputs(service.Build)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, labelled.Build.Labels, types.Labels{"owner": "team", api.BuildInputsLabel: inputs.String()})
+	// the build of the service passed is left unchanged
+	assert.DeepEqual(t, service.Build.Labels, types.Labels{"owner": "team"})
+}
+
+func TestBuildChanges(t *testing.T) {
+	dir := writeBuildContext(t, map[string]string{"Dockerfile": "FROM alpine\n"})
+	build := func(target string) *types.BuildConfig {
+		return &types.BuildConfig{Context: dir, Dockerfile: "Dockerfile", Target: target}
+	}
+	built, err := hashBuildInputs(build("prod"))
+	assert.NilError(t, err)
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web":      {Name: "web", Build: build("prod")},
+			"worker":   {Name: "worker", Build: build("dev")},
+			"new":      {Name: "new", Build: build("prod")},
+			"legacy":   {Name: "legacy", Build: build("prod")},
+			"database": {Name: "database", Image: "postgres"},
+		},
+	}
+	svc, apiClient := newTestService(t)
+	inspected := func(labels map[string]string) client.ImageInspectResult {
+		return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+			Config: &dockerspec.DockerOCIImageConfig{ImageConfig: specs.ImageConfig{Labels: labels}},
+		}}
+	}
+	apiClient.EXPECT().ImageInspect(gomock.Any(), "myproject-web").Return(inspected(map[string]string{api.BuildInputsLabel: built.String()}), nil)
+	apiClient.EXPECT().ImageInspect(gomock.Any(), "myproject-worker").Return(inspected(map[string]string{api.BuildInputsLabel: built.String()}), nil)
+	apiClient.EXPECT().ImageInspect(gomock.Any(), "myproject-new").Return(client.ImageInspectResult{}, notFoundError{})
+	apiClient.EXPECT().ImageInspect(gomock.Any(), "myproject-legacy").Return(inspected(nil), nil)
+
+	changes, err := svc.BuildChanges(t.Context(), project, api.BuildOptions{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, changes, []api.BuildChange{
+		{Service: "legacy", Image: "myproject-legacy", Rebuild: true, Reason: "image built without build inputs label"},
+		{Service: "new", Image: "myproject-new", Rebuild: true, Reason: "image not found"},
+		{Service: "web", Image: "myproject-web"},
+		{Service: "worker", Image: "myproject-worker", Rebuild: true, Reason: "target changed"},
+	})
+}
@@ -88,12 +88,12 @@ func (s *composeService) doBuildClassic(ctx context.Context, project *types.Proj
 		}
 
 		image := api.GetImageNameOrDefault(service, project.Name)
-		s.events.On(buildingEvent(image))
+		s.events(ctx).On(buildingEvent(image))
 		id, err := s.doBuildImage(ctx, project, service, options)
 		if err != nil {
 			return err
 		}
-		s.events.On(builtEvent(image))
+		s.events(ctx).On(builtEvent(image))
 		builtDigests[getServiceIndex(name)] = id
 
 		if options.Push {
@@ -135,10 +135,10 @@ func (s *composeService) doBuildImage(ctx context.Context, project *types.Projec
 	if len(service.Build.AdditionalContexts) > 0 {
 		return "", fmt.Errorf("the classic builder doesn't support additional contexts, set DOCKER_BUILDKIT=1 to use BuildKit")
 	}
-	if len(service.Build.SSH) > 0 {
+	if len(service.Build.SSH) > 0 || len(options.SSHs) > 0 {
 		return "", fmt.Errorf("the classic builder doesn't support SSH keys, set DOCKER_BUILDKIT=1 to use BuildKit")
 	}
-	if len(service.Build.Secrets) > 0 {
+	if len(service.Build.Secrets) > 0 || len(options.Secrets) > 0 {
 		return "", fmt.Errorf("the classic builder doesn't support secrets, set DOCKER_BUILDKIT=1 to use BuildKit")
 	}
 
@@ -260,7 +260,7 @@ func (s *composeService) doBuildImage(ctx context.Context, project *types.Projec
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	s.events.On(buildingEvent(imageName))
+	s.events(ctx).On(buildingEvent(imageName))
 	response, err := s.apiClient().ImageBuild(ctx, body, buildOpts)
 	if err != nil {
 		return "", err
@@ -289,7 +289,7 @@ func (s *composeService) doBuildImage(ctx context.Context, project *types.Projec
 		}
 		return "", err
 	}
-	s.events.On(builtEvent(imageName))
+	s.events(ctx).On(builtEvent(imageName))
 	return imageID, nil
 }
 
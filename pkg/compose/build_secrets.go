@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// buildSecrets returns the secrets used to build service: the ones it
+// declares, overridden by the ones passed in the build options sharing their
+// id, then the other ones passed in the build options
+func buildSecrets(project *types.Project, service types.ServiceConfig, options api.BuildOptions) []api.BuildSecret {
+	var secrets []api.BuildSecret
+	for _, ref := range service.Build.Secrets {
+		id := ref.Target
+		if id == "" {
+			id = ref.Source
+		}
+		if slices.ContainsFunc(options.Secrets, func(s api.BuildSecret) bool { return s.ID == id }) {
+			continue
+		}
+		def := project.Secrets[ref.Source]
+		secrets = append(secrets, api.BuildSecret{ID: id, File: def.File, Env: def.Environment})
+	}
+	return append(secrets, options.Secrets...)
+}
+
+// buildSSH returns the SSH authentications used to build service: the ones it
+// declares, overridden by the ones passed in the build options sharing their
+// id, then the other ones passed in the build options
+func buildSSH(service types.ServiceConfig, options api.BuildOptions) types.SSHConfig {
+	var keys types.SSHConfig
+	for _, key := range service.Build.SSH {
+		if !slices.ContainsFunc(options.SSHs, func(k types.SSHKey) bool { return k.ID == key.ID }) {
+			keys = append(keys, key)
+		}
+	}
+	return append(keys, options.SSHs...)
+}
+
+// checkBuildSources reports all the secrets and SSH keys the services to build
+// use which source doesn't exist at once, before any build is started
+func checkBuildSources(project *types.Project, services types.Services, options api.BuildOptions) error {
+	var errs []error
+	for _, name := range sortedKeys(services) {
+		service := services[name]
+		if service.Build == nil {
+			continue
+		}
+		for _, secret := range buildSecrets(project, service, options) {
+			switch {
+			case secret.File != "":
+				if _, err := os.Stat(secret.File); err != nil {
+					errs = append(errs, fmt.Errorf("service %q build secret %q: %w", name, secret.ID, err))
+				}
+			case secret.Env != "":
+				if _, ok := project.Environment[secret.Env]; !ok {
+					errs = append(errs, fmt.Errorf("service %q build secret %q: environment variable %s is not set", name, secret.ID, secret.Env))
+				}
+			}
+		}
+		for _, key := range buildSSH(service, options) {
+			if key.Path == "" {
+				continue
+			}
+			for path := range strings.SplitSeq(key.Path, ",") {
+				if _, err := os.Stat(path); err != nil {
+					errs = append(errs, fmt.Errorf("service %q build SSH key %q: %w", name, key.ID, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
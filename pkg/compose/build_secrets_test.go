@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestBuildSecretsMerge(t *testing.T) {
+	project := &types.Project{
+		Secrets: types.Secrets{
+			"npmrc": {File: "/project/npmrc"},
+			"token": {Environment: "TOKEN"},
+		},
+		Services: types.Services{
+			"web": {Name: "web", Build: &types.BuildConfig{
+				Secrets: []types.ServiceSecretConfig{{Source: "npmrc"}, {Source: "token", Target: "api_token"}},
+				SSH:     types.SSHConfig{{ID: "default"}, {ID: "github", Path: "/project/github_key"}},
+			}},
+			"worker": {Name: "worker", Build: &types.BuildConfig{}},
+		},
+	}
+	options := api.BuildOptions{
+		Secrets: []api.BuildSecret{{ID: "npmrc", File: "/home/user/.npmrc"}, {ID: "extra", Env: "EXTRA"}},
+		SSHs:    []types.SSHKey{{ID: "github", Path: "/home/user/.ssh/id_ed25519"}},
+	}
+
+	// the secrets passed on the command line override the ones declared
+	// with the same id, and are added to the services declaring none
+	assert.DeepEqual(t, buildSecrets(project, project.Services["web"], options), []api.BuildSecret{
+		{ID: "api_token", Env: "TOKEN"},
+		{ID: "npmrc", File: "/home/user/.npmrc"},
+		{ID: "extra", Env: "EXTRA"},
+	})
+	assert.DeepEqual(t, buildSecrets(project, project.Services["worker"], options), options.Secrets)
+
+	assert.DeepEqual(t, buildSSH(project.Services["web"], options), types.SSHConfig{
+		{ID: "default"},
+		{ID: "github", Path: "/home/user/.ssh/id_ed25519"},
+	})
+	assert.DeepEqual(t, buildSSH(project.Services["worker"], options), types.SSHConfig(options.SSHs))
+
+	bakeSecrets, env := toBakeSecrets(&types.Project{Environment: types.Mapping{"TOKEN": "s3cr3t"}},
+		buildSecrets(project, project.Services["web"], api.BuildOptions{}))
+	assert.DeepEqual(t, bakeSecrets, []string{"id=npmrc,type=file,src=/project/npmrc", "id=api_token,type=env,env=TOKEN"})
+	assert.DeepEqual(t, env, []string{"TOKEN=s3cr3t"})
+}
+
+func TestCheckBuildSources(t *testing.T) {
+	dir := t.TempDir()
+	npmrc := filepath.Join(dir, "npmrc")
+	assert.NilError(t, os.WriteFile(npmrc, []byte("token"), 0o600))
+	missing := filepath.Join(dir, "missing")
+
+	project := &types.Project{
+		Environment: types.Mapping{"TOKEN": "s3cr3t"},
+		Secrets: types.Secrets{
+			"npmrc": {File: npmrc},
+			"token": {Environment: "TOKEN"},
+			"gone":  {File: missing},
+		},
+		Services: types.Services{
+			"api":    {Name: "api", Build: &types.BuildConfig{Secrets: []types.ServiceSecretConfig{{Source: "npmrc"}, {Source: "token"}}}},
+			"web":    {Name: "web", Build: &types.BuildConfig{Secrets: []types.ServiceSecretConfig{{Source: "gone"}}}},
+			"worker": {Name: "worker", Build: &types.BuildConfig{SSH: types.SSHConfig{{ID: "deploy", Path: missing}}}},
+			"db":     {Name: "db", Image: "postgres"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		options api.BuildOptions
+		err     string
+	}{
+		{
+			name: "declared sources",
+			err: `service "web" build secret "gone": stat ` + missing + `: no such file or directory` + "\n" +
+				`service "worker" build SSH key "deploy": stat ` + missing + `: no such file or directory`,
+		},
+		{
+			name: "overridden by the command line",
+			options: api.BuildOptions{
+				Secrets: []api.BuildSecret{{ID: "gone", File: npmrc}},
+				SSHs:    []types.SSHKey{{ID: "deploy"}},
+			},
+		},
+		{
+			name:    "command line sources checked for all services",
+			options: api.BuildOptions{Secrets: []api.BuildSecret{{ID: "gone", File: npmrc}, {ID: "extra", Env: "EXTRA"}}, SSHs: []types.SSHKey{{ID: "deploy"}}},
+			err: `service "api" build secret "extra": environment variable EXTRA is not set` + "\n" +
+				`service "web" build secret "extra": environment variable EXTRA is not set` + "\n" +
+				`service "worker" build secret "extra": environment variable EXTRA is not set`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBuildSources(project, project.Services, tt.options)
+			if tt.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tt.err)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/loader"
 	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
 )
@@ -107,3 +108,64 @@ func Test_addBuildDependencies(t *testing.T) {
 	slices.Sort(expected)
 	assert.DeepEqual(t, services, expected)
 }
+
+func loadBuildDependenciesFixture(t *testing.T) *types.Project {
+	t.Helper()
+	project, err := loader.LoadWithContext(t.Context(), types.ConfigDetails{
+		WorkingDir:  "testdata/build-dependencies/",
+		Environment: types.Mapping{},
+		ConfigFiles: []types.ConfigFile{
+			{
+				Filename: "testdata/build-dependencies/compose.yaml",
+			},
+		},
+	})
+	assert.NilError(t, err)
+	return project
+}
+
+func TestWithBuildDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string
+		selected []string
+		added    []string
+	}{
+		{
+			name:     "build references",
+			services: []string{"app"},
+			selected: []string{"app", "base", "db", "toolchain"},
+			added:    []string{"base", "toolchain"},
+		},
+		{
+			name:     "no build reference",
+			services: []string{"worker"},
+			selected: []string{"worker"},
+		},
+		{
+			name:     "referenced service selected explicitly",
+			services: []string{"base", "worker"},
+			selected: []string{"base", "toolchain", "worker"},
+			added:    []string{"toolchain"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, added, err := WithBuildDependencies(loadBuildDependenciesFixture(t), tt.services)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, project.ServiceNames(), tt.selected)
+			assert.DeepEqual(t, added, tt.added)
+		})
+	}
+
+	_, _, err := WithBuildDependencies(loadBuildDependenciesFixture(t), []string{"unknown"})
+	assert.ErrorContains(t, err, "unknown")
+}
+
+func TestBuildReferenceLevels(t *testing.T) {
+	assert.DeepEqual(t, buildReferenceLevels(loadBuildDependenciesFixture(t)), [][]string{
+		{"db", "toolchain", "worker"},
+		{"base"},
+		{"app"},
+	})
+}
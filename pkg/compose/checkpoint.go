@@ -0,0 +1,395 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/config"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+const (
+	// snapshotsDirectory is where snapshots are stored by default, relative
+	// to the docker CLI configuration directory
+	snapshotsDirectory = "compose/snapshots"
+	// snapshotManifest is the name of the manifest file in a snapshot directory
+	snapshotManifest = "manifest.json"
+	// snapshotMountPath is where the helper containers archiving or
+	// populating a volume mount it
+	snapshotMountPath = "/snapshot"
+)
+
+// snapshotID matches the IDs a snapshot can be given, as they tag images
+var snapshotID = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// newSnapshotID returns the ID of a snapshot taken at t
+func newSnapshotID(t time.Time) string {
+	return t.UTC().Format("20060102-150405")
+}
+
+// snapshotDir returns the directory holding snapshot id of project
+func snapshotDir(dir string, project string, id string) (string, error) {
+	if !snapshotID.MatchString(id) {
+		return "", fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	if dir == "" {
+		dir = filepath.Join(config.Dir(), snapshotsDirectory)
+	}
+	return filepath.Join(dir, project, id), nil
+}
+
+// snapshotImage returns the reference a service container is committed to
+func snapshotImage(project string, service string, id string) string {
+	return strings.ToLower(fmt.Sprintf("%s-%s:%s", project, service, id))
+}
+
+// writeSnapshot writes the manifest of snapshot to dir
+func writeSnapshot(dir string, snapshot api.Snapshot) error {
+	content, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, snapshotManifest), content, 0o644)
+}
+
+// readSnapshot reads the manifest of the snapshot stored in dir
+func readSnapshot(dir string) (api.Snapshot, error) {
+	var snapshot api.Snapshot
+	content, err := os.ReadFile(filepath.Join(dir, snapshotManifest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return snapshot, fmt.Errorf("no snapshot found in %s", dir)
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("invalid snapshot manifest %s: %w", filepath.Join(dir, snapshotManifest), err)
+	}
+	return snapshot, nil
+}
+
+// withSnapshotImages sets the services of project to run the images their
+// containers were committed to. As those only exist locally, the services
+// are neither built nor pulled.
+func withSnapshotImages(project *types.Project, snapshot api.Snapshot) {
+	for name, image := range snapshot.Images {
+		service, ok := project.Services[name]
+		if !ok {
+			logrus.Warnf("service %q of snapshot %s isn't declared by the project, skipping", name, snapshot.ID)
+			continue
+		}
+		service.Image = image
+		service.Build = nil
+		service.PullPolicy = types.PullPolicyNever
+		project.Services[name] = service
+	}
+}
+
+// helperImage returns the image to run the containers archiving and
+// populating the volumes of snapshot with
+func helperImage(snapshot api.Snapshot) string {
+	services := slices.Sorted(maps.Keys(snapshot.Images))
+	return snapshot.Images[services[0]]
+}
+
+func (s *composeService) Checkpoint(ctx context.Context, project *types.Project, options api.CheckpointOptions) (api.Snapshot, error) {
+	var snapshot api.Snapshot
+	err := Run(ctx, func(ctx context.Context) error {
+		var err error
+		snapshot, err = s.checkpoint(ctx, project, options)
+		return err
+	}, "checkpoint", s.events(ctx))
+	return snapshot, err
+}
+
+func (s *composeService) checkpoint(ctx context.Context, project *types.Project, options api.CheckpointOptions) (api.Snapshot, error) {
+	snapshot := api.Snapshot{
+		ID:      options.ID,
+		Project: project.Name,
+		Created: time.Now().UTC(),
+		Images:  map[string]string{},
+	}
+	if snapshot.ID == "" {
+		snapshot.ID = newSnapshotID(snapshot.Created)
+	}
+	dir, err := snapshotDir(options.Dir, project.Name, snapshot.ID)
+	if err != nil {
+		return snapshot, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return snapshot, fmt.Errorf("snapshot %s of project %s already exists", snapshot.ID, project.Name)
+	}
+
+	containers, err := s.getContainers(ctx, project.Name, oneOffExclude, true)
+	if err != nil {
+		return snapshot, err
+	}
+	containers = containers.filter(isService(project.ServiceNames()...))
+	if len(containers) == 0 {
+		return snapshot, fmt.Errorf("no container found for project %q", project.Name)
+	}
+
+	// containers are paused while the snapshot is taken, so it captures a
+	// consistent state of the project
+	paused, err := s.pauseForCheckpoint(ctx, containers.filter(isRunning))
+	defer s.unpauseAfterCheckpoint(context.WithoutCancel(ctx), paused)
+	if err != nil {
+		return snapshot, err
+	}
+
+	// a scaled service is snapshotted from its lowest numbered container
+	for _, service := range project.ServiceNames() {
+		serviceContainers := containers.filter(isService(service))
+		if len(serviceContainers) == 0 {
+			continue
+		}
+		ctr := lowestNumberedContainer(serviceContainers)
+		image := snapshotImage(project.Name, service, snapshot.ID)
+		name := getCanonicalContainerName(ctr)
+		s.events(ctx).On(newEvent(name, api.Working, api.StatusCommitting))
+		if _, err := s.apiClient().ContainerCommit(ctx, ctr.ID, client.ContainerCommitOptions{
+			Reference: image,
+			Comment:   fmt.Sprintf("snapshot %s of project %s", snapshot.ID, project.Name),
+			NoPause:   true,
+		}); err != nil {
+			s.events(ctx).On(errorEvent(name, err.Error()))
+			return snapshot, err
+		}
+		s.events(ctx).On(newEvent(name, api.Done, fmt.Sprintf("Committed as %s", image)))
+		snapshot.Images[service] = image
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "volumes"), 0o755); err != nil {
+		return snapshot, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(project.Volumes)) {
+		vol := project.Volumes[key]
+		if vol.External {
+			continue
+		}
+		if _, err := s.apiClient().VolumeInspect(ctx, vol.Name, client.VolumeInspectOptions{}); errdefs.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return snapshot, err
+		}
+		archive := filepath.Join("volumes", key+".tar")
+		if err := s.archiveVolume(ctx, project.Name, helperImage(snapshot), vol.Name, filepath.Join(dir, archive)); err != nil {
+			return snapshot, err
+		}
+		snapshot.Volumes = append(snapshot.Volumes, api.SnapshotVolume{
+			Volume:  key,
+			Name:    vol.Name,
+			Archive: archive,
+		})
+	}
+	return snapshot, writeSnapshot(dir, snapshot)
+}
+
+// pauseForCheckpoint pauses containers, returning the ones it paused
+func (s *composeService) pauseForCheckpoint(ctx context.Context, containers Containers) (Containers, error) {
+	var (
+		mu     sync.Mutex
+		paused Containers
+	)
+	err := forEachContainerConcurrent(ctx, containers, func(ctx context.Context, ctr container.Summary) error {
+		if _, err := s.apiClient().ContainerPause(ctx, ctr.ID, client.ContainerPauseOptions{}); err != nil {
+			return err
+		}
+		mu.Lock()
+		paused = append(paused, ctr)
+		mu.Unlock()
+		s.events(ctx).On(newEvent(getContainerProgressName(ctr), api.Done, "Paused"))
+		return nil
+	})
+	return paused, err
+}
+
+// unpauseAfterCheckpoint unpauses the containers paused to take a snapshot
+func (s *composeService) unpauseAfterCheckpoint(ctx context.Context, paused Containers) {
+	_ = forEachContainerConcurrent(ctx, paused, func(ctx context.Context, ctr container.Summary) error {
+		if _, err := s.apiClient().ContainerUnpause(ctx, ctr.ID, client.ContainerUnpauseOptions{}); err != nil {
+			logrus.Warnf("failed to unpause container %s: %v", getCanonicalContainerName(ctr), err)
+			return nil
+		}
+		s.events(ctx).On(newEvent(getContainerProgressName(ctr), api.Done, "Unpaused"))
+		return nil
+	})
+}
+
+// withVolumeHelper runs fn with a container mounting volume at
+// snapshotMountPath. The container is created but never started, as the
+// engine mounts the volumes of a container to copy files from or to it.
+func (s *composeService) withVolumeHelper(ctx context.Context, project string, image string, volume string, fn func(id string) error) error {
+	created, err := s.apiClient().ContainerCreate(ctx, client.ContainerCreateOptions{
+		Config: &container.Config{
+			Image: image,
+			Labels: map[string]string{
+				api.ProjectLabel: project,
+				api.VersionLabel: api.ComposeVersion,
+			},
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode: "none",
+			Mounts: []mount.Mount{{
+				Type:   mount.TypeVolume,
+				Source: volume,
+				Target: snapshotMountPath,
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, err := s.apiClient().ContainerRemove(context.WithoutCancel(ctx), created.ID, client.ContainerRemoveOptions{Force: true})
+		if err != nil {
+			logrus.Warnf("failed to remove helper container %s: %v", created.ID, err)
+		}
+	}()
+	return fn(created.ID)
+}
+
+// archiveVolume writes the content of volume as a tar archive to path
+func (s *composeService) archiveVolume(ctx context.Context, project string, image string, volume string, path string) error {
+	eventName := fmt.Sprintf("Volume %s", volume)
+	s.events(ctx).On(newEvent(eventName, api.Working, "Archiving"))
+	err := s.withVolumeHelper(ctx, project, image, volume, func(id string) error {
+		res, err := s.apiClient().CopyFromContainer(ctx, id, client.CopyFromContainerOptions{
+			SourcePath: snapshotMountPath,
+		})
+		if err != nil {
+			return err
+		}
+		defer res.Content.Close() //nolint:errcheck
+
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, res.Content)
+		return errors.Join(err, f.Close())
+	})
+	if err != nil {
+		s.events(ctx).On(errorEvent(eventName, err.Error()))
+		return err
+	}
+	s.events(ctx).On(newEvent(eventName, api.Done, "Archived"))
+	return nil
+}
+
+// populateVolume extracts the tar archive at path, as written by
+// archiveVolume, to volume
+func (s *composeService) populateVolume(ctx context.Context, project string, image string, volume string, path string) error {
+	eventName := fmt.Sprintf("Volume %s", volume)
+	s.events(ctx).On(newEvent(eventName, api.Working, "Restoring"))
+	err := s.withVolumeHelper(ctx, project, image, volume, func(id string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+
+		// the archive holds the mount point directory, and the ownership of
+		// the files it contains is preserved
+		_, err = s.apiClient().CopyToContainer(ctx, id, client.CopyToContainerOptions{
+			DestinationPath: "/",
+			Content:         f,
+		})
+		return err
+	})
+	if err != nil {
+		s.events(ctx).On(errorEvent(eventName, err.Error()))
+		return err
+	}
+	s.events(ctx).On(newEvent(eventName, api.Done, "Restored"))
+	return nil
+}
+
+func (s *composeService) Restore(ctx context.Context, project *types.Project, options api.RestoreOptions) error {
+	err := Run(ctx, func(ctx context.Context) error {
+		return s.restore(ctx, project, options)
+	}, "restore", s.events(ctx))
+	s.clearObservedState(ctx, project.Name)
+	if err != nil {
+		return err
+	}
+	return s.Up(ctx, project, api.UpOptions{
+		Create: options.Create,
+		Start:  options.Start,
+	})
+}
+
+// restore sets the project to run the images of the snapshot and creates
+// its volumes from the snapshot archives, for up to recreate the project
+func (s *composeService) restore(ctx context.Context, project *types.Project, options api.RestoreOptions) error {
+	dir, err := snapshotDir(options.Dir, project.Name, options.ID)
+	if err != nil {
+		return err
+	}
+	snapshot, err := readSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	if snapshot.Project != project.Name {
+		return fmt.Errorf("snapshot %s was taken of project %q, not %q", snapshot.ID, snapshot.Project, project.Name)
+	}
+	if len(snapshot.Images) == 0 {
+		return fmt.Errorf("snapshot %s has no image to restore", snapshot.ID)
+	}
+	withSnapshotImages(project, snapshot)
+
+	for _, archive := range snapshot.Volumes {
+		vol, ok := project.Volumes[archive.Volume]
+		if !ok {
+			logrus.Warnf("volume %q of snapshot %s isn't declared by the project, skipping", archive.Volume, snapshot.ID)
+			continue
+		}
+		if _, err := s.apiClient().VolumeInspect(ctx, vol.Name, client.VolumeInspectOptions{}); err == nil {
+			return fmt.Errorf("volume %s already exists, remove it with `down --volumes` to restore snapshot %s", vol.Name, snapshot.ID)
+		} else if !errdefs.IsNotFound(err) {
+			return err
+		}
+		if err := s.createVolume(ctx, vol); err != nil {
+			return err
+		}
+		if err := s.populateVolume(ctx, project.Name, helperImage(snapshot), vol.Name, filepath.Join(dir, archive.Archive)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
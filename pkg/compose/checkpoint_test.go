@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestSnapshotManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := api.Snapshot{
+		ID:      "20261016-120000",
+		Project: "myproject",
+		Created: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Images: map[string]string{
+			"web": "myproject-web:20261016-120000",
+			"db":  "myproject-db:20261016-120000",
+		},
+		Volumes: []api.SnapshotVolume{
+			{Volume: "data", Name: "myproject_data", Archive: filepath.Join("volumes", "data.tar")},
+		},
+	}
+	assert.NilError(t, writeSnapshot(dir, snapshot))
+
+	read, err := readSnapshot(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, read, snapshot)
+
+	_, err = readSnapshot(t.TempDir())
+	assert.ErrorContains(t, err, "no snapshot found")
+}
+
+func TestSnapshotDir(t *testing.T) {
+	dir, err := snapshotDir("/snapshots", "myproject", "before-upgrade")
+	assert.NilError(t, err)
+	assert.Equal(t, dir, filepath.Join("/snapshots", "myproject", "before-upgrade"))
+
+	_, err = snapshotDir("/snapshots", "myproject", "../other")
+	assert.ErrorContains(t, err, "invalid snapshot ID")
+}
+
+func TestWithSnapshotImages(t *testing.T) {
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {
+				Name:       "web",
+				Build:      &types.BuildConfig{Context: "."},
+				PullPolicy: types.PullPolicyBuild,
+			},
+			"db":    {Name: "db", Image: "postgres", PullPolicy: types.PullPolicyAlways},
+			"cache": {Name: "cache", Image: "redis"},
+		},
+	}
+	withSnapshotImages(project, api.Snapshot{
+		ID: "snap",
+		Images: map[string]string{
+			"web":     "myproject-web:snap",
+			"db":      "myproject-db:snap",
+			"removed": "myproject-removed:snap",
+		},
+	})
+
+	web := project.Services["web"]
+	assert.Equal(t, web.Image, "myproject-web:snap")
+	assert.Assert(t, web.Build == nil)
+	assert.Equal(t, web.PullPolicy, types.PullPolicyNever)
+
+	db := project.Services["db"]
+	assert.Equal(t, db.Image, "myproject-db:snap")
+	assert.Equal(t, db.PullPolicy, types.PullPolicyNever)
+
+	// services absent from the snapshot are created as declared
+	assert.DeepEqual(t, project.Services["cache"], types.ServiceConfig{Name: "cache", Image: "redis"})
+	_, ok := project.Services["removed"]
+	assert.Assert(t, !ok)
+}
+
+func TestSnapshotImage(t *testing.T) {
+	assert.Equal(t, snapshotImage("myproject", "Web", "snap"), "myproject-web:snap")
+	assert.Equal(t, newSnapshotID(time.Date(2026, 10, 16, 12, 30, 5, 0, time.UTC)), "20261016-123005")
+}
@@ -29,7 +29,7 @@ import (
 func (s *composeService) Commit(ctx context.Context, projectName string, options api.CommitOptions) error {
 	return Run(ctx, func(ctx context.Context) error {
 		return s.commit(ctx, projectName, options)
-	}, "commit", s.events)
+	}, "commit", s.events(ctx))
 }
 
 func (s *composeService) commit(ctx context.Context, projectName string, options api.CommitOptions) error {
@@ -42,14 +42,14 @@ func (s *composeService) commit(ctx context.Context, projectName string, options
 
 	name := getCanonicalContainerName(ctr)
 
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     name,
 		Status: api.Working,
 		Text:   api.StatusCommitting,
 	})
 
 	if s.dryRun {
-		s.events.On(api.Resource{
+		s.events(ctx).On(api.Resource{
 			ID:     name,
 			Status: api.Done,
 			Text:   api.StatusCommitted,
@@ -69,7 +69,7 @@ func (s *composeService) commit(ctx context.Context, projectName string, options
 		return err
 	}
 
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     name,
 		Text:   fmt.Sprintf("Committed as %s", response.ID),
 		Status: api.Done,
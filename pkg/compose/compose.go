@@ -39,6 +39,7 @@ import (
 
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/dryrun"
+	"github.com/docker/compose/v5/pkg/readonly"
 )
 
 type Option func(service *composeService) error
@@ -82,8 +83,8 @@ func NewComposeService(dockerCli command.Cli, options ...Option) (api.Compose, e
 			return defaultValue, nil
 		}
 	}
-	if s.events == nil {
-		s.events = &ignore{}
+	if s.eventBus == nil {
+		s.eventBus = &ignore{}
 	}
 
 	// If custom streams were provided, wrap the Docker CLI to use them
@@ -160,9 +161,48 @@ func WithMaxConcurrency(maxConcurrency int) Option {
 	}
 }
 
+// ConcurrencyLimiter bounds the number of operations run concurrently, as
+// golang.org/x/sync/semaphore.Weighted does
+type ConcurrencyLimiter interface {
+	Acquire(ctx context.Context, n int64) error
+	Release(n int64)
+}
+
+// WithConcurrencyLimiter makes the operations run against the engine, by
+// convergence as well as to pull images, start, stop, restart, kill or remove
+// containers and remove project resources, acquire a slot from limiter, so an
+// embedder sharing it with other work keeps the host under a global
+// concurrency budget. Without one, as many operations run concurrently as the
+// plan, or the concurrency of the command, allows.
+func WithConcurrencyLimiter(limiter ConcurrencyLimiter) Option {
+	return func(s *composeService) error {
+		s.limiter = limiter
+		return nil
+	}
+}
+
 // WithDryRun configure Compose to run without actually applying changes
 func WithDryRun(s *composeService) error {
 	s.dryRun = true
+	return s.wrapAPIClient(func(apiClient client.APIClient) (client.APIClient, error) {
+		return dryrun.NewDryRunClient(apiClient, s.dockerCli)
+	})
+}
+
+// WithPlanOnly configure Compose to only plan convergence, with an API client
+// refusing any call which would change the state of the engine. Planning
+// commands, like diff, then run against engine connections only granted
+// inspect and list permissions, and any attempt to apply a plan fails.
+func WithPlanOnly(s *composeService) error {
+	s.planOnly = true
+	return s.wrapAPIClient(func(apiClient client.APIClient) (client.APIClient, error) {
+		return readonly.NewClient(apiClient), nil
+	})
+}
+
+// wrapAPIClient replaces the Docker CLI with one using the API client returned
+// by wrap for the current one
+func (s *composeService) wrapAPIClient(wrap func(client.APIClient) (client.APIClient, error)) error {
 	cli, err := command.NewDockerCli()
 	if err != nil {
 		return err
@@ -171,7 +211,7 @@ func WithDryRun(s *composeService) error {
 	options := flags.NewClientOptions()
 	options.Context = s.dockerCli.CurrentContext()
 	err = cli.Initialize(options, command.WithInitializeClient(func(cli *command.DockerCli) (client.APIClient, error) {
-		return dryrun.NewDryRunClient(s.apiClient(), s.dockerCli)
+		return wrap(s.apiClient())
 	}))
 	if err != nil {
 		return err
@@ -193,7 +233,7 @@ func AlwaysOkPrompt() Prompt {
 // Typically used to configure a progress UI
 func WithEventProcessor(bus api.EventProcessor) Option {
 	return func(s *composeService) error {
-		s.events = bus
+		s.eventBus = bus
 		return nil
 	}
 }
@@ -202,8 +242,9 @@ type composeService struct {
 	dockerCli command.Cli
 	// prompt is used to interact with user and confirm actions
 	prompt Prompt
-	// eventBus collects tasks execution events
-	events api.EventProcessor
+	// eventBus collects tasks execution events, unless the operation runs
+	// with its own processor, see events
+	eventBus api.EventProcessor
 
 	// Optional overrides for specific components (for SDK users)
 	outStream   io.Writer
@@ -214,9 +255,39 @@ type composeService struct {
 
 	clock          clockwork.Clock
 	maxConcurrency int
-	dryRun         bool
+	// limiter bounds the concurrent operations against the engine, nil for
+	// no limit but the plan dependencies
+	limiter  ConcurrencyLimiter
+	dryRun   bool
+	planOnly bool
 
 	runtimeAPIVersion runtimeVersionCache
+	classicSwarm      classicSwarmCache
+	serviceLocks      serviceLocks
+	// stateStore persists the observed state of projects between
+	// convergences, nil to collect it from the engine each time
+	stateStore StateStore
+	// numberAllocator numbers the containers created on scale up, nil to
+	// number them after the highest number in use
+	numberAllocator ContainerNumberAllocator
+	// createOptions are applied to every container created, on top of the
+	// ones set by the operation creating it
+	createOptions []CreateOption
+	// imageVerifier verifies the images of projects with VerifyImages set,
+	// nil when none is configured
+	imageVerifier ImageVerifier
+	// imageGate verifies the image of each container before it is created,
+	// nil when no verifier is configured for it
+	imageGate *imageGate
+	// decrypted keeps the environment values decrypted while loading
+	// projects, by ciphertext
+	decrypted decryptionCache
+
+	// contextRouter routes API calls to the Docker contexts services are
+	// pinned to with x-docker-context, nil until a project does so
+	contextRouter        *contextRouter
+	contextRouterMu      sync.RWMutex
+	contextClientFactory func(name string) (client.APIClient, error)
 }
 
 // Close releases any connections/resources held by the underlying clients.
@@ -232,6 +303,11 @@ func (s *composeService) Close() error {
 }
 
 func (s *composeService) apiClient() client.APIClient {
+	s.contextRouterMu.RLock()
+	defer s.contextRouterMu.RUnlock()
+	if s.contextRouter != nil {
+		return s.contextRouter
+	}
 	return s.dockerCli.Client()
 }
 
@@ -508,6 +584,8 @@ type runtimeVersionCache struct {
 //
 // After negotiation, Compose should never rely on features or request attributes
 // not defined by this API version, even if the daemon's raw version is higher.
+// Versions reported by runtimes other than Docker Engine are normalized, or
+// assumed to be the oldest supported one when they can't be parsed.
 func (s *composeService) RuntimeAPIVersion(ctx context.Context) (string, error) {
 	s.runtimeAPIVersion.mu.Lock()
 	defer s.runtimeAPIVersion.mu.Unlock()
@@ -521,11 +599,31 @@ func (s *composeService) RuntimeAPIVersion(ctx context.Context) (string, error)
 		return "", err
 	}
 
-	version := cli.ClientVersion()
-	if version == "" {
-		return "", fmt.Errorf("docker client returned empty version after successful API negotiation")
+	version, ok := normalizeAPIVersion(cli.ClientVersion())
+	if !ok {
+		// Runtimes implementing the Docker API (podman, containerd shims) may
+		// report a version Compose can't compare. Assume the oldest API version
+		// the client supports, so version-gated features fall back to their
+		// legacy code path rather than being sent to a runtime lacking them.
+		logrus.Warnf("container runtime reported unsupported API version %q, assuming API version %s", cli.ClientVersion(), client.MinAPIVersion)
+		version = client.MinAPIVersion
 	}
 
 	s.runtimeAPIVersion.val = version
 	return s.runtimeAPIVersion.val, nil
 }
+
+// normalizeAPIVersion reduces an API version reported by a container runtime
+// to the "major.minor" form versions.LessThan compares reliably, accepting the
+// variants non-Docker runtimes use such as "v1.41", "1.41.0" or "1.41-podman".
+func normalizeAPIVersion(version string) (string, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexFunc(version, func(r rune) bool { return r != '.' && (r < '0' || r > '9') }); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "." + parts[1], true
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"go.yaml.in/yaml/v4"
+)
+
+// CheckContainerNames reports the services of project declaring the same
+// container_name, or a container_name one of the containers of another
+// service is named by default, which would otherwise only fail once the
+// second container is created. The compose files setting the conflicting
+// names are reported along, to tell which one to fix when several are merged.
+func CheckContainerNames(project *types.Project) error {
+	var sources map[string][]string
+	setBy := func(service string) string {
+		if sources == nil {
+			sources = containerNameSources(project.WorkingDir, project.ComposeFiles)
+		}
+		if files := sources[service]; len(files) > 0 {
+			return fmt.Sprintf(" (set in %s)", strings.Join(files, ", "))
+		}
+		return ""
+	}
+
+	owners := map[string]string{}
+	var errs []error
+	for _, name := range project.ServiceNames() {
+		containerName := project.Services[name].ContainerName
+		if containerName == "" {
+			continue
+		}
+		if owner, ok := owners[containerName]; ok {
+			errs = append(errs, fmt.Errorf("services %q%s and %q%s use the same container name %q",
+				owner, setBy(owner), name, setBy(name), containerName))
+			continue
+		}
+		owners[containerName] = name
+	}
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if service.ContainerName != "" {
+			continue
+		}
+		for number := 1; number <= service.GetScale(); number++ {
+			defaultName := getDefaultContainerName(project.Name, name, strconv.Itoa(number))
+			if owner, ok := owners[defaultName]; ok {
+				errs = append(errs, fmt.Errorf("service %q%s uses container name %q, which is the name of container %d of service %q",
+					owner, setBy(owner), defaultName, number, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// containerNameSources returns, per service, the compose files setting its
+// container_name, in the order they are merged. Files which can't be read,
+// such as a model passed on stdin, are skipped. Files are named relative to
+// the project working directory.
+func containerNameSources(workingDir string, files []string) map[string][]string {
+	sources := map[string][]string{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var model struct {
+			Services map[string]struct {
+				ContainerName any `yaml:"container_name"`
+			} `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(content, &model); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(workingDir, file); err == nil {
+			file = rel
+		}
+		for name, service := range model.Services {
+			if service.ContainerName != nil {
+				sources[name] = append(sources[name], file)
+			}
+		}
+	}
+	return sources
+}
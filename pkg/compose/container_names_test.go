@@ -0,0 +1,55 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestCheckContainerNames(t *testing.T) {
+	service, err := NewComposeService(nil)
+	assert.NilError(t, err)
+	project, err := service.LoadProject(t.Context(), api.ProjectLoadOptions{
+		ConfigPaths: []string{"testdata/container-names/compose.yaml", "testdata/container-names/compose.override.yaml"},
+	})
+	assert.NilError(t, err)
+
+	err = CheckContainerNames(project)
+	assert.Error(t, err, `services "api" (set in compose.override.yaml) and "web" (set in compose.yaml) use the same container name "web"
+service "admin" (set in compose.override.yaml) uses container name "names-worker-2", which is the name of container 2 of service "worker"`)
+
+	t.Run("without sources", func(t *testing.T) {
+		project := &types.Project{Name: "names", Services: types.Services{
+			"web": {Name: "web", ContainerName: "shared"},
+			"api": {Name: "api", ContainerName: "shared"},
+		}}
+		assert.Error(t, CheckContainerNames(project), `services "api" and "web" use the same container name "shared"`)
+	})
+
+	t.Run("no conflict", func(t *testing.T) {
+		project := &types.Project{Name: "names", Services: types.Services{
+			"web":    {Name: "web", ContainerName: "names-worker-3"},
+			"worker": {Name: "worker", Scale: intPtr(2)},
+		}}
+		assert.NilError(t, CheckContainerNames(project))
+	})
+}
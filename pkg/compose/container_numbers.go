@@ -0,0 +1,114 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// ContainerNumberAllocator allocates the numbers of the containers created to
+// scale up a service. Embedders running several controllers against the same
+// project can partition the number space between them, so they never create
+// containers with the same number.
+type ContainerNumberAllocator interface {
+	// Allocate returns count numbers for the new containers of the service.
+	// used holds the numbers of its existing containers, sorted, which must
+	// not be returned.
+	Allocate(projectName string, service string, used []int, count int) ([]int, error)
+}
+
+// WithContainerNumberAllocator makes convergence number the containers it
+// creates with allocator. Without one, containers are numbered after the
+// highest number in use for their service.
+func WithContainerNumberAllocator(allocator ContainerNumberAllocator) Option {
+	return func(s *composeService) error {
+		s.numberAllocator = allocator
+		return nil
+	}
+}
+
+// nextNumberAllocator is the default ContainerNumberAllocator, numbering
+// containers after the highest number in use
+type nextNumberAllocator struct{}
+
+func (nextNumberAllocator) Allocate(_ string, _ string, used []int, count int) ([]int, error) {
+	next := 1
+	if len(used) > 0 {
+		next = slices.Max(used) + 1
+	}
+	numbers := make([]int, count)
+	for i := range numbers {
+		numbers[i] = next + i
+	}
+	return numbers, nil
+}
+
+// usedContainerNumbers returns the numbers of containers, sorted, skipping the
+// containers missing a valid number label
+func usedContainerNumbers(containers []container.Summary) []int {
+	var numbers []int
+	for _, c := range containers {
+		s, ok := c.Labels[api.ContainerNumberLabel]
+		if !ok {
+			logrus.Warnf("container %s is missing %s label", c.ID, api.ContainerNumberLabel)
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			logrus.Warnf("container %s has invalid %s label: %s", c.ID, api.ContainerNumberLabel, s)
+			continue
+		}
+		numbers = append(numbers, n)
+	}
+	slices.Sort(numbers)
+	return slices.Compact(numbers)
+}
+
+// allocateContainerNumbers asks allocator for count numbers for the new
+// containers of service, and checks they can be used
+func allocateContainerNumbers(allocator ContainerNumberAllocator, projectName string, service string, containers []container.Summary, count int) ([]int, error) {
+	if allocator == nil {
+		allocator = nextNumberAllocator{}
+	}
+	used := usedContainerNumbers(containers)
+	numbers, err := allocator.Allocate(projectName, service, slices.Clone(used), count)
+	if err != nil {
+		return nil, fmt.Errorf("allocating container numbers for service %q: %w", service, err)
+	}
+	if len(numbers) != count {
+		return nil, fmt.Errorf("allocating container numbers for service %q: %d numbers allocated, %d requested", service, len(numbers), count)
+	}
+	allocated := map[int]bool{}
+	for _, n := range numbers {
+		switch {
+		case n < 1:
+			return nil, fmt.Errorf("allocating container numbers for service %q: invalid number %d", service, n)
+		case allocated[n]:
+			return nil, fmt.Errorf("allocating container numbers for service %q: number %d allocated twice", service, n)
+		case slices.Contains(used, n):
+			return nil, fmt.Errorf("allocating container numbers for service %q: number %d is already in use", service, n)
+		}
+		allocated[n] = true
+	}
+	return numbers, nil
+}
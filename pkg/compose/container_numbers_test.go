@@ -0,0 +1,140 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// rangeAllocator allocates the lowest numbers not in use in [first, last]
+type rangeAllocator struct {
+	first, last int
+}
+
+func (a rangeAllocator) Allocate(_ string, _ string, used []int, count int) ([]int, error) {
+	var numbers []int
+	for n := a.first; n <= a.last && len(numbers) < count; n++ {
+		if !slices.Contains(used, n) {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) < count {
+		return nil, fmt.Errorf("range %d-%d exhausted", a.first, a.last)
+	}
+	return numbers, nil
+}
+
+type fixedAllocator []int
+
+func (a fixedAllocator) Allocate(_ string, _ string, _ []int, _ int) ([]int, error) {
+	return a, nil
+}
+
+func TestAllocateContainerNumbers(t *testing.T) {
+	numbered := func(numbers ...string) []container.Summary {
+		var containers []container.Summary
+		for _, n := range numbers {
+			containers = append(containers, container.Summary{ID: "c" + n, Labels: map[string]string{api.ContainerNumberLabel: n}})
+		}
+		return containers
+	}
+
+	tests := []struct {
+		name       string
+		allocator  ContainerNumberAllocator
+		containers []container.Summary
+		count      int
+		want       []int
+		err        string
+	}{
+		{name: "default", count: 2, want: []int{1, 2}},
+		{name: "default after highest", containers: numbered("3", "1", "invalid"), count: 2, want: []int{4, 5}},
+		{name: "range", allocator: rangeAllocator{first: 101, last: 200}, containers: numbered("1", "101"), count: 2, want: []int{102, 103}},
+		{
+			name:      "range exhausted",
+			allocator: rangeAllocator{first: 101, last: 101},
+			count:     2,
+			err:       `allocating container numbers for service "web": range 101-101 exhausted`,
+		},
+		{
+			name:      "too few numbers",
+			allocator: fixedAllocator{7},
+			count:     2,
+			err:       `allocating container numbers for service "web": 1 numbers allocated, 2 requested`,
+		},
+		{
+			name:       "number in use",
+			allocator:  fixedAllocator{2},
+			containers: numbered("2"),
+			count:      1,
+			err:        `allocating container numbers for service "web": number 2 is already in use`,
+		},
+		{
+			name:      "duplicated number",
+			allocator: fixedAllocator{4, 4},
+			count:     2,
+			err:       `allocating container numbers for service "web": number 4 allocated twice`,
+		},
+		{
+			name:      "invalid number",
+			allocator: fixedAllocator{0},
+			count:     1,
+			err:       `allocating container numbers for service "web": invalid number 0`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			numbers, err := allocateContainerNumbers(tt.allocator, "myproject", "web", tt.containers, tt.count)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, numbers, tt.want)
+		})
+	}
+}
+
+func TestReconcileNumberAllocator(t *testing.T) {
+	project := &types.Project{Name: "myproject", Services: types.Services{
+		"web": {Name: "web", Image: "nginx", Scale: intPtr(3)},
+	}}
+	hash := mustServiceHash(t, project.Services["web"])
+	observed := emptyObservedState("myproject")
+	observed.Containers["web"] = []ObservedContainer{{
+		ID: "c-web-101", Name: "myproject-web-101", Number: 101, State: container.StateRunning, ConfigHash: hash,
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "101", api.ConfigHashLabel: hash},
+	}}
+	options := defaultReconcileOptions()
+	options.NumberAllocator = rangeAllocator{first: 101, last: 200}
+
+	plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.NilError(t, err)
+	assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:102, CreateContainer, no existing container
+[] -> #2 service:web:103, CreateContainer, no existing container
+`)+"\n")
+}
@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -162,10 +163,46 @@ func isNotOneOff(c container.Summary) bool {
 	return !ok || v == "False"
 }
 
+// isConverged reports whether the container isn't labeled to be left out of
+// convergence
+func isConverged(c container.Summary) bool {
+	return c.Labels[api.ConvergenceLabel] != api.ConvergenceIgnore
+}
+
 func isNotRunning(c container.Summary) bool {
 	return c.State != container.StateRunning
 }
 
+func isRunning(c container.Summary) bool {
+	return c.State == container.StateRunning
+}
+
+// lowestNumbered returns the n containers with the lowest numbers
+func (containers Containers) lowestNumbered(n int) Containers {
+	sorted := slices.Clone(containers)
+	slices.SortStableFunc(sorted, func(a, b container.Summary) int {
+		numberA, _ := strconv.Atoi(a.Labels[api.ContainerNumberLabel])
+		numberB, _ := strconv.Atoi(b.Labels[api.ContainerNumberLabel])
+		return numberA - numberB
+	})
+	return sorted[:max(0, min(n, len(sorted)))]
+}
+
+// staleRecreateName matches the temporary name of a container replacing
+// another one, prefixed by the short ID of the container it replaces
+var staleRecreateName = regexp.MustCompile(`^[0-9a-f]{12}_.+`)
+
+// isStaleRecreate is a predicate to select the containers left under their
+// temporary name by an interrupted recreate, not renamed to their final name
+func isStaleRecreate(c container.Summary) bool {
+	name := getCanonicalContainerName(c)
+	if tmpName, ok := c.Labels[api.TemporaryNameLabel]; ok {
+		return name == tmpName
+	}
+	// containers created before the label was set only tell by their name
+	return staleRecreateName.MatchString(name)
+}
+
 // filter return Containers with elements to match predicate
 func (containers Containers) filter(predicates ...containerPredicate) Containers {
 	var filtered Containers
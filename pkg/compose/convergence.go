@@ -21,20 +21,26 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/platforms"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
 	"github.com/moby/moby/client/pkg/versions"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/docker/compose/v5/internal/tracing"
 	"github.com/docker/compose/v5/pkg/api"
 )
 
@@ -83,34 +89,39 @@ func resolveVolumeFrom(service *types.ServiceConfig, containersByService map[str
 		name := spec[0]
 		dependencies := containersByService[name]
 		if len(dependencies) == 0 {
-			return fmt.Errorf("cannot share volume with service %s: container missing", name)
+			return &api.ReferenceUnresolvableError{Service: service.Name, Reference: name, Kind: "volume"}
 		}
 		service.VolumesFrom[i] = dependencies.sorted()[0].ID
 	}
 	return nil
 }
 
+// resolveSharedNamespaces replaces the network, IPC and PID namespaces shared
+// with a service by the ones of its first container. The namespaces a sidecar
+// shares with the service it is paired with are left as is, as they are the
+// ones of the container with the same number, set at create time.
 func resolveSharedNamespaces(service *types.ServiceConfig, containersByService map[string]Containers) error {
-	if name := getDependentServiceFromMode(service.NetworkMode); name != "" {
+	paired := sidecarOf(*service)
+	if name := getDependentServiceFromMode(service.NetworkMode); name != "" && name != paired {
 		dependencies := containersByService[name]
 		if len(dependencies) == 0 {
-			return fmt.Errorf("cannot share network namespace with service %s: container missing", name)
+			return &api.ReferenceUnresolvableError{Service: service.Name, Reference: name, Kind: "network namespace"}
 		}
 		service.NetworkMode = types.ContainerPrefix + dependencies.sorted()[0].ID
 	}
 
-	if name := getDependentServiceFromMode(service.Ipc); name != "" {
+	if name := getDependentServiceFromMode(service.Ipc); name != "" && name != paired {
 		dependencies := containersByService[name]
 		if len(dependencies) == 0 {
-			return fmt.Errorf("cannot share IPC namespace with service %s: container missing", name)
+			return &api.ReferenceUnresolvableError{Service: service.Name, Reference: name, Kind: "IPC namespace"}
 		}
 		service.Ipc = types.ContainerPrefix + dependencies.sorted()[0].ID
 	}
 
-	if name := getDependentServiceFromMode(service.Pid); name != "" {
+	if name := getDependentServiceFromMode(service.Pid); name != "" && name != paired {
 		dependencies := containersByService[name]
 		if len(dependencies) == 0 {
-			return fmt.Errorf("cannot share PID namespace with service %s: container missing", name)
+			return &api.ReferenceUnresolvableError{Service: service.Name, Reference: name, Kind: "PID namespace"}
 		}
 		service.Pid = types.ContainerPrefix + dependencies.sorted()[0].ID
 	}
@@ -150,9 +161,59 @@ func containerReasonEvents(containers Containers, eventFunc func(string, string)
 	return events
 }
 
+// dependencyEvents flags events about an optional dependency so progress
+// writers can tell them apart from required ones.
+func dependencyEvents(config types.ServiceDependency, events []api.Resource) []api.Resource {
+	if !config.Required {
+		for i := range events {
+			events[i].Optional = true
+		}
+	}
+	return events
+}
+
 // ServiceConditionRunningOrHealthy is a service condition on status running or healthy
 const ServiceConditionRunningOrHealthy = "running_or_healthy"
 
+// dependsOnMinUptimeExtension is the depends_on extension setting how long a
+// dependency must continuously satisfy a healthy condition before the
+// dependent service is started.
+const dependsOnMinUptimeExtension = "x-min-uptime"
+
+func getMinUptime(dep string, config types.ServiceDependency) (time.Duration, error) {
+	value, ok := config.Extensions[dependsOnMinUptimeExtension]
+	if !ok {
+		return 0, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return 0, fmt.Errorf("depends_on %s: %s must be a duration, got %v", dep, dependsOnMinUptimeExtension, value)
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, fmt.Errorf("depends_on %s: invalid %s: %w", dep, dependsOnMinUptimeExtension, err)
+	}
+	return d, nil
+}
+
+// uptimeTracker reports a dependency as ready once it has been observed
+// healthy for minUptime without interruption.
+type uptimeTracker struct {
+	minUptime time.Duration
+	since     time.Time
+}
+
+func (u *uptimeTracker) ready(healthy bool, now time.Time) bool {
+	if !healthy {
+		u.since = time.Time{}
+		return false
+	}
+	if u.since.IsZero() {
+		u.since = now
+	}
+	return now.Sub(u.since) >= u.minUptime
+}
+
 //nolint:gocyclo
 func (s *composeService) waitDependencies(ctx context.Context, project *types.Project, dependant string, dependencies types.DependsOnConfig, containers Containers, timeout time.Duration) error {
 	if timeout > 0 {
@@ -169,56 +230,126 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 		}
 
 		waitingFor := containers.filter(isService(dep), isNotOneOff)
-		s.events.On(containerEvents(waitingFor, waiting)...)
+		s.events(ctx).On(dependencyEvents(config, containerEvents(waitingFor, waiting))...)
 		if len(waitingFor) == 0 {
+			s.dependencyWaited(ctx, dependant, dep, config, nil, s.clock.Now(), api.DependencyWaitMissing)
 			if config.Required {
-				return fmt.Errorf("%s is missing dependency %s", dependant, dep)
+				return &api.DependencyMissingError{Service: dependant, Dependency: dep}
+			}
+			if marksAbsent(config) {
+				// the service was created knowing the dependency is absent
+				logrus.Infof("%s is missing optional dependency %s, marked absent with %s", dependant, dep, absentDependencyEnv(dep))
+				continue
 			}
 			logrus.Warnf("%s is missing dependency %s", dependant, dep)
 			continue
 		}
 
-		eg.Go(func() error {
-			ticker := time.NewTicker(500 * time.Millisecond)
-			defer ticker.Stop()
+		minUptime, err := getMinUptime(dep, config)
+		if err != nil {
+			return err
+		}
+		probe, err := getProbe(dep, config)
+		if err != nil {
+			return err
+		}
+		cadence, err := s.dependencyPollCadence(ctx, project, dep, config, probe)
+		if err != nil {
+			return err
+		}
+		unhealthy := func(err error) error {
+			return &api.DependencyUnhealthyError{Service: dependant, Dependency: dep, Condition: config.Condition, Err: err}
+		}
+		eg.Go(func() (err error) {
+			started := s.clock.Now()
+			outcome := api.DependencyWaitSatisfied
+			defer func() {
+				switch {
+				case err != nil:
+					outcome = api.DependencyWaitFailed
+				case errors.Is(ctx.Err(), context.DeadlineExceeded):
+					outcome = api.DependencyWaitTimeout
+				case ctx.Err() != nil:
+					outcome = api.DependencyWaitInterrupted
+				}
+				s.dependencyWaited(ctx, dependant, dep, config, waitingFor, started, outcome)
+			}()
+			uptime := uptimeTracker{minUptime: minUptime}
+			if cadence.aligned() {
+				cadence.started = s.lastStarted(ctx, waitingFor)
+			}
+			timer := time.NewTimer(cadence.next(time.Now()))
+			defer timer.Stop()
+			lastCode := 0
 			for {
 				select {
-				case <-ticker.C:
+				case <-timer.C:
+					timer.Reset(cadence.next(time.Now()))
 				case <-ctx.Done():
 					return nil
 				}
+				if len(probe) > 0 {
+					ready, code, err := s.probeContainers(ctx, waitingFor, probe)
+					if err != nil {
+						if !config.Required {
+							s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, skippedEvent,
+								fmt.Sprintf("optional dependency %q could not be probed", dep)))...)
+							outcome = api.DependencyWaitSkipped
+							logrus.Warnf("optional dependency %q could not be probed: %s", dep, err.Error())
+							return nil
+						}
+						s.events(ctx).On(containerEvents(waitingFor, func(s string) api.Resource {
+							return errorEventf(s, "dependency %s could not be probed", dep)
+						})...)
+						return unhealthy(err)
+					}
+					if !ready && code != lastCode {
+						// only report changes, as the probe runs on every tick
+						s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, func(id string, reason string) api.Resource {
+							return newEvent(id, api.Working, api.StatusWaiting, reason)
+						}, fmt.Sprintf("%s exited with %d", dependsOnProbeExtension, code)))...)
+					}
+					lastCode = code
+					if uptime.ready(ready, s.clock.Now()) {
+						s.events(ctx).On(dependencyEvents(config, containerEvents(waitingFor, healthy))...)
+						return nil
+					}
+					continue
+				}
 				switch config.Condition {
 				case ServiceConditionRunningOrHealthy:
 					isHealthy, err := s.isServiceHealthy(ctx, waitingFor, true)
 					if err != nil {
 						if !config.Required {
-							s.events.On(containerReasonEvents(waitingFor, skippedEvent,
-								fmt.Sprintf("optional dependency %q is not running or is unhealthy", dep))...)
+							s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, skippedEvent,
+								fmt.Sprintf("optional dependency %q is not running or is unhealthy", dep)))...)
+							outcome = api.DependencyWaitSkipped
 							logrus.Warnf("optional dependency %q is not running or is unhealthy: %s", dep, err.Error())
 							return nil
 						}
-						return err
+						return unhealthy(err)
 					}
-					if isHealthy {
-						s.events.On(containerEvents(waitingFor, healthy)...)
+					if uptime.ready(isHealthy, s.clock.Now()) {
+						s.events(ctx).On(dependencyEvents(config, containerEvents(waitingFor, healthy))...)
 						return nil
 					}
 				case types.ServiceConditionHealthy:
 					isHealthy, err := s.isServiceHealthy(ctx, waitingFor, false)
 					if err != nil {
 						if !config.Required {
-							s.events.On(containerReasonEvents(waitingFor, skippedEvent,
-								fmt.Sprintf("optional dependency %q failed to start", dep))...)
+							s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, skippedEvent,
+								fmt.Sprintf("optional dependency %q failed to start", dep)))...)
+							outcome = api.DependencyWaitSkipped
 							logrus.Warnf("optional dependency %q failed to start: %s", dep, err.Error())
 							return nil
 						}
-						s.events.On(containerEvents(waitingFor, func(s string) api.Resource {
+						s.events(ctx).On(containerEvents(waitingFor, func(s string) api.Resource {
 							return errorEventf(s, "dependency %s failed to start", dep)
 						})...)
-						return fmt.Errorf("dependency failed to start: %w", err)
+						return unhealthy(fmt.Errorf("dependency failed to start: %w", err))
 					}
-					if isHealthy {
-						s.events.On(containerEvents(waitingFor, healthy)...)
+					if uptime.ready(isHealthy, s.clock.Now()) {
+						s.events(ctx).On(dependencyEvents(config, containerEvents(waitingFor, healthy))...)
 						return nil
 					}
 				case types.ServiceConditionCompletedSuccessfully:
@@ -228,27 +359,29 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 					}
 					if isExited {
 						if code == 0 {
-							s.events.On(containerEvents(waitingFor, exited)...)
+							s.events(ctx).On(dependencyEvents(config, containerEvents(waitingFor, exited))...)
 							return nil
 						}
 
 						messageSuffix := fmt.Sprintf("%q didn't complete successfully: exit %d", dep, code)
 						if !config.Required {
 							// optional -> mark as skipped & don't propagate error
-							s.events.On(containerReasonEvents(waitingFor, skippedEvent,
-								fmt.Sprintf("optional dependency %s", messageSuffix))...)
+							s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, skippedEvent,
+								fmt.Sprintf("optional dependency %s", messageSuffix)))...)
+							outcome = api.DependencyWaitSkipped
 							logrus.Warnf("optional dependency %s", messageSuffix)
 							return nil
 						}
 
 						msg := fmt.Sprintf("service %s", messageSuffix)
-						s.events.On(containerEvents(waitingFor, func(s string) api.Resource {
+						s.events(ctx).On(containerEvents(waitingFor, func(s string) api.Resource {
 							return errorEventf(s, "service %s", messageSuffix)
 						})...)
-						return errors.New(msg)
+						return unhealthy(errors.New(msg))
 					}
 				default:
 					logrus.Warnf("unsupported depends_on condition: %s", config.Condition)
+					outcome = api.DependencyWaitSkipped
 					return nil
 				}
 			}
@@ -261,8 +394,43 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 	return err
 }
 
+// dependencyPollCadence returns the cadence to check dependency dep, aligned
+// on the probes of its healthcheck when the condition depends on its health.
+func (s *composeService) dependencyPollCadence(ctx context.Context, project *types.Project, dep string, config types.ServiceDependency, probe []string) (healthPollCadence, error) {
+	if len(probe) > 0 || (config.Condition != ServiceConditionRunningOrHealthy && config.Condition != types.ServiceConditionHealthy) {
+		return healthPollCadence{}, nil
+	}
+	service, err := project.GetService(dep)
+	if err != nil || service.HealthCheck == nil || service.HealthCheck.StartInterval == nil {
+		return healthPollCadence{}, nil
+	}
+	supported, err := s.supportsStartInterval(ctx)
+	if err != nil {
+		return healthPollCadence{}, err
+	}
+	return newHealthPollCadence(service.HealthCheck, supported), nil
+}
+
+// skipDependencyWaits considers the depends_on conditions waitDependencies
+// would block on as satisfied, and reports the waits as skipped. Ordering, and
+// so service_started, is still enforced by InDependencyOrder.
+func (s *composeService) skipDependencyWaits(ctx context.Context, project *types.Project, dependencies types.DependsOnConfig, containers Containers) error {
+	for _, dep := range slices.Sorted(maps.Keys(dependencies)) {
+		config := dependencies[dep]
+		if shouldWait, err := shouldWaitForDependency(dep, config, project); err != nil {
+			return err
+		} else if !shouldWait {
+			continue
+		}
+		waitingFor := containers.filter(isService(dep), isNotOneOff)
+		s.events(ctx).On(dependencyEvents(config, containerReasonEvents(waitingFor, skippedEvent,
+			fmt.Sprintf("%s wait", config.Condition)))...)
+	}
+	return nil
+}
+
 func shouldWaitForDependency(serviceName string, dependencyConfig types.ServiceDependency, project *types.Project) (bool, error) {
-	if dependencyConfig.Condition == types.ServiceConditionStarted {
+	if _, probe := dependencyConfig.Extensions[dependsOnProbeExtension]; dependencyConfig.Condition == types.ServiceConditionStarted && !probe {
 		// already managed by InDependencyOrder
 		return false, nil
 	}
@@ -284,34 +452,15 @@ func shouldWaitForDependency(serviceName string, dependencyConfig types.ServiceD
 	return true, nil
 }
 
-func nextContainerNumber(containers []container.Summary) int {
-	maxNumber := 0
-	for _, c := range containers {
-		s, ok := c.Labels[api.ContainerNumberLabel]
-		if !ok {
-			logrus.Warnf("container %s is missing %s label", c.ID, api.ContainerNumberLabel)
-		}
-		n, err := strconv.Atoi(s)
-		if err != nil {
-			logrus.Warnf("container %s has invalid %s label: %s", c.ID, api.ContainerNumberLabel, s)
-			continue
-		}
-		if n > maxNumber {
-			maxNumber = n
-		}
-	}
-	return maxNumber + 1
-}
-
 func (s *composeService) createContainer(ctx context.Context, project *types.Project, service types.ServiceConfig,
-	name string, number int, opts createOptions,
+	name string, number int, opts CreateOptions,
 ) (ctr container.Summary, err error) {
 	eventName := "Container " + name
-	s.events.On(creatingEvent(eventName))
+	s.events(ctx).On(creatingEvent(eventName))
 	ctr, err = s.createMobyContainer(ctx, project, service, name, number, nil, opts)
 	if err != nil {
 		if ctx.Err() == nil {
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:     eventName,
 				Status: api.Error,
 				Text:   err.Error(),
@@ -319,17 +468,55 @@ func (s *composeService) createContainer(ctx context.Context, project *types.Pro
 		}
 		return ctr, err
 	}
-	s.events.On(createdEvent(eventName))
+	s.events(ctx).On(createdEvent(eventName))
 	return ctr, nil
 }
 
 // force sequential calls to ContainerStart to prevent race condition in engine assigning ports from ranges
 var startMx sync.Mutex
 
+// lockStart takes startMx for a container of service to be started, unless
+// the service opts out with the com.docker.compose.serialize-start
+// annotation, and returns the function releasing it. The start of containers
+// whose service is unknown is serialized.
+func lockStart(service *types.ServiceConfig) func() {
+	if service != nil {
+		if value, ok := service.Annotations[api.SerializeStartAnnotation]; ok {
+			serialize, err := strconv.ParseBool(value)
+			if err != nil {
+				logrus.Warnf("service %q: invalid %s annotation %q, containers are started one at a time", service.Name, api.SerializeStartAnnotation, value)
+			} else if !serialize {
+				return func() {}
+			}
+		}
+	}
+	startMx.Lock()
+	return startMx.Unlock
+}
+
+// isImageRemoved reports whether a container failed to be created as its
+// image doesn't exist
+func isImageRemoved(err error) bool {
+	return errdefs.IsNotFound(err) && strings.Contains(strings.ToLower(err.Error()), "no such image")
+}
+
+// canPullAgain reports whether the image a container of service is created
+// from can be pulled again when it went missing: not when it is built, nor
+// when the pull policy of the service forbids pulling it.
+func canPullAgain(service types.ServiceConfig, image string) bool {
+	if service.Image == "" || image != service.Image || service.Build != nil {
+		return false
+	}
+	policy, _, err := service.GetPullPolicy()
+	if err != nil {
+		return false
+	}
+	return policy != types.PullPolicyNever && policy != types.PullPolicyBuild
+}
+
 func (s *composeService) createMobyContainer(ctx context.Context, project *types.Project, service types.ServiceConfig,
-	name string, number int, inherit *container.Summary, opts createOptions,
-) (container.Summary, error) {
-	var created container.Summary
+	name string, number int, inherit *container.Summary, opts CreateOptions,
+) (created container.Summary, err error) {
 	cfgs, err := s.getCreateConfigs(ctx, project, service, number, inherit, opts)
 	if err != nil {
 		return created, err
@@ -348,18 +535,50 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 		plat = &p
 	}
 
-	response, err := s.apiClient().ContainerCreate(ctx, client.ContainerCreateOptions{
+	ctx, span := otel.Tracer("").Start(ctx, "container/create", tracing.ServiceOptions(service).SpanStartOptions()...)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+		span.End()
+	}()
+
+	if err = s.verifyContainerImage(ctx, service, cfgs.Container.Image); err != nil {
+		return created, err
+	}
+
+	createOptions := client.ContainerCreateOptions{
 		Name:             name,
 		Platform:         plat,
 		Config:           cfgs.Container,
 		HostConfig:       cfgs.Host,
 		NetworkingConfig: cfgs.Network,
-	})
+	}
+	response, err := s.apiClient().ContainerCreate(ctx, createOptions)
+	if err != nil && isImageRemoved(err) && canPullAgain(service, cfgs.Container.Image) {
+		// the image was pulled, but removed since, e.g. by an image garbage
+		// collector: pull it again and retry once
+		logrus.Warnf("image %s of service %s was removed before container %s could be created, pulling it again", service.Image, service.Name, name)
+		if _, pullErr := s.pullServiceImage(ctx, service, false, project.Environment["DOCKER_DEFAULT_PLATFORM"]); pullErr != nil {
+			return created, fmt.Errorf("%w, and pulling it again failed: %w", err, pullErr)
+		}
+		response, err = s.apiClient().ContainerCreate(ctx, createOptions)
+	}
 	if err != nil {
 		return created, err
 	}
+	span.SetAttributes(attribute.String("container.id", response.ID), attribute.String("container.name", name))
+	// once created, the container is removed when anything else fails, rather
+	// than left behind for the next up to find diverged
+	defer func() {
+		if err != nil {
+			s.removeFailedContainer(ctx, response.ID, name)
+		}
+	}()
 	for _, warning := range response.Warnings {
-		s.events.On(api.Resource{
+		s.events(ctx).On(api.Resource{
 			ID:     service.Name,
 			Status: api.Warning,
 			Text:   warning,
@@ -373,24 +592,24 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 	if err != nil {
 		return created, err
 	}
+	var connected []string
 	if versions.LessThan(apiVersion, apiVersion144) {
 		serviceNetworks := service.NetworksByPriority()
 		for _, networkKey := range serviceNetworks {
 			mobyNetworkName := project.Networks[networkKey].Name
+			connected = append(connected, mobyNetworkName)
 			if string(cfgs.Host.NetworkMode) == mobyNetworkName {
 				// primary network already configured as part of ContainerCreate
 				continue
 			}
 			epSettings, err := createEndpointSettings(project, service, number, networkKey, cfgs.Links, opts.UseNetworkAliases)
 			if err != nil {
-				_, _ = s.apiClient().ContainerRemove(ctx, response.ID, client.ContainerRemoveOptions{Force: true})
 				return created, err
 			}
 			if _, err := s.apiClient().NetworkConnect(ctx, mobyNetworkName, client.NetworkConnectOptions{
 				Container:      response.ID,
 				EndpointConfig: epSettings,
 			}); err != nil {
-				_, _ = s.apiClient().ContainerRemove(ctx, response.ID, client.ContainerRemoveOptions{Force: true})
 				return created, err
 			}
 		}
@@ -400,6 +619,12 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 	if err != nil {
 		return created, err
 	}
+	// One-by-one NetworkConnect calls have been seen to silently no-op, so on
+	// the legacy path check the container actually joined every network
+	// rather than leaving the drift to be detected by the next up.
+	if missing := missingNetworks(res.Container, connected); len(missing) > 0 {
+		return created, fmt.Errorf("container %s is not attached to network(s) %s after creation", name, strings.Join(missing, ", "))
+	}
 	created = container.Summary{
 		ID:     res.Container.ID,
 		Labels: res.Container.Config.Labels,
@@ -412,6 +637,28 @@ func (s *composeService) createMobyContainer(ctx context.Context, project *types
 	return created, nil
 }
 
+// removeFailedContainer removes a container whose creation failed past the
+// ContainerCreate call. Failing to do so is only logged: the error which
+// caused the removal is the one worth reporting.
+func (s *composeService) removeFailedContainer(ctx context.Context, id, name string) {
+	_, err := s.apiClient().ContainerRemove(context.WithoutCancel(ctx), id, client.ContainerRemoveOptions{Force: true})
+	if err != nil && !errdefs.IsNotFound(err) {
+		logrus.Warnf("failed to remove container %s after its creation failed: %v", name, err)
+	}
+}
+
+// missingNetworks returns the networks from expected the container isn't
+// attached to.
+func missingNetworks(ctr container.InspectResponse, expected []string) []string {
+	var missing []string
+	for _, name := range expected {
+		if ctr.NetworkSettings == nil || ctr.NetworkSettings.Networks[name] == nil {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
 // getLinks mimics V1 compose/service.py::Service::_get_links()
 func (s *composeService) getLinks(ctx context.Context, projectName string, service types.ServiceConfig, number int) ([]string, error) {
 	var links []string
@@ -503,6 +750,24 @@ func (s *composeService) isServiceHealthy(ctx context.Context, containers Contai
 	return true, nil
 }
 
+// waitContainerHealthy blocks until the container is healthy, or running if
+// it has no healthcheck.
+func (s *composeService) waitContainerHealthy(ctx context.Context, id string) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		healthy, err := s.isServiceHealthy(ctx, Containers{{ID: id}}, true)
+		if err != nil || healthy {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func (s *composeService) isServiceCompleted(ctx context.Context, containers Containers) (bool, int, error) {
 	for _, c := range containers {
 		res, err := s.apiClient().ContainerInspect(ctx, c.ID, client.ContainerInspectOptions{})
@@ -519,15 +784,23 @@ func (s *composeService) isServiceCompleted(ctx context.Context, containers Cont
 func (s *composeService) startService(ctx context.Context,
 	project *types.Project, service types.ServiceConfig,
 	containers Containers, listener api.ContainerEventListener,
-	timeout time.Duration,
+	options api.StartOptions,
 ) error {
 	if service.Deploy != nil && service.Deploy.Replicas != nil && *service.Deploy.Replicas == 0 {
 		return nil
 	}
 
-	err := s.waitDependencies(ctx, project, service.Name, service.DependsOn, containers, timeout)
-	if err != nil {
-		return err
+	if options.SkipDependencyWaits {
+		if err := s.skipDependencyWaits(ctx, project, service.DependsOn, containers); err != nil {
+			return err
+		}
+	} else {
+		waitStart := s.clock.Now()
+		err := s.waitDependencies(ctx, project, service.Name, service.DependsOn, containers, options.WaitTimeout)
+		startupProfileOf(ctx).waited(service.Name, s.clock.Since(waitStart))
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(containers) == 0 {
@@ -537,52 +810,136 @@ func (s *composeService) startService(ctx context.Context,
 		return fmt.Errorf("service %q has no container to start", service.Name)
 	}
 
-	serviceContainers := containers.filter(isService(service.Name), isNotOneOff)
+	serviceContainers := containers.filter(isService(service.Name), isNotOneOff, isConverged)
 	toStart := serviceContainers.filter(isNotRunning)
+	running := len(serviceContainers) - len(toStart)
+	if options.KeepScaledDown {
+		// the containers beyond the scale are kept stopped
+		toStart = toStart.lowestNumbered(service.GetScale() - running)
+	}
+	if options.RestartExhausted == api.RestartExhaustedIgnore {
+		var err error
+		if toStart, err = s.withoutRestartsExhausted(ctx, service, toStart); err != nil {
+			return err
+		}
+	}
 	if len(toStart) == 0 {
 		return nil
 	}
 
+	// Secrets and configs are injected into every replica before pre_start
+	// runs, so a hook can consume them (e.g. to template a configuration file
+	// from a secret) when their target lives on a volume shared with the hook.
+	for _, ctr := range toStart {
+		if err := s.injectSecrets(ctx, project, service, ctr.ID); err != nil {
+			return err
+		}
+		if err := s.injectConfigs(ctx, project, service, ctr.ID); err != nil {
+			return err
+		}
+	}
+
 	// pre_start runs once per service, only when no replica is already running
 	// (e.g. initial up, force-recreate, or spec change). per_replica: false is
 	// the only currently supported mode. Pick the replica with the lowest
 	// container-number so the choice is deterministic regardless of the order
 	// the daemon returns containers in.
-	if len(service.PreStart) > 0 && len(serviceContainers) == len(toStart) {
+	if len(service.PreStart) > 0 && running == 0 {
 		if err := s.runPreStart(ctx, project, service, lowestNumberedContainer(toStart), listener); err != nil {
 			return err
 		}
 	}
 
 	for _, ctr := range toStart {
-		if err := s.startServiceContainer(ctx, project, service, ctr, listener); err != nil {
+		if err := s.startServiceContainer(ctx, service, ctr, listener, options.EarlyExitWindow); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *composeService) startServiceContainer(ctx context.Context, project *types.Project, service types.ServiceConfig, ctr container.Summary, listener api.ContainerEventListener) error {
-	if err := s.injectSecrets(ctx, project, service, ctr.ID); err != nil {
-		return err
+// withoutRestartsExhausted filters out the containers the engine gave up
+// restarting once the retries of the restart policy of the service were
+// exhausted, which are left exited
+func (s *composeService) withoutRestartsExhausted(ctx context.Context, service types.ServiceConfig, containers Containers) (Containers, error) {
+	if !retriesOnFailure(service) {
+		return containers, nil
 	}
-	if err := s.injectConfigs(ctx, project, service, ctr.ID); err != nil {
-		return err
+	var kept Containers
+	for _, ctr := range containers {
+		if ctr.State == container.StateExited {
+			res, err := s.apiClient().ContainerInspect(ctx, ctr.ID, client.ContainerInspectOptions{})
+			if err != nil {
+				return nil, err
+			}
+			oc := ObservedContainer{State: ctr.State}
+			oc.setRestartState(res.Container)
+			if oc.restartsExhausted() {
+				continue
+			}
+		}
+		kept = append(kept, ctr)
 	}
+	return kept, nil
+}
 
+// startServiceContainer starts a container whose secrets and configs have
+// already been injected, then runs the service's post_start hooks. When the
+// container replaces a recreated one and is started for the first time, the
+// x-update post_recreate hooks run too, once it is healthy, and it is paused
+// when the container it replaces was. With a non-zero earlyExitWindow, a
+// container exiting with an error within it is reported as failed.
+func (s *composeService) startServiceContainer(ctx context.Context, service types.ServiceConfig, ctr container.Summary, listener api.ContainerEventListener, earlyExitWindow time.Duration) error {
 	eventName := getContainerProgressName(ctr)
-	s.events.On(newEvent(eventName, api.Working, api.StatusStarting))
-	if _, err := s.apiClient().ContainerStart(ctx, ctr.ID, client.ContainerStartOptions{}); err != nil {
+	s.events(ctx).On(newEvent(eventName, api.Working, api.StatusStarting))
+	if err := s.restoreRestartPolicy(ctx, service, ctr.ID); err != nil {
+		return err
+	}
+	err := s.withSlot(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient().ContainerStart(ctx, ctr.ID, client.ContainerStartOptions{})
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
+	if earlyExitWindow > 0 {
+		if err := s.checkEarlyExit(ctx, ctr, earlyExitWindow); err != nil {
+			return err
+		}
+	}
+
 	for _, hook := range service.PostStart {
 		if err := s.runHook(ctx, ctr, service, hook, listener); err != nil {
 			return err
 		}
 	}
 
-	s.events.On(newEvent(eventName, api.Done, api.StatusStarted))
+	if _, replaces := ctr.Labels[api.ContainerReplaceLabel]; replaces && ctr.State == container.StateCreated {
+		config, err := getUpdateConfig(service)
+		if err != nil {
+			return err
+		}
+		if len(config.PostRecreate) > 0 {
+			if err := s.waitContainerHealthy(ctx, ctr.ID); err != nil {
+				return err
+			}
+			if err := s.runRecreateHooks(ctx, ctr, service, "post_recreate", config.PostRecreate, listener); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ctr.State == container.StateCreated && ctr.Labels[api.ReplacePausedLabel] == "true" {
+		// the container replaces a paused one, and is started for the first time
+		if _, err := s.apiClient().ContainerPause(ctx, ctr.ID, client.ContainerPauseOptions{}); err != nil {
+			return err
+		}
+		s.events(ctx).On(newEvent(eventName, api.Done, "Paused"))
+		return nil
+	}
+
+	s.events(ctx).On(newEvent(eventName, api.Done, api.StatusStarted))
 	return nil
 }
 
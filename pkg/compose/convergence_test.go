@@ -18,17 +18,26 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/netip"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jonboulle/clockwork"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/image"
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 
@@ -280,6 +289,415 @@ func TestWaitDependencies(t *testing.T) {
 		}
 		assert.NilError(t, tested.(*composeService).waitDependencies(t.Context(), &project, "", dependencies, nil, 0))
 	})
+	t.Run("should wait for dependency to stay healthy for min uptime", func(t *testing.T) {
+		clock := clockwork.NewFakeClock()
+		tested.(*composeService).clock = clock
+		defer func() { tested.(*composeService).clock = clockwork.NewRealClock() }()
+
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {
+				Condition:  types.ServiceConditionHealthy,
+				Required:   true,
+				Extensions: types.Extensions{dependsOnMinUptimeExtension: "3s"},
+			},
+		}
+		containers := Containers{{
+			ID:     "db-1",
+			Names:  []string{"/db-1"},
+			Labels: map[string]string{api.ServiceLabel: "db", api.OneoffLabel: "False"},
+		}}
+
+		// the dependency leaves the healthy state after a first blip, which
+		// must reset the uptime it has accumulated
+		statuses := []container.HealthStatus{container.Healthy, container.Starting, container.Healthy, container.Healthy, container.Healthy}
+		calls := 0
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "db-1", gomock.Any()).Times(len(statuses)).
+			DoAndReturn(func(context.Context, string, client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+				clock.Advance(2 * time.Second)
+				status := statuses[calls]
+				calls++
+				return client.ContainerInspectResult{Container: container.InspectResponse{
+					Name:   "/db-1",
+					State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: status}},
+					Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+				}}, nil
+			})
+
+		assert.NilError(t, tested.(*composeService).waitDependencies(t.Context(), &project, "app", dependencies, containers, 0))
+		assert.Equal(t, calls, len(statuses))
+	})
+	t.Run("should reject invalid min uptime", func(t *testing.T) {
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {
+				Condition:  types.ServiceConditionHealthy,
+				Required:   true,
+				Extensions: types.Extensions{dependsOnMinUptimeExtension: "soon"},
+			},
+		}
+		containers := Containers{{
+			ID:     "db-1",
+			Names:  []string{"/db-1"},
+			Labels: map[string]string{api.ServiceLabel: "db", api.OneoffLabel: "False"},
+		}}
+		err := tested.(*composeService).waitDependencies(t.Context(), &project, "app", dependencies, containers, 0)
+		assert.ErrorContains(t, err, "invalid x-min-uptime")
+	})
+	t.Run("should wait for probe to succeed", func(t *testing.T) {
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {
+				Condition:  types.ServiceConditionStarted,
+				Required:   true,
+				Extensions: types.Extensions{dependsOnProbeExtension: "pg_isready"},
+			},
+		}
+		containers := Containers{{
+			ID:     "db-1",
+			Names:  []string{"/db-1"},
+			Labels: map[string]string{api.ServiceLabel: "db", api.OneoffLabel: "False"},
+		}}
+
+		codes := []int{1, 0}
+		apiClient.EXPECT().ExecCreate(gomock.Any(), "db-1", client.ExecCreateOptions{
+			Cmd:          []string{"/bin/sh", "-c", "pg_isready"},
+			AttachStdout: true,
+			AttachStderr: true,
+		}).Times(len(codes)).Return(client.ExecCreateResult{ID: "exec-1"}, nil)
+		apiClient.EXPECT().ExecAttach(gomock.Any(), "exec-1", gomock.Any()).Times(len(codes)).
+			DoAndReturn(func(context.Context, string, client.ExecAttachOptions) (client.ExecAttachResult, error) {
+				serverConn, clientConn := net.Pipe()
+				serverConn.Close() //nolint:errcheck
+				return client.ExecAttachResult{HijackedResponse: client.NewHijackedResponse(clientConn, "")}, nil
+			})
+		calls := 0
+		apiClient.EXPECT().ExecInspect(gomock.Any(), "exec-1", gomock.Any()).Times(len(codes)).
+			DoAndReturn(func(context.Context, string, client.ExecInspectOptions) (client.ExecInspectResult, error) {
+				code := codes[calls]
+				calls++
+				return client.ExecInspectResult{ExitCode: code}, nil
+			})
+
+		assert.NilError(t, tested.(*composeService).waitDependencies(t.Context(), &project, "app", dependencies, containers, 0))
+		assert.Equal(t, calls, len(codes))
+	})
+	t.Run("should fail when probe can't run", func(t *testing.T) {
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {
+				Condition:  types.ServiceConditionHealthy,
+				Required:   true,
+				Extensions: types.Extensions{dependsOnProbeExtension: []any{"pg_isready", "-q"}},
+			},
+		}
+		containers := Containers{{
+			ID:     "db-1",
+			Names:  []string{"/db-1"},
+			Labels: map[string]string{api.ServiceLabel: "db", api.OneoffLabel: "False"},
+		}}
+
+		apiClient.EXPECT().ExecCreate(gomock.Any(), "db-1", gomock.Any()).
+			Return(client.ExecCreateResult{}, errors.New("container is not running"))
+
+		err := tested.(*composeService).waitDependencies(t.Context(), &project, "app", dependencies, containers, 0)
+		assert.ErrorContains(t, err, "failed to run x-probe in container db-1: container is not running")
+	})
+	t.Run("should reject invalid probe", func(t *testing.T) {
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {
+				Condition:  types.ServiceConditionHealthy,
+				Required:   true,
+				Extensions: types.Extensions{dependsOnProbeExtension: 42},
+			},
+		}
+		containers := Containers{{
+			ID:     "db-1",
+			Names:  []string{"/db-1"},
+			Labels: map[string]string{api.ServiceLabel: "db", api.OneoffLabel: "False"},
+		}}
+		err := tested.(*composeService).waitDependencies(t.Context(), &project, "app", dependencies, containers, 0)
+		assert.ErrorContains(t, err, "x-probe must be a command")
+	})
+}
+
+func TestDependencyErrors(t *testing.T) {
+	db := types.ServiceConfig{Name: "db", Scale: intPtr(1)}
+	web := types.ServiceConfig{
+		Name:        "web",
+		NetworkMode: "service:db",
+		DependsOn: types.DependsOnConfig{
+			"db": {Condition: types.ServiceConditionHealthy, Required: true},
+		},
+	}
+	project := &types.Project{Name: "demo", Services: types.Services{"db": db, "web": web}}
+	dbContainer := container.Summary{
+		ID:     "demo-db-1",
+		Names:  []string{"/demo-db-1"},
+		Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.OneoffLabel: "False"},
+	}
+
+	t.Run("dependency missing", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		err := svc.startService(t.Context(), project, web, Containers{}, nil, api.StartOptions{})
+		assert.Error(t, err, "web is missing dependency db")
+
+		var missing *api.DependencyMissingError
+		assert.Assert(t, errors.As(err, &missing))
+		assert.DeepEqual(t, *missing, api.DependencyMissingError{Service: "web", Dependency: "db"})
+	})
+
+	t.Run("dependency unhealthy", func(t *testing.T) {
+		svc, apiClient := newTestService(t)
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "demo-db-1", gomock.Any()).
+			Return(client.ContainerInspectResult{Container: container.InspectResponse{
+				Name:   "/demo-db-1",
+				State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: container.Unhealthy}},
+				Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+			}}, nil)
+
+		err := svc.startService(t.Context(), project, web, Containers{dbContainer}, nil, api.StartOptions{})
+		assert.Error(t, err, "dependency failed to start: container demo-db-1 is unhealthy")
+
+		var unhealthy *api.DependencyUnhealthyError
+		assert.Assert(t, errors.As(err, &unhealthy))
+		assert.Equal(t, unhealthy.Service, "web")
+		assert.Equal(t, unhealthy.Dependency, "db")
+		assert.Equal(t, unhealthy.Condition, types.ServiceConditionHealthy)
+	})
+
+	t.Run("reference unresolvable", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		plan := &Plan{}
+		plan.addNode(Operation{
+			Type:       OpCreateContainer,
+			ResourceID: "service:web:1",
+			Cause:      "no existing container",
+			Service:    &web,
+			Number:     1,
+			Name:       "demo-web-1",
+		}, "")
+
+		err := svc.executePlan(t.Context(), project, emptyObservedState("demo"), plan)
+		assert.Error(t, err, "cannot share network namespace with service db: container missing")
+
+		var unresolvable *api.ReferenceUnresolvableError
+		assert.Assert(t, errors.As(err, &unresolvable))
+		assert.DeepEqual(t, *unresolvable, api.ReferenceUnresolvableError{Service: "web", Reference: "db", Kind: "network namespace"})
+	})
+}
+
+func TestLockStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		service   *types.ServiceConfig
+		serialize bool
+	}{
+		{name: "unknown service", serialize: true},
+		{name: "default", service: &types.ServiceConfig{Name: "web"}, serialize: true},
+		{
+			name:    "opted out",
+			service: &types.ServiceConfig{Name: "web", Annotations: types.Mapping{api.SerializeStartAnnotation: "false"}},
+		},
+		{
+			name:      "invalid annotation",
+			service:   &types.ServiceConfig{Name: "web", Annotations: types.Mapping{api.SerializeStartAnnotation: "sometimes"}},
+			serialize: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unlock := lockStart(tt.service)
+			locked := !startMx.TryLock()
+			if !locked {
+				startMx.Unlock()
+			}
+			unlock()
+			assert.Equal(t, locked, tt.serialize)
+		})
+	}
+}
+
+func TestStartServiceKeepScaledDown(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli := mocks.NewMockCli(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	tested, err := NewComposeService(cli, WithEventProcessor(&capturingEvents{}))
+	assert.NilError(t, err)
+
+	web := types.ServiceConfig{Name: "web", Scale: intPtr(2)}
+	project := &types.Project{Name: "demo", Services: types.Services{"web": web}}
+	summary := func(number string, state container.ContainerState) container.Summary {
+		return container.Summary{
+			ID:     "demo-web-" + number,
+			Names:  []string{"/demo-web-" + number},
+			State:  state,
+			Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: number},
+		}
+	}
+	containers := Containers{
+		summary("3", container.StateExited),
+		summary("1", container.StateRunning),
+		summary("2", container.StateExited),
+	}
+
+	// web-3 is kept stopped beyond the scale of the service
+	apiClient.EXPECT().ContainerStart(gomock.Any(), "demo-web-2", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+
+	err = tested.(*composeService).startService(t.Context(), project, web, containers, nil, api.StartOptions{KeepScaledDown: true})
+	assert.NilError(t, err)
+}
+
+func TestStartServiceEarlyExit(t *testing.T) {
+	tests := []struct {
+		name  string
+		state container.State
+		err   string
+	}{
+		{
+			name:  "running",
+			state: container.State{Status: container.StateRunning, Running: true},
+		},
+		{
+			name:  "completed",
+			state: container.State{Status: container.StateExited, ExitCode: 0},
+		},
+		{
+			name:  "failed",
+			state: container.State{Status: container.StateExited, ExitCode: 127},
+			err: "container demo-web-1 exited with code 127 within 10s of being started, last log lines:\n" +
+				"starting\n" +
+				"exec: \"srever\": executable file not found in $PATH",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient := mocks.NewMockAPIClient(mockCtrl)
+			cli := mocks.NewMockCli(mockCtrl)
+			cli.EXPECT().Client().Return(apiClient).AnyTimes()
+			events := &capturingEvents{}
+			tested, err := NewComposeService(cli, WithEventProcessor(events))
+			assert.NilError(t, err)
+
+			web := types.ServiceConfig{Name: "web", Scale: intPtr(1)}
+			project := &types.Project{Name: "demo", Services: types.Services{"web": web}}
+			containers := Containers{{
+				ID:     "demo-web-1",
+				Names:  []string{"/demo-web-1"},
+				State:  container.StateCreated,
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ProjectLabel: "demo"},
+			}}
+
+			apiClient.EXPECT().ContainerStart(gomock.Any(), "demo-web-1", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "demo-web-1", gomock.Any()).Return(client.ContainerInspectResult{
+				Container: container.InspectResponse{ID: "demo-web-1", State: &tt.state, Config: &container.Config{Tty: true}},
+			}, nil)
+			if tt.err != "" {
+				apiClient.EXPECT().ContainerLogs(gomock.Any(), "demo-web-1", gomock.Any()).
+					Return(io.NopCloser(strings.NewReader("starting\nexec: \"srever\": executable file not found in $PATH")), nil)
+			}
+
+			clock := clockwork.NewFakeClock()
+			tested.(*composeService).clock = clock
+			go func() {
+				if clock.BlockUntilContext(t.Context(), 1) == nil {
+					clock.Advance(10 * time.Second)
+				}
+			}()
+
+			err = tested.(*composeService).startService(t.Context(), project, web, containers, nil, api.StartOptions{EarlyExitWindow: 10 * time.Second})
+			if tt.err == "" {
+				assert.NilError(t, err)
+				return
+			}
+			assert.Error(t, err, tt.err)
+			last := events.resources[len(events.resources)-1]
+			assert.Equal(t, last.Status, api.Error)
+			assert.Equal(t, last.Details, "Exited (127)")
+		})
+	}
+}
+
+func TestStartServiceSkipDependencyWaits(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli := mocks.NewMockCli(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	events := &capturingEvents{}
+	tested, err := NewComposeService(cli, WithEventProcessor(events))
+	assert.NilError(t, err)
+
+	web := types.ServiceConfig{
+		Name:  "web",
+		Scale: intPtr(1),
+		DependsOn: types.DependsOnConfig{
+			"db":    {Condition: types.ServiceConditionHealthy, Required: true},
+			"init":  {Condition: types.ServiceConditionCompletedSuccessfully, Required: true},
+			"cache": {Condition: types.ServiceConditionStarted, Required: true},
+		},
+	}
+	project := &types.Project{Name: "demo", Services: types.Services{
+		"web":   web,
+		"db":    {Name: "db", Scale: intPtr(1)},
+		"init":  {Name: "init", Scale: intPtr(1)},
+		"cache": {Name: "cache", Scale: intPtr(1)},
+	}}
+	summary := func(service string, state container.ContainerState) container.Summary {
+		return container.Summary{
+			ID:     "demo-" + service + "-1",
+			Names:  []string{"/demo-" + service + "-1"},
+			State:  state,
+			Labels: map[string]string{api.ServiceLabel: service, api.ContainerNumberLabel: "1"},
+		}
+	}
+	containers := Containers{
+		summary("db", container.StateRunning),
+		summary("init", container.StateRunning),
+		summary("web", container.StateCreated),
+	}
+
+	// neither db health nor init completion is inspected
+	apiClient.EXPECT().ContainerStart(gomock.Any(), "demo-web-1", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+
+	err = tested.(*composeService).startService(t.Context(), project, web, containers, nil, api.StartOptions{SkipDependencyWaits: true})
+	assert.NilError(t, err)
+
+	var skipped []string
+	for _, e := range events.resources {
+		if e.Status == api.Warning {
+			skipped = append(skipped, e.ID+": "+e.Text)
+		}
+	}
+	assert.DeepEqual(t, skipped, []string{
+		"Container demo-db-1: Skipped: service_healthy wait",
+		"Container demo-init-1: Skipped: service_completed_successfully wait",
+	})
+}
+
+func TestUptimeTracker(t *testing.T) {
+	start := time.Now()
+	tracker := uptimeTracker{minUptime: 10 * time.Second}
+	assert.Check(t, !tracker.ready(true, start))
+	assert.Check(t, !tracker.ready(true, start.Add(5*time.Second)))
+	assert.Check(t, !tracker.ready(false, start.Add(6*time.Second)))
+	assert.Check(t, !tracker.ready(true, start.Add(12*time.Second)))
+	assert.Check(t, tracker.ready(true, start.Add(22*time.Second)))
+
+	noMinimum := uptimeTracker{}
+	assert.Check(t, noMinimum.ready(true, start))
 }
 
 func TestIsServiceHealthy(t *testing.T) {
@@ -482,9 +900,7 @@ func TestCreateMobyContainer(t *testing.T) {
 		},
 	}, nil)
 
-	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, createOptions{
-		Labels: make(types.Labels),
-	})
+	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, NewCreateOptions())
 	var falseBool bool
 	want := client.ContainerCreateOptions{
 		Config: &container.Config{
@@ -492,8 +908,9 @@ func TestCreateMobyContainer(t *testing.T) {
 			AttachStderr: true,
 			Image:        "bork-test",
 			Labels: map[string]string{
-				"com.docker.compose.config-hash": "8dbce408396f8986266bc5deba0c09cfebac63c95c2238e405c7bee5f1bd84b8",
-				"com.docker.compose.depends_on":  "",
+				"com.docker.compose.config-hash":                      "8dbce408396f8986266bc5deba0c09cfebac63c95c2238e405c7bee5f1bd84b8",
+				"com.docker.compose.config-hash.healthcheck-excluded": "8dbce408396f8986266bc5deba0c09cfebac63c95c2238e405c7bee5f1bd84b8",
+				"com.docker.compose.depends_on":                       "",
 			},
 		},
 		HostConfig: &container.HostConfig{
@@ -595,10 +1012,7 @@ func TestCreateMobyContainerLegacyAPI(t *testing.T) {
 		},
 	}, nil)
 
-	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, createOptions{
-		Labels:            make(types.Labels),
-		UseNetworkAliases: true,
-	})
+	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, NewCreateOptions(withNetworkAliases(true)))
 	assert.NilError(t, err)
 
 	// ContainerCreate should only have the primary network (b, highest priority)
@@ -662,13 +1076,258 @@ func TestCreateMobyContainerLegacyAPI_NetworkConnectFailure(t *testing.T) {
 			return client.ContainerRemoveResult{}, nil
 		})
 
-	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, createOptions{
-		Labels:            make(types.Labels),
-		UseNetworkAliases: true,
-	})
+	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, NewCreateOptions(withNetworkAliases(true)))
 	assert.ErrorContains(t, err, "network connect failed")
 }
 
+func TestCreateMobyContainerLegacyAPI_NetworkNotAttached(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli := mocks.NewMockCli(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().ImageInspect(anyCancellableContext(), gomock.Any()).
+		Return(client.ImageInspectResult{}, nil).AnyTimes()
+
+	apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+		Return(client.PingResult{APIVersion: "1.43"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.43").AnyTimes()
+
+	service := types.ServiceConfig{
+		Name: "test",
+		Networks: map[string]*types.ServiceNetworkConfig{
+			"a": {Priority: 10},
+			"b": {Priority: 100},
+		},
+	}
+	project := types.Project{
+		Name: "bork",
+		Services: types.Services{
+			"test": service,
+		},
+		Networks: types.Networks{
+			"a": types.NetworkConfig{Name: "a-moby-name"},
+			"b": types.NetworkConfig{Name: "b-moby-name"},
+		},
+	}
+
+	apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		Return(client.ContainerCreateResult{ID: "an-id"}, nil)
+	// NetworkConnect reports success but the container doesn't end up attached
+	apiClient.EXPECT().NetworkConnect(gomock.Any(), gomock.Eq("a-moby-name"), gomock.Any()).
+		Return(client.NetworkConnectResult{}, nil)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), gomock.Eq("an-id"), gomock.Any()).
+		Return(client.ContainerInspectResult{
+			Container: container.InspectResponse{
+				ID:     "an-id",
+				Name:   "a-name",
+				Config: &container.Config{},
+				NetworkSettings: &container.NetworkSettings{
+					Networks: map[string]*network.EndpointSettings{
+						"b-moby-name": {Aliases: []string{"bork-test-0"}},
+					},
+				},
+			},
+		}, nil)
+	apiClient.EXPECT().ContainerRemove(gomock.Any(), gomock.Eq("an-id"), gomock.Any()).
+		Return(client.ContainerRemoveResult{}, nil)
+
+	_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, NewCreateOptions(withNetworkAliases(true)))
+	assert.ErrorContains(t, err, "container test is not attached to network(s) a-moby-name after creation")
+}
+
+func TestCreateMobyContainer_RemovedOnFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		removeErr error
+		warning   string
+	}{
+		{
+			name: "removed",
+		},
+		{
+			name:      "remove fails",
+			removeErr: errors.New("device or resource busy"),
+			warning:   "failed to remove container test after its creation failed: device or resource busy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient := mocks.NewMockAPIClient(mockCtrl)
+			cli := mocks.NewMockCli(mockCtrl)
+			tested, err := NewComposeService(cli)
+			assert.NilError(t, err)
+			cli.EXPECT().Client().Return(apiClient).AnyTimes()
+			cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+			apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+			apiClient.EXPECT().ImageInspect(anyCancellableContext(), gomock.Any()).
+				Return(client.ImageInspectResult{}, nil).AnyTimes()
+			apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+				Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+			apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+
+			service := types.ServiceConfig{Name: "test"}
+			project := types.Project{Name: "bork", Services: types.Services{"test": service}}
+
+			apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+				Return(client.ContainerCreateResult{ID: "an-id"}, nil)
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "an-id", gomock.Any()).
+				Return(client.ContainerInspectResult{}, errors.New("inspect failed"))
+			apiClient.EXPECT().ContainerRemove(gomock.Any(), "an-id", client.ContainerRemoveOptions{Force: true}).
+				Return(client.ContainerRemoveResult{}, tt.removeErr)
+			logs := logrustest.NewGlobal()
+
+			_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "test", 0, nil, NewCreateOptions())
+			assert.Error(t, err, "inspect failed")
+			if tt.warning == "" {
+				assert.Equal(t, len(logs.AllEntries()), 0)
+				return
+			}
+			assert.Equal(t, logs.LastEntry().Message, tt.warning)
+		})
+	}
+}
+
+func TestCreateMobyContainer_ImageVerification(t *testing.T) {
+	const (
+		trusted  = "sha256:9b7d2eb8d8e6e5ff4f5b1c4e4d1b2d5c6a7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a"
+		tampered = "sha256:1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b"
+	)
+	mockCtrl := gomock.NewController(t)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli := mocks.NewMockCli(mockCtrl)
+	verifier := &fakeImageVerifier{verified: map[string]string{}, failing: map[string]error{}}
+	tested, err := NewComposeService(cli, WithCreateImageVerifier(verifier))
+	assert.NilError(t, err)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	// the local image ID isn't the digest verified
+	repoDigests := []string{"nginx@" + trusted}
+	apiClient.EXPECT().ImageInspect(anyCancellableContext(), gomock.Any()).
+		DoAndReturn(func(context.Context, string, ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+			return client.ImageInspectResult{InspectResponse: image.InspectResponse{
+				ID:          "sha256:localid",
+				RepoDigests: repoDigests,
+			}}, nil
+		}).AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+		Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+
+	service := types.ServiceConfig{
+		Name:         "web",
+		Image:        "nginx",
+		CustomLabels: types.Labels{api.ImageDigestLabel: "sha256:localid"},
+	}
+	project := types.Project{Name: "bork", Services: types.Services{"web": service}}
+	create := func(service types.ServiceConfig) error {
+		_, err := tested.(*composeService).createMobyContainer(t.Context(), &project, service, "bork-web-1", 1, nil, NewCreateOptions(WithContainerLabels(service.CustomLabels)))
+		return err
+	}
+
+	// a verified image is created from, the engine failing the creation here
+	apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		Return(client.ContainerCreateResult{}, errors.New("create failed")).Times(2)
+	assert.Error(t, create(service), "create failed")
+	assert.DeepEqual(t, verifier.verified, map[string]string{"nginx": trusted})
+
+	// the verification of a digest is kept
+	verifier.failing["nginx"] = errors.New("no matching signatures")
+	assert.Error(t, create(service), "create failed")
+
+	// an image failing verification isn't created from
+	repoDigests = []string{"nginx@" + tampered}
+	assert.Error(t, create(service), "image nginx ("+tampered+") of service web failed verification: no matching signatures")
+
+	// nor one without a registry digest
+	repoDigests = nil
+	assert.Error(t, create(service), "image nginx of service web failed verification: no registry digest to verify the image with, as it wasn't pulled from a registry")
+}
+
+// pullResponse is a client.ImagePullResponse streaming no progress
+type pullResponse struct {
+	io.ReadCloser
+	client.ImagePullResponse
+}
+
+func (r pullResponse) Read(p []byte) (int, error) { return r.ReadCloser.Read(p) }
+func (r pullResponse) Close() error               { return r.ReadCloser.Close() }
+func (r pullResponse) Wait(context.Context) error { return r.ReadCloser.Close() }
+
+func TestCreateMobyContainer_ImageRemoved(t *testing.T) {
+	removed := fmt.Errorf("No such image: nginx: %w", errdefs.ErrNotFound)
+	tests := []struct {
+		name       string
+		pullPolicy string
+		build      *types.BuildConfig
+		pullErr    error
+		err        string
+	}{
+		{name: "pulled again"},
+		{name: "pull policy never", pullPolicy: types.PullPolicyNever, err: removed.Error()},
+		{name: "built", build: &types.BuildConfig{Context: "."}, err: removed.Error()},
+		{
+			name:    "pull fails",
+			pullErr: errors.New("registry unreachable"),
+			err:     removed.Error() + ", and pulling it again failed: registry unreachable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient := mocks.NewMockAPIClient(mockCtrl)
+			cli := mocks.NewMockCli(mockCtrl)
+			tested, err := NewComposeService(cli, WithEventProcessor(&capturingEvents{}))
+			assert.NilError(t, err)
+			cli.EXPECT().Client().Return(apiClient).AnyTimes()
+			cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+			apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+			apiClient.EXPECT().ImageInspect(anyCancellableContext(), gomock.Any()).
+				Return(client.ImageInspectResult{}, nil).AnyTimes()
+			// inspected once pulled again
+			apiClient.EXPECT().ImageInspect(gomock.Any(), "nginx").
+				Return(client.ImageInspectResult{}, nil).AnyTimes()
+			apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+				Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+			apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+
+			service := types.ServiceConfig{Name: "test", Image: "nginx", PullPolicy: tt.pullPolicy, Build: tt.build}
+			project := types.Project{Name: "bork", Services: types.Services{"test": service}}
+
+			apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+				Return(client.ContainerCreateResult{}, removed)
+			if tt.err == "" || tt.pullErr != nil {
+				apiClient.EXPECT().ImagePull(gomock.Any(), "nginx", gomock.Any()).
+					Return(pullResponse{ReadCloser: io.NopCloser(strings.NewReader(""))}, tt.pullErr)
+			}
+			if tt.err == "" {
+				apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+					Return(client.ContainerCreateResult{ID: "an-id"}, nil)
+				apiClient.EXPECT().ContainerInspect(gomock.Any(), "an-id", gomock.Any()).
+					Return(client.ContainerInspectResult{Container: container.InspectResponse{
+						ID:              "an-id",
+						Name:            "/bork-test-1",
+						Config:          &container.Config{},
+						NetworkSettings: &container.NetworkSettings{},
+					}}, nil)
+			}
+
+			_, err = tested.(*composeService).createMobyContainer(t.Context(), &project, service, "bork-test-1", 1, nil, NewCreateOptions())
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
 func TestRuntimeAPIVersionCachesNegotiation(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -730,3 +1389,160 @@ func TestRuntimeAPIVersionRetriesOnTransientError(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Equal(t, version, "1.44")
 }
+
+func TestRuntimeAPIVersionNonDockerRuntime(t *testing.T) {
+	tests := []struct {
+		reported string
+		expected string
+	}{
+		{reported: "1.41-podman", expected: "1.41"},
+		{reported: "v1.43", expected: "1.43"},
+		{reported: "", expected: client.MinAPIVersion},
+		{reported: "unknown", expected: client.MinAPIVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reported, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient := mocks.NewMockAPIClient(mockCtrl)
+			cli := mocks.NewMockCli(mockCtrl)
+			tested := &composeService{dockerCli: cli}
+
+			cli.EXPECT().Client().Return(apiClient).AnyTimes()
+			apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+				Return(client.PingResult{APIVersion: tt.reported}, nil)
+			apiClient.EXPECT().ClientVersion().Return(tt.reported).AnyTimes()
+
+			version, err := tested.RuntimeAPIVersion(t.Context())
+			assert.NilError(t, err)
+			assert.Equal(t, version, tt.expected)
+		})
+	}
+}
+
+func TestNormalizeAPIVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+		ok       bool
+	}{
+		{version: "1.44", expected: "1.44", ok: true},
+		{version: "1.41.0", expected: "1.41", ok: true},
+		{version: "v1.40", expected: "1.40", ok: true},
+		{version: "1.41-podman", expected: "1.41", ok: true},
+		{version: " 1.43 ", expected: "1.43", ok: true},
+		{version: "", ok: false},
+		{version: "1", ok: false},
+		{version: "podman", ok: false},
+	}
+	for _, tt := range tests {
+		version, ok := normalizeAPIVersion(tt.version)
+		assert.Equal(t, ok, tt.ok, tt.version)
+		assert.Equal(t, version, tt.expected, tt.version)
+	}
+}
+
+func TestCreateMobyContainerTraceLabel(t *testing.T) {
+	traceID := trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	spanID := trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+	traced := trace.ContextWithSpanContext(t.Context(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "traced", ctx: traced, want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "not traced", ctx: t.Context()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient := mocks.NewMockAPIClient(mockCtrl)
+			cli := mocks.NewMockCli(mockCtrl)
+			tested, err := NewComposeService(cli)
+			assert.NilError(t, err)
+			cli.EXPECT().Client().Return(apiClient).AnyTimes()
+			cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+			apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+			apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+				Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+			apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+
+			service := types.ServiceConfig{Name: "test"}
+			project := types.Project{Name: "bork", Services: types.Services{"test": service}}
+
+			var labels map[string]string
+			apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, opts client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+					labels = opts.Config.Labels
+					return client.ContainerCreateResult{ID: "an-id"}, nil
+				})
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "an-id", gomock.Any()).
+				Return(client.ContainerInspectResult{Container: container.InspectResponse{
+					ID:              "an-id",
+					Config:          &container.Config{},
+					NetworkSettings: &container.NetworkSettings{},
+				}}, nil)
+
+			_, err = tested.(*composeService).createMobyContainer(tt.ctx, &project, service, "test", 1, nil, NewCreateOptions())
+			assert.NilError(t, err)
+			traceParent, ok := labels[api.TraceIDLabel]
+			if tt.want == "" {
+				assert.Assert(t, !ok)
+				return
+			}
+			assert.Equal(t, traceParent, tt.want)
+			parts := strings.Split(traceParent, "-")
+			assert.Equal(t, len(parts), 4)
+			parsed, err := trace.TraceIDFromHex(parts[1])
+			assert.NilError(t, err)
+			assert.Equal(t, parsed, traceID)
+
+			// the trace isn't part of the service configuration, so doesn't change its hash
+			hash, err := ServiceHash(service)
+			assert.NilError(t, err)
+			assert.Equal(t, labels[api.ConfigHashLabel], hash)
+		})
+	}
+}
+
+func TestStartServiceRestartExhaustedIgnore(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli := mocks.NewMockCli(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	web := types.ServiceConfig{Name: "web", Scale: intPtr(2), Restart: "on-failure:3"}
+	project := &types.Project{Name: "demo", Services: types.Services{"web": web}}
+	summary := func(number string) container.Summary {
+		return container.Summary{
+			ID:     "demo-web-" + number,
+			Names:  []string{"/demo-web-" + number},
+			State:  container.StateExited,
+			Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: number},
+		}
+	}
+	inspected := func(id string, restarts int) client.ContainerInspectResult {
+		return client.ContainerInspectResult{Container: container.InspectResponse{
+			ID:           id,
+			RestartCount: restarts,
+			State:        &container.State{Status: container.StateExited, ExitCode: 1},
+			HostConfig:   &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3}},
+		}}
+	}
+
+	// demo-web-1 exhausted its retries and is left exited, demo-web-2 is started
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "demo-web-1", gomock.Any()).Return(inspected("demo-web-1", 3), nil)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "demo-web-2", gomock.Any()).Return(inspected("demo-web-2", 1), nil)
+	apiClient.EXPECT().ContainerStart(gomock.Any(), "demo-web-2", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+
+	err = tested.(*composeService).startService(t.Context(), project, web, Containers{summary("1"), summary("2")}, nil,
+		api.StartOptions{RestartExhausted: api.RestartExhaustedIgnore})
+	assert.NilError(t, err)
+}
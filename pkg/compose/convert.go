@@ -24,6 +24,8 @@ import (
 
 	compose "github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client/pkg/versions"
+	"github.com/sirupsen/logrus"
 )
 
 // ToMobyEnv convert into []string
@@ -73,6 +75,14 @@ func (s *composeService) ToMobyHealthCheck(ctx context.Context, check *compose.H
 			// see https://github.com/moby/moby/issues/48874
 			return nil, errors.New("healthcheck.start_interval requires healthcheck.start_period to be set")
 		}
+		supported, err := s.supportsStartInterval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !supported {
+			logrus.Warnf("healthcheck.start_interval requires Docker Engine %s or later and is ignored", dockerEngineV25)
+			startInterval = 0
+		}
 	}
 	return &container.HealthConfig{
 		Test:          test,
@@ -84,6 +94,16 @@ func (s *composeService) ToMobyHealthCheck(ctx context.Context, check *compose.H
 	}, nil
 }
 
+// supportsStartInterval reports whether the engine supports the start_interval
+// of healthchecks, which older ones ignore.
+func (s *composeService) supportsStartInterval(ctx context.Context) (bool, error) {
+	version, err := s.RuntimeAPIVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	return !versions.LessThan(version, apiVersion144), nil
+}
+
 // ToSeconds convert into seconds
 func ToSeconds(d *compose.Duration) *int {
 	if d == nil {
@@ -45,7 +45,7 @@ const (
 func (s *composeService) Copy(ctx context.Context, projectName string, options api.CopyOptions) error {
 	return Run(ctx, func(ctx context.Context) error {
 		return s.copy(ctx, projectName, options)
-	}, "copy", s.events)
+	}, "copy", s.events(ctx))
 }
 
 func (s *composeService) copy(ctx context.Context, projectName string, options api.CopyOptions) error {
@@ -90,7 +90,7 @@ func (s *composeService) copy(ctx context.Context, projectName string, options a
 			} else {
 				msg = fmt.Sprintf("%s to %s:%s", srcPath, name, dstPath)
 			}
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:      name,
 				Text:    api.StatusCopying,
 				Details: msg,
@@ -99,7 +99,7 @@ func (s *composeService) copy(ctx context.Context, projectName string, options a
 			if err := copyFunc(ctx, ctr.ID, srcPath, dstPath, options); err != nil {
 				return err
 			}
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:      name,
 				Text:    api.StatusCopied,
 				Details: msg,
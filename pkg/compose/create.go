@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/netip"
 	"os"
@@ -29,6 +30,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/paths"
 	"github.com/compose-spec/compose-go/v2/types"
@@ -42,14 +44,108 @@ import (
 	"github.com/sirupsen/logrus"
 	cdi "tags.cncf.io/container-device-interface/pkg/parser"
 
+	"github.com/docker/compose/v5/internal/tracing"
 	"github.com/docker/compose/v5/pkg/api"
 )
 
-type createOptions struct {
+// CreateOptions are the create-time options of a container, set with
+// CreateOption functions
+type CreateOptions struct {
 	AutoRemove        bool
 	AttachStdin       bool
 	UseNetworkAliases bool
 	Labels            types.Labels
+	// CheckHostPaths checks the sources of bind mounts exist, failing for a
+	// missing one declared with create_host_path: false and warning for the
+	// others, or creates them with CreateHostPaths
+	CheckHostPaths  bool
+	CreateHostPaths bool
+	// Image overrides the service image the container is created from
+	Image string
+	// PairedContainer is the ID of the container a sidecar is paired with,
+	// whose namespaces it shares
+	PairedContainer string
+}
+
+// CreateOption sets a create-time option of a container
+type CreateOption func(opts *CreateOptions)
+
+// NewCreateOptions returns the options to create a container, with no label
+// set but those passed with WithContainerLabels.
+func NewCreateOptions(opts ...CreateOption) CreateOptions {
+	options := CreateOptions{Labels: types.Labels{}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// withAutoRemove removes the container once it exited
+func withAutoRemove(autoRemove bool) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.AutoRemove = autoRemove
+	}
+}
+
+// withAttachStdin keeps stdin open for the container to be attached to
+func withAttachStdin(attach bool) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.AttachStdin = attach
+	}
+}
+
+// withImage creates the container from image rather than the service image,
+// the container labels still recording the service one
+func withImage(image string) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.Image = image
+	}
+}
+
+// withNetworkAliases sets the service name and aliases on the networks of the
+// container
+func withNetworkAliases(useAliases bool) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.UseNetworkAliases = useAliases
+	}
+}
+
+// WithContainerLabels adds labels to the container, overriding the ones
+// already set
+func WithContainerLabels(labels types.Labels) CreateOption {
+	return func(opts *CreateOptions) {
+		for k, v := range labels {
+			opts.Labels[k] = v
+		}
+	}
+}
+
+// withHostPathsCheck checks the sources of bind mounts exist, failing for the
+// ones declared with create_host_path: false, or creates them when create is
+// set
+func withHostPathsCheck(create bool) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.CheckHostPaths = true
+		opts.CreateHostPaths = create
+	}
+}
+
+// withPairedContainer shares the namespaces of the container a sidecar is
+// paired with
+func withPairedContainer(id string) CreateOption {
+	return func(opts *CreateOptions) {
+		opts.PairedContainer = id
+	}
+}
+
+// WithCreateOptions sets create-time options applied to every container the
+// service creates, on top of the ones set by the operation creating it, so
+// embedders can add create-time behavior, as with WithContainerLabels.
+func WithCreateOptions(opts ...CreateOption) Option {
+	return func(s *composeService) error {
+		s.createOptions = append(s.createOptions, opts...)
+		return nil
+	}
 }
 
 type createConfigs struct {
@@ -60,9 +156,12 @@ type createConfigs struct {
 }
 
 func (s *composeService) Create(ctx context.Context, project *types.Project, createOpts api.CreateOptions) error {
+	// the containers aren't started, so their restart policy is restored now
+	ctx, restarts := withSuspendedRestarts(ctx)
+	defer s.restoreSuspendedRestarts(context.WithoutCancel(ctx), project, restarts)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.create(ctx, project, createOpts)
-	}, "create", s.events)
+	}, "create", s.events(ctx))
 }
 
 func (s *composeService) create(ctx context.Context, project *types.Project, options api.CreateOptions) error {
@@ -70,12 +169,44 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 		options.Services = project.ServiceNames()
 	}
 
-	err := project.CheckContainerNameUnicity()
+	err := CheckContainerNames(project)
+	if err != nil {
+		return err
+	}
+
+	if !options.SkipIPv6Check {
+		if err := s.checkIPv6(ctx, project); err != nil {
+			return err
+		}
+	}
+
+	if !options.SkipPlacementCheck {
+		if err := s.checkPlacement(ctx, project, options.Services); err != nil {
+			return err
+		}
+	}
+
+	if options.ValidateReferences {
+		if err := s.checkReferences(ctx, project, options.Services); err != nil {
+			return err
+		}
+	}
+
+	err = s.useDockerContexts(project)
+	if err != nil {
+		return err
+	}
+
+	// images pulled in the background couldn't be verified before the
+	// containers are created
+	verify := options.VerifyImages != "" && options.VerifyImages != api.VerifyImagesOff
+	pulls, err := s.ensureImages(ctx, project, options.Build, options.QuietPull, options.PipelinePull && !verify)
 	if err != nil {
 		return err
 	}
+	defer pulls.stop()
 
-	err = s.ensureImagesExists(ctx, project, options.Build, options.QuietPull)
+	err = s.verifyImages(ctx, project, options.VerifyImages)
 	if err != nil {
 		return err
 	}
@@ -86,8 +217,9 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 	}
 
 	prepareNetworks(project)
+	variables := prepareEnvironmentHash(project)
 
-	networks, err := s.ensureNetworks(ctx, project)
+	networks, err := s.ensureNetworks(ctx, project, options.LazyResources)
 	if err != nil {
 		return err
 	}
@@ -104,13 +236,37 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 		return err
 	}
 
-	observed, err := s.collectObservedState(ctx, project)
+	// Hold the service locks from observation to plan execution, so a
+	// concurrent converge of the same services plans against the state this
+	// one leaves behind.
+	unlock, err := s.serviceLocks.lock(ctx, project.Name, project.ServiceNames())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	observed, err := s.loadObservedState(ctx, project)
 	if err != nil {
 		return err
 	}
+	if options.ResourcePreflight != "" {
+		if err := s.collectHostResources(ctx, observed); err != nil {
+			return err
+		}
+	}
+	// existing containers are compared with the image they are expected to
+	// run, so their services can't be planned before it is pulled
+	if err := pulls.await(ctx, project, observed.servicesWithContainers()); err != nil {
+		return err
+	}
+	applyScaleDelta(project, observed, options.ScaleDelta)
+	pairSidecarScales(project)
 	observed.setResolvedNetworks(networks, project)
 	observed.setResolvedVolumes(externalVolumes)
+	markAbsentDependencies(project, observedRunning(observed))
 	warnUnmanagedVolumes(project, observed)
+	warnRestartingContainers(project, observed, options.RecreateRestarting)
+	noticeEnvironmentChanges(project, observed, variables)
 
 	if len(observed.Orphans) > 0 && !options.IgnoreOrphans && !options.RemoveOrphans {
 		logrus.Warnf("Found orphan containers (%s) for this project. If "+
@@ -119,16 +275,57 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 			"--remove-orphans flag to clean it up.", observed.orphanNames())
 	}
 
-	plan, err := reconcile(ctx, project, observed, toReconcileOptions(options), s.prompt)
+	reconcileOptions := toReconcileOptions(options)
+	reconcileOptions.NumberAllocator = s.numberAllocator
+	plan, err := reconcile(ctx, project, observed, reconcileOptions, s.prompt)
 	if err != nil {
 		return err
 	}
 
-	// Emit "Running" events for containers that are already up-to-date,
-	// matching the previous convergence behavior for progress display.
-	emitRunningEvents(project, observed, plan, s.events)
+	// Emit "Unchanged" events for containers that are already up-to-date, so
+	// the progress display tells them apart from the ones acted on.
+	emitUnchangedEvents(project, observed, plan, s.events(ctx))
+	emitRestartsExhaustedEvents(project, observed, options.RestartExhausted, s.events(ctx))
+	if reporter, ok := s.events(ctx).(*upReporter); ok {
+		reporter.onDrifts(plan.Drifts)
+	}
+
+	exec := s.newPlanExecutor(project, observed)
+	exec.createHostPaths = options.CreateHostPaths
+	exec.containerCreated = options.ContainerCreated
+	exec.confirmCanary = options.ConfirmCanary
+	exec.canaryTimeout = options.CanaryTimeout
+	exec.imagePulls = pulls
+	err = exec.run(ctx, plan)
+	if err == nil {
+		err = pulls.awaitAll(ctx, project)
+	}
+	return s.saveObservedState(ctx, project, observed, plan, err)
+}
 
-	return s.executePlan(ctx, project, observed, plan)
+// applyScaleDelta resolves relative scale requests against the containers
+// currently running or created for the service, a delta bringing the count
+// below zero scaling the service down to zero.
+func applyScaleDelta(project *types.Project, observed *ObservedState, deltas map[string]int) {
+	for _, name := range slices.Sorted(maps.Keys(deltas)) {
+		service, ok := project.Services[name]
+		if !ok {
+			continue
+		}
+		current := 0
+		for _, oc := range observed.Containers[name] {
+			if oc.State == container.StateRunning || oc.State == container.StateCreated {
+				current++
+			}
+		}
+		replicas := current + deltas[name]
+		if replicas < 0 {
+			logrus.Warnf("Service %q can't be scaled down by %d as it only has %d replicas, scaling it to 0", name, -deltas[name], current)
+			replicas = 0
+		}
+		service.SetScale(replicas)
+		project.Services[name] = service
+	}
 }
 
 func prepareNetworks(project *types.Project) {
@@ -141,9 +338,15 @@ func prepareNetworks(project *types.Project) {
 	}
 }
 
-func (s *composeService) ensureNetworks(ctx context.Context, project *types.Project) (map[string]string, error) {
+// ensureNetworks resolves the networks of the project, creating the missing
+// ones unless lazy: their creation is then planned with the containers using
+// them.
+func (s *composeService) ensureNetworks(ctx context.Context, project *types.Project, lazy bool) (map[string]string, error) {
 	networks := map[string]string{}
 	for name, nw := range project.Networks {
+		if lazy && !bool(nw.External) {
+			continue
+		}
 		id, err := s.ensureNetwork(ctx, project, name, &nw)
 		if err != nil {
 			return nil, err
@@ -215,18 +418,50 @@ func warnUnmanagedVolumes(project *types.Project, observed *ObservedState) {
 	}
 }
 
+// warnRestartingContainers warns about the containers of the project the
+// engine keeps restarting, which otherwise would be left as is and up succeed
+// while the service is crash-looping. The ones stuck restarting for longer
+// than threshold are recreated.
+func warnRestartingContainers(project *types.Project, observed *ObservedState, threshold time.Duration) {
+	for _, name := range project.ServiceNames() {
+		for _, oc := range observed.Containers[name] {
+			if oc.State != container.StateRestarting || oc.RestartCount == 0 {
+				continue
+			}
+			if stuckRestarting(oc, observed.ObservedAt, threshold) {
+				logrus.Warnf("container %s is stuck restarting, restarted %d times: it will be recreated", oc.Name, oc.RestartCount)
+			} else {
+				logrus.Warnf("container %s is restarting, restarted %d times: service %q may be crash-looping", oc.Name, oc.RestartCount, name)
+			}
+		}
+	}
+}
+
 //nolint:gocyclo
 func (s *composeService) getCreateConfigs(ctx context.Context,
 	p *types.Project,
 	service types.ServiceConfig,
 	number int,
 	inherit *container.Summary,
-	opts createOptions,
+	opts CreateOptions,
 ) (createConfigs, error) {
+	if len(s.createOptions) > 0 {
+		labels := types.Labels{}
+		maps.Copy(labels, opts.Labels)
+		opts.Labels = labels
+		for _, opt := range s.createOptions {
+			opt(&opts)
+		}
+	}
 	labels, err := s.prepareLabels(opts.Labels, service, number)
 	if err != nil {
 		return createConfigs{}, err
 	}
+	// the traceparent is set on the container, not the service, so it doesn't
+	// contribute to the config hash
+	if traceParent := tracing.TraceParent(ctx); traceParent != "" {
+		labels[api.TraceIDLabel] = traceParent
+	}
 
 	var runCmd, entrypoint []string
 	if service.Command != nil {
@@ -243,6 +478,10 @@ func (s *composeService) getCreateConfigs(ctx context.Context,
 
 	proxyConfig := types.MappingWithEquals(s.configFile().ParseProxyConfig(s.apiClient().DaemonHost(), nil))
 	env := proxyConfig.OverrideBy(service.Environment)
+	placementEnv, err := s.placementEnv(ctx, service)
+	if err != nil {
+		return createConfigs{}, err
+	}
 
 	var mainNwName string
 	var mainNw *types.ServiceNetworkConfig
@@ -283,15 +522,24 @@ func (s *composeService) getCreateConfigs(ctx context.Context,
 		NetworkDisabled: service.NetworkMode == "disabled",
 		Labels:          labels,
 		StopSignal:      service.StopSignal,
-		Env:             ToMobyEnv(env),
+		Env:             append(ToMobyEnv(env), placementEnv...),
 		Healthcheck:     healthcheck,
 		StopTimeout:     ToSeconds(service.StopGracePeriod),
-	} // VOLUMES/MOUNTS/FILESYSTEMS
+	}
+	if opts.Image != "" {
+		containerConfig.Image = opts.Image
+	}
+	// VOLUMES/MOUNTS/FILESYSTEMS
 	tmpfs := map[string]string{}
 	for _, t := range service.Tmpfs {
 		k, v, _ := strings.Cut(t, ":")
 		tmpfs[k] = v
 	}
+	if opts.CheckHostPaths && isLocalDaemon(s.apiClient().DaemonHost()) {
+		if err := s.checkHostPaths(ctx, service, opts.CreateHostPaths); err != nil {
+			return createConfigs{}, err
+		}
+	}
 	binds, mounts, err := s.buildContainerVolumes(ctx, *p, service, inherit)
 	if err != nil {
 		return createConfigs{}, err
@@ -380,6 +628,9 @@ func (s *composeService) getCreateConfigs(ctx context.Context,
 		hostConfig.MaskedPaths = []string{}
 		hostConfig.ReadonlyPaths = []string{}
 	}
+	if opts.PairedContainer != "" {
+		shareNamespaces(&hostConfig, service, opts.PairedContainer)
+	}
 
 	cfgs := createConfigs{
 		Container: &containerConfig,
@@ -550,6 +801,11 @@ func (s *composeService) prepareLabels(labels types.Labels, service types.Servic
 		return nil, err
 	}
 	labels[api.ConfigHashLabel] = hash
+	hash, err = serviceHashExcludingHealthcheck(service)
+	if err != nil {
+		return nil, err
+	}
+	labels[api.HealthcheckExcludedHashLabel] = hash
 
 	if number > 0 {
 		// One-off containers are not indexed
@@ -883,7 +1139,7 @@ func buildContainerPortBindingOptions(s types.ServiceConfig) (network.PortMap, e
 		}
 		var hostIP netip.Addr
 		if port.HostIP != "" {
-			hostIP, err = netip.ParseAddr(port.HostIP)
+			hostIP, err = parseHostIP(port.HostIP)
 			if err != nil {
 				return nil, err
 			}
@@ -1500,14 +1756,14 @@ func (s *composeService) resolveOrCreateNetwork(ctx context.Context, project *ty
 	}
 
 	networkEventName := fmt.Sprintf("Network %s", n.Name)
-	s.events.On(creatingEvent(networkEventName))
+	s.events(ctx).On(creatingEvent(networkEventName))
 
 	resp, err := s.apiClient().NetworkCreate(ctx, n.Name, createOpts)
 	if err != nil {
-		s.events.On(errorEvent(networkEventName, err.Error()))
+		s.events(ctx).On(errorEvent(networkEventName, err.Error()))
 		return "", fmt.Errorf("failed to create network %s: %w", n.Name, err)
 	}
-	s.events.On(createdEvent(networkEventName))
+	s.events(ctx).On(createdEvent(networkEventName))
 
 	err = s.connectNetwork(ctx, n.Name, dangledContainers, nil)
 	if err != nil {
@@ -1549,7 +1805,7 @@ func (s *composeService) removeDivergedNetwork(ctx context.Context, project *typ
 
 	_, err = s.apiClient().NetworkRemove(ctx, n.Name, client.NetworkRemoveOptions{})
 	eventName := fmt.Sprintf("Network %s", n.Name)
-	s.events.On(removedEvent(eventName))
+	s.events(ctx).On(removedEvent(eventName))
 	return containers, err
 }
 
@@ -1643,7 +1899,7 @@ func (s *composeService) resolveExternalNetwork(ctx context.Context, n *types.Ne
 
 func (s *composeService) createVolume(ctx context.Context, volume types.VolumeConfig) error {
 	eventName := fmt.Sprintf("Volume %s", volume.Name)
-	s.events.On(creatingEvent(eventName))
+	s.events(ctx).On(creatingEvent(eventName))
 	hash, err := VolumeHash(volume)
 	if err != nil {
 		return err
@@ -1656,10 +1912,10 @@ func (s *composeService) createVolume(ctx context.Context, volume types.VolumeCo
 		DriverOpts: volume.DriverOpts,
 	})
 	if err != nil {
-		s.events.On(errorEvent(eventName, err.Error()))
+		s.events(ctx).On(errorEvent(eventName, err.Error()))
 		return err
 	}
-	s.events.On(createdEvent(eventName))
+	s.events(ctx).On(createdEvent(eventName))
 	return nil
 }
 
@@ -31,6 +31,7 @@ import (
 	mountTypes "github.com/moby/moby/api/types/mount"
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/assert/cmp"
@@ -90,6 +91,25 @@ func TestServiceImageName(t *testing.T) {
 	assert.Equal(t, api.GetImageNameOrDefault(composetypes.ServiceConfig{Name: "aService"}, "myProject"), "myProject-aService")
 }
 
+func TestNewCreateOptions(t *testing.T) {
+	assert.DeepEqual(t, NewCreateOptions(), CreateOptions{Labels: composetypes.Labels{}})
+
+	opts := NewCreateOptions(
+		withAutoRemove(true),
+		withNetworkAliases(true),
+		WithContainerLabels(composetypes.Labels{"a": "1", "b": "2"}),
+		WithContainerLabels(composetypes.Labels{"b": "3"}),
+		withHostPathsCheck(true),
+	)
+	assert.DeepEqual(t, opts, CreateOptions{
+		AutoRemove:        true,
+		UseNetworkAliases: true,
+		Labels:            composetypes.Labels{"a": "1", "b": "3"},
+		CheckHostPaths:    true,
+		CreateHostPaths:   true,
+	})
+}
+
 func TestPrepareNetworkLabels(t *testing.T) {
 	project := composetypes.Project{
 		Name:     "myProject",
@@ -103,6 +123,37 @@ func TestPrepareNetworkLabels(t *testing.T) {
 	}))
 }
 
+func TestApplyScaleDelta(t *testing.T) {
+	project := &composetypes.Project{
+		Services: composetypes.Services{
+			"worker": {Name: "worker", Scale: intPtr(1)},
+			"web":    {Name: "web"},
+			"db":     {Name: "db"},
+		},
+	}
+	observed := &ObservedState{
+		Containers: map[string][]ObservedContainer{
+			"worker": {
+				{ID: "w1", State: container.StateRunning},
+				{ID: "w2", State: container.StateCreated},
+				{ID: "w3", State: container.StateExited},
+			},
+			"web": {
+				{ID: "web1", State: container.StateRunning},
+			},
+		},
+	}
+
+	hook := logrustest.NewGlobal()
+	applyScaleDelta(project, observed, map[string]int{"worker": 3, "web": -2, "db": 1, "unknown": 1})
+
+	assert.Equal(t, *project.Services["worker"].Scale, 5)
+	assert.Equal(t, *project.Services["web"].Scale, 0)
+	assert.Equal(t, *project.Services["db"].Scale, 1)
+	assert.Equal(t, len(hook.AllEntries()), 1)
+	assert.Equal(t, hook.LastEntry().Message, `Service "web" can't be scaled down by 2 as it only has 1 replicas, scaling it to 0`)
+}
+
 func TestBuildContainerMountOptions(t *testing.T) {
 	project := composetypes.Project{
 		Name: "myProject",
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+const (
+	daemonEvent        = "Docker engine"
+	daemonPollInterval = time.Second
+)
+
+// isDaemonUnavailable reports whether err is caused by the connection to the
+// Docker engine being lost, as when it restarts
+func isDaemonUnavailable(err error) bool {
+	return client.IsErrConnectionFailed(err) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// resumeAfterDaemonRestart runs converge, and runs it again when it failed as
+// the Docker engine went away, once the engine is back within wait. converge
+// observes the project containers on each run, so it resumes from the ones
+// left behind, completing interrupted recreates.
+func (s *composeService) resumeAfterDaemonRestart(ctx context.Context, wait time.Duration, converge func(context.Context) error) error {
+	for {
+		err := converge(ctx)
+		if err == nil || wait <= 0 || ctx.Err() != nil || !isDaemonUnavailable(err) {
+			return err
+		}
+		logrus.Warnf("lost connection to the Docker engine: %v", err)
+		if waitErr := s.waitForDaemon(ctx, wait); waitErr != nil {
+			return fmt.Errorf("%w: %w", waitErr, err)
+		}
+	}
+}
+
+// waitForDaemon polls the Docker engine until it responds, for up to wait
+func (s *composeService) waitForDaemon(ctx context.Context, wait time.Duration) error {
+	s.events(ctx).On(newEvent(daemonEvent, api.Working, api.StatusWaiting, "Waiting for Docker engine..."))
+	deadline := s.clock.Now().Add(wait)
+	for {
+		_, err := s.apiClient().Ping(ctx, client.PingOptions{})
+		if err == nil {
+			s.events(ctx).On(newEvent(daemonEvent, api.Done, "Recovered", "resuming convergence"))
+			return nil
+		}
+		logrus.Debugf("Docker engine is not back yet: %v", err)
+		if !s.clock.Now().Before(deadline) {
+			s.events(ctx).On(newEvent(daemonEvent, api.Error, api.StatusError, fmt.Sprintf("did not come back within %s", wait)))
+			return fmt.Errorf("the Docker engine did not come back within %s", wait)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.clock.After(min(daemonPollInterval, deadline.Sub(s.clock.Now()))):
+		}
+	}
+}
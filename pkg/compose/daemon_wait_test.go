@@ -0,0 +1,141 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func connectionRefused() error {
+	return &net.OpError{Op: "dial", Net: "unix", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+}
+
+// flakyDaemon converges once the engine it depends on is back
+type flakyDaemon struct {
+	runs int
+	down bool
+}
+
+func (d *flakyDaemon) converge(context.Context) error {
+	d.runs++
+	if d.down {
+		return connectionRefused()
+	}
+	return nil
+}
+
+func TestResumeAfterDaemonRestart(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	events := &capturingEvents{}
+	svc.eventBus = events
+
+	// the engine restarts while converging, and is back after 3 pings
+	daemon := &flakyDaemon{down: true}
+	pings := 0
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Times(4).
+		DoAndReturn(func(context.Context, client.PingOptions) (client.PingResult, error) {
+			pings++
+			if pings <= 3 {
+				return client.PingResult{}, connectionRefused()
+			}
+			daemon.down = false
+			return client.PingResult{}, nil
+		})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.resumeAfterDaemonRestart(t.Context(), 30*time.Second, daemon.converge)
+	}()
+	for range 3 {
+		assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+		clock.Advance(time.Second)
+	}
+	assert.NilError(t, <-done)
+	assert.Equal(t, daemon.runs, 2)
+
+	var got []string
+	for _, e := range events.resources {
+		got = append(got, e.ID+" "+e.Text+" "+e.Details)
+	}
+	assert.DeepEqual(t, got, []string{
+		"Docker engine Waiting Waiting for Docker engine...",
+		"Docker engine Recovered resuming convergence",
+	})
+}
+
+func TestResumeAfterDaemonRestartTimeout(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	svc.eventBus = &capturingEvents{}
+
+	daemon := &flakyDaemon{down: true}
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{}, connectionRefused()).Times(4)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.resumeAfterDaemonRestart(t.Context(), 3*time.Second, daemon.converge)
+	}()
+	for range 3 {
+		assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+		clock.Advance(time.Second)
+	}
+	err := <-done
+	assert.ErrorContains(t, err, "the Docker engine did not come back within 3s")
+	assert.Assert(t, errors.Is(err, syscall.ECONNREFUSED))
+	assert.Equal(t, daemon.runs, 1)
+}
+
+func TestResumeAfterDaemonRestartOtherErrors(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	runs := 0
+	err := svc.resumeAfterDaemonRestart(t.Context(), 30*time.Second, func(context.Context) error {
+		runs++
+		return errors.New("no such image")
+	})
+	assert.ErrorContains(t, err, "no such image")
+	assert.Equal(t, runs, 1)
+
+	// waiting for the engine is disabled
+	daemon := &flakyDaemon{down: true}
+	err = svc.resumeAfterDaemonRestart(t.Context(), 0, daemon.converge)
+	assert.Assert(t, errors.Is(err, syscall.ECONNREFUSED))
+	assert.Equal(t, daemon.runs, 1)
+}
+
+func TestIsDaemonUnavailable(t *testing.T) {
+	assert.Assert(t, isDaemonUnavailable(connectionRefused()))
+	assert.Assert(t, isDaemonUnavailable(&url.Error{Op: "Post", URL: "http://docker/containers/create", Err: io.EOF}))
+	assert.Assert(t, !isDaemonUnavailable(errors.New("conflict")))
+	assert.Assert(t, !isDaemonUnavailable(context.Canceled))
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/mattn/go-shellwords"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// encryptedValue returns the ciphertext of an environment value encrypted
+// at rest, set as ENC[ciphertext]
+func encryptedValue(value string) (string, bool) {
+	ciphertext, ok := strings.CutPrefix(value, "ENC[")
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(ciphertext, "]")
+}
+
+// decryptionCache keeps the values decrypted by ciphertext, so each is only
+// decrypted once per invocation
+type decryptionCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (c *decryptionCache) get(ciphertext string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[ciphertext]
+	return value, ok
+}
+
+func (c *decryptionCache) set(ciphertext, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.values == nil {
+		c.values = map[string]string{}
+	}
+	c.values[ciphertext] = value
+}
+
+// decryptEnvironment replaces the environment values of the project services
+// encrypted as ENC[ciphertext] with their plain value, as written to stdout by
+// the COMPOSE_DECRYPT_CMD command run with the ciphertext on stdin. With
+// redact, the values are still decrypted, so they are checked to be
+// decryptable, but replaced with a placeholder.
+func (s *composeService) decryptEnvironment(ctx context.Context, project *types.Project, redact bool) (*types.Project, error) {
+	var command []string
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		for _, key := range sortedKeys(service.Environment) {
+			value := service.Environment[key]
+			if value == nil {
+				continue
+			}
+			ciphertext, ok := encryptedValue(*value)
+			if !ok {
+				continue
+			}
+			if command == nil {
+				var err error
+				command, err = decryptCommand(project)
+				if err != nil {
+					return nil, fmt.Errorf("service %q: environment variable %s is encrypted: %w", name, key, err)
+				}
+			}
+			plain, err := s.decrypt(ctx, command, ciphertext)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: decrypting environment variable %s: %w", name, key, err)
+			}
+			if redact {
+				plain = redacted
+			}
+			service.Environment[key] = &plain
+		}
+		project.Services[name] = service
+	}
+	return project, nil
+}
+
+// decryptCommand returns the command set as COMPOSE_DECRYPT_CMD to decrypt
+// the environment values with
+func decryptCommand(project *types.Project) ([]string, error) {
+	command, err := shellwords.Parse(project.Environment[api.ComposeDecryptCmd])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", api.ComposeDecryptCmd, err)
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("set %s to the command decrypting it", api.ComposeDecryptCmd)
+	}
+	return command, nil
+}
+
+// decrypt runs command with ciphertext on stdin, and returns its output
+// without the trailing newline
+func (s *composeService) decrypt(ctx context.Context, command []string, ciphertext string) (string, error) {
+	if value, ok := s.decrypted.get(ciphertext); ok {
+		return value, nil
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(ciphertext)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if out := strings.TrimSpace(stderr.String()); out != "" {
+			return "", fmt.Errorf("%s: %w: %s", api.ComposeDecryptCmd, err, out)
+		}
+		return "", fmt.Errorf("%s: %w", api.ComposeDecryptCmd, err)
+	}
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	s.decrypted.set(ciphertext, value)
+	return value, nil
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// rot13 is the reversible cipher of the fake decryption command
+func rot13(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, s)
+}
+
+// fakeDecryptCommand sets COMPOSE_DECRYPT_CMD to a command deciphering rot13,
+// and returns the file each of its runs is logged to
+func fakeDecryptCommand(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake decryption command requires a POSIX shell")
+	}
+	runs := filepath.Join(t.TempDir(), "runs")
+	t.Setenv(api.ComposeDecryptCmd, `sh -c "echo run >> `+runs+`; tr A-Za-z N-ZA-Mn-za-m"`)
+	return runs
+}
+
+func writeEncryptedProject(t *testing.T) string {
+	t.Helper()
+	composeFile := filepath.Join(t.TempDir(), "compose.yaml")
+	assert.NilError(t, os.WriteFile(composeFile, []byte(`
+name: encrypted
+services:
+  web:
+    image: nginx
+    environment:
+      DB_USER: app
+      DB_PASS: ENC[`+rot13("s3cret")+`]
+  worker:
+    image: worker
+    environment:
+      DB_PASS: ENC[`+rot13("s3cret")+`]
+`), 0o644))
+	return composeFile
+}
+
+func TestLoadProject_DecryptEnvironment(t *testing.T) {
+	runs := fakeDecryptCommand(t)
+	composeFile := writeEncryptedProject(t)
+	service, err := NewComposeService(nil)
+	assert.NilError(t, err)
+
+	project, err := service.LoadProject(t.Context(), api.ProjectLoadOptions{ConfigPaths: []string{composeFile}})
+	assert.NilError(t, err)
+	app, secret := "app", "s3cret"
+	assert.DeepEqual(t, project.Services["web"].Environment, types.MappingWithEquals{
+		"DB_USER": &app,
+		"DB_PASS": &secret,
+	})
+	assert.Equal(t, *project.Services["worker"].Environment["DB_PASS"], "s3cret")
+
+	project, err = service.LoadProject(t.Context(), api.ProjectLoadOptions{ConfigPaths: []string{composeFile}, RedactDecrypted: true})
+	assert.NilError(t, err)
+	assert.Equal(t, *project.Services["web"].Environment["DB_PASS"], redacted)
+	assert.Equal(t, *project.Services["web"].Environment["DB_USER"], "app")
+
+	// the value is decrypted once for the invocation
+	logged, err := os.ReadFile(runs)
+	assert.NilError(t, err)
+	assert.Equal(t, string(logged), "run\n")
+}
+
+func TestLoadProject_DecryptEnvironmentErrors(t *testing.T) {
+	composeFile := writeEncryptedProject(t)
+	tests := []struct {
+		name    string
+		command string
+		err     string
+	}{
+		{
+			name: "no command",
+			err:  `service "web": environment variable DB_PASS is encrypted: set COMPOSE_DECRYPT_CMD to the command decrypting it`,
+		},
+		{
+			name:    "missing command",
+			command: "compose-decrypt-missing",
+			err:     `service "web": decrypting environment variable DB_PASS: COMPOSE_DECRYPT_CMD: exec: "compose-decrypt-missing": executable file not found in $PATH`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(api.ComposeDecryptCmd, tt.command)
+			service, err := NewComposeService(nil)
+			assert.NilError(t, err)
+
+			_, err = service.LoadProject(t.Context(), api.ProjectLoadOptions{ConfigPaths: []string{composeFile}})
+			assert.Error(t, err, tt.err)
+		})
+	}
+}
@@ -22,8 +22,10 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/jonboulle/clockwork"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/docker/compose/v5/pkg/api"
@@ -129,6 +131,20 @@ func WithRootNodesAndDown(nodes []string) func(*graphTraversal) {
 	}
 }
 
+// WithTimings calls fn with the time each service was visited at, and the
+// time its visit completed, successfully or not, as told by clock
+func WithTimings(clock clockwork.Clock, fn func(service string, start, finish time.Time)) func(*graphTraversal) {
+	return func(t *graphTraversal) {
+		visitorFn := t.visitorFn
+		t.visitorFn = func(ctx context.Context, service string) error {
+			start := clock.Now()
+			err := visitorFn(ctx, service)
+			fn(service, start, clock.Now())
+			return err
+		}
+	}
+}
+
 func (t *graphTraversal) visit(ctx context.Context, g *Graph) error {
 	expect := len(g.Vertices)
 	if expect == 0 {
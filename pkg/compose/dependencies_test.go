@@ -22,8 +22,10 @@ import (
 	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/jonboulle/clockwork"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 
@@ -426,3 +428,17 @@ func TestWith_RootNodesAndUp(t *testing.T) {
 		})
 	}
 }
+
+func TestWithTimingsUsesClock(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	project := &types.Project{Services: types.Services{"test": {Name: "test"}}}
+	var elapsed time.Duration
+	err := InDependencyOrder(t.Context(), project, func(ctx context.Context, s string) error {
+		clock.Advance(2 * time.Second)
+		return nil
+	}, WithTimings(clock, func(service string, start, finish time.Time) {
+		elapsed = finish.Sub(start)
+	}))
+	assert.NilError(t, err)
+	assert.Equal(t, elapsed, 2*time.Second)
+}
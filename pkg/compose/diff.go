@@ -0,0 +1,88 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// Diff is the read-only sibling of create: it runs the reconciler against the
+// observed state and reports the drifts the resulting plan is built from,
+// without pulling images, creating networks, or executing anything.
+func (s *composeService) Diff(ctx context.Context, project *types.Project) ([]api.Drift, error) {
+	project, observed, err := s.observeDiff(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	return diff(project, observed)
+}
+
+// observeDiff applies to project the mutations create applies before
+// reconciling, so hashes and recreate decisions match what `up` would
+// evaluate, and collects the observed state to compare it with.
+func (s *composeService) observeDiff(ctx context.Context, project *types.Project) (*types.Project, *ObservedState, error) {
+	if err := CheckContainerNames(project); err != nil {
+		return nil, nil, err
+	}
+
+	images, err := s.getLocalImagesDigests(ctx, project)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, service := range project.Services {
+		resolveImageVolumes(&service, images, project.Name)
+		project.Services[name] = service
+	}
+	prepareNetworks(project)
+	prepareVolumes(project)
+	externalVolumes, err := s.checkExternalVolumes(ctx, project)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err = s.useAPISocket(project)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	observed, err := s.collectObservedState(ctx, project)
+	if err != nil {
+		return nil, nil, err
+	}
+	observed.setResolvedVolumes(externalVolumes)
+	markAbsentDependencies(project, observedRunning(observed))
+	return project, observed, nil
+}
+
+// diff reports the drifts between project and observed, as evaluated by the
+// reconciler for a plain `up`. A diverged volume is reported, but its
+// recreation isn't assumed, as `up` would ask for confirmation first.
+func diff(project *types.Project, observed *ObservedState) ([]api.Drift, error) {
+	r := newReconciler(project, observed, ReconcileOptions{
+		Recreate:             api.RecreateDiverged,
+		RecreateDependencies: api.RecreateDiverged,
+	}, func(string, bool) (bool, error) {
+		return false, nil
+	})
+	if _, err := r.build(); err != nil {
+		return nil, err
+	}
+	return r.drifts, nil
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestDiff(t *testing.T) {
+	web := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	worker := types.ServiceConfig{Name: "worker", Image: "worker", Scale: intPtr(3)}
+	db := types.ServiceConfig{Name: "db", Image: "postgres", Scale: intPtr(1)}
+	data := types.VolumeConfig{Name: "myproject_data"}
+	project := &types.Project{
+		Name:     "myproject",
+		Services: types.Services{"web": web, "worker": worker, "db": db},
+		Networks: types.Networks{"default": {Name: "myproject_default"}},
+		Volumes:  types.Volumes{"data": data},
+	}
+	observedContainer := func(service types.ServiceConfig, number int, hash string) ObservedContainer {
+		return ObservedContainer{
+			ID:         service.Name + "-id",
+			Name:       getContainerName("myproject", service, number),
+			Number:     number,
+			State:      container.StateRunning,
+			ConfigHash: hash,
+			Labels:     map[string]string{api.ServiceLabel: service.Name, api.ContainerNumberLabel: "1"},
+		}
+	}
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers: map[string][]ObservedContainer{
+			"web":    {observedContainer(web, 1, "outdated")},
+			"worker": {observedContainer(worker, 1, mustServiceHash(t, worker))},
+			"db":     {observedContainer(db, 1, mustServiceHash(t, db))},
+		},
+		Networks: map[string]ObservedNetwork{},
+		Volumes: map[string]ObservedVolume{
+			"data": {Name: "myproject_data", ConfigHash: "outdated"},
+		},
+	}
+
+	drifts, err := diff(project, observed)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, drifts, []api.Drift{
+		{Kind: api.DriftNetwork, Detail: "network default not found"},
+		{Kind: api.DriftVolume, Detail: "volume data config changed"},
+		{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: config hash diverged"},
+		{Service: "worker", Kind: api.DriftScale, Detail: "1 container(s) running, 3 expected"},
+	})
+}
+
+func TestDiffUpToDate(t *testing.T) {
+	web := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	project := &types.Project{Name: "myproject", Services: types.Services{"web": web}}
+	observed := emptyObservedState("myproject")
+	observed.Containers["web"] = []ObservedContainer{{
+		ID:         "web-id",
+		Name:       "myproject-web-1",
+		Number:     1,
+		State:      container.StateRunning,
+		ConfigHash: mustServiceHash(t, web),
+		Labels:     map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+	}}
+
+	drifts, err := diff(project, observed)
+	assert.NilError(t, err)
+	assert.Check(t, len(drifts) == 0, drifts)
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+)
+
+// resourcesDivergence compares the resource settings a container without a
+// config hash label was created with to the expected service configuration,
+// and returns the settings diverging as the more specific reason to recreate
+// it, if any.
+func resourcesDivergence(expected types.ServiceConfig, actual ObservedResources) string {
+	var reasons []string
+	if names := ulimitsDivergence(toUlimits(expected.Ulimits), actual.Ulimits); len(names) > 0 {
+		reasons = append(reasons, "ulimits changed: "+strings.Join(names, ", "))
+	}
+	if keys := sysctlsDivergence(expected.Sysctls, actual.Sysctls); len(keys) > 0 {
+		reasons = append(reasons, "sysctls changed: "+strings.Join(keys, ", "))
+	}
+	var weight uint16
+	if expected.BlkioConfig != nil {
+		weight = expected.BlkioConfig.Weight
+	}
+	if weight != actual.BlkioWeight {
+		reasons = append(reasons, fmt.Sprintf("blkio weight changed: %s to %s", blkioWeight(actual.BlkioWeight), blkioWeight(weight)))
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// ulimitsDivergence returns the sorted names of the ulimits set differently,
+// or only on one side
+func ulimitsDivergence(expected, actual []*container.Ulimit) []string {
+	index := func(ulimits []*container.Ulimit) map[string]container.Ulimit {
+		m := map[string]container.Ulimit{}
+		for _, u := range ulimits {
+			if u != nil {
+				m[u.Name] = *u
+			}
+		}
+		return m
+	}
+	want, got := index(expected), index(actual)
+	var names []string
+	for name, u := range want {
+		if g, ok := got[name]; !ok || g != u {
+			names = append(names, name)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// sysctlsDivergence returns the sorted keys of the sysctls set differently,
+// or only on one side
+func sysctlsDivergence(expected types.Mapping, actual map[string]string) []string {
+	var keys []string
+	for k, v := range expected {
+		if a, ok := actual[k]; !ok || a != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range actual {
+		if _, ok := expected[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// blkioWeight renders a blkio weight, 0 standing for the engine default
+func blkioWeight(w uint16) string {
+	if w == 0 {
+		return "default"
+	}
+	return fmt.Sprint(w)
+}
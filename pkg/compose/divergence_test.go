@@ -0,0 +1,218 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestResourcesDivergence_Ulimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]*types.UlimitsConfig
+		actual   []*container.Ulimit
+		want     string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:     "single value",
+			expected: map[string]*types.UlimitsConfig{"nproc": {Single: 65535}},
+			actual:   []*container.Ulimit{{Name: "nproc", Soft: 65535, Hard: 65535}},
+		},
+		{
+			name: "same in another order",
+			expected: map[string]*types.UlimitsConfig{
+				"nofile": {Soft: 1024, Hard: 2048},
+				"nproc":  {Single: 100},
+			},
+			actual: []*container.Ulimit{{Name: "nproc", Soft: 100, Hard: 100}, {Name: "nofile", Soft: 1024, Hard: 2048}},
+		},
+		{
+			name:     "changed",
+			expected: map[string]*types.UlimitsConfig{"nofile": {Soft: 1024, Hard: 4096}},
+			actual:   []*container.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+			want:     "ulimits changed: nofile",
+		},
+		{
+			name:     "added and removed",
+			expected: map[string]*types.UlimitsConfig{"nproc": {Single: 100}},
+			actual:   []*container.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+			want:     "ulimits changed: nofile, nproc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resourcesDivergence(types.ServiceConfig{Ulimits: tt.expected}, ObservedResources{Ulimits: tt.actual})
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestResourcesDivergence_Sysctls(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected types.Mapping
+		actual   map[string]string
+		want     string
+	}{
+		{
+			name:     "none",
+			expected: types.Mapping{},
+		},
+		{
+			name:     "same",
+			expected: types.Mapping{"net.core.somaxconn": "1024", "net.ipv4.tcp_syncookies": "0"},
+			actual:   map[string]string{"net.ipv4.tcp_syncookies": "0", "net.core.somaxconn": "1024"},
+		},
+		{
+			name:     "changed",
+			expected: types.Mapping{"net.core.somaxconn": "2048"},
+			actual:   map[string]string{"net.core.somaxconn": "1024"},
+			want:     "sysctls changed: net.core.somaxconn",
+		},
+		{
+			name:     "added and removed",
+			expected: types.Mapping{"net.ipv4.tcp_syncookies": "0"},
+			actual:   map[string]string{"net.core.somaxconn": "1024"},
+			want:     "sysctls changed: net.core.somaxconn, net.ipv4.tcp_syncookies",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resourcesDivergence(types.ServiceConfig{Sysctls: tt.expected}, ObservedResources{Sysctls: tt.actual})
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestResourcesDivergence_BlkioWeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected *types.BlkioConfig
+		actual   uint16
+		want     string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:     "default weight elided",
+			expected: &types.BlkioConfig{},
+		},
+		{
+			name:     "same",
+			expected: &types.BlkioConfig{Weight: 300},
+			actual:   300,
+		},
+		{
+			name:     "changed",
+			expected: &types.BlkioConfig{Weight: 300},
+			actual:   500,
+			want:     "blkio weight changed: 500 to 300",
+		},
+		{
+			name:   "unset",
+			actual: 500,
+			want:   "blkio weight changed: 500 to default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resourcesDivergence(types.ServiceConfig{BlkioConfig: tt.expected}, ObservedResources{BlkioWeight: tt.actual})
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestReconcileContainers_WithoutConfigHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources *ObservedResources
+		want      string
+	}{
+		{
+			name: "not inspected",
+			want: recreateReasonConfigChanged,
+		},
+		{
+			// other settings may have changed, which can't be inspected
+			name:      "resources unchanged",
+			resources: &ObservedResources{Sysctls: map[string]string{"net.core.somaxconn": "1024"}},
+			want:      recreateReasonConfigChanged,
+		},
+		{
+			name:      "resources changed",
+			resources: &ObservedResources{Sysctls: map[string]string{"net.core.somaxconn": "512"}, BlkioWeight: 500},
+			want:      "sysctls changed: net.core.somaxconn; blkio weight changed: 500 to default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{
+				Name: "myproject",
+				Services: types.Services{
+					"web": {Name: "web", Scale: intPtr(1), Sysctls: types.Mapping{"net.core.somaxconn": "1024"}},
+				},
+			}
+			observed := emptyObservedState("myproject")
+			observed.Containers["web"] = []ObservedContainer{{
+				ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning,
+				Labels:    map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+				Resources: tt.resources,
+			}}
+
+			plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, plan.Drifts, []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: " + tt.want}})
+		})
+	}
+}
+
+func TestInspectContainersWithoutHash(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	project := &types.Project{Name: "myproject", Services: types.Services{"web": {Name: "web"}}}
+	state := emptyObservedState("myproject")
+	state.Containers["web"] = []ObservedContainer{{ID: "c1"}, {ID: "c2", ConfigHash: "hash"}}
+
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "c1", gomock.Any()).Return(client.ContainerInspectResult{
+		Container: container.InspectResponse{HostConfig: &container.HostConfig{
+			Resources: container.Resources{
+				Ulimits:     []*container.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+				BlkioWeight: 500,
+			},
+			Sysctls: map[string]string{"net.core.somaxconn": "1024"},
+		}},
+	}, nil)
+
+	assert.NilError(t, svc.inspectContainersWithoutHash(t.Context(), project, state))
+	assert.DeepEqual(t, state.Containers["web"][0].Resources, &ObservedResources{
+		Ulimits:     []*container.Ulimit{{Name: "nofile", Soft: 1024, Hard: 2048}},
+		Sysctls:     map[string]string{"net.core.somaxconn": "1024"},
+		BlkioWeight: 500,
+	})
+	assert.Assert(t, state.Containers["web"][1].Resources == nil)
+}
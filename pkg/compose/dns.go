@@ -0,0 +1,228 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func (s *composeService) DNS(ctx context.Context, project *types.Project, options api.DNSOptions) ([]api.NetworkDNS, error) {
+	services := options.Services
+	if len(services) > 0 && options.Probe != "" && !slices.Contains(services, options.Probe) {
+		services = append(slices.Clone(services), options.Probe)
+	}
+	containers, err := s.getContainers(ctx, project.Name, oneOffExclude, false, services...)
+	if err != nil {
+		return nil, err
+	}
+	inspected := make([]container.InspectResponse, 0, len(containers))
+	for _, ctr := range containers {
+		res, err := s.apiClient().ContainerInspect(ctx, ctr.ID, client.ContainerInspectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		inspected = append(inspected, res.Container)
+	}
+
+	networks := map[string]network.Inspect{}
+	for key, nw := range project.Networks {
+		res, err := s.apiClient().NetworkInspect(ctx, nw.Name, client.NetworkInspectOptions{})
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		networks[key] = res.Network
+	}
+
+	reports := dnsReports(project, inspected, networks, options.Services)
+	if options.Probe == "" {
+		return reports, nil
+	}
+	probe := slices.IndexFunc(inspected, func(ctr container.InspectResponse) bool {
+		return ctr.Config != nil && ctr.Config.Labels[api.ServiceLabel] == options.Probe
+	})
+	if probe < 0 {
+		return nil, fmt.Errorf("no running container for probe service %q", options.Probe)
+	}
+	if err := s.probeAliases(ctx, inspected[probe].ID, reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// dnsReports compares, for each project network, the aliases service
+// containers are expected to have with the ones the engine reports, networks
+// being keyed by their name in the Compose file. Expected aliases are the ones
+// createEndpointSettings sets on creation.
+func dnsReports(project *types.Project, containers []container.InspectResponse, networks map[string]network.Inspect, services []string) []api.NetworkDNS {
+	containers = slices.Clone(containers)
+	slices.SortFunc(containers, func(a, b container.InspectResponse) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	var reports []api.NetworkDNS
+	for _, key := range slices.Sorted(maps.Keys(project.Networks)) {
+		nw := project.Networks[key]
+		report := api.NetworkDNS{Network: key, Name: nw.Name}
+		for _, ctr := range containers {
+			if ctr.Config == nil {
+				continue
+			}
+			service, ok := project.Services[ctr.Config.Labels[api.ServiceLabel]]
+			if !ok || (len(services) > 0 && !slices.Contains(services, service.Name)) {
+				continue
+			}
+			config, ok := service.Networks[key]
+			if !ok {
+				continue
+			}
+			number, _ := strconv.Atoi(ctr.Config.Labels[api.ContainerNumberLabel])
+			_, attached := networks[key].Containers[ctr.ID]
+			expected := getAliases(project, service, number, config, true)
+			actual := endpointAliases(ctr, nw.Name)
+			var missing []string
+			for _, alias := range expected {
+				if !slices.Contains(actual, alias) {
+					missing = append(missing, alias)
+				}
+			}
+			report.Containers = append(report.Containers, api.ContainerDNS{
+				Container: strings.TrimPrefix(ctr.Name, "/"),
+				Service:   service.Name,
+				Attached:  attached,
+				Expected:  expected,
+				Actual:    actual,
+				Missing:   missing,
+			})
+		}
+		if len(report.Containers) > 0 {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// endpointAliases returns the DNS names of a container on a network, as
+// reported by the engine.
+func endpointAliases(ctr container.InspectResponse, networkName string) []string {
+	if ctr.NetworkSettings == nil {
+		return []string{}
+	}
+	endpoint, ok := ctr.NetworkSettings.Networks[networkName]
+	if !ok || endpoint == nil {
+		return []string{}
+	}
+	// DNSNames is only reported by API 1.45+, which also includes the
+	// container name and short ID
+	aliases := endpoint.DNSNames
+	if len(aliases) == 0 {
+		aliases = append([]string{strings.TrimPrefix(ctr.Name, "/")}, endpoint.Aliases...)
+	}
+	aliases = slices.Clone(aliases)
+	slices.Sort(aliases)
+	return slices.Compact(aliases)
+}
+
+// probeAliases resolves the expected aliases of all containers from the probe
+// container, each alias once.
+func (s *composeService) probeAliases(ctx context.Context, probeID string, reports []api.NetworkDNS) error {
+	resolved := map[string]api.DNSProbe{}
+	for i := range reports {
+		for j := range reports[i].Containers {
+			ctr := &reports[i].Containers[j]
+			for _, alias := range ctr.Expected {
+				probe, ok := resolved[alias]
+				if !ok {
+					var err error
+					if probe, err = s.resolveAlias(ctx, probeID, alias); err != nil {
+						return err
+					}
+					resolved[alias] = probe
+				}
+				ctr.Probes = append(ctr.Probes, probe)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAlias runs `getent hosts` in a container to resolve alias.
+func (s *composeService) resolveAlias(ctx context.Context, containerID, alias string) (api.DNSProbe, error) {
+	probe := api.DNSProbe{Alias: alias}
+	exec, err := s.apiClient().ExecCreate(ctx, containerID, client.ExecCreateOptions{
+		Cmd:          []string{"getent", "hosts", alias},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return probe, err
+	}
+	attach, err := s.apiClient().ExecAttach(ctx, exec.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return probe, err
+	}
+	defer attach.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attach.Reader); err != nil {
+		return probe, err
+	}
+	inspected, err := s.apiClient().ExecInspect(ctx, exec.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return probe, err
+	}
+	switch inspected.ExitCode {
+	case 0:
+		probe.Resolved = true
+		probe.Addresses = parseGetentHosts(stdout.String())
+	case 2:
+		// getent exit status for a key not found
+	default:
+		return probe, fmt.Errorf("failed to resolve %s with getent (exit status %d): %s", alias, inspected.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return probe, nil
+}
+
+// parseGetentHosts returns the addresses listed by `getent hosts`, one entry
+// per line starting with the address followed by names.
+func parseGetentHosts(output string) []string {
+	var addresses []string
+	for line := range strings.Lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !slices.Contains(addresses, fields[0]) {
+			addresses = append(addresses, fields[0])
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func dnsFixtureContainer(id, name, service, number string, endpoints map[string]*network.EndpointSettings) container.InspectResponse {
+	return container.InspectResponse{
+		ID:   id,
+		Name: "/" + name,
+		Config: &container.Config{Labels: map[string]string{
+			api.ServiceLabel:         service,
+			api.ContainerNumberLabel: number,
+		}},
+		NetworkSettings: &container.NetworkSettings{Networks: endpoints},
+	}
+}
+
+func TestDNSReports(t *testing.T) {
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {Name: "web", Networks: map[string]*types.ServiceNetworkConfig{
+				"front": {Aliases: []string{"www"}},
+				"back":  nil,
+			}},
+			"db": {Name: "db", Networks: map[string]*types.ServiceNetworkConfig{"back": nil}},
+		},
+		Networks: types.Networks{
+			"front": {Name: "myproject_front"},
+			"back":  {Name: "myproject_back"},
+		},
+	}
+	containers := []container.InspectResponse{
+		dnsFixtureContainer("web1", "myproject-web-1", "web", "1", map[string]*network.EndpointSettings{
+			"myproject_front": {DNSNames: []string{"myproject-web-1", "web", "www", "web1"}},
+			// created before the alias was declared
+			"myproject_back": {DNSNames: []string{"myproject-web-1", "web1"}},
+		}),
+		// an engine older than API 1.45 only reports user aliases
+		dnsFixtureContainer("db1", "myproject-db-1", "db", "1", map[string]*network.EndpointSettings{
+			"myproject_back": {Aliases: []string{"db"}},
+		}),
+	}
+	networks := map[string]network.Inspect{
+		"front": {Containers: map[string]network.EndpointResource{"web1": {Name: "myproject-web-1"}}},
+		"back":  {Containers: map[string]network.EndpointResource{"web1": {Name: "myproject-web-1"}}},
+	}
+
+	assert.DeepEqual(t, dnsReports(project, containers, networks, nil), []api.NetworkDNS{
+		{
+			Network: "back",
+			Name:    "myproject_back",
+			Containers: []api.ContainerDNS{
+				{
+					Container: "myproject-db-1",
+					Service:   "db",
+					Expected:  []string{"myproject-db-1", "db"},
+					Actual:    []string{"db", "myproject-db-1"},
+				},
+				{
+					Container: "myproject-web-1",
+					Service:   "web",
+					Attached:  true,
+					Expected:  []string{"myproject-web-1", "web"},
+					Actual:    []string{"myproject-web-1", "web1"},
+					Missing:   []string{"web"},
+				},
+			},
+		},
+		{
+			Network: "front",
+			Name:    "myproject_front",
+			Containers: []api.ContainerDNS{{
+				Container: "myproject-web-1",
+				Service:   "web",
+				Attached:  true,
+				Expected:  []string{"myproject-web-1", "web", "www"},
+				Actual:    []string{"myproject-web-1", "web", "web1", "www"},
+			}},
+		},
+	})
+
+	reports := dnsReports(project, containers, networks, []string{"db"})
+	assert.Equal(t, len(reports), 1)
+	assert.Equal(t, reports[0].Containers[0].Container, "myproject-db-1")
+	assert.Check(t, reports[0].Containers[0].Mismatch(), "db is not attached to the network")
+}
+
+func TestParseGetentHosts(t *testing.T) {
+	assert.DeepEqual(t, parseGetentHosts("172.18.0.3      web\n172.18.0.4      web\nfd00::3  web\n"), []string{"172.18.0.3", "172.18.0.4", "fd00::3"})
+	assert.Check(t, parseGetentHosts("") == nil)
+}
@@ -0,0 +1,672 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/dryrun"
+	"github.com/docker/compose/v5/pkg/readonly"
+)
+
+// dockerContextExtension is the service extension pinning a service to a
+// Docker context other than the current one:
+//
+//	services:
+//	  trainer:
+//	    x-docker-context: remote-gpu
+const dockerContextExtension = "x-docker-context"
+
+// serviceDockerContext returns the Docker context a service is pinned to, or
+// an empty string for the current context.
+func serviceDockerContext(service types.ServiceConfig, current string) (string, error) {
+	var name string
+	if _, err := service.Extensions.Get(dockerContextExtension, &name); err != nil {
+		return "", fmt.Errorf("service %q: invalid %s: %w", service.Name, dockerContextExtension, err)
+	}
+	if name == current {
+		return "", nil
+	}
+	return name, nil
+}
+
+// projectDockerContexts returns the Docker context of each service pinned to
+// one, after checking the services sharing a network run on the same context.
+func projectDockerContexts(project *types.Project, current string) (map[string]string, error) {
+	contexts := map[string]string{}
+	for _, service := range project.Services {
+		name, err := serviceDockerContext(service, current)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			contexts[service.Name] = name
+		}
+	}
+	if len(contexts) == 0 {
+		return nil, nil
+	}
+
+	describe := func(name string) string {
+		if name == "" {
+			return "the current context"
+		}
+		return fmt.Sprintf("context %q", name)
+	}
+	for _, service := range project.Services {
+		if ref, ok := strings.CutPrefix(service.NetworkMode, types.ServicePrefix); ok && contexts[ref] != contexts[service.Name] {
+			return nil, fmt.Errorf("service %q runs on %s but shares the network stack of service %q which runs on %s: network sharing across Docker contexts is not supported",
+				service.Name, describe(contexts[service.Name]), ref, describe(contexts[ref]))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(project.Networks)) {
+		var first string
+		for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+			service := project.Services[name]
+			if _, ok := service.Networks[key]; !ok {
+				continue
+			}
+			if first == "" {
+				first = service.Name
+				continue
+			}
+			if contexts[service.Name] != contexts[first] {
+				return nil, fmt.Errorf("network %q is used by service %q on %s and service %q on %s: a network can't span Docker contexts, declare dedicated networks for services pinned with %s",
+					key, first, describe(contexts[first]), service.Name, describe(contexts[service.Name]), dockerContextExtension)
+			}
+		}
+	}
+	return contexts, nil
+}
+
+// useDockerContexts prepares the API clients for the Docker contexts services
+// of project are pinned to, and from then on routes API calls through a
+// contextRouter. A project without pinned services leaves the client as is.
+func (s *composeService) useDockerContexts(project *types.Project) error {
+	if project == nil || !pinsDockerContext(project) {
+		return nil
+	}
+	contexts, err := projectDockerContexts(project, s.getContextInfo().CurrentContext())
+	if err != nil || len(contexts) == 0 {
+		return err
+	}
+
+	s.contextRouterMu.Lock()
+	defer s.contextRouterMu.Unlock()
+	router := s.contextRouter
+	if router == nil {
+		router = newContextRouter(s.dockerCli.Client())
+	}
+	for _, name := range slices.Sorted(maps.Values(contexts)) {
+		if _, ok := router.clients[name]; ok {
+			continue
+		}
+		c, err := s.newContextClient(name)
+		if err != nil {
+			return fmt.Errorf("docker context %q: %w", name, err)
+		}
+		router.addClient(name, c)
+	}
+	router.register(project, contexts)
+	s.contextRouter = router
+	return nil
+}
+
+func pinsDockerContext(project *types.Project) bool {
+	for _, service := range project.Services {
+		if _, ok := service.Extensions[dockerContextExtension]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newContextClient creates an API client for a Docker context from the CLI
+// context store.
+func (s *composeService) newContextClient(name string) (client.APIClient, error) {
+	if s.contextClientFactory != nil {
+		return s.contextClientFactory(name)
+	}
+	store := s.dockerCli.ContextStore()
+	metadata, err := store.GetMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	endpointMeta, err := docker.EndpointFromContext(metadata)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := docker.WithTLSData(store, name, endpointMeta)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := endpoint.ClientOpts()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(append(opts, client.WithUserAgent(command.UserAgent()))...)
+	if err != nil {
+		return nil, err
+	}
+	var apiClient client.APIClient = c
+	if s.dryRun {
+		apiClient, err = dryrun.NewDryRunClient(c, s.dockerCli)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.planOnly {
+		apiClient = readonly.NewClient(apiClient)
+	}
+	return apiClient, nil
+}
+
+// contextRouter is the API client used once services are pinned to other
+// Docker contexts. It embeds the current context's client, and routes calls
+// about a container, network, volume or image to the client of the context it
+// belongs to: containers by their labels on creation, then by ID, as are their
+// execs; networks, volumes and images by name, when only used by services of a
+// single pinned context. Images used on several contexts are pulled to each of
+// them. Lists and events are merged from all contexts.
+type contextRouter struct {
+	client.APIClient
+
+	mu      sync.RWMutex
+	clients map[string]client.APIClient
+	// services maps project name → service name → context
+	services map[string]map[string]string
+	// resources maps "network:<name>", "volume:<name>" and "image:<ref>" to
+	// the context they are exclusively used on
+	resources map[string]string
+	// images maps the images used on several contexts, one of them at least
+	// pinned, to these contexts, the current one being ""
+	images map[string][]string
+	// containers maps the IDs and names of containers found on other
+	// contexts, and the IDs of the execs created in them
+	containers map[string]client.APIClient
+}
+
+func newContextRouter(current client.APIClient) *contextRouter {
+	return &contextRouter{
+		APIClient:  current,
+		clients:    map[string]client.APIClient{},
+		services:   map[string]map[string]string{},
+		resources:  map[string]string{},
+		images:     map[string][]string{},
+		containers: map[string]client.APIClient{},
+	}
+}
+
+func (r *contextRouter) addClient(name string, c client.APIClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[name] = c
+}
+
+// register records the services of project pinned to a context, and the
+// networks, volumes and images only they use.
+func (r *contextRouter) register(project *types.Project, contexts map[string]string) {
+	usage := map[string]map[string]bool{}
+	use := func(resource, context string) {
+		if usage[resource] == nil {
+			usage[resource] = map[string]bool{}
+		}
+		usage[resource][context] = true
+	}
+	for _, service := range project.Services {
+		context := contexts[service.Name]
+		for key := range service.Networks {
+			if nw, ok := project.Networks[key]; ok {
+				use("network:"+nw.Name, context)
+			}
+		}
+		for _, v := range service.Volumes {
+			if vol, ok := project.Volumes[v.Source]; ok && v.Type == types.VolumeTypeVolume {
+				use("volume:"+vol.Name, context)
+			}
+		}
+		if service.Image != "" {
+			use("image:"+service.Image, context)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services[project.Name] = contexts
+	for resource, used := range usage {
+		contexts := slices.Sorted(maps.Keys(used))
+		switch {
+		case len(contexts) == 1 && contexts[0] != "":
+			r.resources[resource] = contexts[0]
+		case len(contexts) > 1 && strings.HasPrefix(resource, "image:"):
+			r.images[strings.TrimPrefix(resource, "image:")] = contexts
+		}
+	}
+}
+
+// forResource returns the client for a network, volume or image.
+func (r *contextRouter) forResource(kind, name string) client.APIClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if context, ok := r.resources[kind+":"+name]; ok {
+		return r.clients[context]
+	}
+	return r.APIClient
+}
+
+// forImage returns the clients for the contexts an image is used on
+func (r *contextRouter) forImage(ref string) []client.APIClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contexts, ok := r.images[ref]
+	if !ok {
+		if context, ok := r.resources["image:"+ref]; ok {
+			return []client.APIClient{r.clients[context]}
+		}
+		return []client.APIClient{r.APIClient}
+	}
+	var clients []client.APIClient
+	for _, context := range contexts {
+		if context == "" {
+			clients = append(clients, r.APIClient)
+		} else {
+			clients = append(clients, r.clients[context])
+		}
+	}
+	return clients
+}
+
+// forLabels returns the client for a container to be created with labels.
+func (r *contextRouter) forLabels(labels map[string]string) client.APIClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if context, ok := r.services[labels[api.ProjectLabel]][labels[api.ServiceLabel]]; ok {
+		return r.clients[context]
+	}
+	return r.APIClient
+}
+
+// forContainer returns the client for an existing container.
+func (r *contextRouter) forContainer(idOrName string) client.APIClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if c, ok := r.containers[strings.TrimPrefix(idOrName, "/")]; ok {
+		return c
+	}
+	return r.APIClient
+}
+
+func (r *contextRouter) recordContainer(c client.APIClient, id string, names ...string) {
+	if c == r.APIClient {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.containers[id] = c
+	for _, name := range names {
+		r.containers[strings.TrimPrefix(name, "/")] = c
+	}
+}
+
+// all returns the current context's client followed by the other contexts' ones.
+func (r *contextRouter) all() []client.APIClient {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clients := []client.APIClient{r.APIClient}
+	for _, name := range slices.Sorted(maps.Keys(r.clients)) {
+		clients = append(clients, r.clients[name])
+	}
+	return clients
+}
+
+func (r *contextRouter) Close() error {
+	var errs []error
+	for _, c := range r.all() {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (r *contextRouter) ContainerList(ctx context.Context, options client.ContainerListOptions) (client.ContainerListResult, error) {
+	var result client.ContainerListResult
+	for _, c := range r.all() {
+		res, err := c.ContainerList(ctx, options)
+		if err != nil {
+			return client.ContainerListResult{}, err
+		}
+		for _, ctr := range res.Items {
+			r.recordContainer(c, ctr.ID, ctr.Names...)
+		}
+		result.Items = append(result.Items, res.Items...)
+	}
+	return result, nil
+}
+
+func (r *contextRouter) ContainerCreate(ctx context.Context, options client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+	c := r.APIClient
+	if options.Config != nil {
+		c = r.forLabels(options.Config.Labels)
+	}
+	res, err := c.ContainerCreate(ctx, options)
+	if err == nil {
+		r.recordContainer(c, res.ID, options.Name)
+	}
+	return res, err
+}
+
+func (r *contextRouter) ContainerInspect(ctx context.Context, ctr string, options client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+	return r.forContainer(ctr).ContainerInspect(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerStart(ctx context.Context, ctr string, options client.ContainerStartOptions) (client.ContainerStartResult, error) {
+	return r.forContainer(ctr).ContainerStart(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerStop(ctx context.Context, ctr string, options client.ContainerStopOptions) (client.ContainerStopResult, error) {
+	return r.forContainer(ctr).ContainerStop(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerRestart(ctx context.Context, ctr string, options client.ContainerRestartOptions) (client.ContainerRestartResult, error) {
+	return r.forContainer(ctr).ContainerRestart(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerKill(ctx context.Context, ctr string, options client.ContainerKillOptions) (client.ContainerKillResult, error) {
+	return r.forContainer(ctr).ContainerKill(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerRemove(ctx context.Context, ctr string, options client.ContainerRemoveOptions) (client.ContainerRemoveResult, error) {
+	return r.forContainer(ctr).ContainerRemove(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerRename(ctx context.Context, ctr string, options client.ContainerRenameOptions) (client.ContainerRenameResult, error) {
+	c := r.forContainer(ctr)
+	res, err := c.ContainerRename(ctx, ctr, options)
+	if err == nil {
+		r.recordContainer(c, ctr, options.NewName)
+	}
+	return res, err
+}
+
+func (r *contextRouter) ContainerWait(ctx context.Context, ctr string, options client.ContainerWaitOptions) client.ContainerWaitResult {
+	return r.forContainer(ctr).ContainerWait(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerLogs(ctx context.Context, ctr string, options client.ContainerLogsOptions) (client.ContainerLogsResult, error) {
+	return r.forContainer(ctr).ContainerLogs(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerAttach(ctx context.Context, ctr string, options client.ContainerAttachOptions) (client.ContainerAttachResult, error) {
+	return r.forContainer(ctr).ContainerAttach(ctx, ctr, options)
+}
+
+func (r *contextRouter) CopyToContainer(ctx context.Context, ctr string, options client.CopyToContainerOptions) (client.CopyToContainerResult, error) {
+	return r.forContainer(ctr).CopyToContainer(ctx, ctr, options)
+}
+
+func (r *contextRouter) NetworkCreate(ctx context.Context, name string, options client.NetworkCreateOptions) (client.NetworkCreateResult, error) {
+	return r.forResource("network", name).NetworkCreate(ctx, name, options)
+}
+
+func (r *contextRouter) NetworkInspect(ctx context.Context, name string, options client.NetworkInspectOptions) (client.NetworkInspectResult, error) {
+	return r.forResource("network", name).NetworkInspect(ctx, name, options)
+}
+
+func (r *contextRouter) NetworkRemove(ctx context.Context, name string, options client.NetworkRemoveOptions) (client.NetworkRemoveResult, error) {
+	return r.forResource("network", name).NetworkRemove(ctx, name, options)
+}
+
+func (r *contextRouter) NetworkConnect(ctx context.Context, name string, options client.NetworkConnectOptions) (client.NetworkConnectResult, error) {
+	return r.forContainer(options.Container).NetworkConnect(ctx, name, options)
+}
+
+func (r *contextRouter) NetworkDisconnect(ctx context.Context, name string, options client.NetworkDisconnectOptions) (client.NetworkDisconnectResult, error) {
+	return r.forContainer(options.Container).NetworkDisconnect(ctx, name, options)
+}
+
+func (r *contextRouter) NetworkList(ctx context.Context, options client.NetworkListOptions) (client.NetworkListResult, error) {
+	var result client.NetworkListResult
+	for _, c := range r.all() {
+		res, err := c.NetworkList(ctx, options)
+		if err != nil {
+			return client.NetworkListResult{}, err
+		}
+		result.Items = append(result.Items, res.Items...)
+	}
+	return result, nil
+}
+
+func (r *contextRouter) VolumeCreate(ctx context.Context, options client.VolumeCreateOptions) (client.VolumeCreateResult, error) {
+	return r.forResource("volume", options.Name).VolumeCreate(ctx, options)
+}
+
+func (r *contextRouter) VolumeInspect(ctx context.Context, name string, options client.VolumeInspectOptions) (client.VolumeInspectResult, error) {
+	return r.forResource("volume", name).VolumeInspect(ctx, name, options)
+}
+
+func (r *contextRouter) VolumeRemove(ctx context.Context, name string, options client.VolumeRemoveOptions) (client.VolumeRemoveResult, error) {
+	return r.forResource("volume", name).VolumeRemove(ctx, name, options)
+}
+
+func (r *contextRouter) VolumeList(ctx context.Context, options client.VolumeListOptions) (client.VolumeListResult, error) {
+	var result client.VolumeListResult
+	for _, c := range r.all() {
+		res, err := c.VolumeList(ctx, options)
+		if err != nil {
+			return client.VolumeListResult{}, err
+		}
+		result.Items = append(result.Items, res.Items...)
+		result.Warnings = append(result.Warnings, res.Warnings...)
+	}
+	return result, nil
+}
+
+func (r *contextRouter) ContainerUpdate(ctx context.Context, ctr string, options client.ContainerUpdateOptions) (client.ContainerUpdateResult, error) {
+	return r.forContainer(ctr).ContainerUpdate(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerPause(ctx context.Context, ctr string, options client.ContainerPauseOptions) (client.ContainerPauseResult, error) {
+	return r.forContainer(ctr).ContainerPause(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerUnpause(ctx context.Context, ctr string, options client.ContainerUnpauseOptions) (client.ContainerUnpauseResult, error) {
+	return r.forContainer(ctr).ContainerUnpause(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerCommit(ctx context.Context, ctr string, options client.ContainerCommitOptions) (client.ContainerCommitResult, error) {
+	return r.forContainer(ctr).ContainerCommit(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerExport(ctx context.Context, ctr string, options client.ContainerExportOptions) (client.ContainerExportResult, error) {
+	return r.forContainer(ctr).ContainerExport(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerTop(ctx context.Context, ctr string, options client.ContainerTopOptions) (client.ContainerTopResult, error) {
+	return r.forContainer(ctr).ContainerTop(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerStats(ctx context.Context, ctr string, options client.ContainerStatsOptions) (client.ContainerStatsResult, error) {
+	return r.forContainer(ctr).ContainerStats(ctx, ctr, options)
+}
+
+func (r *contextRouter) ContainerStatPath(ctx context.Context, ctr string, options client.ContainerStatPathOptions) (client.ContainerStatPathResult, error) {
+	return r.forContainer(ctr).ContainerStatPath(ctx, ctr, options)
+}
+
+func (r *contextRouter) CopyFromContainer(ctx context.Context, ctr string, options client.CopyFromContainerOptions) (client.CopyFromContainerResult, error) {
+	return r.forContainer(ctr).CopyFromContainer(ctx, ctr, options)
+}
+
+func (r *contextRouter) ExecCreate(ctx context.Context, ctr string, options client.ExecCreateOptions) (client.ExecCreateResult, error) {
+	c := r.forContainer(ctr)
+	res, err := c.ExecCreate(ctx, ctr, options)
+	if err == nil {
+		r.recordContainer(c, res.ID)
+	}
+	return res, err
+}
+
+func (r *contextRouter) ExecInspect(ctx context.Context, execID string, options client.ExecInspectOptions) (client.ExecInspectResult, error) {
+	return r.forContainer(execID).ExecInspect(ctx, execID, options)
+}
+
+func (r *contextRouter) ExecResize(ctx context.Context, execID string, options client.ExecResizeOptions) (client.ExecResizeResult, error) {
+	return r.forContainer(execID).ExecResize(ctx, execID, options)
+}
+
+func (r *contextRouter) ExecStart(ctx context.Context, execID string, options client.ExecStartOptions) (client.ExecStartResult, error) {
+	return r.forContainer(execID).ExecStart(ctx, execID, options)
+}
+
+func (r *contextRouter) ExecAttach(ctx context.Context, execID string, options client.ExecAttachOptions) (client.ExecAttachResult, error) {
+	return r.forContainer(execID).ExecAttach(ctx, execID, options)
+}
+
+// Events merges the events of all the contexts. The stream ends with the
+// first error of one of them, or io.EOF once they all ended.
+func (r *contextRouter) Events(parent context.Context, options client.EventsListOptions) client.EventsResult {
+	ctx, cancel := context.WithCancel(parent)
+	messages := make(chan events.Message)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	for _, c := range r.all() {
+		res := c.Events(ctx, options)
+		wg.Go(func() {
+			for {
+				select {
+				case msg := <-res.Messages:
+					select {
+					case messages <- msg:
+					case <-ctx.Done():
+						return
+					}
+				case err, ok := <-res.Err:
+					if ok && err != nil && !errors.Is(err, io.EOF) {
+						select {
+						case errs <- err:
+						default:
+						}
+						cancel()
+					}
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		defer close(errs)
+		wg.Wait()
+		err := parent.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		select {
+		case errs <- err:
+		default:
+		}
+		cancel()
+	}()
+	return client.EventsResult{Messages: messages, Err: errs}
+}
+
+// ImagePull pulls the image to every context it is used on, the response
+// being the one of the last pull
+func (r *contextRouter) ImagePull(ctx context.Context, ref string, options client.ImagePullOptions) (client.ImagePullResponse, error) {
+	clients := r.forImage(ref)
+	for _, c := range clients[:len(clients)-1] {
+		res, err := c.ImagePull(ctx, ref, options)
+		if err != nil {
+			return nil, err
+		}
+		if err := res.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return clients[len(clients)-1].ImagePull(ctx, ref, options)
+}
+
+// ImageInspect inspects the image on every context it is used on, so it is
+// reported missing when one of them doesn't have it yet
+func (r *contextRouter) ImageInspect(ctx context.Context, ref string, options ...client.ImageInspectOption) (client.ImageInspectResult, error) {
+	var result client.ImageInspectResult
+	for i, c := range r.forImage(ref) {
+		res, err := c.ImageInspect(ctx, ref, options...)
+		if err != nil {
+			return client.ImageInspectResult{}, err
+		}
+		if i == 0 {
+			result = res
+		}
+	}
+	return result, nil
+}
+
+func (r *contextRouter) ImageRemove(ctx context.Context, ref string, options client.ImageRemoveOptions) (client.ImageRemoveResult, error) {
+	var result client.ImageRemoveResult
+	for _, c := range r.forImage(ref) {
+		res, err := c.ImageRemove(ctx, ref, options)
+		if err != nil {
+			return client.ImageRemoveResult{}, err
+		}
+		result.Items = append(result.Items, res.Items...)
+	}
+	return result, nil
+}
+
+// ImageBuild builds the image on the context it is used on, or the current
+// one when used on several: the build context can only be read once.
+func (r *contextRouter) ImageBuild(ctx context.Context, buildContext io.Reader, options client.ImageBuildOptions) (client.ImageBuildResult, error) {
+	c := r.APIClient
+	if len(options.Tags) > 0 {
+		if clients := r.forImage(options.Tags[0]); len(clients) == 1 {
+			c = clients[0]
+		}
+	}
+	return c.ImageBuild(ctx, buildContext, options)
+}
+
+func (r *contextRouter) ImageList(ctx context.Context, options client.ImageListOptions) (client.ImageListResult, error) {
+	var result client.ImageListResult
+	for _, c := range r.all() {
+		res, err := c.ImageList(ctx, options)
+		if err != nil {
+			return client.ImageListResult{}, err
+		}
+		result.Items = append(result.Items, res.Items...)
+	}
+	return result, nil
+}
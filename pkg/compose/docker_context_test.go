@@ -0,0 +1,244 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/mocks"
+)
+
+func dockerContextProject() *types.Project {
+	return &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {Name: "web", Image: "nginx", Networks: map[string]*types.ServiceNetworkConfig{"front": nil}},
+			"trainer": {
+				Name:       "trainer",
+				Image:      "trainer",
+				Networks:   map[string]*types.ServiceNetworkConfig{"gpu": nil},
+				Extensions: types.Extensions{dockerContextExtension: "remote-gpu"},
+			},
+		},
+		Networks: types.Networks{
+			"front": {Name: "myproject_front"},
+			"gpu":   {Name: "myproject_gpu"},
+		},
+	}
+}
+
+func TestProjectDockerContexts(t *testing.T) {
+	contexts, err := projectDockerContexts(dockerContextProject(), "default")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, contexts, map[string]string{"trainer": "remote-gpu"})
+
+	contexts, err = projectDockerContexts(dockerContextProject(), "remote-gpu")
+	assert.NilError(t, err)
+	assert.Equal(t, len(contexts), 0)
+}
+
+func TestProjectDockerContextsSharedNetwork(t *testing.T) {
+	project := dockerContextProject()
+	trainer := project.Services["trainer"]
+	trainer.Networks = map[string]*types.ServiceNetworkConfig{"front": nil}
+	project.Services["trainer"] = trainer
+
+	_, err := projectDockerContexts(project, "default")
+	assert.Error(t, err, `network "front" is used by service "trainer" on context "remote-gpu" and service "web" on the current context: a network can't span Docker contexts, declare dedicated networks for services pinned with x-docker-context`)
+}
+
+func TestProjectDockerContextsSharedNetworkStack(t *testing.T) {
+	project := dockerContextProject()
+	web := project.Services["web"]
+	web.Networks = nil
+	web.NetworkMode = "service:trainer"
+	project.Services["web"] = web
+
+	_, err := projectDockerContexts(project, "default")
+	assert.Error(t, err, `service "web" runs on the current context but shares the network stack of service "trainer" which runs on context "remote-gpu": network sharing across Docker contexts is not supported`)
+}
+
+func TestContextRouter(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	local, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().CurrentContext().Return("default").AnyTimes()
+	remote := mocks.NewMockAPIClient(mockCtrl)
+
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	s := tested.(*composeService)
+	s.contextClientFactory = func(name string) (client.APIClient, error) {
+		assert.Equal(t, name, "remote-gpu")
+		return remote, nil
+	}
+	project := dockerContextProject()
+	assert.NilError(t, s.useDockerContexts(project))
+
+	// containers are created on the context of their service
+	remote.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		Return(client.ContainerCreateResult{ID: "remote-1"}, nil)
+	local.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		Return(client.ContainerCreateResult{ID: "local-1"}, nil)
+	_, err = s.apiClient().ContainerCreate(t.Context(), client.ContainerCreateOptions{
+		Name:   "myproject-trainer-1",
+		Config: &container.Config{Labels: map[string]string{api.ProjectLabel: "myproject", api.ServiceLabel: "trainer"}},
+	})
+	assert.NilError(t, err)
+	_, err = s.apiClient().ContainerCreate(t.Context(), client.ContainerCreateOptions{
+		Name:   "myproject-web-1",
+		Config: &container.Config{Labels: map[string]string{api.ProjectLabel: "myproject", api.ServiceLabel: "web"}},
+	})
+	assert.NilError(t, err)
+
+	// then managed with the client they were created by
+	remote.EXPECT().ContainerStart(gomock.Any(), "remote-1", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+	local.EXPECT().ContainerStart(gomock.Any(), "local-1", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+	_, err = s.apiClient().ContainerStart(t.Context(), "remote-1", client.ContainerStartOptions{})
+	assert.NilError(t, err)
+	_, err = s.apiClient().ContainerStart(t.Context(), "local-1", client.ContainerStartOptions{})
+	assert.NilError(t, err)
+
+	// networks only used by pinned services are created on their context
+	remote.EXPECT().NetworkCreate(gomock.Any(), "myproject_gpu", gomock.Any()).Return(client.NetworkCreateResult{}, nil)
+	local.EXPECT().NetworkCreate(gomock.Any(), "myproject_front", gomock.Any()).Return(client.NetworkCreateResult{}, nil)
+	_, err = s.apiClient().NetworkCreate(t.Context(), "myproject_gpu", client.NetworkCreateOptions{})
+	assert.NilError(t, err)
+	_, err = s.apiClient().NetworkCreate(t.Context(), "myproject_front", client.NetworkCreateOptions{})
+	assert.NilError(t, err)
+
+	// as are execs in their containers
+	remote.EXPECT().ExecCreate(gomock.Any(), "remote-1", gomock.Any()).Return(client.ExecCreateResult{ID: "exec-1"}, nil)
+	remote.EXPECT().ExecStart(gomock.Any(), "exec-1", gomock.Any()).Return(client.ExecStartResult{}, nil)
+	exec, err := s.apiClient().ExecCreate(t.Context(), "remote-1", client.ExecCreateOptions{})
+	assert.NilError(t, err)
+	_, err = s.apiClient().ExecStart(t.Context(), exec.ID, client.ExecStartOptions{})
+	assert.NilError(t, err)
+}
+
+func TestContextRouterSharedImage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	local, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().CurrentContext().Return("default").AnyTimes()
+	remote := mocks.NewMockAPIClient(mockCtrl)
+
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	s := tested.(*composeService)
+	s.contextClientFactory = func(string) (client.APIClient, error) {
+		return remote, nil
+	}
+	project := dockerContextProject()
+	trainer := project.Services["trainer"]
+	trainer.Image = "nginx"
+	project.Services["trainer"] = trainer
+	assert.NilError(t, s.useDockerContexts(project))
+
+	// an image used on several contexts is pulled to each of them
+	local.EXPECT().ImagePull(gomock.Any(), "nginx", gomock.Any()).
+		Return(pullResponse{ReadCloser: io.NopCloser(strings.NewReader(""))}, nil)
+	remote.EXPECT().ImagePull(gomock.Any(), "nginx", gomock.Any()).
+		Return(pullResponse{ReadCloser: io.NopCloser(strings.NewReader(""))}, nil)
+	_, err = s.apiClient().ImagePull(t.Context(), "nginx", client.ImagePullOptions{})
+	assert.NilError(t, err)
+
+	// and missing as long as one of them doesn't have it
+	local.EXPECT().ImageInspect(gomock.Any(), "nginx").Return(client.ImageInspectResult{}, nil)
+	remote.EXPECT().ImageInspect(gomock.Any(), "nginx").
+		Return(client.ImageInspectResult{}, fmt.Errorf("No such image: nginx: %w", errdefs.ErrNotFound))
+	_, err = s.apiClient().ImageInspect(t.Context(), "nginx")
+	assert.Check(t, errdefs.IsNotFound(err))
+}
+
+func TestContextRouterEvents(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	local, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().CurrentContext().Return("default").AnyTimes()
+	remote := mocks.NewMockAPIClient(mockCtrl)
+
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	s := tested.(*composeService)
+	s.contextClientFactory = func(string) (client.APIClient, error) {
+		return remote, nil
+	}
+	assert.NilError(t, s.useDockerContexts(dockerContextProject()))
+
+	localErrs, remoteErrs := make(chan error, 1), make(chan error, 1)
+	stream := func(id string, errs chan error) client.EventsResult {
+		messages := make(chan events.Message, 1)
+		messages <- events.Message{Actor: events.Actor{ID: id}}
+		return client.EventsResult{Messages: messages, Err: errs}
+	}
+	local.EXPECT().Events(gomock.Any(), gomock.Any()).Return(stream("local-1", localErrs))
+	remote.EXPECT().Events(gomock.Any(), gomock.Any()).Return(stream("remote-1", remoteErrs))
+
+	res := s.apiClient().Events(t.Context(), client.EventsListOptions{})
+	var ids []string
+	for range 2 {
+		ids = append(ids, (<-res.Messages).Actor.ID)
+	}
+	assert.DeepEqual(t, slices.Sorted(slices.Values(ids)), []string{"local-1", "remote-1"})
+
+	// the merged stream ends once all the contexts' ones did
+	localErrs <- io.EOF
+	remoteErrs <- io.EOF
+	assert.Equal(t, <-res.Err, io.EOF)
+}
+
+func TestContextRouterListsAllContexts(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	local, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().CurrentContext().Return("default").AnyTimes()
+	remote := mocks.NewMockAPIClient(mockCtrl)
+
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	s := tested.(*composeService)
+	s.contextClientFactory = func(string) (client.APIClient, error) {
+		return remote, nil
+	}
+
+	web := testContainer("web", "local-1", false)
+	trainer := testContainer("trainer", "remote-1", false)
+	local.EXPECT().ContainerList(gomock.Any(), gomock.Any()).
+		Return(client.ContainerListResult{Items: []container.Summary{web}}, nil)
+	remote.EXPECT().ContainerList(gomock.Any(), gomock.Any()).
+		Return(client.ContainerListResult{Items: []container.Summary{trainer}}, nil)
+
+	// containers found on another context are then inspected there
+	local.EXPECT().ContainerInspect(gomock.Any(), "local-1", gomock.Any()).
+		Return(client.ContainerInspectResult{Container: container.InspectResponse{ID: "local-1"}}, nil)
+	remote.EXPECT().ContainerInspect(gomock.Any(), "remote-1", gomock.Any()).
+		Return(client.ContainerInspectResult{Container: container.InspectResponse{ID: "remote-1"}}, nil)
+
+	containers, err := s.Ps(t.Context(), strings.ToLower(testProject), api.PsOptions{Project: dockerContextProject(), All: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(containers), 2)
+}
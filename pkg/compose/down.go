@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -36,18 +37,30 @@ import (
 type downOp func() error
 
 func (s *composeService) Down(ctx context.Context, projectName string, options api.DownOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.down(ctx, strings.ToLower(projectName), options)
-	}, "down", s.events)
+	}, "down", s.events(ctx))
 }
 
 func (s *composeService) down(ctx context.Context, projectName string, options api.DownOptions) error { //nolint:gocyclo
 	resourceToRemove := false
 
+	switch options.VolumesScope {
+	case "", api.VolumesScopeAll, api.VolumesScopeNamed, api.VolumesScopeAnonymous:
+	default:
+		return fmt.Errorf("invalid volumes scope %q, must be one of %s, %s or %s", options.VolumesScope, api.VolumesScopeNamed, api.VolumesScopeAnonymous, api.VolumesScopeAll)
+	}
+	anonymousVolumes := options.Volumes && options.VolumesScope != api.VolumesScopeNamed
+	namedVolumes := options.Volumes && options.VolumesScope != api.VolumesScopeAnonymous
+
 	include := oneOffExclude
 	if options.RemoveOrphans {
 		include = oneOffInclude
 	}
+	if err := s.useDockerContexts(options.Project); err != nil {
+		return err
+	}
 	containers, err := s.getContainers(ctx, projectName, include, true)
 	if err != nil {
 		return err
@@ -84,7 +97,7 @@ func (s *composeService) down(ctx context.Context, projectName string, options a
 			return s.runPlugin(ctx, project, serv, "down")
 		}
 		serviceContainers := containers.filter(isService(service))
-		err := s.removeContainers(ctx, serviceContainers, &serv, options.Timeout, options.Volumes)
+		err := s.removeContainers(ctx, serviceContainers, &serv, options.Timeout, anonymousVolumes)
 		return err
 	}, WithRootNodesAndDown(options.Services))
 	if err != nil {
@@ -109,8 +122,12 @@ func (s *composeService) down(ctx context.Context, projectName string, options a
 		ops = append(ops, imgOps...)
 	}
 
-	if options.Volumes {
-		ops = append(ops, s.ensureVolumesDown(ctx, project)...)
+	if namedVolumes {
+		volumeOps, err := s.ensureVolumesDown(ctx, project, options.ForceVolumes)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, volumeOps...)
 	}
 
 	if !resourceToRemove && len(ops) == 0 {
@@ -141,19 +158,27 @@ func checkSelectedServices(options api.DownOptions, project *types.Project) ([]s
 	return services, nil
 }
 
-func (s *composeService) ensureVolumesDown(ctx context.Context, project *types.Project) []downOp {
+func (s *composeService) ensureVolumesDown(ctx context.Context, project *types.Project, force bool) ([]downOp, error) {
+	recorded, err := readProtectedVolumes(project.Name)
+	if err != nil {
+		return nil, err
+	}
 	var ops []downOp
 	for _, vol := range project.Volumes {
 		if vol.External {
 			continue
 		}
 		volumeName := vol.Name
+		protected := slices.Contains(recorded, volumeName)
 		ops = append(ops, func() error {
-			return s.removeVolume(ctx, volumeName)
+			if err := s.removeVolume(ctx, volumeName, protected, force); err != nil || !protected || !force {
+				return err
+			}
+			return forgetProtectedVolume(project.Name, volumeName)
 		})
 	}
 
-	return ops
+	return ops, nil
 }
 
 func (s *composeService) ensureImagesDown(ctx context.Context, project *types.Project, options api.DownOptions) ([]downOp, error) {
@@ -207,7 +232,7 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 	}
 
 	eventName := fmt.Sprintf("Network %s", name)
-	s.events.On(removingEvent(eventName))
+	s.events(ctx).On(removingEvent(eventName))
 
 	var found int
 	for _, net := range networks {
@@ -216,7 +241,7 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 		}
 		nwInspect, err := s.apiClient().NetworkInspect(ctx, net.ID, client.NetworkInspectOptions{})
 		if errdefs.IsNotFound(err) {
-			s.events.On(newEvent(eventName, api.Warning, "No resource found to remove"))
+			s.events(ctx).On(newEvent(eventName, api.Warning, "No resource found to remove"))
 			return nil
 		}
 		if err != nil {
@@ -224,19 +249,23 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 		}
 		nw := nwInspect.Network
 		if len(nw.Containers) > 0 {
-			s.events.On(newEvent(eventName, api.Warning, "Resource is still in use"))
+			s.events(ctx).On(newEvent(eventName, api.Warning, "Resource is still in use"))
 			found++
 			continue
 		}
 
-		if _, err := s.apiClient().NetworkRemove(ctx, net.ID, client.NetworkRemoveOptions{}); err != nil {
+		err = s.withSlot(ctx, func(ctx context.Context) error {
+			_, err := s.apiClient().NetworkRemove(ctx, net.ID, client.NetworkRemoveOptions{})
+			return err
+		})
+		if err != nil {
 			if errdefs.IsNotFound(err) {
 				continue
 			}
-			s.events.On(errorEvent(eventName, err.Error()))
+			s.events(ctx).On(errorEvent(eventName, err.Error()))
 			return fmt.Errorf("failed to remove network %s: %w", name, err)
 		}
-		s.events.On(removedEvent(eventName))
+		s.events(ctx).On(removedEvent(eventName))
 		found++
 	}
 
@@ -244,7 +273,7 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 		// in practice, it's extremely unlikely for this to ever occur, as it'd
 		// mean the network was present when we queried at the start of this
 		// method but was then deleted by something else in the interim
-		s.events.On(newEvent(eventName, api.Warning, "No resource found to remove"))
+		s.events(ctx).On(newEvent(eventName, api.Warning, "No resource found to remove"))
 		return nil
 	}
 	return nil
@@ -252,22 +281,28 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 
 func (s *composeService) removeImage(ctx context.Context, image string) error {
 	id := fmt.Sprintf("Image %s", image)
-	return s.removeResource(id, func() error {
+	return s.removeResource(ctx, id, func(ctx context.Context) error {
 		_, err := s.apiClient().ImageRemove(ctx, image, client.ImageRemoveOptions{})
 		return err
 	})
 }
 
-func (s *composeService) removeVolume(ctx context.Context, id string) error {
+// removeVolume removes a volume, unless it is labeled or recorded as protected
+// and force isn't set
+func (s *composeService) removeVolume(ctx context.Context, id string, recorded bool, force bool) error {
 	resource := fmt.Sprintf("Volume %s", id)
 
-	_, err := s.apiClient().VolumeInspect(ctx, id, client.VolumeInspectOptions{})
+	res, err := s.apiClient().VolumeInspect(ctx, id, client.VolumeInspectOptions{})
 	if errdefs.IsNotFound(err) {
 		// Already gone
 		return nil
 	}
+	if err == nil && (recorded || isProtectedVolume(res.Volume.Labels)) && !force {
+		s.events(ctx).On(newEvent(resource, api.Warning, "Protected", "kept as protected, use --force-volumes to remove it"))
+		return nil
+	}
 
-	return s.removeResource(resource, func() error {
+	return s.removeResource(ctx, resource, func(ctx context.Context) error {
 		_, err := s.apiClient().VolumeRemove(ctx, id, client.VolumeRemoveOptions{
 			Force: true,
 		})
@@ -277,19 +312,19 @@ func (s *composeService) removeVolume(ctx context.Context, id string) error {
 
 // removeResource emits a "Removing" progress event, calls op, then emits the appropriate
 // completion event based on the error: nil→Removed, conflict→still-in-use warning, not-found→gone warning.
-func (s *composeService) removeResource(eventID string, op func() error) error {
-	s.events.On(newEvent(eventID, api.Working, "Removing"))
-	err := op()
+func (s *composeService) removeResource(ctx context.Context, eventID string, op func(ctx context.Context) error) error {
+	s.events(ctx).On(newEvent(eventID, api.Working, "Removing"))
+	err := s.withSlot(ctx, op)
 	if err == nil {
-		s.events.On(newEvent(eventID, api.Done, "Removed"))
+		s.events(ctx).On(newEvent(eventID, api.Done, "Removed"))
 		return nil
 	}
 	if errdefs.IsConflict(err) {
-		s.events.On(newEvent(eventID, api.Warning, "Resource is still in use"))
+		s.events(ctx).On(newEvent(eventID, api.Warning, "Resource is still in use"))
 		return nil
 	}
 	if errdefs.IsNotFound(err) {
-		s.events.On(newEvent(eventID, api.Done, "Warning: No resource found to remove"))
+		s.events(ctx).On(newEvent(eventID, api.Done, "Warning: No resource found to remove"))
 		return nil
 	}
 	return err
@@ -297,7 +332,7 @@ func (s *composeService) removeResource(eventID string, op func() error) error {
 
 func (s *composeService) stopContainer(ctx context.Context, service *types.ServiceConfig, ctr containerType.Summary, timeout *time.Duration, listener api.ContainerEventListener) error {
 	eventName := getContainerProgressName(ctr)
-	s.events.On(newEvent(eventName, api.Working, api.StatusStopping))
+	s.events(ctx).On(newEvent(eventName, api.Working, api.StatusStopping))
 
 	if service != nil {
 		for _, hook := range service.PreStop {
@@ -312,14 +347,17 @@ func (s *composeService) stopContainer(ctx context.Context, service *types.Servi
 		}
 	}
 
-	_, err := s.apiClient().ContainerStop(ctx, ctr.ID, client.ContainerStopOptions{
-		Timeout: utils.DurationSecondToInt(timeout),
+	err := s.withSlot(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient().ContainerStop(ctx, ctr.ID, client.ContainerStopOptions{
+			Timeout: utils.DurationSecondToInt(timeout),
+		})
+		return err
 	})
 	if err != nil {
-		s.events.On(errorEvent(eventName, "Error while Stopping"))
+		s.events(ctx).On(errorEvent(eventName, "Error while Stopping"))
 		return err
 	}
-	s.events.On(newEvent(eventName, api.Done, api.StatusStopped))
+	s.events(ctx).On(newEvent(eventName, api.Done, api.StatusStopped))
 	return nil
 }
 
@@ -347,22 +385,25 @@ func (s *composeService) stopAndRemoveContainer(ctx context.Context, ctr contain
 	eventName := getContainerProgressName(ctr)
 	err := s.stopContainer(ctx, service, ctr, timeout, nil)
 	if errdefs.IsNotFound(err) {
-		s.events.On(removedEvent(eventName))
+		s.events(ctx).On(removedEvent(eventName))
 		return nil
 	}
 	if err != nil {
 		return err
 	}
-	s.events.On(removingEvent(eventName))
-	_, err = s.apiClient().ContainerRemove(ctx, ctr.ID, client.ContainerRemoveOptions{
-		Force:         true,
-		RemoveVolumes: volumes,
+	s.events(ctx).On(removingEvent(eventName))
+	err = s.withSlot(ctx, func(ctx context.Context) error {
+		_, err := s.apiClient().ContainerRemove(ctx, ctr.ID, client.ContainerRemoveOptions{
+			Force:         true,
+			RemoveVolumes: volumes,
+		})
+		return err
 	})
 	if err != nil && !errdefs.IsNotFound(err) && !errdefs.IsConflict(err) {
-		s.events.On(errorEvent(eventName, "Error while Removing"))
+		s.events(ctx).On(errorEvent(eventName, "Error while Removing"))
 		return err
 	}
-	s.events.On(removedEvent(eventName))
+	s.events(ctx).On(removedEvent(eventName))
 	return nil
 }
 
@@ -270,6 +270,120 @@ func TestDownRemoveVolumes(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestDownRemoveVolumesScope(t *testing.T) {
+	tests := []struct {
+		scope     string
+		anonymous bool
+		named     bool
+	}{
+		{scope: compose.VolumesScopeAll, anonymous: true, named: true},
+		{scope: compose.VolumesScopeNamed, named: true},
+		{scope: compose.VolumesScopeAnonymous, anonymous: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			api, cli := prepareMocks(mockCtrl)
+			tested, err := NewComposeService(cli)
+			assert.NilError(t, err)
+
+			api.EXPECT().ContainerList(gomock.Any(), projectFilterListOpt(false)).Return(
+				client.ContainerListResult{
+					Items: []container.Summary{testContainer("service1", "123", false)},
+				}, nil)
+			api.EXPECT().VolumeList(gomock.Any(), client.VolumeListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.VolumeListResult{
+					Items: []volume.Volume{{Name: "myProject_volume"}},
+				}, nil)
+			api.EXPECT().NetworkList(gomock.Any(), client.NetworkListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.NetworkListResult{}, nil)
+
+			api.EXPECT().ContainerStop(gomock.Any(), "123", client.ContainerStopOptions{}).Return(client.ContainerStopResult{}, nil)
+			api.EXPECT().ContainerRemove(gomock.Any(), "123", client.ContainerRemoveOptions{Force: true, RemoveVolumes: tt.anonymous}).
+				Return(client.ContainerRemoveResult{}, nil)
+			if tt.named {
+				api.EXPECT().VolumeInspect(gomock.Any(), "myProject_volume", gomock.Any()).
+					Return(client.VolumeInspectResult{}, nil)
+				api.EXPECT().VolumeRemove(gomock.Any(), "myProject_volume", client.VolumeRemoveOptions{Force: true}).
+					Return(client.VolumeRemoveResult{}, nil)
+			}
+
+			err = tested.Down(t.Context(), strings.ToLower(testProject), compose.DownOptions{Volumes: true, VolumesScope: tt.scope})
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestDownProtectedVolume(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		t.Run(fmt.Sprintf("force=%t", force), func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			api, cli := prepareMocks(mockCtrl)
+			tested, err := NewComposeService(cli)
+			assert.NilError(t, err)
+
+			api.EXPECT().ContainerList(gomock.Any(), projectFilterListOpt(false)).Return(client.ContainerListResult{}, nil)
+			api.EXPECT().VolumeList(gomock.Any(), client.VolumeListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.VolumeListResult{
+					Items: []volume.Volume{{Name: "myProject_db"}},
+				}, nil)
+			api.EXPECT().NetworkList(gomock.Any(), client.NetworkListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.NetworkListResult{}, nil)
+			api.EXPECT().VolumeInspect(gomock.Any(), "myProject_db", gomock.Any()).
+				Return(client.VolumeInspectResult{Volume: volume.Volume{
+					Name:   "myProject_db",
+					Labels: map[string]string{compose.VolumeProtectLabel: "true"},
+				}}, nil)
+			if force {
+				api.EXPECT().VolumeRemove(gomock.Any(), "myProject_db", client.VolumeRemoveOptions{Force: true}).
+					Return(client.VolumeRemoveResult{}, nil)
+			}
+
+			err = tested.Down(t.Context(), strings.ToLower(testProject), compose.DownOptions{Volumes: true, ForceVolumes: force})
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestDownRecordedProtectedVolume(t *testing.T) {
+	for _, force := range []bool{false, true} {
+		t.Run(fmt.Sprintf("force=%t", force), func(t *testing.T) {
+			withConfigDir(t)
+			assert.NilError(t, recordProtectedVolume(strings.ToLower(testProject), "myProject_db"))
+
+			mockCtrl := gomock.NewController(t)
+			api, cli := prepareMocks(mockCtrl)
+			tested, err := NewComposeService(cli)
+			assert.NilError(t, err)
+
+			api.EXPECT().ContainerList(gomock.Any(), projectFilterListOpt(false)).Return(client.ContainerListResult{}, nil)
+			api.EXPECT().VolumeList(gomock.Any(), client.VolumeListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.VolumeListResult{
+					Items: []volume.Volume{{Name: "myProject_db"}},
+				}, nil)
+			api.EXPECT().NetworkList(gomock.Any(), client.NetworkListOptions{Filters: projectFilter(strings.ToLower(testProject))}).
+				Return(client.NetworkListResult{}, nil)
+			api.EXPECT().VolumeInspect(gomock.Any(), "myProject_db", gomock.Any()).
+				Return(client.VolumeInspectResult{Volume: volume.Volume{Name: "myProject_db"}}, nil)
+			if force {
+				api.EXPECT().VolumeRemove(gomock.Any(), "myProject_db", client.VolumeRemoveOptions{Force: true}).
+					Return(client.VolumeRemoveResult{}, nil)
+			}
+
+			err = tested.Down(t.Context(), strings.ToLower(testProject), compose.DownOptions{Volumes: true, ForceVolumes: force})
+			assert.NilError(t, err)
+
+			recorded, err := readProtectedVolumes(strings.ToLower(testProject))
+			assert.NilError(t, err)
+			if force {
+				assert.Equal(t, len(recorded), 0)
+			} else {
+				assert.DeepEqual(t, recorded, []string{"myProject_db"})
+			}
+		})
+	}
+}
+
 func TestDownRemoveImages(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+
+	"github.com/docker/compose/v5/pkg/utils"
+)
+
+// earlyExitLogLines is the number of log lines reported for a container
+// exiting right after being started
+const earlyExitLogLines = 10
+
+// checkEarlyExit inspects ctr once window elapsed after it was started, and
+// reports it as failed when it already exited with an error, as a container
+// running a bad command does. Containers exiting successfully, as one-shot
+// jobs do, are not.
+func (s *composeService) checkEarlyExit(ctx context.Context, ctr container.Summary, window time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.clock.After(window):
+	}
+	res, err := s.apiClient().ContainerInspect(ctx, ctr.ID, client.ContainerInspectOptions{})
+	if err != nil {
+		return err
+	}
+	state := res.Container.State
+	if state == nil || state.Running || state.ExitCode == 0 {
+		return nil
+	}
+	name := getContainerProgressName(ctr)
+	s.events(ctx).On(errorEventf(name, "Exited (%d)", state.ExitCode))
+
+	msg := fmt.Sprintf("container %s exited with code %d within %s of being started", getCanonicalContainerName(ctr), state.ExitCode, window)
+	lines, err := s.lastLogLines(ctx, ctr.ID, res.Container.Config != nil && res.Container.Config.Tty, earlyExitLogLines)
+	if err != nil || len(lines) == 0 {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%s, last log lines:\n%s", msg, strings.Join(lines, "\n"))
+}
+
+// lastLogLines returns the last n log lines of a container
+func (s *composeService) lastLogLines(ctx context.Context, id string, tty bool, n int) ([]string, error) {
+	r, err := s.apiClient().ContainerLogs(ctx, id, client.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       fmt.Sprint(n),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint:errcheck
+
+	var lines []string
+	w := utils.GetWriter(func(line string) {
+		lines = append(lines, line)
+	})
+	if tty {
+		_, err = io.Copy(w, r)
+	} else {
+		_, err = stdcopy.StdCopy(w, w, r)
+	}
+	_ = w.Close()
+	return lines, err
+}
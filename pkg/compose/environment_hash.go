@@ -0,0 +1,198 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/template"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// interpolatedVariables maps the variables interpolated in the compose files to
+// the services using them. Variables only used outside services map to none.
+type interpolatedVariables map[string][]string
+
+// prepareEnvironmentHash sets the label recording the value of each variable
+// interpolated in the configuration of a service on its containers, so a
+// later up can tell which ones changed. Values are only stored as salted
+// hashes. The label is left out of the configuration hash: the reference is
+// the environment the containers were created with, which the containers
+// recreated by up as their configuration changed update.
+func prepareEnvironmentHash(project *types.Project) interpolatedVariables {
+	variables, err := projectInterpolatedVariables(project)
+	if err != nil {
+		logrus.Debugf("can't detect interpolated variables: %v", err)
+		return nil
+	}
+	for name, service := range project.Services {
+		names := serviceVariables(variables, name)
+		if len(names) == 0 {
+			continue
+		}
+		service.CustomLabels = service.CustomLabels.Add(api.EnvironmentHashLabel, environmentHash(project, names))
+		project.Services[name] = service
+	}
+	return variables
+}
+
+// serviceVariables returns the variables interpolated in the configuration of
+// service
+func serviceVariables(variables interpolatedVariables, service string) []string {
+	var names []string
+	for name, services := range variables {
+		if slices.Contains(services, service) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// noticeEnvironmentChanges lists the interpolated variables whose value changed
+// since the containers of the services using them were created, and these
+// services. It is purely informational: whether a service gets recreated only
+// depends on its resolved configuration.
+func noticeEnvironmentChanges(project *types.Project, observed *ObservedState, variables interpolatedVariables) {
+	if variables == nil {
+		return
+	}
+	var changed, services []string
+	for _, name := range slices.Sorted(maps.Keys(project.Services)) {
+		current := project.Services[name].CustomLabels[api.EnvironmentHashLabel]
+		for _, ctr := range observed.Containers[name] {
+			previous := ctr.Labels[api.EnvironmentHashLabel]
+			if previous == "" {
+				continue
+			}
+			names := changedVariables(previous, current)
+			if len(names) == 0 {
+				continue
+			}
+			for _, variable := range names {
+				if !slices.Contains(changed, variable) {
+					changed = append(changed, variable)
+				}
+			}
+			services = append(services, name)
+			break
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	slices.Sort(changed)
+	logrus.Warnf("Environment variables changed since the containers using them were created: %s. Services using them: %s",
+		strings.Join(changed, ", "), strings.Join(services, ", "))
+}
+
+// projectInterpolatedVariables reads the variables interpolated in the project
+// compose files.
+func projectInterpolatedVariables(project *types.Project) (interpolatedVariables, error) {
+	variables := interpolatedVariables{}
+	for _, file := range project.ComposeFiles {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var model map[string]any
+		if err := yaml.Unmarshal(b, &model); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for name, services := range modelInterpolatedVariables(model) {
+			for _, service := range services {
+				if !slices.Contains(variables[name], service) {
+					variables[name] = append(variables[name], service)
+				}
+			}
+			if _, ok := variables[name]; !ok {
+				variables[name] = nil
+			}
+		}
+	}
+	for name := range variables {
+		slices.Sort(variables[name])
+	}
+	return variables, nil
+}
+
+// modelInterpolatedVariables attributes the variables interpolated in a compose
+// file model to the services using them.
+func modelInterpolatedVariables(model map[string]any) interpolatedVariables {
+	variables := interpolatedVariables{}
+	for name := range template.ExtractVariables(model, template.DefaultPattern) {
+		variables[name] = nil
+	}
+	services, _ := model["services"].(map[string]any)
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		config, ok := services[service].(map[string]any)
+		if !ok {
+			continue
+		}
+		for name := range template.ExtractVariables(config, template.DefaultPattern) {
+			variables[name] = append(variables[name], service)
+		}
+	}
+	return variables
+}
+
+// environmentHash encodes the value of each variable as a NAME:hash list.
+func environmentHash(project *types.Project, variables []string) string {
+	entries := make([]string, 0, len(variables))
+	for _, name := range slices.Sorted(slices.Values(variables)) {
+		value, ok := project.Environment[name]
+		if !ok {
+			entries = append(entries, name+":-")
+			continue
+		}
+		hash := digest.SHA256.FromString(project.Name + "\x00" + name + "=" + value).Encoded()
+		entries = append(entries, name+":"+hash[:12])
+	}
+	return strings.Join(entries, ",")
+}
+
+// changedVariables returns the names of the variables with a different value
+// between two environment hashes. Variables only referenced on one side are a
+// change of the compose files rather than of the environment, and are ignored.
+func changedVariables(previous, current string) []string {
+	parse := func(hash string) map[string]string {
+		values := map[string]string{}
+		for entry := range strings.SplitSeq(hash, ",") {
+			if name, value, ok := strings.Cut(entry, ":"); ok {
+				values[name] = value
+			}
+		}
+		return values
+	}
+	before, after := parse(previous), parse(current)
+	var changed []string
+	for name, value := range after {
+		if previous, ok := before[name]; ok && previous != value {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestModelInterpolatedVariables(t *testing.T) {
+	model := map[string]any{
+		"services": map[string]any{
+			"web": map[string]any{
+				"image": "nginx:${NGINX_VERSION:-latest}",
+				"ports": []any{"${WEB_PORT}:80"},
+			},
+			"worker": map[string]any{
+				"image":       "worker:${TAG}",
+				"environment": map[string]any{"QUEUE": "$QUEUE", "PRICE": "$$5"},
+			},
+			"db": map[string]any{
+				"image": "postgres:${TAG}",
+			},
+		},
+		"networks": map[string]any{
+			"front": map[string]any{"name": "${NETWORK_NAME}"},
+		},
+	}
+	assert.DeepEqual(t, modelInterpolatedVariables(model), interpolatedVariables{
+		"NGINX_VERSION": {"web"},
+		"WEB_PORT":      {"web"},
+		"TAG":           {"db", "worker"},
+		"QUEUE":         {"worker"},
+		"NETWORK_NAME":  nil,
+	})
+}
+
+func TestChangedVariables(t *testing.T) {
+	assert.DeepEqual(t, changedVariables("A:111,B:222,C:-", "A:111,B:333,C:444,D:555"), []string{"B", "C"})
+	assert.Check(t, changedVariables("A:111", "A:111") == nil)
+	// variables no longer referenced are a compose file change
+	assert.Check(t, changedVariables("A:111,B:222", "A:111") == nil)
+}
+
+func TestNoticeEnvironmentChanges(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compose.yaml")
+	assert.NilError(t, os.WriteFile(file, []byte(`
+services:
+  web:
+    image: nginx:${TAG}
+  worker:
+    image: worker:${TAG}
+    command: ["--queue", "${QUEUE}"]
+`), 0o644))
+	project := func(env types.Mapping) *types.Project {
+		return &types.Project{
+			Name:         "myproject",
+			ComposeFiles: []string{file},
+			Environment:  env,
+			Services: types.Services{
+				"web":    {Name: "web", Image: "nginx:" + env["TAG"]},
+				"worker": {Name: "worker", Image: "worker:" + env["TAG"]},
+			},
+		}
+	}
+	observedState := func(project *types.Project) *ObservedState {
+		observed := emptyObservedState("myproject")
+		for name, service := range project.Services {
+			observed.Containers[name] = []ObservedContainer{{
+				ID:     name + "1",
+				Labels: map[string]string{api.EnvironmentHashLabel: service.CustomLabels[api.EnvironmentHashLabel]},
+			}}
+		}
+		return observed
+	}
+
+	previous := project(types.Mapping{"TAG": "1.0", "QUEUE": "emails"})
+	prepareEnvironmentHash(previous)
+	observed := observedState(previous)
+
+	current := project(types.Mapping{"TAG": "1.0", "QUEUE": "reports"})
+	variables := prepareEnvironmentHash(current)
+
+	hook := logrustest.NewGlobal()
+	noticeEnvironmentChanges(current, observed, variables)
+	assert.Equal(t, len(hook.AllEntries()), 1)
+	assert.Equal(t, hook.LastEntry().Message, "Environment variables changed since the containers using them were created: QUEUE. Services using them: worker")
+
+	// once the worker containers are recreated with the new environment, the
+	// change isn't noticed again
+	hook.Reset()
+	noticeEnvironmentChanges(current, observedState(current), prepareEnvironmentHash(current))
+	assert.Equal(t, len(hook.AllEntries()), 0)
+}
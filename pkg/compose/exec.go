@@ -23,7 +23,9 @@ import (
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command/container"
+	"github.com/docker/cli/opts"
 	containerType "github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
@@ -35,6 +37,29 @@ func (s *composeService) Exec(ctx context.Context, projectName string, options a
 		return 0, err
 	}
 
+	var reset []string
+	if options.NoServiceEnv {
+		reset, err = s.serviceEnvReset(ctx, target.ID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	exec, err := toExecOptions(options, reset)
+	if err != nil {
+		return 0, err
+	}
+
+	err = container.RunExec(ctx, s.dockerCli, target.ID, exec)
+	var sterr cli.StatusError
+	if errors.As(err, &sterr) {
+		return sterr.StatusCode, err
+	}
+	return 0, err
+}
+
+// toExecOptions converts options to the ones of docker exec, the variables of
+// reset being set before the ones of options.Environment.
+func toExecOptions(options api.RunOptions, reset []string) (container.ExecOptions, error) {
 	exec := container.NewExecOptions()
 	exec.Interactive = options.Interactive
 	exec.TTY = options.Tty
@@ -43,19 +68,63 @@ func (s *composeService) Exec(ctx context.Context, projectName string, options a
 	exec.Privileged = options.Privileged
 	exec.Workdir = options.WorkingDir
 	exec.Command = options.Command
+	if len(reset) > 0 {
+		// variables are unset by their name alone, which the validation of
+		// Env would resolve from the local environment
+		exec.Env = opts.NewListOpts(nil)
+		for _, v := range reset {
+			_ = exec.Env.Set(v)
+		}
+	}
 	for _, v := range options.Environment {
-		err := exec.Env.Set(v)
+		v, err := opts.ValidateEnv(v)
 		if err != nil {
-			return 0, err
+			return exec, err
+		}
+		if err := exec.Env.Set(v); err != nil {
+			return exec, err
 		}
 	}
+	return exec, nil
+}
 
-	err = container.RunExec(ctx, s.dockerCli, target.ID, exec)
-	var sterr cli.StatusError
-	if errors.As(err, &sterr) {
-		return sterr.StatusCode, err
+// serviceEnvReset returns the variables to pass to exec for a command to run
+// in ctr with the environment of its image, as resolved when the container was
+// created: the variables set by the service are unset, or reset to the value
+// of the image when they override one.
+func (s *composeService) serviceEnvReset(ctx context.Context, ctr string) ([]string, error) {
+	inspect, err := s.apiClient().ContainerInspect(ctx, ctr, client.ContainerInspectOptions{})
+	if err != nil {
+		return nil, err
 	}
-	return 0, err
+	img, err := s.apiClient().ImageInspect(ctx, inspect.Container.Image)
+	if err != nil {
+		return nil, err
+	}
+	var imageEnv []string
+	if img.Config != nil {
+		imageEnv = img.Config.Env
+	}
+	return envReset(inspect.Container.Config.Env, imageEnv), nil
+}
+
+// envReset returns the variables resetting env to imageEnv
+func envReset(env, imageEnv []string) []string {
+	image := map[string]string{}
+	for _, e := range imageEnv {
+		k, v, _ := strings.Cut(e, "=")
+		image[k] = v
+	}
+	var reset []string
+	for _, e := range envDiff(env, imageEnv) {
+		k, _, _ := strings.Cut(e, "=")
+		if v, ok := image[k]; ok {
+			reset = append(reset, k+"="+v)
+		} else {
+			reset = append(reset, k)
+		}
+	}
+	return reset
 }
 
 func (s *composeService) getExecTarget(ctx context.Context, projectName string, opts api.RunOptions) (containerType.Summary, error) {
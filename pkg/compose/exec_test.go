@@ -0,0 +1,61 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestEnvReset(t *testing.T) {
+	imageEnv := []string{"PATH=/usr/local/bin:/usr/bin", "LANG=C.UTF-8", "APP_MODE=production"}
+	env := []string{"PATH=/usr/local/bin:/usr/bin", "LANG=C.UTF-8", "APP_MODE=development", "DATABASE_URL=postgres://db"}
+
+	// variables of the image are kept or reset, the ones of the service unset
+	assert.DeepEqual(t, envReset(env, imageEnv), []string{"APP_MODE=production", "DATABASE_URL"})
+	assert.Equal(t, len(envReset(imageEnv, imageEnv)), 0)
+}
+
+func TestToExecOptions(t *testing.T) {
+	options := api.RunOptions{
+		Command:     []string{"sh"},
+		User:        "1000",
+		Privileged:  true,
+		WorkingDir:  "/tmp",
+		Environment: []string{"DEBUG=1"},
+	}
+
+	t.Run("inherit service environment", func(t *testing.T) {
+		exec, err := toExecOptions(options, nil)
+		assert.NilError(t, err)
+		assert.Equal(t, exec.User, "1000")
+		assert.Equal(t, exec.Privileged, true)
+		assert.Equal(t, exec.Workdir, "/tmp")
+		assert.DeepEqual(t, exec.Env.GetSlice(), []string{"DEBUG=1"})
+	})
+
+	t.Run("no service environment", func(t *testing.T) {
+		t.Setenv("DATABASE_URL", "postgres://localhost")
+		exec, err := toExecOptions(options, []string{"APP_MODE=production", "DATABASE_URL"})
+		assert.NilError(t, err)
+		// the local value of an unset variable must not leak into the container
+		assert.DeepEqual(t, exec.Env.GetSlice(), []string{"APP_MODE=production", "DATABASE_URL", "DEBUG=1"})
+	})
+}
@@ -20,9 +20,12 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose/v5/pkg/api"
 )
 
 // planExecutor executes a reconciliation Plan by walking the DAG and performing
@@ -38,6 +41,20 @@ type planExecutor struct {
 	// round-trip per create.
 	containersMu        sync.Mutex
 	containersByService map[string]Containers
+
+	// createHostPaths creates the missing sources of bind mounts rather than
+	// failing to create the containers using them
+	createHostPaths bool
+	// containerCreated is notified of each container created
+	containerCreated func(service string, containerID string)
+	// imagePulls tracks the images pulled in the background, the containers
+	// of their services are created once pulled
+	imagePulls *imagePulls
+	// confirmCanary is called with the canaries of the plan, which are
+	// confirmed without it
+	confirmCanary func(ctx context.Context, canary api.CanaryContainer) (bool, error)
+	// canaryTimeout is how long confirmCanary is given, 0 for no limit
+	canaryTimeout time.Duration
 }
 
 // reconciliationContext holds results produced by completed nodes so that downstream
@@ -51,6 +68,7 @@ type reconciliationContext struct {
 type operationResult struct {
 	ContainerID   string
 	ContainerName string
+	ImageID       string
 }
 
 func (pc *reconciliationContext) set(nodeID int, r operationResult) {
@@ -100,7 +118,7 @@ func (exec *planExecutor) run(ctx context.Context, plan *Plan) error {
 
 	// Track group event state: first node emits Working, last emits Done
 	groups := exec.buildGroupTracker(plan)
-	events := exec.compose.events
+	events := exec.compose.events(ctx)
 
 	eg, ctx := errgroup.WithContext(ctx)
 	for _, node := range plan.Nodes {
@@ -114,26 +132,60 @@ func (exec *planExecutor) run(ctx context.Context, plan *Plan) error {
 				}
 			}
 
+			// Wait for the image of the container to be pulled, without
+			// holding a slot of the concurrency limiter
+			if node.Operation.Type == OpCreateContainer {
+				if _, _, err := exec.imagePulls.wait(ctx, node.Operation.Service.Name); err != nil {
+					return err
+				}
+			}
+
+			nodeCtx, release, err := exec.acquire(ctx, node)
+			if err != nil {
+				return err
+			}
+
 			// Emit group start event if this is the first node of a group
 			groups.onNodeStart(node, events)
 
-			err := exec.executeNode(ctx, node)
-
-			if err == nil {
-				// Emit group done event if this is the last node of a group
-				groups.onNodeDone(node, events)
-			} else if ctx.Err() == nil {
-				groups.onNodeError(node, events, err)
+			started := exec.compose.clock.Now()
+			err = exec.executeNode(nodeCtx, node)
+			release()
+			if node.Operation.Service != nil {
+				startupProfileOf(ctx).executed(node.Operation.Service.Name, started, exec.compose.clock.Now())
 			}
 
+			if err != nil {
+				if ctx.Err() == nil {
+					groups.onNodeError(node, events, err)
+				}
+				// the dependents are left waiting until the plan is canceled
+				return err
+			}
+			// Emit group done event if this is the last node of a group
+			groups.onNodeDone(node, events)
 			close(done[node.ID])
-			return err
+			return nil
 		})
 	}
 
 	return eg.Wait()
 }
 
+// acquire takes a slot from the concurrency limiter of the compose service, if
+// any, for the node to run, and returns the context to run it with and the
+// function releasing it. Waiting on a container, for the confirmation of a
+// canary, for the drain period of a container or for a post_recreate hook to
+// complete doesn't count against the budget: these mostly wait, and would
+// otherwise hold the slots the other operations of the plan need.
+func (exec *planExecutor) acquire(ctx context.Context, node *PlanNode) (context.Context, func(), error) {
+	switch node.Operation.Type {
+	case OpWaitContainer, OpConfirmCanary, OpDrainContainer, OpPostRecreateHook:
+		return ctx, func() {}, nil
+	}
+	return exec.compose.acquireSlot(ctx)
+}
+
 // executeNode dispatches a single plan node to the appropriate API call.
 func (exec *planExecutor) executeNode(ctx context.Context, node *PlanNode) error {
 	op := node.Operation
@@ -153,15 +205,27 @@ func (exec *planExecutor) executeNode(ctx context.Context, node *PlanNode) error
 	case OpCreateContainer:
 		return exec.execCreateContainer(ctx, node)
 	case OpStartContainer:
-		return exec.execStartContainer(ctx, op)
+		return exec.execStartContainer(ctx, node)
 	case OpStopContainer:
 		return exec.execStopContainer(ctx, op)
 	case OpRemoveContainer:
 		return exec.execRemoveContainer(ctx, op)
 	case OpRenameContainer:
 		return exec.execRenameContainer(ctx, node)
+	case OpWaitContainer:
+		return exec.execWaitContainer(ctx, node)
+	case OpDrainContainer:
+		return exec.execDrainContainer(ctx, op)
+	case OpPreRecreateHook:
+		return exec.execPreRecreateHook(ctx, op)
+	case OpPostRecreateHook:
+		return exec.execPostRecreateHook(ctx, node)
+	case OpCommitContainer:
+		return exec.execCommitContainer(ctx, node)
 	case OpRunProvider:
 		return exec.compose.runPlugin(ctx, exec.project, *op.Service, "up")
+	case OpConfirmCanary:
+		return exec.execConfirmCanary(ctx, node)
 	default:
 		return fmt.Errorf("unknown operation type: %s", op.Type)
 	}
@@ -34,6 +34,7 @@ type groupState struct {
 	total     int    // total nodes in this group
 	started   int    // nodes that have started
 	done      int    // nodes that have completed
+	paused    bool   // the group replaces a paused container, whose replacement is paused once started
 }
 
 func (exec *planExecutor) buildGroupTracker(plan *Plan) *groupTracker {
@@ -46,6 +47,9 @@ func (exec *planExecutor) buildGroupTracker(plan *Plan) *groupTracker {
 			gt.groups[node.Group] = &groupState{}
 		}
 		gt.groups[node.Group].total++
+		if node.Operation.Paused {
+			gt.groups[node.Group].paused = true
+		}
 		// Pick the event name from a node that has the existing container reference
 		if gt.groups[node.Group].eventName == "" && node.Operation.Container != nil {
 			gt.groups[node.Group].eventName = getContainerProgressName(*node.Operation.Container)
@@ -85,7 +89,11 @@ func (gt *groupTracker) onNodeDone(node *PlanNode, events api.EventProcessor) {
 	gs := gt.groups[node.Group]
 	gs.done++
 	if gs.done == gs.total {
-		events.On(newEvent(gs.eventName, api.Done, "Recreated"))
+		text := "Recreated"
+		if gs.paused {
+			text = "Recreated (paused)"
+		}
+		events.On(newEvent(gs.eventName, api.Done, text))
 	}
 }
 
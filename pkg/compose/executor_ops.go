@@ -17,12 +17,21 @@
 package compose
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/compose-spec/compose-go/v2/types"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
 
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/utils"
@@ -80,12 +89,26 @@ func (exec *planExecutor) execCreateContainer(ctx context.Context, node *PlanNod
 	// any containers created by earlier plan nodes.
 	exec.containersMu.Lock()
 	err := resolveServiceReferences(&service, exec.containersByService)
+	pairedID, paired := pairedContainer(exec.containersByService[sidecarOf(service)], op.Number)
 	exec.containersMu.Unlock()
 	if err != nil {
 		return err
 	}
+	if sidecarOf(service) != "" && !paired {
+		return fmt.Errorf("cannot pair sidecar %s with container %d of service %s: container missing", op.Name, op.Number, sidecarOf(service))
+	}
 
-	labels := mergeLabels(service.Labels, service.CustomLabels)
+	// The digest of an image pulled in the background is only known once
+	// pulled, the labels are cloned as shared with the plan
+	customLabels := service.CustomLabels
+	imageID, pulled, err := exec.imagePulls.wait(ctx, service.Name)
+	if err != nil {
+		return err
+	}
+	if pulled && imageID != "" {
+		customLabels = maps.Clone(customLabels).Add(api.ImageDigestLabel, imageID)
+	}
+	labels := mergeLabels(service.Labels, customLabels)
 	if op.Inherited != nil {
 		// This is a recreate: add the replace label
 		replacedName := op.Service.ContainerName
@@ -94,13 +117,27 @@ func (exec *planExecutor) execCreateContainer(ctx context.Context, node *PlanNod
 		}
 		labels = labels.Add(api.ContainerReplaceLabel, replacedName)
 	}
+	if op.Paused {
+		labels = labels.Add(api.ReplacePausedLabel, "true")
+	}
+	if op.Name != getContainerName(exec.project.Name, service, op.Number) {
+		// Created under a temporary name: record it, so a later up can
+		// complete the rename if it doesn't happen
+		labels = labels.Add(api.TemporaryNameLabel, op.Name)
+	}
 
-	opts := createOptions{
-		AutoRemove:        false,
-		AttachStdin:       false,
-		UseNetworkAliases: true,
-		Labels:            labels,
+	createOpts := []CreateOption{
+		withNetworkAliases(true),
+		WithContainerLabels(labels),
+		withHostPathsCheck(exec.createHostPaths),
+	}
+	if op.CommitNodeID != 0 {
+		createOpts = append(createOpts, withImage(exec.pctx.get(op.CommitNodeID).ImageID))
 	}
+	if paired {
+		createOpts = append(createOpts, withPairedContainer(pairedID))
+	}
+	opts := NewCreateOptions(createOpts...)
 	ctr, err := exec.compose.createMobyContainer(ctx, exec.project, service, op.Name, op.Number, op.Inherited, opts)
 	if err != nil {
 		return err
@@ -110,6 +147,9 @@ func (exec *planExecutor) execCreateContainer(ctx context.Context, node *PlanNod
 		ContainerID:   ctr.ID,
 		ContainerName: op.Name,
 	})
+	if exec.containerCreated != nil {
+		exec.containerCreated(op.Service.Name, ctr.ID)
+	}
 
 	// Make the new container visible to subsequent execCreateContainer calls
 	// that resolve service references against op.Service.Name.
@@ -119,14 +159,313 @@ func (exec *planExecutor) execCreateContainer(ctx context.Context, node *PlanNod
 	return nil
 }
 
-func (exec *planExecutor) execStartContainer(ctx context.Context, op Operation) error {
-	startMx.Lock()
-	defer startMx.Unlock()
-	_, err := exec.compose.apiClient().ContainerStart(ctx, op.Container.ID, client.ContainerStartOptions{})
+func (exec *planExecutor) execStartContainer(ctx context.Context, node *PlanNode) error {
+	op := node.Operation
+	id := ""
+	if op.CreateNodeID != 0 {
+		// Starting a replacement created by this plan (surge recreate): it
+		// needs its secrets and configs before it runs.
+		var err error
+		if id, err = exec.createdContainerID(node); err != nil {
+			return err
+		}
+		if err := exec.compose.injectSecrets(ctx, exec.project, *op.Service, id); err != nil {
+			return err
+		}
+		if err := exec.compose.injectConfigs(ctx, exec.project, *op.Service, id); err != nil {
+			return err
+		}
+	} else {
+		id = op.Container.ID
+	}
+	service := op.Service
+	if service == nil {
+		if s, ok := exec.project.Services[op.Container.Labels[api.ServiceLabel]]; ok {
+			service = &s
+		}
+	}
+	defer lockStart(service)()
+	_, err := exec.compose.apiClient().ContainerStart(ctx, id, client.ContainerStartOptions{})
 	return err
 }
 
+// execWaitContainer blocks until the container created by the referenced
+// create node is healthy, or running if it has no healthcheck, for as long as
+// the engine takes to report it unhealthy, and a canary no longer than it is
+// given to be confirmed. A surge replacement which doesn't get healthy is
+// removed, the container it replaces still running.
+func (exec *planExecutor) execWaitContainer(ctx context.Context, node *PlanNode) error {
+	op := node.Operation
+	id, err := exec.createdContainerID(node)
+	if err != nil {
+		return err
+	}
+	timeout := healthcheckTimeout(op.Service.HealthCheck)
+	if op.Canary && exec.canaryTimeout > 0 {
+		timeout = min(timeout, exec.canaryTimeout)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = exec.compose.waitContainerHealthy(waitCtx, id)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("container %s of service %s isn't healthy after %s", id, op.Service.Name, timeout)
+	}
+	if op.Canary {
+		err = fmt.Errorf("canary of service %s didn't get healthy, the other containers of the service aren't recreated: %w", op.Service.Name, err)
+	}
+	if op.RemoveOnFailure {
+		_, rmErr := exec.compose.apiClient().ContainerRemove(context.WithoutCancel(ctx), id, client.ContainerRemoveOptions{
+			Force:         true,
+			RemoveVolumes: true,
+		})
+		err = errors.Join(err, rmErr)
+	}
+	return err
+}
+
+// healthcheckTimeout is how long the engine takes at most to report a
+// container unhealthy with check, which has the engine defaults when not set
+func healthcheckTimeout(check *types.HealthCheckConfig) time.Duration {
+	interval, timeout, startPeriod, retries := 30*time.Second, 30*time.Second, time.Duration(0), uint64(3)
+	if check != nil {
+		if check.Interval != nil {
+			interval = time.Duration(*check.Interval)
+		}
+		if check.Timeout != nil {
+			timeout = time.Duration(*check.Timeout)
+		}
+		if check.StartPeriod != nil {
+			startPeriod = time.Duration(*check.StartPeriod)
+		}
+		if check.Retries != nil {
+			retries = *check.Retries
+		}
+	}
+	return startPeriod + time.Duration(retries)*(interval+timeout)
+}
+
+// execConfirmCanary calls the confirmation callback with the canary created
+// by the referenced create node, which the plan already awaited to be
+// healthy. The recreate of the other containers of its service depends on
+// its approval.
+func (exec *planExecutor) execConfirmCanary(ctx context.Context, node *PlanNode) error {
+	if exec.confirmCanary == nil {
+		return nil
+	}
+	op := node.Operation
+	id, err := exec.createdContainerID(node)
+	if err != nil {
+		return err
+	}
+	res, err := exec.compose.apiClient().ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return err
+	}
+	canary := api.CanaryContainer{
+		Service: op.Service.Name,
+		Number:  op.Number,
+		ID:      id,
+		Name:    strings.TrimPrefix(res.Container.Name, "/"),
+	}
+	if state := res.Container.State; state != nil {
+		canary.State = string(state.Status)
+		if state.Health != nil {
+			canary.Health = string(state.Health.Status)
+		}
+	}
+
+	if exec.canaryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, exec.canaryTimeout)
+		defer cancel()
+	}
+	exec.compose.events(ctx).On(newEvent("Container "+canary.Name, api.Working, "Waiting for confirmation"))
+	type confirmation struct {
+		ok  bool
+		err error
+	}
+	// the callback may not honor the context, such as when it prompts
+	confirmed := make(chan confirmation, 1)
+	go func() {
+		ok, err := exec.confirmCanary(ctx, canary)
+		confirmed <- confirmation{ok: ok, err: err}
+	}()
+	select {
+	case c := <-confirmed:
+		switch {
+		case c.err != nil:
+			return fmt.Errorf("confirming canary %s of service %s: %w", canary.Name, canary.Service, c.err)
+		case !c.ok:
+			return fmt.Errorf("canary %s of service %s was declined, the other containers of the service aren't recreated", canary.Name, canary.Service)
+		}
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("canary %s of service %s wasn't confirmed within %s", canary.Name, canary.Service, exec.canaryTimeout)
+		}
+		return ctx.Err()
+	}
+}
+
+// execPreRecreateHook runs the x-update pre_recreate hooks of the service in
+// the container about to be replaced.
+func (exec *planExecutor) execPreRecreateHook(ctx context.Context, op Operation) error {
+	config, err := getUpdateConfig(*op.Service)
+	if err != nil {
+		return err
+	}
+	return exec.compose.runRecreateHooks(ctx, *op.Container, *op.Service, "pre_recreate", config.PreRecreate, nil)
+}
+
+// execPostRecreateHook runs the x-update post_recreate hooks of the service in
+// the replacement created by the referenced create node, which the plan
+// already awaited to be healthy.
+func (exec *planExecutor) execPostRecreateHook(ctx context.Context, node *PlanNode) error {
+	op := node.Operation
+	id, err := exec.createdContainerID(node)
+	if err != nil {
+		return err
+	}
+	config, err := getUpdateConfig(*op.Service)
+	if err != nil {
+		return err
+	}
+	return exec.compose.runRecreateHooks(ctx, container.Summary{ID: id}, *op.Service, "post_recreate", config.PostRecreate, nil)
+}
+
+// execCommitContainer commits the filesystem of the stopped container being
+// recreated to an untagged image, its replacement is created from. Once the
+// replacement is removed in turn, the image is left dangling for image prune
+// to collect.
+//
+// The image is committed with the config of the image of the container, so
+// only the filesystem carries over: the command, user, working directory or
+// healthcheck the container was created with don't become defaults of its
+// replacement. The engine still adds the environment of the container to the
+// image, so a variable removed from the service would be set again in the
+// replacement: the recreate fails instead, and the container is started
+// again.
+func (exec *planExecutor) execCommitContainer(ctx context.Context, node *PlanNode) error {
+	op := node.Operation
+	apiClient := exec.compose.apiClient()
+	inspect, err := apiClient.ContainerInspect(ctx, op.Container.ID, client.ContainerInspectOptions{})
+	if err != nil {
+		return err
+	}
+	img, err := apiClient.ImageInspect(ctx, inspect.Container.Image)
+	if err != nil {
+		return err
+	}
+	if img.Config == nil {
+		img.Config = &dockerspec.DockerOCIImageConfig{}
+	}
+	var env []string
+	if inspect.Container.Config != nil {
+		env = inspect.Container.Config.Env
+	}
+	if removed := removedEnvironment(env, img.Config.Env, op.Service.Environment); len(removed) > 0 {
+		if op.Container.State == container.StateRunning {
+			if _, err := apiClient.ContainerStart(context.WithoutCancel(ctx), op.Container.ID, client.ContainerStartOptions{}); err != nil {
+				logrus.Warnf("failed to start container %s again: %v", getCanonicalContainerName(*op.Container), err)
+			}
+		}
+		return fmt.Errorf("service %q: can't preserve the writable layer of %s as %s was removed from its environment, which the engine would set again in its replacement, remove %s.preserve_writable_layer to recreate it",
+			op.Service.Name, getCanonicalContainerName(*op.Container), strings.Join(removed, ", "), updateExtension)
+	}
+	res, err := apiClient.ContainerCommit(ctx, op.Container.ID, client.ContainerCommitOptions{
+		Comment: fmt.Sprintf("writable layer of %s, preserved by %s", getCanonicalContainerName(*op.Container), updateExtension),
+		NoPause: true,
+		Config:  commitConfig(img.Config),
+	})
+	if err != nil {
+		return err
+	}
+	exec.pctx.set(node.ID, operationResult{ImageID: res.ID})
+	return nil
+}
+
+// commitConfig returns the config to commit a container with for the image
+// to have the same defaults as the one of the container. The engine fills the
+// settings left unset from the container config, so the ones the image
+// doesn't set are given their default value.
+func commitConfig(img *dockerspec.DockerOCIImageConfig) *container.Config {
+	config := &container.Config{
+		User:        cmp.Or(img.User, "0"),
+		Env:         img.Env,
+		Entrypoint:  img.Entrypoint,
+		Cmd:         img.Cmd,
+		Volumes:     img.Volumes,
+		WorkingDir:  cmp.Or(img.WorkingDir, "/"),
+		Labels:      img.Labels,
+		StopSignal:  cmp.Or(img.StopSignal, "SIGTERM"),
+		Healthcheck: img.Healthcheck,
+	}
+	if config.Healthcheck == nil {
+		config.Healthcheck = &container.HealthConfig{Test: []string{"NONE"}}
+	}
+	for port := range img.ExposedPorts {
+		if p, err := network.ParsePort(port); err == nil {
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = network.PortSet{}
+			}
+			config.ExposedPorts[p] = struct{}{}
+		}
+	}
+	return config
+}
+
+// removedEnvironment returns the variables set in the environment of a
+// container which neither its image nor the service set anymore
+func removedEnvironment(env, imageEnv []string, environment types.MappingWithEquals) []string {
+	var removed []string
+	for _, e := range env {
+		key, _, _ := strings.Cut(e, "=")
+		if _, ok := environment[key]; ok {
+			continue
+		}
+		if slices.ContainsFunc(imageEnv, func(i string) bool {
+			k, _, _ := strings.Cut(i, "=")
+			return k == key
+		}) {
+			continue
+		}
+		removed = append(removed, key)
+	}
+	return removed
+}
+
+// execDrainContainer lets the old container run for the drain period before
+// the following stop. When interrupted, the drain ends early and the old
+// container is stopped right away, as the plan won't run the stop anymore.
+func (exec *planExecutor) execDrainContainer(ctx context.Context, op Operation) error {
+	eventName := getContainerProgressName(*op.Container)
+	events := exec.compose.events(ctx)
+	events.On(newEvent(eventName, api.Working, api.StatusDraining, op.Drain.String()))
+	select {
+	case <-exec.compose.clock.After(op.Drain):
+		return nil
+	case <-ctx.Done():
+	}
+	// a failing operation elsewhere in the plan also cancels ctx, but with
+	// its own error as the cause: only an interrupt stops the container
+	if !errors.Is(context.Cause(ctx), context.Canceled) {
+		return ctx.Err()
+	}
+	events.On(stoppingEvent(eventName))
+	if err := exec.execStopContainer(context.WithoutCancel(ctx), op); err != nil {
+		return err
+	}
+	events.On(stoppedEvent(eventName))
+	return ctx.Err()
+}
+
 func (exec *planExecutor) execStopContainer(ctx context.Context, op Operation) error {
+	if op.SuspendRestart {
+		exec.compose.suspendRestartPolicy(ctx, *op.Container)
+	}
 	_, err := exec.compose.apiClient().ContainerStop(ctx, op.Container.ID, client.ContainerStopOptions{
 		Timeout: utils.DurationSecondToInt(op.Timeout),
 	})
@@ -138,6 +477,8 @@ func (exec *planExecutor) execRemoveContainer(ctx context.Context, op Operation)
 	if err != nil {
 		return err
 	}
+	// a dependent stopped then recreated has no restart policy to restore
+	suspendedRestartsOf(ctx).take(op.Container.ID)
 	// Why: a dependent service's create may resolve `network_mode: service:X`
 	// (or volumes_from / ipc / pid) against the live view. Containers.sorted()
 	// orders by canonical name; without this drop, a just-removed container
@@ -153,17 +494,60 @@ func (exec *planExecutor) execRemoveContainer(ctx context.Context, op Operation)
 	return nil
 }
 
+// renameAttempts and renameRetryDelay bound the retries of a failing rename,
+// typically the name still being held by the container just removed.
+const (
+	renameAttempts   = 3
+	renameRetryDelay = time.Second
+)
+
+// execRenameContainer gives its final name to a container created under a
+// temporary name, either by this plan or by an earlier interrupted one.
 func (exec *planExecutor) execRenameContainer(ctx context.Context, node *PlanNode) error {
+	op := node.Operation
+	var id, tmpName string
+	if op.CreateNodeID == 0 && op.Container != nil {
+		id, tmpName = op.Container.ID, getCanonicalContainerName(*op.Container)
+	} else {
+		createdID, err := exec.createdContainerID(node)
+		if err != nil {
+			return err
+		}
+		id, tmpName = createdID, exec.pctx.get(op.CreateNodeID).ContainerName
+	}
+
+	var err error
+	for attempt := 1; attempt <= renameAttempts; attempt++ {
+		_, err = exec.compose.apiClient().ContainerRename(ctx, id, client.ContainerRenameOptions{
+			NewName: op.Name,
+		})
+		if err == nil || attempt == renameAttempts {
+			break
+		}
+		logrus.Debugf("failed to rename container %s to %s (attempt %d/%d): %v", tmpName, op.Name, attempt, renameAttempts, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-exec.compose.clock.After(renameRetryDelay):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("container %s exists under its temporary name %q but couldn't be renamed to %q: %w. Run `docker compose up` again to complete the rename",
+			id[:min(12, len(id))], tmpName, op.Name, err)
+	}
+	return nil
+}
+
+// createdContainerID returns the ID of the container created by the
+// CreateContainer node node refers to.
+func (exec *planExecutor) createdContainerID(node *PlanNode) (string, error) {
 	op := node.Operation
 	if op.CreateNodeID == 0 {
-		return fmt.Errorf("internal: rename node #%d missing CreateNodeID", node.ID)
+		return "", fmt.Errorf("internal: %s node #%d missing CreateNodeID", op.Type, node.ID)
 	}
 	createdID := exec.pctx.get(op.CreateNodeID).ContainerID
 	if createdID == "" {
-		return fmt.Errorf("internal: rename node #%d: create node #%d returned empty ID", node.ID, op.CreateNodeID)
+		return "", fmt.Errorf("internal: %s node #%d: create node #%d returned empty ID", op.Type, node.ID, op.CreateNodeID)
 	}
-	_, err := exec.compose.apiClient().ContainerRename(ctx, createdID, client.ContainerRenameOptions{
-		NewName: op.Name,
-	})
-	return err
+	return createdID, nil
 }
@@ -18,13 +18,23 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/jonboulle/clockwork"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/image"
 	"github.com/moby/moby/client"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/sync/semaphore"
 	"gotest.tools/v3/assert"
 
 	"github.com/docker/compose/v5/pkg/api"
@@ -160,7 +170,7 @@ func TestExecutePlanRemoveContainerDropsFromCache(t *testing.T) {
 	observed := &ObservedState{
 		ProjectName: "test",
 		Containers: map[string][]ObservedContainer{
-			"web": {{ID: "old-id", Summary: oldCtr}},
+			"web": {toObservedContainer(oldCtr)},
 		},
 		Networks: map[string]ObservedNetwork{},
 		Volumes:  map[string]ObservedVolume{},
@@ -219,7 +229,7 @@ func TestExecutePlanConcurrentRemovesCacheCoherence(t *testing.T) {
 
 	webContainers := make([]ObservedContainer, replicas)
 	for i := range ctrs {
-		webContainers[i] = ObservedContainer{ID: ctrs[i].ID, Summary: ctrs[i]}
+		webContainers[i] = toObservedContainer(ctrs[i])
 	}
 	observed := &ObservedState{
 		ProjectName: "test",
@@ -253,6 +263,93 @@ func TestExecutePlanConcurrentRemovesCacheCoherence(t *testing.T) {
 		"all removed containers should be dropped from the live view")
 }
 
+func TestExecutePlanConcurrencyLimiter(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	svc.limiter = semaphore.NewWeighted(2)
+
+	var running, maxRunning atomic.Int32
+	plan := &Plan{}
+	for i := range 6 {
+		ctr := container.Summary{ID: fmt.Sprintf("c%d", i), Names: []string{fmt.Sprintf("/test-web-%d", i+1)}}
+		plan.addNode(Operation{
+			Type:       OpStopContainer,
+			ResourceID: fmt.Sprintf("service:web:%d", i+1),
+			Cause:      "scale down",
+			Container:  &ctr,
+		}, "")
+	}
+	apiClient.EXPECT().ContainerStop(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, string, client.ContainerStopOptions) (client.ContainerStopResult, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return client.ContainerStopResult{}, nil
+		}).Times(6)
+
+	err := svc.executePlan(t.Context(), &types.Project{Name: "test"}, emptyObservedState("test"), plan)
+	assert.NilError(t, err)
+	assert.Assert(t, maxRunning.Load() <= 2, "%d operations ran concurrently", maxRunning.Load())
+}
+
+func TestExecutePlanConcurrencyLimiterDrain(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	svc.limiter = semaphore.NewWeighted(1)
+
+	draining := container.Summary{ID: "c1", Names: []string{"/test-web-1"}}
+	first := container.Summary{ID: "c2", Names: []string{"/test-worker-1"}}
+	second := container.Summary{ID: "c3", Names: []string{"/test-worker-2"}}
+	plan := &Plan{}
+	plan.addNode(Operation{
+		Type:       OpDrainContainer,
+		ResourceID: "service:web:1",
+		Cause:      "drain",
+		Container:  &draining,
+		Drain:      time.Minute,
+	}, "")
+	stop := plan.addNode(Operation{
+		Type:       OpStopContainer,
+		ResourceID: "service:worker:1",
+		Cause:      "scale down",
+		Container:  &first,
+	}, "")
+	plan.addNode(Operation{
+		Type:       OpStopContainer,
+		ResourceID: "service:worker:2",
+		Cause:      "scale down",
+		Container:  &second,
+	}, "", stop)
+	// whichever runs first, the drain period doesn't hold the only slot the
+	// stops need
+	stoppedCh := make(chan struct{})
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "c2", gomock.Any()).Return(client.ContainerStopResult{}, nil)
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "c3", gomock.Any()).
+		DoAndReturn(func(context.Context, string, client.ContainerStopOptions) (client.ContainerStopResult, error) {
+			close(stoppedCh)
+			return client.ContainerStopResult{}, nil
+		})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.executePlan(t.Context(), &types.Project{Name: "test"}, emptyObservedState("test"), plan)
+	}()
+	select {
+	case <-stoppedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("container stop is blocked by the drain period")
+	}
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	clock.Advance(time.Minute)
+	assert.NilError(t, <-done)
+}
+
 // TestExecutePlanRecreateVolume drives the destructive core of a volume
 // recreation — stop container → remove container → remove volume → create
 // volume — end to end through the executor, asserting each Docker API call
@@ -321,3 +418,643 @@ type notFoundError struct{}
 
 func (notFoundError) Error() string { return "not found" }
 func (notFoundError) NotFound()     {}
+
+func TestExecutePlanStartAndWaitCreatedContainer(t *testing.T) {
+	svc, apiClient := newTestService(t)
+
+	apiClient.EXPECT().ContainerStart(gomock.Any(), "new1", gomock.Any()).
+		Return(client.ContainerStartResult{}, nil)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "new1", gomock.Any()).
+		Return(client.ContainerInspectResult{Container: container.InspectResponse{
+			Name:   "/abc_test-web-1",
+			State:  &container.State{Status: container.StateRunning},
+			Config: &container.Config{},
+		}}, nil)
+
+	service := types.ServiceConfig{Name: "web"}
+	plan := &Plan{}
+	// CreateNodeID points at a result seeded below, standing in for a
+	// CreateContainer node that already ran.
+	startNode := plan.addNode(Operation{
+		Type:         OpStartContainer,
+		ResourceID:   "service:web:1",
+		Service:      &service,
+		CreateNodeID: 99,
+	}, "recreate:web:1")
+	plan.addNode(Operation{
+		Type:         OpWaitContainer,
+		ResourceID:   "service:web:1",
+		Service:      &service,
+		CreateNodeID: 99,
+	}, "recreate:web:1", startNode)
+
+	exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+	exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "abc_test-web-1"})
+	assert.NilError(t, exec.run(t.Context(), plan))
+}
+
+func TestExecutePlanWaitReplacementTimeout(t *testing.T) {
+	svc, apiClient := newTestService(t)
+
+	// the replacement stays starting past its healthcheck retries
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "new1", gomock.Any()).
+		Return(client.ContainerInspectResult{Container: container.InspectResponse{
+			Name: "/abc_test-web-1",
+			State: &container.State{
+				Status: container.StateRunning,
+				Health: &container.Health{Status: container.Starting},
+			},
+			Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+		}}, nil).AnyTimes()
+	apiClient.EXPECT().ContainerRemove(gomock.Any(), "new1", client.ContainerRemoveOptions{Force: true, RemoveVolumes: true}).
+		Return(client.ContainerRemoveResult{}, nil)
+
+	interval := types.Duration(10 * time.Millisecond)
+	retries := uint64(1)
+	service := types.ServiceConfig{Name: "web", HealthCheck: &types.HealthCheckConfig{
+		Interval: &interval,
+		Timeout:  &interval,
+		Retries:  &retries,
+	}}
+	plan := &Plan{}
+	plan.addNode(Operation{
+		Type:            OpWaitContainer,
+		ResourceID:      "service:web:1",
+		Service:         &service,
+		CreateNodeID:    99,
+		RemoveOnFailure: true,
+	}, "recreate:web:1")
+
+	exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+	exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "abc_test-web-1"})
+	assert.ErrorContains(t, exec.run(t.Context(), plan), "container new1 of service web isn't healthy after 20ms")
+}
+
+func drainPlan(ctr *container.Summary) *Plan {
+	plan := &Plan{}
+	drain := plan.addNode(Operation{
+		Type:       OpDrainContainer,
+		ResourceID: "service:web:1",
+		Cause:      "drain",
+		Container:  ctr,
+		Drain:      10 * time.Second,
+	}, "recreate:web:1")
+	plan.addNode(Operation{
+		Type:       OpStopContainer,
+		ResourceID: "service:web:1",
+		Cause:      "replaced by #1",
+		Container:  ctr,
+	}, "recreate:web:1", drain)
+	return plan
+}
+
+func TestExecutePlanDrainBeforeStop(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	events := &capturingEvents{}
+	svc.eventBus = events
+
+	ctr := &container.Summary{ID: "c1", Names: []string{"/test-web-1"}}
+	started := clock.Now()
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "c1", gomock.Any()).
+		DoAndReturn(func(context.Context, string, client.ContainerStopOptions) (client.ContainerStopResult, error) {
+			assert.Check(t, clock.Since(started) == 10*time.Second, "stopped after %s", clock.Since(started))
+			return client.ContainerStopResult{}, nil
+		})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test")).run(t.Context(), drainPlan(ctr))
+	}()
+
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	clock.Advance(9 * time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("drain ended early: %v", err)
+	default:
+	}
+	clock.Advance(time.Second)
+	assert.NilError(t, <-done)
+
+	assert.DeepEqual(t, events.resources, []api.Resource{
+		{ID: "Container test-web-1", Status: api.Working, Text: "Recreate"},
+		{ID: "Container test-web-1", Status: api.Working, Text: api.StatusDraining, Details: "10s"},
+		{ID: "Container test-web-1", Status: api.Done, Text: "Recreated"},
+	})
+}
+
+func TestExecutePlanDrainInterrupted(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	events := &capturingEvents{}
+	svc.eventBus = events
+
+	ctr := &container.Summary{ID: "c1", Names: []string{"/test-web-1"}}
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "c1", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ client.ContainerStopOptions) (client.ContainerStopResult, error) {
+			assert.Check(t, ctx.Err() == nil, "stop must not be canceled by the interrupt")
+			return client.ContainerStopResult{}, nil
+		})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test")).run(ctx, drainPlan(ctr))
+	}()
+
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	assert.DeepEqual(t, events.resources, []api.Resource{
+		{ID: "Container test-web-1", Status: api.Working, Text: "Recreate"},
+		{ID: "Container test-web-1", Status: api.Working, Text: api.StatusDraining, Details: "10s"},
+		{ID: "Container test-web-1", Status: api.Working, Text: api.StatusStopping},
+		{ID: "Container test-web-1", Status: api.Done, Text: api.StatusStopped},
+	})
+}
+
+func renamePlan() *Plan {
+	plan := &Plan{}
+	plan.addNode(Operation{
+		Type:         OpRenameContainer,
+		ResourceID:   "service:web:1",
+		Cause:        "finalize recreate",
+		Name:         "test-web-1",
+		CreateNodeID: 99,
+	}, "")
+	return plan
+}
+
+func TestExecutePlanRenameRetries(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+
+	gomock.InOrder(
+		apiClient.EXPECT().ContainerRename(gomock.Any(), "new1", client.ContainerRenameOptions{NewName: "test-web-1"}).
+			Return(client.ContainerRenameResult{}, errors.New("name already in use")),
+		apiClient.EXPECT().ContainerRename(gomock.Any(), "new1", client.ContainerRenameOptions{NewName: "test-web-1"}).
+			Return(client.ContainerRenameResult{}, nil),
+	)
+
+	exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+	exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "abc_test-web-1"})
+	done := make(chan error, 1)
+	go func() {
+		done <- exec.run(t.Context(), renamePlan())
+	}()
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	clock.Advance(renameRetryDelay)
+	assert.NilError(t, <-done)
+}
+
+func TestExecutePlanRenameFailure(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+
+	apiClient.EXPECT().ContainerRename(gomock.Any(), "new1", gomock.Any()).
+		Return(client.ContainerRenameResult{}, errors.New("name already in use")).
+		Times(renameAttempts)
+
+	exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+	exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "abc_test-web-1"})
+	done := make(chan error, 1)
+	go func() {
+		done <- exec.run(t.Context(), renamePlan())
+	}()
+	for range renameAttempts - 1 {
+		assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+		clock.Advance(renameRetryDelay)
+	}
+	assert.ErrorContains(t, <-done, `container new1 exists under its temporary name "abc_test-web-1" but couldn't be renamed to "test-web-1": name already in use. Run `+"`docker compose up`"+` again to complete the rename`)
+}
+
+func TestExecutePlanContainerCreated(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(mockCtrl)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	tested, err := NewComposeService(cli, WithEventProcessor(noopEventProcessor{}))
+	assert.NilError(t, err)
+	svc := tested.(*composeService)
+
+	web := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	project := &types.Project{Name: "test", Services: types.Services{"web": web}}
+	observed := emptyObservedState("test")
+	observed.Containers["web"] = []ObservedContainer{{
+		ID: "old-id", Name: "test-web-1", Number: 1, State: container.StateRunning, ConfigHash: "stale",
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "stale"},
+	}}
+	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+
+	apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).Return(client.ContainerCreateResult{ID: "new-id"}, nil)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerInspectResult{
+		Container: container.InspectResponse{
+			ID:              "new-id",
+			Name:            "/old-id_test-web-1",
+			Config:          &container.Config{},
+			NetworkSettings: &container.NetworkSettings{},
+		},
+	}, nil)
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerStopResult{}, nil)
+	apiClient.EXPECT().ContainerRemove(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerRemoveResult{}, nil)
+	apiClient.EXPECT().ContainerRename(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerRenameResult{}, nil)
+
+	var created []string
+	exec := svc.newPlanExecutor(project, observed)
+	exec.containerCreated = func(service string, containerID string) {
+		created = append(created, service+"="+containerID)
+	}
+	assert.NilError(t, exec.run(t.Context(), plan))
+	// the replacement is listed, not the container it replaces
+	assert.DeepEqual(t, created, []string{"web=new-id"})
+}
+
+func TestExecutePlanImagePulls(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(mockCtrl)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	tested, err := NewComposeService(cli, WithEventProcessor(noopEventProcessor{}))
+	assert.NilError(t, err)
+	svc := tested.(*composeService)
+
+	project := &types.Project{Name: "test", Services: types.Services{
+		"web": {Name: "web", Image: "nginx", Scale: intPtr(1)},
+		"db":  {Name: "db", Image: "postgres", Scale: intPtr(1)},
+	}}
+	observed := emptyObservedState("test")
+	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+
+	// the image of web is still being pulled
+	webPull := &imagePull{done: make(chan struct{})}
+	pulls := &imagePulls{cancel: func() {}, pulls: map[string]*imagePull{"web": webPull}}
+
+	dbCreated := make(chan struct{})
+	var mu sync.Mutex
+	digests := map[string]string{}
+	apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, opts client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+			service := strings.TrimSuffix(strings.TrimPrefix(opts.Name, "test-"), "-1")
+			mu.Lock()
+			digests[service] = opts.Config.Labels[api.ImageDigestLabel]
+			mu.Unlock()
+			if service == "db" {
+				close(dbCreated)
+			}
+			return client.ContainerCreateResult{ID: service + "-id"}, nil
+		}).Times(2)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, id string, _ client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+			return client.ContainerInspectResult{Container: container.InspectResponse{
+				ID: id, Name: "/" + id, Config: &container.Config{}, NetworkSettings: &container.NetworkSettings{},
+			}}, nil
+		}).Times(2)
+
+	exec := svc.newPlanExecutor(project, observed)
+	exec.imagePulls = pulls
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- exec.run(t.Context(), plan)
+	}()
+
+	// db is created while the image of web is pulled, web once pulled
+	<-dbCreated
+	webPull.id = "sha256:web"
+	close(webPull.done)
+	assert.NilError(t, <-errCh)
+	assert.DeepEqual(t, digests, map[string]string{"db": "", "web": "sha256:web"})
+
+	assert.NilError(t, pulls.awaitAll(t.Context(), project))
+	assert.Equal(t, project.Services["web"].CustomLabels[api.ImageDigestLabel], "sha256:web")
+}
+
+func TestExecutePlanPreserveWritableLayer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(mockCtrl)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	tested, err := NewComposeService(cli, WithEventProcessor(noopEventProcessor{}))
+	assert.NilError(t, err)
+	svc := tested.(*composeService)
+
+	web := types.ServiceConfig{
+		Name: "web", Image: "legacy", Scale: intPtr(1),
+		Command:     types.ShellCommand{"serve"},
+		Environment: types.NewMappingWithEquals([]string{"MODE=prod"}),
+		Extensions:  types.Extensions{"x-update": map[string]any{"preserve_writable_layer": true}},
+	}
+	project := &types.Project{Name: "test", Services: types.Services{"web": web}}
+	observed := emptyObservedState("test")
+	observed.Containers["web"] = []ObservedContainer{{
+		ID: "old-id", Name: "test-web-1", Number: 1, State: container.StateRunning, ConfigHash: "stale",
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "stale"},
+	}}
+	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+
+	// the image is committed with the config of the legacy image, not the
+	// command and user the old container was created with
+	imageConfig := &dockerspec.DockerOCIImageConfig{}
+	imageConfig.Env = []string{"PATH=/bin"}
+	imageConfig.Cmd = []string{"sh"}
+	imageConfig.WorkingDir = "/app"
+	gomock.InOrder(
+		apiClient.EXPECT().ContainerStop(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerStopResult{}, nil),
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerInspectResult{
+			Container: container.InspectResponse{
+				ID:    "old-id",
+				Image: "sha256:legacy",
+				Config: &container.Config{
+					Env:  []string{"PATH=/bin", "MODE=dev"},
+					Cmd:  []string{"serve", "--debug"},
+					User: "app",
+				},
+			},
+		}, nil),
+		apiClient.EXPECT().ImageInspect(gomock.Any(), "sha256:legacy").Return(client.ImageInspectResult{
+			InspectResponse: image.InspectResponse{Config: imageConfig},
+		}, nil),
+		apiClient.EXPECT().ContainerCommit(gomock.Any(), "old-id", client.ContainerCommitOptions{
+			Comment: "writable layer of test-web-1, preserved by x-update",
+			NoPause: true,
+			Config: &container.Config{
+				User:        "0",
+				Env:         []string{"PATH=/bin"},
+				Cmd:         []string{"sh"},
+				WorkingDir:  "/app",
+				StopSignal:  "SIGTERM",
+				Healthcheck: &container.HealthConfig{Test: []string{"NONE"}},
+			},
+		}).Return(client.ContainerCommitResult{ID: "sha256:layer"}, nil),
+		apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, opts client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+				assert.Equal(t, opts.Config.Image, "sha256:layer")
+				assert.Equal(t, opts.Config.Labels[api.ConfigHashLabel], mustServiceHash(t, web))
+				return client.ContainerCreateResult{ID: "new-id"}, nil
+			}),
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerInspectResult{
+			Container: container.InspectResponse{
+				ID:              "new-id",
+				Name:            "/old-id_test-web-1",
+				Config:          &container.Config{},
+				NetworkSettings: &container.NetworkSettings{},
+			},
+		}, nil),
+		apiClient.EXPECT().ContainerRemove(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerRemoveResult{}, nil),
+		apiClient.EXPECT().ContainerRename(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerRenameResult{}, nil),
+	)
+
+	assert.NilError(t, svc.executePlan(t.Context(), project, observed, plan))
+
+	// a variable removed from the service would be set again in the
+	// replacement, the engine adding the environment of the container to
+	// the image committed
+	web.Environment = types.MappingWithEquals{}
+	project.Services["web"] = web
+	plan, err = reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+	gomock.InOrder(
+		apiClient.EXPECT().ContainerStop(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerStopResult{}, nil),
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerInspectResult{
+			Container: container.InspectResponse{
+				ID:     "old-id",
+				Image:  "sha256:legacy",
+				Config: &container.Config{Env: []string{"PATH=/bin", "MODE=dev"}},
+			},
+		}, nil),
+		apiClient.EXPECT().ImageInspect(gomock.Any(), "sha256:legacy").Return(client.ImageInspectResult{
+			InspectResponse: image.InspectResponse{Config: imageConfig},
+		}, nil),
+		// the container is started again rather than committed
+		apiClient.EXPECT().ContainerStart(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerStartResult{}, nil),
+	)
+	err = svc.executePlan(t.Context(), project, observed, plan)
+	assert.ErrorContains(t, err, `service "web": can't preserve the writable layer of test-web-1 as MODE was removed from its environment`)
+}
+
+func TestExecutePlanRecreatePaused(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(mockCtrl)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	events := &capturingEvents{}
+	tested, err := NewComposeService(cli, WithEventProcessor(events))
+	assert.NilError(t, err)
+	svc := tested.(*composeService)
+
+	web := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	project := &types.Project{Name: "test", Services: types.Services{"web": web}}
+	observed := emptyObservedState("test")
+	observed.Containers["web"] = []ObservedContainer{{
+		ID: "old-id", Name: "test-web-1", Number: 1, State: container.StatePaused, ConfigHash: "stale",
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "stale"},
+	}}
+	options := defaultReconcileOptions()
+	options.PreservePaused = true
+	plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.NilError(t, err)
+
+	apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, opts client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+			assert.Equal(t, opts.Config.Labels[api.ReplacePausedLabel], "true")
+			return client.ContainerCreateResult{ID: "new-id"}, nil
+		})
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerInspectResult{
+		Container: container.InspectResponse{
+			ID:              "new-id",
+			Name:            "/old-id_test-web-1",
+			Config:          &container.Config{},
+			NetworkSettings: &container.NetworkSettings{},
+		},
+	}, nil)
+	apiClient.EXPECT().ContainerStop(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerStopResult{}, nil)
+	apiClient.EXPECT().ContainerRemove(gomock.Any(), "old-id", gomock.Any()).Return(client.ContainerRemoveResult{}, nil)
+	apiClient.EXPECT().ContainerRename(gomock.Any(), "new-id", gomock.Any()).Return(client.ContainerRenameResult{}, nil)
+
+	assert.NilError(t, svc.executePlan(t.Context(), project, observed, plan))
+	assert.DeepEqual(t, events.resources[len(events.resources)-1], newEvent("Container test-web-1", api.Done, "Recreated (paused)"))
+}
+
+func TestStartServiceContainerPausesReplacement(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  container.ContainerState
+		labels map[string]string
+		paused bool
+	}{
+		{name: "replacement of a paused container", state: container.StateCreated, labels: map[string]string{api.ReplacePausedLabel: "true"}, paused: true},
+		{name: "restarted replacement", state: container.StateExited, labels: map[string]string{api.ReplacePausedLabel: "true"}},
+		{name: "replacement of a running container", state: container.StateCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			ctr := container.Summary{ID: "c1", Names: []string{"/test-web-1"}, State: tt.state, Labels: tt.labels}
+			apiClient.EXPECT().ContainerStart(gomock.Any(), "c1", gomock.Any()).Return(client.ContainerStartResult{}, nil)
+			if tt.paused {
+				apiClient.EXPECT().ContainerPause(gomock.Any(), "c1", gomock.Any()).Return(client.ContainerPauseResult{}, nil)
+			}
+			assert.NilError(t, svc.startServiceContainer(t.Context(), types.ServiceConfig{Name: "web"}, ctr, nil, 0))
+		})
+	}
+}
+
+func TestExecutePlanConfirmCanary(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	web := types.ServiceConfig{Name: "web"}
+	canaryPlan := func() *Plan {
+		plan := &Plan{}
+		plan.addNode(Operation{
+			Type:         OpConfirmCanary,
+			ResourceID:   "service:web:1",
+			Cause:        "canary",
+			Service:      &web,
+			Number:       1,
+			CreateNodeID: 99,
+		}, "recreate:web:1")
+		return plan
+	}
+	tests := []struct {
+		name    string
+		confirm func(ctx context.Context, canary api.CanaryContainer) (bool, error)
+		timeout time.Duration
+		err     string
+	}{
+		{
+			name: "approved",
+			confirm: func(_ context.Context, canary api.CanaryContainer) (bool, error) {
+				return canary == api.CanaryContainer{
+					Service: "web", Number: 1, ID: "new1", Name: "test-web-1", State: "running", Health: "healthy",
+				}, nil
+			},
+		},
+		{
+			name: "declined",
+			confirm: func(context.Context, api.CanaryContainer) (bool, error) {
+				return false, nil
+			},
+			err: "canary test-web-1 of service web was declined, the other containers of the service aren't recreated",
+		},
+		{
+			name: "failed",
+			confirm: func(context.Context, api.CanaryContainer) (bool, error) {
+				return false, errors.New("error rate too high")
+			},
+			err: "confirming canary test-web-1 of service web: error rate too high",
+		},
+		{
+			name: "timed out",
+			confirm: func(context.Context, api.CanaryContainer) (bool, error) {
+				// ignores its context, as a prompt would
+				<-release
+				return true, nil
+			},
+			timeout: 10 * time.Millisecond,
+			err:     "canary test-web-1 of service web wasn't confirmed within 10ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "new1", gomock.Any()).
+				Return(client.ContainerInspectResult{Container: container.InspectResponse{
+					Name:  "/test-web-1",
+					State: &container.State{Status: container.StateRunning, Health: &container.Health{Status: container.Healthy}},
+				}}, nil)
+
+			exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+			exec.confirmCanary = tt.confirm
+			exec.canaryTimeout = tt.timeout
+			exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "test-web-1"})
+			err := exec.run(t.Context(), canaryPlan())
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestExecutePlanCanaryNotHealthy(t *testing.T) {
+	web := types.ServiceConfig{Name: "web"}
+	tests := []struct {
+		name   string
+		health container.HealthStatus
+		err    string
+	}{
+		{
+			name:   "unhealthy",
+			health: container.Unhealthy,
+			err:    "canary of service web didn't get healthy, the other containers of the service aren't recreated: container test-web-1 is unhealthy",
+		},
+		{
+			name:   "starting past the canary timeout",
+			health: container.Starting,
+			err:    "canary of service web didn't get healthy, the other containers of the service aren't recreated: container new1 of service web isn't healthy after 10ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "new1", gomock.Any()).
+				Return(client.ContainerInspectResult{Container: container.InspectResponse{
+					Name:   "/test-web-1",
+					State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: tt.health}},
+					Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+				}}, nil).AnyTimes()
+
+			plan := &Plan{}
+			wait := plan.addNode(Operation{
+				Type:         OpWaitContainer,
+				ResourceID:   "service:web:1",
+				Cause:        "canary",
+				Service:      &web,
+				CreateNodeID: 99,
+				Canary:       true,
+			}, "recreate:web:1")
+			plan.addNode(Operation{
+				Type:         OpConfirmCanary,
+				ResourceID:   "service:web:1",
+				Cause:        "canary",
+				Service:      &web,
+				Number:       1,
+				CreateNodeID: 99,
+			}, "recreate:web:1", wait)
+
+			exec := svc.newPlanExecutor(&types.Project{Name: "test"}, emptyObservedState("test"))
+			exec.confirmCanary = func(context.Context, api.CanaryContainer) (bool, error) {
+				t.Error("canary not healthy must not be confirmed")
+				return true, nil
+			}
+			exec.canaryTimeout = 10 * time.Millisecond
+			exec.pctx.set(99, operationResult{ContainerID: "new1", ContainerName: "test-web-1"})
+			assert.Error(t, exec.run(t.Context(), plan), tt.err)
+		})
+	}
+}
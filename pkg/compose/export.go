@@ -32,7 +32,7 @@ import (
 func (s *composeService) Export(ctx context.Context, projectName string, options api.ExportOptions) error {
 	return Run(ctx, func(ctx context.Context) error {
 		return s.export(ctx, projectName, options)
-	}, "export", s.events)
+	}, "export", s.events(ctx))
 }
 
 func (s *composeService) export(ctx context.Context, projectName string, options api.ExportOptions) error {
@@ -52,7 +52,7 @@ func (s *composeService) export(ctx context.Context, projectName string, options
 	}
 
 	name := getCanonicalContainerName(container)
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     name,
 		Text:   api.StatusExporting,
 		Status: api.Working,
@@ -65,7 +65,7 @@ func (s *composeService) export(ctx context.Context, projectName string, options
 
 	defer func() {
 		if err := responseBody.Close(); err != nil {
-			s.events.On(errorEventf(name, "Failed to close response body: %s", err.Error()))
+			s.events(ctx).On(errorEventf(name, "Failed to close response body: %s", err.Error()))
 		}
 	}()
 
@@ -85,7 +85,7 @@ func (s *composeService) export(ctx context.Context, projectName string, options
 		}
 	}
 
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     name,
 		Text:   api.StatusExported,
 		Status: api.Done,
@@ -0,0 +1,158 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/utils"
+)
+
+// redacted replaces the value of secrets in exported container specs
+const redacted = "<redacted>"
+
+func (s *composeService) ExportSpecs(ctx context.Context, project *types.Project, options api.ExportSpecsOptions) ([]api.ContainerSpec, error) {
+	// resolve image digests and image volumes from the local images, as create
+	// does once they have been pulled or built
+	images, err := s.getLocalImagesDigests(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	for name, service := range project.Services {
+		resolveImageVolumes(&service, images, project.Name)
+		project.Services[name] = service
+	}
+
+	containersByService, err := s.getContainersByService(ctx, project.Name)
+	if err != nil {
+		return nil, err
+	}
+	references := exportReferences(project, containersByService)
+
+	services := options.Services
+	if len(services) == 0 {
+		services = slices.Sorted(maps.Keys(project.Services))
+	}
+	var specs []api.ContainerSpec
+	for _, name := range services {
+		service, err := project.GetService(name)
+		if err != nil {
+			return nil, err
+		}
+		if service.Provider != nil {
+			continue
+		}
+		service.VolumesFrom = slices.Clone(service.VolumesFrom)
+		if err := resolveServiceReferences(&service, references); err != nil {
+			return nil, err
+		}
+		for number := 1; number <= service.GetScale(); number++ {
+			spec, err := s.exportSpec(ctx, project, service, number, containersByService[name])
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+func (s *composeService) exportSpec(ctx context.Context, project *types.Project, service types.ServiceConfig, number int, existing Containers) (api.ContainerSpec, error) {
+	// a replacement container inherits the anonymous volumes of the one it replaces
+	var inherit *container.Summary
+	for i, ctr := range existing {
+		if ctr.Labels[api.ContainerNumberLabel] == strconv.Itoa(number) {
+			inherit = &existing[i]
+			break
+		}
+	}
+	cfgs, err := s.getCreateConfigs(ctx, project, service, number, inherit, NewCreateOptions(
+		withNetworkAliases(true),
+		WithContainerLabels(mergeLabels(service.Labels, service.CustomLabels)),
+	))
+	if err != nil {
+		return api.ContainerSpec{}, err
+	}
+	cfgs.Container.Env = redactSecrets(project, cfgs.Container.Env)
+	// the environment is built from a map, sort it for the output to be stable
+	slices.Sort(cfgs.Container.Env)
+	return api.ContainerSpec{
+		Service:          service.Name,
+		Name:             getContainerName(project.Name, service, number),
+		Number:           number,
+		Config:           cfgs.Container,
+		HostConfig:       cfgs.Host,
+		NetworkingConfig: cfgs.Network,
+	}, nil
+}
+
+// exportReferences completes the live containers with placeholders for the
+// services not running yet, named after the first container up would create
+// for them, so references to other services can be resolved in all cases.
+func exportReferences(project *types.Project, containersByService map[string]Containers) map[string]Containers {
+	references := maps.Clone(containersByService)
+	for name, service := range project.Services {
+		if len(references[name]) > 0 {
+			continue
+		}
+		placeholder := getContainerName(project.Name, service, 1)
+		references[name] = Containers{{
+			ID:     placeholder,
+			Names:  []string{"/" + placeholder},
+			Labels: map[string]string{api.ServiceLabel: name, api.ContainerNumberLabel: "1"},
+		}}
+	}
+	return references
+}
+
+// redactSecrets hides the environment variables set with the value of a
+// project secret, or named after the variable a secret is read from.
+func redactSecrets(project *types.Project, env []string) []string {
+	names := utils.Set[string]{}
+	values := utils.Set[string]{}
+	for _, secret := range project.Secrets {
+		if secret.Content != "" {
+			values.Add(secret.Content)
+		}
+		if secret.Environment != "" {
+			names.Add(secret.Environment)
+			if value := project.Environment[secret.Environment]; value != "" {
+				values.Add(value)
+			}
+		}
+	}
+	if len(names) == 0 && len(values) == 0 {
+		return env
+	}
+	result := make([]string, len(env))
+	for i, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		if names.Has(name) || values.Has(value) {
+			e = name + "=" + redacted
+		}
+		result[i] = e
+	}
+	return result
+}
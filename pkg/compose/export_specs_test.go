@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestExportSpecs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	apiClient.EXPECT().ImageInspect(gomock.Any(), gomock.Any()).Return(client.ImageInspectResult{}, notFoundError{}).AnyTimes()
+	// the data service isn't running, so web refers to the container up would create for it
+	apiClient.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(client.ContainerListResult{}, nil).AnyTimes()
+
+	t.Setenv("API_TOKEN", "s3cr3t")
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+	project, err := tested.LoadProject(t.Context(), api.ProjectLoadOptions{
+		ConfigPaths: []string{filepath.Join("testdata", "export-specs", "compose.yaml")},
+	})
+	assert.NilError(t, err)
+
+	specs, err := tested.ExportSpecs(t.Context(), project, api.ExportSpecsOptions{Services: []string{"web"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(specs), 2)
+	assert.Equal(t, specs[0].HostConfig.VolumesFrom[0], "demo-data-1")
+
+	for _, spec := range specs {
+		// make the golden file independent of the checkout location
+		spec.Config.Labels[api.WorkingDirLabel] = "."
+		spec.Config.Labels[api.ConfigFilesLabel] = "compose.yaml"
+	}
+	b, err := json.MarshalIndent(specs, "", "  ")
+	assert.NilError(t, err)
+	golden.Assert(t, string(b), filepath.Join("export-specs", "web.golden"))
+}
+
+func TestExportSpecsCreateOptions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	apiClient.EXPECT().DaemonHost().Return("").AnyTimes()
+	apiClient.EXPECT().Ping(gomock.Any(), gomock.Any()).Return(client.PingResult{APIVersion: "1.44"}, nil).AnyTimes()
+	apiClient.EXPECT().ClientVersion().Return("1.44").AnyTimes()
+	apiClient.EXPECT().ImageInspect(gomock.Any(), gomock.Any()).Return(client.ImageInspectResult{}, notFoundError{}).AnyTimes()
+	apiClient.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(client.ContainerListResult{}, nil).AnyTimes()
+
+	tested, err := NewComposeService(cli, WithCreateOptions(WithContainerLabels(types.Labels{"com.example.team": "web"})))
+	assert.NilError(t, err)
+	project := &types.Project{
+		Name:     "demo",
+		Services: types.Services{"web": {Name: "web", Image: "nginx", Scale: intPtr(1)}},
+	}
+
+	specs, err := tested.ExportSpecs(t.Context(), project, api.ExportSpecsOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(specs), 1)
+	assert.Equal(t, specs[0].Config.Labels["com.example.team"], "web")
+}
+
+func TestRedactSecrets(t *testing.T) {
+	project := &types.Project{
+		Environment: types.Mapping{"DB_PASSWORD": "hunter2"},
+		Secrets: types.Secrets{
+			"db":  {Environment: "DB_PASSWORD"},
+			"key": {Content: "inline"},
+		},
+	}
+	env := redactSecrets(project, []string{"DB_PASSWORD=other", "PASS=hunter2", "KEY=inline", "LOG_LEVEL=debug"})
+	assert.DeepEqual(t, env, []string{"DB_PASSWORD=<redacted>", "PASS=<redacted>", "KEY=<redacted>", "LOG_LEVEL=debug"})
+}
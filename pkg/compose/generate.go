@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/mount"
 	"github.com/moby/moby/api/types/network"
@@ -62,15 +63,20 @@ func (s *composeService) Generate(ctx context.Context, options api.GenerateOptio
 		return nil, fmt.Errorf("no container(s) found with the following name(s): %s", strings.Join(options.Containers, ","))
 	}
 
-	return s.createProjectFromContainers(containers, options.ProjectName)
+	return s.createProjectFromContainers(ctx, containers, options.ProjectName)
 }
 
-func (s *composeService) createProjectFromContainers(containers []container.Summary, projectName string) (*types.Project, error) {
+// GenerateNotesExtension is the project extension in which Generate records,
+// per service, the container settings that have no Compose equivalent.
+const GenerateNotesExtension = "x-generate-notes"
+
+func (s *composeService) createProjectFromContainers(ctx context.Context, containers []container.Summary, projectName string) (*types.Project, error) {
 	project := &types.Project{}
 	services := types.Services{}
 	networks := types.Networks{}
 	volumes := types.Volumes{}
 	secrets := types.Secrets{}
+	notes := map[string][]string{}
 
 	if projectName != "" {
 		project.Name = projectName
@@ -92,29 +98,60 @@ func (s *composeService) createProjectFromContainers(containers []container.Summ
 		}
 		service.Scale = increment(service.Scale)
 
-		inspect, err := s.apiClient().ContainerInspect(context.Background(), c.ID, client.ContainerInspectOptions{})
+		inspect, err := s.apiClient().ContainerInspect(ctx, c.ID, client.ContainerInspectOptions{})
 		if err != nil {
 			services[serviceLabel] = service
 			continue
 		}
-		s.extractComposeConfiguration(&service, inspect.Container, volumes, secrets, networks)
+		// image defaults (env, command, healthcheck) are left out of the
+		// generated service; without them every setting is kept
+		var imageConfig *dockerspec.DockerOCIImageConfig
+		if img, err := s.apiClient().ImageInspect(ctx, inspect.Container.Image); err == nil {
+			imageConfig = img.Config
+		}
+		s.extractComposeConfiguration(ctx, &service, inspect.Container, imageConfig, volumes, secrets, networks)
 		service.Labels = cleanDockerPreviousLabels(service.Labels)
 		services[serviceLabel] = service
+		if unsupported := unsupportedSettings(inspect.Container); len(unsupported) > 0 {
+			notes[serviceLabel] = append(notes[serviceLabel], unsupported...)
+		}
 	}
 
 	project.Services = services
 	project.Networks = networks
 	project.Volumes = volumes
 	project.Secrets = secrets
+	if len(notes) > 0 {
+		project.Extensions = types.Extensions{GenerateNotesExtension: notes}
+	}
 	return project, nil
 }
 
-func (s *composeService) extractComposeConfiguration(service *types.ServiceConfig, inspect container.InspectResponse, volumes types.Volumes, secrets types.Secrets, networks types.Networks) {
-	service.Environment = types.NewMappingWithEquals(inspect.Config.Env)
-	if inspect.Config.Healthcheck != nil {
+func (s *composeService) extractComposeConfiguration(ctx context.Context, service *types.ServiceConfig, inspect container.InspectResponse, imageConfig *dockerspec.DockerOCIImageConfig,
+	volumes types.Volumes, secrets types.Secrets, networks types.Networks,
+) {
+	if imageConfig == nil {
+		imageConfig = &dockerspec.DockerOCIImageConfig{}
+	}
+	service.Environment = types.NewMappingWithEquals(envDiff(inspect.Config.Env, imageConfig.Env))
+	if !slices.Equal(inspect.Config.Entrypoint, imageConfig.Entrypoint) {
+		service.Entrypoint = types.ShellCommand(inspect.Config.Entrypoint)
+	}
+	if !slices.Equal(inspect.Config.Cmd, imageConfig.Cmd) {
+		service.Command = types.ShellCommand(inspect.Config.Cmd)
+	}
+	if inspect.Config.Healthcheck != nil &&
+		(imageConfig.Healthcheck == nil || !slices.Equal(inspect.Config.Healthcheck.Test, imageConfig.Healthcheck.Test)) {
 		healthConfig := inspect.Config.Healthcheck
 		service.HealthCheck = s.toComposeHealthCheck(healthConfig)
 	}
+	if inspect.HostConfig != nil {
+		service.Restart = toComposeRestart(inspect.HostConfig.RestartPolicy)
+		switch inspect.HostConfig.NetworkMode {
+		case network.NetworkHost, network.NetworkNone:
+			service.NetworkMode = string(inspect.HostConfig.NetworkMode)
+		}
+	}
 	if len(inspect.Mounts) > 0 {
 		detectedVolumes, volumeConfigs, detectedSecrets, secretsConfigs := s.toComposeVolumes(inspect.Mounts)
 		service.Volumes = append(service.Volumes, volumeConfigs...)
@@ -122,20 +159,23 @@ func (s *composeService) extractComposeConfiguration(service *types.ServiceConfi
 		maps.Copy(volumes, detectedVolumes)
 		maps.Copy(secrets, detectedSecrets)
 	}
-	if len(inspect.NetworkSettings.Networks) > 0 {
-		detectedNetworks, networkConfigs := s.toComposeNetwork(inspect.NetworkSettings.Networks)
+	if service.NetworkMode == "" && inspect.NetworkSettings != nil && len(inspect.NetworkSettings.Networks) > 0 {
+		detectedNetworks, networkConfigs := s.toComposeNetwork(ctx, inspect.NetworkSettings.Networks)
 		service.Networks = networkConfigs
 		maps.Copy(networks, detectedNetworks)
 	}
-	if len(inspect.HostConfig.PortBindings) > 0 {
+	if inspect.HostConfig != nil && len(inspect.HostConfig.PortBindings) > 0 {
 		for key, portBindings := range inspect.HostConfig.PortBindings {
 			for _, portBinding := range portBindings {
-				service.Ports = append(service.Ports, types.ServicePortConfig{
+				port := types.ServicePortConfig{
 					Target:    uint32(key.Num()),
 					Published: portBinding.HostPort,
 					Protocol:  string(key.Proto()),
-					HostIP:    portBinding.HostIP.String(),
-				})
+				}
+				if portBinding.HostIP.IsValid() {
+					port.HostIP = portBinding.HostIP.String()
+				}
+				service.Ports = append(service.Ports, port)
 			}
 		}
 	}
@@ -212,12 +252,12 @@ func (s *composeService) toComposeVolumes(volumes []container.MountPoint) (map[s
 	return volumeConfigs, serviceVolumeConfigs, secretConfigs, serviceSecretConfigs
 }
 
-func (s *composeService) toComposeNetwork(networks map[string]*network.EndpointSettings) (map[string]types.NetworkConfig, map[string]*types.ServiceNetworkConfig) {
+func (s *composeService) toComposeNetwork(ctx context.Context, networks map[string]*network.EndpointSettings) (map[string]types.NetworkConfig, map[string]*types.ServiceNetworkConfig) {
 	networkConfigs := make(map[string]types.NetworkConfig)
 	serviceNetworkConfigs := make(map[string]*types.ServiceNetworkConfig)
 
 	for name, net := range networks {
-		inspect, err := s.apiClient().NetworkInspect(context.Background(), name, client.NetworkInspectOptions{})
+		inspect, err := s.apiClient().NetworkInspect(ctx, name, client.NetworkInspectOptions{})
 		if err != nil {
 			networkConfigs[name] = types.NetworkConfig{}
 		} else {
@@ -232,6 +272,50 @@ func (s *composeService) toComposeNetwork(networks map[string]*network.EndpointS
 	return networkConfigs, serviceNetworkConfigs
 }
 
+// envDiff returns the container environment without the entries inherited
+// unchanged from the image.
+func envDiff(env, imageEnv []string) []string {
+	var diff []string
+	for _, e := range env {
+		if !slices.Contains(imageEnv, e) {
+			diff = append(diff, e)
+		}
+	}
+	return diff
+}
+
+func toComposeRestart(policy container.RestartPolicy) string {
+	switch policy.Name {
+	case "", container.RestartPolicyDisabled:
+		return ""
+	case container.RestartPolicyOnFailure:
+		if policy.MaximumRetryCount > 0 {
+			return fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+		}
+	}
+	return string(policy.Name)
+}
+
+// unsupportedSettings lists the container settings the generated service can't
+// express, so they can be reported to the user instead of silently dropped.
+func unsupportedSettings(inspect container.InspectResponse) []string {
+	var unsupported []string
+	if inspect.HostConfig != nil {
+		if inspect.HostConfig.AutoRemove {
+			unsupported = append(unsupported, "auto-remove (--rm)")
+		}
+		if len(inspect.HostConfig.Links) > 0 {
+			unsupported = append(unsupported, fmt.Sprintf("legacy links %s", strings.Join(inspect.HostConfig.Links, ", ")))
+		}
+	}
+	for _, m := range inspect.Mounts {
+		if m.Type != mount.TypeVolume && m.Type != mount.TypeBind {
+			unsupported = append(unsupported, fmt.Sprintf("%s mount on %s", m.Type, m.Destination))
+		}
+	}
+	return unsupported
+}
+
 func cleanDockerPreviousLabels(labels types.Labels) types.Labels {
 	cleanedLabels := types.Labels{}
 	for key, value := range labels {
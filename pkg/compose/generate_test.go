@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/image"
+	"github.com/moby/moby/api/types/network"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func loadJSONFixture(t *testing.T, path string, v any) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(b, v))
+}
+
+func TestCreateProjectFromContainers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	var ctr container.InspectResponse
+	loadJSONFixture(t, "testdata/generate/container-inspect.json", &ctr)
+	var img image.InspectResponse
+	loadJSONFixture(t, "testdata/generate/image-inspect.json", &img)
+
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), ctr.ID, gomock.Any()).
+		Return(client.ContainerInspectResult{Container: ctr}, nil)
+	apiClient.EXPECT().ImageInspect(gomock.Any(), ctr.Image).
+		Return(client.ImageInspectResult{InspectResponse: img}, nil)
+	apiClient.EXPECT().NetworkInspect(gomock.Any(), "frontend", gomock.Any()).
+		Return(client.NetworkInspectResult{Network: network.Inspect{Network: network.Network{Internal: true}}}, nil)
+
+	project, err := tested.(*composeService).createProjectFromContainers(t.Context(), []container.Summary{{
+		ID:    ctr.ID,
+		Names: []string{"/web"},
+		Image: "nginx:1.27",
+	}}, "demo")
+	assert.NilError(t, err)
+
+	assert.Equal(t, project.Name, "demo")
+	service := project.Services["web"]
+	assert.Equal(t, service.Image, "nginx:1.27")
+	assert.Equal(t, service.Restart, "on-failure:3")
+	assert.DeepEqual(t, service.Environment, types.NewMappingWithEquals([]string{"APP_ENV=production"}))
+	assert.DeepEqual(t, service.Command, types.ShellCommand{"nginx", "-g", "daemon off;", "-c", "/etc/nginx/custom.conf"})
+	assert.Check(t, service.Entrypoint == nil)
+	assert.DeepEqual(t, service.Ports, []types.ServicePortConfig{
+		{Target: 80, Published: "8080", Protocol: "tcp", HostIP: "127.0.0.1"},
+	})
+	assert.DeepEqual(t, service.Volumes, []types.ServiceVolumeConfig{
+		{Type: "volume", Source: "web-data", Target: "/data"},
+		{Type: "bind", Source: "/srv/nginx/conf", Target: "/etc/nginx/conf.d", ReadOnly: true},
+	})
+	assert.DeepEqual(t, project.Volumes, types.Volumes{"web-data": {}})
+	assert.DeepEqual(t, service.Networks, map[string]*types.ServiceNetworkConfig{"frontend": {Aliases: []string{"web"}}})
+	assert.DeepEqual(t, project.Networks, types.Networks{"frontend": {Internal: true}})
+	assert.DeepEqual(t, project.Extensions[GenerateNotesExtension], map[string][]string{
+		"web": {"auto-remove (--rm)", "tmpfs mount on /cache"},
+	})
+}
+
+func TestCreateProjectFromContainersWithoutImage(t *testing.T) {
+	// the image may have been removed since the container was created: all
+	// settings are kept as no default can be subtracted
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	ctr := container.InspectResponse{
+		ID:    "abc",
+		Image: "sha256:gone",
+		Config: &container.Config{
+			Env: []string{"PATH=/bin", "FOO=bar"},
+			Cmd: []string{"sleep", "infinity"},
+		},
+		HostConfig: &container.HostConfig{NetworkMode: network.NetworkHost},
+	}
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "abc", gomock.Any()).
+		Return(client.ContainerInspectResult{Container: ctr}, nil)
+	apiClient.EXPECT().ImageInspect(gomock.Any(), "sha256:gone").
+		Return(client.ImageInspectResult{}, notFoundError{})
+
+	project, err := tested.(*composeService).createProjectFromContainers(t.Context(), []container.Summary{{
+		ID:    "abc",
+		Names: []string{"/sleeper"},
+		Image: "alpine",
+	}}, "")
+	assert.NilError(t, err)
+
+	service := project.Services["sleeper"]
+	assert.DeepEqual(t, service.Environment, types.NewMappingWithEquals([]string{"PATH=/bin", "FOO=bar"}))
+	assert.DeepEqual(t, service.Command, types.ShellCommand{"sleep", "infinity"})
+	assert.Equal(t, service.NetworkMode, "host")
+	assert.Check(t, service.Networks == nil)
+	assert.Check(t, project.Extensions == nil)
+}
@@ -44,6 +44,14 @@ func ServiceHash(o types.ServiceConfig) (string, error) {
 	return digest.SHA256.FromBytes(bytes).Encoded(), nil
 }
 
+// serviceHashExcludingHealthcheck computes the configuration hash for a
+// service regardless of its healthcheck, so a change to the healthcheck alone
+// can be told apart from other configuration changes.
+func serviceHashExcludingHealthcheck(o types.ServiceConfig) (string, error) {
+	o.HealthCheck = nil
+	return ServiceHash(o)
+}
+
 // NetworkHash computes the configuration hash for a network.
 func NetworkHash(o *types.NetworkConfig) (string, error) {
 	bytes, err := json.Marshal(o)
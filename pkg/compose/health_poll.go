@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/client"
+)
+
+const (
+	// dependencyPollInterval is how often waitDependencies checks the
+	// dependencies it waits for
+	dependencyPollInterval = 500 * time.Millisecond
+	// healthPollMargin is how long after the expected time of a probe the
+	// health of a container is checked, for the engine to run the probe and
+	// record its result
+	healthPollMargin = 50 * time.Millisecond
+)
+
+// healthPollCadence decides when waitDependencies checks the health of a
+// dependency. During the start_period of a healthcheck declaring a
+// start_interval, the engine probes the container every start_interval since
+// it started: the health is then checked right after each expected probe, so
+// a dependency becoming healthy is noticed without waiting for the next tick
+// of the fixed poll interval, which still bounds the delay between checks as
+// probes drift by the time they take to run.
+type healthPollCadence struct {
+	started       time.Time
+	startPeriod   time.Duration
+	startInterval time.Duration
+}
+
+// newHealthPollCadence returns the cadence to check the health of containers
+// with healthcheck check. startIntervalSupported is false when the engine
+// ignores start_interval.
+func newHealthPollCadence(check *types.HealthCheckConfig, startIntervalSupported bool) healthPollCadence {
+	if check == nil || check.Disable || check.StartPeriod == nil || check.StartInterval == nil || !startIntervalSupported {
+		return healthPollCadence{}
+	}
+	return healthPollCadence{
+		startPeriod:   time.Duration(*check.StartPeriod),
+		startInterval: time.Duration(*check.StartInterval),
+	}
+}
+
+// aligned reports whether the cadence follows the probes of the engine, and
+// needs to know when the containers started
+func (c healthPollCadence) aligned() bool {
+	return c.startInterval > 0
+}
+
+// next returns how long to wait, at now, before checking the health again
+func (c healthPollCadence) next(now time.Time) time.Duration {
+	elapsed := now.Sub(c.started)
+	if c.startInterval <= 0 || elapsed < 0 || elapsed >= c.startPeriod {
+		return dependencyPollInterval
+	}
+	// first expected probe the check hasn't followed yet
+	probe := (elapsed-healthPollMargin)/c.startInterval + 1
+	delay := time.Duration(probe)*c.startInterval + healthPollMargin - elapsed
+	return min(delay, dependencyPollInterval)
+}
+
+// lastStarted returns when the last of containers started, or zero when it
+// can't be told.
+func (s *composeService) lastStarted(ctx context.Context, containers Containers) time.Time {
+	var last time.Time
+	for _, c := range containers {
+		res, err := s.apiClient().ContainerInspect(ctx, c.ID, client.ContainerInspectOptions{})
+		if err != nil || res.Container.State == nil {
+			return time.Time{}
+		}
+		started, err := time.Parse(time.RFC3339Nano, res.Container.State.StartedAt)
+		if err != nil {
+			return time.Time{}
+		}
+		if started.After(last) {
+			last = started
+		}
+	}
+	return last
+}
@@ -0,0 +1,131 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestHealthPollCadence(t *testing.T) {
+	duration := func(d time.Duration) *types.Duration {
+		v := types.Duration(d)
+		return &v
+	}
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	check := &types.HealthCheckConfig{
+		StartPeriod:   duration(10 * time.Second),
+		StartInterval: duration(200 * time.Millisecond),
+	}
+	slowCheck := &types.HealthCheckConfig{
+		StartPeriod:   duration(10 * time.Second),
+		StartInterval: duration(2 * time.Second),
+	}
+
+	tests := []struct {
+		name      string
+		check     *types.HealthCheckConfig
+		supported bool
+		elapsed   time.Duration
+		expected  time.Duration
+	}{
+		{
+			name:     "no healthcheck",
+			elapsed:  time.Second,
+			expected: dependencyPollInterval,
+		},
+		{
+			name:      "no start_interval",
+			check:     &types.HealthCheckConfig{StartPeriod: duration(10 * time.Second)},
+			supported: true,
+			elapsed:   time.Second,
+			expected:  dependencyPollInterval,
+		},
+		{
+			name:     "start_interval ignored by the engine",
+			check:    check,
+			elapsed:  time.Second,
+			expected: dependencyPollInterval,
+		},
+		{
+			name:      "before the first probe",
+			check:     check,
+			supported: true,
+			elapsed:   0,
+			expected:  250 * time.Millisecond,
+		},
+		{
+			name:      "between probes",
+			check:     check,
+			supported: true,
+			elapsed:   1130 * time.Millisecond,
+			expected:  120 * time.Millisecond,
+		},
+		{
+			name:      "within the margin of a probe",
+			check:     check,
+			supported: true,
+			elapsed:   1220 * time.Millisecond,
+			expected:  30 * time.Millisecond,
+		},
+		{
+			name:      "right after a probe",
+			check:     check,
+			supported: true,
+			elapsed:   1250 * time.Millisecond,
+			expected:  200 * time.Millisecond,
+		},
+		{
+			name:      "bounded by the poll interval",
+			check:     slowCheck,
+			supported: true,
+			elapsed:   time.Second,
+			expected:  dependencyPollInterval,
+		},
+		{
+			name:      "close to a slow probe",
+			check:     slowCheck,
+			supported: true,
+			elapsed:   3800 * time.Millisecond,
+			expected:  250 * time.Millisecond,
+		},
+		{
+			name:      "after start_period",
+			check:     check,
+			supported: true,
+			elapsed:   11 * time.Second,
+			expected:  dependencyPollInterval,
+		},
+		{
+			name:      "clock skew",
+			check:     check,
+			supported: true,
+			elapsed:   -time.Second,
+			expected:  dependencyPollInterval,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cadence := newHealthPollCadence(tt.check, tt.supported)
+			cadence.started = started
+			assert.Equal(t, cadence.next(started.Add(tt.elapsed)), tt.expected)
+		})
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/moby/moby/api/pkg/stdcopy"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
 
 	"github.com/docker/compose/v5/pkg/api"
 	"github.com/docker/compose/v5/pkg/utils"
@@ -96,6 +97,23 @@ func (s *composeService) runHook(ctx context.Context, ctr container.Summary, ser
 	return nil
 }
 
+// runRecreateHooks runs the x-update hooks of a service being recreated in
+// ctr. A failing hook fails the recreate when it sets abort_on_failure,
+// otherwise it is only reported.
+func (s *composeService) runRecreateHooks(ctx context.Context, ctr container.Summary, service types.ServiceConfig, stage string, hooks []recreateHook, listener api.ContainerEventListener) error {
+	for _, hook := range hooks {
+		err := s.runHook(ctx, ctr, service, hook.serviceHook(), listener)
+		if err == nil {
+			continue
+		}
+		if hook.AbortOnFailure {
+			return fmt.Errorf("service %q: %s hook failed: %w", service.Name, stage, err)
+		}
+		logrus.Warnf("service %q: %s hook failed: %v", service.Name, stage, err)
+	}
+	return nil
+}
+
 func (s *composeService) runWaitExec(ctx context.Context, execID string, service types.ServiceConfig, listener api.ContainerEventListener) error {
 	_, err := s.apiClient().ExecStart(ctx, execID, client.ExecStartOptions{
 		Detach: listener == nil,
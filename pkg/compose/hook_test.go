@@ -28,6 +28,7 @@ import (
 	"github.com/docker/cli/cli/streams"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 
@@ -114,3 +115,43 @@ func TestRunHook_ConsoleSize(t *testing.T) {
 		})
 	}
 }
+
+func TestRunRecreateHooks(t *testing.T) {
+	tests := []struct {
+		name  string
+		abort bool
+		err   string
+		warn  string
+	}{
+		{
+			name: "warn on failure",
+			warn: `service "web": pre_recreate hook failed: web hook exited with status 1`,
+		},
+		{
+			name:  "abort on failure",
+			abort: true,
+			err:   `service "web": pre_recreate hook failed: web hook exited with status 1`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			apiClient.EXPECT().ExecCreate(gomock.Any(), "c1", client.ExecCreateOptions{Cmd: []string{"/snapshot.sh"}}).
+				Return(client.ExecCreateResult{ID: "exec1"}, nil)
+			apiClient.EXPECT().ExecStart(gomock.Any(), "exec1", client.ExecStartOptions{Detach: true}).
+				Return(client.ExecStartResult{}, nil)
+			apiClient.EXPECT().ExecInspect(gomock.Any(), "exec1", gomock.Any()).
+				Return(client.ExecInspectResult{ExitCode: 1}, nil)
+			logs := logrustest.NewGlobal()
+
+			hooks := []recreateHook{{Command: []string{"/snapshot.sh"}, AbortOnFailure: tt.abort}}
+			err := svc.runRecreateHooks(t.Context(), container.Summary{ID: "c1"}, types.ServiceConfig{Name: "web"}, "pre_recreate", hooks, nil)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, logs.LastEntry().Message, tt.warn)
+		})
+	}
+}
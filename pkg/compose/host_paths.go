@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/remote"
+)
+
+// isLocalDaemon reports whether the engine runs on this host, so the sources
+// of bind mounts can be checked on the local filesystem.
+func isLocalDaemon(host string) bool {
+	return strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://")
+}
+
+// checkHostPaths checks the sources of the service bind mounts before the
+// container is created. A missing source is only an error for a bind declared
+// with create_host_path: false, the engine creating the others as an empty
+// directory owned by root, which is warned about as it may hide a typo in the
+// path. With createHostPaths set, missing sources are created as directories
+// owned by the user running compose instead.
+func (s *composeService) checkHostPaths(ctx context.Context, service types.ServiceConfig, createHostPaths bool) error {
+	for _, volume := range service.Volumes {
+		if volume.Type != types.VolumeTypeBind || !filepath.IsAbs(volume.Source) {
+			continue
+		}
+		// sources inside a remote resource are managed by compose, and
+		// rejected or rebased by checkRemoteRelativeBinds when loading
+		if remote.InCacheDir(volume.Source) {
+			continue
+		}
+		// create_host_path defaults to true, the source may also only exist
+		// where the engine runs, as in the VM of Docker Desktop
+		noCreate := volume.Bind != nil && !bool(volume.Bind.CreateHostPath)
+		_, err := os.Stat(volume.Source)
+		switch {
+		case err == nil:
+			continue
+		case !errors.Is(err, fs.ErrNotExist):
+			if !createHostPaths && !noCreate {
+				logrus.Warnf("service %q: bind mount source %q can't be checked: %v", service.Name, volume.Source, err)
+				continue
+			}
+			return fmt.Errorf("service %q: bind mount source %q can't be used: %w", service.Name, volume.Source, err)
+		case createHostPaths:
+			if err := createHostPath(volume.Source); err != nil {
+				return fmt.Errorf("service %q: failed to create bind mount source %q: %w", service.Name, volume.Source, err)
+			}
+			s.events(ctx).On(createdEvent("Directory " + volume.Source))
+		case noCreate:
+			return fmt.Errorf("service %q: bind mount source %q does not exist and create_host_path is false; fix the path, or use --create-host-paths to create it", service.Name, volume.Source)
+		default:
+			logrus.Warnf("service %q: bind mount source %q does not exist, the engine creates it as an empty directory", service.Name, volume.Source)
+		}
+	}
+	return nil
+}
+
+// createHostPath creates a missing bind mount source. When compose runs with
+// sudo, the directory is owned by the invoking user rather than root.
+func createHostPath(path string) error {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return err
+	}
+	uid, gid := os.Getenv("SUDO_UID"), os.Getenv("SUDO_GID")
+	if uid == "" || gid == "" {
+		return nil
+	}
+	u, err := strconv.Atoi(uid)
+	if err != nil {
+		return fmt.Errorf("invalid SUDO_UID %q: %w", uid, err)
+	}
+	g, err := strconv.Atoi(gid)
+	if err != nil {
+		return fmt.Errorf("invalid SUDO_GID %q: %w", gid, err)
+	}
+	return os.Chown(path, u, g)
+}
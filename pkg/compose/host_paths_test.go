@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestCheckHostPaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+	t.Setenv("SUDO_UID", "")
+	t.Setenv("SUDO_GID", "")
+	file := filepath.Join(dir, "config.yaml")
+	assert.NilError(t, os.WriteFile(file, []byte("key: value"), 0o600))
+
+	withBind := func(source string) types.ServiceConfig {
+		return types.ServiceConfig{
+			Name: "web",
+			Volumes: []types.ServiceVolumeConfig{
+				{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"},
+				{Type: types.VolumeTypeBind, Source: source, Target: "/src", Bind: &types.ServiceVolumeBind{CreateHostPath: true}},
+			},
+		}
+	}
+	withNoCreateBind := func(source string) types.ServiceConfig {
+		service := withBind(source)
+		service.Volumes[1].Bind = &types.ServiceVolumeBind{CreateHostPath: false}
+		return service
+	}
+
+	t.Run("existing sources", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		assert.NilError(t, svc.checkHostPaths(t.Context(), withBind(dir), false))
+		assert.NilError(t, svc.checkHostPaths(t.Context(), withBind(file), false))
+	})
+
+	t.Run("missing source created by the engine", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		missing := filepath.Join(dir, "data")
+		assert.NilError(t, svc.checkHostPaths(t.Context(), withBind(missing), false))
+		// without bind options, create_host_path defaults to true
+		service := withBind(missing)
+		service.Volumes[1].Bind = nil
+		assert.NilError(t, svc.checkHostPaths(t.Context(), service, false))
+		_, err := os.Stat(missing)
+		assert.Check(t, os.IsNotExist(err))
+	})
+
+	t.Run("missing source without create_host_path", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		missing := filepath.Join(dir, "typo")
+		err := svc.checkHostPaths(t.Context(), withNoCreateBind(missing), false)
+		assert.Error(t, err, fmt.Sprintf(`service "web": bind mount source %q does not exist and create_host_path is false; fix the path, or use --create-host-paths to create it`, missing))
+		_, err = os.Stat(missing)
+		assert.Check(t, os.IsNotExist(err))
+	})
+
+	t.Run("file used as a directory", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		err := svc.checkHostPaths(t.Context(), withBind(filepath.Join(file, "app")), true)
+		assert.ErrorContains(t, err, fmt.Sprintf(`service "web": bind mount source %q can't be used`, filepath.Join(file, "app")))
+		assert.ErrorContains(t, err, "not a directory")
+	})
+
+	t.Run("create missing source", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		events := &capturingEvents{}
+		svc.eventBus = events
+		missing := filepath.Join(dir, "created", "data")
+		assert.NilError(t, svc.checkHostPaths(t.Context(), withBind(missing), true))
+		info, err := os.Stat(missing)
+		assert.NilError(t, err)
+		assert.Check(t, info.IsDir())
+		assert.DeepEqual(t, events.resources, []api.Resource{createdEvent("Directory " + missing)})
+	})
+
+	t.Run("remote resource cache", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		assert.NilError(t, svc.checkHostPaths(t.Context(), withBind(filepath.Join(dir, "cache", "docker-compose", "git", "src")), false))
+	})
+}
+
+func TestIsLocalDaemon(t *testing.T) {
+	assert.Check(t, isLocalDaemon("unix:///var/run/docker.sock"))
+	assert.Check(t, isLocalDaemon("npipe:////./pipe/docker_engine"))
+	assert.Check(t, !isLocalDaemon("tcp://10.0.0.1:2376"))
+	assert.Check(t, !isLocalDaemon("ssh://user@host"))
+}
@@ -0,0 +1,285 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
+	"github.com/moby/moby/api/types/image"
+	"github.com/opencontainers/go-digest"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose/v5/pkg/api"
+	"github.com/docker/compose/v5/pkg/utils"
+)
+
+// ImageVerifier verifies the images of a project, such as checking their
+// signature or provenance, before containers are created from them
+type ImageVerifier interface {
+	// Verify returns an error when the image imageRef, resolved to digest,
+	// must not be run
+	Verify(ctx context.Context, imageRef string, digest string) error
+}
+
+// WithImageVerifier sets the verifier the images are checked with when
+// creating containers or pulling images with VerifyImages set
+func WithImageVerifier(verifier ImageVerifier) Option {
+	return func(s *composeService) error {
+		s.imageVerifier = verifier
+		return nil
+	}
+}
+
+// NewCommandImageVerifier returns an ImageVerifier running command, such as
+// `cosign verify --key cosign.pub`, with the image reference pinned to its
+// digest as last argument. The image is verified when the command exits
+// successfully. The reference and digest are also set in the environment of
+// the command as COMPOSE_IMAGE_REF and COMPOSE_IMAGE_DIGEST.
+func NewCommandImageVerifier(command []string) ImageVerifier {
+	return commandImageVerifier{command: command}
+}
+
+type commandImageVerifier struct {
+	command []string
+}
+
+func (c commandImageVerifier) Verify(ctx context.Context, imageRef string, dgst string) error {
+	if len(c.command) == 0 {
+		return errors.New("no command to verify images with")
+	}
+	args := append(slices.Clone(c.command[1:]), pinnedReference(imageRef, dgst))
+	cmd := exec.CommandContext(ctx, c.command[0], args...)
+	cmd.Env = append(os.Environ(), "COMPOSE_IMAGE_REF="+imageRef, "COMPOSE_IMAGE_DIGEST="+dgst)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// pinnedReference returns imageRef pinned to dgst, or imageRef as is when
+// either can't be parsed
+func pinnedReference(imageRef string, dgst string) string {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return imageRef
+	}
+	d, err := digest.Parse(dgst)
+	if err != nil {
+		return imageRef
+	}
+	pinned, err := reference.WithDigest(reference.TrimNamed(named), d)
+	if err != nil {
+		return imageRef
+	}
+	return reference.FamiliarString(pinned)
+}
+
+// imagesToVerify returns the references of the images the project services
+// run. Images built by the project, or missing locally, are not verified.
+func imagesToVerify(project *types.Project, images map[string]api.ImageSummary) []string {
+	refs := utils.Set[string]{}
+	for _, service := range project.Services {
+		if service.Image == "" || service.Build != nil {
+			continue
+		}
+		if _, ok := images[service.Image]; ok {
+			refs.Add(service.Image)
+		}
+	}
+	return refs.Elements()
+}
+
+// registryDigest returns the digest the registry serves the image imageRef
+// with, which verifiers resolve signatures and attestations by, unlike the
+// local image ID. It is the digest of imageRef when pinned, or the one of the
+// repo digest of the image for its repository, empty when the image wasn't
+// pulled from, nor pushed to, the registry.
+func registryDigest(img image.InspectResponse, imageRef string) string {
+	named, err := reference.ParseNormalizedNamed(imageRef)
+	if err != nil {
+		return ""
+	}
+	if canonical, ok := named.(reference.Canonical); ok {
+		return canonical.Digest().String()
+	}
+	for _, repoDigest := range img.RepoDigests {
+		pinned, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := pinned.(reference.Canonical); ok && pinned.Name() == named.Name() {
+			return canonical.Digest().String()
+		}
+	}
+	return ""
+}
+
+// verifyImages runs the image verifier on the images of the project, as
+// resolved locally, when policy requires it
+func (s *composeService) verifyImages(ctx context.Context, project *types.Project, policy string) error {
+	if policy == "" || policy == api.VerifyImagesOff {
+		return nil
+	}
+	if s.imageVerifier == nil {
+		return errors.New("images can't be verified, as no image verifier is configured")
+	}
+	images, err := s.getLocalImagesDigests(ctx, project)
+	if err != nil {
+		return err
+	}
+	refs := map[string]string{}
+	for _, ref := range imagesToVerify(project, images) {
+		inspect, err := s.apiClient().ImageInspect(ctx, ref)
+		if err != nil {
+			return err
+		}
+		refs[ref] = registryDigest(inspect.InspectResponse, ref)
+	}
+	return s.verifyImageDigests(ctx, refs, policy)
+}
+
+// errNoRegistryDigest is the verification error of images only known
+// locally, such as ones loaded or built elsewhere, whose signature can't be
+// looked up
+var errNoRegistryDigest = errors.New("no registry digest to verify the image with, as it wasn't pulled from a registry")
+
+// verifyImageDigests runs the image verifier on each of the images, given
+// with their registry digest by reference, concurrently. Images without one
+// can't be verified and fail verification. With VerifyImagesEnforce, it
+// fails listing the images which didn't pass verification,
+// VerifyImagesWarn only warns about them.
+func (s *composeService) verifyImageDigests(ctx context.Context, refs map[string]string, policy string) error {
+	keys := sortedKeys(refs)
+	errs := make([]error, len(keys))
+	var eg errgroup.Group
+	limit := s.maxConcurrency
+	if limit <= 0 {
+		limit = runtime.NumCPU()
+	}
+	eg.SetLimit(limit)
+	for i, ref := range keys {
+		eg.Go(func() error {
+			eventName := "Image " + ref
+			s.events(ctx).On(newEvent(eventName, api.Working, "Verifying"))
+			err := errNoRegistryDigest
+			if refs[ref] != "" {
+				err = s.imageVerifier.Verify(ctx, ref, refs[ref])
+			}
+			if err != nil {
+				errs[i] = fmt.Errorf("image %s: %w", ref, err)
+				if policy == api.VerifyImagesEnforce {
+					s.events(ctx).On(errorEvent(eventName, "Verification failed"))
+				} else {
+					s.events(ctx).On(newEvent(eventName, api.Warning, "Verification failed"))
+				}
+				return nil
+			}
+			s.events(ctx).On(newEvent(eventName, api.Done, "Verified"))
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	err := errors.Join(errs...)
+	switch {
+	case err == nil:
+		return nil
+	case policy == api.VerifyImagesEnforce:
+		return fmt.Errorf("images failed verification:\n%w", err)
+	default:
+		logrus.Warnf("images failed verification:\n%v", err)
+		return nil
+	}
+}
+
+// WithCreateImageVerifier sets the verifier consulted before each container
+// is created, with the registry digest of the image the container runs. The
+// container isn't created when the image fails verification, or has no
+// registry digest. Images built by the project aren't verified. Without one,
+// containers are created from images as is.
+func WithCreateImageVerifier(verifier ImageVerifier) Option {
+	return func(s *composeService) error {
+		s.imageGate = &imageGate{verifier: verifier, verified: map[string]bool{}}
+		return nil
+	}
+}
+
+// imageGate verifies the images containers are created from, each image
+// reference and digest once verified being trusted for the lifetime of the
+// service
+type imageGate struct {
+	verifier ImageVerifier
+	mu       sync.Mutex
+	verified map[string]bool
+}
+
+func (g *imageGate) isVerified(key string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.verified[key]
+}
+
+func (g *imageGate) setVerified(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.verified[key] = true
+}
+
+// verifyContainerImage runs the image gate, when set, on the image imageRef
+// a container of service is about to be created from, with its registry
+// digest. The image of the container, as resolved locally, is inspected for
+// it: the digest label of the service is the local image ID.
+func (s *composeService) verifyContainerImage(ctx context.Context, service types.ServiceConfig, imageRef string) error {
+	gate := s.imageGate
+	if gate == nil || service.Build != nil {
+		return nil
+	}
+	inspect, err := s.apiClient().ImageInspect(ctx, imageRef)
+	if err != nil {
+		return fmt.Errorf("resolving the digest of image %s to verify it: %w", imageRef, err)
+	}
+	dgst := registryDigest(inspect.InspectResponse, imageRef)
+	if dgst == "" {
+		return fmt.Errorf("image %s of service %s failed verification: %w", imageRef, service.Name, errNoRegistryDigest)
+	}
+	key := imageRef + "@" + dgst
+	if gate.isVerified(key) {
+		return nil
+	}
+	if err := gate.verifier.Verify(ctx, imageRef, dgst); err != nil {
+		return fmt.Errorf("image %s (%s) of service %s failed verification: %w", imageRef, dgst, service.Name, err)
+	}
+	gate.setVerified(key)
+	return nil
+}
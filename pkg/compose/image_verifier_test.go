@@ -0,0 +1,159 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/image"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+type fakeImageVerifier struct {
+	mu       sync.Mutex
+	verified map[string]string
+	failing  map[string]error
+}
+
+func (f *fakeImageVerifier) Verify(_ context.Context, imageRef string, digest string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.verified[imageRef] = digest
+	return f.failing[imageRef]
+}
+
+func TestImagesToVerify(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web":   {Name: "web", Image: "nginx"},
+			"proxy": {Name: "proxy", Image: "nginx"},
+			"db":    {Name: "db", Image: "postgres"},
+			"app":   {Name: "app", Image: "myapp", Build: &types.BuildConfig{Context: "."}},
+			"cache": {Name: "cache", Image: "redis"},
+		},
+	}
+	images := map[string]api.ImageSummary{
+		"nginx":    {ID: "sha256:nginx"},
+		"postgres": {ID: "sha256:postgres"},
+		"myapp":    {ID: "sha256:myapp"},
+	}
+	// built images aren't verified, nor images missing locally
+	refs := imagesToVerify(project, images)
+	slices.Sort(refs)
+	assert.DeepEqual(t, refs, []string{"nginx", "postgres"})
+}
+
+func TestRegistryDigest(t *testing.T) {
+	const (
+		hub    = "sha256:9b7d2eb8d8e6e5ff4f5b1c4e4d1b2d5c6a7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a"
+		mirror = "sha256:1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b"
+	)
+	img := image.InspectResponse{
+		ID:          "sha256:localconfigdigest",
+		RepoDigests: []string{"mirror.example.com/library/nginx@" + mirror, "nginx@" + hub},
+	}
+	// the digest of the repository of the reference, not the local image ID
+	assert.Equal(t, registryDigest(img, "nginx:1.27"), hub)
+	assert.Equal(t, registryDigest(img, "mirror.example.com/library/nginx"), mirror)
+	assert.Equal(t, registryDigest(img, "nginx@"+mirror), mirror)
+	assert.Equal(t, registryDigest(img, "registry.example.com/nginx"), "")
+	assert.Equal(t, registryDigest(image.InspectResponse{ID: "sha256:built"}, "myapp"), "")
+}
+
+func TestPinnedReference(t *testing.T) {
+	const dgst = "sha256:9b7d2eb8d8e6e5ff4f5b1c4e4d1b2d5c6a7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a"
+	assert.Equal(t, pinnedReference("nginx:1.27", dgst), "nginx@"+dgst)
+	assert.Equal(t, pinnedReference("registry.example.com/team/app", dgst), "registry.example.com/team/app@"+dgst)
+	assert.Equal(t, pinnedReference("nginx", "not-a-digest"), "nginx")
+}
+
+func TestVerifyImageDigests(t *testing.T) {
+	refs := map[string]string{
+		"nginx":    "sha256:nginx",
+		"postgres": "sha256:postgres",
+		"redis":    "sha256:redis",
+		"local":    "",
+	}
+	tests := []struct {
+		policy string
+		err    string
+		status api.EventStatus
+	}{
+		{
+			policy: api.VerifyImagesEnforce,
+			err:    "images failed verification:\nimage local: no registry digest to verify the image with, as it wasn't pulled from a registry\nimage postgres: no signature found\nimage redis: untrusted key",
+			status: api.Error,
+		},
+		{
+			policy: api.VerifyImagesWarn,
+			status: api.Warning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			verifier := &fakeImageVerifier{
+				verified: map[string]string{},
+				failing: map[string]error{
+					"postgres": errors.New("no signature found"),
+					"redis":    errors.New("untrusted key"),
+				},
+			}
+			events := &capturingEvents{}
+			svc := &composeService{eventBus: events, imageVerifier: verifier, maxConcurrency: 1}
+
+			err := svc.verifyImageDigests(t.Context(), refs, tt.policy)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+			} else {
+				assert.NilError(t, err)
+			}
+			// images without a registry digest fail without being verified
+			assert.DeepEqual(t, verifier.verified, map[string]string{
+				"nginx":    "sha256:nginx",
+				"postgres": "sha256:postgres",
+				"redis":    "sha256:redis",
+			})
+
+			last := map[string]api.EventStatus{}
+			for _, e := range events.resources {
+				last[e.ID] = e.Status
+			}
+			assert.DeepEqual(t, last, map[string]api.EventStatus{
+				"Image local":    tt.status,
+				"Image nginx":    api.Done,
+				"Image postgres": tt.status,
+				"Image redis":    tt.status,
+			})
+		})
+	}
+}
+
+func TestVerifyImagesOff(t *testing.T) {
+	// neither the engine nor a verifier are needed to skip verification
+	svc := &composeService{}
+	assert.NilError(t, svc.verifyImages(t.Context(), &types.Project{}, api.VerifyImagesOff))
+	assert.NilError(t, svc.verifyImages(t.Context(), &types.Project{}, ""))
+	assert.Error(t, svc.verifyImages(t.Context(), &types.Project{}, api.VerifyImagesEnforce),
+		"images can't be verified, as no image verifier is configured")
+}
@@ -0,0 +1,162 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func (s *composeService) Inspect(ctx context.Context, project *types.Project, options api.InspectOptions) (api.ServiceInspect, error) {
+	if _, err := project.GetService(options.Service); err != nil {
+		return api.ServiceInspect{}, err
+	}
+
+	// Mirror the project mutations create applies before reconciling, so the
+	// computed hash and recreate decision match what `up` would evaluate.
+	images, err := s.getLocalImagesDigests(ctx, project)
+	if err != nil {
+		return api.ServiceInspect{}, err
+	}
+	for name, service := range project.Services {
+		resolveImageVolumes(&service, images, project.Name)
+		project.Services[name] = service
+	}
+	project, err = s.useAPISocket(project)
+	if err != nil {
+		return api.ServiceInspect{}, err
+	}
+
+	observed, err := s.collectObservedState(ctx, project)
+	if err != nil {
+		return api.ServiceInspect{}, err
+	}
+	markAbsentDependencies(project, observedRunning(observed))
+
+	service, err := project.GetService(options.Service)
+	if err != nil {
+		return api.ServiceInspect{}, err
+	}
+	index := options.Index
+	if index == 0 {
+		index = 1
+	}
+	var oc *ObservedContainer
+	for i, c := range observed.Containers[service.Name] {
+		if c.Number == index {
+			oc = &observed.Containers[service.Name][i]
+			break
+		}
+	}
+
+	result, err := inspectDivergence(project, observed, service, oc)
+	if err != nil {
+		return result, err
+	}
+	if oc == nil {
+		return result, nil
+	}
+
+	res, err := s.apiClient().ContainerInspect(ctx, oc.ID, client.ContainerInspectOptions{})
+	if err != nil {
+		return result, err
+	}
+	result.Container = toContainerRuntime(res.Container)
+	return result, nil
+}
+
+// inspectDivergence compares the desired service configuration with the
+// observed container (nil if none exists) using the reconciler's decision
+// logic, so the reported recreate decision is the one `up` would take.
+func inspectDivergence(project *types.Project, observed *ObservedState, service types.ServiceConfig, oc *ObservedContainer) (api.ServiceInspect, error) {
+	r := newReconciler(project, observed, ReconcileOptions{
+		Recreate:             api.RecreateDiverged,
+		RecreateDependencies: api.RecreateDiverged,
+	}, nil)
+
+	expectedHash, err := serviceHashWithResolvedRefs(service, r.observedContainersByService)
+	if err != nil {
+		return api.ServiceInspect{}, err
+	}
+	result := api.ServiceInspect{
+		Service:    service,
+		ConfigHash: expectedHash,
+	}
+	if oc == nil {
+		result.Recreate = true
+		result.RecreateReason = "no existing container"
+		return result, nil
+	}
+
+	result.StoredConfigHash = oc.ConfigHash
+	result.Diverged = oc.ConfigHash != expectedHash
+	reason, matched := r.compareContainer(service, expectedHash, "", *oc, api.RecreateDiverged)
+	result.RecreateReason = reason
+	result.Recreate = reason != ""
+	if !result.Recreate {
+		result.Matched = matched
+	}
+	return result, nil
+}
+
+// toContainerRuntime extracts the key runtime facts reported by Inspect.
+func toContainerRuntime(ctr container.InspectResponse) *api.ContainerRuntime {
+	runtime := &api.ContainerRuntime{
+		ID:          ctr.ID,
+		Name:        ctr.Name[1:],
+		ImageDigest: ctr.Image,
+	}
+	if ctr.State != nil {
+		runtime.State = ctr.State.Status
+		if ctr.State.Health != nil {
+			runtime.Health = ctr.State.Health.Status
+		}
+	}
+	if ctr.NetworkSettings != nil {
+		for name, settings := range ctr.NetworkSettings.Networks {
+			attachment := api.NetworkAttachment{
+				Name:    name,
+				Aliases: settings.Aliases,
+			}
+			if settings.IPAddress.IsValid() {
+				attachment.IPAddress = settings.IPAddress.String()
+			}
+			if settings.GlobalIPv6Address.IsValid() {
+				attachment.IPv6Address = settings.GlobalIPv6Address.String()
+			}
+			runtime.Networks = append(runtime.Networks, attachment)
+		}
+		sort.Slice(runtime.Networks, func(i, j int) bool {
+			return runtime.Networks[i].Name < runtime.Networks[j].Name
+		})
+	}
+	for _, m := range ctr.Mounts {
+		source := m.Name
+		if source == "" {
+			source = m.Source
+		}
+		runtime.Mounts = append(runtime.Mounts, fmt.Sprintf("%s:%s:%s", m.Type, source, m.Destination))
+	}
+	return runtime
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func inspectFixture(t *testing.T, storedHash string) (*types.Project, *ObservedState, types.ServiceConfig) {
+	t.Helper()
+	service := types.ServiceConfig{Name: "web", Image: "nginx"}
+	project := &types.Project{
+		Name:     "myproject",
+		Services: types.Services{"web": service},
+	}
+	if storedHash == "" {
+		hash, err := ServiceHash(service)
+		assert.NilError(t, err)
+		storedHash = hash
+	}
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers: map[string][]ObservedContainer{
+			"web": {{
+				ID:         "abc123",
+				Name:       "myproject-web-1",
+				State:      container.StateRunning,
+				ConfigHash: storedHash,
+				Number:     1,
+				Labels:     map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+			}},
+		},
+		Networks: map[string]ObservedNetwork{},
+		Volumes:  map[string]ObservedVolume{},
+	}
+	return project, observed, service
+}
+
+func TestInspectDivergence_Matching(t *testing.T) {
+	project, observed, service := inspectFixture(t, "")
+	oc := observed.Containers["web"][0]
+
+	result, err := inspectDivergence(project, observed, service, &oc)
+	assert.NilError(t, err)
+	assert.Equal(t, result.StoredConfigHash, result.ConfigHash)
+	assert.Check(t, !result.Diverged)
+	assert.Check(t, !result.Recreate)
+	assert.Equal(t, result.RecreateReason, "")
+	// neither the image digest nor the image ID are known
+	assert.DeepEqual(t, result.Matched, []string{matchConfigHash, matchNetworks, matchVolumes})
+}
+
+func TestInspectDivergence_Mismatching(t *testing.T) {
+	project, observed, service := inspectFixture(t, "outdated")
+	oc := observed.Containers["web"][0]
+
+	result, err := inspectDivergence(project, observed, service, &oc)
+	assert.NilError(t, err)
+	assert.Equal(t, result.StoredConfigHash, "outdated")
+	assert.Check(t, result.ConfigHash != "outdated")
+	assert.Check(t, result.Diverged)
+	assert.Check(t, result.Recreate)
+	assert.Equal(t, result.RecreateReason, recreateReasonConfigChanged)
+	assert.Check(t, result.Matched == nil)
+}
+
+func TestInspectDivergence_NoContainer(t *testing.T) {
+	project, observed, service := inspectFixture(t, "")
+
+	result, err := inspectDivergence(project, observed, service, nil)
+	assert.NilError(t, err)
+	assert.Check(t, !result.Diverged)
+	assert.Check(t, result.Recreate)
+	assert.Equal(t, result.RecreateReason, "no existing container")
+}
+
+func TestToContainerRuntime(t *testing.T) {
+	runtime := toContainerRuntime(container.InspectResponse{
+		ID:    "abc123",
+		Name:  "/myproject-web-1",
+		Image: "sha256:1234",
+		State: &container.State{
+			Status: container.StateRunning,
+			Health: &container.Health{Status: container.Healthy},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"myproject_front": {IPAddress: netip.MustParseAddr("172.18.0.2"), Aliases: []string{"web"}},
+				"myproject_back":  {IPAddress: netip.MustParseAddr("172.19.0.2")},
+			},
+		},
+	})
+	assert.DeepEqual(t, runtime, &api.ContainerRuntime{
+		ID:          "abc123",
+		Name:        "myproject-web-1",
+		State:       container.StateRunning,
+		Health:      container.Healthy,
+		ImageDigest: "sha256:1234",
+		Networks: []api.NetworkAttachment{
+			{Name: "myproject_back", IPAddress: "172.19.0.2"},
+			{Name: "myproject_front", IPAddress: "172.18.0.2", Aliases: []string{"web"}},
+		},
+	})
+}
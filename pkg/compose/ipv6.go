@@ -0,0 +1,177 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/client"
+	"github.com/moby/moby/client/pkg/versions"
+)
+
+// parseHostIP parses the host IP a port is published on, which may be an
+// IPv6 address enclosed in brackets
+func parseHostIP(hostIP string) (netip.Addr, error) {
+	if ip, ok := strings.CutPrefix(hostIP, "["); ok {
+		if ip, ok = strings.CutSuffix(ip, "]"); !ok {
+			return netip.Addr{}, fmt.Errorf("invalid IP address: %s", hostIP)
+		}
+		hostIP = ip
+	}
+	addr, err := netip.ParseAddr(hostIP)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if addr.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("IPv6 address with a zone isn't supported: %s", hostIP)
+	}
+	return addr, nil
+}
+
+// ipv6Capabilities tells the IPv6 network configurations the engine supports
+type ipv6Capabilities struct {
+	// ipv6Only is set when networks can be created with IPv4 disabled
+	ipv6Only bool
+	// defaultSubnet is set when the engine allocates a subnet to the IPv6
+	// networks declaring none
+	defaultSubnet bool
+}
+
+// checkIPv6 reports all the issues with the IPv6 configuration of the
+// project at once, before anything is created: host IPs of published ports
+// which can't be parsed, and networks requesting IPv6 configurations the
+// engine doesn't support.
+func (s *composeService) checkIPv6(ctx context.Context, project *types.Project) error {
+	errs := checkPortHostIPs(project)
+	if requestsIPv6(project) {
+		caps, err := s.ipv6Capabilities(ctx)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, checkIPv6Networks(project, caps)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid IPv6 configuration, run with --skip-ipv6-check to ignore:\n%w", errors.Join(errs...))
+}
+
+// ipv6Capabilities queries the engine for the IPv6 network configurations it
+// supports
+func (s *composeService) ipv6Capabilities(ctx context.Context) (ipv6Capabilities, error) {
+	apiVersion, err := s.RuntimeAPIVersion(ctx)
+	if err != nil {
+		return ipv6Capabilities{}, err
+	}
+	res, err := s.apiClient().Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return ipv6Capabilities{}, err
+	}
+	caps := ipv6Capabilities{
+		ipv6Only:      !versions.LessThan(apiVersion, apiVersion147),
+		defaultSubnet: !versions.LessThan(apiVersion, apiVersion146),
+	}
+	// subnets are only allocated from the address pools configured on the
+	// engine, when some are
+	if pools := res.Info.DefaultAddressPools; len(pools) > 0 {
+		caps.defaultSubnet = false
+		for _, pool := range pools {
+			if pool.Base.Addr().Is6() {
+				caps.defaultSubnet = true
+			}
+		}
+	}
+	return caps, nil
+}
+
+// checkPortHostIPs reports the published ports of the project services with a
+// host IP which can't be parsed
+func checkPortHostIPs(project *types.Project) []error {
+	var errs []error
+	for _, name := range project.ServiceNames() {
+		for _, port := range project.Services[name].Ports {
+			if port.HostIP == "" {
+				continue
+			}
+			if _, err := parseHostIP(port.HostIP); err != nil {
+				errs = append(errs, fmt.Errorf("service %q publishes port %d on invalid host IP %q: %w", name, port.Target, port.HostIP, err))
+			}
+		}
+	}
+	return errs
+}
+
+// requestsIPv6 reports whether a network of the project enables IPv6 or
+// disables IPv4
+func requestsIPv6(project *types.Project) bool {
+	for _, n := range project.Networks {
+		if !bool(n.External) && (enablesIPv6(n) || !enablesIPv4(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+func enablesIPv4(n types.NetworkConfig) bool {
+	return n.EnableIPv4 == nil || *n.EnableIPv4
+}
+
+func enablesIPv6(n types.NetworkConfig) bool {
+	return n.EnableIPv6 != nil && *n.EnableIPv6
+}
+
+// checkIPv6Networks reports the networks of the project requesting an IPv6
+// configuration the engine doesn't support
+func checkIPv6Networks(project *types.Project, caps ipv6Capabilities) []error {
+	var errs []error
+	for _, key := range sortedKeys(project.Networks) {
+		n := project.Networks[key]
+		if n.External {
+			continue
+		}
+		ipv4, ipv6 := enablesIPv4(n), enablesIPv6(n)
+		if !ipv4 && !ipv6 {
+			errs = append(errs, fmt.Errorf("network %q disables IPv4 without enabling IPv6", key))
+			continue
+		}
+		if !ipv4 && !caps.ipv6Only {
+			errs = append(errs, fmt.Errorf("network %q disables IPv4, which requires Docker Engine API %s or later", key, apiVersion147))
+		}
+		if ipv6 && !hasIPv6Subnet(n) && !caps.defaultSubnet {
+			errs = append(errs, fmt.Errorf("network %q enables IPv6 without an IPv6 subnet, and the engine has no IPv6 address pool to allocate one from: declare one in ipam.config", key))
+		}
+	}
+	return errs
+}
+
+// hasIPv6Subnet reports whether the IPAM configuration of n declares an IPv6 subnet
+func hasIPv6Subnet(n types.NetworkConfig) bool {
+	for _, pool := range n.Ipam.Config {
+		if pool == nil {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(pool.Subnet); err == nil && prefix.Addr().Is6() {
+			return true
+		}
+	}
+	return false
+}
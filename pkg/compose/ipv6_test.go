@@ -0,0 +1,162 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/system"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseHostIP(t *testing.T) {
+	tests := []struct {
+		hostIP string
+		want   string
+		err    string
+	}{
+		{hostIP: "127.0.0.1", want: "127.0.0.1"},
+		{hostIP: "::1", want: "::1"},
+		{hostIP: "[::1]", want: "::1"},
+		{hostIP: "::", want: "::"},
+		{hostIP: "2001:db8::8080", want: "2001:db8::8080"},
+		{hostIP: "[::1", err: "invalid IP address: [::1"},
+		{hostIP: "fe80::1%eth0", err: "IPv6 address with a zone isn't supported: fe80::1%eth0"},
+		{hostIP: "localhost", err: `ParseAddr("localhost"): unable to parse IP`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.hostIP, func(t *testing.T) {
+			addr, err := parseHostIP(tt.hostIP)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, addr, netip.MustParseAddr(tt.want))
+		})
+	}
+}
+
+func TestCheckPortHostIPs(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web": {Name: "web", Ports: []types.ServicePortConfig{
+				{Target: 80, Published: "8080", HostIP: "::1"},
+				{Target: 443, Published: "8443", HostIP: "[::1]"},
+				{Target: 8080, Published: "9090", HostIP: "fe80::1%eth0"},
+			}},
+		},
+	}
+	errs := checkPortHostIPs(project)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], `service "web" publishes port 8080 on invalid host IP "fe80::1%eth0": IPv6 address with a zone isn't supported: fe80::1%eth0`)
+}
+
+func TestCheckIPv6Networks(t *testing.T) {
+	enabled, disabled := true, false
+	project := &types.Project{
+		Networks: types.Networks{
+			"default":   {},
+			"dual":      {EnableIPv6: &enabled},
+			"subnetted": {EnableIPv6: &enabled, Ipam: types.IPAMConfig{Config: []*types.IPAMPool{{Subnet: "fd00:1::/64"}}}},
+			"v6only":    {EnableIPv4: &disabled, EnableIPv6: &enabled, Ipam: types.IPAMConfig{Config: []*types.IPAMPool{{Subnet: "fd00:2::/64"}}}},
+			"none":      {EnableIPv4: &disabled},
+			"external":  {EnableIPv4: &disabled, External: true},
+		},
+	}
+	assert.Assert(t, requestsIPv6(project))
+
+	tests := []struct {
+		name string
+		caps ipv6Capabilities
+		want []string
+	}{
+		{
+			name: "supported",
+			caps: ipv6Capabilities{ipv6Only: true, defaultSubnet: true},
+			want: []string{`network "none" disables IPv4 without enabling IPv6`},
+		},
+		{
+			name: "unsupported",
+			caps: ipv6Capabilities{},
+			want: []string{
+				`network "dual" enables IPv6 without an IPv6 subnet, and the engine has no IPv6 address pool to allocate one from: declare one in ipam.config`,
+				`network "none" disables IPv4 without enabling IPv6`,
+				`network "v6only" disables IPv4, which requires Docker Engine API 1.47 or later`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range checkIPv6Networks(project, tt.caps) {
+				got = append(got, err.Error())
+			}
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestIPv6Capabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		pools      []system.NetworkAddressPool
+		want       ipv6Capabilities
+	}{
+		{
+			name:       "old engine",
+			apiVersion: "1.45",
+			want:       ipv6Capabilities{},
+		},
+		{
+			name:       "old engine with an IPv6 pool",
+			apiVersion: "1.45",
+			pools:      []system.NetworkAddressPool{{Base: netip.MustParsePrefix("fd00::/48"), Size: 64}},
+			want:       ipv6Capabilities{defaultSubnet: true},
+		},
+		{
+			name:       "recent engine",
+			apiVersion: "1.48",
+			want:       ipv6Capabilities{ipv6Only: true, defaultSubnet: true},
+		},
+		{
+			name:       "recent engine with IPv4 pools only",
+			apiVersion: "1.48",
+			pools:      []system.NetworkAddressPool{{Base: netip.MustParsePrefix("10.10.0.0/16"), Size: 24}},
+			want:       ipv6Capabilities{ipv6Only: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			apiClient.EXPECT().Ping(gomock.Any(), client.PingOptions{NegotiateAPIVersion: true}).
+				Return(client.PingResult{APIVersion: tt.apiVersion}, nil).AnyTimes()
+			apiClient.EXPECT().ClientVersion().Return(tt.apiVersion).AnyTimes()
+			apiClient.EXPECT().Info(gomock.Any(), client.InfoOptions{}).
+				Return(client.SystemInfoResult{Info: system.Info{DefaultAddressPools: tt.pools}}, nil)
+
+			caps, err := svc.ipv6Capabilities(t.Context())
+			assert.NilError(t, err)
+			assert.Equal(t, caps, tt.want)
+		})
+	}
+}
@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"strings"
+	"sync"
 
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
@@ -27,16 +28,19 @@ import (
 )
 
 func (s *composeService) Kill(ctx context.Context, projectName string, options api.KillOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.kill(ctx, strings.ToLower(projectName), options)
-	}, "kill", s.events)
+	}, "kill", s.events(ctx))
 }
 
 func (s *composeService) kill(ctx context.Context, projectName string, options api.KillOptions) error {
 	services := options.Services
 
+	// stopped containers are only listed to be reported as skipped
+	reportStopped := !options.All && options.Report != nil
 	var containers Containers
-	containers, err := s.getContainers(ctx, projectName, oneOffInclude, options.All, services...)
+	containers, err := s.getContainers(ctx, projectName, oneOffInclude, options.All || reportStopped, services...)
 	if err != nil {
 		return err
 	}
@@ -52,21 +56,72 @@ func (s *composeService) kill(ctx context.Context, projectName string, options a
 	if !options.RemoveOrphans {
 		containers = containers.filter(isService(project.ServiceNames()...))
 	}
-	if len(containers) == 0 {
+
+	var mu sync.Mutex
+	report := func(ctr container.Summary, signal string, status string) {
+		if options.Report == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		options.Report(api.KillResult{
+			Container: getCanonicalContainerName(ctr),
+			Service:   ctr.Labels[api.ServiceLabel],
+			Signal:    signal,
+			Status:    status,
+		})
+	}
+
+	var running Containers
+	for _, ctr := range containers {
+		if reportStopped && isStopped(ctr) {
+			s.events(ctx).On(skippedEvent(getContainerProgressName(ctr), "not running"))
+			report(ctr, killSignal(options, ctr), api.KillStatusSkipped)
+			continue
+		}
+		running = append(running, ctr)
+	}
+	if len(running) == 0 {
 		return api.ErrNoResources
 	}
 
-	return forEachContainerConcurrent(ctx, containers, func(ctx context.Context, ctr container.Summary) error {
+	return forEachContainerConcurrent(ctx, running, func(ctx context.Context, ctr container.Summary) error {
 		eventName := getContainerProgressName(ctr)
-		s.events.On(newEvent(eventName, api.Working, api.StatusKilling))
-		_, err := s.apiClient().ContainerKill(ctx, ctr.ID, client.ContainerKillOptions{
-			Signal: options.Signal,
+		signal := killSignal(options, ctr)
+		s.events(ctx).On(newEvent(eventName, api.Working, api.StatusKilling))
+		err := s.withSlot(ctx, func(ctx context.Context) error {
+			_, err := s.apiClient().ContainerKill(ctx, ctr.ID, client.ContainerKillOptions{
+				Signal: signal,
+			})
+			return err
 		})
 		if err != nil {
-			s.events.On(errorEvent(eventName, "Error while Killing"))
+			s.events(ctx).On(errorEvent(eventName, "Error while Killing"))
+			report(ctr, signal, api.KillStatusError)
 			return err
 		}
-		s.events.On(newEvent(eventName, api.Done, api.StatusKilled))
+		s.events(ctx).On(newEvent(eventName, api.Done, api.StatusKilled))
+		report(ctr, signal, api.KillStatusKilled)
 		return nil
 	})
 }
+
+// isStopped tells if a container would not have been listed without All, as
+// the Engine lists running, paused and restarting containers by default
+func isStopped(ctr container.Summary) bool {
+	switch ctr.State {
+	case container.StateRunning, container.StatePaused, container.StateRestarting:
+		return false
+	default:
+		return true
+	}
+}
+
+// killSignal returns the signal to send to a container, the service specific
+// one taking precedence over the default
+func killSignal(options api.KillOptions, ctr container.Summary) string {
+	if signal, ok := options.Signals[ctr.Labels[api.ServiceLabel]]; ok {
+		return signal
+	}
+	return options.Signal
+}
@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -43,7 +44,7 @@ func TestKillAll(t *testing.T) {
 
 	name := strings.ToLower(testProject)
 
-	api.EXPECT().ContainerList(t.Context(), client.ContainerListOptions{
+	api.EXPECT().ContainerList(anyCancellableContext(), client.ContainerListOptions{
 		Filters: projectFilter(name).Add("label", compose.ConfigHashLabel),
 	}).Return(client.ContainerListResult{
 		Items: []container.Summary{
@@ -86,7 +87,7 @@ func TestKillSignal(t *testing.T) {
 		Filters: projectFilter(name).Add("label", serviceFilter(serviceName), compose.ConfigHashLabel),
 	}
 
-	api.EXPECT().ContainerList(t.Context(), listOptions).Return(client.ContainerListResult{
+	api.EXPECT().ContainerList(anyCancellableContext(), listOptions).Return(client.ContainerListResult{
 		Items: []container.Summary{testContainer(serviceName, "123", false)},
 	}, nil)
 	api.EXPECT().VolumeList(
@@ -109,6 +110,59 @@ func TestKillSignal(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestKillPerServiceSignalReportsStopped(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	name := strings.ToLower(testProject)
+	running := func(service, id string) container.Summary {
+		ctr := testContainer(service, id, false)
+		ctr.State = container.StateRunning
+		return ctr
+	}
+
+	api.EXPECT().ContainerList(anyCancellableContext(), client.ContainerListOptions{
+		Filters: projectFilter(name).Add("label", compose.ConfigHashLabel),
+		All:     true,
+	}).Return(client.ContainerListResult{
+		Items: []container.Summary{
+			running("service1", "123"),
+			running("service1", "456"),
+			running("service2", "789"),
+			testContainer("service2", "abc", false),
+		},
+	}, nil)
+	api.EXPECT().VolumeList(gomock.Any(), gomock.Any()).Return(client.VolumeListResult{}, nil)
+	api.EXPECT().NetworkList(gomock.Any(), gomock.Any()).Return(client.NetworkListResult{}, nil)
+	api.EXPECT().ContainerKill(anyCancellableContext(), "123", client.ContainerKillOptions{Signal: "HUP"}).Return(client.ContainerKillResult{}, nil)
+	api.EXPECT().ContainerKill(anyCancellableContext(), "456", client.ContainerKillOptions{Signal: "HUP"}).Return(client.ContainerKillResult{}, nil)
+	api.EXPECT().ContainerKill(anyCancellableContext(), "789", client.ContainerKillOptions{Signal: "SIGTERM"}).Return(client.ContainerKillResult{}, nil)
+
+	var results []compose.KillResult
+	err = tested.Kill(t.Context(), name, compose.KillOptions{
+		Signal:  "SIGTERM",
+		Signals: map[string]string{"service1": "HUP"},
+		Report: func(result compose.KillResult) {
+			results = append(results, result)
+		},
+	})
+	assert.NilError(t, err)
+
+	slices.SortFunc(results, func(a, b compose.KillResult) int {
+		return strings.Compare(a.Container, b.Container)
+	})
+	assert.DeepEqual(t, results, []compose.KillResult{
+		{Container: "123", Service: "service1", Signal: "HUP", Status: compose.KillStatusKilled},
+		{Container: "456", Service: "service1", Signal: "HUP", Status: compose.KillStatusKilled},
+		{Container: "789", Service: "service2", Signal: "SIGTERM", Status: compose.KillStatusKilled},
+		{Container: "abc", Service: "service2", Signal: "SIGTERM", Status: compose.KillStatusSkipped},
+	})
+}
+
 func testContainer(service string, id string, oneOff bool) container.Summary {
 	// canonical docker names in the API start with a leading slash, some
 	// parts of Compose code will attempt to strip this off, so make sure
@@ -138,10 +192,9 @@ func containerLabels(service string, oneOff bool) map[string]string {
 }
 
 func anyCancellableContext() gomock.Matcher {
-	//nolint:forbidigo // This creates a context type for gomock matching, not for actual test usage
-	ctxWithCancel, cancel := context.WithCancel(context.Background())
-	cancel()
-	return gomock.AssignableToTypeOf(ctxWithCancel)
+	return gomock.Cond(func(ctx context.Context) bool {
+		return ctx.Done() != nil
+	})
 }
 
 func projectFilterListOpt(withOneOff bool) client.ContainerListOptions {
@@ -0,0 +1,46 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import "context"
+
+type heldSlotKey struct{}
+
+// acquireSlot takes a slot from the concurrency limiter, if any, for an
+// operation against the engine, and returns the function releasing it along
+// with a context marking the slot as held: the operations run on behalf of
+// the one holding it don't take another.
+func (s *composeService) acquireSlot(ctx context.Context) (context.Context, func(), error) {
+	if s.limiter == nil || ctx.Value(heldSlotKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+	if err := s.limiter.Acquire(ctx, 1); err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, heldSlotKey{}, true), func() { s.limiter.Release(1) }, nil
+}
+
+// withSlot runs fn, an operation against the engine, holding a slot of the
+// concurrency limiter
+func (s *composeService) withSlot(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, release, err := s.acquireSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/sync/semaphore"
+	"gotest.tools/v3/assert"
+)
+
+func TestStopContainersConcurrencyLimiter(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	svc.limiter = semaphore.NewWeighted(2)
+
+	var running, maxRunning atomic.Int32
+	var containers []container.Summary
+	for i := range 6 {
+		containers = append(containers, container.Summary{ID: fmt.Sprintf("c%d", i), Names: []string{fmt.Sprintf("/test-web-%d", i+1)}})
+	}
+	apiClient.EXPECT().ContainerStop(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, string, client.ContainerStopOptions) (client.ContainerStopResult, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return client.ContainerStopResult{}, nil
+		}).Times(6)
+
+	assert.NilError(t, svc.stopContainers(t.Context(), nil, containers, nil, nil))
+	assert.Assert(t, maxRunning.Load() <= 2, "%d operations ran concurrently", maxRunning.Load())
+}
+
+func TestAcquireSlotHeld(t *testing.T) {
+	svc := &composeService{limiter: semaphore.NewWeighted(1)}
+	ctx, release, err := svc.acquireSlot(t.Context())
+	assert.NilError(t, err)
+	defer release()
+
+	// the operations run on behalf of the one holding the slot don't wait for
+	// it to be released
+	ran := false
+	assert.NilError(t, svc.withSlot(ctx, func(context.Context) error {
+		ran = true
+		return nil
+	}))
+	assert.Assert(t, ran)
+}
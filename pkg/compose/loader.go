@@ -19,7 +19,9 @@ package compose
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/compose-spec/compose-go/v2/cli"
@@ -58,12 +60,38 @@ func (s *composeService) LoadProject(ctx context.Context, options api.ProjectLoa
 		return nil, err
 	}
 
+	project, err = expandServiceTemplates(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err = expandSidecars(project)
+	if err != nil {
+		return nil, err
+	}
+	options.Services = withSidecars(project, options.Services)
+
+	project, err = checkRemoteRelativeBinds(project, projectOptions, options.WorkingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err = mapRemoteHostPaths(project, projectOptions)
+	if err != nil {
+		return nil, err
+	}
+
 	// Post-processing: service selection, environment resolution, etc.
 	project, err = s.postProcessProject(project, options)
 	if err != nil {
 		return nil, err
 	}
 
+	project, err = s.decryptEnvironment(ctx, project, options.RedactDecrypted)
+	if err != nil {
+		return nil, err
+	}
+
 	return project, nil
 }
 
@@ -116,6 +144,63 @@ func (s *composeService) buildProjectOptions(options api.ProjectLoadOptions, rem
 	return cli.NewProjectOptions(options.ConfigPaths, append(options.ProjectOptionsFns, opts...)...)
 }
 
+// checkRemoteRelativeBinds detects bind mounts declared with a relative source
+// in a project loaded from a remote resource. compose-go resolves those against
+// the cache directory the resource was downloaded to, which is never what the
+// user expects. They are rebased on the project directory when one is set,
+// kept as-is when COMPOSE_REMOTE_RELATIVE_PATHS is enabled, and rejected
+// otherwise.
+func checkRemoteRelativeBinds(project *types.Project, options *cli.ProjectOptions, projectDir string) (*types.Project, error) {
+	if !isRemoteProject(options) {
+		return project, nil
+	}
+	if utils.StringToBool(options.Environment[api.ComposeRemoteRelativePaths]) {
+		return project, nil
+	}
+	if projectDir != "" {
+		abs, err := filepath.Abs(projectDir)
+		if err != nil {
+			return nil, err
+		}
+		projectDir = abs
+	}
+
+	cacheDir := project.WorkingDir
+	for name, service := range project.Services {
+		for i, volume := range service.Volumes {
+			if volume.Type != types.VolumeTypeBind {
+				continue
+			}
+			rel, err := filepath.Rel(cacheDir, volume.Source)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			if projectDir == "" {
+				return nil, fmt.Errorf("service %q: bind mount source %q is relative to a remote Compose file and would resolve to %s; "+
+					"use --project-directory to set the local directory it is relative to, or make the path absolute. Set %s=1 to keep this behavior",
+					name, "./"+filepath.ToSlash(rel), volume.Source, api.ComposeRemoteRelativePaths)
+			}
+			service.Volumes[i].Source = filepath.Join(projectDir, rel)
+		}
+		project.Services[name] = service
+	}
+	return project, nil
+}
+
+// isRemoteProject reports whether one of the Compose files is loaded from a
+// remote resource, in which case compose-go uses the local cache directory
+// as project working directory.
+func isRemoteProject(options *cli.ProjectOptions) bool {
+	for _, path := range options.ConfigPaths {
+		for _, l := range options.ResourceLoaders {
+			if l.Accept(path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // postProcessProject applies post-loading transformations to the project
 func (s *composeService) postProcessProject(project *types.Project, options api.ProjectLoadOptions) (*types.Project, error) {
 	if project.Name == "" {
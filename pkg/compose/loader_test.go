@@ -17,13 +17,18 @@
 package compose
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
@@ -319,3 +324,172 @@ func TestLoadProject_MissingComposeFile(t *testing.T) {
 	assert.Assert(t, err != nil)
 	assert.Assert(t, project == nil)
 }
+
+// fakeRemoteLoader serves a Compose file from a local directory, standing in
+// for the cache directory an OCI artifact or git repository is downloaded to.
+type fakeRemoteLoader struct {
+	prefix string
+	dir    string
+}
+
+func (f fakeRemoteLoader) Accept(path string) bool {
+	return strings.HasPrefix(path, f.prefix)
+}
+
+func (f fakeRemoteLoader) Load(context.Context, string) (string, error) {
+	return filepath.Join(f.dir, "compose.yaml"), nil
+}
+
+func (f fakeRemoteLoader) Dir(string) string {
+	return f.dir
+}
+
+func loadRemoteFixture(t *testing.T, prefix, source, projectDir string) (*types.Project, string, error) {
+	t.Helper()
+	cacheDir := t.TempDir()
+	composeContent := `
+name: remote
+services:
+  app:
+    image: alpine
+    volumes:
+      - ` + source + `:/data
+`
+	assert.NilError(t, os.WriteFile(filepath.Join(cacheDir, "compose.yaml"), []byte(composeContent), 0o644))
+
+	service, err := NewComposeService(nil)
+	assert.NilError(t, err)
+	project, err := service.LoadProject(t.Context(), api.ProjectLoadOptions{
+		ConfigPaths: []string{prefix + "example/project"},
+		WorkingDir:  projectDir,
+		ProjectOptionsFns: []cli.ProjectOptionsFn{
+			cli.WithResourceLoader(fakeRemoteLoader{prefix: prefix, dir: cacheDir}),
+		},
+	})
+	return project, cacheDir, err
+}
+
+func TestLoadProject_RemoteRelativeBind(t *testing.T) {
+	for _, prefix := range []string{"oci://", "https://github.com/"} {
+		t.Run(prefix, func(t *testing.T) {
+			_, _, err := loadRemoteFixture(t, prefix, "./data", "")
+			assert.ErrorContains(t, err, `service "app": bind mount source "./data" is relative to a remote Compose file`)
+			assert.ErrorContains(t, err, "--project-directory")
+		})
+	}
+}
+
+func TestLoadProject_RemoteRelativeBindWithProjectDirectory(t *testing.T) {
+	for _, prefix := range []string{"oci://", "https://github.com/"} {
+		t.Run(prefix, func(t *testing.T) {
+			projectDir := t.TempDir()
+			project, _, err := loadRemoteFixture(t, prefix, "./data", projectDir)
+			assert.NilError(t, err)
+			assert.Equal(t, project.Services["app"].Volumes[0].Source, filepath.Join(projectDir, "data"))
+		})
+	}
+}
+
+func TestLoadProject_RemoteRelativeBindAllowed(t *testing.T) {
+	t.Setenv(api.ComposeRemoteRelativePaths, "1")
+	project, cacheDir, err := loadRemoteFixture(t, "oci://", "./data", "")
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["app"].Volumes[0].Source, filepath.Join(cacheDir, "data"))
+}
+
+func TestLoadProject_RemoteAbsoluteBind(t *testing.T) {
+	source := t.TempDir()
+	project, _, err := loadRemoteFixture(t, "oci://", source, "")
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["app"].Volumes[0].Source, source)
+}
+
+func TestLoadProject_RemoteWindowsBind(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows paths are valid on Windows")
+	}
+	_, _, err := loadRemoteFixture(t, "oci://", `C:\data`, "")
+	assert.ErrorContains(t, err, `service "app": bind mount C:\data:/data uses host path "C:\\data", which isn't a valid path on `+runtime.GOOS)
+
+	t.Setenv(api.ComposePathMap, `C:\data=/home/me/data`)
+	project, _, err := loadRemoteFixture(t, "oci://", `C:\data\db`, "")
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["app"].Volumes[0].Source, "/home/me/data/db")
+}
+
+func TestLoadProject_ServiceTemplate(t *testing.T) {
+	service, err := NewComposeService(nil)
+	assert.NilError(t, err)
+
+	project, err := service.LoadProject(t.Context(), api.ProjectLoadOptions{
+		ConfigPaths: []string{filepath.Join("testdata", "templates", "compose.yaml")},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, project.ServiceNames(), []string{"broker", "worker-emails", "worker-reports"})
+	assert.Equal(t, project.Services["worker-emails"].CustomLabels[api.ServiceLabel], "worker-emails")
+
+	b, err := project.MarshalYAML()
+	assert.NilError(t, err)
+	golden.Assert(t, string(b), filepath.Join("templates", "expanded.golden"))
+}
+
+func TestLoadProject_ServiceTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name: "missing instances",
+			template: `
+  worker:
+    image: worker`,
+			err: "x-service-template.worker: x-instances must be a non-empty list of instances",
+		},
+		{
+			name: "duplicate instance",
+			template: `
+  worker:
+    x-instances: [a, a]
+    image: worker`,
+			err: `x-service-template.worker: duplicate instance "a"`,
+		},
+		{
+			name: "conflicting service",
+			template: `
+  web:
+    x-instances: [a]
+    image: worker`,
+			err: `x-service-template.web: service "web-a" is already defined`,
+		},
+		{
+			name: "invalid service",
+			template: `
+  worker:
+    x-instances: [a]
+    image: worker
+    restart: [no]`,
+			err: "services.worker-a.restart must be a string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			composeFile := filepath.Join(tmpDir, "compose.yaml")
+			composeContent := `
+name: templates
+services:
+  web-a:
+    image: nginx
+x-service-template:` + tt.template + "\n"
+			assert.NilError(t, os.WriteFile(composeFile, []byte(composeContent), 0o644))
+
+			service, err := NewComposeService(nil)
+			assert.NilError(t, err)
+			_, err = service.LoadProject(t.Context(), api.ProjectLoadOptions{
+				ConfigPaths: []string{composeFile},
+			})
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
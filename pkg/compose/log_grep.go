@@ -0,0 +1,109 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// logGrepSeparator is selected between groups of non-contiguous lines when
+// context lines are selected, as grep does
+const logGrepSeparator = "--"
+
+// logMatcher selects the lines of the log stream of a container matching a
+// LogGrep, along with the context lines around them
+type logMatcher struct {
+	grep api.LogGrep
+	// before holds the last lines not selected, to be selected before the
+	// next matching line
+	before *lineRing
+	// after is the number of lines left to select after the last matching one
+	after int
+	// line is the index of the next line
+	line int
+	// selected is the index of the last line selected, -1 when none is
+	selected int
+	// count is the number of matching lines
+	count int
+}
+
+func newLogMatcher(grep api.LogGrep) *logMatcher {
+	return &logMatcher{
+		grep:     grep,
+		before:   newLineRing(grep.Context),
+		selected: -1,
+	}
+}
+
+// match returns the lines to log once line is read
+func (m *logMatcher) match(line string) []string {
+	i := m.line
+	m.line++
+	if m.grep.Pattern.MatchString(line) == m.grep.Invert {
+		if m.after > 0 {
+			m.after--
+			m.selected = i
+			return []string{line}
+		}
+		m.before.push(line)
+		return nil
+	}
+	m.count++
+	m.after = m.grep.Context
+	before := m.before.drain()
+	var lines []string
+	if first := i - len(before); m.grep.Context > 0 && m.selected >= 0 && first > m.selected+1 {
+		lines = append(lines, logGrepSeparator)
+	}
+	m.selected = i
+	lines = append(lines, before...)
+	return append(lines, line)
+}
+
+// lineRing holds the last lines pushed, up to its capacity
+type lineRing struct {
+	lines []string
+	start int
+	size  int
+}
+
+func newLineRing(capacity int) *lineRing {
+	return &lineRing{lines: make([]string, capacity)}
+}
+
+func (r *lineRing) push(line string) {
+	if len(r.lines) == 0 {
+		return
+	}
+	if r.size < len(r.lines) {
+		r.lines[(r.start+r.size)%len(r.lines)] = line
+		r.size++
+		return
+	}
+	r.lines[r.start] = line
+	r.start = (r.start + 1) % len(r.lines)
+}
+
+// drain returns the lines held, oldest first, and empties the ring
+func (r *lineRing) drain() []string {
+	lines := make([]string, 0, r.size)
+	for i := range r.size {
+		lines = append(lines, r.lines[(r.start+i)%len(r.lines)])
+	}
+	r.start, r.size = 0, 0
+	return lines
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestLogMatcher(t *testing.T) {
+	// lines 0 to 19, with errors on lines 3, 5, 12 and 19
+	var lines []string
+	for i := range 20 {
+		switch i {
+		case 3, 5, 12, 19:
+			lines = append(lines, fmt.Sprintf("%d error", i))
+		default:
+			lines = append(lines, fmt.Sprintf("%d info", i))
+		}
+	}
+	tests := []struct {
+		name    string
+		context int
+		invert  bool
+		want    []string
+		count   int
+	}{
+		{
+			name:  "no context",
+			want:  []string{"3 error", "5 error", "12 error", "19 error"},
+			count: 4,
+		},
+		{
+			// the context of line 3 and 5 overlaps, the ring holding the
+			// lines before line 12 wraps
+			name:    "context",
+			context: 2,
+			want: []string{
+				"1 info", "2 info", "3 error", "4 info", "5 error", "6 info", "7 info",
+				"--",
+				"10 info", "11 info", "12 error", "13 info", "14 info",
+				"--",
+				"17 info", "18 info", "19 error",
+			},
+			count: 4,
+		},
+		{
+			// contiguous groups aren't separated
+			name:    "adjacent context",
+			context: 3,
+			want: []string{
+				"0 info", "1 info", "2 info", "3 error", "4 info", "5 error", "6 info", "7 info", "8 info",
+				"9 info", "10 info", "11 info", "12 error", "13 info", "14 info", "15 info",
+				"16 info", "17 info", "18 info", "19 error",
+			},
+			count: 4,
+		},
+		{
+			name:    "invert",
+			context: 1,
+			invert:  true,
+			want: []string{
+				"0 info", "1 info", "2 info", "3 error", "4 info", "5 error", "6 info", "7 info", "8 info",
+				"9 info", "10 info", "11 info", "12 error", "13 info", "14 info", "15 info",
+				"16 info", "17 info", "18 info", "19 error",
+			},
+			count: 16,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher := newLogMatcher(api.LogGrep{
+				Pattern: regexp.MustCompile(`error$`),
+				Context: tt.context,
+				Invert:  tt.invert,
+			})
+			var got []string
+			for _, line := range lines {
+				got = append(got, matcher.match(line)...)
+			}
+			assert.DeepEqual(t, got, tt.want)
+			assert.Equal(t, matcher.count, tt.count)
+		})
+	}
+}
@@ -37,6 +37,10 @@ func (s *composeService) Logs(
 	consumer api.LogConsumer,
 	options api.LogOptions,
 ) error {
+	if err := s.useDockerContexts(options.Project); err != nil {
+		return err
+	}
+
 	var containers Containers
 	var err error
 
@@ -90,11 +94,13 @@ func (s *composeService) Logs(
 					}
 
 					err = s.doLogContainer(ctx, consumer, event.Source, res.Container, api.LogOptions{
-						Follow:     options.Follow,
-						Since:      res.Container.State.StartedAt,
-						Until:      options.Until,
-						Tail:       options.Tail,
-						Timestamps: options.Timestamps,
+						Follow:       options.Follow,
+						Since:        res.Container.State.StartedAt,
+						Until:        options.Until,
+						Tail:         options.Tail,
+						Timestamps:   options.Timestamps,
+						LogProcessor: options.LogProcessor,
+						Grep:         options.Grep,
 					})
 					if errdefs.IsNotImplemented(err) {
 						// ignore
@@ -137,8 +143,30 @@ func (s *composeService) doLogContainer(ctx context.Context, consumer api.LogCon
 	}
 	defer r.Close() //nolint:errcheck
 
+	service := ctr.Config.Labels[api.ServiceLabel]
+	var matcher *logMatcher
+	if options.Grep != nil {
+		matcher = newLogMatcher(*options.Grep)
+		if options.Grep.Matched != nil {
+			defer func() {
+				options.Grep.Matched(service, matcher.count)
+			}()
+		}
+	}
 	w := utils.GetWriter(func(line string) {
-		consumer.Log(name, line)
+		if options.LogProcessor != nil {
+			var keep bool
+			if line, keep = options.LogProcessor(service, line); !keep {
+				return
+			}
+		}
+		if matcher == nil {
+			consumer.Log(name, line)
+			return
+		}
+		for _, l := range matcher.match(line) {
+			consumer.Log(name, l)
+		}
 	})
 	if ctr.Config.Tty {
 		_, err = io.Copy(w, r)
@@ -21,6 +21,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
@@ -219,6 +220,113 @@ func TestComposeService_Logs_ServiceFiltering(t *testing.T) {
 	assert.Assert(t, is.DeepEqual([]string{"hello c4"}, consumer.LogsForContainer("c4")))
 }
 
+func TestComposeService_Logs_LogProcessor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	name := strings.ToLower(testProject)
+
+	api.EXPECT().ContainerList(t.Context(), client.ContainerListOptions{
+		All:     true,
+		Filters: projectFilter(name).Add("label", oneOffFilter(false), compose.ConfigHashLabel),
+	}).Return(
+		client.ContainerListResult{
+			Items: []containerType.Summary{
+				testContainer("service", "c", false),
+			},
+		},
+		nil,
+	)
+	api.EXPECT().
+		ContainerInspect(anyCancellableContext(), "c", gomock.Any()).
+		Return(client.ContainerInspectResult{
+			Container: containerType.InspectResponse{
+				ID:     "c",
+				Config: &containerType.Config{Tty: true, Labels: map[string]string{compose.ServiceLabel: "service"}},
+			},
+		}, nil)
+	api.EXPECT().ContainerLogs(anyCancellableContext(), "c", gomock.Any()).
+		Return(io.NopCloser(strings.NewReader("password=secret\nhello\ndebug: noise\n")), nil)
+
+	opts := compose.LogOptions{
+		Project: &types.Project{
+			Services: types.Services{
+				"service": {Name: "service"},
+			},
+		},
+		LogProcessor: func(service, line string) (string, bool) {
+			if strings.HasPrefix(line, "debug:") {
+				return "", false
+			}
+			return service + ": " + strings.ReplaceAll(line, "secret", "***"), true
+		},
+	}
+
+	consumer := &testLogConsumer{}
+	err = tested.Logs(t.Context(), name, consumer, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"service: password=***", "service: hello"}, consumer.LogsForContainer("c"))
+}
+
+func TestComposeService_Logs_Grep(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested, err := NewComposeService(cli)
+	assert.NilError(t, err)
+
+	name := strings.ToLower(testProject)
+
+	api.EXPECT().ContainerList(t.Context(), client.ContainerListOptions{
+		All:     true,
+		Filters: projectFilter(name).Add("label", oneOffFilter(false), compose.ConfigHashLabel),
+	}).Return(
+		client.ContainerListResult{
+			Items: []containerType.Summary{
+				testContainer("service", "c", false),
+			},
+		},
+		nil,
+	)
+	api.EXPECT().
+		ContainerInspect(anyCancellableContext(), "c", gomock.Any()).
+		Return(client.ContainerInspectResult{
+			Container: containerType.InspectResponse{
+				ID:     "c",
+				Config: &containerType.Config{Tty: true, Labels: map[string]string{compose.ServiceLabel: "service"}},
+			},
+		}, nil)
+	api.EXPECT().ContainerLogs(anyCancellableContext(), "c", gomock.Any()).
+		Return(io.NopCloser(strings.NewReader("starting\nready\nGET /\nerror: timeout\nGET /health\n")), nil)
+
+	counts := map[string]int{}
+	opts := compose.LogOptions{
+		Project: &types.Project{
+			Services: types.Services{
+				"service": {Name: "service"},
+			},
+		},
+		Grep: &compose.LogGrep{
+			Pattern: regexp.MustCompile(`^error`),
+			Context: 1,
+			Matched: func(service string, count int) {
+				counts[service] += count
+			},
+		},
+	}
+
+	consumer := &testLogConsumer{}
+	err = tested.Logs(t.Context(), name, consumer, opts)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{"GET /", "error: timeout", "GET /health"}, consumer.LogsForContainer("c"))
+	assert.DeepEqual(t, counts, map[string]int{"service": 1})
+}
+
 type testLogConsumer struct {
 	mu sync.Mutex
 	// logs is keyed by container ID; values are log lines
@@ -59,12 +59,12 @@ func (s *composeService) ensureModels(ctx context.Context, project *types.Projec
 		}
 		eg.Go(func() error {
 			if !slices.Contains(availableModels, config.Model) {
-				err = mdlAPI.PullModel(ctx, config, quietPull, s.events)
+				err = mdlAPI.PullModel(ctx, config, quietPull, s.events(ctx))
 				if err != nil {
 					return err
 				}
 			}
-			return mdlAPI.ConfigureModel(ctx, config, s.events)
+			return mdlAPI.ConfigureModel(ctx, config, s.events(ctx))
 		})
 	}
 	return eg.Wait()
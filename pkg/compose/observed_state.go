@@ -18,12 +18,16 @@ package compose
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/containerd/errdefs"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
+	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
 
 	"github.com/docker/compose/v5/pkg/api"
@@ -39,6 +43,19 @@ type ObservedState struct {
 	Orphans     []ObservedContainer            // containers with no matching service
 	Networks    map[string]ObservedNetwork     // compose network key → observed
 	Volumes     map[string]ObservedVolume      // compose volume key → observed
+
+	// ImageIDs maps an image reference to its local image ID. It is only
+	// populated for services whose digest comparison is ambiguous (see
+	// discoverAmbiguousImageIDs).
+	ImageIDs map[string]string
+
+	// ObservedAt is when the state was collected, the reference to compute
+	// container ages from.
+	ObservedAt time.Time
+
+	// Host holds the resources of the host, only collected for the resource
+	// preflight check.
+	Host *HostResources
 }
 
 // ObservedContainer holds the relevant state extracted from a running or stopped
@@ -49,6 +66,7 @@ type ObservedContainer struct {
 	State       container.ContainerState // "running", "exited", "created", "restarting", etc.
 	ConfigHash  string                   // label com.docker.compose.config-hash
 	ImageDigest string                   // label com.docker.compose.image
+	ImageID     string                   // ID of the image the container runs
 	Number      int                      // label com.docker.compose.container-number
 
 	// ConnectedNetworks maps network IDs found in the container's network
@@ -56,8 +74,53 @@ type ObservedContainer struct {
 	// network ID.
 	ConnectedNetworks map[string]string
 
-	// Raw summary kept for the executor which needs it to call Moby APIs.
-	Summary container.Summary
+	// Labels only holds the com.docker.compose.* labels, the only ones read
+	// during reconciliation.
+	Labels  map[string]string
+	Image   string
+	Created int64
+	Mounts  []ObservedMount
+	// RestartCount is the number of times the engine restarted the
+	// container, only inspected for restarting containers and the exited
+	// ones with a restart policy retrying on failure a limited number of
+	// times.
+	RestartCount int
+	// FinishedAt is when the container last exited, only inspected for
+	// restarting containers.
+	FinishedAt time.Time
+	// ExitCode and MaxRestartRetries are the exit code of the container and
+	// the maximum retry count of its restart policy, only inspected for the
+	// exited containers with a restart policy retrying on failure a limited
+	// number of times.
+	ExitCode          int
+	MaxRestartRetries int
+	// References lists the IDs or names of the containers this one shares
+	// the network, IPC or PID namespace or the volumes of, only inspected for
+	// the running containers of services declaring such a reference to
+	// another service.
+	References []string
+	// Resources holds the resource settings the container was created with,
+	// only inspected for the containers without a config hash label, which
+	// are compared with the service configuration field by field.
+	Resources *ObservedResources
+}
+
+// ObservedResources holds the resource settings of a container compared with
+// the service configuration when the container has no config hash label.
+type ObservedResources struct {
+	Ulimits     []*container.Ulimit
+	Sysctls     map[string]string
+	BlkioWeight uint16
+}
+
+// ObservedMount holds the attributes of a container mount used to check
+// volumes and to inherit anonymous volumes on recreate.
+type ObservedMount struct {
+	Type        mount.Type
+	Name        string
+	Source      string
+	Destination string
+	RW          bool
 }
 
 // ObservedNetwork holds the state of a Docker network that belongs to the
@@ -88,6 +151,8 @@ func (s *composeService) collectObservedState(ctx context.Context, project *type
 		Containers:  map[string][]ObservedContainer{},
 		Networks:    map[string]ObservedNetwork{},
 		Volumes:     map[string]ObservedVolume{},
+		ImageIDs:    map[string]string{},
+		ObservedAt:  s.clock.Now(),
 	}
 
 	// --- Containers ---
@@ -116,6 +181,22 @@ func (s *composeService) collectObservedState(ctx context.Context, project *type
 		}
 	}
 
+	if err := s.discoverAmbiguousImageIDs(ctx, project, state); err != nil {
+		return nil, err
+	}
+	if err := s.inspectRestartingContainers(ctx, state); err != nil {
+		return nil, err
+	}
+	if err := s.inspectExitedContainers(ctx, project, state); err != nil {
+		return nil, err
+	}
+	if err := s.inspectContainerReferences(ctx, project, state); err != nil {
+		return nil, err
+	}
+	if err := s.inspectContainersWithoutHash(ctx, project, state); err != nil {
+		return nil, err
+	}
+
 	// --- Networks ---
 	nwList, err := s.apiClient().NetworkList(ctx, client.NetworkListOptions{
 		Filters: projectFilter(project.Name),
@@ -196,6 +277,209 @@ func (s *composeService) discoverUnmanagedVolumes(ctx context.Context, project *
 	return nil
 }
 
+// discoverAmbiguousImageIDs records the local image ID of services for which
+// only one side of the image digest comparison is known: the expected digest
+// label is missing (image couldn't be inspected before create) or a container
+// lacks it (created by an older Compose release). The reconciler then compares
+// image IDs instead of forcing a recreate on a label it cannot trust.
+func (s *composeService) discoverAmbiguousImageIDs(ctx context.Context, project *types.Project, state *ObservedState) error {
+	for _, service := range project.Services {
+		expected := service.CustomLabels[api.ImageDigestLabel]
+		ambiguous := false
+		for _, oc := range state.Containers[service.Name] {
+			if (oc.ImageDigest == "") != (expected == "") {
+				ambiguous = true
+				break
+			}
+		}
+		if !ambiguous {
+			continue
+		}
+		imageName := api.GetImageNameOrDefault(service, project.Name)
+		if _, ok := state.ImageIDs[imageName]; ok {
+			continue
+		}
+		inspected, err := s.apiClient().ImageInspect(ctx, imageName)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		state.ImageIDs[imageName] = inspected.ID
+	}
+	return nil
+}
+
+// inspectRestartingContainers records the restart count of the containers the
+// engine is restarting, and when they last exited, which the container list
+// doesn't report, to tell the ones stuck in a crash loop.
+func (s *composeService) inspectRestartingContainers(ctx context.Context, state *ObservedState) error {
+	for _, containers := range state.Containers {
+		for i, oc := range containers {
+			if oc.State != container.StateRestarting {
+				continue
+			}
+			inspected, err := s.apiClient().ContainerInspect(ctx, oc.ID, client.ContainerInspectOptions{})
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			containers[i].RestartCount = inspected.Container.RestartCount
+			if inspected.Container.State != nil {
+				containers[i].FinishedAt, _ = time.Parse(time.RFC3339Nano, inspected.Container.State.FinishedAt)
+			}
+		}
+	}
+	return nil
+}
+
+// inspectExitedContainers records the exit code and restart count of the
+// exited containers of services with a restart policy retrying on failure a
+// limited number of times, which the container list doesn't report, to tell
+// the ones the engine gave up restarting.
+func (s *composeService) inspectExitedContainers(ctx context.Context, project *types.Project, state *ObservedState) error {
+	for name, containers := range state.Containers {
+		service, ok := project.Services[name]
+		if !ok {
+			continue
+		}
+		if !retriesOnFailure(service) {
+			continue
+		}
+		for i, oc := range containers {
+			if oc.State != container.StateExited {
+				continue
+			}
+			inspected, err := s.apiClient().ContainerInspect(ctx, oc.ID, client.ContainerInspectOptions{})
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			containers[i].setRestartState(inspected.Container)
+		}
+	}
+	return nil
+}
+
+// retriesOnFailure reports whether the restart policy of the service retries
+// on failure a limited number of times
+func retriesOnFailure(service types.ServiceConfig) bool {
+	policy := getRestartPolicy(service)
+	return policy.IsOnFailure() && policy.MaximumRetryCount > 0
+}
+
+// setRestartState records the exit code and restart count of the inspected
+// container, and the maximum retry count of its restart policy
+func (oc *ObservedContainer) setRestartState(inspected container.InspectResponse) {
+	oc.RestartCount = inspected.RestartCount
+	if inspected.State != nil {
+		oc.ExitCode = inspected.State.ExitCode
+	}
+	if inspected.HostConfig != nil && inspected.HostConfig.RestartPolicy.IsOnFailure() {
+		oc.MaxRestartRetries = inspected.HostConfig.RestartPolicy.MaximumRetryCount
+	}
+}
+
+// inspectContainerReferences records the containers the running containers
+// of services referencing another service with network_mode, ipc, pid:
+// service:x or volumes_from share namespaces or volumes with, as resolved on
+// creation.
+func (s *composeService) inspectContainerReferences(ctx context.Context, project *types.Project, state *ObservedState) error {
+	for name, containers := range state.Containers {
+		service, ok := project.Services[name]
+		if !ok || !referencesServices(service) {
+			continue
+		}
+		for i, oc := range containers {
+			if oc.State != container.StateRunning {
+				continue
+			}
+			inspected, err := s.apiClient().ContainerInspect(ctx, oc.ID, client.ContainerInspectOptions{})
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if inspected.Container.HostConfig == nil {
+				continue
+			}
+			containers[i].References = containerReferences(inspected.Container.HostConfig)
+		}
+	}
+	return nil
+}
+
+// inspectContainersWithoutHash records the resource settings of the service
+// containers without a config hash label, created by another tool or an older
+// version, the configuration they were created with being unknown.
+func (s *composeService) inspectContainersWithoutHash(ctx context.Context, project *types.Project, state *ObservedState) error {
+	for name, containers := range state.Containers {
+		if _, ok := project.Services[name]; !ok {
+			continue
+		}
+		for i, oc := range containers {
+			if oc.ConfigHash != "" {
+				continue
+			}
+			inspected, err := s.apiClient().ContainerInspect(ctx, oc.ID, client.ContainerInspectOptions{})
+			if err != nil {
+				if errdefs.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			hostConfig := inspected.Container.HostConfig
+			if hostConfig == nil {
+				continue
+			}
+			containers[i].Resources = &ObservedResources{
+				Ulimits:     hostConfig.Ulimits,
+				Sysctls:     hostConfig.Sysctls,
+				BlkioWeight: hostConfig.BlkioWeight,
+			}
+		}
+	}
+	return nil
+}
+
+// referencesServices reports whether service shares the namespaces or the
+// volumes of another service
+func referencesServices(service types.ServiceConfig) bool {
+	for _, mode := range []string{service.NetworkMode, service.Ipc, service.Pid} {
+		if getDependentServiceFromMode(mode) != "" {
+			return true
+		}
+	}
+	for _, vol := range service.VolumesFrom {
+		if !strings.HasPrefix(vol, types.ContainerPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerReferences returns the containers a container configured with
+// hostConfig shares namespaces or volumes with
+func containerReferences(hostConfig *container.HostConfig) []string {
+	var refs []string
+	for _, mode := range []string{string(hostConfig.NetworkMode), string(hostConfig.IpcMode), string(hostConfig.PidMode)} {
+		if ref, ok := strings.CutPrefix(mode, types.ContainerPrefix); ok {
+			refs = append(refs, ref)
+		}
+	}
+	for _, vol := range hostConfig.VolumesFrom {
+		ref, _, _ := strings.Cut(vol, ":")
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
 // toObservedContainer extracts the relevant fields from a container.Summary,
 // parsing labels into typed values.
 func toObservedContainer(c container.Summary) ObservedContainer {
@@ -208,18 +492,92 @@ func toObservedContainer(c container.Summary) ObservedContainer {
 		}
 	}
 
+	labels := map[string]string{}
+	for k, v := range c.Labels {
+		if strings.HasPrefix(k, composeLabelPrefix) {
+			labels[k] = v
+		}
+	}
+
+	var mounts []ObservedMount
+	for _, m := range c.Mounts {
+		mounts = append(mounts, ObservedMount{
+			Type:        m.Type,
+			Name:        m.Name,
+			Source:      m.Source,
+			Destination: m.Destination,
+			RW:          m.RW,
+		})
+	}
+
 	return ObservedContainer{
 		ID:                c.ID,
 		Name:              getCanonicalContainerName(c),
 		State:             c.State,
 		ConfigHash:        c.Labels[api.ConfigHashLabel],
 		ImageDigest:       c.Labels[api.ImageDigestLabel],
+		ImageID:           c.ImageID,
 		Number:            number,
 		ConnectedNetworks: networks,
-		Summary:           c,
+		Labels:            labels,
+		Image:             c.Image,
+		Created:           c.Created,
+		Mounts:            mounts,
 	}
 }
 
+// composeLabelPrefix is the namespace of the labels set by Compose.
+const composeLabelPrefix = "com.docker.compose."
+
+// summary rebuilds the container.Summary the executor passes to Moby helpers,
+// holding only the attributes kept in the observed record.
+func (oc *ObservedContainer) summary() *container.Summary {
+	ctr := &container.Summary{
+		ID:      oc.ID,
+		Image:   oc.Image,
+		ImageID: oc.ImageID,
+		Labels:  oc.Labels,
+		State:   oc.State,
+		Created: oc.Created,
+	}
+	if oc.Name != "" {
+		ctr.Names = []string{"/" + oc.Name}
+	}
+	for _, m := range oc.Mounts {
+		ctr.Mounts = append(ctr.Mounts, container.MountPoint{
+			Type:        m.Type,
+			Name:        m.Name,
+			Source:      m.Source,
+			Destination: m.Destination,
+			RW:          m.RW,
+		})
+	}
+	if len(oc.ConnectedNetworks) > 0 {
+		ctr.NetworkSettings = &container.NetworkSettingsSummary{Networks: map[string]*network.EndpointSettings{}}
+		for name, id := range oc.ConnectedNetworks {
+			ctr.NetworkSettings.Networks[name] = &network.EndpointSettings{NetworkID: id}
+		}
+	}
+	return ctr
+}
+
+// stopped reports whether oc has no process to stop
+func (oc ObservedContainer) stopped() bool {
+	return isStopped(container.Summary{State: oc.State})
+}
+
+// restartsExhausted reports whether the engine gave up restarting oc, which
+// exited with an error after as many restarts as its restart policy allows
+func (oc ObservedContainer) restartsExhausted() bool {
+	return oc.State == container.StateExited && oc.ExitCode != 0 &&
+		oc.MaxRestartRetries > 0 && oc.RestartCount >= oc.MaxRestartRetries
+}
+
+// excluded reports whether oc is labeled to be left out of convergence
+func (oc ObservedContainer) excluded() bool {
+	return oc.Labels[api.ConvergenceLabel] == api.ConvergenceIgnore
+}
+
 // setResolvedNetworks injects network IDs already resolved by ensureNetworks
 // into the observed state, so the reconciler can compare container connections
 // against actual network IDs.
@@ -249,14 +607,14 @@ func (s *ObservedState) setResolvedVolumes(volumes map[string]string) {
 	}
 }
 
-// emitRunningEvents emits "Running" progress events for containers that are already
-// running and have no operations planned for them. This matches the previous behavior
-// where convergence.ensureService emitted runningEvent for up-to-date containers.
+// emitUnchangedEvents emits "Unchanged" progress events for containers that are
+// already running and have no operations planned for them, so users can tell the
+// containers left untouched by up from the ones that were started or recreated.
 //
 // Iterates project.Services (not observed.Containers) so that containers of
 // disabled services (e.g. dependencies untouched by `compose run --no-deps`)
-// are not falsely reported as Running — see issue 13882.
-func emitRunningEvents(project *types.Project, observed *ObservedState, plan *Plan, events api.EventProcessor) {
+// are not falsely reported — see issue 13882.
+func emitUnchangedEvents(project *types.Project, observed *ObservedState, plan *Plan, events api.EventProcessor) {
 	planned := map[string]bool{}
 	for _, node := range plan.Nodes {
 		if node.Operation.Container != nil {
@@ -267,12 +625,36 @@ func emitRunningEvents(project *types.Project, observed *ObservedState, plan *Pl
 	for _, svc := range project.Services {
 		for _, oc := range observed.Containers[svc.Name] {
 			if oc.State == container.StateRunning && !planned[oc.ID] {
-				events.On(newEvent("Container "+oc.Name, api.Done, api.StatusRunning))
+				events.On(newEvent("Container "+oc.Name, api.Done, api.StatusUnchanged))
 			}
 		}
 	}
 }
 
+// emitRestartsExhaustedEvents emits a warning for each container the engine
+// gave up restarting once the retries of its restart policy were exhausted,
+// telling what is done with it
+func emitRestartsExhaustedEvents(project *types.Project, observed *ObservedState, policy string, events api.EventProcessor) {
+	var decision string
+	switch policy {
+	case api.RestartExhaustedRecreate:
+		decision = "recreating it"
+	case api.RestartExhaustedIgnore:
+		decision = "leaving it exited"
+	default:
+		decision = "starting it again"
+	}
+	for _, name := range project.ServiceNames() {
+		for _, oc := range observed.Containers[name] {
+			if !oc.restartsExhausted() {
+				continue
+			}
+			events.On(newEvent("Container "+oc.Name, api.Warning, "Restarts exhausted",
+				fmt.Sprintf("exited with code %d after %d restarts, %s", oc.ExitCode, oc.RestartCount, decision)))
+		}
+	}
+}
+
 // orphanNames returns the names of orphaned containers as a comma-separated string.
 func (s *ObservedState) orphanNames() string {
 	names := make([]string, len(s.Orphans))
@@ -282,6 +664,18 @@ func (s *ObservedState) orphanNames() string {
 	return strings.Join(names, ", ")
 }
 
+// servicesWithContainers returns the names of the services with observed
+// containers, sorted
+func (s *ObservedState) servicesWithContainers() []string {
+	var services []string
+	for _, name := range sortedKeys(s.Containers) {
+		if len(s.Containers[name]) > 0 {
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
 // containersByService flattens the observed containers into the shape
 // resolveServiceReferences expects: project service name → raw Summaries.
 func (s *ObservedState) containersByService() map[string]Containers {
@@ -292,7 +686,7 @@ func (s *ObservedState) containersByService() map[string]Containers {
 	for svc, ocs := range s.Containers {
 		summaries := make(Containers, len(ocs))
 		for i, oc := range ocs {
-			summaries[i] = oc.Summary
+			summaries[i] = *oc.summary()
 		}
 		result[svc] = summaries
 	}
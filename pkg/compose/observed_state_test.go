@@ -17,11 +17,16 @@
 package compose
 
 import (
+	"fmt"
+	"net/netip"
+	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/mount"
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/api/types/volume"
 	"github.com/moby/moby/client"
@@ -62,7 +67,6 @@ func TestToObservedContainer(t *testing.T) {
 	assert.Equal(t, oc.ImageDigest, "sha256:bbb")
 	assert.Equal(t, oc.Number, 1)
 	assert.Equal(t, oc.ConnectedNetworks["mynet"], "net123")
-	assert.Equal(t, oc.Summary.ID, "abc123")
 }
 
 func TestToObservedContainerNoNetworkSettings(t *testing.T) {
@@ -82,6 +86,127 @@ func TestToObservedContainerNoNetworkSettings(t *testing.T) {
 	assert.Equal(t, len(oc.ConnectedNetworks), 0)
 }
 
+func TestToObservedContainerKeepsOnlyUsedFields(t *testing.T) {
+	c := syntheticContainer(1)
+	oc := toObservedContainer(c)
+
+	assert.DeepEqual(t, oc.Labels, map[string]string{
+		api.ProjectLabel:         "bench",
+		api.ServiceLabel:         "web",
+		api.ContainerNumberLabel: "1",
+		api.ConfigHashLabel:      "sha256:aaa",
+		api.ImageDigestLabel:     "sha256:bbb",
+	})
+	assert.DeepEqual(t, oc.Mounts, []ObservedMount{
+		{Type: mount.TypeVolume, Name: "bench_data", Source: "/var/lib/docker/volumes/bench_data/_data", Destination: "/data", RW: true},
+		{Type: mount.TypeBind, Source: "/srv/conf", Destination: "/etc/conf"},
+	})
+
+	// the executor gets back what it needs to drive the Moby API
+	summary := oc.summary()
+	assert.Equal(t, summary.ID, c.ID)
+	assert.Equal(t, getCanonicalContainerName(*summary), "bench-web-1")
+	assert.Equal(t, summary.Labels[api.ServiceLabel], "web")
+	assert.Equal(t, summary.Created, c.Created)
+	assert.Equal(t, summary.Mounts[0].Name, "bench_data")
+	assert.Equal(t, summary.NetworkSettings.Networks["bench_default"].NetworkID, "net-default")
+}
+
+// syntheticContainer returns a container.Summary as decoded from the Moby API
+// for a typical service container.
+func syntheticContainer(n int) container.Summary {
+	return container.Summary{
+		ID:      fmt.Sprintf("%064d", n),
+		Names:   []string{fmt.Sprintf("/bench-web-%d", n)},
+		Image:   "registry.example.com/team/web:1.2.3",
+		ImageID: "sha256:" + strings.Repeat("c", 64),
+		Command: "/docker-entrypoint.sh nginx -g 'daemon off;'",
+		Created: 1700000000 + int64(n),
+		State:   container.StateRunning,
+		Status:  "Up 3 hours (healthy)",
+		Ports: []container.PortSummary{
+			{IP: netip.MustParseAddr("0.0.0.0"), PrivatePort: 80, PublicPort: uint16(8000 + n%1000), Type: "tcp"},
+			{IP: netip.MustParseAddr("::"), PrivatePort: 80, PublicPort: uint16(8000 + n%1000), Type: "tcp"},
+		},
+		Labels: map[string]string{
+			api.ProjectLabel:                         "bench",
+			api.ServiceLabel:                         "web",
+			api.ContainerNumberLabel:                 strconv.Itoa(n),
+			api.ConfigHashLabel:                      "sha256:aaa",
+			api.ImageDigestLabel:                     "sha256:bbb",
+			"org.opencontainers.image.source":        "https://github.com/example/web",
+			"org.opencontainers.image.revision":      strings.Repeat("d", 40),
+			"org.opencontainers.image.description":   "A web server used to benchmark the observed state",
+			"maintainer":                             "NGINX Docker Maintainers <docker-maint@nginx.com>",
+			"com.example.team":                       "platform",
+			"com.example.cost-center":                "1234",
+			"traefik.http.routers.web.rule":          "Host(`web.example.com`)",
+			"traefik.http.services.web.loadbalancer": "80",
+		},
+		HostConfig: struct {
+			NetworkMode string            `json:",omitempty"`
+			Annotations map[string]string `json:",omitempty"`
+		}{NetworkMode: "bench_default"},
+		NetworkSettings: &container.NetworkSettingsSummary{
+			Networks: map[string]*network.EndpointSettings{
+				"bench_default": {
+					NetworkID:  "net-default",
+					EndpointID: strings.Repeat("e", 64),
+					Gateway:    netip.MustParseAddr("172.18.0.1"),
+					IPAddress:  netip.MustParseAddr("172.18.0.2"),
+					Aliases:    []string{"web", fmt.Sprintf("bench-web-%d", n)},
+					DNSNames:   []string{"web", fmt.Sprintf("bench-web-%d", n), fmt.Sprintf("%064d", n)[:12]},
+				},
+				"bench_backend": {
+					NetworkID:  "net-backend",
+					EndpointID: strings.Repeat("f", 64),
+					Gateway:    netip.MustParseAddr("172.19.0.1"),
+					IPAddress:  netip.MustParseAddr("172.19.0.2"),
+					Aliases:    []string{"web"},
+					DNSNames:   []string{"web", fmt.Sprintf("bench-web-%d", n)},
+				},
+			},
+		},
+		Mounts: []container.MountPoint{
+			{Type: mount.TypeVolume, Name: "bench_data", Source: "/var/lib/docker/volumes/bench_data/_data", Destination: "/data", Driver: "local", Mode: "z", RW: true},
+			{Type: mount.TypeBind, Source: "/srv/conf", Destination: "/etc/conf", Mode: "ro", Propagation: mount.PropagationRPrivate},
+		},
+	}
+}
+
+// BenchmarkObservedContainers compares the heap retained by 1000 observed
+// containers when keeping the raw container.Summary, as the observed state
+// used to, with the slim ObservedContainer record.
+func BenchmarkObservedContainers(b *testing.B) {
+	const containers = 1000
+	for _, bc := range []struct {
+		name    string
+		observe func(container.Summary) any
+	}{
+		{name: "summary", observe: func(c container.Summary) any { return c }},
+		{name: "observed", observe: func(c container.Summary) any { return toObservedContainer(c) }},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var retained uint64
+			for b.Loop() {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				state := make([]any, containers)
+				for i := range state {
+					state[i] = bc.observe(syntheticContainer(i))
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				runtime.KeepAlive(state)
+				retained = after.HeapAlloc - before.HeapAlloc
+			}
+			b.ReportMetric(float64(retained)/containers, "retained-B/container")
+		})
+	}
+}
+
 func TestCollectObservedState(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 
@@ -325,13 +450,13 @@ func (c *capturingEvents) On(events ...api.Resource) {
 	c.resources = append(c.resources, events...)
 }
 
-func TestEmitRunningEvents(t *testing.T) {
+func TestEmitUnchangedEvents(t *testing.T) {
 	runningWeb := ObservedContainer{ID: "c-web", Name: "p-web-1", State: container.StateRunning}
 	runningDB := ObservedContainer{ID: "c-db", Name: "p-db-1", State: container.StateRunning}
 	runningDisabled := ObservedContainer{ID: "c-misc", Name: "p-misc-1", State: container.StateRunning}
 	exitedWeb := ObservedContainer{ID: "c-web-old", Name: "p-web-2", State: container.StateExited}
 
-	t.Run("emits Running for active services not in plan", func(t *testing.T) {
+	t.Run("emits Unchanged for active services not in plan", func(t *testing.T) {
 		project := &types.Project{
 			Services: types.Services{
 				"web": {Name: "web"},
@@ -346,13 +471,13 @@ func TestEmitRunningEvents(t *testing.T) {
 		}
 		events := &capturingEvents{}
 
-		emitRunningEvents(project, observed, &Plan{}, events)
+		emitUnchangedEvents(project, observed, &Plan{}, events)
 
 		assert.Equal(t, len(events.resources), 2)
 		names := map[string]bool{}
 		for _, r := range events.resources {
 			names[r.ID] = true
-			assert.Equal(t, r.Text, api.StatusRunning)
+			assert.Equal(t, r.Text, api.StatusUnchanged)
 		}
 		assert.Assert(t, names["Container p-web-1"])
 		assert.Assert(t, names["Container p-db-1"])
@@ -377,7 +502,7 @@ func TestEmitRunningEvents(t *testing.T) {
 		}
 		events := &capturingEvents{}
 
-		emitRunningEvents(project, observed, &Plan{}, events)
+		emitUnchangedEvents(project, observed, &Plan{}, events)
 
 		assert.Equal(t, len(events.resources), 0)
 	})
@@ -396,7 +521,7 @@ func TestEmitRunningEvents(t *testing.T) {
 		}, "")
 		events := &capturingEvents{}
 
-		emitRunningEvents(project, observed, plan, events)
+		emitUnchangedEvents(project, observed, plan, events)
 
 		assert.Equal(t, len(events.resources), 0)
 	})
@@ -410,8 +535,48 @@ func TestEmitRunningEvents(t *testing.T) {
 		}
 		events := &capturingEvents{}
 
-		emitRunningEvents(project, observed, &Plan{}, events)
+		emitUnchangedEvents(project, observed, &Plan{}, events)
 
 		assert.Equal(t, len(events.resources), 0)
 	})
 }
+
+func TestEmitRestartsExhaustedEvents(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{"web": {Name: "web", Restart: "on-failure:3"}},
+	}
+	observed := &ObservedState{
+		Containers: map[string][]ObservedContainer{"web": {
+			{ID: "c-web-1", Name: "p-web-1", State: container.StateExited, ExitCode: 2, RestartCount: 3, MaxRestartRetries: 3},
+			{ID: "c-web-2", Name: "p-web-2", State: container.StateExited, ExitCode: 2, RestartCount: 1, MaxRestartRetries: 3},
+			{ID: "c-web-3", Name: "p-web-3", State: container.StateRunning, RestartCount: 3, MaxRestartRetries: 3},
+		}},
+	}
+	for policy, decision := range map[string]string{
+		api.RestartExhaustedRestart:  "starting it again",
+		api.RestartExhaustedRecreate: "recreating it",
+		api.RestartExhaustedIgnore:   "leaving it exited",
+	} {
+		t.Run(policy, func(t *testing.T) {
+			events := &capturingEvents{}
+			emitRestartsExhaustedEvents(project, observed, policy, events)
+			assert.DeepEqual(t, events.resources, []api.Resource{
+				newEvent("Container p-web-1", api.Warning, "Restarts exhausted", "exited with code 2 after 3 restarts, "+decision),
+			})
+		})
+	}
+}
+
+func TestContainerReferences(t *testing.T) {
+	refs := containerReferences(&container.HostConfig{
+		NetworkMode: "container:proxy3",
+		IpcMode:     "shareable",
+		PidMode:     "container:proxy3",
+		VolumesFrom: []string{"data1:ro", "myproject-cache-1"},
+	})
+	assert.DeepEqual(t, refs, []string{"proxy3", "proxy3", "data1", "myproject-cache-1"})
+
+	assert.Assert(t, referencesServices(types.ServiceConfig{NetworkMode: "service:proxy"}))
+	assert.Assert(t, referencesServices(types.ServiceConfig{VolumesFrom: []string{"data:ro"}}))
+	assert.Assert(t, !referencesServices(types.ServiceConfig{NetworkMode: "bridge", VolumesFrom: []string{"container:data"}}))
+}
@@ -0,0 +1,154 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/paths"
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// pathMapping maps the host paths under from to the same paths under to
+type pathMapping struct {
+	from string
+	to   string
+}
+
+// parsePathMap parses the comma-separated SOURCE=TARGET entries of
+// COMPOSE_PATH_MAP
+func parsePathMap(value string) ([]pathMapping, error) {
+	var mappings []pathMapping
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(entry, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid %s entry %q, expected SOURCE=TARGET", api.ComposePathMap, entry)
+		}
+		mappings = append(mappings, pathMapping{from: from, to: to})
+	}
+	return mappings, nil
+}
+
+// mapRemoteHostPaths applies COMPOSE_PATH_MAP to the sources of the bind
+// mounts of a project loaded from a remote resource, which may have been
+// authored on another OS. The sources left as absolute paths of another OS
+// than the one compose runs on are rejected, as the engine would fail to
+// mount them with a confusing error.
+func mapRemoteHostPaths(project *types.Project, options *cli.ProjectOptions) (*types.Project, error) {
+	if !isRemoteProject(options) {
+		return project, nil
+	}
+	mappings, err := parsePathMap(options.Environment[api.ComposePathMap])
+	if err != nil {
+		return nil, err
+	}
+	return mapHostPaths(project, mappings, runtime.GOOS)
+}
+
+// mapHostPaths maps the sources of the project bind mounts, and checks the
+// ones left can be used on goos
+func mapHostPaths(project *types.Project, mappings []pathMapping, goos string) (*types.Project, error) {
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		for i, volume := range service.Volumes {
+			if volume.Type != types.VolumeTypeBind {
+				continue
+			}
+			if mapped, ok := mapHostPath(volume.Source, mappings); ok {
+				service.Volumes[i].Source = mapped
+				continue
+			}
+			if foreignHostPath(volume.Source, goos) {
+				return nil, fmt.Errorf("service %q: bind mount %s:%s uses host path %q, which isn't a valid path on %s; "+
+					"set %s=%s=<local path> to map it to a local path",
+					name, volume.Source, volume.Target, volume.Source, goos, api.ComposePathMap, volume.Source)
+			}
+		}
+		project.Services[name] = service
+	}
+	return project, nil
+}
+
+// mapHostPath maps p with the mapping matching the longest prefix of it, if any
+func mapHostPath(p string, mappings []pathMapping) (string, bool) {
+	var (
+		match *pathMapping
+		rest  string
+	)
+	for i, m := range mappings {
+		suffix, ok := cutPathPrefix(p, m.from)
+		if ok && (match == nil || len(m.from) > len(match.from)) {
+			match, rest = &mappings[i], suffix
+		}
+	}
+	if match == nil {
+		return p, false
+	}
+	if rest == "" {
+		return match.to, true
+	}
+	sep := "/"
+	if paths.IsWindowsAbs(match.to) {
+		sep = `\`
+	}
+	return strings.TrimRight(match.to, `/\`) + sep + strings.ReplaceAll(rest, "/", sep), true
+}
+
+// cutPathPrefix returns the part of p under prefix, with slashes as
+// separators, when p is prefix or a path under it. Both separators are
+// accepted, and Windows paths are compared ignoring case.
+func cutPathPrefix(p, prefix string) (string, bool) {
+	p = strings.ReplaceAll(p, `\`, "/")
+	prefix = strings.TrimRight(strings.ReplaceAll(prefix, `\`, "/"), "/")
+	if len(p) < len(prefix) {
+		return "", false
+	}
+	head, rest := p[:len(prefix)], p[len(prefix):]
+	if paths.IsWindowsAbs(p) {
+		if !strings.EqualFold(head, prefix) {
+			return "", false
+		}
+	} else if head != prefix {
+		return "", false
+	}
+	if rest != "" && rest[0] != '/' {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, "/"), true
+}
+
+// foreignHostPath reports whether p is an absolute path of another OS than goos
+func foreignHostPath(p, goos string) bool {
+	if goos != "windows" {
+		return paths.IsWindowsAbs(p)
+	}
+	// Docker Desktop exposes the engine socket at its Linux path
+	if p == "/var/run/docker.sock" {
+		return false
+	}
+	return path.IsAbs(p) && !paths.IsWindowsAbs(p)
+}
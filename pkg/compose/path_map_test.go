@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/google/go-cmp/cmp"
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePathMap(t *testing.T) {
+	mappings, err := parsePathMap(`/var/data=/Users/me/data, C:\data=/home/me/data`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, mappings, []pathMapping{
+		{from: "/var/data", to: "/Users/me/data"},
+		{from: `C:\data`, to: "/home/me/data"},
+	}, cmp.AllowUnexported(pathMapping{}))
+
+	_, err = parsePathMap("/var/data")
+	assert.Error(t, err, `invalid COMPOSE_PATH_MAP entry "/var/data", expected SOURCE=TARGET`)
+}
+
+func TestMapHostPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		source   string
+		mappings []pathMapping
+		want     string
+		err      string
+	}{
+		{
+			name:   "windows to unix",
+			goos:   "linux",
+			source: `C:\data\db`,
+			mappings: []pathMapping{
+				{from: `C:\data`, to: "/home/me/data"},
+				{from: `c:\data\db`, to: "/srv/db"},
+			},
+			want: "/srv/db",
+		},
+		{
+			name:     "windows to unix under the prefix",
+			goos:     "linux",
+			source:   `C:\data\db\files`,
+			mappings: []pathMapping{{from: `C:\Data\`, to: "/home/me/data/"}},
+			want:     "/home/me/data/db/files",
+		},
+		{
+			name:   "windows to unix without mapping",
+			goos:   "linux",
+			source: `C:\data`,
+			err:    `service "app": bind mount C:\data:/data uses host path "C:\\data", which isn't a valid path on linux; set COMPOSE_PATH_MAP=C:\data=<local path> to map it to a local path`,
+		},
+		{
+			name:     "unix to windows",
+			goos:     "windows",
+			source:   "/var/data/db",
+			mappings: []pathMapping{{from: "/var/data", to: `D:\me\data`}},
+			want:     `D:\me\data\db`,
+		},
+		{
+			name:     "unix to windows not matching a partial name",
+			goos:     "windows",
+			source:   "/var/database",
+			mappings: []pathMapping{{from: "/var/data", to: `D:\me\data`}},
+			err:      `service "app": bind mount /var/database:/data uses host path "/var/database", which isn't a valid path on windows; set COMPOSE_PATH_MAP=/var/database=<local path> to map it to a local path`,
+		},
+		{
+			name:   "unix to windows engine socket",
+			goos:   "windows",
+			source: "/var/run/docker.sock",
+			want:   "/var/run/docker.sock",
+		},
+		{
+			name:   "unix to unix",
+			goos:   "linux",
+			source: "/var/data",
+			want:   "/var/data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{Services: types.Services{
+				"app": {Name: "app", Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeBind, Source: tt.source, Target: "/data"},
+					{Type: types.VolumeTypeVolume, Source: "/not/a/path", Target: "/volume"},
+				}},
+			}}
+			project, err := mapHostPaths(project, tt.mappings, tt.goos)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, project.Services["app"].Volumes[0].Source, tt.want)
+			assert.Equal(t, project.Services["app"].Volumes[1].Source, "/not/a/path")
+		})
+	}
+}
@@ -27,9 +27,10 @@ import (
 )
 
 func (s *composeService) Pause(ctx context.Context, projectName string, options api.PauseOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.pause(ctx, strings.ToLower(projectName), options)
-	}, "pause", s.events)
+	}, "pause", s.events(ctx))
 }
 
 func (s *composeService) pause(ctx context.Context, projectName string, options api.PauseOptions) error {
@@ -45,16 +46,17 @@ func (s *composeService) pause(ctx context.Context, projectName string, options
 	return forEachContainerConcurrent(ctx, containers, func(ctx context.Context, ctr container.Summary) error {
 		_, err := s.apiClient().ContainerPause(ctx, ctr.ID, client.ContainerPauseOptions{})
 		if err == nil {
-			s.events.On(newEvent(getContainerProgressName(ctr), api.Done, "Paused"))
+			s.events(ctx).On(newEvent(getContainerProgressName(ctr), api.Done, "Paused"))
 		}
 		return err
 	})
 }
 
 func (s *composeService) UnPause(ctx context.Context, projectName string, options api.PauseOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.unPause(ctx, strings.ToLower(projectName), options)
-	}, "unpause", s.events)
+	}, "unpause", s.events(ctx))
 }
 
 func (s *composeService) unPause(ctx context.Context, projectName string, options api.PauseOptions) error {
@@ -70,7 +72,7 @@ func (s *composeService) unPause(ctx context.Context, projectName string, option
 	return forEachContainerConcurrent(ctx, containers, func(ctx context.Context, ctr container.Summary) error {
 		_, err := s.apiClient().ContainerUnpause(ctx, ctr.ID, client.ContainerUnpauseOptions{})
 		if err == nil {
-			s.events.On(newEvent(getContainerProgressName(ctr), api.Done, "Unpaused"))
+			s.events(ctx).On(newEvent(getContainerProgressName(ctr), api.Done, "Unpaused"))
 		}
 		return err
 	})
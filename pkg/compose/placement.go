@@ -0,0 +1,147 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/client"
+)
+
+// classicSwarmCache caches whether the engine is a classic (standalone) Swarm
+// cluster, the only target placing the containers it creates on nodes
+type classicSwarmCache struct {
+	mu      sync.Mutex
+	checked bool
+	val     bool
+}
+
+// isClassicSwarm reports whether the engine is a classic Swarm cluster, which
+// reports its version as swarm/x.y.z
+func (s *composeService) isClassicSwarm(ctx context.Context) (bool, error) {
+	s.classicSwarm.mu.Lock()
+	defer s.classicSwarm.mu.Unlock()
+	if s.classicSwarm.checked {
+		return s.classicSwarm.val, nil
+	}
+	res, err := s.apiClient().Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return false, err
+	}
+	s.classicSwarm.checked = true
+	s.classicSwarm.val = strings.HasPrefix(res.Info.ServerVersion, "swarm/")
+	return s.classicSwarm.val, nil
+}
+
+func placementConstraints(service types.ServiceConfig) []string {
+	if service.Deploy == nil {
+		return nil
+	}
+	return service.Deploy.Placement.Constraints
+}
+
+// checkPlacement reports the services declaring deploy.placement.constraints
+// the engine can't honor, before anything is created: a single engine, even
+// a Swarm mode manager, doesn't place the containers it runs, and would
+// silently ignore them.
+func (s *composeService) checkPlacement(ctx context.Context, project *types.Project, services []string) error {
+	var constrained []string
+	for _, name := range services {
+		if service, ok := project.Services[name]; ok && len(placementConstraints(service)) > 0 {
+			constrained = append(constrained, name)
+		}
+	}
+	if len(constrained) == 0 {
+		return nil
+	}
+	classic, err := s.isClassicSwarm(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range constrained {
+		if !classic {
+			errs = append(errs, fmt.Errorf("service %q declares deploy.placement.constraints, but the engine doesn't place containers on nodes", name))
+			continue
+		}
+		for _, constraint := range placementConstraints(project.Services[name]) {
+			if _, err := classicSwarmConstraint(constraint); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: %w", name, err))
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("placement constraints can't be honored, run with --skip-placement-check to ignore them:\n%w", errors.Join(errs...))
+}
+
+// placementEnv returns the environment entries a classic Swarm cluster reads
+// the placement constraints of a container from, none when the engine isn't
+// one
+func (s *composeService) placementEnv(ctx context.Context, service types.ServiceConfig) ([]string, error) {
+	constraints := placementConstraints(service)
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+	classic, err := s.isClassicSwarm(ctx)
+	if err != nil || !classic {
+		return nil, err
+	}
+	env := make([]string, 0, len(constraints))
+	for _, constraint := range constraints {
+		entry, err := classicSwarmConstraint(constraint)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", service.Name, err)
+		}
+		env = append(env, entry)
+	}
+	return env, nil
+}
+
+// classicSwarmConstraint translates a Swarm mode placement constraint, like
+// `node.labels.zone == east`, to the environment entry classic Swarm reads
+// it from, like `constraint:zone==east`
+func classicSwarmConstraint(constraint string) (string, error) {
+	op := "=="
+	key, value, ok := strings.Cut(constraint, op)
+	if !ok {
+		op = "!="
+		if key, value, ok = strings.Cut(constraint, op); !ok {
+			return "", fmt.Errorf("invalid placement constraint %q: should be KEY==VALUE or KEY!=VALUE", constraint)
+		}
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	switch {
+	case key == "node.hostname":
+		key = "node"
+	case key == "node.platform.os":
+		key = "operatingsystem"
+	case strings.HasPrefix(key, "node.labels."):
+		key = strings.TrimPrefix(key, "node.labels.")
+	case strings.HasPrefix(key, "engine.labels."):
+		key = strings.TrimPrefix(key, "engine.labels.")
+	default:
+		return "", fmt.Errorf("unsupported placement constraint %q: classic Swarm only places containers by node.hostname, node.platform.os, node.labels and engine.labels", constraint)
+	}
+	return "constraint:" + key + op + value, nil
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/system"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestClassicSwarmConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+		err        string
+	}{
+		{constraint: "node.hostname == node-1", want: "constraint:node==node-1"},
+		{constraint: "node.labels.zone!=east", want: "constraint:zone!=east"},
+		{constraint: "engine.labels.storage == ssd", want: "constraint:storage==ssd"},
+		{constraint: "node.platform.os == linux", want: "constraint:operatingsystem==linux"},
+		{constraint: "node.role == manager", err: `unsupported placement constraint "node.role == manager": classic Swarm only places containers by node.hostname, node.platform.os, node.labels and engine.labels`},
+		{constraint: "node.hostname", err: `invalid placement constraint "node.hostname": should be KEY==VALUE or KEY!=VALUE`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			got, err := classicSwarmConstraint(tt.constraint)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web": {Name: "web", Deploy: &types.DeployConfig{Placement: types.Placement{
+				Constraints: []string{"node.labels.zone == east"},
+			}}},
+			"db": {Name: "db", Deploy: &types.DeployConfig{Placement: types.Placement{
+				Constraints: []string{"node.role == manager"},
+			}}},
+			"cache": {Name: "cache"},
+		},
+	}
+	tests := []struct {
+		name          string
+		serverVersion string
+		services      []string
+		err           string
+	}{
+		{
+			name:     "no constraint",
+			services: []string{"cache"},
+		},
+		{
+			name:          "single engine",
+			serverVersion: "28.0.1",
+			services:      []string{"web", "cache"},
+			err: "placement constraints can't be honored, run with --skip-placement-check to ignore them:\n" +
+				`service "web" declares deploy.placement.constraints, but the engine doesn't place containers on nodes`,
+		},
+		{
+			name:          "classic swarm",
+			serverVersion: "swarm/1.2.9",
+			services:      []string{"web", "cache"},
+		},
+		{
+			name:          "classic swarm with an unsupported constraint",
+			serverVersion: "swarm/1.2.9",
+			services:      []string{"web", "db"},
+			err: "placement constraints can't be honored, run with --skip-placement-check to ignore them:\n" +
+				`service "db": unsupported placement constraint "node.role == manager": classic Swarm only places containers by node.hostname, node.platform.os, node.labels and engine.labels`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, apiClient := newTestService(t)
+			if tt.serverVersion != "" {
+				apiClient.EXPECT().Info(gomock.Any(), client.InfoOptions{}).
+					Return(client.SystemInfoResult{Info: system.Info{ServerVersion: tt.serverVersion}}, nil)
+			}
+			err := svc.checkPlacement(t.Context(), project, tt.services)
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestPlacementEnv(t *testing.T) {
+	service := types.ServiceConfig{Name: "web", Deploy: &types.DeployConfig{Placement: types.Placement{
+		Constraints: []string{"node.hostname == node-1", "node.labels.zone != west"},
+	}}}
+
+	svc, apiClient := newTestService(t)
+	apiClient.EXPECT().Info(gomock.Any(), client.InfoOptions{}).
+		Return(client.SystemInfoResult{Info: system.Info{ServerVersion: "swarm/1.2.9"}}, nil).Times(1)
+	env, err := svc.placementEnv(t.Context(), service)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, env, []string{"constraint:node==node-1", "constraint:zone!=west"})
+	// the engine is only inspected once
+	_, err = svc.placementEnv(t.Context(), service)
+	assert.NilError(t, err)
+
+	svc, apiClient = newTestService(t)
+	apiClient.EXPECT().Info(gomock.Any(), client.InfoOptions{}).
+		Return(client.SystemInfoResult{Info: system.Info{ServerVersion: "28.0.1"}}, nil)
+	env, err = svc.placementEnv(t.Context(), service)
+	assert.NilError(t, err)
+	assert.Assert(t, env == nil)
+}
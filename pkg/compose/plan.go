@@ -25,6 +25,8 @@ import (
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/api/types/container"
+
+	"github.com/docker/compose/v5/pkg/api"
 )
 
 // OperationType identifies the kind of atomic operation in a reconciliation plan.
@@ -45,14 +47,22 @@ const (
 	OpRemoveVolume OperationType = 11
 
 	// Container operations
-	OpCreateContainer OperationType = 20
-	OpStartContainer  OperationType = 21
-	OpStopContainer   OperationType = 22
-	OpRemoveContainer OperationType = 23
-	OpRenameContainer OperationType = 24
+	OpCreateContainer  OperationType = 20
+	OpStartContainer   OperationType = 21
+	OpStopContainer    OperationType = 22
+	OpRemoveContainer  OperationType = 23
+	OpRenameContainer  OperationType = 24
+	OpWaitContainer    OperationType = 25
+	OpDrainContainer   OperationType = 26
+	OpPreRecreateHook  OperationType = 27
+	OpPostRecreateHook OperationType = 28
+	OpCommitContainer  OperationType = 29
 
 	// Provider operations
 	OpRunProvider OperationType = 30
+
+	// Canary operations
+	OpConfirmCanary OperationType = 40
 )
 
 // String returns the human-readable name of an OperationType.
@@ -80,8 +90,20 @@ func (o OperationType) String() string {
 		return "RemoveContainer"
 	case OpRenameContainer:
 		return "RenameContainer"
+	case OpWaitContainer:
+		return "WaitContainer"
+	case OpDrainContainer:
+		return "DrainContainer"
+	case OpPreRecreateHook:
+		return "PreRecreateHook"
+	case OpPostRecreateHook:
+		return "PostRecreateHook"
+	case OpCommitContainer:
+		return "CommitContainer"
 	case OpRunProvider:
 		return "RunProvider"
+	case OpConfirmCanary:
+		return "ConfirmCanary"
 	default:
 		return fmt.Sprintf("Unknown(%d)", int(o))
 	}
@@ -101,8 +123,20 @@ type Operation struct {
 	Name         string               // target container/resource name
 	Network      *types.NetworkConfig // for network operations
 	Volume       *types.VolumeConfig  // for volume operations
-	Timeout      *time.Duration       // for stop operations
-	CreateNodeID int                  // for OpRenameContainer: ID of the CreateContainer node whose result to rename
+	Timeout      *time.Duration       // for stop and drain operations
+	Drain        time.Duration        // for OpDrainContainer: how long the old container keeps running
+	CreateNodeID int                  // for OpRenameContainer, OpStartContainer, OpWaitContainer, OpPostRecreateHook and OpConfirmCanary: ID of the CreateContainer node whose result to act on
+	CommitNodeID int                  // for OpCreateContainer: ID of the CommitContainer node whose image to create the container from
+	Paused       bool                 // for OpCreateContainer: the container replaces a paused one, and is paused once started
+	// SuspendRestart, for OpStopContainer, disables the restart policy of the
+	// container while it is stopped, until compose starts it again
+	SuspendRestart bool
+	// RemoveOnFailure, for OpWaitContainer, removes the awaited replacement
+	// when it doesn't become healthy, the container it replaces still running
+	RemoveOnFailure bool
+	// Canary, for OpWaitContainer, awaits a canary, for no longer than it is
+	// given to be confirmed
+	Canary bool
 }
 
 // PlanNode is a single node in the reconciliation DAG. It represents one
@@ -117,7 +151,10 @@ type PlanNode struct {
 // Plan is a directed acyclic graph of operations produced by the reconciler.
 // Nodes are stored in topological order (dependencies before dependents).
 type Plan struct {
-	Nodes  []*PlanNode
+	Nodes []*PlanNode
+	// Drifts are the differences between desired and observed state the
+	// plan acts upon
+	Drifts []api.Drift
 	nextID int
 }
 
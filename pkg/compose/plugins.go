@@ -76,7 +76,7 @@ func (s *composeService) runPlugin(ctx context.Context, project *types.Project,
 		return nil
 	}
 
-	variables, err := s.executePlugin(cmd, command, service)
+	variables, err := s.executePlugin(ctx, cmd, command, service)
 	if err != nil {
 		return err
 	}
@@ -105,17 +105,17 @@ func (s *composeService) runPlugin(ctx context.Context, project *types.Project,
 	return nil
 }
 
-func (s *composeService) executePlugin(cmd *exec.Cmd, command string, service types.ServiceConfig) (pluginVariables, error) { //nolint:gocyclo
+func (s *composeService) executePlugin(ctx context.Context, cmd *exec.Cmd, command string, service types.ServiceConfig) (pluginVariables, error) { //nolint:gocyclo
 	var action string
 	switch command {
 	case "up":
-		s.events.On(creatingEvent(service.Name))
+		s.events(ctx).On(creatingEvent(service.Name))
 		action = "create"
 	case "down":
-		s.events.On(removingEvent(service.Name))
+		s.events(ctx).On(removingEvent(service.Name))
 		action = "remove"
 	case "stop":
-		s.events.On(stoppingEvent(service.Name))
+		s.events(ctx).On(stoppingEvent(service.Name))
 		action = "stop"
 	default:
 		return pluginVariables{}, fmt.Errorf("unsupported plugin command: %s", command)
@@ -150,10 +150,10 @@ func (s *composeService) executePlugin(cmd *exec.Cmd, command string, service ty
 		}
 		switch msg.Type {
 		case ErrorType:
-			s.events.On(newEvent(service.Name, api.Error, firstLine(msg.Message)))
+			s.events(ctx).On(newEvent(service.Name, api.Error, firstLine(msg.Message)))
 			return pluginVariables{}, errors.New(msg.Message)
 		case InfoType:
-			s.events.On(newEvent(service.Name, api.Working, firstLine(msg.Message)))
+			s.events(ctx).On(newEvent(service.Name, api.Working, firstLine(msg.Message)))
 		case SetEnvType:
 			key, val, found := strings.Cut(msg.Message, "=")
 			if !found {
@@ -175,16 +175,16 @@ func (s *composeService) executePlugin(cmd *exec.Cmd, command string, service ty
 
 	err = cmd.Wait()
 	if err != nil {
-		s.events.On(errorEvent(service.Name, err.Error()))
+		s.events(ctx).On(errorEvent(service.Name, err.Error()))
 		return pluginVariables{}, fmt.Errorf("failed to %s service provider: %s", action, err.Error())
 	}
 	switch command {
 	case "up":
-		s.events.On(createdEvent(service.Name))
+		s.events(ctx).On(createdEvent(service.Name))
 	case "down":
-		s.events.On(removedEvent(service.Name))
+		s.events(ctx).On(removedEvent(service.Name))
 	case "stop":
-		s.events.On(stoppedEvent(service.Name))
+		s.events(ctx).On(stoppedEvent(service.Name))
 	}
 	return variables, nil
 }
@@ -51,7 +51,8 @@ func lowestNumberedContainer(containers Containers) container.Summary {
 // runPreStart executes the service's pre_start hooks sequentially, in declared
 // order. Each hook runs as an ephemeral container that shares the service
 // container's volumes via VolumesFrom and is attached to the same networks.
-// A non-zero exit gates service start.
+// A non-zero exit gates service start. Secrets and configs have already been
+// injected into the service container when the hooks run.
 //
 // With per_replica: false (the only currently supported mode), the hook sees
 // the volumes of the first non-running replica only — anonymous volumes and
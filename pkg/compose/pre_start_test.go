@@ -367,3 +367,44 @@ func TestWaitPreStart_RaceRealErrorAndResult(t *testing.T) {
 		assert.ErrorContains(t, err, "connection lost")
 	}
 }
+
+func TestStartService_InjectsSecretsBeforePreStart(t *testing.T) {
+	tested, apiClient := newPreStartTestService(t)
+
+	project := &types.Project{
+		Name: "demo",
+		Secrets: types.Secrets{
+			"token": {Name: "token", Content: "s3cr3t"},
+		},
+	}
+	service := types.ServiceConfig{
+		Name:    "web",
+		Image:   "alpine",
+		Secrets: []types.ServiceSecretConfig{{Source: "token"}},
+		PreStart: []types.ServiceHook{
+			{Image: "alpine", Command: types.ShellCommand{"render-config"}},
+		},
+	}
+	ctr := container.Summary{
+		ID:     "service-ctr-id",
+		Names:  []string{"/demo-web-1"},
+		State:  container.StateCreated,
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+	}
+
+	inject := apiClient.EXPECT().CopyToContainer(gomock.Any(), "service-ctr-id", gomock.Any()).
+		Return(client.CopyToContainerResult{}, nil)
+	create := apiClient.EXPECT().ContainerCreate(gomock.Any(), gomock.Any()).
+		Return(client.ContainerCreateResult{ID: "hook-1"}, nil).After(inject)
+	wait := apiClient.EXPECT().ContainerWait(gomock.Any(), "hook-1", gomock.Any()).
+		Return(waitResultExit(0)).After(create)
+	logs := apiClient.EXPECT().ContainerLogs(gomock.Any(), "hook-1", gomock.Any()).
+		Return(emptyLogs(), nil).After(wait)
+	hookStart := apiClient.EXPECT().ContainerStart(gomock.Any(), "hook-1", gomock.Any()).
+		Return(client.ContainerStartResult{}, nil).After(logs)
+	apiClient.EXPECT().ContainerStart(gomock.Any(), "service-ctr-id", gomock.Any()).
+		Return(client.ContainerStartResult{}, nil).After(hookStart)
+
+	err := tested.startService(t.Context(), project, service, Containers{ctr}, func(api.ContainerEvent) {}, api.StartOptions{})
+	assert.NilError(t, err)
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/pkg/stdcopy"
+	"github.com/moby/moby/client"
+)
+
+// dependsOnProbeExtension is the depends_on extension declaring a command run
+// inside the dependency containers, the dependency being ready once it exits
+// with status 0 in all of them:
+//
+//	depends_on:
+//	  db:
+//	    condition: service_started
+//	    x-probe: pg_isready -U postgres
+//
+// A string is run by /bin/sh, a list is run as is. The probe replaces the
+// evaluation of the condition, so a readiness check can be added without
+// changing the healthcheck of the image.
+const dependsOnProbeExtension = "x-probe"
+
+func getProbe(dep string, config types.ServiceDependency) ([]string, error) {
+	value, ok := config.Extensions[dependsOnProbeExtension]
+	if !ok {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return []string{"/bin/sh", "-c", v}, nil
+		}
+	case []any:
+		cmd := make([]string, 0, len(v))
+		for _, arg := range v {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("depends_on %s: %s must be a string or a list of strings, got %v", dep, dependsOnProbeExtension, value)
+			}
+			cmd = append(cmd, s)
+		}
+		if len(cmd) > 0 {
+			return cmd, nil
+		}
+	}
+	return nil, fmt.Errorf("depends_on %s: %s must be a command, got %v", dep, dependsOnProbeExtension, value)
+}
+
+// probeContainers runs the probe in each container and reports whether it
+// succeeded in all of them, along with the exit code of the first failure.
+func (s *composeService) probeContainers(ctx context.Context, containers Containers, probe []string) (bool, int, error) {
+	for _, ctr := range containers {
+		code, err := s.runProbe(ctx, ctr.ID, probe)
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to run %s in container %s: %w", dependsOnProbeExtension, getCanonicalContainerName(ctr), err)
+		}
+		if code != 0 {
+			return false, code, nil
+		}
+	}
+	return true, 0, nil
+}
+
+func (s *composeService) runProbe(ctx context.Context, containerID string, probe []string) (int, error) {
+	exec, err := s.apiClient().ExecCreate(ctx, containerID, client.ExecCreateOptions{
+		Cmd:          probe,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	attach, err := s.apiClient().ExecAttach(ctx, exec.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer attach.Close()
+
+	// wait for the probe to complete, its output is not relevant
+	if _, err := stdcopy.StdCopy(io.Discard, io.Discard, attach.Reader); err != nil {
+		return 0, err
+	}
+	inspected, err := s.apiClient().ExecInspect(ctx, exec.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return inspected.ExitCode, nil
+}
@@ -19,19 +19,74 @@ package compose
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
 
 type progressFunc func(context.Context) error
 
+type eventProcessorKey struct{}
+
+// withEventProcessor sends the events of the operation run with the returned
+// context to bus, rather than to the event bus of the service, so concurrent
+// operations each get their own
+func withEventProcessor(ctx context.Context, bus api.EventProcessor) context.Context {
+	return context.WithValue(ctx, eventProcessorKey{}, bus)
+}
+
+// events returns the processor the events of the operation run with ctx are
+// sent to
+func (s *composeService) events(ctx context.Context) api.EventProcessor {
+	if bus, ok := ctx.Value(eventProcessorKey{}).(api.EventProcessor); ok {
+		return bus
+	}
+	return s.eventBus
+}
+
 func Run(ctx context.Context, pf progressFunc, operation string, bus api.EventProcessor) error {
+	ctx = withEventProcessor(ctx, bus)
 	bus.Start(ctx, operation)
 	err := pf(ctx)
 	bus.Done(operation, err != nil)
 	return err
 }
 
+// bufferedEvents is an api.EventProcessor holding the events it is notified
+// about, until they are replayed to another one
+type bufferedEvents struct {
+	mu     sync.Mutex
+	events []func(api.EventProcessor)
+}
+
+func (b *bufferedEvents) add(event func(api.EventProcessor)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+func (b *bufferedEvents) Start(ctx context.Context, operation string) {
+	b.add(func(bus api.EventProcessor) { bus.Start(ctx, operation) })
+}
+
+func (b *bufferedEvents) On(events ...api.Resource) {
+	b.add(func(bus api.EventProcessor) { bus.On(events...) })
+}
+
+func (b *bufferedEvents) Done(operation string, success bool) {
+	b.add(func(bus api.EventProcessor) { bus.Done(operation, success) })
+}
+
+// replay notifies bus about the events held, in the order they were received
+func (b *bufferedEvents) replay(bus api.EventProcessor) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, event := range b.events {
+		event(bus)
+	}
+	b.events = nil
+}
+
 // errorEvent creates a new Error Resource with message
 func errorEvent(id string, msg string) api.Resource {
 	return api.Resource{
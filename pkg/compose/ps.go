@@ -35,6 +35,9 @@ func (s *composeService) Ps(ctx context.Context, projectName string, options api
 	if options.All {
 		oneOff = oneOffInclude
 	}
+	if err := s.useDockerContexts(options.Project); err != nil {
+		return nil, err
+	}
 	containers, err := s.getContainers(ctx, projectName, oneOff, options.All, options.Services...)
 	if err != nil {
 		return nil, err
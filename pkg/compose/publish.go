@@ -48,7 +48,7 @@ import (
 func (s *composeService) Publish(ctx context.Context, project *types.Project, repository string, options api.PublishOptions) error {
 	return Run(ctx, func(ctx context.Context) error {
 		return s.publish(ctx, project, repository, options)
-	}, "publish", s.events)
+	}, "publish", s.events(ctx))
 }
 
 //nolint:gocyclo
@@ -74,7 +74,7 @@ func (s *composeService) publish(ctx context.Context, project *types.Project, re
 		return err
 	}
 
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     repository,
 		Text:   "publishing",
 		Status: api.Working,
@@ -101,7 +101,7 @@ func (s *composeService) publish(ctx context.Context, project *types.Project, re
 
 		descriptor, err := oci.PushManifest(ctx, resolver, named, layers, options.OCIVersion)
 		if err != nil {
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:     repository,
 				Text:   "publishing",
 				Status: api.Error,
@@ -153,7 +153,7 @@ func (s *composeService) publish(ctx context.Context, project *types.Project, re
 			}
 		}
 	}
-	s.events.On(api.Resource{
+	s.events(ctx).On(api.Resource{
 		ID:     repository,
 		Text:   "published",
 		Status: api.Done,
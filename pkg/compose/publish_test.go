@@ -720,8 +720,8 @@ func Test_publish_decline_returns_ErrCanceled(t *testing.T) {
 		return false, nil
 	}
 	svc := &composeService{
-		prompt: declined,
-		events: &ignore{},
+		prompt:   declined,
+		eventBus: &ignore{},
 	}
 
 	err := svc.publish(t.Context(), project, "docker.io/myorg/myapp:latest", api.PublishOptions{})
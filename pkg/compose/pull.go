@@ -47,7 +47,7 @@ import (
 func (s *composeService) Pull(ctx context.Context, project *types.Project, options api.PullOptions) error {
 	return Run(ctx, func(ctx context.Context) error {
 		return s.pull(ctx, project, options)
-	}, "pull", s.events)
+	}, "pull", s.events(ctx))
 }
 
 func (s *composeService) pull(ctx context.Context, project *types.Project, opts api.PullOptions) error { //nolint:gocyclo
@@ -56,7 +56,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		return err
 	}
 
-	eg, ctx := errgroup.WithContext(ctx)
+	eg, pullCtx := errgroup.WithContext(ctx)
 	eg.SetLimit(s.maxConcurrency)
 
 	var (
@@ -68,7 +68,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 	i := 0
 	for name, service := range project.Services {
 		if service.Image == "" {
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:      name,
 				Status:  api.Done,
 				Text:    "Skipped",
@@ -79,7 +79,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 
 		switch service.PullPolicy {
 		case types.PullPolicyNever, types.PullPolicyBuild:
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:     "Image " + service.Image,
 				Status: api.Done,
 				Text:   "Skipped",
@@ -87,7 +87,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 			continue
 		case types.PullPolicyMissing, types.PullPolicyIfNotPresent:
 			if imageAlreadyPresent(service.Image, images) {
-				s.events.On(api.Resource{
+				s.events(ctx).On(api.Resource{
 					ID:      "Image " + service.Image,
 					Status:  api.Done,
 					Text:    "Skipped",
@@ -98,7 +98,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		}
 
 		if service.Build != nil && opts.IgnoreBuildable {
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:      "Image " + service.Image,
 				Status:  api.Done,
 				Text:    "Skipped",
@@ -115,7 +115,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 
 		idx := i
 		eg.Go(func() error {
-			_, err := s.pullServiceImage(ctx, service, opts.Quiet, project.Environment["DOCKER_DEFAULT_PLATFORM"])
+			_, err := s.pullServiceImage(pullCtx, service, opts.Quiet, project.Environment["DOCKER_DEFAULT_PLATFORM"])
 			if err != nil {
 				pullErrors[idx] = err
 				if service.Build != nil {
@@ -123,7 +123,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 				}
 				if !opts.IgnoreFailures && service.Build == nil {
 					if s.dryRun {
-						s.events.On(errorEventf("Image "+service.Image,
+						s.events(ctx).On(errorEventf("Image "+service.Image,
 							"error pulling image: %s", service.Image))
 					}
 					// fail fast if image can't be pulled nor built
@@ -144,10 +144,12 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 	if err != nil {
 		return err
 	}
-	if opts.IgnoreFailures {
-		return nil
+	if !opts.IgnoreFailures {
+		if err := errors.Join(pullErrors...); err != nil {
+			return err
+		}
 	}
-	return errors.Join(pullErrors...)
+	return s.verifyImages(ctx, project, opts.VerifyImages)
 }
 
 func imageAlreadyPresent(serviceImage string, localImages map[string]api.ImageSummary) bool {
@@ -175,7 +177,12 @@ func getUnwrappedErrorMessage(err error) string {
 
 func (s *composeService) pullServiceImage(ctx context.Context, service types.ServiceConfig, quietPull bool, defaultPlatform string) (string, error) {
 	resource := "Image " + service.Image
-	s.events.On(newEvent(resource, api.Working, api.StatusPulling))
+	ctx, release, err := s.acquireSlot(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	s.events(ctx).On(newEvent(resource, api.Working, api.StatusPulling))
 	ref, err := reference.ParseNormalizedNamed(service.Image)
 	if err != nil {
 		return "", err
@@ -206,7 +213,7 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 	})
 
 	if ctx.Err() != nil {
-		s.events.On(api.Resource{
+		s.events(ctx).On(api.Resource{
 			ID:     resource,
 			Status: api.Warning,
 			Text:   "Interrupted",
@@ -217,7 +224,7 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 	// check if it has an error and the service has a build section
 	// then the status should be warning instead of error
 	if err != nil && service.Build != nil {
-		s.events.On(api.Resource{
+		s.events(ctx).On(api.Resource{
 			ID:     resource,
 			Status: api.Warning,
 			Text:   getUnwrappedErrorMessage(err),
@@ -226,7 +233,7 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 	}
 
 	if err != nil {
-		s.events.On(errorEvent(resource, getUnwrappedErrorMessage(err)))
+		s.events(ctx).On(errorEvent(resource, getUnwrappedErrorMessage(err)))
 		return "", err
 	}
 
@@ -243,16 +250,27 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 			return "", errors.New(jm.Error.Message)
 		}
 		if !quietPull {
-			toPullProgressEvent(resource, jm, s.events)
+			toPullProgressEvent(resource, jm, s.events(ctx))
 		}
 	}
-	s.events.On(newEvent(resource, api.Done, api.StatusPulled))
+	s.events(ctx).On(newEvent(resource, api.Done, api.StatusPulled))
 
-	inspected, err := s.apiClient().ImageInspect(ctx, service.Image)
+	// report the same digest getImageSummaries computes for local images, so
+	// the ImageDigestLabel set after a pull matches the one computed by the
+	// next up and containers aren't recreated in a loop
+	withManifests, err := s.manifestsSupported(ctx)
+	if err != nil {
+		return "", err
+	}
+	var opts []client.ImageInspectOption
+	if withManifests {
+		opts = append(opts, client.ImageInspectWithManifests(true))
+	}
+	inspected, err := s.apiClient().ImageInspect(ctx, service.Image, opts...)
 	if err != nil {
 		return "", err
 	}
-	return inspected.ID, nil
+	return contentDigest(inspected.InspectResponse, platforms.Default()), nil
 }
 
 // ImageDigestResolver creates a func able to resolve image digest from a docker ref,
@@ -290,14 +308,16 @@ func encodedAuth(ref reference.Named, configFile authProvider) (string, error) {
 	return base64.URLEncoding.EncodeToString(buf), nil
 }
 
-func (s *composeService) pullRequiredImages(ctx context.Context, project *types.Project, images map[string]api.ImageSummary, quietPull bool) error {
+// pullRequiredImages pulls the images of the project services missing or to
+// be refreshed, but the ones pulled in the background
+func (s *composeService) pullRequiredImages(ctx context.Context, project *types.Project, images map[string]api.ImageSummary, quietPull bool, pulls *imagePulls) error {
 	needPull := map[string]types.ServiceConfig{}
 	for name, service := range project.Services {
 		pull, err := mustPull(service, images)
 		if err != nil {
 			return err
 		}
-		if pull {
+		if pull && !pulls.pending(name) {
 			needPull[name] = service
 		}
 		for i, vol := range service.Volumes {
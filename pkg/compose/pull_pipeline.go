@@ -0,0 +1,156 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// imagePulls tracks the images of services pulled while convergence runs, so
+// the containers of services whose image is present are created without
+// waiting for the images of the others to be pulled. A nil *imagePulls has no
+// pull in progress.
+type imagePulls struct {
+	cancel context.CancelFunc
+	// pulls is keyed by service name
+	pulls map[string]*imagePull
+}
+
+// imagePull signals the image of a service is available once done is closed
+type imagePull struct {
+	done chan struct{}
+	id   string
+	err  error
+}
+
+// startImagePulls starts pulling the images of the services which can be
+// created as soon as their own image is pulled, and returns nil when there
+// is none. The images of services built, or mounted as image volumes, are
+// left to be pulled before convergence.
+func (s *composeService) startImagePulls(ctx context.Context, project *types.Project, images map[string]api.ImageSummary, quietPull bool) (*imagePulls, error) {
+	pipelined := map[string]types.ServiceConfig{}
+	for name, service := range project.Services {
+		pull, err := mustPull(service, images)
+		if err != nil {
+			return nil, err
+		}
+		if pull && !isServiceImageToBuild(service, project.Services) && !isImageVolumeSource(service, project.Services) {
+			pipelined[name] = service
+		}
+	}
+	if len(pipelined) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &imagePulls{cancel: cancel, pulls: map[string]*imagePull{}}
+	for name := range pipelined {
+		p.pulls[name] = &imagePull{done: make(chan struct{})}
+	}
+	go func() {
+		var eg errgroup.Group
+		eg.SetLimit(s.maxConcurrency)
+		for _, name := range slices.Sorted(maps.Keys(pipelined)) {
+			pull := p.pulls[name]
+			eg.Go(func() error {
+				defer close(pull.done)
+				pull.id, pull.err = s.pullServiceImage(ctx, pipelined[name], quietPull, project.Environment["DOCKER_DEFAULT_PLATFORM"])
+				return nil
+			})
+		}
+		_ = eg.Wait()
+	}()
+	return p, nil
+}
+
+// isImageVolumeSource reports whether the image of service is mounted by a
+// service as an image volume, whose source is resolved before convergence
+func isImageVolumeSource(service types.ServiceConfig, services types.Services) bool {
+	for _, svc := range services {
+		for _, vol := range svc.Volumes {
+			if vol.Type == types.VolumeTypeImage && (vol.Source == service.Image || vol.Source == service.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pending reports whether the image of service is pulled in the background
+func (p *imagePulls) pending(service string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.pulls[service]
+	return ok
+}
+
+// wait waits for the image of service to be pulled, and returns its ID. ok is
+// false when the image isn't pulled in the background.
+func (p *imagePulls) wait(ctx context.Context, service string) (id string, ok bool, err error) {
+	if !p.pending(service) {
+		return "", false, nil
+	}
+	pull := p.pulls[service]
+	select {
+	case <-pull.done:
+		return pull.id, true, pull.err
+	case <-ctx.Done():
+		return "", true, ctx.Err()
+	}
+}
+
+// await waits for the images of services to be pulled, and sets their digest
+// on the project services, as it is before convergence when images aren't
+// pulled in the background
+func (p *imagePulls) await(ctx context.Context, project *types.Project, services []string) error {
+	for _, name := range services {
+		id, ok, err := p.wait(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !ok || id == "" {
+			continue
+		}
+		service := project.Services[name]
+		service.CustomLabels = service.CustomLabels.Add(api.ImageDigestLabel, id)
+		project.Services[name] = service
+	}
+	return nil
+}
+
+// awaitAll waits for all the images to be pulled
+func (p *imagePulls) awaitAll(ctx context.Context, project *types.Project) error {
+	if p == nil {
+		return nil
+	}
+	return p.await(ctx, project, slices.Sorted(maps.Keys(p.pulls)))
+}
+
+// stop cancels the pulls still in progress
+func (p *imagePulls) stop() {
+	if p != nil {
+		p.cancel()
+	}
+}
@@ -42,19 +42,32 @@ func (s *composeService) Push(ctx context.Context, project *types.Project, optio
 	}
 	return Run(ctx, func(ctx context.Context) error {
 		return s.push(ctx, project, options)
-	}, "push", s.events)
+	}, "push", s.events(ctx))
 }
 
+// push pushes the images of the services referenced by others as additional
+// build contexts first, so the registry never holds an image whose build
+// references an image missing from it.
 func (s *composeService) push(ctx context.Context, project *types.Project, options api.PushOptions) error {
+	for _, level := range buildReferenceLevels(project) {
+		if err := s.pushServices(ctx, project, level, options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *composeService) pushServices(ctx context.Context, project *types.Project, names []string, options api.PushOptions) error {
 	eg, ctx := errgroup.WithContext(ctx)
 	eg.SetLimit(s.maxConcurrency)
 
-	for _, service := range project.Services {
+	for _, name := range names {
+		service := project.Services[name]
 		if service.Build == nil || service.Image == "" {
 			if options.ImageMandatory && service.Image == "" && service.Provider == nil {
 				return fmt.Errorf("%q attribute is mandatory to push an image for service %q", "service.image", service.Name)
 			}
-			s.events.On(api.Resource{
+			s.events(ctx).On(api.Resource{
 				ID:     service.Name,
 				Status: api.Done,
 				Text:   "Skipped",
@@ -68,16 +81,16 @@ func (s *composeService) push(ctx context.Context, project *types.Project, optio
 
 		for _, tag := range tags {
 			eg.Go(func() error {
-				s.events.On(newEvent(tag, api.Working, "Pushing"))
+				s.events(ctx).On(newEvent(tag, api.Working, "Pushing"))
 				err := s.pushServiceImage(ctx, tag, options.Quiet)
 				if err != nil {
 					if !options.IgnoreFailures {
-						s.events.On(newEvent(tag, api.Error, err.Error()))
+						s.events(ctx).On(newEvent(tag, api.Error, err.Error()))
 						return err
 					}
-					s.events.On(newEvent(tag, api.Warning, err.Error()))
+					s.events(ctx).On(newEvent(tag, api.Warning, err.Error()))
 				} else {
-					s.events.On(newEvent(tag, api.Done, "Pushed"))
+					s.events(ctx).On(newEvent(tag, api.Done, "Pushed"))
 				}
 				return nil
 			})
@@ -122,7 +135,7 @@ func (s *composeService) pushServiceImage(ctx context.Context, tag string, quiet
 		}
 
 		if !quietPush {
-			toPushProgressEvent(tag, jm, s.events)
+			toPushProgressEvent(tag, jm, s.events(ctx))
 		}
 	}
 
@@ -19,6 +19,7 @@ package compose
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
@@ -27,6 +28,7 @@ import (
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/api/types/container"
 	mmount "github.com/moby/moby/api/types/mount"
+	"github.com/sirupsen/logrus"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
@@ -37,10 +39,23 @@ func toReconcileOptions(options api.CreateOptions) ReconcileOptions {
 		Services:             options.Services,
 		Recreate:             options.Recreate,
 		RecreateDependencies: options.RecreateDependencies,
+		RecreateDependents:   options.RecreateDependents,
+		RecreateOrder:        options.RecreateOrder,
 		Inherit:              options.Inherit,
 		Timeout:              options.Timeout,
 		RemoveOrphans:        options.RemoveOrphans,
 		SkipProviders:        options.SkipProviders,
+		MaxAge:               options.MaxAge,
+		RecreateRestarting:   options.RecreateRestarting,
+		RestartExhausted:     options.RestartExhausted,
+		KeepScaledDown:       options.KeepScaledDown,
+		LazyResources:        options.LazyResources,
+		Canary:               options.ConfirmCanary != nil,
+		DuplicateNumbers:     options.DuplicateNumbers,
+		ResourcePreflight:    options.ResourcePreflight,
+		ScaleDownReferenced:  options.ScaleDownReferenced,
+		IgnoreHealthcheck:    options.IgnoreHealthcheckChanges,
+		PreservePaused:       options.PreservePaused,
 	}
 }
 
@@ -49,10 +64,26 @@ type ReconcileOptions struct {
 	Services             []string       // targeted services (empty = all)
 	Recreate             string         // "diverged", "force", "never" for targeted services
 	RecreateDependencies string         // same for non-targeted services
+	RecreateDependents   bool           // recreate restart: true dependents of recreated services
+	RecreateOrder        string         // "dependencies-first" (default) or "dependents-first"
 	Inherit              bool           // inherit anonymous volumes on recreate
 	Timeout              *time.Duration // for stop operations
 	RemoveOrphans        bool
 	SkipProviders        bool
+	MaxAge               time.Duration // recreate containers older than this, 0 = disabled
+	RecreateRestarting   time.Duration // recreate containers stuck restarting for longer than this, 0 = disabled
+	RestartExhausted     string        // "restart" (default), "recreate" or "ignore" for containers which exhausted their restart retries
+	KeepScaledDown       bool          // stop containers on scale down rather than removing them
+	LazyResources        bool          // only create missing networks and volumes once a container needs them
+	Canary               bool          // recreate the other containers of a service once the first one recreated is confirmed
+	DuplicateNumbers     string        // "keep-newest" (default) or "error"
+	ResourcePreflight    string        // "warn" or "error" when scaling up beyond the host resources, "" = disabled
+	ScaleDownReferenced  string        // "warn" (default), "reselect", "error" or "recreate" when scaling down a container others share namespaces with
+	IgnoreHealthcheck    bool          // don't recreate containers whose configuration only differs by the healthcheck
+	PreservePaused       bool          // pause the replacements of paused containers once started
+	// NumberAllocator numbers the containers created on scale up, nil to
+	// number them after the highest number in use
+	NumberAllocator ContainerNumberAllocator
 }
 
 // reconciler compares a types.Project (desired state) with an ObservedState
@@ -73,6 +104,11 @@ type reconciler struct {
 	// network/volume, so container creation nodes can depend on them.
 	networkNodes map[string]*PlanNode // compose network key → create node
 	volumeNodes  map[string]*PlanNode // compose volume key → create node
+	// lazyNetworks and lazyVolumes hold the missing networks and volumes
+	// whose creation is deferred, with LazyResources, until an operation on
+	// a container using them is planned.
+	lazyNetworks map[string]*types.NetworkConfig
+	lazyVolumes  map[string]*types.VolumeConfig
 	// serviceNodes tracks the last plan node per service, so dependent
 	// services can order their operations after dependencies.
 	serviceNodes map[string]*PlanNode
@@ -90,30 +126,44 @@ type reconciler struct {
 	// "container:<old_id>" references.
 	recreatedServices map[string]bool
 
+	// atomicGroups records, once planned, the stop nodes of the containers
+	// of each atomic group (see api.AtomicGroupAnnotation), nil when no
+	// member of the group has to be recreated.
+	atomicGroups map[string][]*PlanNode
+
 	// observedContainersByService memoizes ObservedState.containersByService()
 	// (an O(services * containers) build) for expectedConfigHash, which is
 	// called once per service.
 	observedContainersByService map[string]Containers
+
+	// drifts records, as they are taken, the decisions to act on a
+	// difference between desired and observed state, for Diff to report
+	// exactly what the plan is built from.
+	drifts []api.Drift
+
+	// preflighted is set once the resources reserved by the project have
+	// been checked against the host ones
+	preflighted bool
+
+	// deferredRecreates holds, when recreating dependents first, the
+	// recreations of the containers of each service, planned once all the
+	// services are visited. deferredServices lists the services holding some
+	// in dependency order, and recreateNodes records the last node of the
+	// recreations planned.
+	deferredRecreates map[string][]func() *PlanNode
+	deferredServices  []string
+	recreateNodes     map[string][]*PlanNode
 }
 
 // reconcile is the main entry point: it builds a Plan from desired vs observed state.
 // The prompt function is consulted while planning to confirm destructive
 // decisions (see the reconciler.prompt field).
 func reconcile(_ context.Context, project *types.Project, observed *ObservedState, options ReconcileOptions, prompt Prompt) (*Plan, error) {
-	r := &reconciler{
-		project:                     project,
-		observed:                    observed,
-		options:                     options,
-		prompt:                      prompt,
-		plan:                        &Plan{},
-		networkNodes:                map[string]*PlanNode{},
-		volumeNodes:                 map[string]*PlanNode{},
-		serviceNodes:                map[string]*PlanNode{},
-		stoppedByPlan:               map[string]*PlanNode{},
-		recreatedServices:           map[string]bool{},
-		observedContainersByService: observed.containersByService(),
-	}
+	return newReconciler(project, observed, options, prompt).build()
+}
 
+// build runs all reconciliation stages and returns the resulting plan.
+func (r *reconciler) build() (*Plan, error) {
 	if err := r.reconcileNetworks(); err != nil {
 		return nil, err
 	}
@@ -130,9 +180,39 @@ func reconcile(_ context.Context, project *types.Project, observed *ObservedStat
 		r.reconcileOrphans()
 	}
 
+	r.plan.Drifts = r.drifts
 	return r.plan, nil
 }
 
+// drift records a difference between desired and observed state the plan acts upon.
+func (r *reconciler) drift(service, kind, detail string) {
+	r.drifts = append(r.drifts, api.Drift{Service: service, Kind: kind, Detail: detail})
+}
+
+// newReconciler builds a reconciler with an empty plan. Split out from
+// reconcile so read-only callers (e.g. Inspect) can evaluate the exact same
+// decision logic without producing a plan.
+func newReconciler(project *types.Project, observed *ObservedState, options ReconcileOptions, prompt Prompt) *reconciler {
+	return &reconciler{
+		project:                     project,
+		observed:                    observed,
+		options:                     options,
+		prompt:                      prompt,
+		plan:                        &Plan{},
+		networkNodes:                map[string]*PlanNode{},
+		volumeNodes:                 map[string]*PlanNode{},
+		lazyNetworks:                map[string]*types.NetworkConfig{},
+		lazyVolumes:                 map[string]*types.VolumeConfig{},
+		serviceNodes:                map[string]*PlanNode{},
+		stoppedByPlan:               map[string]*PlanNode{},
+		recreatedServices:           map[string]bool{},
+		atomicGroups:                map[string][]*PlanNode{},
+		observedContainersByService: observed.containersByService(),
+		deferredRecreates:           map[string][]func() *PlanNode{},
+		recreateNodes:               map[string][]*PlanNode{},
+	}
+}
+
 // reconcileNetworks adds plan nodes for network creation or recreation.
 func (r *reconciler) reconcileNetworks() error {
 	for _, key := range sortedKeys(r.project.Networks) {
@@ -142,6 +222,11 @@ func (r *reconciler) reconcileNetworks() error {
 		}
 		observed, exists := r.observed.Networks[key]
 		if !exists {
+			if r.options.LazyResources {
+				r.lazyNetworks[key] = &desired
+				continue
+			}
+			r.drift("", api.DriftNetwork, fmt.Sprintf("network %s not found", key))
 			r.planCreateNetwork(key, &desired)
 			continue
 		}
@@ -151,6 +236,7 @@ func (r *reconciler) reconcileNetworks() error {
 			return err
 		}
 		if observed.ConfigHash != "" && observed.ConfigHash != expectedHash {
+			r.drift("", api.DriftNetwork, fmt.Sprintf("network %s config changed", key))
 			if err := r.planRecreateNetwork(key, &desired); err != nil {
 				return err
 			}
@@ -188,9 +274,9 @@ func (r *reconciler) planRecreateNetwork(key string, nw *types.NetworkConfig) er
 		oc := &affectedContainers[i]
 		node := r.plan.addNode(Operation{
 			Type:       OpStopContainer,
-			ResourceID: fmt.Sprintf("service:%s:%d", oc.Summary.Labels[api.ServiceLabel], oc.Number),
+			ResourceID: fmt.Sprintf("service:%s:%d", oc.Labels[api.ServiceLabel], oc.Number),
 			Cause:      fmt.Sprintf("network %s config changed", key),
-			Container:  &oc.Summary,
+			Container:  oc.summary(),
 		}, "")
 		stopNodes = append(stopNodes, node)
 		r.stoppedByPlan[oc.ID] = node
@@ -201,9 +287,9 @@ func (r *reconciler) planRecreateNetwork(key string, nw *types.NetworkConfig) er
 	for i, oc := range affectedContainers {
 		node := r.plan.addNode(Operation{
 			Type:       OpDisconnectNetwork,
-			ResourceID: fmt.Sprintf("service:%s:%d", oc.Summary.Labels[api.ServiceLabel], oc.Number),
+			ResourceID: fmt.Sprintf("service:%s:%d", oc.Labels[api.ServiceLabel], oc.Number),
 			Cause:      fmt.Sprintf("network %s recreate", key),
-			Container:  &affectedContainers[i].Summary,
+			Container:  affectedContainers[i].summary(),
 			Name:       observed.Name,
 		}, "", stopNodes[i])
 		disconnectNodes = append(disconnectNodes, node)
@@ -247,6 +333,11 @@ func (r *reconciler) reconcileVolumes() error {
 		}
 		observed, exists := r.observed.Volumes[key]
 		if !exists {
+			if r.options.LazyResources {
+				r.lazyVolumes[key] = &desired
+				continue
+			}
+			r.drift("", api.DriftVolume, fmt.Sprintf("volume %s not found", key))
 			r.planCreateVolume(key, &desired, "not found")
 			continue
 		}
@@ -263,6 +354,7 @@ func (r *reconciler) reconcileVolumes() error {
 			// historical additive behavior — create the new volume and leave the
 			// old one (and its data) untouched — instead of prompting to delete
 			// data under a name that does not exist yet.
+			r.drift("", api.DriftVolume, fmt.Sprintf("volume %s renamed to %s", key, desired.Name))
 			r.planCreateVolume(key, &desired, "renamed")
 			// Rewrite the observed name to the desired one so reconcileContainers
 			// detects the mount mismatch and migrates existing containers onto
@@ -273,6 +365,7 @@ func (r *reconciler) reconcileVolumes() error {
 			r.observed.Volumes[key] = observed
 			continue
 		}
+		r.drift("", api.DriftVolume, fmt.Sprintf("volume %s config changed", key))
 		confirmed, err := r.prompt(
 			fmt.Sprintf("Volume %q exists but doesn't match configuration in compose file. Recreate (data will be lost)?", desired.Name),
 			false)
@@ -337,14 +430,14 @@ func (r *reconciler) planRecreateVolumes(keys []string) {
 	var removeNodes []*PlanNode
 	for i := range containers {
 		oc := &containers[i]
-		resID := fmt.Sprintf("service:%s:%d", oc.Summary.Labels[api.ServiceLabel], oc.Number)
+		resID := fmt.Sprintf("service:%s:%d", oc.Labels[api.ServiceLabel], oc.Number)
 		stopNode, alreadyStopped := r.stoppedByPlan[oc.ID]
 		if !alreadyStopped {
 			stopNode = r.plan.addNode(Operation{
 				Type:       OpStopContainer,
 				ResourceID: resID,
 				Cause:      "mounted volume config changed",
-				Container:  &oc.Summary,
+				Container:  oc.summary(),
 				Timeout:    r.options.Timeout,
 			}, "")
 			r.stoppedByPlan[oc.ID] = stopNode
@@ -353,7 +446,7 @@ func (r *reconciler) planRecreateVolumes(keys []string) {
 			Type:       OpRemoveContainer,
 			ResourceID: resID,
 			Cause:      "mounted volume config changed",
-			Container:  &oc.Summary,
+			Container:  oc.summary(),
 		}, "", stopNode)
 		removeNodes = append(removeNodes, removeNode)
 	}
@@ -480,8 +573,112 @@ func (r *reconciler) reconcileContainers() error {
 		return err
 	}
 
+	if r.dependentsFirst() {
+		if err := r.checkDependentsFirst(); err != nil {
+			return err
+		}
+	}
+
 	// Visit in dependency order (leaves first = services with no deps)
-	return r.visitInDependencyOrder(graph)
+	if err := r.visitInDependencyOrder(graph); err != nil {
+		return err
+	}
+	r.planDeferredRecreates()
+	return nil
+}
+
+// dependentsFirst reports whether the containers of the services are
+// recreated after the ones of the services depending on them
+func (r *reconciler) dependentsFirst() bool {
+	return r.options.RecreateOrder == api.RecreateOrderDependentsFirst
+}
+
+// deferRecreate holds the recreation of a container of the service, when
+// recreating dependents first, for it to be planned once the services are
+// visited. Only recreations are reordered: the containers created and
+// scaled up are still planned in dependency order.
+func (r *reconciler) deferRecreate(service string, recreate func() *PlanNode) error {
+	for _, name := range r.project.ServiceNames() {
+		for _, link := range r.project.Services[name].Links {
+			if linked, _, _ := strings.Cut(link, ":"); linked == service {
+				return fmt.Errorf("service %q links to service %q, which can't be recreated after it: recreate order %s isn't supported", name, service, api.RecreateOrderDependentsFirst)
+			}
+		}
+	}
+	if _, ok := r.deferredRecreates[service]; !ok {
+		r.deferredServices = append(r.deferredServices, service)
+	}
+	r.deferredRecreates[service] = append(r.deferredRecreates[service], recreate)
+	return nil
+}
+
+// planDeferredRecreates plans the recreations held by deferRecreate, the
+// services being visited in dependency order, from the dependents to their
+// dependencies
+func (r *reconciler) planDeferredRecreates() {
+	for _, service := range slices.Backward(r.deferredServices) {
+		for _, recreate := range r.deferredRecreates[service] {
+			r.recreateNodes[service] = append(r.recreateNodes[service], recreate())
+		}
+	}
+}
+
+// dependentRecreates returns the recreation nodes of the services depending,
+// directly or not, on service, which its own recreations come after when
+// recreating dependents first
+func (r *reconciler) dependentRecreates(service types.ServiceConfig) []*PlanNode {
+	var nodes []*PlanNode
+	seen := map[string]bool{}
+	var collect func(service types.ServiceConfig)
+	collect = func(service types.ServiceConfig) {
+		dependents := r.project.GetDependentsForService(service)
+		sort.Strings(dependents)
+		for _, name := range dependents {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			nodes = append(nodes, r.recreateNodes[name]...)
+			collect(r.project.Services[name])
+		}
+	}
+	collect(service)
+	return nodes
+}
+
+// checkDependentsFirst rejects the projects whose containers can't be
+// recreated dependents first: a container referencing the namespace or the
+// volumes of a service container is created with the ID of the container,
+// which must then be replaced first. Recreating the dependents of a recreated
+// service relies on them being recreated after it, the other way round.
+func (r *reconciler) checkDependentsFirst() error {
+	if r.options.RecreateDependents {
+		return fmt.Errorf("recreate order %s can't be combined with recreating dependents", api.RecreateOrderDependentsFirst)
+	}
+	for _, name := range r.project.ServiceNames() {
+		if parent := referencedService(r.project.Services[name]); parent != "" {
+			return fmt.Errorf("service %q uses the namespace or the volumes of service %q, which can't be recreated after it: recreate order %s isn't supported", name, parent, api.RecreateOrderDependentsFirst)
+		}
+	}
+	return nil
+}
+
+// referencedService returns the service svc shares the network, IPC or PID
+// namespace or the volumes of, if any
+func referencedService(svc types.ServiceConfig) string {
+	for _, mode := range []string{svc.NetworkMode, svc.Ipc, svc.Pid} {
+		if name := getDependentServiceFromMode(mode); name != "" {
+			return name
+		}
+	}
+	for _, vol := range svc.VolumesFrom {
+		if strings.HasPrefix(vol, types.ContainerPrefix) {
+			continue
+		}
+		name, _, _ := strings.Cut(vol, ":")
+		return name
+	}
+	return ""
 }
 
 // visitInDependencyOrder processes services from leaves to roots so that
@@ -550,13 +747,13 @@ func (r *reconciler) reconcileService(service types.ServiceConfig) error {
 		return err
 	}
 
-	containers := r.observed.Containers[service.Name]
+	containers, lastNode, err := r.resolveDuplicateNumbers(service, r.convergedContainers(service))
+	if err != nil {
+		return err
+	}
 	actual := len(containers)
 
-	strategy := r.options.RecreateDependencies
-	if slices.Contains(r.options.Services, service.Name) || len(r.options.Services) == 0 {
-		strategy = r.options.Recreate
-	}
+	strategy := r.recreateStrategy(service)
 
 	// Precompute once per service: mustRecreate is called twice per container
 	// (sortContainers + main loop) and the hash/cascade inputs depend on the
@@ -565,48 +762,154 @@ func (r *reconciler) reconcileService(service types.ServiceConfig) error {
 	if err != nil {
 		return err
 	}
-	parentRecreated := r.parentNamespaceRecreated(service)
+	parentRecreated := r.parentRecreated(service)
+	update, err := getUpdateConfig(service)
+	if err != nil {
+		return err
+	}
+	atomicStops, err := r.planAtomicGroupStop(service)
+	if err != nil {
+		return err
+	}
+	if atomicStops != nil && parentRecreated == "" {
+		parentRecreated = recreateReasonAtomicGroup
+	}
 
 	// Sort containers: obsolete first, then by number descending, then reverse
 	// to get the same ordering as the existing convergence code.
 	r.sortContainers(containers, service, expectedHash, parentRecreated, strategy)
 
-	// Collect dependency nodes that container creation should depend on
-	infraDeps := r.infrastructureDeps(service)
+	// Collect dependency nodes that container creation should depend on. In
+	// an atomic group, no container is created before all the members of the
+	// group are stopped.
+	// With LazyResources, the missing networks and volumes of the service
+	// are only planned once an operation on its containers needs them.
+	var infraDeps []*PlanNode
+	infraResolved := false
+	infra := func() []*PlanNode {
+		if !infraResolved {
+			infraDeps = append(r.infrastructureDeps(service), atomicStops...)
+			infraResolved = true
+		}
+		return infraDeps
+	}
+
+	surge, err := r.canSurge(service, expected, actual)
+	if err != nil {
+		return err
+	}
+
+	if err := r.checkReferencedScaleDown(service, containers, expected); err != nil {
+		return err
+	}
 
-	var lastNode *PlanNode
+	if expected != actual && !r.keptScaledDown(containers, expected) {
+		r.drift(service.Name, api.DriftScale, fmt.Sprintf("%d container(s) running, %d expected", actual, expected))
+	}
+
+	// With Canary, the first container recreated is the canary, and the
+	// recreate of the others depends on its confirmation
+	var canary, confirmed *PlanNode
+	canaryNumber, canaryStarted := 0, false
 
 	// Process existing containers
 	for i, oc := range containers {
+		if i >= expected && r.options.KeepScaledDown {
+			// Scale down keeping containers: only stop the ones still running
+			if _, alreadyStopped := r.stoppedByPlan[oc.ID]; !alreadyStopped && !oc.stopped() {
+				lastNode = r.plan.addNode(Operation{
+					Type:       OpStopContainer,
+					ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
+					Cause:      "scale down, kept stopped",
+					Container:  containers[i].summary(),
+					Timeout:    r.options.Timeout,
+				}, "")
+			}
+			continue
+		}
 		if i >= expected {
 			// Scale down: stop + remove excess containers. Track the remove
 			// node so dependent services wait for the scale-down to finish
 			// even when no other operation runs on this service.
-			stopNode := r.plan.addNode(Operation{
-				Type:       OpStopContainer,
-				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
-				Cause:      "scale down",
-				Container:  &containers[i].Summary,
-				Timeout:    r.options.Timeout,
-			}, "")
+			stopNode, alreadyStopped := r.stoppedByPlan[oc.ID]
+			if !alreadyStopped {
+				stopNode = r.plan.addNode(Operation{
+					Type:       OpStopContainer,
+					ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
+					Cause:      "scale down",
+					Container:  containers[i].summary(),
+					Timeout:    r.options.Timeout,
+				}, "")
+			}
 			lastNode = r.plan.addNode(Operation{
 				Type:       OpRemoveContainer,
 				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
 				Cause:      "scale down",
-				Container:  &containers[i].Summary,
+				Container:  containers[i].summary(),
 			}, "", stopNode)
 			continue
 		}
 
-		if r.mustRecreate(service, expectedHash, parentRecreated, oc, strategy) {
-			lastNode = r.planRecreateContainer(service, &containers[i], infraDeps)
+		reason, matched := r.compareContainer(service, expectedHash, parentRecreated, oc, strategy)
+		if reason != "" {
+			if update.PreserveWritableLayer && r.imageChanged(service, oc) {
+				return fmt.Errorf("service %q: can't preserve the writable layer of %s as its image changed, remove %s.preserve_writable_layer to recreate it", service.Name, oc.Name, updateExtension)
+			}
+			r.drift(service.Name, api.DriftRecreate, fmt.Sprintf("%s: %s", oc.Name, reason))
+			recreate := func() *PlanNode {
+				deps := infra()
+				if r.dependentsFirst() {
+					deps = append(slices.Clone(deps), r.dependentRecreates(service)...)
+				}
+				if canary != nil {
+					if confirmed == nil {
+						confirmed = r.planConfirmCanary(service, canaryNumber, canary, canaryStarted)
+					}
+					deps = append(slices.Clone(deps), confirmed)
+				}
+				_, alreadyStopped := r.stoppedByPlan[oc.ID]
+				started := surge && !alreadyStopped && oc.State == container.StateRunning
+				var node *PlanNode
+				if started {
+					node = r.planSurgeRecreateContainer(service, &containers[i], deps, update)
+				} else {
+					node = r.planRecreateContainer(service, &containers[i], deps, update)
+				}
+				if r.options.Canary && canary == nil {
+					canary, canaryNumber, canaryStarted = node, oc.Number, started
+				}
+				return node
+			}
 			r.recreatedServices[service.Name] = true
+			if r.dependentsFirst() {
+				if err := r.deferRecreate(service.Name, recreate); err != nil {
+					return err
+				}
+				continue
+			}
+			lastNode = recreate()
 			continue
 		}
 
 		// Container is up-to-date
-		switch oc.State {
-		case container.StateRunning, container.StateCreated, container.StateRestarting, container.StateExited:
+		if len(matched) > 0 {
+			logrus.Debugf("%s matches service %s: %s", oc.Name, service.Name, strings.Join(matched, ", "))
+		}
+		if node := r.planCompleteRename(service, containers, oc); node != nil {
+			lastNode = node
+		}
+		switch {
+		case oc.restartsExhausted() && r.restartExhausted():
+			// The engine gave up restarting the container, which would
+			// otherwise be left as is by the plan
+			lastNode = r.plan.addNode(Operation{
+				Type:       OpStartContainer,
+				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
+				Cause:      recreateReasonRestartsExhausted,
+				Container:  containers[i].summary(),
+			}, "", infra()...)
+		case oc.State == container.StateRunning, oc.State == container.StateCreated,
+			oc.State == container.StateRestarting, oc.State == container.StateExited:
 			// Nothing to do (exited containers are left as-is, matching convergence.go behavior)
 		default:
 			// Any other state (paused, dead, ...): attempt to (re)start
@@ -614,15 +917,26 @@ func (r *reconciler) reconcileService(service types.ServiceConfig) error {
 				Type:       OpStartContainer,
 				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
 				Cause:      "not running",
-				Container:  &containers[i].Summary,
-			}, "", infraDeps...)
+				Container:  containers[i].summary(),
+			}, "", infra()...)
 		}
 	}
 
 	// Scale up: create new containers
-	nextNum := nextContainerNumber(r.observedSummaries(service.Name))
-	for i := 0; i < expected-actual; i++ {
-		number := nextNum + i
+	if expected > actual {
+		if err := r.preflightResources(service); err != nil {
+			return err
+		}
+	}
+	var numbers []int
+	if expected > actual {
+		var err error
+		numbers, err = allocateContainerNumbers(r.options.NumberAllocator, r.project.Name, service.Name, r.observedSummaries(service.Name), expected-actual)
+		if err != nil {
+			return err
+		}
+	}
+	for _, number := range numbers {
 		name := getContainerName(r.project.Name, service, number)
 		svc := service // copy for pointer stability
 		lastNode = r.plan.addNode(Operation{
@@ -632,7 +946,7 @@ func (r *reconciler) reconcileService(service types.ServiceConfig) error {
 			Service:    &svc,
 			Number:     number,
 			Name:       name,
-		}, "", infraDeps...)
+		}, "", infra()...)
 	}
 
 	if lastNode != nil {
@@ -641,30 +955,322 @@ func (r *reconciler) reconcileService(service types.ServiceConfig) error {
 	return nil
 }
 
+// Reasons reported by recreateReason for a container that must be recreated.
+const (
+	recreateReasonForced              = "recreate forced"
+	recreateReasonParentRecreated     = "shared namespace or volumes recreated"
+	recreateReasonDependencyRecreated = "dependency recreated"
+	recreateReasonConfigChanged       = "config hash diverged"
+	recreateReasonHealthcheckChanged  = "healthcheck changed"
+	recreateReasonImageChanged        = "image digest changed"
+	recreateReasonNetworkMismatch     = "not connected to expected networks"
+	recreateReasonVolumeMismatch      = "missing expected volume mounts"
+	recreateReasonMaxAgeExceeded      = "max age exceeded"
+	recreateReasonAtomicGroup         = "atomic group recreated"
+	recreateReasonStuckRestarting     = "stuck restarting"
+	recreateReasonRestartsExhausted   = "restart retries exhausted"
+)
+
+// recreateStrategy returns the recreate strategy applying to service, which
+// depends on it being targeted or only a dependency of the targeted services.
+func (r *reconciler) recreateStrategy(service types.ServiceConfig) string {
+	if slices.Contains(r.options.Services, service.Name) || len(r.options.Services) == 0 {
+		return r.options.Recreate
+	}
+	return r.options.RecreateDependencies
+}
+
+// planAtomicGroupStop plans, when the first member of the atomic group of
+// service is reconciled, the stop of the running containers of all its
+// members if any of them has to be recreated. The members are then all
+// recreated, their containers being created once all the stops completed,
+// and started together by the start phase, so the old and new versions of
+// the group never run side by side. It returns the stop nodes, nil when the
+// service isn't part of a group to recreate.
+//
+// Whether a member has to be recreated is evaluated ahead of its turn, so a
+// recreate cascading from outside of the group to a later member isn't seen.
+func (r *reconciler) planAtomicGroupStop(service types.ServiceConfig) ([]*PlanNode, error) {
+	group := service.Annotations[api.AtomicGroupAnnotation]
+	if group == "" {
+		return nil, nil
+	}
+	if stops, planned := r.atomicGroups[group]; planned {
+		return stops, nil
+	}
+	var members []types.ServiceConfig
+	for _, name := range sortedKeys(r.project.Services) {
+		member := r.project.Services[name]
+		if member.Annotations[api.AtomicGroupAnnotation] == group && member.Provider == nil &&
+			r.recreateStrategy(member) != api.RecreateNever {
+			members = append(members, member)
+		}
+	}
+	recreate := false
+	for _, member := range members {
+		expectedHash, err := serviceHashWithResolvedRefs(member, r.observedContainersByService)
+		if err != nil {
+			return nil, err
+		}
+		parentRecreated := r.parentRecreated(member)
+		for _, oc := range r.observed.Containers[member.Name] {
+			if oc.excluded() {
+				continue
+			}
+			if r.mustRecreate(member, expectedHash, parentRecreated, oc, r.recreateStrategy(member)) {
+				recreate = true
+			}
+		}
+	}
+	if !recreate {
+		r.atomicGroups[group] = nil
+		return nil, nil
+	}
+
+	stops := []*PlanNode{}
+	for _, member := range members {
+		for i, oc := range r.observed.Containers[member.Name] {
+			if _, already := r.stoppedByPlan[oc.ID]; already || oc.State != container.StateRunning || oc.excluded() {
+				continue
+			}
+			node := r.plan.addNode(Operation{
+				Type:       OpStopContainer,
+				ResourceID: fmt.Sprintf("service:%s:%d", member.Name, oc.Number),
+				Cause:      fmt.Sprintf("atomic group %s recreate", group),
+				Container:  r.observed.Containers[member.Name][i].summary(),
+				Timeout:    r.options.Timeout,
+			}, "")
+			r.stoppedByPlan[oc.ID] = node
+			stops = append(stops, node)
+		}
+	}
+	r.atomicGroups[group] = stops
+	return stops, nil
+}
+
 // mustRecreate decides whether oc must be recreated to match expected. The
 // expectedHash and parentRecreated inputs are precomputed once per service by
-// reconcileService — see expectedConfigHash and parentNamespaceRecreated for
+// reconcileService — see expectedConfigHash and parentRecreated for
 // the rationale (issue #13878).
-func (r *reconciler) mustRecreate(expected types.ServiceConfig, expectedHash string, parentRecreated bool, oc ObservedContainer, policy string) bool {
+func (r *reconciler) mustRecreate(expected types.ServiceConfig, expectedHash string, parentRecreated string, oc ObservedContainer, policy string) bool {
+	return r.recreateReason(expected, expectedHash, parentRecreated, oc, policy) != ""
+}
+
+// recreateReason is the explanatory form of mustRecreate: it returns why oc
+// must be recreated, or an empty string when the container is up-to-date.
+func (r *reconciler) recreateReason(expected types.ServiceConfig, expectedHash string, parentRecreated string, oc ObservedContainer, policy string) string {
+	reason, _ := r.compareContainer(expected, expectedHash, parentRecreated, oc, policy)
+	return reason
+}
+
+// Checks reported by compareContainer as passed by a container which isn't
+// recreated.
+const (
+	matchConfigHash           = "config hash equal"
+	matchConfigChangesIgnored = "config changes ignored"
+	matchHealthcheckIgnored   = "only healthcheck changed, ignored"
+	matchImage                = "image digest equal"
+	matchImageID              = "image ID equal"
+	matchNetworks             = "networks ok"
+	matchVolumes              = "volumes ok"
+	matchMaxAge               = "within max age"
+)
+
+// compareContainer runs the comparisons recreateReason is based on. Along
+// with the reason oc must be recreated, it returns the checks oc passed, so
+// a container which isn't recreated can be told to match expected, and why.
+func (r *reconciler) compareContainer(expected types.ServiceConfig, expectedHash string, parentRecreated string, oc ObservedContainer, policy string) (string, []string) {
 	switch policy {
 	case api.RecreateNever:
-		return false
+		return "", nil
 	case api.RecreateForce:
-		return true
+		return recreateReasonForced, nil
+	}
+	if parentRecreated != "" {
+		return parentRecreated, nil
+	}
+	var matched []string
+	switch {
+	case oc.ConfigHash == expectedHash:
+		matched = append(matched, matchConfigHash)
+	case ignoreConfigChanges(expected):
+		matched = append(matched, matchConfigChangesIgnored)
+	case oc.ConfigHash == "" && oc.Resources != nil:
+		// the configuration the container was created with is unknown, so it
+		// is recreated regardless: the settings which could be inspected only
+		// give a more specific reason
+		if reason := resourcesDivergence(expected, *oc.Resources); reason != "" {
+			return reason, matched
+		}
+		return recreateReasonConfigChanged, matched
+	case !r.healthcheckChangedOnly(expected, oc):
+		return recreateReasonConfigChanged, matched
+	case !r.options.IgnoreHealthcheck:
+		return recreateReasonHealthcheckChanged, matched
+	default:
+		matched = append(matched, matchHealthcheckIgnored)
+	}
+	if r.imageChanged(expected, oc) {
+		return recreateReasonImageChanged, matched
+	}
+	if match := r.imageMatch(expected, oc); match != "" {
+		matched = append(matched, match)
+	}
+	if oc.State == container.StateRunning {
+		if r.hasNetworkMismatch(expected, oc) {
+			return recreateReasonNetworkMismatch, matched
+		}
+		matched = append(matched, matchNetworks)
+	}
+	if r.hasVolumeMismatch(expected, oc) {
+		return recreateReasonVolumeMismatch, matched
+	}
+	matched = append(matched, matchVolumes)
+	if r.maxAgeExceeded(expected, oc) {
+		return recreateReasonMaxAgeExceeded, matched
+	}
+	if r.maxAge(expected) > 0 {
+		matched = append(matched, matchMaxAge)
+	}
+	if stuckRestarting(oc, r.observed.ObservedAt, r.options.RecreateRestarting) {
+		return recreateReasonStuckRestarting, matched
+	}
+	if r.options.RestartExhausted == api.RestartExhaustedRecreate && oc.restartsExhausted() {
+		return recreateReasonRestartsExhausted, matched
+	}
+	return "", matched
+}
+
+// restartExhausted reports whether the containers which exhausted the
+// retries of their restart policy are started again, the default
+func (r *reconciler) restartExhausted() bool {
+	return r.options.RestartExhausted == "" || r.options.RestartExhausted == api.RestartExhaustedRestart
+}
+
+// healthcheckChangedOnly reports whether the configuration oc was created with
+// only differs from expected by the healthcheck, which doesn't affect the
+// application the container runs. Containers created before the hash
+// excluding the healthcheck was recorded never qualify.
+func (r *reconciler) healthcheckChangedOnly(expected types.ServiceConfig, oc ObservedContainer) bool {
+	actual := oc.Labels[api.HealthcheckExcludedHashLabel]
+	if actual == "" {
+		return false
+	}
+	resolved := expected
+	resolved.VolumesFrom = slices.Clone(expected.VolumesFrom)
+	_ = resolveServiceReferences(&resolved, r.observedContainersByService)
+	hash, err := serviceHashExcludingHealthcheck(resolved)
+	return err == nil && hash == actual
+}
+
+// ignoreConfigChanges reports whether the service opted out of being recreated
+// on configuration changes with x-update.ignore_config_changes.
+func ignoreConfigChanges(expected types.ServiceConfig) bool {
+	config, err := getUpdateConfig(expected)
+	return err == nil && config.IgnoreConfigChanges
+}
+
+// maxAgeExceeded reports whether oc was created longer ago than the maximum
+// age set for the service by x-update.max_age, or else by the max-age option.
+// Age is measured from the time the state was observed.
+func (r *reconciler) maxAgeExceeded(expected types.ServiceConfig, oc ObservedContainer) bool {
+	maxAge := r.maxAge(expected)
+	if maxAge <= 0 || oc.Created == 0 || r.observed.ObservedAt.IsZero() {
+		return false
 	}
-	if parentRecreated {
-		return true
+	return r.observed.ObservedAt.Sub(time.Unix(oc.Created, 0)) > maxAge
+}
+
+// maxAge returns the maximum age of the containers of expected, set by
+// x-update.max_age, or else by the max-age option. 0 means no maximum.
+func (r *reconciler) maxAge(expected types.ServiceConfig) time.Duration {
+	if config, err := getUpdateConfig(expected); err == nil && config.MaxAge != "" {
+		return config.maxAge
 	}
-	if oc.ConfigHash != expectedHash {
-		return true
+	return r.options.MaxAge
+}
+
+// stuckRestarting reports whether oc is being restarted by the engine and has
+// been for longer than threshold. The engine doesn't record when a container
+// started failing, which is estimated from when it last exited and the number
+// of times it was restarted, see restartingSince.
+func stuckRestarting(oc ObservedContainer, observedAt time.Time, threshold time.Duration) bool {
+	if threshold <= 0 || oc.State != container.StateRestarting || oc.RestartCount == 0 ||
+		oc.FinishedAt.IsZero() || observedAt.IsZero() {
+		return false
 	}
-	if oc.ImageDigest != expected.CustomLabels[api.ImageDigestLabel] {
-		return true
+	return observedAt.Sub(oc.restartingSince()) > threshold
+}
+
+// restartingSince returns when the engine started restarting oc, at the
+// latest. The engine backs off restarting a container exiting soon after it
+// started, doubling the delay from 100ms up to a minute, so the restarts of
+// oc took at least as long before it last exited.
+func (oc ObservedContainer) restartingSince() time.Time {
+	var backoff time.Duration
+	delay := 100 * time.Millisecond
+	for range oc.RestartCount {
+		backoff += delay
+		delay = min(2*delay, time.Minute)
 	}
-	if oc.State == container.StateRunning && r.hasNetworkMismatch(expected, oc) {
-		return true
+	return oc.FinishedAt.Add(-backoff)
+}
+
+// imageChanged reports whether oc runs a different image than expected. Digest
+// labels are compared when both sides carry one. When only one side does — the
+// expected image couldn't be inspected, or the container predates the label —
+// the comparison is ambiguous and falls back to image IDs, so a missing label
+// alone doesn't trigger a recreate on every up.
+func (r *reconciler) imageChanged(expected types.ServiceConfig, oc ObservedContainer) bool {
+	digest := expected.CustomLabels[api.ImageDigestLabel]
+	if digest == "" || oc.ImageDigest == "" {
+		if digest == oc.ImageDigest {
+			return false
+		}
+		localID, ok := r.observed.ImageIDs[api.GetImageNameOrDefault(expected, r.project.Name)]
+		if ok && oc.ImageID != "" {
+			return localID != oc.ImageID
+		}
 	}
-	return r.hasVolumeMismatch(expected, oc)
+	return oc.ImageDigest != digest
+}
+
+// imageMatch returns how oc, which imageChanged tells runs the image of
+// expected, was found to run it: by equal digest labels, else by equal image
+// IDs, or empty when neither are known.
+func (r *reconciler) imageMatch(expected types.ServiceConfig, oc ObservedContainer) string {
+	if digest := expected.CustomLabels[api.ImageDigestLabel]; digest != "" && digest == oc.ImageDigest {
+		return matchImage
+	}
+	if localID := r.observed.ImageIDs[api.GetImageNameOrDefault(expected, r.project.Name)]; localID != "" && localID == oc.ImageID {
+		return matchImageID
+	}
+	return ""
+}
+
+// parentRecreated returns why the containers of svc must be recreated along
+// with a parent scheduled for recreation, or an empty string when none is.
+func (r *reconciler) parentRecreated(svc types.ServiceConfig) string {
+	if r.parentNamespaceRecreated(svc) {
+		return recreateReasonParentRecreated
+	}
+	if r.options.RecreateDependents && r.restartDependencyRecreated(svc) {
+		return recreateReasonDependencyRecreated
+	}
+	return ""
+}
+
+// restartDependencyRecreated reports whether a dependency of svc declared with
+// restart: true has at least one container scheduled for recreation. Such
+// dependents are otherwise only stopped and restarted by planStopDependents,
+// keeping the configuration they were created with.
+func (r *reconciler) restartDependencyRecreated(svc types.ServiceConfig) bool {
+	for name, dep := range svc.DependsOn {
+		if dep.Restart && r.recreatedServices[name] {
+			return true
+		}
+	}
+	return false
 }
 
 // parentNamespaceRecreated reports whether any namespace- or volume-sharing
@@ -744,7 +1350,7 @@ func (r *reconciler) hasVolumeMismatch(expected types.ServiceConfig, oc Observed
 			continue
 		}
 		found := false
-		for _, m := range oc.Summary.Mounts {
+		for _, m := range oc.Mounts {
 			if m.Type == mmount.TypeVolume && m.Name == expectedName {
 				found = true
 				break
@@ -759,7 +1365,12 @@ func (r *reconciler) hasVolumeMismatch(expected types.ServiceConfig, oc Observed
 
 // planRecreateContainer decomposes container recreation into 4 atomic operations:
 // CreateContainer(tmpName) → StopContainer → RemoveContainer → RenameContainer
-func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *ObservedContainer, infraDeps []*PlanNode) *PlanNode {
+// preceded by the x-update pre_recreate hooks, when declared. The replacement
+// is only started with the project, which runs the post_recreate hooks.
+func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *ObservedContainer, infraDeps []*PlanNode, update updateConfig) *PlanNode {
+	if update.PreserveWritableLayer {
+		return r.planPreservingRecreateContainer(service, oc, infraDeps, update)
+	}
 	resID := fmt.Sprintf("service:%s:%d", service.Name, oc.Number)
 	group := fmt.Sprintf("recreate:%s:%d", service.Name, oc.Number)
 	tmpName := fmt.Sprintf("%s_%s", oc.ID[:min(12, len(oc.ID))], getContainerName(r.project.Name, service, oc.Number))
@@ -770,10 +1381,11 @@ func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *Obse
 
 	// All deps: infrastructure + dependent stops
 	allDeps := append(slices.Clone(infraDeps), depStopNodes...)
+	allDeps = r.planPreRecreateHook(&svc, oc, resID, group, update, allDeps)
 
 	var inherited *container.Summary
 	if r.options.Inherit {
-		inherited = &oc.Summary
+		inherited = oc.summary()
 	}
 
 	// 1. Create new container with temporary name
@@ -785,6 +1397,7 @@ func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *Obse
 		Inherited:  inherited,
 		Number:     oc.Number,
 		Name:       tmpName,
+		Paused:     r.preservesPaused(oc),
 	}, group, allDeps...)
 
 	// 2. Stop old container. If an earlier stage of the plan (e.g.
@@ -798,9 +1411,9 @@ func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *Obse
 			Type:       OpStopContainer,
 			ResourceID: resID,
 			Cause:      fmt.Sprintf("replaced by #%d", createNode.ID),
-			Container:  &oc.Summary,
+			Container:  oc.summary(),
 			Timeout:    r.options.Timeout,
-		}, group, createNode)
+		}, group, r.planDrainContainer(oc, resID, group, update.drain, createNode))
 		r.stoppedByPlan[oc.ID] = stopNode
 	}
 
@@ -822,7 +1435,7 @@ func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *Obse
 		Type:       OpRemoveContainer,
 		ResourceID: resID,
 		Cause:      fmt.Sprintf("replaced by #%d", createNode.ID),
-		Container:  &oc.Summary,
+		Container:  oc.summary(),
 	}, group, removeDeps...)
 
 	// 4. Rename to final name. Link to the create node so the executor can
@@ -839,26 +1452,319 @@ func (r *reconciler) planRecreateContainer(service types.ServiceConfig, oc *Obse
 	return renameNode
 }
 
+// planConfirmCanary plans the confirmation of the canary of service, the
+// first of its containers recreated, whose recreate ends with last: the
+// canary is started, unless its recreate already did, and awaited until
+// healthy before being confirmed. The recreate of the other containers of the
+// service depends on the node returned.
+func (r *reconciler) planConfirmCanary(service types.ServiceConfig, number int, last *PlanNode, started bool) *PlanNode {
+	svc := service // copy for pointer stability
+	resID := last.Operation.ResourceID
+	createID := last.Operation.CreateNodeID
+	deps := last
+	if started {
+		// the recreate already awaited the canary
+		for _, node := range r.plan.Nodes {
+			if node.Operation.Type == OpWaitContainer && node.Operation.CreateNodeID == createID {
+				node.Operation.Canary = true
+			}
+		}
+	} else {
+		startNode := r.plan.addNode(Operation{
+			Type:         OpStartContainer,
+			ResourceID:   resID,
+			Cause:        "canary",
+			Service:      &svc,
+			CreateNodeID: createID,
+		}, last.Group, last)
+		deps = r.plan.addNode(Operation{
+			Type:         OpWaitContainer,
+			ResourceID:   resID,
+			Cause:        "canary",
+			Service:      &svc,
+			CreateNodeID: createID,
+			Canary:       true,
+		}, last.Group, startNode)
+	}
+	return r.plan.addNode(Operation{
+		Type:         OpConfirmCanary,
+		ResourceID:   resID,
+		Cause:        "canary",
+		Service:      &svc,
+		Number:       number,
+		CreateNodeID: createID,
+	}, last.Group, deps)
+}
+
+// preservesPaused reports whether the replacement of oc is to be paused once
+// started, for a recreate not to resume a paused container. Containers in any
+// other state are replaced by containers started as usual.
+func (r *reconciler) preservesPaused(oc *ObservedContainer) bool {
+	return r.options.PreservePaused && oc.State == container.StatePaused
+}
+
+// planPreservingRecreateContainer plans the recreation of a container whose
+// writable layer is preserved by x-update.preserve_writable_layer: the old
+// container is stopped, then committed to the image its replacement is
+// created from, so no write happens between the commit and the stop.
+func (r *reconciler) planPreservingRecreateContainer(service types.ServiceConfig, oc *ObservedContainer, infraDeps []*PlanNode, update updateConfig) *PlanNode {
+	resID := fmt.Sprintf("service:%s:%d", service.Name, oc.Number)
+	group := fmt.Sprintf("recreate:%s:%d", service.Name, oc.Number)
+	tmpName := fmt.Sprintf("%s_%s", oc.ID[:min(12, len(oc.ID))], getContainerName(r.project.Name, service, oc.Number))
+	svc := service // copy for pointer stability
+
+	allDeps := append(slices.Clone(infraDeps), r.planStopDependents(service)...)
+	allDeps = r.planPreRecreateHook(&svc, oc, resID, group, update, allDeps)
+
+	var inherited *container.Summary
+	if r.options.Inherit {
+		inherited = oc.summary()
+	}
+
+	// 1. Stop the old container, unless an earlier stage of the plan already
+	// does, so its writable layer no longer changes
+	stopNode, alreadyStopped := r.stoppedByPlan[oc.ID]
+	if !alreadyStopped {
+		stopNode = r.plan.addNode(Operation{
+			Type:       OpStopContainer,
+			ResourceID: resID,
+			Cause:      "writable layer preserved",
+			Container:  oc.summary(),
+			Timeout:    r.options.Timeout,
+		}, group, allDeps...)
+		r.stoppedByPlan[oc.ID] = stopNode
+	}
+
+	// 2. Commit it to the image the replacement is created from
+	commitNode := r.plan.addNode(Operation{
+		Type:       OpCommitContainer,
+		ResourceID: resID,
+		Cause:      updateExtension + ".preserve_writable_layer",
+		Service:    &svc,
+		Container:  oc.summary(),
+	}, group, append(allDeps, stopNode)...)
+
+	// 3. Create the replacement with a temporary name
+	createNode := r.plan.addNode(Operation{
+		Type:         OpCreateContainer,
+		ResourceID:   resID,
+		Cause:        "config changed (tmpName)",
+		Service:      &svc,
+		Inherited:    inherited,
+		Number:       oc.Number,
+		Name:         tmpName,
+		CommitNodeID: commitNode.ID,
+		Paused:       r.preservesPaused(oc),
+	}, group, commitNode)
+
+	// 4. Remove the old container, then rename the replacement
+	removeNode := r.plan.addNode(Operation{
+		Type:       OpRemoveContainer,
+		ResourceID: resID,
+		Cause:      fmt.Sprintf("replaced by #%d", createNode.ID),
+		Container:  oc.summary(),
+	}, group, createNode)
+
+	return r.plan.addNode(Operation{
+		Type:         OpRenameContainer,
+		ResourceID:   resID,
+		Cause:        "finalize recreate",
+		Name:         getContainerName(r.project.Name, service, oc.Number),
+		CreateNodeID: createNode.ID,
+	}, group, removeNode)
+}
+
+// planCompleteRename renames a container left under its temporary name by an
+// interrupted recreate, when its final name isn't taken.
+func (r *reconciler) planCompleteRename(service types.ServiceConfig, containers []ObservedContainer, oc ObservedContainer) *PlanNode {
+	tmpName := oc.Labels[api.TemporaryNameLabel]
+	if tmpName == "" || oc.Name != tmpName {
+		return nil
+	}
+	finalName := getContainerName(r.project.Name, service, oc.Number)
+	if slices.ContainsFunc(containers, func(c ObservedContainer) bool { return c.Name == finalName }) {
+		return nil
+	}
+	return r.plan.addNode(Operation{
+		Type:       OpRenameContainer,
+		ResourceID: fmt.Sprintf("service:%s:%d", service.Name, oc.Number),
+		Cause:      "complete interrupted recreate",
+		Container:  oc.summary(),
+		Name:       finalName,
+	}, "")
+}
+
+// canSurge reports whether a recreate of service may temporarily run the
+// replacement alongside the old container (x-update.surge). Surge is limited to
+// single-replica services and falls back to a regular recreate, with a warning,
+// when both containers can't coexist: a fixed container_name or published host
+// ports would conflict.
+func (r *reconciler) canSurge(service types.ServiceConfig, expected, actual int) (bool, error) {
+	config, err := getUpdateConfig(service)
+	if err != nil || !config.Surge {
+		return false, err
+	}
+	if expected != 1 || actual != 1 {
+		return false, nil
+	}
+	if service.ContainerName != "" {
+		logrus.Warnf("service %q: surge recreate isn't supported with a fixed container_name, falling back to stop-first", service.Name)
+		return false, nil
+	}
+	for _, port := range service.Ports {
+		if port.Published != "" {
+			logrus.Warnf("service %q: surge recreate isn't supported with published port %s, falling back to stop-first", service.Name, port.Published)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// planSurgeRecreateContainer is the start-first variant of
+// planRecreateContainer: the replacement is created under a temporary name,
+// started and awaited until healthy (or running, without a healthcheck) before
+// the old container is stopped, removed, and the replacement renamed. The
+// x-update post_recreate hooks run last, as the replacement is already up.
+func (r *reconciler) planSurgeRecreateContainer(service types.ServiceConfig, oc *ObservedContainer, infraDeps []*PlanNode, update updateConfig) *PlanNode {
+	resID := fmt.Sprintf("service:%s:%d", service.Name, oc.Number)
+	group := fmt.Sprintf("recreate:%s:%d", service.Name, oc.Number)
+	tmpName := fmt.Sprintf("%s_%s", oc.ID[:min(12, len(oc.ID))], getContainerName(r.project.Name, service, oc.Number))
+	svc := service // copy for pointer stability
+
+	allDeps := append(slices.Clone(infraDeps), r.planStopDependents(service)...)
+	allDeps = r.planPreRecreateHook(&svc, oc, resID, group, update, allDeps)
+
+	var inherited *container.Summary
+	if r.options.Inherit {
+		inherited = oc.summary()
+	}
+
+	createNode := r.plan.addNode(Operation{
+		Type:       OpCreateContainer,
+		ResourceID: resID,
+		Cause:      "config changed (tmpName, surge)",
+		Service:    &svc,
+		Inherited:  inherited,
+		Number:     oc.Number,
+		Name:       tmpName,
+	}, group, allDeps...)
+
+	startNode := r.plan.addNode(Operation{
+		Type:         OpStartContainer,
+		ResourceID:   resID,
+		Cause:        "surge",
+		Service:      &svc,
+		CreateNodeID: createNode.ID,
+	}, group, createNode)
+
+	waitNode := r.plan.addNode(Operation{
+		Type:            OpWaitContainer,
+		ResourceID:      resID,
+		Cause:           "surge",
+		Service:         &svc,
+		CreateNodeID:    createNode.ID,
+		RemoveOnFailure: true,
+	}, group, startNode)
+
+	stopNode := r.plan.addNode(Operation{
+		Type:       OpStopContainer,
+		ResourceID: resID,
+		Cause:      fmt.Sprintf("replaced by #%d", createNode.ID),
+		Container:  oc.summary(),
+		Timeout:    r.options.Timeout,
+	}, group, r.planDrainContainer(oc, resID, group, update.drain, waitNode))
+	r.stoppedByPlan[oc.ID] = stopNode
+
+	removeNode := r.plan.addNode(Operation{
+		Type:       OpRemoveContainer,
+		ResourceID: resID,
+		Cause:      fmt.Sprintf("replaced by #%d", createNode.ID),
+		Container:  oc.summary(),
+	}, group, stopNode)
+
+	renameNode := r.plan.addNode(Operation{
+		Type:         OpRenameContainer,
+		ResourceID:   resID,
+		Cause:        "finalize recreate",
+		Name:         getContainerName(r.project.Name, service, oc.Number),
+		CreateNodeID: createNode.ID,
+	}, group, removeNode)
+	if len(update.PostRecreate) == 0 {
+		return renameNode
+	}
+	// the replacement is started by the plan itself: the post_recreate hooks
+	// run once it took over, rather than when the project is started
+	return r.plan.addNode(Operation{
+		Type:         OpPostRecreateHook,
+		ResourceID:   resID,
+		Cause:        "x-update.post_recreate",
+		Service:      &svc,
+		CreateNodeID: createNode.ID,
+	}, group, renameNode)
+}
+
+// planPreRecreateHook plans the x-update pre_recreate hooks to run in the old
+// container before anything else of its recreate, and returns the nodes the
+// creation of its replacement must depend on. Hooks can only run in a running
+// container: they are skipped when it is stopped, or will be by an earlier
+// stage of the plan.
+func (r *reconciler) planPreRecreateHook(service *types.ServiceConfig, oc *ObservedContainer, resID, group string, update updateConfig, deps []*PlanNode) []*PlanNode {
+	if _, alreadyStopped := r.stoppedByPlan[oc.ID]; alreadyStopped || len(update.PreRecreate) == 0 || oc.State != container.StateRunning {
+		return deps
+	}
+	return []*PlanNode{r.plan.addNode(Operation{
+		Type:       OpPreRecreateHook,
+		ResourceID: resID,
+		Cause:      "x-update.pre_recreate",
+		Service:    service,
+		Container:  oc.summary(),
+	}, group, deps...)}
+}
+
+// planDrainContainer plans the x-update.drain period the old container keeps
+// running after its replacement is up (after node), and returns the node its
+// stop must depend on.
+func (r *reconciler) planDrainContainer(oc *ObservedContainer, resID, group string, drain time.Duration, after *PlanNode) *PlanNode {
+	if drain == 0 || oc.State != container.StateRunning {
+		return after
+	}
+	return r.plan.addNode(Operation{
+		Type:       OpDrainContainer,
+		ResourceID: resID,
+		Cause:      "drain",
+		Container:  oc.summary(),
+		Timeout:    r.options.Timeout,
+		Drain:      drain,
+	}, group, after)
+}
+
 // planStopDependents plans stop operations for containers of services that
 // depend on the given service with restart: true. Each emitted Stop is
 // recorded in stoppedByPlan so a later planRecreateContainer for the same
-// dependent reuses it instead of emitting a duplicate Stop.
+// dependent reuses it instead of emitting a duplicate Stop. The restart policy
+// of the dependents is suspended while they are stopped, so the engine doesn't
+// start them again before their dependency is back.
 func (r *reconciler) planStopDependents(service types.ServiceConfig) []*PlanNode {
 	dependents := r.project.GetDependentsForService(service, func(dep types.ServiceDependency) bool {
 		return dep.Restart
 	})
 	var nodes []*PlanNode
 	for _, depName := range dependents {
+		suspendRestart := false
+		if dependent, err := r.project.GetService(depName); err == nil {
+			suspendRestart = restartsAutomatically(dependent)
+		}
 		for i, oc := range r.observed.Containers[depName] {
-			if _, already := r.stoppedByPlan[oc.ID]; already {
+			if _, already := r.stoppedByPlan[oc.ID]; already || oc.excluded() {
 				continue
 			}
 			node := r.plan.addNode(Operation{
-				Type:       OpStopContainer,
-				ResourceID: fmt.Sprintf("service:%s:%d", depName, oc.Number),
-				Cause:      fmt.Sprintf("dependency %s being recreated", service.Name),
-				Container:  &r.observed.Containers[depName][i].Summary,
-				Timeout:    r.options.Timeout,
+				Type:           OpStopContainer,
+				ResourceID:     fmt.Sprintf("service:%s:%d", depName, oc.Number),
+				Cause:          fmt.Sprintf("dependency %s being recreated", service.Name),
+				Container:      r.observed.Containers[depName][i].summary(),
+				Timeout:        r.options.Timeout,
+				SuspendRestart: suspendRestart,
 			}, "")
 			r.stoppedByPlan[oc.ID] = node
 			nodes = append(nodes, node)
@@ -874,12 +1780,22 @@ func (r *reconciler) infrastructureDeps(service types.ServiceConfig) []*PlanNode
 	var deps []*PlanNode
 	// Sort map keys for deterministic plan output in tests
 	for _, net := range sortedKeys(service.Networks) {
+		if nw, ok := r.lazyNetworks[net]; ok {
+			delete(r.lazyNetworks, net)
+			r.drift("", api.DriftNetwork, fmt.Sprintf("network %s not found", net))
+			r.planCreateNetwork(net, nw)
+		}
 		if node, ok := r.networkNodes[net]; ok {
 			deps = append(deps, node)
 		}
 	}
 	for _, vol := range service.Volumes {
 		if vol.Type == string(mmount.TypeVolume) && vol.Source != "" {
+			if v, ok := r.lazyVolumes[vol.Source]; ok {
+				delete(r.lazyVolumes, vol.Source)
+				r.drift("", api.DriftVolume, fmt.Sprintf("volume %s not found", vol.Source))
+				r.planCreateVolume(vol.Source, v, "not found")
+			}
 			if node, ok := r.volumeNodes[vol.Source]; ok {
 				deps = append(deps, node)
 			}
@@ -893,12 +1809,66 @@ func (r *reconciler) infrastructureDeps(service types.ServiceConfig) []*PlanNode
 	return deps
 }
 
+// resolveDuplicateNumbers detects the service containers sharing a number,
+// which the rest of the reconciliation assumes to be unique. Unless configured
+// to fail, the newest container is kept and the others are planned for
+// removal. The containers left and the last removal node are returned.
+func (r *reconciler) resolveDuplicateNumbers(service types.ServiceConfig, containers []ObservedContainer) ([]ObservedContainer, *PlanNode, error) {
+	byNumber := map[int][]ObservedContainer{}
+	for _, oc := range containers {
+		byNumber[oc.Number] = append(byNumber[oc.Number], oc)
+	}
+	var (
+		kept     []ObservedContainer
+		lastNode *PlanNode
+	)
+	for _, number := range slices.Sorted(maps.Keys(byNumber)) {
+		duplicates := byNumber[number]
+		if len(duplicates) == 1 {
+			kept = append(kept, duplicates[0])
+			continue
+		}
+		// newest first
+		sort.SliceStable(duplicates, func(i, j int) bool {
+			return duplicates[i].Created > duplicates[j].Created
+		})
+		names := make([]string, len(duplicates))
+		for i, oc := range duplicates {
+			names[i] = oc.Name
+		}
+		if r.options.DuplicateNumbers == api.DuplicateNumbersError {
+			return nil, nil, fmt.Errorf("service %q has %d containers numbered %d: %s. Remove all but one of them, or run with --duplicate-numbers=%s",
+				service.Name, len(duplicates), number, strings.Join(names, ", "), api.DuplicateNumbersKeepNewest)
+		}
+		logrus.Warnf("service %q has %d containers numbered %d: %s. Keeping the newest one, %s",
+			service.Name, len(duplicates), number, strings.Join(names, ", "), names[0])
+		kept = append(kept, duplicates[0])
+		for i := range duplicates[1:] {
+			oc := &duplicates[i+1]
+			stopNode := r.plan.addNode(Operation{
+				Type:       OpStopContainer,
+				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, number),
+				Cause:      "duplicate container number",
+				Container:  oc.summary(),
+				Timeout:    r.options.Timeout,
+			}, "")
+			lastNode = r.plan.addNode(Operation{
+				Type:       OpRemoveContainer,
+				ResourceID: fmt.Sprintf("service:%s:%d", service.Name, number),
+				Cause:      "duplicate container number",
+				Container:  oc.summary(),
+			}, "", stopNode)
+		}
+	}
+	return kept, lastNode, nil
+}
+
 // sortContainers sorts containers the same way as convergence.go:138-160:
 // obsolete first, then by container number descending, then reversed.
 //
 // mustRecreate is evaluated once per container before sorting to avoid
 // quadratic re-evaluation in the comparator.
-func (r *reconciler) sortContainers(containers []ObservedContainer, service types.ServiceConfig, expectedHash string, parentRecreated bool, policy string) {
+func (r *reconciler) sortContainers(containers []ObservedContainer, service types.ServiceConfig, expectedHash string, parentRecreated string, policy string) {
 	obsolete := make(map[string]bool, len(containers))
 	for _, oc := range containers {
 		obsolete[oc.ID] = r.mustRecreate(service, expectedHash, parentRecreated, oc, policy)
@@ -908,15 +1878,107 @@ func (r *reconciler) sortContainers(containers []ObservedContainer, service type
 		if obsi != obsj {
 			return obsi // obsolete first
 		}
+		// keep running containers rather than the ones kept stopped by an
+		// earlier scale down
+		if stoppedi, stoppedj := containers[i].stopped(), containers[j].stopped(); r.options.KeepScaledDown && stoppedi != stoppedj {
+			return stoppedi
+		}
 		// preserve low container numbers
 		if containers[i].Number != containers[j].Number {
 			return containers[i].Number > containers[j].Number
 		}
-		return containers[i].Summary.Created < containers[j].Summary.Created
+		return containers[i].Created < containers[j].Created
 	})
 	slices.Reverse(containers)
 }
 
+// keptScaledDown reports whether the containers beyond expected, in the order
+// of sortContainers, are all kept stopped by KeepScaledDown, which then isn't
+// a scale drift.
+func (r *reconciler) keptScaledDown(containers []ObservedContainer, expected int) bool {
+	if !r.options.KeepScaledDown || len(containers) < expected {
+		return false
+	}
+	for _, oc := range containers[expected:] {
+		if !oc.stopped() {
+			return false
+		}
+	}
+	return true
+}
+
+// checkReferencedScaleDown handles the containers to be removed by a scale
+// down, in the order of sortContainers, which running containers of the
+// project share the namespaces or the volumes of: removing them would leave
+// the dependents with a dead network namespace. Depending on
+// ScaleDownReferenced, the removal is warned about, other containers are
+// selected to be removed instead, the convergence fails or the dependents are
+// recreated to bind to a remaining container.
+func (r *reconciler) checkReferencedScaleDown(service types.ServiceConfig, containers []ObservedContainer, expected int) error {
+	if expected >= len(containers) || r.options.KeepScaledDown {
+		return nil
+	}
+	for i := expected; i < len(containers); i++ {
+		dependents := r.referencingContainers(containers[i])
+		if len(dependents) == 0 {
+			continue
+		}
+		switch r.options.ScaleDownReferenced {
+		case api.ScaleDownReferencedReselect:
+			if j := r.unreferencedContainer(containers[:expected]); j >= 0 {
+				containers[i], containers[j] = containers[j], containers[i]
+				continue
+			}
+			logrus.Warnf("service %q: scale down removes container %s which %s depend on, and no other container can be removed instead",
+				service.Name, containers[i].Name, strings.Join(dependents, ", "))
+		case api.ScaleDownReferencedError:
+			return fmt.Errorf("service %q: scale down would remove container %s which %s depend on. Run with --scale-down-referenced=%s or %s",
+				service.Name, containers[i].Name, strings.Join(dependents, ", "), api.ScaleDownReferencedReselect, api.ScaleDownReferencedRecreate)
+		case api.ScaleDownReferencedRecreate:
+			// dependents are recreated by the parentNamespaceRecreated cascade
+			r.recreatedServices[service.Name] = true
+		default:
+			logrus.Warnf("service %q: scale down removes container %s which %s depend on",
+				service.Name, containers[i].Name, strings.Join(dependents, ", "))
+		}
+	}
+	return nil
+}
+
+// referencingContainers returns the names of the running containers of the
+// project sharing the namespaces or the volumes of oc
+func (r *reconciler) referencingContainers(oc ObservedContainer) []string {
+	var names []string
+	for _, name := range r.project.ServiceNames() {
+		if sidecarOf(r.project.Services[name]) == oc.Labels[api.ServiceLabel] {
+			// sidecars are scaled down along with the containers they are
+			// paired with
+			continue
+		}
+		for _, other := range r.observed.Containers[name] {
+			if other.State != container.StateRunning {
+				continue
+			}
+			if slices.Contains(other.References, oc.ID) || slices.Contains(other.References, oc.Name) {
+				names = append(names, other.Name)
+			}
+		}
+	}
+	return names
+}
+
+// unreferencedContainer returns the index of the last of kept no running
+// container of the project shares the namespaces or the volumes of, -1 if
+// none
+func (r *reconciler) unreferencedContainer(kept []ObservedContainer) int {
+	for j := len(kept) - 1; j >= 0; j-- {
+		if len(r.referencingContainers(kept[j])) == 0 {
+			return j
+		}
+	}
+	return -1
+}
+
 // reconcileOrphans plans stop + remove for orphaned containers.
 func (r *reconciler) reconcileOrphans() {
 	for i, oc := range r.observed.Orphans {
@@ -924,25 +1986,41 @@ func (r *reconciler) reconcileOrphans() {
 			Type:       OpStopContainer,
 			ResourceID: fmt.Sprintf("orphan:%s", oc.Name),
 			Cause:      "orphaned container",
-			Container:  &r.observed.Orphans[i].Summary,
+			Container:  r.observed.Orphans[i].summary(),
 			Timeout:    r.options.Timeout,
 		}, "")
 		r.plan.addNode(Operation{
 			Type:       OpRemoveContainer,
 			ResourceID: fmt.Sprintf("orphan:%s", oc.Name),
 			Cause:      "orphaned container",
-			Container:  &r.observed.Orphans[i].Summary,
+			Container:  r.observed.Orphans[i].summary(),
 		}, "", stopNode)
 	}
 }
 
+// convergedContainers returns the observed containers of service, but the
+// ones labeled to be left out of convergence, which are reported
+func (r *reconciler) convergedContainers(service types.ServiceConfig) []ObservedContainer {
+	var containers []ObservedContainer
+	for _, oc := range r.observed.Containers[service.Name] {
+		if oc.excluded() {
+			logrus.Warnf("service %q: container %s is labeled %s=%s, leaving it out of convergence", service.Name, oc.Name, api.ConvergenceLabel, api.ConvergenceIgnore)
+			continue
+		}
+		containers = append(containers, oc)
+	}
+	return containers
+}
+
 // observedSummaries returns the raw container.Summary list for a service,
-// needed by nextContainerNumber which expects []container.Summary.
+// needed by allocateContainerNumbers which expects []container.Summary. The
+// containers left out of convergence are included, so their numbers aren't
+// reused.
 func (r *reconciler) observedSummaries(serviceName string) []container.Summary {
 	ocs := r.observed.Containers[serviceName]
 	result := make([]container.Summary, len(ocs))
 	for i, oc := range ocs {
-		result[i] = oc.Summary
+		result[i] = *oc.summary()
 	}
 	return result
 }
@@ -0,0 +1,188 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// detectDriftFunc reports the drifts of the services of the project from
+// their expected state
+type detectDriftFunc func(ctx context.Context) ([]api.Drift, error)
+
+// convergeFunc converges services of the project
+type convergeFunc func(ctx context.Context, services []string) error
+
+// runReconcileLoop checks the project for drift every interval, and
+// converges again the services diverging. A failed check or convergence is
+// logged, and retried on the next interval. It returns once ctx is done.
+func (s *composeService) runReconcileLoop(ctx context.Context, interval time.Duration, detect detectDriftFunc, converge convergeFunc) error {
+	ticker := s.clock.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.Chan():
+		}
+		drifts, err := detect(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logrus.Warnf("failed to check the project for drift: %v", err)
+			continue
+		}
+		services, details := driftsByService(drifts)
+		if len(services) == 0 {
+			continue
+		}
+		for _, service := range services {
+			s.events(ctx).On(newEvent("Service "+service, api.Working, "Reconciling", details[service]))
+		}
+		if err := converge(ctx, services); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			for _, service := range services {
+				s.events(ctx).On(errorEvent("Service "+service, err.Error()))
+			}
+			logrus.Warnf("failed to reconcile services %s: %v", strings.Join(services, ", "), err)
+			continue
+		}
+		for _, service := range services {
+			s.events(ctx).On(newEvent("Service "+service, api.Done, "Reconciled", details[service]))
+		}
+	}
+}
+
+// driftsByService returns the sorted names of the services drifts apply to,
+// with their joined details
+func driftsByService(drifts []api.Drift) ([]string, map[string]string) {
+	details := map[string]string{}
+	var services []string
+	for _, d := range drifts {
+		if d.Service == "" {
+			continue
+		}
+		if detail, ok := details[d.Service]; ok {
+			details[d.Service] = detail + "; " + d.Detail
+			continue
+		}
+		services = append(services, d.Service)
+		details[d.Service] = d.Detail
+	}
+	slices.Sort(services)
+	return services, details
+}
+
+// detectDrift returns the detectDriftFunc listing the project containers,
+// networks and volumes again to compare them with the live project
+func (s *composeService) detectDrift(live *liveProject) detectDriftFunc {
+	return func(ctx context.Context) ([]api.Drift, error) {
+		// observeDiff mutates the project it prepares
+		project, err := live.get().WithServicesTransform(func(_ string, svc types.ServiceConfig) (types.ServiceConfig, error) {
+			return svc, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		project, observed, err := s.observeDiff(ctx, project)
+		if err != nil {
+			return nil, err
+		}
+		drifts, err := diff(project, observed)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, missingNetworkDrifts(project, observed)...)
+		return append(drifts, stoppedContainerDrifts(project, observed)...), nil
+	}
+}
+
+// missingNetworkDrifts reports the services attached to a network of the
+// project which was removed
+func missingNetworkDrifts(project *types.Project, observed *ObservedState) []api.Drift {
+	var drifts []api.Drift
+	for _, key := range sortedKeys(project.Networks) {
+		if project.Networks[key].External {
+			continue
+		}
+		if _, ok := observed.Networks[key]; ok {
+			continue
+		}
+		for _, name := range project.ServiceNames() {
+			if _, ok := project.Services[name].Networks[key]; ok {
+				drifts = append(drifts, api.Drift{Service: name, Kind: api.DriftNetwork, Detail: fmt.Sprintf("network %s not found", key)})
+			}
+		}
+	}
+	return drifts
+}
+
+// stoppedContainerDrifts reports the stopped containers of the services the
+// engine doesn't restart, as their restart policy is no. The containers of
+// one-shot services, which others wait for to complete, are expected to stop.
+func stoppedContainerDrifts(project *types.Project, observed *ObservedState) []api.Drift {
+	var drifts []api.Drift
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		if policy := getRestartPolicy(service).Name; policy != "" && policy != container.RestartPolicyDisabled {
+			continue
+		}
+		if getDependencyCondition(service, project) == types.ServiceConditionCompletedSuccessfully {
+			continue
+		}
+		for _, oc := range observed.Containers[name] {
+			if oc.stopped() {
+				drifts = append(drifts, api.Drift{Service: name, Kind: api.DriftStopped, Detail: fmt.Sprintf("%s is %s", oc.Name, oc.State)})
+			}
+		}
+	}
+	return drifts
+}
+
+// reconcileDrift returns the convergeFunc converging services of the live
+// project with the options of up. Only the diverged containers are
+// recreated, whatever the recreate policy up ran with.
+func (s *composeService) reconcileDrift(live *liveProject, options api.UpOptions, listener api.ContainerEventListener) convergeFunc {
+	return func(ctx context.Context, services []string) error {
+		project := live.get()
+		create := options.Create
+		create.Services = services
+		create.ScaleDelta = nil
+		create.Recreate = api.RecreateDiverged
+		create.RecreateDependencies = api.RecreateDiverged
+		if err := s.create(ctx, project, create); err != nil {
+			return err
+		}
+		start := options.Start
+		start.Project = project
+		start.Services = services
+		return s.start(ctx, project.Name, start, listener)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/jonboulle/clockwork"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// driftingProject is a project whose observed state is mutated between the
+// checks of the reconcile loop, converging restores it
+type driftingProject struct {
+	mu        sync.Mutex
+	project   *types.Project
+	observed  *ObservedState
+	checked   chan struct{}
+	converged [][]string
+	err       error
+}
+
+func (d *driftingProject) detect(context.Context) ([]api.Drift, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer func() { d.checked <- struct{}{} }()
+	drifts := missingNetworkDrifts(d.project, d.observed)
+	return append(drifts, stoppedContainerDrifts(d.project, d.observed)...), nil
+}
+
+func (d *driftingProject) converge(_ context.Context, services []string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.converged = append(d.converged, services)
+	if d.err != nil {
+		return d.err
+	}
+	d.observed.Networks["back"] = ObservedNetwork{ID: "n-back"}
+	for name, containers := range d.observed.Containers {
+		for i := range containers {
+			containers[i].State = container.StateRunning
+		}
+		d.observed.Containers[name] = containers
+	}
+	return nil
+}
+
+func (d *driftingProject) mutate(fn func(observed *ObservedState)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(d.observed)
+}
+
+func newDriftingProject() *driftingProject {
+	observed := emptyObservedState("myproject")
+	observed.Networks["back"] = ObservedNetwork{ID: "n-back"}
+	observed.Containers["web"] = []ObservedContainer{{Name: "myproject-web-1", State: container.StateRunning}}
+	observed.Containers["api"] = []ObservedContainer{{Name: "myproject-api-1", State: container.StateRunning}}
+	observed.Containers["db"] = []ObservedContainer{{Name: "myproject-db-1", State: container.StateRunning}}
+	return &driftingProject{
+		project: &types.Project{
+			Name: "myproject",
+			Services: types.Services{
+				"web": {Name: "web"},
+				"api": {Name: "api", Networks: map[string]*types.ServiceNetworkConfig{"back": nil}},
+				"db":  {Name: "db", Restart: types.RestartPolicyAlways},
+			},
+			Networks: types.Networks{"back": {}},
+		},
+		observed: observed,
+		checked:  make(chan struct{}),
+	}
+}
+
+func TestRunReconcileLoop(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	events := &capturingEvents{}
+	svc.eventBus = events
+	drifting := newDriftingProject()
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.runReconcileLoop(ctx, 5*time.Minute, drifting.detect, drifting.converge)
+	}()
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+
+	// nothing drifted
+	clock.Advance(5 * time.Minute)
+	<-drifting.checked
+
+	drifting.mutate(func(observed *ObservedState) {
+		observed.Containers["web"][0].State = container.StateExited
+		// the engine restarts the containers of db
+		observed.Containers["db"][0].State = container.StateExited
+		delete(observed.Networks, "back")
+	})
+	clock.Advance(5 * time.Minute)
+	<-drifting.checked
+
+	// the previous check converged the project back
+	clock.Advance(5 * time.Minute)
+	<-drifting.checked
+	cancel()
+	assert.NilError(t, <-done)
+
+	assert.DeepEqual(t, drifting.converged, [][]string{{"api", "web"}})
+	var got []string
+	for _, e := range events.resources {
+		got = append(got, e.ID+" "+e.Text+" "+e.Details)
+	}
+	assert.DeepEqual(t, got, []string{
+		"Service api Reconciling network back not found",
+		"Service web Reconciling myproject-web-1 is exited",
+		"Service api Reconciled network back not found",
+		"Service web Reconciled myproject-web-1 is exited",
+	})
+}
+
+func TestRunReconcileLoopFailure(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	svc.eventBus = &capturingEvents{}
+	drifting := newDriftingProject()
+	drifting.err = errors.New("no space left on device")
+	drifting.mutate(func(observed *ObservedState) {
+		observed.Containers["web"][0].State = container.StateExited
+	})
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.runReconcileLoop(ctx, time.Minute, drifting.detect, drifting.converge)
+	}()
+
+	// a failed convergence is retried on the next interval
+	for range 2 {
+		assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+		clock.Advance(time.Minute)
+		<-drifting.checked
+	}
+	cancel()
+	assert.NilError(t, <-done)
+	assert.DeepEqual(t, drifting.converged, [][]string{{"web"}, {"web"}})
+}
+
+func TestStoppedContainerDrifts(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web":     {Name: "web", DependsOn: types.DependsOnConfig{"migrate": {Condition: types.ServiceConditionCompletedSuccessfully}}},
+			"migrate": {Name: "migrate"},
+			"worker":  {Name: "worker", Restart: types.RestartPolicyUnlessStopped},
+		},
+	}
+	observed := emptyObservedState("myproject")
+	observed.Containers["web"] = []ObservedContainer{
+		{Name: "myproject-web-1", State: container.StateRunning},
+		{Name: "myproject-web-2", State: container.StateCreated},
+	}
+	observed.Containers["migrate"] = []ObservedContainer{{Name: "myproject-migrate-1", State: container.StateExited}}
+	observed.Containers["worker"] = []ObservedContainer{{Name: "myproject-worker-1", State: container.StateExited}}
+
+	assert.DeepEqual(t, stoppedContainerDrifts(project, observed), []api.Drift{
+		{Service: "web", Kind: api.DriftStopped, Detail: "myproject-web-2 is created"},
+	})
+}
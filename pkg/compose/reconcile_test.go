@@ -18,12 +18,16 @@ package compose
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/api/types/container"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"gotest.tools/v3/assert"
 
 	"github.com/docker/compose/v5/pkg/api"
@@ -151,12 +155,9 @@ func TestReconcileNetworks_Diverged(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1aabbccddee", Number: 1, State: container.StateRunning,
-				Summary: container.Summary{
-					ID: "c1aabbccddee",
-					Labels: map[string]string{
-						api.ServiceLabel:         "web",
-						api.ContainerNumberLabel: "1",
-					},
+				Labels: map[string]string{
+					api.ServiceLabel:         "web",
+					api.ContainerNumberLabel: "1",
 				},
 			}},
 		},
@@ -206,11 +207,11 @@ func TestReconcileNetworks_DivergedMultipleServices(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1aabbccddee", Number: 1, State: container.StateRunning,
-				Summary: container.Summary{ID: "c1aabbccddee", Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"}},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
 			}},
 			"api": {{
 				ID: "c2aabbccddee", Number: 1, State: container.StateRunning,
-				Summary: container.Summary{ID: "c2aabbccddee", Labels: map[string]string{api.ServiceLabel: "api", api.ContainerNumberLabel: "1"}},
+				Labels: map[string]string{api.ServiceLabel: "api", api.ContainerNumberLabel: "1"},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{
@@ -333,11 +334,7 @@ func divergedVolumeProject(t *testing.T, count, scale int) (*types.Project, *Obs
 			id := fmt.Sprintf("%s-%d", name, n)
 			observed.Containers[name] = append(observed.Containers[name], ObservedContainer{
 				ID: id, Number: n, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: id, State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: name, api.ContainerNumberLabel: strconv.Itoa(n), api.ConfigHashLabel: hash},
-					Mounts: []container.MountPoint{{Type: "volume", Name: vol.Name}},
-				},
+				Labels: map[string]string{api.ServiceLabel: name, api.ContainerNumberLabel: strconv.Itoa(n), api.ConfigHashLabel: hash}, Mounts: []ObservedMount{{Type: "volume", Name: vol.Name}},
 			})
 		}
 	}
@@ -478,11 +475,7 @@ func TestReconcileVolumes_DivergedConfirmedSharedContainer(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"db": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-					Mounts: []container.MountPoint{{Type: "volume", Name: vol1.Name}, {Type: "volume", Name: vol2.Name}},
-				},
+				Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash}, Mounts: []ObservedMount{{Type: "volume", Name: vol1.Name}, {Type: "volume", Name: vol2.Name}},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -523,11 +516,7 @@ func TestReconcileVolumes_DivergedPartialConfirm(t *testing.T) {
 	mountedContainer := func(id, service, hash, volName string) ObservedContainer {
 		return ObservedContainer{
 			ID: id, Number: 1, State: container.StateRunning, ConfigHash: hash,
-			Summary: container.Summary{
-				ID: id, State: container.StateRunning,
-				Labels: map[string]string{api.ServiceLabel: service, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				Mounts: []container.MountPoint{{Type: "volume", Name: volName}},
-			},
+			Labels: map[string]string{api.ServiceLabel: service, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash}, Mounts: []ObservedMount{{Type: "volume", Name: volName}},
 		}
 	}
 	observed := &ObservedState{
@@ -600,13 +589,11 @@ func TestReconcileVolumes_DivergedCascadesToDependent(t *testing.T) {
 	observed := &ObservedState{
 		ProjectName: "myproject",
 		Containers: map[string][]ObservedContainer{
-			"owner": {{ID: "owner-1", Number: 1, State: container.StateRunning, ConfigHash: ownerHash, Summary: ownerSummary}},
+			"owner": {{ID: "owner-1", Number: 1, State: container.StateRunning, ConfigHash: ownerHash,
+				Labels: ownerSummary.Labels, Mounts: []ObservedMount{{Type: "volume", Name: vol.Name}}}},
 			"dependent": {{
 				ID: "dependent-1", Number: 1, State: container.StateRunning, ConfigHash: dependentHash,
-				Summary: container.Summary{
-					ID: "dependent-1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "dependent", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dependentHash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "dependent", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dependentHash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -669,15 +656,13 @@ func TestReconcileVolumes_DivergedVolumesFromRemovedBeforeVolume(t *testing.T) {
 	observed := &ObservedState{
 		ProjectName: "myproject",
 		Containers: map[string][]ObservedContainer{
-			"owner": {{ID: "owner-1", Number: 1, State: container.StateRunning, ConfigHash: ownerHash, Summary: ownerSummary}},
+			"owner": {{ID: "owner-1", Number: 1, State: container.StateRunning, ConfigHash: ownerHash,
+				Labels: ownerSummary.Labels, Mounts: []ObservedMount{{Type: "volume", Name: vol.Name}}}},
 			"consumer": {{
 				ID: "consumer-1", Number: 1, State: container.StateRunning, ConfigHash: consumerHash,
-				Summary: container.Summary{
-					ID: "consumer-1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "consumer", api.ContainerNumberLabel: "1", api.ConfigHashLabel: consumerHash},
-					// Docker materializes the inherited mount on the consumer.
-					Mounts: []container.MountPoint{{Type: "volume", Name: vol.Name}},
-				},
+				Labels: map[string]string{api.ServiceLabel: "consumer", api.ContainerNumberLabel: "1", api.ConfigHashLabel: consumerHash},
+				// Docker materializes the inherited mount on the consumer.
+				Mounts: []ObservedMount{{Type: "volume", Name: vol.Name}},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -732,11 +717,7 @@ func TestReconcileVolumes_UnmanagedMatchReused(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"db": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: dbHash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dbHash},
-					Mounts: []container.MountPoint{{Type: "volume", Name: "myproject_data"}},
-				},
+				Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dbHash}, Mounts: []ObservedMount{{Type: "volume", Name: "myproject_data"}},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -795,12 +776,9 @@ func TestReconcileVolumes_RenamedMigratesContainers(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"db": {{
 				ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: dbHash,
-				Summary: container.Summary{
-					ID: "c1aabbccddee", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dbHash},
-					// The existing container is still mounted on the old volume.
-					Mounts: []container.MountPoint{{Type: "volume", Name: "myproject_data"}},
-				},
+				Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dbHash},
+				// The existing container is still mounted on the old volume.
+				Mounts: []ObservedMount{{Type: "volume", Name: "myproject_data"}},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -849,6 +827,57 @@ func TestReconcileVolumes_DivergedUnmountedVolume(t *testing.T) {
 `)+"\n")
 }
 
+// TestReconcileLazyResources verifies that with lazy resources, networks and
+// volumes are only created once a container using them is, and once only when
+// several services share them.
+func TestReconcileLazyResources(t *testing.T) {
+	project := &types.Project{
+		Name: "myproject",
+		Networks: types.Networks{
+			"front": {Name: "myproject_front"},
+			"back":  {Name: "myproject_back"},
+		},
+		Volumes: types.Volumes{
+			"data":  {Name: "myproject_data"},
+			"cache": {Name: "myproject_cache"},
+		},
+		Services: types.Services{
+			"web": {
+				Name:     "web",
+				Image:    "nginx",
+				Networks: map[string]*types.ServiceNetworkConfig{"front": nil},
+				Volumes:  []types.ServiceVolumeConfig{{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"}},
+			},
+			"api": {
+				Name:     "api",
+				Image:    "api",
+				Networks: map[string]*types.ServiceNetworkConfig{"front": nil},
+				Volumes:  []types.ServiceVolumeConfig{{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"}},
+			},
+			"db": {
+				Name:     "db",
+				Image:    "postgres",
+				Scale:    intPtr(0),
+				Networks: map[string]*types.ServiceNetworkConfig{"back": nil},
+				Volumes:  []types.ServiceVolumeConfig{{Type: types.VolumeTypeVolume, Source: "cache", Target: "/cache"}},
+			},
+		},
+	}
+	options := defaultReconcileOptions()
+	options.LazyResources = true
+
+	plan, err := reconcile(t.Context(), project, emptyObservedState("myproject"), options, noPrompt)
+	assert.NilError(t, err)
+
+	// back and cache are only used by db, scaled to 0
+	assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 network:front, CreateNetwork, not found
+[] -> #2 volume:data, CreateVolume, not found
+[1,2] -> #3 service:api:1, CreateContainer, no existing container
+[1,2] -> #4 service:web:1, CreateContainer, no existing container
+`)+"\n")
+}
+
 // --- Container tests ---
 
 func TestReconcileContainers_NewProject(t *testing.T) {
@@ -886,10 +915,7 @@ func TestReconcileContainers_AlreadyRunning(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -913,10 +939,7 @@ func TestReconcileContainers_ConfigChanged(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
-				Summary: container.Summary{
-					ID: "c1aabbccddee", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -934,6 +957,74 @@ func TestReconcileContainers_ConfigChanged(t *testing.T) {
 `)+"\n")
 }
 
+func TestReconcileContainers_Canary(t *testing.T) {
+	observedContainer := func(id string, number int) ObservedContainer {
+		n := strconv.Itoa(number)
+		return ObservedContainer{
+			ID: id, Number: number, State: container.StateRunning, ConfigHash: "oldhash",
+			Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: n, api.ConfigHashLabel: "oldhash"},
+		}
+	}
+	options := defaultReconcileOptions()
+	options.Canary = true
+
+	t.Run("several containers to recreate", func(t *testing.T) {
+		project := &types.Project{
+			Name:     "myproject",
+			Services: types.Services{"web": {Name: "web", Scale: intPtr(3)}},
+		}
+		observed := emptyObservedState("myproject")
+		observed.Containers["web"] = []ObservedContainer{
+			observedContainer("c1aabbccddee", 1),
+			observedContainer("c2aabbccddee", 2),
+			observedContainer("c3aabbccddee", 3),
+		}
+
+		plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+		assert.NilError(t, err)
+
+		// web-1 is recreated first, the others once it is confirmed
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[1] -> #2 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[2] -> #3 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[3] -> #4 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+[4] -> #5 service:web:1, StartContainer, canary [recreate:web:1]
+[5] -> #6 service:web:1, WaitContainer, canary [recreate:web:1]
+[6] -> #7 service:web:1, ConfirmCanary, canary [recreate:web:1]
+[7] -> #8 service:web:2, CreateContainer, config changed (tmpName) [recreate:web:2]
+[8] -> #9 service:web:2, StopContainer, replaced by #8 [recreate:web:2]
+[9] -> #10 service:web:2, RemoveContainer, replaced by #8 [recreate:web:2]
+[10] -> #11 service:web:2, RenameContainer, finalize recreate [recreate:web:2]
+[7] -> #12 service:web:3, CreateContainer, config changed (tmpName) [recreate:web:3]
+[12] -> #13 service:web:3, StopContainer, replaced by #12 [recreate:web:3]
+[13] -> #14 service:web:3, RemoveContainer, replaced by #12 [recreate:web:3]
+[14] -> #15 service:web:3, RenameContainer, finalize recreate [recreate:web:3]
+`)+"\n")
+		assert.Check(t, plan.Nodes[5].Operation.Canary)
+	})
+
+	t.Run("single container to recreate", func(t *testing.T) {
+		project := &types.Project{
+			Name:     "myproject",
+			Services: types.Services{"web": {Name: "web", Scale: intPtr(1)}},
+		}
+		observed := emptyObservedState("myproject")
+		observed.Containers["web"] = []ObservedContainer{observedContainer("c1aabbccddee", 1)}
+
+		plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+		assert.NilError(t, err)
+
+		// there are no other containers for a canary to protect
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[1] -> #2 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[2] -> #3 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[3] -> #4 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`)+"\n")
+	})
+}
+
 func TestReconcileContainers_ScaleUp(t *testing.T) {
 	svc := types.ServiceConfig{Name: "web", Scale: intPtr(3)}
 	hash := mustServiceHash(t, svc)
@@ -947,10 +1038,7 @@ func TestReconcileContainers_ScaleUp(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -980,17 +1068,11 @@ func TestReconcileContainers_ScaleDown(t *testing.T) {
 			"web": {
 				{
 					ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: hash,
-					Summary: container.Summary{
-						ID: "c1", State: container.StateRunning,
-						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-					},
+					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 				},
 				{
 					ID: "c2", Number: 2, State: container.StateRunning, ConfigHash: hash,
-					Summary: container.Summary{
-						ID: "c2", State: container.StateRunning,
-						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "2", api.ConfigHashLabel: hash},
-					},
+					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "2", api.ConfigHashLabel: hash},
 				},
 			},
 		},
@@ -1007,6 +1089,68 @@ func TestReconcileContainers_ScaleDown(t *testing.T) {
 `)+"\n")
 }
 
+func TestReconcileContainers_KeepScaledDown(t *testing.T) {
+	observed := func(t *testing.T, svc types.ServiceConfig, states ...container.ContainerState) *ObservedState {
+		hash := mustServiceHash(t, svc)
+		var containers []ObservedContainer
+		for i, state := range states {
+			number := strconv.Itoa(i + 1)
+			containers = append(containers, ObservedContainer{
+				ID: "c" + number, Number: i + 1, State: state, ConfigHash: hash,
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: number, api.ConfigHashLabel: hash},
+			})
+		}
+		return &ObservedState{
+			ProjectName: "myproject",
+			Containers:  map[string][]ObservedContainer{"web": containers},
+			Networks:    map[string]ObservedNetwork{},
+			Volumes:     map[string]ObservedVolume{},
+		}
+	}
+	options := defaultReconcileOptions()
+	options.KeepScaledDown = true
+
+	tests := []struct {
+		name   string
+		scale  int
+		states []container.ContainerState
+		plan   string
+		drift  bool
+	}{
+		{
+			name:   "scale down stops without removing",
+			scale:  1,
+			states: []container.ContainerState{container.StateRunning, container.StateRunning},
+			plan:   "[] -> #1 service:web:2, StopContainer, scale down, kept stopped\n",
+			drift:  true,
+		},
+		{
+			name:   "kept container left stopped",
+			scale:  1,
+			states: []container.ContainerState{container.StateExited, container.StateRunning},
+			plan:   "",
+		},
+		{
+			name:   "scale up reuses kept container",
+			scale:  2,
+			states: []container.ContainerState{container.StateRunning, container.StateExited},
+			plan:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := types.ServiceConfig{Name: "web", Scale: intPtr(tt.scale)}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": svc}}
+
+			r := newReconciler(project, observed(t, svc, tt.states...), options, noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.Equal(t, plan.String(), tt.plan)
+			assert.Equal(t, slices.ContainsFunc(r.drifts, func(d api.Drift) bool { return d.Kind == api.DriftScale }), tt.drift)
+		})
+	}
+}
+
 func TestReconcileContainers_ForceRecreate(t *testing.T) {
 	svc := types.ServiceConfig{Name: "web", Scale: intPtr(1)}
 	hash := mustServiceHash(t, svc)
@@ -1020,10 +1164,7 @@ func TestReconcileContainers_ForceRecreate(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1aabbccddee", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -1056,10 +1197,7 @@ func TestReconcileContainers_NeverRecreate(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -1087,10 +1225,7 @@ func TestReconcileContainers_ExitedIsNoop(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"web": {{
 				ID: "c1", Number: 1, State: container.StateExited, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateExited,
-					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -1164,10 +1299,7 @@ func TestReconcileContainers_DependsOnScaleDown(t *testing.T) {
 		Containers: map[string][]ObservedContainer{
 			"db": {{
 				ID: "c1", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: "c1", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}},
 		},
 		Networks: map[string]ObservedNetwork{},
@@ -1197,7 +1329,6 @@ func TestReconcileOrphans(t *testing.T) {
 		Containers:  map[string][]ObservedContainer{},
 		Orphans: []ObservedContainer{{
 			ID: "orphan1", Number: 1, Name: "myproject-old-1",
-			Summary: container.Summary{ID: "orphan1"},
 		}},
 		Networks: map[string]ObservedNetwork{},
 		Volumes:  map[string]ObservedVolume{},
@@ -1240,7 +1371,7 @@ func parentDependentObserved(t *testing.T, parent, dependent types.ServiceConfig
 	parentObserved := []ObservedContainer{{
 		ID: parentID, Number: 1, State: container.StateRunning,
 		ConfigHash: mustServiceHash(t, parent),
-		Summary:    parentSummary,
+		Labels:     parentSummary.Labels,
 	}}
 	containersByService := map[string]Containers{"parent": {parentSummary}}
 	dependentHash := mustResolvedServiceHash(t, dependent, containersByService)
@@ -1251,13 +1382,10 @@ func parentDependentObserved(t *testing.T, parent, dependent types.ServiceConfig
 			"dependent": {{
 				ID: "dependent_container_xyz", Number: 1, State: container.StateRunning,
 				ConfigHash: dependentHash,
-				Summary: container.Summary{
-					ID: "dependent_container_xyz", State: container.StateRunning,
-					Labels: map[string]string{
-						api.ServiceLabel:         "dependent",
-						api.ContainerNumberLabel: "1",
-						api.ConfigHashLabel:      dependentHash,
-					},
+				Labels: map[string]string{
+					api.ServiceLabel:         "dependent",
+					api.ContainerNumberLabel: "1",
+					api.ConfigHashLabel:      dependentHash,
 				},
 			}},
 		},
@@ -1317,7 +1445,7 @@ func TestReconcileContainers_NamespaceParentRecreated_CascadesToDependent(t *tes
 	}
 	observed := parentDependentObserved(t, parent, dependent)
 	observed.Containers["parent"][0].ConfigHash = "stale_parent_hash"
-	observed.Containers["parent"][0].Summary.Labels[api.ConfigHashLabel] = "stale_parent_hash"
+	observed.Containers["parent"][0].Labels[api.ConfigHashLabel] = "stale_parent_hash"
 
 	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
 	assert.NilError(t, err)
@@ -1375,18 +1503,12 @@ func TestReconcileContainers_MultipleParents_EitherTriggersCascade(t *testing.T)
 			}
 			obs.Containers[name] = []ObservedContainer{{
 				ID: name + "_id", Number: 1, State: container.StateRunning, ConfigHash: hash,
-				Summary: container.Summary{
-					ID: name + "_id", State: container.StateRunning,
-					Labels: map[string]string{api.ServiceLabel: name, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
-				},
+				Labels: map[string]string{api.ServiceLabel: name, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
 			}}
 		}
 		obs.Containers["dependent"] = []ObservedContainer{{
 			ID: "dependent_id", Number: 1, State: container.StateRunning, ConfigHash: dependentHash,
-			Summary: container.Summary{
-				ID: "dependent_id", State: container.StateRunning,
-				Labels: map[string]string{api.ServiceLabel: "dependent", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dependentHash},
-			},
+			Labels: map[string]string{api.ServiceLabel: "dependent", api.ContainerNumberLabel: "1", api.ConfigHashLabel: dependentHash},
 		}}
 		return obs
 	}
@@ -1415,7 +1537,7 @@ func TestReconcileContainers_RegularDependsOn_NoCascade(t *testing.T) {
 	}
 	observed := parentDependentObserved(t, parent, dependent)
 	observed.Containers["parent"][0].ConfigHash = "stale_parent_hash"
-	observed.Containers["parent"][0].Summary.Labels[api.ConfigHashLabel] = "stale_parent_hash"
+	observed.Containers["parent"][0].Labels[api.ConfigHashLabel] = "stale_parent_hash"
 
 	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
 	assert.NilError(t, err)
@@ -1425,8 +1547,1139 @@ func TestReconcileContainers_RegularDependsOn_NoCascade(t *testing.T) {
 	assert.Assert(t, !strings.Contains(planStr, "service:dependent:1, CreateContainer"), "dependent must NOT recreate without namespace sharing:\n%s", planStr)
 }
 
+// TestReconcileContainers_RecreateDependents verifies that RecreateDependents
+// extends the cascade to depends_on with restart: true, so dependents are
+// recreated rather than only stopped and restarted.
+func TestReconcileContainers_RecreateDependents(t *testing.T) {
+	parent := types.ServiceConfig{Name: "parent", Image: "alpine", Scale: intPtr(1)}
+	dependent := types.ServiceConfig{
+		Name: "dependent", Image: "alpine", Scale: intPtr(1),
+		DependsOn: types.DependsOnConfig{"parent": {Condition: types.ServiceConditionStarted, Restart: true}},
+	}
+	unrelated := types.ServiceConfig{
+		Name: "unrelated", Image: "alpine", Scale: intPtr(1),
+		DependsOn: types.DependsOnConfig{"parent": {Condition: types.ServiceConditionStarted}},
+	}
+	project := &types.Project{
+		Name:     "myproject",
+		Services: types.Services{"parent": parent, "dependent": dependent, "unrelated": unrelated},
+	}
+	observed := parentDependentObserved(t, parent, dependent)
+	observed.Containers["parent"][0].ConfigHash = "stale_parent_hash"
+	observed.Containers["parent"][0].Labels[api.ConfigHashLabel] = "stale_parent_hash"
+	unrelatedHash := mustServiceHash(t, unrelated)
+	observed.Containers["unrelated"] = []ObservedContainer{{
+		ID: "unrelated-id", Name: "myproject-unrelated-1", Number: 1, State: container.StateRunning, ConfigHash: unrelatedHash,
+		Labels: map[string]string{api.ServiceLabel: "unrelated", api.ContainerNumberLabel: "1", api.ConfigHashLabel: unrelatedHash},
+	}}
+
+	options := defaultReconcileOptions()
+	options.RecreateDependents = true
+	plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.NilError(t, err)
+
+	planStr := plan.String()
+	assert.Assert(t, strings.Contains(planStr, "service:parent:1, CreateContainer"), "parent must be recreated:\n%s", planStr)
+	assert.Assert(t, strings.Contains(planStr, "service:dependent:1, CreateContainer"), "dependent must be recreated:\n%s", planStr)
+	assert.Equal(t, strings.Count(planStr, "service:dependent:1, StopContainer"), 1, "duplicate Stop for dependent:\n%s", planStr)
+	assert.Assert(t, !strings.Contains(planStr, "service:unrelated:1"), "dependent without restart must be left untouched:\n%s", planStr)
+}
+
+func TestReconcileContainers_AtomicGroup(t *testing.T) {
+	schema := types.Mapping{api.AtomicGroupAnnotation: "schema"}
+	db := types.ServiceConfig{Name: "db", Image: "postgres", Scale: intPtr(1), Annotations: schema}
+	app := types.ServiceConfig{
+		Name: "app", Image: "alpine", Scale: intPtr(1), Annotations: schema,
+		DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionStarted}},
+	}
+	web := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	project := &types.Project{
+		Name:     "myproject",
+		Services: types.Services{"db": db, "app": app, "web": web},
+	}
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers:  map[string][]ObservedContainer{},
+		Networks:    map[string]ObservedNetwork{},
+		Volumes:     map[string]ObservedVolume{},
+	}
+	for _, svc := range []types.ServiceConfig{db, app, web} {
+		hash := mustServiceHash(t, svc)
+		if svc.Name == "db" {
+			hash = "stale_db_hash"
+		}
+		observed.Containers[svc.Name] = []ObservedContainer{{
+			ID: svc.Name + "-id", Name: "myproject-" + svc.Name + "-1", Number: 1, State: container.StateRunning, ConfigHash: hash,
+			Labels: map[string]string{api.ServiceLabel: svc.Name, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+		}}
+	}
+
+	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+
+	planStr := plan.String()
+	assert.Assert(t, !strings.Contains(planStr, "service:web:1"), "service out of the group must be left untouched:\n%s", planStr)
+	stops := map[string]*PlanNode{}
+	for _, node := range plan.Nodes {
+		if node.Operation.Type == OpStopContainer {
+			_, duplicate := stops[node.Operation.ResourceID]
+			assert.Assert(t, !duplicate, "duplicate Stop for %s:\n%s", node.Operation.ResourceID, planStr)
+			stops[node.Operation.ResourceID] = node
+		}
+	}
+	assert.Equal(t, len(stops), 2, planStr)
+	var creates int
+	for _, node := range plan.Nodes {
+		if node.Operation.Type != OpCreateContainer {
+			continue
+		}
+		creates++
+		for _, stop := range stops {
+			assert.Assert(t, slices.Contains(node.DependsOn, stop),
+				"%s must be created once all the group is stopped:\n%s", node.Operation.ResourceID, planStr)
+		}
+	}
+	assert.Equal(t, creates, 2, "all the group must be recreated:\n%s", planStr)
+	assert.Assert(t, slices.Contains(plan.Drifts, api.Drift{Service: "app", Kind: api.DriftRecreate, Detail: "myproject-app-1: " + recreateReasonAtomicGroup}))
+}
+
+func TestReconcileContainers_ImageDigest(t *testing.T) {
+	const (
+		indexDigest    = "sha256:index"    // multi-arch manifest list digest
+		platformDigest = "sha256:platform" // platform image manifest digest
+		imageID        = "sha256:imageid"
+	)
+	tests := []struct {
+		name           string
+		expectedDigest string
+		actualDigest   string
+		actualImageID  string
+		localImageIDs  map[string]string
+		recreate       bool
+	}{
+		{name: "same digest", expectedDigest: platformDigest, actualDigest: platformDigest},
+		{name: "digest changed", expectedDigest: platformDigest, actualDigest: indexDigest, recreate: true},
+		{name: "no digest on either side", actualImageID: imageID},
+		{
+			name: "container predates label, same image", expectedDigest: platformDigest,
+			actualImageID: imageID, localImageIDs: map[string]string{"alpine": imageID},
+		},
+		{
+			name: "container predates label, image changed", expectedDigest: platformDigest,
+			actualImageID: "sha256:old", localImageIDs: map[string]string{"alpine": imageID}, recreate: true,
+		},
+		{
+			name: "expected digest unknown, same image", actualDigest: indexDigest,
+			actualImageID: imageID, localImageIDs: map[string]string{"alpine": imageID},
+		},
+		{name: "ambiguous without local image ID", expectedDigest: platformDigest, actualImageID: imageID, recreate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := types.ServiceConfig{Name: "web", Image: "alpine", Scale: intPtr(1)}
+			hash := mustServiceHash(t, svc)
+			if tt.expectedDigest != "" {
+				svc.CustomLabels = types.Labels{api.ImageDigestLabel: tt.expectedDigest}
+			}
+			project := &types.Project{
+				Name:     "myproject",
+				Services: types.Services{"web": svc},
+			}
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: hash,
+						ImageDigest: tt.actualDigest, ImageID: tt.actualImageID,
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+				ImageIDs: tt.localImageIDs,
+			}
+
+			plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+			assert.Equal(t, !plan.IsEmpty(), tt.recreate, plan.String())
+		})
+	}
+}
+
+func TestReconcileContainers_SurgeRecreate(t *testing.T) {
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {Name: "web", Scale: intPtr(1), Extensions: types.Extensions{"x-update": map[string]any{"surge": true}}},
+		},
+	}
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers: map[string][]ObservedContainer{
+			"web": {{
+				ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+			}},
+		},
+		Networks: map[string]ObservedNetwork{},
+		Volumes:  map[string]ObservedVolume{},
+	}
+
+	plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+
+	assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName, surge) [recreate:web:1]
+[1] -> #2 service:web:1, StartContainer, surge [recreate:web:1]
+[2] -> #3 service:web:1, WaitContainer, surge [recreate:web:1]
+[3] -> #4 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[4] -> #5 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[5] -> #6 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`)+"\n")
+}
+
+func TestReconcileContainers_SurgeFallsBack(t *testing.T) {
+	surge := types.Extensions{"x-update": map[string]any{"surge": true}}
+	tests := []struct {
+		name    string
+		service types.ServiceConfig
+	}{
+		{name: "container_name", service: types.ServiceConfig{Name: "web", Scale: intPtr(1), ContainerName: "web", Extensions: surge}},
+		{name: "published port", service: types.ServiceConfig{
+			Name: "web", Scale: intPtr(1), Extensions: surge,
+			Ports: []types.ServicePortConfig{{Target: 80, Published: "8080"}},
+		}},
+		{name: "replicas", service: types.ServiceConfig{Name: "web", Scale: intPtr(2), Extensions: surge}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{
+				Name:     "myproject",
+				Services: types.Services{"web": tt.service},
+			}
+			surge, err := newReconciler(project, emptyObservedState("myproject"), defaultReconcileOptions(), noPrompt).
+				canSurge(tt.service, tt.service.GetScale(), 1)
+			assert.NilError(t, err)
+			assert.Check(t, !surge)
+		})
+	}
+}
+
+func TestReconcileContainers_Drain(t *testing.T) {
+	observed := func() *ObservedState {
+		return &ObservedState{
+			ProjectName: "myproject",
+			Containers: map[string][]ObservedContainer{
+				"web": {{
+					ID: "c1aabbccddee", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
+					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+				}},
+			},
+			Networks: map[string]ObservedNetwork{},
+			Volumes:  map[string]ObservedVolume{},
+		}
+	}
+	tests := []struct {
+		name   string
+		update map[string]any
+		want   string
+	}{
+		{
+			name:   "stop first",
+			update: map[string]any{"drain": "10s"},
+			want: `
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[1] -> #2 service:web:1, DrainContainer, drain [recreate:web:1]
+[2] -> #3 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[3] -> #4 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[4] -> #5 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`,
+		},
+		{
+			name:   "surge",
+			update: map[string]any{"surge": true, "drain": "10s"},
+			want: `
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName, surge) [recreate:web:1]
+[1] -> #2 service:web:1, StartContainer, surge [recreate:web:1]
+[2] -> #3 service:web:1, WaitContainer, surge [recreate:web:1]
+[3] -> #4 service:web:1, DrainContainer, drain [recreate:web:1]
+[4] -> #5 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[5] -> #6 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[6] -> #7 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{
+				Name: "myproject",
+				Services: types.Services{
+					"web": {Name: "web", Scale: intPtr(1), Extensions: types.Extensions{"x-update": tt.update}},
+				},
+			}
+			plan, err := reconcile(t.Context(), project, observed(), defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+			assert.Equal(t, plan.String(), strings.TrimSpace(tt.want)+"\n")
+			drain := plan.Nodes[len(plan.Nodes)-4].Operation
+			assert.Equal(t, drain.Type, OpDrainContainer)
+			assert.Equal(t, drain.Drain, 10*time.Second)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		project := &types.Project{
+			Name: "myproject",
+			Services: types.Services{
+				"web": {Name: "web", Scale: intPtr(1), Extensions: types.Extensions{"x-update": map[string]any{"drain": "soon"}}},
+			},
+		}
+		_, err := reconcile(t.Context(), project, observed(), defaultReconcileOptions(), noPrompt)
+		assert.ErrorContains(t, err, `service "web": invalid x-update.drain "soon": must be a positive duration`)
+	})
+}
+
+func TestReconcileContainers_RecreateHooks(t *testing.T) {
+	hooks := func(extra map[string]any) map[string]any {
+		update := map[string]any{
+			"pre_recreate":  []any{map[string]any{"command": []any{"/snapshot.sh"}, "abort_on_failure": true}},
+			"post_recreate": []any{map[string]any{"command": []any{"/notify.sh"}}},
+		}
+		maps.Copy(update, extra)
+		return update
+	}
+	tests := []struct {
+		name   string
+		update map[string]any
+		state  container.ContainerState
+		want   string
+	}{
+		{
+			name:   "stop first",
+			update: hooks(nil),
+			state:  container.StateRunning,
+			want: `
+[] -> #1 service:web:1, PreRecreateHook, x-update.pre_recreate [recreate:web:1]
+[1] -> #2 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[2] -> #3 service:web:1, StopContainer, replaced by #2 [recreate:web:1]
+[3] -> #4 service:web:1, RemoveContainer, replaced by #2 [recreate:web:1]
+[4] -> #5 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`,
+		},
+		{
+			name:   "surge",
+			update: hooks(map[string]any{"surge": true}),
+			state:  container.StateRunning,
+			want: `
+[] -> #1 service:web:1, PreRecreateHook, x-update.pre_recreate [recreate:web:1]
+[1] -> #2 service:web:1, CreateContainer, config changed (tmpName, surge) [recreate:web:1]
+[2] -> #3 service:web:1, StartContainer, surge [recreate:web:1]
+[3] -> #4 service:web:1, WaitContainer, surge [recreate:web:1]
+[4] -> #5 service:web:1, StopContainer, replaced by #2 [recreate:web:1]
+[5] -> #6 service:web:1, RemoveContainer, replaced by #2 [recreate:web:1]
+[6] -> #7 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+[7] -> #8 service:web:1, PostRecreateHook, x-update.post_recreate [recreate:web:1]
+`,
+		},
+		{
+			name:   "stopped container",
+			update: hooks(nil),
+			state:  container.StateExited,
+			want: `
+[] -> #1 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[1] -> #2 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[2] -> #3 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[3] -> #4 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{
+				Name: "myproject",
+				Services: types.Services{
+					"web": {Name: "web", Scale: intPtr(1), Extensions: types.Extensions{"x-update": tt.update}},
+				},
+			}
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Number: 1, State: tt.state, ConfigHash: "oldhash",
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+			}
+			plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+			assert.Equal(t, plan.String(), strings.TrimSpace(tt.want)+"\n")
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := getUpdateConfig(types.ServiceConfig{Name: "web", Extensions: types.Extensions{
+			"x-update": map[string]any{"post_recreate": []any{map[string]any{"user": "root"}}},
+		}})
+		assert.ErrorContains(t, err, `service "web": invalid x-update.post_recreate: hook has no command`)
+	})
+}
+
+func TestReconcileContainers_PreserveWritableLayer(t *testing.T) {
+	newProject := func(update map[string]any) *types.Project {
+		return &types.Project{
+			Name: "myproject",
+			Services: types.Services{
+				"web": {
+					Name: "web", Scale: intPtr(1),
+					CustomLabels: types.Labels{api.ImageDigestLabel: "sha256:current"},
+					Extensions:   types.Extensions{"x-update": update},
+				},
+			},
+		}
+	}
+	newObserved := func(digest string) *ObservedState {
+		return &ObservedState{
+			ProjectName: "myproject",
+			Containers: map[string][]ObservedContainer{
+				"web": {{
+					ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning,
+					ConfigHash: "oldhash", ImageDigest: digest,
+					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+				}},
+			},
+			Networks: map[string]ObservedNetwork{},
+			Volumes:  map[string]ObservedVolume{},
+		}
+	}
+
+	t.Run("config changed", func(t *testing.T) {
+		project := newProject(map[string]any{
+			"preserve_writable_layer": true,
+			"pre_recreate":            []any{map[string]any{"command": []any{"/flush.sh"}}},
+		})
+		plan, err := reconcile(t.Context(), project, newObserved("sha256:current"), defaultReconcileOptions(), noPrompt)
+		assert.NilError(t, err)
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:1, PreRecreateHook, x-update.pre_recreate [recreate:web:1]
+[1] -> #2 service:web:1, StopContainer, writable layer preserved [recreate:web:1]
+[1,2] -> #3 service:web:1, CommitContainer, x-update.preserve_writable_layer [recreate:web:1]
+[3] -> #4 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[4] -> #5 service:web:1, RemoveContainer, replaced by #4 [recreate:web:1]
+[5] -> #6 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+`)+"\n")
+		assert.Equal(t, plan.Nodes[3].Operation.CommitNodeID, plan.Nodes[2].ID)
+	})
+
+	t.Run("image changed", func(t *testing.T) {
+		project := newProject(map[string]any{"preserve_writable_layer": true})
+		_, err := reconcile(t.Context(), project, newObserved("sha256:previous"), defaultReconcileOptions(), noPrompt)
+		assert.Error(t, err, `service "web": can't preserve the writable layer of myproject-web-1 as its image changed, remove x-update.preserve_writable_layer to recreate it`)
+	})
+
+	t.Run("surge", func(t *testing.T) {
+		_, err := getUpdateConfig(types.ServiceConfig{Name: "web", Extensions: types.Extensions{
+			"x-update": map[string]any{"preserve_writable_layer": true, "surge": true},
+		}})
+		assert.ErrorContains(t, err, "preserve_writable_layer stops the old container before its replacement is created, and can't be combined with surge or drain")
+	})
+}
+
+func TestReconcileContainers_PreservePaused(t *testing.T) {
+	tests := []struct {
+		state          container.ContainerState
+		preservePaused bool
+		paused         bool
+	}{
+		{state: container.StatePaused, preservePaused: true, paused: true},
+		{state: container.StatePaused, preservePaused: false, paused: false},
+		{state: container.StateExited, preservePaused: true, paused: false},
+		{state: container.StateCreated, preservePaused: true, paused: false},
+		{state: container.StateRunning, preservePaused: true, paused: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s preserve=%t", tt.state, tt.preservePaused), func(t *testing.T) {
+			project := &types.Project{
+				Name:     "myproject",
+				Services: types.Services{"web": {Name: "web", Scale: intPtr(1)}},
+			}
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Number: 1, State: tt.state, ConfigHash: "oldhash",
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+			}
+			options := defaultReconcileOptions()
+			options.PreservePaused = tt.preservePaused
+
+			plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+			assert.NilError(t, err)
+			var creates int
+			for _, node := range plan.Nodes {
+				if node.Operation.Type == OpCreateContainer {
+					creates++
+					assert.Equal(t, node.Operation.Paused, tt.paused)
+				}
+			}
+			assert.Equal(t, creates, 1)
+		})
+	}
+}
+
+func TestReconcileContainers_ExcludedFromConvergence(t *testing.T) {
+	service := types.ServiceConfig{Name: "web", Image: "nginx"}
+	hash := mustServiceHash(t, service)
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers: map[string][]ObservedContainer{
+			"web": {
+				{
+					ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
+					Labels: map[string]string{
+						api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash",
+						api.ConvergenceLabel: api.ConvergenceIgnore,
+					},
+				},
+				{
+					ID: "c2aabbccddee", Name: "myproject-web-2", Number: 2, State: container.StateRunning, ConfigHash: hash,
+					Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "2", api.ConfigHashLabel: hash},
+				},
+			},
+		},
+		Networks: map[string]ObservedNetwork{},
+		Volumes:  map[string]ObservedVolume{},
+	}
+
+	tests := []struct {
+		scale int
+		want  string
+	}{
+		{
+			// the diverged container isn't recreated, nor counted toward the
+			// scale, and its number isn't reused
+			scale: 2,
+			want:  "[] -> #1 service:web:3, CreateContainer, no existing container\n",
+		},
+		{
+			// only the converged container is removed on scale down
+			scale: 0,
+			want: "[] -> #1 service:web:2, StopContainer, scale down\n" +
+				"[1] -> #2 service:web:2, RemoveContainer, scale down\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("scale=%d", tt.scale), func(t *testing.T) {
+			logs := logrustest.NewGlobal()
+			svc := service
+			svc.Scale = intPtr(tt.scale)
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": svc}}
+
+			plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+			assert.Equal(t, plan.String(), tt.want)
+			assert.Equal(t, logs.LastEntry().Message, `service "web": container myproject-web-1 is labeled com.docker.compose.convergence=ignore, leaving it out of convergence`)
+		})
+	}
+}
+
+func TestReconcileContainers_RecreateDependentsFirst(t *testing.T) {
+	db := types.ServiceConfig{Name: "db", Image: "postgres", Scale: intPtr(1)}
+	app := types.ServiceConfig{
+		Name: "app", Image: "myapp", Scale: intPtr(1),
+		DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionStarted}},
+	}
+	project := &types.Project{Name: "myproject", Services: types.Services{"db": db, "app": app}}
+	observed := &ObservedState{
+		ProjectName: "myproject",
+		Containers: map[string][]ObservedContainer{
+			"db": {{
+				ID: "db1aabbccddee", Name: "myproject-db-1", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
+				Labels: map[string]string{api.ServiceLabel: "db", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+			}},
+			"app": {{
+				ID: "app1aabbccdd", Name: "myproject-app-1", Number: 1, State: container.StateRunning, ConfigHash: "oldhash",
+				Labels: map[string]string{api.ServiceLabel: "app", api.ContainerNumberLabel: "1", api.ConfigHashLabel: "oldhash"},
+			}},
+		},
+		Networks: map[string]ObservedNetwork{},
+		Volumes:  map[string]ObservedVolume{},
+	}
+	options := defaultReconcileOptions()
+	options.RecreateOrder = api.RecreateOrderDependentsFirst
+
+	plan, err := reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.NilError(t, err)
+	// the container of app is replaced first, and the one of db once it is
+	assert.Equal(t, plan.String(), "[] -> #1 service:app:1, CreateContainer, config changed (tmpName) [recreate:app:1]\n"+
+		"[1] -> #2 service:app:1, StopContainer, replaced by #1 [recreate:app:1]\n"+
+		"[2] -> #3 service:app:1, RemoveContainer, replaced by #1 [recreate:app:1]\n"+
+		"[3] -> #4 service:app:1, RenameContainer, finalize recreate [recreate:app:1]\n"+
+		"[4] -> #5 service:db:1, CreateContainer, config changed (tmpName) [recreate:db:1]\n"+
+		"[5] -> #6 service:db:1, StopContainer, replaced by #5 [recreate:db:1]\n"+
+		"[6] -> #7 service:db:1, RemoveContainer, replaced by #5 [recreate:db:1]\n"+
+		"[7] -> #8 service:db:1, RenameContainer, finalize recreate [recreate:db:1]\n")
+
+	// only recreations are reordered: the containers created for the first
+	// time, here of the new cache dependency and to scale app up, are still
+	// created after the ones of their dependencies
+	cache := types.ServiceConfig{Name: "cache", Image: "redis", Scale: intPtr(1)}
+	app.Scale = intPtr(2)
+	app.DependsOn["cache"] = types.ServiceDependency{Condition: types.ServiceConditionStarted}
+	project.Services["cache"] = cache
+	project.Services["app"] = app
+	plan, err = reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.NilError(t, err)
+	assert.Equal(t, plan.String(), "[] -> #1 service:cache:1, CreateContainer, no existing container\n"+
+		"[1] -> #2 service:app:2, CreateContainer, no existing container\n"+
+		"[1] -> #3 service:app:1, CreateContainer, config changed (tmpName) [recreate:app:1]\n"+
+		"[3] -> #4 service:app:1, StopContainer, replaced by #3 [recreate:app:1]\n"+
+		"[4] -> #5 service:app:1, RemoveContainer, replaced by #3 [recreate:app:1]\n"+
+		"[5] -> #6 service:app:1, RenameContainer, finalize recreate [recreate:app:1]\n"+
+		"[6] -> #7 service:db:1, CreateContainer, config changed (tmpName) [recreate:db:1]\n"+
+		"[7] -> #8 service:db:1, StopContainer, replaced by #7 [recreate:db:1]\n"+
+		"[8] -> #9 service:db:1, RemoveContainer, replaced by #7 [recreate:db:1]\n"+
+		"[9] -> #10 service:db:1, RenameContainer, finalize recreate [recreate:db:1]\n")
+
+	// links are bound when the containers are created, to the ones of the
+	// linked service at the time
+	app.Links = []string{"db:database"}
+	project.Services["app"] = app
+	_, err = reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.Error(t, err, `service "app" links to service "db", which can't be recreated after it: recreate order dependents-first isn't supported`)
+
+	// a container sharing the namespace of a service container is created
+	// with the ID of the container, which must then be replaced first
+	app.Links = nil
+	app.NetworkMode = "service:db"
+	project.Services["app"] = app
+	_, err = reconcile(t.Context(), project, observed, options, noPrompt)
+	assert.Error(t, err, `service "app" uses the namespace or the volumes of service "db", which can't be recreated after it: recreate order dependents-first isn't supported`)
+}
+
 // --- Helpers ---
 
+func TestReconcileContainers_CompleteInterruptedRename(t *testing.T) {
+	service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+	project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+	hash := mustServiceHash(t, service)
+	observed := func(name string) *ObservedState {
+		return &ObservedState{
+			ProjectName: "myproject",
+			Containers: map[string][]ObservedContainer{
+				"web": {{
+					ID: "c2aabbccddee", Name: name, Number: 1, State: container.StateRunning, ConfigHash: hash,
+					Labels: map[string]string{
+						api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash,
+						api.TemporaryNameLabel: "c1aabbccddee_myproject-web-1",
+					},
+				}},
+			},
+			Networks: map[string]ObservedNetwork{},
+			Volumes:  map[string]ObservedVolume{},
+		}
+	}
+
+	plan, err := reconcile(t.Context(), project, observed("c1aabbccddee_myproject-web-1"), defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+	assert.Equal(t, plan.String(), "[] -> #1 service:web:1, RenameContainer, complete interrupted recreate\n")
+	assert.Equal(t, plan.Nodes[0].Operation.Name, "myproject-web-1")
+
+	// renamed already
+	plan, err = reconcile(t.Context(), project, observed("myproject-web-1"), defaultReconcileOptions(), noPrompt)
+	assert.NilError(t, err)
+	assert.Equal(t, len(plan.Nodes), 0)
+}
+
+func TestReconcileContainers_IgnoreConfigChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		update        map[string]any
+		configChanged bool
+		imageChanged  bool
+		want          []api.Drift
+	}{
+		{
+			name:          "config changed",
+			configChanged: true,
+			want:          []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: config hash diverged"}},
+		},
+		{name: "config changed, ignored", update: map[string]any{"ignore_config_changes": true}, configChanged: true},
+		{
+			name:          "image changed, config changes ignored",
+			update:        map[string]any{"ignore_config_changes": true},
+			configChanged: true,
+			imageChanged:  true,
+			want:          []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: image digest changed"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := types.ServiceConfig{
+				Name: "web", Image: "nginx", Scale: intPtr(1),
+				CustomLabels: types.Labels{api.ImageDigestLabel: "sha256:current"},
+			}
+			if tt.update != nil {
+				service.Extensions = types.Extensions{"x-update": tt.update}
+			}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+			hash := mustServiceHash(t, service)
+			if tt.configChanged {
+				hash = "stale_hash"
+			}
+			digest := "sha256:current"
+			if tt.imageChanged {
+				digest = "sha256:previous"
+			}
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning,
+						ConfigHash: hash, ImageDigest: digest,
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+			}
+
+			r := newReconciler(project, observed, defaultReconcileOptions(), noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, r.drifts, tt.want)
+			assert.Equal(t, len(plan.Nodes) > 0, tt.want != nil)
+		})
+	}
+}
+
+func TestReconcileContainers_HealthcheckChanged(t *testing.T) {
+	previous := types.ServiceConfig{
+		Name: "web", Image: "nginx", Scale: intPtr(1),
+		HealthCheck: &types.HealthCheckConfig{Test: types.HealthCheckTest{"CMD", "true"}},
+	}
+	interval := types.Duration(time.Minute)
+	healthcheckChanged := previous
+	healthcheckChanged.HealthCheck = &types.HealthCheckConfig{Test: types.HealthCheckTest{"CMD", "true"}, Interval: &interval}
+	configChanged := healthcheckChanged
+	configChanged.Environment = types.NewMappingWithEquals([]string{"FOO=bar"})
+
+	tests := []struct {
+		name              string
+		service           types.ServiceConfig
+		ignoreHealthcheck bool
+		legacy            bool
+		want              []api.Drift
+	}{
+		{name: "unchanged", service: previous},
+		{
+			name:    "healthcheck changed",
+			service: healthcheckChanged,
+			want:    []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: healthcheck changed"}},
+		},
+		{name: "healthcheck changed, ignored", service: healthcheckChanged, ignoreHealthcheck: true},
+		{
+			name:              "healthcheck and config changed, healthcheck ignored",
+			service:           configChanged,
+			ignoreHealthcheck: true,
+			want:              []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: config hash diverged"}},
+		},
+		{
+			name:              "container without hash excluding healthcheck",
+			service:           healthcheckChanged,
+			ignoreHealthcheck: true,
+			legacy:            true,
+			want:              []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: config hash diverged"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": tt.service}}
+			hash := mustServiceHash(t, previous)
+			labels := map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash}
+			if !tt.legacy {
+				excluded, err := serviceHashExcludingHealthcheck(previous)
+				assert.NilError(t, err)
+				labels[api.HealthcheckExcludedHashLabel] = excluded
+			}
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning,
+						ConfigHash: hash, Labels: labels,
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+			}
+			options := defaultReconcileOptions()
+			options.IgnoreHealthcheck = tt.ignoreHealthcheck
+
+			r := newReconciler(project, observed, options, noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, r.drifts, tt.want)
+			assert.Equal(t, len(plan.Nodes) > 0, tt.want != nil)
+		})
+	}
+}
+
+func TestReconcileContainers_MaxAge(t *testing.T) {
+	now := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		maxAge  time.Duration
+		update  map[string]any
+		created time.Time
+		want    []api.Drift
+	}{
+		{name: "disabled by default", created: now.Add(-365 * 24 * time.Hour)},
+		{name: "younger", maxAge: 24 * time.Hour, created: now.Add(-time.Hour)},
+		{
+			name:    "older",
+			maxAge:  24 * time.Hour,
+			created: now.Add(-25 * time.Hour),
+			want:    []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: max age exceeded"}},
+		},
+		{
+			name:    "service override",
+			update:  map[string]any{"max_age": "1h"},
+			created: now.Add(-2 * time.Hour),
+			want:    []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: max age exceeded"}},
+		},
+		{name: "disabled for service", maxAge: time.Hour, update: map[string]any{"max_age": "0"}, created: now.Add(-2 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+			if tt.update != nil {
+				service.Extensions = types.Extensions{"x-update": tt.update}
+			}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+			hash := mustServiceHash(t, service)
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateRunning,
+						ConfigHash: hash, Created: tt.created.Unix(),
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+					}},
+				},
+				Networks:   map[string]ObservedNetwork{},
+				Volumes:    map[string]ObservedVolume{},
+				ObservedAt: now,
+			}
+			options := defaultReconcileOptions()
+			options.MaxAge = tt.maxAge
+
+			r := newReconciler(project, observed, options, noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, r.drifts, tt.want)
+			assert.Equal(t, len(plan.Nodes) > 0, tt.want != nil)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		project := &types.Project{
+			Name: "myproject",
+			Services: types.Services{
+				"web": {Name: "web", Scale: intPtr(1), Extensions: types.Extensions{"x-update": map[string]any{"max_age": "-1h"}}},
+			},
+		}
+		_, err := reconcile(t.Context(), project, emptyObservedState("myproject"), defaultReconcileOptions(), noPrompt)
+		assert.ErrorContains(t, err, `service "web": invalid x-update.max_age "-1h": must be a positive duration`)
+	})
+}
+
+func TestCompareContainer(t *testing.T) {
+	now := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		update      map[string]any
+		digest      string
+		noDigest    bool
+		imageID     string
+		state       container.ContainerState
+		diverged    bool
+		maxAge      time.Duration
+		wantReason  string
+		wantMatched []string
+	}{
+		{
+			name:        "running",
+			state:       container.StateRunning,
+			wantMatched: []string{matchConfigHash, matchImage, matchNetworks, matchVolumes},
+		},
+		{
+			name:        "exited",
+			state:       container.StateExited,
+			wantMatched: []string{matchConfigHash, matchImage, matchVolumes},
+		},
+		{
+			name:        "config changes ignored",
+			update:      map[string]any{"ignore_config_changes": true},
+			state:       container.StateRunning,
+			diverged:    true,
+			wantMatched: []string{matchConfigChangesIgnored, matchImage, matchNetworks, matchVolumes},
+		},
+		{
+			name:        "within max age",
+			state:       container.StateRunning,
+			maxAge:      24 * time.Hour,
+			wantMatched: []string{matchConfigHash, matchImage, matchNetworks, matchVolumes, matchMaxAge},
+		},
+		{
+			name:       "config changed",
+			state:      container.StateRunning,
+			diverged:   true,
+			wantReason: recreateReasonConfigChanged,
+		},
+		{
+			name:        "image changed",
+			digest:      "sha256:new",
+			state:       container.StateRunning,
+			wantReason:  recreateReasonImageChanged,
+			wantMatched: []string{matchConfigHash},
+		},
+		{
+			name:        "image ID equal",
+			noDigest:    true,
+			imageID:     "sha256:local",
+			state:       container.StateRunning,
+			wantMatched: []string{matchConfigHash, matchImageID, matchNetworks, matchVolumes},
+		},
+		{
+			name:        "image unknown",
+			noDigest:    true,
+			state:       container.StateRunning,
+			wantMatched: []string{matchConfigHash, matchNetworks, matchVolumes},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+			if tt.update != nil {
+				service.Extensions = types.Extensions{"x-update": tt.update}
+			}
+			digest := "sha256:old"
+			if tt.digest != "" {
+				digest = tt.digest
+			}
+			if !tt.noDigest {
+				service.CustomLabels = types.Labels{api.ImageDigestLabel: digest}
+			}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+			hash := mustServiceHash(t, service)
+			oc := ObservedContainer{
+				ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: tt.state,
+				ConfigHash: hash, ImageDigest: "sha256:old", Created: now.Add(-time.Hour).Unix(),
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+			}
+			if tt.diverged {
+				oc.ConfigHash = "outdated"
+			}
+			if tt.noDigest {
+				oc.ImageDigest = ""
+			}
+			oc.ImageID = tt.imageID
+			observed := emptyObservedState("myproject")
+			observed.Containers["web"] = []ObservedContainer{oc}
+			if tt.imageID != "" {
+				observed.ImageIDs = map[string]string{"nginx": tt.imageID}
+			}
+			observed.ObservedAt = now
+			options := defaultReconcileOptions()
+			options.MaxAge = tt.maxAge
+
+			r := newReconciler(project, observed, options, noPrompt)
+			reason, matched := r.compareContainer(service, hash, "", oc, api.RecreateDiverged)
+			assert.Equal(t, reason, tt.wantReason)
+			assert.DeepEqual(t, matched, tt.wantMatched)
+		})
+	}
+}
+
+func TestReconcileContainers_StuckRestarting(t *testing.T) {
+	now := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	stuck := []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: stuck restarting"}}
+	tests := []struct {
+		name         string
+		threshold    time.Duration
+		state        container.ContainerState
+		restartCount int
+		finished     time.Time
+		want         []api.Drift
+	}{
+		{name: "disabled by default", state: container.StateRestarting, restartCount: 12, finished: now.Add(-2 * time.Minute)},
+		// 12 restarts backed off for more than 3 minutes
+		{name: "restarting for longer", threshold: 5 * time.Minute, state: container.StateRestarting, restartCount: 12, finished: now.Add(-2 * time.Minute), want: stuck},
+		{name: "restarting for shorter", threshold: 5 * time.Minute, state: container.StateRestarting, restartCount: 12, finished: now.Add(-time.Second)},
+		// created long ago, the container only started failing recently
+		{name: "recently failing", threshold: 5 * time.Minute, state: container.StateRestarting, restartCount: 2, finished: now.Add(-time.Minute)},
+		{name: "not restarted yet", threshold: 5 * time.Minute, state: container.StateRestarting, finished: now.Add(-time.Hour)},
+		{name: "running", threshold: 5 * time.Minute, state: container.StateRunning, restartCount: 12, finished: now.Add(-time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1)}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+			hash := mustServiceHash(t, service)
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: tt.state,
+						ConfigHash: hash, Created: now.Add(-24 * time.Hour).Unix(), RestartCount: tt.restartCount, FinishedAt: tt.finished,
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+					}},
+				},
+				Networks:   map[string]ObservedNetwork{},
+				Volumes:    map[string]ObservedVolume{},
+				ObservedAt: now,
+			}
+			options := defaultReconcileOptions()
+			options.RecreateRestarting = tt.threshold
+
+			r := newReconciler(project, observed, options, noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, r.drifts, tt.want)
+			assert.Equal(t, len(plan.Nodes) > 0, tt.want != nil)
+		})
+	}
+}
+
+func TestReconcileContainers_RestartExhausted(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		exitCode int
+		restarts int
+		want     string
+		drifts   []api.Drift
+	}{
+		{
+			name:     "restart by default",
+			exitCode: 1,
+			restarts: 3,
+			want:     "[] -> #1 service:web:1, StartContainer, restart retries exhausted",
+		},
+		{
+			name:     "restart",
+			policy:   api.RestartExhaustedRestart,
+			exitCode: 1,
+			restarts: 3,
+			want:     "[] -> #1 service:web:1, StartContainer, restart retries exhausted",
+		},
+		{
+			name:     "recreate",
+			policy:   api.RestartExhaustedRecreate,
+			exitCode: 1,
+			restarts: 3,
+			want: `[] -> #1 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[1] -> #2 service:web:1, StopContainer, replaced by #1 [recreate:web:1]
+[2] -> #3 service:web:1, RemoveContainer, replaced by #1 [recreate:web:1]
+[3] -> #4 service:web:1, RenameContainer, finalize recreate [recreate:web:1]`,
+			drifts: []api.Drift{{Service: "web", Kind: api.DriftRecreate, Detail: "myproject-web-1: restart retries exhausted"}},
+		},
+		{
+			name:     "ignore",
+			policy:   api.RestartExhaustedIgnore,
+			exitCode: 1,
+			restarts: 3,
+		},
+		{
+			name:     "retries left",
+			policy:   api.RestartExhaustedRestart,
+			exitCode: 1,
+			restarts: 2,
+		},
+		{
+			name:     "exited successfully",
+			policy:   api.RestartExhaustedRestart,
+			restarts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(1), Restart: "on-failure:3"}
+			project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+			hash := mustServiceHash(t, service)
+			observed := &ObservedState{
+				ProjectName: "myproject",
+				Containers: map[string][]ObservedContainer{
+					"web": {{
+						ID: "c1aabbccddee", Name: "myproject-web-1", Number: 1, State: container.StateExited,
+						ConfigHash: hash, ExitCode: tt.exitCode, RestartCount: tt.restarts, MaxRestartRetries: 3,
+						Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+					}},
+				},
+				Networks: map[string]ObservedNetwork{},
+				Volumes:  map[string]ObservedVolume{},
+			}
+			options := defaultReconcileOptions()
+			options.RestartExhausted = tt.policy
+
+			r := newReconciler(project, observed, options, noPrompt)
+			plan, err := r.build()
+			assert.NilError(t, err)
+			assert.Equal(t, strings.TrimSpace(plan.String()), tt.want)
+			assert.DeepEqual(t, r.drifts, tt.drifts)
+		})
+	}
+}
+
+func TestReconcileContainers_DuplicateNumbers(t *testing.T) {
+	service := types.ServiceConfig{Name: "web", Image: "nginx", Scale: intPtr(2)}
+	project := &types.Project{Name: "myproject", Services: types.Services{"web": service}}
+	hash := mustServiceHash(t, service)
+	observed := func() *ObservedState {
+		ctr := func(id, name string, number int, created int64) ObservedContainer {
+			return ObservedContainer{
+				ID: id, Name: name, Number: number, State: container.StateRunning, ConfigHash: hash, Created: created,
+				Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: strconv.Itoa(number), api.ConfigHashLabel: hash},
+			}
+		}
+		return &ObservedState{
+			ProjectName: "myproject",
+			Containers: map[string][]ObservedContainer{
+				"web": {
+					ctr("c1aabbccddee", "myproject-web-1", 1, 100),
+					ctr("c2aabbccddee", "myproject-web-1-copy", 1, 200),
+					ctr("c3aabbccddee", "myproject-web-2", 2, 100),
+				},
+			},
+			Networks: map[string]ObservedNetwork{},
+			Volumes:  map[string]ObservedVolume{},
+		}
+	}
+
+	t.Run("keep newest", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		plan, err := reconcile(t.Context(), project, observed(), defaultReconcileOptions(), noPrompt)
+		assert.NilError(t, err)
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:1, StopContainer, duplicate container number
+[1] -> #2 service:web:1, RemoveContainer, duplicate container number
+`)+"\n")
+		assert.Equal(t, hook.LastEntry().Message, `service "web" has 2 containers numbered 1: myproject-web-1-copy, myproject-web-1. Keeping the newest one, myproject-web-1-copy`)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		options := defaultReconcileOptions()
+		options.DuplicateNumbers = api.DuplicateNumbersError
+		_, err := reconcile(t.Context(), project, observed(), options, noPrompt)
+		assert.Error(t, err, `service "web" has 2 containers numbered 1: myproject-web-1-copy, myproject-web-1. Remove all but one of them, or run with --duplicate-numbers=keep-newest`)
+	})
+}
+
 func mustServiceHash(t *testing.T, svc types.ServiceConfig) string {
 	t.Helper()
 	h, err := ServiceHash(svc)
@@ -1451,3 +2704,70 @@ func mustResolvedServiceHash(t *testing.T, svc types.ServiceConfig, containers m
 	assert.NilError(t, err)
 	return h
 }
+
+func TestReconcileContainers_ScaleDownReferenced(t *testing.T) {
+	proxy := types.ServiceConfig{Name: "proxy", Image: "nginx", Scale: intPtr(2)}
+	app := types.ServiceConfig{
+		Name: "app", Image: "alpine", Scale: intPtr(1), NetworkMode: "service:proxy",
+		DependsOn: types.DependsOnConfig{"proxy": {Condition: types.ServiceConditionStarted, Restart: true, Required: true}},
+	}
+	project := &types.Project{
+		Name:     "myproject",
+		Services: types.Services{"proxy": proxy, "app": app},
+	}
+	observed := func(t *testing.T) *ObservedState {
+		hash := mustServiceHash(t, proxy)
+		state := &ObservedState{
+			ProjectName: "myproject",
+			Containers:  map[string][]ObservedContainer{},
+			Networks:    map[string]ObservedNetwork{},
+			Volumes:     map[string]ObservedVolume{},
+		}
+		for number := 1; number <= 3; number++ {
+			n := strconv.Itoa(number)
+			state.Containers["proxy"] = append(state.Containers["proxy"], ObservedContainer{
+				ID: "proxy" + n, Name: "myproject-proxy-" + n, Number: number, State: container.StateRunning, ConfigHash: hash,
+				Labels: map[string]string{api.ServiceLabel: "proxy", api.ContainerNumberLabel: n, api.ConfigHashLabel: hash},
+			})
+		}
+		state.Containers["app"] = []ObservedContainer{{
+			ID: "app1", Name: "myproject-app-1", Number: 1, State: container.StateRunning,
+			Labels: map[string]string{api.ServiceLabel: "app", api.ContainerNumberLabel: "1"},
+			// app was created while proxy replica 3 was the one resolved
+			References: []string{"proxy3"},
+		}}
+		appHash, err := serviceHashWithResolvedRefs(app, state.containersByService())
+		assert.NilError(t, err)
+		state.Containers["app"][0].ConfigHash = appHash
+		state.Containers["app"][0].Labels[api.ConfigHashLabel] = appHash
+		return state
+	}
+
+	tests := []struct {
+		mode     string
+		removed  string
+		err      string
+		recreate bool
+	}{
+		{mode: api.ScaleDownReferencedWarn, removed: "service:proxy:3"},
+		{mode: api.ScaleDownReferencedReselect, removed: "service:proxy:2"},
+		{mode: api.ScaleDownReferencedError, err: `service "proxy": scale down would remove container myproject-proxy-3 which myproject-app-1 depend on`},
+		{mode: api.ScaleDownReferencedRecreate, removed: "service:proxy:3", recreate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			options := defaultReconcileOptions()
+			options.ScaleDownReferenced = tt.mode
+			plan, err := reconcile(t.Context(), project, observed(t), options, noPrompt)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			planStr := plan.String()
+			assert.Equal(t, strings.Count(planStr, "RemoveContainer, scale down"), 1, planStr)
+			assert.Assert(t, strings.Contains(planStr, tt.removed+", RemoveContainer, scale down"), planStr)
+			assert.Equal(t, strings.Contains(planStr, "service:app:1, CreateContainer"), tt.recreate, planStr)
+		})
+	}
+}
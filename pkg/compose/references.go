@@ -0,0 +1,100 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/client"
+)
+
+// checkReferences checks the secrets, configs and external volumes the
+// services reference exist, so a misconfigured project fails before any
+// container is created, with all the missing ones, rather than when the first
+// container using one is created or started
+func (s *composeService) checkReferences(ctx context.Context, project *types.Project, services []string) error {
+	var errs []error
+	var volumes []string
+	for _, name := range services {
+		service, ok := project.Services[name]
+		if !ok {
+			continue
+		}
+		for _, secret := range service.Secrets {
+			if err := checkFileObject(project, types.FileObjectConfig(project.Secrets[secret.Source]), secretMount, secret.Source); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: %w", name, err))
+			}
+		}
+		for _, config := range service.Configs {
+			if err := checkFileObject(project, types.FileObjectConfig(project.Configs[config.Source]), configMount, config.Source); err != nil {
+				errs = append(errs, fmt.Errorf("service %q: %w", name, err))
+			}
+		}
+		for _, volume := range service.Volumes {
+			if volume.Type == types.VolumeTypeVolume && bool(project.Volumes[volume.Source].External) && !slices.Contains(volumes, volume.Source) {
+				volumes = append(volumes, volume.Source)
+			}
+		}
+	}
+	slices.Sort(volumes)
+	for _, key := range volumes {
+		volume := project.Volumes[key]
+		if _, err := s.apiClient().VolumeInspect(ctx, volume.Name, client.VolumeInspectOptions{}); err != nil {
+			if !errdefs.IsNotFound(err) {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("external volume %q not found", volume.Name))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("resources referenced by the services are missing:\n%w", errors.Join(errs...))
+}
+
+// checkFileObject checks the source of a secret or config exists
+func checkFileObject(project *types.Project, source types.FileObjectConfig, mountType mountType, name string) error {
+	switch {
+	case bool(source.External):
+		return fmt.Errorf("unsupported external %s %q", mountType, name)
+	case source.Content != "":
+		return nil
+	case source.Environment != "":
+		if _, ok := project.Environment[source.Environment]; !ok {
+			return fmt.Errorf("environment variable %q required by %s %q is not set", source.Environment, mountType, name)
+		}
+		return nil
+	case source.File != "":
+		_, err := os.Stat(source.File)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("file %s of %s %q doesn't exist", source.File, mountType, name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %q: %w", mountType, name, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s %q isn't defined", mountType, name)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckReferences(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	present := filepath.Join(t.TempDir(), "present.txt")
+	assert.NilError(t, os.WriteFile(present, []byte("secret"), 0o600))
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+
+	project := &types.Project{
+		Name:        "test",
+		Environment: types.Mapping{"TOKEN": "s3cr3t"},
+		Secrets: types.Secrets{
+			"present": {Name: "present", File: present},
+			"missing": {Name: "missing", File: missing},
+			"token":   {Name: "token", Environment: "TOKEN"},
+			"unset":   {Name: "unset", Environment: "API_KEY"},
+		},
+		Configs: types.Configs{
+			"inline": {Name: "inline", Content: "key=value"},
+			"absent": {Name: "absent", File: missing},
+		},
+		Volumes: types.Volumes{
+			"data":  {Name: "data", External: true},
+			"cache": {Name: "shared_cache", External: true},
+			"logs":  {Name: "test_logs"},
+		},
+		Services: types.Services{
+			"web": {
+				Name:    "web",
+				Secrets: []types.ServiceSecretConfig{{Source: "present"}, {Source: "token"}, {Source: "missing"}},
+				Configs: []types.ServiceConfigObjConfig{{Source: "inline"}, {Source: "absent"}},
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"},
+					{Type: types.VolumeTypeVolume, Source: "logs", Target: "/logs"},
+				},
+			},
+			"worker": {
+				Name:    "worker",
+				Secrets: []types.ServiceSecretConfig{{Source: "unset"}},
+				Volumes: []types.ServiceVolumeConfig{
+					{Type: types.VolumeTypeVolume, Source: "data", Target: "/data"},
+					{Type: types.VolumeTypeVolume, Source: "cache", Target: "/cache"},
+				},
+			},
+		},
+	}
+
+	apiClient.EXPECT().VolumeInspect(gomock.Any(), "data", gomock.Any()).Return(client.VolumeInspectResult{}, nil).Times(2)
+	apiClient.EXPECT().VolumeInspect(gomock.Any(), "shared_cache", gomock.Any()).Return(client.VolumeInspectResult{}, errdefs.ErrNotFound)
+
+	err := svc.checkReferences(t.Context(), project, []string{"web", "worker"})
+	assert.Error(t, err, `resources referenced by the services are missing:
+service "web": file `+missing+` of secret "missing" doesn't exist
+service "web": file `+missing+` of config "absent" doesn't exist
+service "worker": environment variable "API_KEY" required by secret "unset" is not set
+external volume "shared_cache" not found`)
+
+	// only the selected services are checked
+	project.Services["web"].Secrets[2].Source = "present"
+	err = svc.checkReferences(t.Context(), project, []string{"web"})
+	assert.Error(t, err, `resources referenced by the services are missing:
+service "web": file `+missing+` of config "absent" doesn't exist`)
+}
@@ -28,6 +28,7 @@ import (
 )
 
 func (s *composeService) Remove(ctx context.Context, projectName string, options api.RemoveOptions) error { //nolint:gocyclo
+	defer s.clearObservedState(ctx, projectName)
 	projectName = strings.ToLower(projectName)
 
 	if options.Stop {
@@ -52,6 +53,9 @@ func (s *composeService) Remove(ctx context.Context, projectName string, options
 	if options.Project != nil {
 		containers = containers.filter(isService(options.Project.ServiceNames()...))
 	}
+	if options.StaleOnly {
+		containers = containers.filter(isStaleRecreate)
+	}
 
 	var stoppedContainers Containers
 	for _, ctr := range containers {
@@ -69,16 +73,11 @@ func (s *composeService) Remove(ctx context.Context, projectName string, options
 		}
 	}
 
-	var names []string
-	for _, c := range stoppedContainers {
-		names = append(names, getCanonicalContainerName(c))
-	}
-
-	if len(names) == 0 {
+	if len(stoppedContainers) == 0 {
 		return api.ErrNoResources
 	}
 
-	msg := fmt.Sprintf("Going to remove %s", strings.Join(names, ", "))
+	msg := removeMessage(stoppedContainers)
 	if options.Force {
 		_, _ = fmt.Fprintln(s.stdout(), msg)
 	} else {
@@ -92,7 +91,28 @@ func (s *composeService) Remove(ctx context.Context, projectName string, options
 	}
 	return Run(ctx, func(ctx context.Context) error {
 		return s.remove(ctx, stoppedContainers, options)
-	}, "remove", s.events)
+	}, "remove", s.events(ctx))
+}
+
+// removeMessage lists the containers to be removed, the leftovers of
+// interrupted recreates apart from the service containers
+func removeMessage(containers Containers) string {
+	var names, stale []string
+	for _, c := range containers {
+		if isStaleRecreate(c) {
+			stale = append(stale, getCanonicalContainerName(c))
+		} else {
+			names = append(names, getCanonicalContainerName(c))
+		}
+	}
+	var parts []string
+	if len(names) > 0 {
+		parts = append(parts, strings.Join(names, ", "))
+	}
+	if len(stale) > 0 {
+		parts = append(parts, fmt.Sprintf("stale recreate leftovers %s", strings.Join(stale, ", ")))
+	}
+	return fmt.Sprintf("Going to remove %s", strings.Join(parts, " and "))
 }
 
 func (s *composeService) remove(ctx context.Context, containers Containers, options api.RemoveOptions) error {
@@ -100,13 +120,16 @@ func (s *composeService) remove(ctx context.Context, containers Containers, opti
 	for _, ctr := range containers {
 		eg.Go(func() error {
 			eventName := getContainerProgressName(ctr)
-			s.events.On(removingEvent(eventName))
-			_, err := s.apiClient().ContainerRemove(ctx, ctr.ID, client.ContainerRemoveOptions{
-				RemoveVolumes: options.Volumes,
-				Force:         options.Force,
+			s.events(ctx).On(removingEvent(eventName))
+			err := s.withSlot(ctx, func(ctx context.Context) error {
+				_, err := s.apiClient().ContainerRemove(ctx, ctr.ID, client.ContainerRemoveOptions{
+					RemoveVolumes: options.Volumes,
+					Force:         options.Force,
+				})
+				return err
 			})
 			if err == nil {
-				s.events.On(removedEvent(eventName))
+				s.events(ctx).On(removedEvent(eventName))
 			}
 			return err
 		})
@@ -0,0 +1,90 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	compose "github.com/docker/compose/v5/pkg/api"
+)
+
+// staleContainer returns a container left under its temporary name by an
+// interrupted recreate, labeled with it unless unlabeled
+func staleContainer(service string, id string, name string, labeled bool) container.Summary {
+	ctr := testContainer(service, id, false)
+	ctr.Names = []string{"/" + name}
+	if labeled {
+		ctr.Labels[compose.TemporaryNameLabel] = name
+	}
+	return ctr
+}
+
+func TestIsStaleRecreate(t *testing.T) {
+	renamed := testContainer("service1", "123", false)
+	renamed.Labels[compose.TemporaryNameLabel] = "0123456789ab_myproject-service1-1"
+
+	assert.Check(t, isStaleRecreate(staleContainer("service1", "456", "0123456789ab_myproject-service1-1", true)))
+	assert.Check(t, isStaleRecreate(staleContainer("service1", "789", "0123456789ab_myproject-service1-1", false)))
+	assert.Check(t, !isStaleRecreate(renamed))
+	assert.Check(t, !isStaleRecreate(testContainer("service1", "321", false)))
+}
+
+func TestRemoveStaleRecreate(t *testing.T) {
+	for _, staleOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stale-only=%t", staleOnly), func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			apiClient, cli := prepareMocks(mockCtrl)
+			stdout := &bytes.Buffer{}
+			tested, err := NewComposeService(cli, WithOutputStream(stdout))
+			assert.NilError(t, err)
+
+			apiClient.EXPECT().ContainerList(gomock.Any(), projectFilterListOpt(false)).Return(
+				client.ContainerListResult{Items: []container.Summary{
+					testContainer("service1", "123", false),
+					staleContainer("service1", "456", "0123456789ab_myproject-service1-1", true),
+					staleContainer("service2", "789", "ba9876543210_myproject-service2-1", false),
+				}}, nil)
+			for _, id := range []string{"123", "456", "789"} {
+				apiClient.EXPECT().ContainerInspect(gomock.Any(), id, gomock.Any()).Return(client.ContainerInspectResult{
+					Container: container.InspectResponse{ID: id, State: &container.State{Status: container.StateExited}},
+				}, nil).AnyTimes()
+			}
+			if !staleOnly {
+				apiClient.EXPECT().ContainerRemove(gomock.Any(), "123", client.ContainerRemoveOptions{Force: true}).Return(client.ContainerRemoveResult{}, nil)
+			}
+			apiClient.EXPECT().ContainerRemove(gomock.Any(), "456", client.ContainerRemoveOptions{Force: true}).Return(client.ContainerRemoveResult{}, nil)
+			apiClient.EXPECT().ContainerRemove(gomock.Any(), "789", client.ContainerRemoveOptions{Force: true}).Return(client.ContainerRemoveResult{}, nil)
+
+			err = tested.Remove(t.Context(), strings.ToLower(testProject), compose.RemoveOptions{Force: true, StaleOnly: staleOnly})
+			assert.NilError(t, err)
+
+			expected := "Going to remove stale recreate leftovers 0123456789ab_myproject-service1-1, ba9876543210_myproject-service2-1\n"
+			if !staleOnly {
+				expected = "Going to remove 123 and stale recreate leftovers 0123456789ab_myproject-service1-1, ba9876543210_myproject-service2-1\n"
+			}
+			assert.Equal(t, stdout.String(), expected)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/go-units"
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// HostResources holds the CPUs and memory of the host, as reported by the engine
+type HostResources struct {
+	NCPU     int
+	MemTotal int64
+}
+
+// collectHostResources queries the engine for the resources of the host, for
+// the reconciler to check the project reservations against them
+func (s *composeService) collectHostResources(ctx context.Context, state *ObservedState) error {
+	res, err := s.apiClient().Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return err
+	}
+	state.Host = &HostResources{
+		NCPU:     res.Info.NCPU,
+		MemTotal: res.Info.MemTotal,
+	}
+	return nil
+}
+
+// preflightResources checks, before containers are created to scale service
+// up, that the host can accommodate the resources reserved by the containers
+// of the project once converged. The check runs once per plan, as it covers
+// all the services.
+func (r *reconciler) preflightResources(service types.ServiceConfig) error {
+	if r.options.ResourcePreflight == "" || r.observed.Host == nil || r.preflighted {
+		return nil
+	}
+	r.preflighted = true
+
+	var (
+		cpus         float64
+		memory       int64
+		cpuDetail    []string
+		memoryDetail []string
+	)
+	for _, name := range r.project.ServiceNames() {
+		s := r.project.Services[name]
+		if s.Deploy == nil || s.Deploy.Resources.Reservations == nil {
+			continue
+		}
+		scale := s.GetScale()
+		if scale <= 0 {
+			continue
+		}
+		reservations := s.Deploy.Resources.Reservations
+		if reservations.NanoCPUs > 0 {
+			cpus += float64(reservations.NanoCPUs) * float64(scale)
+			cpuDetail = append(cpuDetail, fmt.Sprintf("%s: %d x %s", name, scale, formatCPUs(float64(reservations.NanoCPUs))))
+		}
+		if reservations.MemoryBytes > 0 {
+			memory += int64(reservations.MemoryBytes) * int64(scale)
+			memoryDetail = append(memoryDetail, fmt.Sprintf("%s: %d x %s", name, scale, units.BytesSize(float64(reservations.MemoryBytes))))
+		}
+	}
+
+	host := r.observed.Host
+	var shortfalls []string
+	if host.NCPU > 0 && cpus > float64(host.NCPU) {
+		shortfalls = append(shortfalls, fmt.Sprintf("%s CPUs reserved (%s) but the host has %d, %s short",
+			formatCPUs(cpus), strings.Join(cpuDetail, ", "), host.NCPU, formatCPUs(cpus-float64(host.NCPU))))
+	}
+	if host.MemTotal > 0 && memory > host.MemTotal {
+		shortfalls = append(shortfalls, fmt.Sprintf("%s of memory reserved (%s) but the host has %s, %s short",
+			units.BytesSize(float64(memory)), strings.Join(memoryDetail, ", "),
+			units.BytesSize(float64(host.MemTotal)), units.BytesSize(float64(memory-host.MemTotal))))
+	}
+	if len(shortfalls) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("scaling up service %q, the host can't accommodate the resources reserved by the project: %s. Lower the reservations or the scale of the services",
+		service.Name, strings.Join(shortfalls, "; "))
+	if r.options.ResourcePreflight == api.ResourcePreflightError {
+		return errors.New(msg)
+	}
+	logrus.Warn(msg)
+	return nil
+}
+
+// formatCPUs formats a number of CPUs without trailing zeros
+func formatCPUs(cpus float64) string {
+	return strconv.FormatFloat(cpus, 'f', -1, 32)
+}
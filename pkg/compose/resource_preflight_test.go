@@ -0,0 +1,120 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestPreflightResources(t *testing.T) {
+	const gib = 1 << 30
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {
+				Name:  "web",
+				Image: "nginx",
+				Scale: intPtr(4),
+				Deploy: &types.DeployConfig{Resources: types.Resources{
+					Reservations: &types.Resource{NanoCPUs: 1.5, MemoryBytes: 2 * gib},
+				}},
+			},
+			"db": {
+				Name:  "db",
+				Image: "postgres",
+				Deploy: &types.DeployConfig{Resources: types.Resources{
+					Reservations: &types.Resource{MemoryBytes: 4 * gib},
+				}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		preflight string
+		host      *HostResources
+		err       string
+	}{
+		{
+			name:      "disabled",
+			preflight: "",
+			host:      &HostResources{NCPU: 2, MemTotal: 8 * gib},
+		},
+		{
+			name:      "host accommodates reservations",
+			preflight: api.ResourcePreflightError,
+			host:      &HostResources{NCPU: 8, MemTotal: 16 * gib},
+		},
+		{
+			name:      "warn on shortfall",
+			preflight: api.ResourcePreflightWarn,
+			host:      &HostResources{NCPU: 2, MemTotal: 8 * gib},
+		},
+		{
+			name:      "error on shortfall",
+			preflight: api.ResourcePreflightError,
+			host:      &HostResources{NCPU: 4, MemTotal: 8 * gib},
+			err: `scaling up service "db", the host can't accommodate the resources reserved by the project: ` +
+				`6 CPUs reserved (web: 4 x 1.5) but the host has 4, 2 short; ` +
+				`12GiB of memory reserved (db: 1 x 4GiB, web: 4 x 2GiB) but the host has 8GiB, 4GiB short. ` +
+				`Lower the reservations or the scale of the services`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observed := emptyObservedState("myproject")
+			observed.Host = tt.host
+			options := defaultReconcileOptions()
+			options.ResourcePreflight = tt.preflight
+
+			_, err := newReconciler(project, observed, options, noPrompt).build()
+			if tt.err != "" {
+				assert.Error(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestPreflightResourcesOnlyOnScaleUp(t *testing.T) {
+	project := &types.Project{
+		Name: "myproject",
+		Services: types.Services{
+			"web": {
+				Name:  "web",
+				Image: "nginx",
+				Scale: intPtr(0),
+				Deploy: &types.DeployConfig{Resources: types.Resources{
+					Reservations: &types.Resource{NanoCPUs: 64},
+				}},
+			},
+		},
+	}
+	observed := emptyObservedState("myproject")
+	observed.Host = &HostResources{NCPU: 2}
+	options := defaultReconcileOptions()
+	options.ResourcePreflight = api.ResourcePreflightError
+
+	_, err := newReconciler(project, observed, options, noPrompt).build()
+	assert.NilError(t, err)
+}
@@ -29,9 +29,10 @@ import (
 )
 
 func (s *composeService) Restart(ctx context.Context, projectName string, options api.RestartOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.restart(ctx, strings.ToLower(projectName), options)
-	}, "restart", s.events)
+	}, "restart", s.events(ctx))
 }
 
 func (s *composeService) restart(ctx context.Context, projectName string, options api.RestartOptions) error { //nolint:gocyclo
@@ -93,14 +94,17 @@ func (s *composeService) restart(ctx context.Context, projectName string, option
 					}
 				}
 				eventName := getContainerProgressName(ctr)
-				s.events.On(newEvent(eventName, api.Working, api.StatusRestarting))
-				_, err = s.apiClient().ContainerRestart(ctx, ctr.ID, client.ContainerRestartOptions{
-					Timeout: utils.DurationSecondToInt(options.Timeout),
+				s.events(ctx).On(newEvent(eventName, api.Working, api.StatusRestarting))
+				err = s.withSlot(ctx, func(ctx context.Context) error {
+					_, err := s.apiClient().ContainerRestart(ctx, ctr.ID, client.ContainerRestartOptions{
+						Timeout: utils.DurationSecondToInt(options.Timeout),
+					})
+					return err
 				})
 				if err != nil {
 					return err
 				}
-				s.events.On(newEvent(eventName, api.Done, api.StatusStarted))
+				s.events(ctx).On(newEvent(eventName, api.Done, api.StatusStarted))
 				for _, hook := range def.PostStart {
 					err = s.runHook(ctx, ctr, def, hook, nil)
 					if err != nil {
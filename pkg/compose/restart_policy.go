@@ -0,0 +1,142 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// suspendedRestarts records the containers whose restart policy is disabled
+// while a convergence run keeps them stopped, with their service
+type suspendedRestarts struct {
+	mu         sync.Mutex
+	containers map[string]string
+}
+
+type suspendedRestartsKey struct{}
+
+// withSuspendedRestarts returns a context for a convergence run to record the
+// containers it suspends the restart policy of, to be restored by the run
+func withSuspendedRestarts(ctx context.Context) (context.Context, *suspendedRestarts) {
+	restarts := &suspendedRestarts{}
+	return context.WithValue(ctx, suspendedRestartsKey{}, restarts), restarts
+}
+
+// suspendedRestartsOf returns the containers suspended by the convergence run,
+// nil outside of one
+func suspendedRestartsOf(ctx context.Context) *suspendedRestarts {
+	restarts, _ := ctx.Value(suspendedRestartsKey{}).(*suspendedRestarts)
+	return restarts
+}
+
+func (r *suspendedRestarts) add(id, service string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.containers == nil {
+		r.containers = map[string]string{}
+	}
+	r.containers[id] = service
+}
+
+// take removes the container, reporting whether it was suspended
+func (r *suspendedRestarts) take(id string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.containers[id]
+	delete(r.containers, id)
+	return ok
+}
+
+// drain removes all the containers, returned by ID with their service
+func (r *suspendedRestarts) drain() map[string]string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	containers := r.containers
+	r.containers = nil
+	return containers
+}
+
+// restartsAutomatically reports whether the engine restarts the containers of
+// the service by itself, as with the always, unless-stopped and on-failure
+// restart policies
+func restartsAutomatically(service types.ServiceConfig) bool {
+	policy := getRestartPolicy(service)
+	return policy.Name != "" && policy.Name != container.RestartPolicyDisabled
+}
+
+// suspendRestartPolicy disables the restart policy of a container about to be
+// stopped, so the engine doesn't start it again, e.g. as it restarts, before
+// compose does. It is best effort, the container is stopped regardless. Outside
+// of a convergence run, which would restore it, the policy is left as is.
+func (s *composeService) suspendRestartPolicy(ctx context.Context, ctr container.Summary) {
+	restarts := suspendedRestartsOf(ctx)
+	if restarts == nil {
+		return
+	}
+	_, err := s.apiClient().ContainerUpdate(ctx, ctr.ID, client.ContainerUpdateOptions{
+		RestartPolicy: &container.RestartPolicy{Name: container.RestartPolicyDisabled},
+	})
+	if err != nil {
+		logrus.Warnf("failed to suspend the restart policy of container %s: %v", getCanonicalContainerName(ctr), err)
+		return
+	}
+	restarts.add(ctr.ID, ctr.Labels[api.ServiceLabel])
+}
+
+// restoreRestartPolicy restores the restart policy of the service on a
+// container it was suspended for by the convergence run, before it is started
+// again
+func (s *composeService) restoreRestartPolicy(ctx context.Context, service types.ServiceConfig, id string) error {
+	if !suspendedRestartsOf(ctx).take(id) {
+		return nil
+	}
+	policy := getRestartPolicy(service)
+	_, err := s.apiClient().ContainerUpdate(ctx, id, client.ContainerUpdateOptions{RestartPolicy: &policy})
+	return err
+}
+
+// restoreSuspendedRestarts restores the restart policy of the containers the
+// convergence run left stopped, e.g. as it failed before starting them again.
+// The containers removed since, being recreated, are ignored.
+func (s *composeService) restoreSuspendedRestarts(ctx context.Context, project *types.Project, restarts *suspendedRestarts) {
+	for id, name := range restarts.drain() {
+		service, err := project.GetService(name)
+		if err != nil {
+			continue
+		}
+		policy := getRestartPolicy(service)
+		_, err = s.apiClient().ContainerUpdate(ctx, id, client.ContainerUpdateOptions{RestartPolicy: &policy})
+		if err != nil && !errdefs.IsNotFound(err) {
+			logrus.Warnf("failed to restore the restart policy of container %s: %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestPlanStopDependentsSuspendsRestart(t *testing.T) {
+	for _, tc := range []struct {
+		restart string
+		suspend bool
+	}{
+		{restart: "", suspend: false},
+		{restart: types.RestartPolicyNo, suspend: false},
+		{restart: types.RestartPolicyUnlessStopped, suspend: true},
+		{restart: types.RestartPolicyAlways, suspend: true},
+		{restart: "on-failure:3", suspend: true},
+	} {
+		t.Run(tc.restart, func(t *testing.T) {
+			parent := types.ServiceConfig{Name: "parent", Image: "alpine", Scale: intPtr(1)}
+			dependent := types.ServiceConfig{
+				Name: "dependent", Image: "alpine", Scale: intPtr(1), Restart: tc.restart,
+				DependsOn: types.DependsOnConfig{"parent": {Condition: types.ServiceConditionStarted, Restart: true}},
+			}
+			project := &types.Project{
+				Name:     "myproject",
+				Services: types.Services{"parent": parent, "dependent": dependent},
+			}
+			observed := parentDependentObserved(t, parent, dependent)
+			observed.Containers["parent"][0].ConfigHash = "stale_parent_hash"
+			observed.Containers["parent"][0].Labels[api.ConfigHashLabel] = "stale_parent_hash"
+
+			plan, err := reconcile(t.Context(), project, observed, defaultReconcileOptions(), noPrompt)
+			assert.NilError(t, err)
+
+			var stops []Operation
+			for _, node := range plan.Nodes {
+				if node.Operation.Type == OpStopContainer && node.Operation.ResourceID == "service:dependent:1" {
+					stops = append(stops, node.Operation)
+				}
+			}
+			assert.Equal(t, len(stops), 1, plan.String())
+			assert.Equal(t, stops[0].SuspendRestart, tc.suspend)
+		})
+	}
+}
+
+func TestSuspendedRestartPolicyRestoredOnStart(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	service := types.ServiceConfig{Name: "web", Restart: types.RestartPolicyUnlessStopped}
+	ctr := container.Summary{
+		ID:     "c1",
+		Names:  []string{"/test-web-1"},
+		State:  container.StateExited,
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+	}
+
+	gomock.InOrder(
+		apiClient.EXPECT().ContainerUpdate(gomock.Any(), "c1", client.ContainerUpdateOptions{
+			RestartPolicy: &container.RestartPolicy{Name: container.RestartPolicyDisabled},
+		}).Return(client.ContainerUpdateResult{}, nil),
+		apiClient.EXPECT().ContainerStop(gomock.Any(), "c1", gomock.Any()).Return(client.ContainerStopResult{}, nil),
+		apiClient.EXPECT().ContainerUpdate(gomock.Any(), "c1", client.ContainerUpdateOptions{
+			RestartPolicy: &container.RestartPolicy{Name: container.RestartPolicyUnlessStopped},
+		}).Return(client.ContainerUpdateResult{}, nil),
+		apiClient.EXPECT().ContainerStart(gomock.Any(), "c1", gomock.Any()).Return(client.ContainerStartResult{}, nil),
+	)
+
+	plan := &Plan{}
+	plan.addNode(Operation{
+		Type:           OpStopContainer,
+		ResourceID:     "service:web:1",
+		Cause:          "dependency db being recreated",
+		Container:      &ctr,
+		SuspendRestart: true,
+	}, "")
+	project := &types.Project{Name: "test", Services: types.Services{"web": service}}
+	ctx, restarts := withSuspendedRestarts(t.Context())
+	assert.NilError(t, svc.executePlan(ctx, project, emptyObservedState("test"), plan))
+
+	assert.NilError(t, svc.startServiceContainer(ctx, service, ctr, nil, 0))
+	// the policy is restored once
+	svc.restoreSuspendedRestarts(t.Context(), project, restarts)
+}
+
+func TestSuspendRestartPolicyOutsideConvergenceRun(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	ctr := container.Summary{
+		ID:     "c1",
+		Names:  []string{"/test-web-1"},
+		Labels: map[string]string{api.ServiceLabel: "web", api.ContainerNumberLabel: "1"},
+	}
+	// nothing would restore the policy, so it is left as is
+	apiClient.EXPECT().ContainerUpdate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	svc.suspendRestartPolicy(t.Context(), ctr)
+}
+
+func TestRestoreSuspendedRestarts(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	project := &types.Project{Name: "test", Services: types.Services{
+		"web":    {Name: "web", Restart: "on-failure:3"},
+		"worker": {Name: "worker", Restart: types.RestartPolicyAlways},
+	}}
+	_, restarts := withSuspendedRestarts(t.Context())
+	restarts.add("c1", "web")
+	restarts.add("c2", "worker")
+	// a container of a service not in the project anymore is left as is
+	restarts.add("c3", "legacy")
+	// the containers suspended by a concurrent run are left to it
+	_, concurrent := withSuspendedRestarts(t.Context())
+	concurrent.add("c4", "web")
+
+	apiClient.EXPECT().ContainerUpdate(gomock.Any(), "c1", client.ContainerUpdateOptions{
+		RestartPolicy: &container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3},
+	}).Return(client.ContainerUpdateResult{}, nil)
+	// removed since
+	apiClient.EXPECT().ContainerUpdate(gomock.Any(), "c2", gomock.Any()).
+		Return(client.ContainerUpdateResult{}, errdefs.ErrNotFound)
+
+	svc.restoreSuspendedRestarts(t.Context(), project, restarts)
+	assert.Assert(t, !restarts.take("c1"))
+	assert.Assert(t, concurrent.take("c4"))
+}
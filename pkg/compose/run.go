@@ -126,9 +126,9 @@ func (s *composeService) prepareRun(ctx context.Context, project *types.Project,
 		return prepareRunResult{}, err
 	}
 
-	err = Run(ctx, func(ctx context.Context) error {
+	err = s.runDependencies(ctx, func(ctx context.Context) error {
 		return s.startDependencies(ctx, project, opts)
-	}, "run", s.events)
+	}, opts.QuietDeps)
 	if err != nil {
 		return prepareRunResult{}, err
 	}
@@ -174,12 +174,14 @@ func (s *composeService) prepareRun(ctx context.Context, project *types.Project,
 			return prepareRunResult{}, err
 		}
 	}
-	createOpts := createOptions{
-		AutoRemove:        opts.AutoRemove,
-		AttachStdin:       opts.Interactive,
-		UseNetworkAliases: opts.UseNetworkAliases,
-		Labels:            mergeLabels(service.Labels, service.CustomLabels),
-	}
+	service = withAbsentDependencies(project, service, containersRunning(observedState))
+	createOpts := NewCreateOptions(
+		withAutoRemove(opts.AutoRemove),
+		withAttachStdin(opts.Interactive),
+		withNetworkAliases(opts.UseNetworkAliases),
+		WithContainerLabels(mergeLabels(service.Labels, service.CustomLabels)),
+		withHostPathsCheck(opts.CreateHostPaths),
+	)
 
 	if err := s.resolveRunServiceReferences(ctx, project.Name, &service); err != nil {
 		return prepareRunResult{}, err
@@ -194,6 +196,9 @@ func (s *composeService) prepareRun(ctx context.Context, project *types.Project,
 	if err != nil {
 		return prepareRunResult{}, err
 	}
+	if opts.ContainerCreated != nil {
+		opts.ContainerCreated(service.Name, created.ID)
+	}
 
 	inspect, err := s.apiClient().ContainerInspect(ctx, created.ID, client.ContainerInspectOptions{})
 	if err != nil {
@@ -268,6 +273,22 @@ func applyRunOptions(project *types.Project, service *types.ServiceConfig, opts
 	}
 }
 
+// runDependencies runs the dependency phase of run. When quiet, its progress
+// is held rather than displayed, so it doesn't get in the way of the one-off
+// container attached next, and only displayed if the phase fails.
+func (s *composeService) runDependencies(ctx context.Context, pf progressFunc, quiet bool) error {
+	if !quiet {
+		return Run(ctx, pf, "run", s.events(ctx))
+	}
+	events := s.events(ctx)
+	buffer := &bufferedEvents{}
+	err := Run(ctx, pf, "run", buffer)
+	if err != nil {
+		buffer.replay(events)
+	}
+	return err
+}
+
 func (s *composeService) resolveRunServiceReferences(ctx context.Context, projectName string, service *types.ServiceConfig) error {
 	containersByService, err := s.getContainersByService(ctx, projectName)
 	if err != nil {
@@ -280,10 +301,11 @@ func (s *composeService) startDependencies(ctx context.Context, project *types.P
 	project = project.WithServicesDisabled(options.Service)
 
 	err := s.Create(ctx, project, api.CreateOptions{
-		Build:         options.Build,
-		IgnoreOrphans: options.IgnoreOrphans,
-		RemoveOrphans: options.RemoveOrphans,
-		QuietPull:     options.QuietPull,
+		Build:            options.Build,
+		IgnoreOrphans:    options.IgnoreOrphans,
+		RemoveOrphans:    options.RemoveOrphans,
+		QuietPull:        options.QuietPull,
+		ContainerCreated: options.ContainerCreated,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,65 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestRunDependenciesQuiet(t *testing.T) {
+	pulled := newEvent("Image redis", api.Done, api.StatusPulled)
+	started := newEvent("Container myproject-redis-1", api.Done, api.StatusStarted)
+
+	tests := []struct {
+		name  string
+		quiet bool
+		err   error
+		want  []api.Resource
+	}{
+		{name: "verbose", want: []api.Resource{pulled, started}},
+		{name: "quiet", quiet: true},
+		{name: "quiet failure", quiet: true, err: errors.New("failed"), want: []api.Resource{pulled, started}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(t)
+			events := &capturingEvents{}
+			svc.eventBus = events
+
+			err := svc.runDependencies(t.Context(), func(ctx context.Context) error {
+				svc.events(ctx).On(pulled)
+				svc.events(ctx).On(started)
+				// nothing reaches the user while the dependencies are starting
+				if tt.quiet {
+					assert.Assert(t, len(events.resources) == 0)
+				}
+				// the event processor of the service, as used by concurrent
+				// operations, is left as is
+				assert.Equal(t, svc.eventBus, api.EventProcessor(events))
+				return tt.err
+			}, tt.quiet)
+			assert.Equal(t, err, tt.err)
+			assert.DeepEqual(t, events.resources, tt.want)
+		})
+	}
+}
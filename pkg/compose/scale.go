@@ -25,11 +25,17 @@ import (
 )
 
 func (s *composeService) Scale(ctx context.Context, project *types.Project, options api.ScaleOptions) error {
+	ctx, restarts := withSuspendedRestarts(ctx)
+	defer s.restoreSuspendedRestarts(context.WithoutCancel(ctx), project, restarts)
 	return Run(ctx, tracing.SpanWrapFunc("project/scale", tracing.ProjectOptions(ctx, project), func(ctx context.Context) error {
-		err := s.create(ctx, project, api.CreateOptions{Services: options.Services})
+		err := s.create(ctx, project, api.CreateOptions{
+			Services:            options.Services,
+			KeepScaledDown:      options.KeepScaledDown,
+			ScaleDownReferenced: options.ScaleDownReferenced,
+		})
 		if err != nil {
 			return err
 		}
-		return s.start(ctx, project.Name, api.StartOptions{Project: project, Services: options.Services}, nil)
-	}), "scale", s.events)
+		return s.start(ctx, project.Name, api.StartOptions{Project: project, Services: options.Services, KeepScaledDown: options.KeepScaledDown}, nil)
+	}), "scale", s.events(ctx))
 }
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 Docker Compose CLI authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// scaleFunc converges a service to a number of replicas
+type scaleFunc func(ctx context.Context, service string, replicas int) error
+
+// runScaleSchedule applies the steps of a scale schedule as up runs, each once
+// the time it is scheduled at has elapsed since started. It returns when all
+// the steps are applied, one fails, or ctx is done.
+func (s *composeService) runScaleSchedule(ctx context.Context, started time.Time, steps []api.ScaleStep, scale scaleFunc) error {
+	steps = slices.Clone(steps)
+	slices.SortStableFunc(steps, func(a, b api.ScaleStep) int {
+		return int(a.At - b.At)
+	})
+	for _, step := range steps {
+		if wait := step.At - s.clock.Since(started); wait > 0 {
+			timer := s.clock.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.Chan():
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		eventName := "Service " + step.Service
+		s.events(ctx).On(newEvent(eventName, api.Working, "Scaling", fmt.Sprintf("%d replicas, scheduled at %s", step.Scale, step.At)))
+		if err := scale(ctx, step.Service, step.Scale); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.events(ctx).On(errorEvent(eventName, err.Error()))
+			return fmt.Errorf("scaling service %q to %d replicas: %w", step.Service, step.Scale, err)
+		}
+		s.events(ctx).On(newEvent(eventName, api.Done, "Scaled", fmt.Sprintf("%d replicas", step.Scale)))
+	}
+	return nil
+}
+
+// liveProject is the project up converges while running attached. It keeps
+// track of the scale schedule steps already applied, so converging a service
+// again doesn't reset the scale of the others.
+type liveProject struct {
+	mu      sync.Mutex
+	project *types.Project
+}
+
+func (p *liveProject) get() *types.Project {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.project
+}
+
+func (p *liveProject) set(project *types.Project) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.project = project
+}
+
+// scheduledScale returns the scaleFunc converging the services of the live
+// project with the options of up
+func (s *composeService) scheduledScale(live *liveProject, options api.UpOptions, listener api.ContainerEventListener) scaleFunc {
+	return func(ctx context.Context, service string, replicas int) error {
+		scaled, err := live.get().WithServicesTransform(func(name string, svc types.ServiceConfig) (types.ServiceConfig, error) {
+			if name == service {
+				svc.SetScale(replicas)
+			}
+			return svc, nil
+		})
+		if err != nil {
+			return err
+		}
+		live.set(scaled)
+
+		create := options.Create
+		create.Services = []string{service}
+		create.ScaleDelta = nil
+		if err := s.create(ctx, scaled, create); err != nil {
+			return err
+		}
+		start := options.Start
+		start.Project = scaled
+		start.Services = []string{service}
+		return s.start(ctx, scaled.Name, start, listener)
+	}
+}
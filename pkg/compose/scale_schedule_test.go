@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Docker Compose CLI authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+type recordingScale struct {
+	mu    sync.Mutex
+	steps []string
+	err   error
+}
+
+func (r *recordingScale) scale(_ context.Context, service string, replicas int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, fmt.Sprintf("%s=%d", service, replicas))
+	return r.err
+}
+
+func (r *recordingScale) requested() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.steps)
+}
+
+func TestRunScaleSchedule(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	steps := []api.ScaleStep{
+		{Service: "worker", Scale: 2, At: 0},
+		{Service: "worker", Scale: 10, At: 2 * time.Minute},
+		{Service: "web", Scale: 3, At: time.Minute},
+		{Service: "worker", Scale: 2, At: 10 * time.Minute},
+	}
+	scale := &recordingScale{}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.runScaleSchedule(t.Context(), clock.Now(), steps, scale.scale)
+	}()
+
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	assert.DeepEqual(t, scale.requested(), []string{"worker=2"})
+	clock.Advance(time.Minute)
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	assert.DeepEqual(t, scale.requested(), []string{"worker=2", "web=3"})
+	clock.Advance(time.Minute)
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	assert.DeepEqual(t, scale.requested(), []string{"worker=2", "web=3", "worker=10"})
+	clock.Advance(8 * time.Minute)
+	assert.NilError(t, <-done)
+	assert.DeepEqual(t, scale.requested(), []string{"worker=2", "web=3", "worker=10", "worker=2"})
+}
+
+func TestRunScaleScheduleInterrupted(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	scale := &recordingScale{}
+	ctx, cancel := context.WithCancel(t.Context())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.runScaleSchedule(ctx, clock.Now(), []api.ScaleStep{{Service: "worker", Scale: 10, At: time.Minute}}, scale.scale)
+	}()
+	assert.NilError(t, clock.BlockUntilContext(t.Context(), 1))
+	cancel()
+	assert.NilError(t, <-done)
+	assert.Equal(t, len(scale.requested()), 0)
+}
+
+func TestRunScaleScheduleFailure(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	scale := &recordingScale{err: errors.New("no space left on device")}
+
+	err := svc.runScaleSchedule(t.Context(), clock.Now(), []api.ScaleStep{
+		{Service: "worker", Scale: 10, At: 0},
+		{Service: "worker", Scale: 2, At: time.Minute},
+	}, scale.scale)
+	assert.Error(t, err, `scaling service "worker" to 10 replicas: no space left on device`)
+	assert.DeepEqual(t, scale.requested(), []string{"worker=10"})
+}
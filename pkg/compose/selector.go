@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// labelRequirement is a single term of a label selector.
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool
+	// exists only checks whether the label is set
+	exists bool
+}
+
+// parseLabelSelector parses a comma separated list of requirements, all of
+// which a service must match: key=value, key!=value, key (label set) or !key
+// (label not set).
+func parseLabelSelector(selector string) ([]labelRequirement, error) {
+	var requirements []labelRequirement
+	for term := range strings.SplitSeq(selector, ",") {
+		term = strings.TrimSpace(term)
+		var req labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			req.key, req.value, _ = strings.Cut(term, "!=")
+			req.negate = true
+		case strings.Contains(term, "="):
+			req.key, req.value, _ = strings.Cut(term, "=")
+		case strings.HasPrefix(term, "!"):
+			req.key = strings.TrimPrefix(term, "!")
+			req.exists, req.negate = true, true
+		default:
+			req.key = term
+			req.exists = true
+		}
+		req.key, req.value = strings.TrimSpace(req.key), strings.TrimSpace(req.value)
+		if req.key == "" {
+			return nil, fmt.Errorf("invalid selector %q: %q has no label key", selector, term)
+		}
+		requirements = append(requirements, req)
+	}
+	return requirements, nil
+}
+
+func (r labelRequirement) matches(labels types.Labels) bool {
+	value, ok := labels[r.key]
+	if r.exists {
+		return ok != r.negate
+	}
+	return (ok && value == r.value) != r.negate
+}
+
+// ServicesMatchingSelector returns the names of the project services whose
+// labels, including the ones set by Compose, match a label selector such as
+// "tier=frontend,env!=prod". See parseLabelSelector for the syntax.
+func ServicesMatchingSelector(project *types.Project, selector string) ([]string, error) {
+	requirements, err := parseLabelSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, service := range project.Services {
+		labels := mergeLabels(service.Labels, service.CustomLabels)
+		if !slices.ContainsFunc(requirements, func(r labelRequirement) bool { return !r.matches(labels) }) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestServicesMatchingSelector(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web":    {Name: "web", Labels: types.Labels{"tier": "frontend", "env": "prod"}},
+			"admin":  {Name: "admin", Labels: types.Labels{"tier": "frontend", "env": "staging"}},
+			"api":    {Name: "api", Labels: types.Labels{"tier": "backend"}},
+			"worker": {Name: "worker", CustomLabels: types.Labels{api.ServiceLabel: "worker"}},
+		},
+	}
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "tier=frontend", want: []string{"admin", "web"}},
+		{selector: "tier=frontend,env!=prod", want: []string{"admin"}},
+		{selector: "tier", want: []string{"admin", "api", "web"}},
+		{selector: "!tier", want: []string{"worker"}},
+		{selector: "env!=prod", want: []string{"admin", "api", "worker"}},
+		{selector: " tier = backend ", want: []string{"api"}},
+		{selector: api.ServiceLabel + "=worker", want: []string{"worker"}},
+		{selector: "tier=database"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			names, err := ServicesMatchingSelector(project, tt.selector)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, names, tt.want)
+		})
+	}
+
+	_, err := ServicesMatchingSelector(project, "tier=frontend,")
+	assert.Error(t, err, `invalid selector "tier=frontend,": "" has no label key`)
+	_, err = ServicesMatchingSelector(project, "=frontend")
+	assert.Error(t, err, `invalid selector "=frontend": "=frontend" has no label key`)
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// serviceLocks serializes concurrent reconciliations of the same services, so
+// an embedder triggering two converges of a project at once doesn't have them
+// race on the observed state and container operations. Services are keyed by
+// project and service name, so converging distinct services still runs in
+// parallel.
+type serviceLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// lock acquires the locks for the given services of a project and returns the
+// function releasing them. Locks are taken in a stable order so two callers
+// with overlapping services can't deadlock. Waiting is aborted when ctx is
+// done.
+func (l *serviceLocks) lock(ctx context.Context, project string, services []string) (func(), error) {
+	services = slices.Clone(services)
+	slices.Sort(services)
+	services = slices.Compact(services)
+
+	var held []chan struct{}
+	unlock := func() {
+		for _, ch := range held {
+			<-ch
+		}
+	}
+	for _, service := range services {
+		ch := l.get(project + "/" + service)
+		select {
+		case ch <- struct{}{}:
+			held = append(held, ch)
+		case <-ctx.Done():
+			unlock()
+			return nil, ctx.Err()
+		}
+	}
+	return unlock, nil
+}
+
+func (l *serviceLocks) get(key string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks == nil {
+		l.locks = map[string]chan struct{}{}
+	}
+	ch, ok := l.locks[key]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.locks[key] = ch
+	}
+	return ch
+}
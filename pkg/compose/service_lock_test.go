@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestServiceLocksSerializeSameService(t *testing.T) {
+	var locks serviceLocks
+	unlock, err := locks.lock(t.Context(), "demo", []string{"web", "db"})
+	assert.NilError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock, err := locks.lock(t.Context(), "demo", []string{"db"})
+		assert.Check(t, err)
+		close(acquired)
+		unlock()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock on db acquired while held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+}
+
+func TestServiceLocksDistinctServicesInParallel(t *testing.T) {
+	var locks serviceLocks
+	unlock, err := locks.lock(t.Context(), "demo", []string{"web"})
+	assert.NilError(t, err)
+	defer unlock()
+
+	// same service name in another project, and another service of the same project
+	for _, tc := range []struct{ project, service string }{{"other", "web"}, {"demo", "db"}} {
+		unlock, err := locks.lock(t.Context(), tc.project, []string{tc.service})
+		assert.NilError(t, err)
+		unlock()
+	}
+}
+
+func TestServiceLocksNoDeadlockOnOverlap(t *testing.T) {
+	var locks serviceLocks
+	var wg sync.WaitGroup
+	for i := range 20 {
+		services := []string{"a", "b", "c"}
+		if i%2 == 0 {
+			services = []string{"c", "b", "a"}
+		}
+		wg.Go(func() {
+			unlock, err := locks.lock(t.Context(), "demo", services)
+			assert.Check(t, err)
+			unlock()
+		})
+	}
+	wg.Wait()
+}
+
+func TestServiceLocksCanceled(t *testing.T) {
+	var locks serviceLocks
+	unlock, err := locks.lock(t.Context(), "demo", []string{"b"})
+	assert.NilError(t, err)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locks.lock(ctx, "demo", []string{"a", "b"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// "a" was released when giving up on "b"
+	unlockA, err := locks.lock(t.Context(), "demo", []string{"a"})
+	assert.NilError(t, err)
+	unlockA()
+}
@@ -0,0 +1,158 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// sidecarSuffix is appended to the name of a service to name the service
+// declared for its sidecar
+const sidecarSuffix = "-sidecar"
+
+// expandSidecars declares a service for the sidecar of each service with a
+// sidecar image annotation:
+//
+//	services:
+//	  web:
+//	    image: nginx
+//	    annotations:
+//	      com.docker.compose.sidecar.image: fluent/fluent-bit
+//	      com.docker.compose.sidecar.share: network,pid
+//
+// declares service web-sidecar, running fluent/fluent-bit in a container
+// paired with each container of web, sharing its network and PID namespaces.
+// The sidecar service depends on the service it is paired with, so it is
+// recreated along with it, and has the same scale.
+func expandSidecars(project *types.Project) (*types.Project, error) {
+	for _, name := range project.ServiceNames() {
+		service := project.Services[name]
+		image, ok := service.Annotations[api.SidecarImageAnnotation]
+		if !ok {
+			if _, ok := service.Annotations[api.SidecarShareAnnotation]; ok {
+				return nil, fmt.Errorf("service %q: annotation %s requires %s", name, api.SidecarShareAnnotation, api.SidecarImageAnnotation)
+			}
+			continue
+		}
+		if image == "" {
+			return nil, fmt.Errorf("service %q: annotation %s can't be empty", name, api.SidecarImageAnnotation)
+		}
+		scale := service.GetScale()
+		sidecarName := name + sidecarSuffix
+		if _, exists := project.Services[sidecarName]; exists {
+			return nil, fmt.Errorf("service %q: can't declare a sidecar, service %q is already defined", name, sidecarName)
+		}
+		sidecar := types.ServiceConfig{
+			Name:     sidecarName,
+			Image:    image,
+			Profiles: service.Profiles,
+			Restart:  service.Restart,
+			Scale:    &scale,
+			DependsOn: types.DependsOnConfig{
+				name: {Condition: types.ServiceConditionStarted, Restart: true, Required: true},
+			},
+			Annotations: types.Mapping{api.SidecarOfAnnotation: name},
+		}
+		share := service.Annotations[api.SidecarShareAnnotation]
+		if share == "" {
+			share = "network"
+		}
+		reference := types.ServicePrefix + name
+		for _, namespace := range strings.Split(share, ",") {
+			switch strings.TrimSpace(namespace) {
+			case "network":
+				sidecar.NetworkMode = reference
+			case "pid":
+				sidecar.Pid = reference
+			case "ipc":
+				sidecar.Ipc = reference
+			default:
+				return nil, fmt.Errorf("service %q: invalid namespace %q in annotation %s, should be network, pid or ipc", name, namespace, api.SidecarShareAnnotation)
+			}
+		}
+		project.Services[sidecarName] = sidecar
+	}
+	return project, nil
+}
+
+// sidecarOf returns the name of the service the containers of the sidecar
+// service are paired with, an empty string when it isn't one
+func sidecarOf(service types.ServiceConfig) string {
+	return service.Annotations[api.SidecarOfAnnotation]
+}
+
+// withSidecars returns the selected services completed with their sidecars
+func withSidecars(project *types.Project, services []string) []string {
+	selected := slices.Clone(services)
+	for _, name := range services {
+		sidecar := name + sidecarSuffix
+		if s, ok := project.Services[sidecar]; ok && sidecarOf(s) == name && !slices.Contains(selected, sidecar) {
+			selected = append(selected, sidecar)
+		}
+	}
+	return selected
+}
+
+// pairSidecarScales sets the scale of the sidecar services to the scale of
+// the services they are paired with, once changed from the command line
+func pairSidecarScales(project *types.Project) {
+	for name, service := range project.Services {
+		paired, ok := project.Services[sidecarOf(service)]
+		if !ok {
+			continue
+		}
+		scale := paired.GetScale()
+		service.Scale = &scale
+		project.Services[name] = service
+	}
+}
+
+// pairedContainer returns the ID of the container with the given number in
+// containers, the latest created when a recreate left several
+func pairedContainer(containers Containers, number int) (string, bool) {
+	var id string
+	for _, ctr := range containers {
+		if n, err := strconv.Atoi(ctr.Labels[api.ContainerNumberLabel]); err == nil && n == number {
+			id = ctr.ID
+		}
+	}
+	return id, id != ""
+}
+
+// shareNamespaces sets the namespaces the sidecar service refers to the
+// service it is paired with for to the ones of the paired container
+func shareNamespaces(hostConfig *container.HostConfig, service types.ServiceConfig, pairedID string) {
+	reference := types.ServicePrefix + sidecarOf(service)
+	paired := types.ContainerPrefix + pairedID
+	if string(hostConfig.NetworkMode) == reference {
+		hostConfig.NetworkMode = container.NetworkMode(paired)
+	}
+	if string(hostConfig.PidMode) == reference {
+		hostConfig.PidMode = container.PidMode(paired)
+	}
+	if string(hostConfig.IpcMode) == reference {
+		hostConfig.IpcMode = container.IpcMode(paired)
+	}
+}
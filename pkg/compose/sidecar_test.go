@@ -0,0 +1,167 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestExpandSidecars(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"web": {
+			Name:     "web",
+			Image:    "nginx",
+			Scale:    intPtr(2),
+			Restart:  types.RestartPolicyUnlessStopped,
+			Profiles: []string{"frontend"},
+			Annotations: types.Mapping{
+				api.SidecarImageAnnotation: "fluent/fluent-bit",
+				api.SidecarShareAnnotation: "network, pid",
+			},
+		},
+		"db": {Name: "db", Image: "postgres"},
+	}}
+	project, err := expandSidecars(project)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, project.ServiceNames(), []string{"db", "web", "web-sidecar"})
+	assert.DeepEqual(t, project.Services["web-sidecar"], types.ServiceConfig{
+		Name:        "web-sidecar",
+		Image:       "fluent/fluent-bit",
+		Profiles:    []string{"frontend"},
+		Restart:     types.RestartPolicyUnlessStopped,
+		Scale:       intPtr(2),
+		NetworkMode: "service:web",
+		Pid:         "service:web",
+		DependsOn: types.DependsOnConfig{
+			"web": {Condition: types.ServiceConditionStarted, Restart: true, Required: true},
+		},
+		Annotations: types.Mapping{api.SidecarOfAnnotation: "web"},
+	})
+	assert.DeepEqual(t, withSidecars(project, []string{"web"}), []string{"web", "web-sidecar"})
+	assert.DeepEqual(t, withSidecars(project, []string{"db"}), []string{"db"})
+
+	tests := []struct {
+		name        string
+		annotations types.Mapping
+		err         string
+	}{
+		{
+			name:        "share without image",
+			annotations: types.Mapping{api.SidecarShareAnnotation: "pid"},
+			err:         `service "web": annotation com.docker.compose.sidecar.share requires com.docker.compose.sidecar.image`,
+		},
+		{
+			name:        "invalid namespace",
+			annotations: types.Mapping{api.SidecarImageAnnotation: "fluent/fluent-bit", api.SidecarShareAnnotation: "uts"},
+			err:         `service "web": invalid namespace "uts" in annotation com.docker.compose.sidecar.share, should be network, pid or ipc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := expandSidecars(&types.Project{Services: types.Services{
+				"web": {Name: "web", Image: "nginx", Annotations: tt.annotations},
+			}})
+			assert.Error(t, err, tt.err)
+		})
+	}
+}
+
+func TestPairSidecarContainers(t *testing.T) {
+	summary := func(id string, number string) container.Summary {
+		return container.Summary{ID: id, Labels: map[string]string{api.ContainerNumberLabel: number}}
+	}
+	// a recreate left the replaced container and its replacement
+	containers := Containers{summary("old1", "1"), summary("c2", "2"), summary("new1", "1")}
+	id, ok := pairedContainer(containers, 1)
+	assert.Assert(t, ok)
+	assert.Equal(t, id, "new1")
+	_, ok = pairedContainer(containers, 3)
+	assert.Assert(t, !ok)
+
+	sidecar := types.ServiceConfig{Name: "web-sidecar", Annotations: types.Mapping{api.SidecarOfAnnotation: "web"}}
+	hostConfig := container.HostConfig{NetworkMode: "service:web", PidMode: "service:web", IpcMode: "shareable"}
+	shareNamespaces(&hostConfig, sidecar, "c2")
+	assert.Equal(t, hostConfig.NetworkMode, container.NetworkMode("container:c2"))
+	assert.Equal(t, hostConfig.PidMode, container.PidMode("container:c2"))
+	assert.Equal(t, hostConfig.IpcMode, container.IpcMode("shareable"))
+}
+
+func TestReconcileSidecars(t *testing.T) {
+	load := func(scale int) *types.Project {
+		project, err := expandSidecars(&types.Project{Name: "myproject", Services: types.Services{
+			"web": {
+				Name:        "web",
+				Image:       "nginx",
+				Scale:       intPtr(scale),
+				Annotations: types.Mapping{api.SidecarImageAnnotation: "fluent/fluent-bit"},
+			},
+		}})
+		assert.NilError(t, err)
+		return project
+	}
+	running := func(project *types.Project, service string, webHash string) ObservedContainer {
+		hash := webHash
+		if hash == "" {
+			hash = mustServiceHash(t, project.Services[service])
+		}
+		return ObservedContainer{
+			ID: "c-" + service + "-1", Name: "myproject-" + service + "-1", Number: 1, State: container.StateRunning, ConfigHash: hash,
+			Labels: map[string]string{api.ServiceLabel: service, api.ContainerNumberLabel: "1", api.ConfigHashLabel: hash},
+		}
+	}
+	observed := func(containers ...ObservedContainer) *ObservedState {
+		state := emptyObservedState("myproject")
+		for _, oc := range containers {
+			name := oc.Labels[api.ServiceLabel]
+			state.Containers[name] = append(state.Containers[name], oc)
+		}
+		return state
+	}
+
+	t.Run("scale up", func(t *testing.T) {
+		project := load(2)
+		plan, err := reconcile(t.Context(), project, observed(running(project, "web", ""), running(project, "web-sidecar", "")), defaultReconcileOptions(), noPrompt)
+		assert.NilError(t, err)
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web:2, CreateContainer, no existing container
+[1] -> #2 service:web-sidecar:2, CreateContainer, no existing container
+`)+"\n")
+	})
+
+	t.Run("recreate", func(t *testing.T) {
+		project := load(1)
+		plan, err := reconcile(t.Context(), project, observed(running(project, "web", "diverged"), running(project, "web-sidecar", "")), defaultReconcileOptions(), noPrompt)
+		assert.NilError(t, err)
+		assert.Equal(t, plan.String(), strings.TrimSpace(`
+[] -> #1 service:web-sidecar:1, StopContainer, dependency web being recreated
+[1] -> #2 service:web:1, CreateContainer, config changed (tmpName) [recreate:web:1]
+[2] -> #3 service:web:1, StopContainer, replaced by #2 [recreate:web:1]
+[3] -> #4 service:web:1, RemoveContainer, replaced by #2 [recreate:web:1]
+[4] -> #5 service:web:1, RenameContainer, finalize recreate [recreate:web:1]
+[5] -> #6 service:web-sidecar:1, CreateContainer, config changed (tmpName) [recreate:web-sidecar:1]
+[1,6] -> #7 service:web-sidecar:1, RemoveContainer, replaced by #6 [recreate:web-sidecar:1]
+[7] -> #8 service:web-sidecar:1, RenameContainer, finalize recreate [recreate:web-sidecar:1]
+`)+"\n")
+	})
+}
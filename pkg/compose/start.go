@@ -24,14 +24,16 @@ import (
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/client"
+	"github.com/sirupsen/logrus"
 
 	"github.com/docker/compose/v5/pkg/api"
 )
 
 func (s *composeService) Start(ctx context.Context, projectName string, options api.StartOptions) error {
+	defer s.clearObservedState(ctx, projectName)
 	return Run(ctx, func(ctx context.Context) error {
 		return s.start(ctx, strings.ToLower(projectName), options, nil)
-	}, "start", s.events)
+	}, "start", s.events(ctx))
 }
 
 func (s *composeService) start(ctx context.Context, projectName string, options api.StartOptions, listener api.ContainerEventListener) error {
@@ -58,14 +60,21 @@ func (s *composeService) start(ctx context.Context, projectName string, options
 	}
 	containers := Containers(res.Items)
 
+	if options.SkipDependencyWaits {
+		logrus.Warn("Not waiting for depends_on healthy or completed conditions, services are only started in dependency order")
+	}
+	var traversalOptions []func(*graphTraversal)
+	if profile := startupProfileOf(ctx); profile != nil {
+		traversalOptions = append(traversalOptions, WithTimings(s.clock, profile.visited))
+	}
 	err = InDependencyOrder(ctx, project, func(c context.Context, name string) error {
 		service, err := project.GetService(name)
 		if err != nil {
 			return err
 		}
 
-		return s.startService(ctx, project, service, containers, listener, options.WaitTimeout)
-	})
+		return s.startService(ctx, project, service, containers, listener, options)
+	}, traversalOptions...)
 	if err != nil {
 		return err
 	}
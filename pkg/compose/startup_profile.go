@@ -0,0 +1,276 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// startupProfile records the time spent bringing up each service while up
+// runs: creating its containers, waiting for its dependencies and starting
+// it. All its methods are no-ops on a nil profile.
+type startupProfile struct {
+	mu       sync.Mutex
+	services map[string]*serviceTiming
+	waits    []api.DependencyWait
+}
+
+type serviceTiming struct {
+	// createStart and createEnd span the plan nodes of the service
+	createStart, createEnd time.Time
+	// start and finish span the visit of the service in dependency order
+	start, finish time.Time
+	wait          time.Duration
+}
+
+func newStartupProfile() *startupProfile {
+	return &startupProfile{services: map[string]*serviceTiming{}}
+}
+
+type startupProfileKey struct{}
+
+// withStartupProfile returns a context recording the operation run with it
+// in profile
+func withStartupProfile(ctx context.Context, profile *startupProfile) context.Context {
+	return context.WithValue(ctx, startupProfileKey{}, profile)
+}
+
+// startupProfileOf returns the profile the operation records in, nil when
+// it runs without a profile requested
+func startupProfileOf(ctx context.Context) *startupProfile {
+	profile, _ := ctx.Value(startupProfileKey{}).(*startupProfile)
+	return profile
+}
+
+func (p *startupProfile) timing(service string) *serviceTiming {
+	t, ok := p.services[service]
+	if !ok {
+		t = &serviceTiming{}
+		p.services[service] = t
+	}
+	return t
+}
+
+// executed records a plan node of the service ran from start to end
+func (p *startupProfile) executed(service string, start, end time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.timing(service)
+	if t.createStart.IsZero() || start.Before(t.createStart) {
+		t.createStart = start
+	}
+	if end.After(t.createEnd) {
+		t.createEnd = end
+	}
+}
+
+// waited records the service waited for its dependencies for d
+func (p *startupProfile) waited(service string, d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timing(service).wait += d
+}
+
+// dependencyWaited records a wait for a dependency to meet its condition
+func (p *startupProfile) dependencyWaited(wait api.DependencyWait) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.waits = append(p.waits, wait)
+}
+
+// visited records the service was started in dependency order from start to
+// finish, its wait for its dependencies included
+func (p *startupProfile) visited(service string, start, finish time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	t := p.timing(service)
+	t.start, t.finish = start, finish
+}
+
+// summary returns the profile of the services, sorted by decreasing total
+// time, and the critical path through their dependencies
+func (p *startupProfile) summary(project *types.Project) api.StartupProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	profile := api.StartupProfile{
+		Services:     []api.ServiceProfile{},
+		CriticalPath: []api.CriticalPathStep{},
+		Waits:        slices.Clone(p.waits),
+	}
+	if profile.Waits == nil {
+		profile.Waits = []api.DependencyWait{}
+	}
+	slices.SortStableFunc(profile.Waits, func(a, b api.DependencyWait) int {
+		return cmp.Or(
+			a.Started.Compare(b.Started),
+			cmp.Compare(a.Service, b.Service),
+			cmp.Compare(a.Dependency, b.Dependency),
+		)
+	})
+	durations := map[string]time.Duration{}
+	for name, t := range p.services {
+		start := t.finish.Sub(t.start) - t.wait
+		profile.Services = append(profile.Services, api.ServiceProfile{
+			Name:   name,
+			Create: t.createEnd.Sub(t.createStart).Milliseconds(),
+			Wait:   t.wait.Milliseconds(),
+			Start:  max(start, 0).Milliseconds(),
+		})
+		if !t.start.IsZero() {
+			durations[name] = t.finish.Sub(t.start)
+		}
+	}
+	slices.SortFunc(profile.Services, func(a, b api.ServiceProfile) int {
+		return cmp.Or(
+			cmp.Compare(b.Create+b.Wait+b.Start, a.Create+a.Wait+a.Start),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	dependencies := map[string][]string{}
+	for name := range durations {
+		if service, ok := project.Services[name]; ok {
+			dependencies[name] = service.GetDependencies()
+		}
+	}
+	profile.CriticalPath = criticalPath(dependencies, durations)
+	return profile
+}
+
+// criticalPath returns the chain of dependencies with the longest cumulative
+// duration. Services are only started once their dependencies are, so this is
+// the chain which bounds the time to start the project. Ties are broken by
+// service name, for the path to be stable.
+func criticalPath(dependencies map[string][]string, durations map[string]time.Duration) []api.CriticalPathStep {
+	// finish is the cumulative duration of the longest chain ending with a
+	// service, through the dependency it comes after
+	type chain struct {
+		finish time.Duration
+		after  string
+	}
+	chains := map[string]chain{}
+	var longest func(service string) time.Duration
+	longest = func(service string) time.Duration {
+		if c, ok := chains[service]; ok {
+			return c.finish
+		}
+		var c chain
+		deps := slices.Clone(dependencies[service])
+		slices.Sort(deps)
+		for _, dep := range deps {
+			if _, ok := durations[dep]; !ok {
+				continue
+			}
+			if finish := longest(dep); finish > c.finish {
+				c = chain{finish: finish, after: dep}
+			}
+		}
+		c.finish += durations[service]
+		chains[service] = c
+		return c.finish
+	}
+
+	var last string
+	for _, service := range sortedKeys(durations) {
+		if finish := longest(service); last == "" || finish > chains[last].finish {
+			last = service
+		}
+	}
+
+	path := []api.CriticalPathStep{}
+	for service := last; service != ""; service = chains[service].after {
+		path = append(path, api.CriticalPathStep{
+			Service:    service,
+			Duration:   durations[service].Milliseconds(),
+			Cumulative: chains[service].finish.Milliseconds(),
+		})
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// dependencyWaited records the wait of dependant for dep in the profile,
+// with the state of the dependency containers when the condition wasn't met
+func (s *composeService) dependencyWaited(ctx context.Context, dependant, dep string, config types.ServiceDependency, containers Containers, started time.Time, outcome string) {
+	profile := startupProfileOf(ctx)
+	if profile == nil {
+		return
+	}
+	finished := s.clock.Now()
+	wait := api.DependencyWait{
+		Service:    dependant,
+		Dependency: dep,
+		Condition:  config.Condition,
+		Started:    started,
+		Finished:   finished,
+		Duration:   finished.Sub(started).Milliseconds(),
+		Outcome:    outcome,
+	}
+	switch outcome {
+	case api.DependencyWaitFailed, api.DependencyWaitTimeout, api.DependencyWaitInterrupted:
+		// the wait context is done on timeout, but the state is still worth reporting
+		wait.State = s.containersState(context.WithoutCancel(ctx), containers)
+	}
+	profile.dependencyWaited(wait)
+}
+
+// containersState describes the state of the containers, as "name: status"
+// with their health when they have a healthcheck
+func (s *composeService) containersState(ctx context.Context, containers Containers) string {
+	var states []string
+	for _, c := range containers {
+		res, err := s.apiClient().ContainerInspect(ctx, c.ID, client.ContainerInspectOptions{})
+		if err != nil {
+			states = append(states, fmt.Sprintf("%s: %s", getCanonicalContainerName(c), err.Error()))
+			continue
+		}
+		ctr := res.Container
+		state := string(ctr.State.Status)
+		if ctr.State.Status == container.StateExited {
+			state = fmt.Sprintf("%s (%d)", state, ctr.State.ExitCode)
+		}
+		if ctr.State.Health != nil {
+			state = fmt.Sprintf("%s (health: %s)", state, ctr.State.Health.Status)
+		}
+		states = append(states, fmt.Sprintf("%s: %s", strings.TrimPrefix(ctr.Name, "/"), state))
+	}
+	return strings.Join(states, ", ")
+}
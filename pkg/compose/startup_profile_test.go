@@ -0,0 +1,180 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestCriticalPath(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name         string
+		dependencies map[string][]string
+		durations    map[string]time.Duration
+		want         []api.CriticalPathStep
+	}{
+		{
+			name:      "no service",
+			durations: map[string]time.Duration{},
+			want:      []api.CriticalPathStep{},
+		},
+		{
+			name: "longest chain",
+			dependencies: map[string][]string{
+				"api":    {"db", "cache"},
+				"worker": {"db"},
+				"web":    {"api"},
+			},
+			durations: map[string]time.Duration{
+				"db":     100 * ms,
+				"cache":  300 * ms,
+				"api":    200 * ms,
+				"worker": 450 * ms,
+				"web":    100 * ms,
+			},
+			want: []api.CriticalPathStep{
+				{Service: "cache", Duration: 300, Cumulative: 300},
+				{Service: "api", Duration: 200, Cumulative: 500},
+				{Service: "web", Duration: 100, Cumulative: 600},
+			},
+		},
+		{
+			name: "ties broken by name",
+			dependencies: map[string][]string{
+				"c": {"b", "a"},
+			},
+			durations: map[string]time.Duration{
+				"a": 100 * ms,
+				"b": 100 * ms,
+				"c": 50 * ms,
+			},
+			want: []api.CriticalPathStep{
+				{Service: "a", Duration: 100, Cumulative: 100},
+				{Service: "c", Duration: 50, Cumulative: 150},
+			},
+		},
+		{
+			name: "dependency not started",
+			dependencies: map[string][]string{
+				"web": {"db"},
+			},
+			durations: map[string]time.Duration{
+				"web": 100 * ms,
+			},
+			want: []api.CriticalPathStep{
+				{Service: "web", Duration: 100, Cumulative: 100},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, criticalPath(tt.dependencies, tt.durations), tt.want)
+		})
+	}
+}
+
+func TestStartupProfileSummary(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"db":  {Name: "db"},
+			"web": {Name: "web", DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionHealthy}}},
+		},
+	}
+	at := func(ms int) time.Time {
+		return time.Unix(0, 0).Add(time.Duration(ms) * time.Millisecond)
+	}
+	profile := newStartupProfile()
+	profile.executed("db", at(0), at(40))
+	profile.executed("web", at(10), at(30))
+	profile.executed("web", at(20), at(60))
+	profile.visited("db", at(100), at(150))
+	profile.waited("web", 300*time.Millisecond)
+	profile.visited("web", at(150), at(500))
+
+	assert.DeepEqual(t, profile.summary(project), api.StartupProfile{
+		Services: []api.ServiceProfile{
+			{Name: "web", Create: 50, Wait: 300, Start: 50},
+			{Name: "db", Create: 40, Start: 50},
+		},
+		CriticalPath: []api.CriticalPathStep{
+			{Service: "db", Duration: 50, Cumulative: 50},
+			{Service: "web", Duration: 350, Cumulative: 400},
+		},
+		Waits: []api.DependencyWait{},
+	})
+
+	// a nil profile records nothing
+	var disabled *startupProfile
+	disabled.executed("db", at(0), at(40))
+	disabled.waited("db", time.Second)
+	disabled.visited("db", at(0), at(40))
+	disabled.dependencyWaited(api.DependencyWait{Service: "web", Dependency: "db"})
+}
+
+func TestDependencyWaitTimeline(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	profile := newStartupProfile()
+	project := &types.Project{Name: "test", Services: types.Services{
+		"cache": {Name: "cache", Scale: intPtr(1)},
+		"queue": {Name: "queue", Scale: intPtr(1)},
+	}}
+	dependencies := types.DependsOnConfig{
+		"cache": {Condition: types.ServiceConditionHealthy, Required: true},
+		"queue": {Condition: types.ServiceConditionHealthy, Required: false},
+	}
+	containers := Containers{{
+		ID:     "cache-1",
+		Names:  []string{"/cache-1"},
+		Labels: map[string]string{api.ServiceLabel: "cache", api.OneoffLabel: "False"},
+	}}
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), "cache-1", gomock.Any()).Return(client.ContainerInspectResult{
+		Container: container.InspectResponse{
+			Name:   "/cache-1",
+			State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: container.Starting}},
+			Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+		},
+	}, nil).AnyTimes()
+
+	err := svc.waitDependencies(withStartupProfile(t.Context(), profile), project, "web", dependencies, containers, 50*time.Millisecond)
+	assert.NilError(t, err)
+
+	waits := profile.summary(project).Waits
+	assert.Equal(t, len(waits), 2)
+	byDependency := map[string]api.DependencyWait{}
+	for _, w := range waits {
+		assert.Equal(t, w.Service, "web")
+		assert.Assert(t, !w.Finished.Before(w.Started))
+		byDependency[w.Dependency] = w
+	}
+	assert.Equal(t, byDependency["queue"].Outcome, api.DependencyWaitMissing)
+	assert.Equal(t, byDependency["queue"].State, "")
+	cache := byDependency["cache"]
+	assert.Equal(t, cache.Outcome, api.DependencyWaitTimeout)
+	assert.Equal(t, cache.Condition, types.ServiceConditionHealthy)
+	assert.Equal(t, cache.State, "cache-1: running (health: starting)")
+	assert.Equal(t, cache.Duration, cache.Finished.Sub(cache.Started).Milliseconds())
+}
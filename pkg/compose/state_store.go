@@ -0,0 +1,157 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/sirupsen/logrus"
+)
+
+// StateStore persists the state observed by convergence between runs, so a
+// long-running controller converging a project repeatedly plans from the
+// state the previous run left behind rather than scanning the engine again.
+//
+// The stored state is trusted as is: changes made to the project resources by
+// other means than the composeService the store is set on are not detected
+// until the state is cleared. It is saved once the resources are created,
+// before the containers are started, so it holds the containers as create left
+// them, and cleared by the other operations changing the project containers,
+// such as Stop or Down.
+type StateStore interface {
+	// Load returns the state stored for the project, nil if none is
+	Load(ctx context.Context, projectName string) (*ObservedState, error)
+	// Save stores the state of the project, a nil state clearing it
+	Save(ctx context.Context, projectName string, state *ObservedState) error
+}
+
+// WithStateStore sets the store convergence loads the observed state of the
+// project from, and saves it to once converged. Without one, the state is
+// collected from the engine on each convergence.
+func WithStateStore(store StateStore) Option {
+	return func(s *composeService) error {
+		s.stateStore = store
+		return nil
+	}
+}
+
+// NewMemoryStateStore returns a StateStore keeping the state of the projects
+// in memory, for the lifetime of the process
+func NewMemoryStateStore() StateStore {
+	return &memoryStateStore{states: map[string]*ObservedState{}}
+}
+
+type memoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]*ObservedState
+}
+
+func (m *memoryStateStore) Load(_ context.Context, projectName string) (*ObservedState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.states[projectName]
+	if state != nil {
+		// convergence updates the state it plans from
+		state = state.clone()
+	}
+	return state, nil
+}
+
+func (m *memoryStateStore) Save(_ context.Context, projectName string, state *ObservedState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if state == nil {
+		delete(m.states, projectName)
+		return nil
+	}
+	m.states[projectName] = state
+	return nil
+}
+
+// loadObservedState returns the state of the project from the state store,
+// when set and holding one, or collects it from the engine
+func (s *composeService) loadObservedState(ctx context.Context, project *types.Project) (*ObservedState, error) {
+	if s.stateStore != nil {
+		state, err := s.stateStore.Load(ctx, project.Name)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			return state, nil
+		}
+	}
+	return s.collectObservedState(ctx, project)
+}
+
+// clearObservedState clears the state stored for the project, which the
+// operation changing its containers made stale
+func (s *composeService) clearObservedState(ctx context.Context, projectName string) {
+	if s.stateStore == nil || s.dryRun {
+		return
+	}
+	if err := s.stateStore.Save(context.WithoutCancel(ctx), strings.ToLower(projectName), nil); err != nil {
+		logrus.Warnf("failed to clear the stored state of project %s: %v", projectName, err)
+	}
+}
+
+// saveObservedState stores the state of the project once converged. The
+// state the plan was built from still holds when the plan was empty, and is
+// stored as of now, otherwise it is collected again. A failed convergence
+// leaves the project in an unknown state, which is cleared from the store.
+func (s *composeService) saveObservedState(ctx context.Context, project *types.Project, observed *ObservedState, plan *Plan, err error) error {
+	if s.stateStore == nil || s.dryRun {
+		return err
+	}
+	if err != nil {
+		if clearErr := s.stateStore.Save(context.WithoutCancel(ctx), project.Name, nil); clearErr != nil {
+			logrus.Warnf("failed to clear the stored state of project %s: %v", project.Name, clearErr)
+		}
+		return err
+	}
+	if plan.IsEmpty() {
+		// the state the plan was built from is updated as convergence goes,
+		// the stored one is left to the next run
+		observed = observed.clone()
+		observed.ObservedAt = s.clock.Now()
+	} else {
+		observed, err = s.collectObservedState(ctx, project)
+		if err != nil {
+			return err
+		}
+	}
+	return s.stateStore.Save(ctx, project.Name, observed)
+}
+
+// clone returns a copy of the state, not sharing its containers, networks
+// and volumes with it
+func (s *ObservedState) clone() *ObservedState {
+	state := *s
+	state.Containers = make(map[string][]ObservedContainer, len(s.Containers))
+	for service, containers := range s.Containers {
+		state.Containers[service] = slices.Clone(containers)
+	}
+	state.Orphans = slices.Clone(s.Orphans)
+	state.Networks = maps.Clone(s.Networks)
+	state.Volumes = maps.Clone(s.Volumes)
+	state.ImageIDs = maps.Clone(s.ImageIDs)
+	return &state
+}
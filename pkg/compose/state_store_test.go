@@ -0,0 +1,121 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/jonboulle/clockwork"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	store := NewMemoryStateStore()
+	state, err := store.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Assert(t, state == nil)
+
+	observed := emptyObservedState("myproject")
+	assert.NilError(t, store.Save(t.Context(), "myproject", observed))
+	state, err = store.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, state, observed)
+
+	// the loaded state is a copy, convergence updating it as it goes
+	state.setResolvedVolumes(map[string]string{"data": "myproject_data"})
+	state, err = store.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Equal(t, len(state.Volumes), 0)
+
+	state, err = store.Load(t.Context(), "other")
+	assert.NilError(t, err)
+	assert.Assert(t, state == nil)
+
+	assert.NilError(t, store.Save(t.Context(), "myproject", nil))
+	state, err = store.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Assert(t, state == nil)
+}
+
+func TestLoadObservedStateFromStore(t *testing.T) {
+	// the API client mock expects no call: the state isn't collected from the engine
+	svc, _ := newTestService(t)
+	svc.stateStore = NewMemoryStateStore()
+	project := &types.Project{Name: "myproject"}
+	stored := emptyObservedState("myproject")
+	assert.NilError(t, svc.stateStore.Save(t.Context(), "myproject", stored))
+
+	observed, err := svc.loadObservedState(t.Context(), project)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, observed, stored)
+}
+
+func TestPauseClearsObservedState(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	svc.stateStore = NewMemoryStateStore()
+	assert.NilError(t, svc.stateStore.Save(t.Context(), "myproject", emptyObservedState("myproject")))
+
+	apiClient.EXPECT().ContainerList(gomock.Any(), gomock.Any()).
+		Return(client.ContainerListResult{Items: []container.Summary{testContainer("web", "123", false)}}, nil)
+	apiClient.EXPECT().ContainerPause(gomock.Any(), "123", gomock.Any()).Return(client.ContainerPauseResult{}, nil)
+	assert.NilError(t, svc.Pause(t.Context(), "myproject", api.PauseOptions{}))
+
+	// the next convergence collects the state the pause changed from the engine
+	stored, err := svc.stateStore.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Assert(t, stored == nil)
+}
+
+func TestSaveObservedState(t *testing.T) {
+	svc, _ := newTestService(t)
+	clock := clockwork.NewFakeClock()
+	svc.clock = clock
+	svc.stateStore = NewMemoryStateStore()
+	project := &types.Project{Name: "myproject"}
+	observed := emptyObservedState("myproject")
+	observed.ObservedAt = clock.Now()
+	clock.Advance(time.Minute)
+
+	// an empty plan leaves the state the plan was built from unchanged, as of now
+	assert.NilError(t, svc.saveObservedState(t.Context(), project, observed, &Plan{}, nil))
+	stored, err := svc.stateStore.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Assert(t, stored != observed)
+	assert.Equal(t, stored.ObservedAt, clock.Now())
+	stored.ObservedAt = observed.ObservedAt
+	assert.DeepEqual(t, stored, observed)
+
+	// the stored state doesn't change along with the one of the run
+	observed.setResolvedVolumes(map[string]string{"data": "myproject_data"})
+	assert.Equal(t, len(stored.Volumes), 0)
+
+	// a failed convergence clears the state
+	failure := errors.New("failed")
+	err = svc.saveObservedState(t.Context(), project, observed, &Plan{}, failure)
+	assert.Equal(t, err, failure)
+	stored, err = svc.stateStore.Load(t.Context(), "myproject")
+	assert.NilError(t, err)
+	assert.Assert(t, stored == nil)
+}
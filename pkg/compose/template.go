@@ -0,0 +1,201 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	// serviceTemplateExtension is the project extension declaring service
+	// templates, each expanded into one service per instance:
+	//
+	//	x-service-template:
+	//	  worker:
+	//	    x-instances: [emails, reports]
+	//	    image: worker
+	//	    environment:
+	//	      QUEUE: $${INSTANCE}
+	//
+	// declares services worker-emails and worker-reports.
+	serviceTemplateExtension = "x-service-template"
+	// serviceInstancesExtension lists the instances a template is expanded for.
+	serviceInstancesExtension = "x-instances"
+	// instancePlaceholder is replaced by the instance value in the template.
+	// As the template is interpolated when the project is loaded, it must be
+	// escaped as $${INSTANCE} in the Compose file.
+	instancePlaceholder = "${INSTANCE}"
+)
+
+// expandServiceTemplates replaces the x-service-template project extension with
+// the concrete services it declares, so everything downstream only deals with
+// ordinary services.
+func expandServiceTemplates(ctx context.Context, project *types.Project) (*types.Project, error) {
+	raw, ok := project.Extensions[serviceTemplateExtension]
+	if !ok {
+		return project, nil
+	}
+	templates, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s must be a mapping of service templates", serviceTemplateExtension)
+	}
+
+	services := map[string]any{}
+	for _, name := range slices.Sorted(maps.Keys(templates)) {
+		template, ok := templates[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s must be a service definition", serviceTemplateExtension, name)
+		}
+		instances, err := templateInstances(name, template[serviceInstancesExtension])
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			serviceName := name + "-" + instance
+			if _, exists := project.Services[serviceName]; exists {
+				return nil, fmt.Errorf("%s.%s: service %q is already defined", serviceTemplateExtension, name, serviceName)
+			}
+			service := substituteInstance(template, instance).(map[string]any)
+			delete(service, serviceInstancesExtension)
+			services[serviceName] = service
+		}
+	}
+
+	expanded, err := loadTemplateServices(ctx, project, services)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Services = mergeServices(project.Services, expanded.Services)
+	project.DisabledServices = mergeServices(project.DisabledServices, expanded.DisabledServices)
+	for name, network := range expanded.Networks {
+		if _, ok := project.Networks[name]; !ok {
+			if project.Networks == nil {
+				project.Networks = types.Networks{}
+			}
+			project.Networks[name] = network
+		}
+	}
+	delete(project.Extensions, serviceTemplateExtension)
+	return project, nil
+}
+
+func templateInstances(name string, raw any) ([]string, error) {
+	list, ok := raw.([]any)
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("%s.%s: %s must be a non-empty list of instances", serviceTemplateExtension, name, serviceInstancesExtension)
+	}
+	instances := make([]string, 0, len(list))
+	for _, v := range list {
+		instance := fmt.Sprint(v)
+		if slices.Contains(instances, instance) {
+			return nil, fmt.Errorf("%s.%s: duplicate instance %q", serviceTemplateExtension, name, instance)
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// substituteInstance returns a deep copy of the template with the instance
+// placeholder replaced in all string values.
+func substituteInstance(value any, instance string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = substituteInstance(item, instance)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = substituteInstance(item, instance)
+		}
+		return copied
+	case string:
+		return strings.ReplaceAll(v, instancePlaceholder, instance)
+	default:
+		return v
+	}
+}
+
+// loadTemplateServices runs the expanded services through the compose-go
+// loader, so they get validated and normalized like any other service. The
+// already loaded project is part of the model for references to be checked,
+// and interpolation is skipped as the project already went through it.
+func loadTemplateServices(ctx context.Context, project *types.Project, services map[string]any) (*types.Project, error) {
+	b, err := project.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	var model map[string]any
+	if err := yaml.Unmarshal(b, &model); err != nil {
+		return nil, err
+	}
+	delete(model, serviceTemplateExtension)
+	existing, _ := model["services"].(map[string]any)
+	if existing == nil {
+		existing = map[string]any{}
+	}
+	maps.Copy(existing, services)
+	model["services"] = existing
+
+	loaded, err := loader.LoadWithContext(ctx, types.ConfigDetails{
+		WorkingDir:  project.WorkingDir,
+		ConfigFiles: []types.ConfigFile{{Filename: serviceTemplateExtension, Config: model}},
+		Environment: project.Environment,
+	}, func(options *loader.Options) {
+		options.SetProjectName(project.Name, true)
+		options.SkipInterpolation = true
+		options.Profiles = project.Profiles
+	})
+	if err != nil {
+		return nil, err
+	}
+	loaded.Services = filterServices(loaded.Services, services)
+	loaded.DisabledServices = filterServices(loaded.DisabledServices, services)
+	return loaded, nil
+}
+
+func filterServices(services types.Services, names map[string]any) types.Services {
+	filtered := types.Services{}
+	for name, service := range services {
+		if _, ok := names[name]; ok {
+			filtered[name] = service
+		}
+	}
+	return filtered
+}
+
+func mergeServices(services types.Services, others types.Services) types.Services {
+	if len(others) == 0 {
+		return services
+	}
+	if services == nil {
+		services = types.Services{}
+	}
+	maps.Copy(services, others)
+	return services
+}
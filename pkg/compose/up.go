@@ -33,6 +33,7 @@ import (
 	"github.com/eiannone/keyboard"
 	"github.com/moby/moby/client"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/docker/compose/v5/cmd/formatter"
@@ -41,17 +42,67 @@ import (
 	"github.com/docker/compose/v5/pkg/api"
 )
 
-func (s *composeService) Up(ctx context.Context, project *types.Project, options api.UpOptions) error { //nolint:gocyclo
-	err := Run(ctx, tracing.SpanWrapFunc("project/up", tracing.ProjectOptions(ctx, project), func(ctx context.Context) error {
-		err := s.create(ctx, project, options.Create)
+func (s *composeService) Up(ctx context.Context, project *types.Project, options api.UpOptions) error {
+	var profile *startupProfile
+	if options.Profile != nil {
+		profile = newStartupProfile()
+		ctx = withStartupProfile(ctx, profile)
+		defer func() {
+			options.Profile(profile.summary(project))
+		}()
+	}
+	if options.Report == nil {
+		return s.up(ctx, project, options)
+	}
+	reporter := newUpReporter(s.events(ctx), s.clock)
+	err := s.up(withEventProcessor(ctx, reporter), project, options)
+
+	// up may have been canceled, the final state is still worth reporting
+	containers, psErr := s.Ps(context.WithoutCancel(ctx), project.Name, api.PsOptions{All: true})
+	if psErr != nil {
+		logrus.Warnf("failed to collect the final state of the containers for the report: %v", psErr)
+	}
+	report := reporter.report(err, containers)
+	if profile != nil {
+		summary := profile.summary(project)
+		report.Profile = &summary
+	}
+	options.Report(report)
+	return err
+}
+
+func (s *composeService) up(ctx context.Context, project *types.Project, options api.UpOptions) error { //nolint:gocyclo
+	// containers stopped while converging are started again by up, unless it
+	// fails on the way
+	ctx, restarts := withSuspendedRestarts(ctx)
+	defer s.restoreSuspendedRestarts(context.WithoutCancel(ctx), project, restarts)
+	started := s.clock.Now()
+	checks, err := endpointChecks(project, options.Verify)
+	if err != nil {
+		return err
+	}
+	if s.dryRun {
+		checks = nil
+	}
+	err = Run(ctx, tracing.SpanWrapFunc("project/up", tracing.ProjectOptions(ctx, project), func(ctx context.Context) error {
+		tracing.AddAttributeToSpan(ctx, upCountAttributes(project)...)
+		err := s.resumeAfterDaemonRestart(ctx, options.DaemonWait, func(ctx context.Context) error {
+			if err := s.create(ctx, project, options.Create); err != nil {
+				return err
+			}
+			if options.Start.Attach == nil {
+				return s.start(ctx, project.Name, options.Start, nil)
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
 		if options.Start.Attach == nil {
-			return s.start(ctx, project.Name, options.Start, nil)
+			return s.verifyEndpoints(ctx, checks)
 		}
 		return nil
-	}), "up", s.events)
+	}), "up", s.events(ctx))
 	if err != nil {
 		return err
 	}
@@ -115,6 +166,11 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 		navigationMenu.EnableDetach(cancel)
 	}
 
+	// the scale schedule and the reconcile loop stop as soon as shutdown is
+	// requested
+	scheduleCtx, stopSchedule := context.WithCancel(globalCtx)
+	defer stopSchedule()
+
 	var (
 		eg   errgroup.Group
 		mu   sync.Mutex
@@ -130,38 +186,27 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 	}
 
 	eg.Go(func() error {
-		first := true
-		gracefulTeardown := func() {
-			first = false
-			s.events.On(newEvent(api.ResourceCompose, api.Working, api.StatusStopping, "Gracefully Stopping... press Ctrl+C again to force"))
-			eg.Go(func() error {
-				err = s.stop(context.WithoutCancel(globalCtx), project.Name, api.StopOptions{
-					Services: options.Create.Services,
-					Project:  project,
-				}, printer.HandleEvent)
-				appendErr(err)
-				return nil
-			})
-			isTerminated.Store(true)
-		}
-
-		for {
-			select {
-			case <-globalCtx.Done():
-				if watcher != nil {
-					return watcher.Stop()
-				}
-				return nil
-			case <-ctx.Done():
-				if first {
-					gracefulTeardown()
-				}
-			case <-signalChan:
-				if first {
-					_ = keyboard.Close()
-					gracefulTeardown()
-					break
-				}
+		shutdown := newShutdownHandler(options.Start.ExitMode)
+		handle := func(action shutdownAction) {
+			stopSchedule()
+			switch action {
+			case shutdownDetach:
+				s.events(ctx).On(newEvent(api.ResourceCompose, api.Done, "Detaching", "containers are left running"))
+				isTerminated.Store(true)
+				cancel()
+			case shutdownStop:
+				s.events(ctx).On(newEvent(api.ResourceCompose, api.Working, api.StatusStopping, "Gracefully Stopping... press Ctrl+C again to force"))
+				eg.Go(func() error {
+					err := s.stop(context.WithoutCancel(globalCtx), project.Name, api.StopOptions{
+						Services: options.Create.Services,
+						Project:  project,
+						Timeout:  options.Create.Timeout,
+					}, printer.HandleEvent)
+					appendErr(err)
+					return nil
+				})
+				isTerminated.Store(true)
+			case shutdownKill:
 				eg.Go(func() error {
 					err := s.kill(context.WithoutCancel(globalCtx), project.Name, api.KillOptions{
 						Services: options.Create.Services,
@@ -176,7 +221,27 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 					appendErr(err)
 					return nil
 				})
+			}
+		}
+
+		for {
+			select {
+			case <-globalCtx.Done():
+				if watcher != nil {
+					return watcher.Stop()
+				}
 				return nil
+			case <-ctx.Done():
+				if !shutdown.requested() {
+					handle(shutdown.next())
+				}
+			case <-signalChan:
+				_ = keyboard.Close()
+				action := shutdown.next()
+				handle(action)
+				if action == shutdownKill {
+					return nil
+				}
 			case event := <-kEvents:
 				navigationMenu.HandleKeyEvents(globalCtx, event, project, options)
 			}
@@ -212,7 +277,7 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 				}
 				once = false
 				exitCode = event.ExitCode
-				s.events.On(newEvent(api.ResourceCompose, api.Working, api.StatusStopping, "Aborting on container exit..."))
+				s.events(ctx).On(newEvent(api.ResourceCompose, api.Working, api.StatusStopping, "Aborting on container exit..."))
 				eg.Go(func() error {
 					err = s.stop(context.WithoutCancel(globalCtx), project.Name, api.StopOptions{
 						Services: options.Create.Services,
@@ -290,7 +355,40 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 		return err
 	}
 
+	if len(checks) > 0 {
+		eg.Go(func() error {
+			// verification is abandoned, not failed, when up is interrupted
+			if err := s.verifyEndpoints(globalCtx, checks); globalCtx.Err() == nil {
+				appendErr(err)
+			}
+			return nil
+		})
+	}
+
+	live := &liveProject{project: project}
+	if len(options.ScaleSchedule) > 0 {
+		eg.Go(func() error {
+			appendErr(s.runScaleSchedule(scheduleCtx, started, options.ScaleSchedule, s.scheduledScale(live, options, printer.HandleEvent)))
+			return nil
+		})
+	}
+
+	if options.ReconcileInterval > 0 {
+		eg.Go(func() error {
+			appendErr(s.runReconcileLoop(scheduleCtx, options.ReconcileInterval, s.detectDrift(live), s.reconcileDrift(live, options, printer.HandleEvent)))
+			return nil
+		})
+	}
+
 	_ = eg.Wait()
+	if options.Start.ExitMode == api.ExitModeDown {
+		err := s.down(context.WithoutCancel(ctx), project.Name, api.DownOptions{
+			Project:  project,
+			Services: options.Create.Services,
+			Timeout:  options.Create.Timeout,
+		})
+		appendErr(err)
+	}
 	err = errors.Join(errs...)
 	if exitCode != 0 {
 		errMsg := ""
@@ -314,3 +412,16 @@ func shouldFollowStartEvent(event api.ContainerEvent, attached []string, attachT
 	}
 	return true
 }
+
+// upCountAttributes counts the services of the project and the containers
+// they declare, recorded on the up span
+func upCountAttributes(project *types.Project) []attribute.KeyValue {
+	containers := 0
+	for _, service := range project.Services {
+		containers += service.GetScale()
+	}
+	return []attribute.KeyValue{
+		attribute.Int("project.services.count", len(project.Services)),
+		attribute.Int("project.containers.count", containers),
+	}
+}
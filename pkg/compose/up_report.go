@@ -0,0 +1,257 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// upPhase is a phase of up the report measures the duration of
+type upPhase int
+
+const (
+	phasePull upPhase = iota
+	phaseCreate
+	phaseStart
+	phaseWait
+	phaseCount
+)
+
+// phaseOf returns the phase an event belongs to, from its status text
+func phaseOf(e api.Resource) (upPhase, bool) {
+	switch e.Text {
+	case api.StatusPulling, api.StatusPulled:
+		return phasePull, true
+	case api.StatusCreating, api.StatusCreated, "Recreate", "Recreated":
+		return phaseCreate, true
+	case api.StatusStarting, api.StatusStarted:
+		return phaseStart, true
+	case api.StatusWaiting, api.StatusHealthy, api.StatusExited:
+		return phaseWait, true
+	}
+	return 0, false
+}
+
+// actionRanks orders the actions a container can go through during up, the
+// report keeping the most significant one: a created container is also
+// started, but is reported as created.
+var actionRanks = map[string]int{
+	api.ReportActionUnchanged: 1,
+	api.ReportActionStarted:   2,
+	api.ReportActionCreated:   3,
+	api.ReportActionRecreated: 4,
+}
+
+// upReporter collects what up does for api.UpOptions.Report, from the
+// progress events it forwards to the actual event processor and from the
+// drifts the reconciler acted upon.
+type upReporter struct {
+	next  api.EventProcessor
+	clock clockwork.Clock
+	start time.Time
+
+	mu         sync.Mutex
+	phases     [phaseCount]struct{ first, last time.Time }
+	containers map[string]*containerRecord
+	reasons    map[string]string
+	warnings   []string
+}
+
+type containerRecord struct {
+	action    string
+	waitStart time.Time
+	wait      *api.WaitReport
+	err       string
+}
+
+var _ api.EventProcessor = &upReporter{}
+
+func newUpReporter(next api.EventProcessor, clock clockwork.Clock) *upReporter {
+	return &upReporter{
+		next:       next,
+		clock:      clock,
+		start:      clock.Now(),
+		containers: map[string]*containerRecord{},
+		reasons:    map[string]string{},
+	}
+}
+
+func (r *upReporter) Start(ctx context.Context, operation string) {
+	r.next.Start(ctx, operation)
+}
+
+func (r *upReporter) Done(operation string, success bool) {
+	r.next.Done(operation, success)
+}
+
+func (r *upReporter) On(events ...api.Resource) {
+	r.record(events)
+	r.next.On(events...)
+}
+
+func (r *upReporter) record(events []api.Resource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	for _, e := range events {
+		if phase, ok := phaseOf(e); ok {
+			if r.phases[phase].first.IsZero() {
+				r.phases[phase].first = now
+			}
+			r.phases[phase].last = now
+		}
+		if e.Status == api.Warning && !e.Optional {
+			msg := e.Text
+			if e.Details != "" {
+				msg = e.Details
+			}
+			r.warnings = append(r.warnings, e.ID+": "+msg)
+		}
+
+		name, ok := strings.CutPrefix(e.ID, "Container ")
+		if !ok {
+			continue
+		}
+		ctr, ok := r.containers[name]
+		if !ok {
+			ctr = &containerRecord{}
+			r.containers[name] = ctr
+		}
+		switch {
+		case e.Text == api.StatusCreated:
+			ctr.setAction(api.ReportActionCreated)
+		case e.Text == "Recreated":
+			ctr.setAction(api.ReportActionRecreated)
+		case e.Text == api.StatusStarted:
+			ctr.setAction(api.ReportActionStarted)
+		case e.Text == api.StatusUnchanged:
+			ctr.setAction(api.ReportActionUnchanged)
+		case e.Text == api.StatusWaiting:
+			if ctr.waitStart.IsZero() {
+				ctr.waitStart = now
+			}
+		case e.Text == api.StatusHealthy, e.Text == api.StatusExited:
+			ctr.waited(strings.ToLower(e.Text), e.Details, now)
+		case e.Status == api.Warning && strings.HasPrefix(e.Text, "Skipped: "):
+			ctr.waited("skipped", strings.TrimPrefix(e.Text, "Skipped: "), now)
+		case e.Status == api.Error:
+			ctr.err = e.Details
+			if ctr.err == "" {
+				ctr.err = e.Text
+			}
+			ctr.waited("error", ctr.err, now)
+		}
+	}
+}
+
+func (c *containerRecord) setAction(action string) {
+	if actionRanks[action] > actionRanks[c.action] {
+		c.action = action
+	}
+}
+
+// waited records the outcome of a wait, if the container was waited for
+func (c *containerRecord) waited(outcome, reason string, now time.Time) {
+	if c.waitStart.IsZero() {
+		return
+	}
+	c.wait = &api.WaitReport{
+		Outcome:  outcome,
+		Reason:   reason,
+		Duration: now.Sub(c.waitStart).Milliseconds(),
+	}
+	c.waitStart = time.Time{}
+}
+
+// onDrifts records the reasons for the containers the plan recreates
+func (r *upReporter) onDrifts(drifts []api.Drift) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range drifts {
+		if d.Kind != api.DriftRecreate {
+			continue
+		}
+		if name, reason, ok := strings.Cut(d.Detail, ": "); ok {
+			r.reasons[name] = reason
+		}
+	}
+}
+
+// report builds the report once up returned err, containers being the
+// project containers as up left them.
+func (r *upReporter) report(err error, containers []api.ContainerSummary) api.UpReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := api.UpReport{
+		Success:   err == nil,
+		StartedAt: r.start.UTC().Format(time.RFC3339),
+		Duration:  r.clock.Since(r.start).Milliseconds(),
+		Phases: api.UpPhases{
+			Pull:   r.phaseDuration(phasePull),
+			Create: r.phaseDuration(phaseCreate),
+			Start:  r.phaseDuration(phaseStart),
+			Wait:   r.phaseDuration(phaseWait),
+		},
+		Services: []api.ServiceReport{},
+		Warnings: append([]string{}, r.warnings...),
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	byService := map[string][]api.ContainerReport{}
+	for _, c := range containers {
+		ctr := api.ContainerReport{
+			Name:   c.Name,
+			Action: api.ReportActionUnchanged,
+			State:  string(c.State),
+			Health: string(c.Health),
+		}
+		if rec, ok := r.containers[c.Name]; ok {
+			if rec.action != "" {
+				ctr.Action = rec.action
+			}
+			ctr.Wait = rec.wait
+			ctr.Error = rec.err
+		}
+		if ctr.Action == api.ReportActionRecreated {
+			ctr.Reason = r.reasons[c.Name]
+		}
+		byService[c.Service] = append(byService[c.Service], ctr)
+	}
+	for _, name := range slices.Sorted(maps.Keys(byService)) {
+		ctrs := byService[name]
+		slices.SortFunc(ctrs, func(a, b api.ContainerReport) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		report.Services = append(report.Services, api.ServiceReport{Name: name, Containers: ctrs})
+	}
+	return report
+}
+
+func (r *upReporter) phaseDuration(phase upPhase) int64 {
+	return r.phases[phase].last.Sub(r.phases[phase].first).Milliseconds()
+}
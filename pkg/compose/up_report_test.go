@@ -0,0 +1,138 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/moby/moby/api/types/container"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestUpReport(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	events := &capturingEvents{}
+	reporter := newUpReporter(events, clock)
+	on := func(elapsed time.Duration, e ...api.Resource) {
+		clock.Advance(elapsed)
+		reporter.On(e...)
+	}
+
+	on(0, newEvent("Image postgres", api.Working, api.StatusPulling))
+	on(1500*time.Millisecond, newEvent("Image postgres", api.Done, api.StatusPulled))
+	reporter.onDrifts([]api.Drift{
+		{Service: "web", Kind: api.DriftRecreate, Detail: "demo-web-1: config hash diverged"},
+		{Service: "web", Kind: api.DriftScale, Detail: "1 container(s) running, 2 expected"},
+	})
+	on(100*time.Millisecond,
+		newEvent("Container demo-cache-1", api.Done, api.StatusUnchanged),
+		creatingEvent("Network demo_default"),
+	)
+	on(50*time.Millisecond,
+		createdEvent("Network demo_default"),
+		creatingEvent("Container demo-db-1"),
+		newEvent("Container demo-web-1", api.Working, "Recreate"),
+		creatingEvent("Container demo-web-2"),
+	)
+	on(200*time.Millisecond,
+		createdEvent("Container demo-db-1"),
+		newEvent("Container demo-web-1", api.Done, "Recreated"),
+		createdEvent("Container demo-web-2"),
+	)
+	on(10*time.Millisecond, newEvent("Container demo-db-1", api.Working, api.StatusStarting))
+	on(300*time.Millisecond, newEvent("Container demo-db-1", api.Done, api.StatusStarted))
+	on(0, waiting("Container demo-db-1"))
+	on(2*time.Second, healthy("Container demo-db-1"))
+	on(0, api.Resource{ID: "Container demo-cache-1", Status: api.Warning, Text: "Skipped: optional dependency", Optional: true})
+	on(10*time.Millisecond,
+		newEvent("Container demo-web-1", api.Working, api.StatusStarting),
+		newEvent("Container demo-web-2", api.Working, api.StatusStarting),
+	)
+	on(400*time.Millisecond,
+		newEvent("Container demo-web-1", api.Done, api.StatusStarted),
+		newEvent("Container demo-web-2", api.Done, api.StatusStarted),
+	)
+	on(0, api.Resource{ID: "Volume demo_data", Status: api.Warning, Text: "Warning", Details: "volume already exists but was not created by Docker Compose"})
+
+	// events are forwarded to the actual processor
+	assert.Equal(t, len(events.resources), 21)
+
+	clock.Advance(30 * time.Millisecond)
+	report := reporter.report(nil, []api.ContainerSummary{
+		{Name: "demo-web-2", Service: "web", State: container.StateRunning},
+		{Name: "demo-web-1", Service: "web", State: container.StateRunning},
+		{Name: "demo-db-1", Service: "db", State: container.StateRunning, Health: container.Healthy},
+		{Name: "demo-cache-1", Service: "cache", State: container.StateRunning},
+	})
+	b, err := json.MarshalIndent(report, "", "  ")
+	assert.NilError(t, err)
+	golden.Assert(t, string(b), "up-report.golden")
+}
+
+func TestUpReportFailure(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	reporter := newUpReporter(&capturingEvents{}, clock)
+	reporter.On(newEvent("Container demo-db-1", api.Done, api.StatusStarted))
+	reporter.On(waiting("Container demo-db-1"))
+	clock.Advance(time.Second)
+	reporter.On(errorEventf("Container demo-db-1", "dependency %s failed to start", "db"))
+
+	report := reporter.report(errors.New("dependency failed to start"), []api.ContainerSummary{
+		{Name: "demo-db-1", Service: "db", State: container.StateExited},
+	})
+	assert.Equal(t, report.Success, false)
+	assert.Equal(t, report.Error, "dependency failed to start")
+	assert.DeepEqual(t, report.Services, []api.ServiceReport{{
+		Name: "db",
+		Containers: []api.ContainerReport{{
+			Name:   "demo-db-1",
+			Action: api.ReportActionStarted,
+			Wait:   &api.WaitReport{Outcome: "error", Reason: "dependency db failed to start", Duration: 1000},
+			Error:  "dependency db failed to start",
+			State:  "exited",
+		}},
+	}})
+}
+
+func TestRunEventProcessor(t *testing.T) {
+	svc, _ := newTestService(t)
+	bus := &capturingEvents{}
+	svc.eventBus = bus
+	next := &capturingEvents{}
+	reporter := newUpReporter(next, clockwork.NewFakeClock())
+
+	// the events of an operation go to its own processor, leaving the ones
+	// of the service, as used by concurrent operations, as is
+	err := Run(t.Context(), func(ctx context.Context) error {
+		assert.Equal(t, svc.events(ctx), api.EventProcessor(reporter))
+		svc.events(ctx).On(createdEvent("Container demo-web-1"))
+		svc.events(t.Context()).On(createdEvent("Container demo-db-1"))
+		return nil
+	}, "up", reporter)
+	assert.NilError(t, err)
+	assert.Equal(t, svc.eventBus, api.EventProcessor(bus))
+	assert.DeepEqual(t, bus.resources, []api.Resource{createdEvent("Container demo-db-1")})
+	assert.DeepEqual(t, next.resources, []api.Resource{createdEvent("Container demo-web-1")})
+}
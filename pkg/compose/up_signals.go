@@ -0,0 +1,67 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+// shutdownAction is what an attached up does when asked to terminate
+type shutdownAction int
+
+const (
+	// shutdownIgnore means the termination is already in progress
+	shutdownIgnore shutdownAction = iota
+	// shutdownDetach stops following the logs, leaving the containers running
+	shutdownDetach
+	// shutdownStop gracefully stops the containers
+	shutdownStop
+	// shutdownKill kills the containers still running
+	shutdownKill
+)
+
+// shutdownHandler decides how an attached up reacts to SIGINT and SIGTERM,
+// which are handled the same way: the first one detaches or gracefully stops
+// the project depending on the exit mode, the second one forces a kill.
+type shutdownHandler struct {
+	exitMode string
+	requests int
+}
+
+func newShutdownHandler(exitMode string) *shutdownHandler {
+	return &shutdownHandler{exitMode: exitMode}
+}
+
+// next returns the action for a new termination request
+func (h *shutdownHandler) next() shutdownAction {
+	h.requests++
+	switch {
+	case h.requests == 1 && h.exitMode == api.ExitModeDetach:
+		return shutdownDetach
+	case h.requests == 1:
+		return shutdownStop
+	case h.requests == 2 && h.exitMode != api.ExitModeDetach:
+		return shutdownKill
+	default:
+		return shutdownIgnore
+	}
+}
+
+// requested reports whether a termination has already been requested
+func (h *shutdownHandler) requested() bool {
+	return h.requests > 0
+}
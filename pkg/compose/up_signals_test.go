@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestShutdownHandler(t *testing.T) {
+	tests := []struct {
+		exitMode string
+		expected []shutdownAction
+	}{
+		{
+			exitMode: api.ExitModeStop,
+			expected: []shutdownAction{shutdownStop, shutdownKill, shutdownIgnore},
+		},
+		{
+			exitMode: api.ExitModeDown,
+			expected: []shutdownAction{shutdownStop, shutdownKill, shutdownIgnore},
+		},
+		{
+			exitMode: api.ExitModeDetach,
+			expected: []shutdownAction{shutdownDetach, shutdownIgnore, shutdownIgnore},
+		},
+		{
+			// options built by API consumers without an exit mode keep stopping the project
+			exitMode: "",
+			expected: []shutdownAction{shutdownStop, shutdownKill, shutdownIgnore},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exitMode, func(t *testing.T) {
+			handler := newShutdownHandler(tt.exitMode)
+			assert.Check(t, !handler.requested())
+			var actions []shutdownAction
+			for range tt.expected {
+				actions = append(actions, handler.next())
+			}
+			assert.DeepEqual(t, actions, tt.expected)
+			assert.Check(t, handler.requested())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// updateExtension is the service extension tuning how containers are replaced
+// when a service is recreated.
+const updateExtension = "x-update"
+
+// updateConfig is the decoded form of the x-update service extension:
+//
+//	x-update:
+//	  surge: true
+//	  drain: 10s
+//	  max_age: 720h
+//	  ignore_config_changes: true
+//	  preserve_writable_layer: true
+//	  pre_recreate:
+//	    - command: ["/snapshot.sh"]
+//	      abort_on_failure: true
+//	  post_recreate:
+//	    - command: ["/notify.sh", "recreated"]
+type updateConfig struct {
+	// Surge starts the replacement of a single-replica service, and waits for
+	// it to be healthy, before the old container is stopped.
+	Surge bool `mapstructure:"surge"`
+	// Drain keeps the old container running for the given duration once its
+	// replacement is up, so a reverse proxy has time to notice the change.
+	Drain string `mapstructure:"drain"`
+	// MaxAge recreates containers created longer ago than the given duration,
+	// so they pick up base image updates. It overrides the max-age option of
+	// up and create, "0" disabling it for the service.
+	MaxAge string `mapstructure:"max_age"`
+	// IgnoreConfigChanges doesn't recreate containers when the service
+	// configuration changed, for services whose runtime configuration is
+	// managed externally. Image updates still recreate them.
+	IgnoreConfigChanges bool `mapstructure:"ignore_config_changes"`
+	// PreserveWritableLayer creates the replacement of a container from an
+	// image committed from the old container, so the files it wrote outside
+	// of volumes survive the recreate. The image keeps the settings of the
+	// service image, not the ones of the old container, but the engine adds
+	// the environment of the old container to it: the recreate is refused
+	// when a variable was removed from the service, as it is when the service
+	// image changed. The old container is stopped before it is committed.
+	PreserveWritableLayer bool `mapstructure:"preserve_writable_layer"`
+	// PreRecreate hooks run in the old container, while it is still running,
+	// before it is replaced.
+	PreRecreate []recreateHook `mapstructure:"pre_recreate"`
+	// PostRecreate hooks run in the replacement once it is healthy, or
+	// running if it has no healthcheck.
+	PostRecreate []recreateHook `mapstructure:"post_recreate"`
+
+	drain  time.Duration
+	maxAge time.Duration
+}
+
+func getUpdateConfig(service types.ServiceConfig) (updateConfig, error) {
+	var config updateConfig
+	if _, err := service.Extensions.Get(updateExtension, &config); err != nil {
+		return config, fmt.Errorf("service %q: invalid %s: %w", service.Name, updateExtension, err)
+	}
+	if config.Drain != "" {
+		drain, err := time.ParseDuration(config.Drain)
+		if err != nil || drain < 0 {
+			return config, fmt.Errorf("service %q: invalid %s.drain %q: must be a positive duration", service.Name, updateExtension, config.Drain)
+		}
+		config.drain = drain
+	}
+	if config.MaxAge != "" {
+		maxAge, err := time.ParseDuration(config.MaxAge)
+		if err != nil || maxAge < 0 {
+			return config, fmt.Errorf("service %q: invalid %s.max_age %q: must be a positive duration", service.Name, updateExtension, config.MaxAge)
+		}
+		config.maxAge = maxAge
+	}
+	if config.PreserveWritableLayer && (config.Surge || config.drain > 0) {
+		return config, fmt.Errorf("service %q: invalid %s: preserve_writable_layer stops the old container before its replacement is created, and can't be combined with surge or drain", service.Name, updateExtension)
+	}
+	for _, hook := range config.PreRecreate {
+		if len(hook.Command) == 0 {
+			return config, fmt.Errorf("service %q: invalid %s.pre_recreate: hook has no command", service.Name, updateExtension)
+		}
+	}
+	for _, hook := range config.PostRecreate {
+		if len(hook.Command) == 0 {
+			return config, fmt.Errorf("service %q: invalid %s.post_recreate: hook has no command", service.Name, updateExtension)
+		}
+	}
+	return config, nil
+}
+
+// recreateHook is a command run in a container of a service being recreated,
+// declared by the x-update pre_recreate and post_recreate lists.
+type recreateHook struct {
+	Command     []string          `mapstructure:"command"`
+	User        string            `mapstructure:"user"`
+	Privileged  bool              `mapstructure:"privileged"`
+	WorkingDir  string            `mapstructure:"working_dir"`
+	Environment map[string]string `mapstructure:"environment"`
+	// AbortOnFailure fails the recreate when the hook fails, rather than
+	// only warning about it.
+	AbortOnFailure bool `mapstructure:"abort_on_failure"`
+}
+
+func (h recreateHook) serviceHook() types.ServiceHook {
+	env := types.MappingWithEquals{}
+	for k, v := range h.Environment {
+		env[k] = &v
+	}
+	return types.ServiceHook{
+		Command:     h.Command,
+		User:        h.User,
+		Privileged:  h.Privileged,
+		WorkingDir:  h.WorkingDir,
+		Environment: env,
+	}
+}
@@ -0,0 +1,149 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+const (
+	// verifyExtension is the project extension declaring the endpoints up
+	// checks once the project is converged:
+	//
+	//	x-verify:
+	//	  - url: http://localhost:8080/health
+	//	    timeout: 1m
+	verifyExtension = "x-verify"
+	// defaultVerifyTimeout is how long an endpoint declaring no timeout is
+	// polled for
+	defaultVerifyTimeout = 30 * time.Second
+	// verifyInterval is the delay between two requests to an endpoint
+	verifyInterval = 500 * time.Millisecond
+)
+
+type verifyConfig struct {
+	URL     string `mapstructure:"url"`
+	Timeout string `mapstructure:"timeout"`
+}
+
+// endpointChecks returns the endpoint checks declared by the x-verify project
+// extension followed by the ones of the options, validated
+func endpointChecks(project *types.Project, checks []api.EndpointCheck) ([]api.EndpointCheck, error) {
+	var configs []verifyConfig
+	if _, err := project.Extensions.Get(verifyExtension, &configs); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", verifyExtension, err)
+	}
+	var all []api.EndpointCheck
+	for _, config := range configs {
+		check := api.EndpointCheck{URL: config.URL}
+		if config.Timeout != "" {
+			timeout, err := time.ParseDuration(config.Timeout)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid %s timeout %q for %s: must be a positive duration", verifyExtension, config.Timeout, config.URL)
+			}
+			check.Timeout = timeout
+		}
+		all = append(all, check)
+	}
+	all = append(all, checks...)
+	for i, check := range all {
+		u, err := url.Parse(check.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q to verify: must be an http or https URL", check.URL)
+		}
+		if check.Timeout == 0 {
+			all[i].Timeout = defaultVerifyTimeout
+		}
+	}
+	return all, nil
+}
+
+// verifyEndpoints checks all the endpoints concurrently, failing with the
+// endpoints which didn't respond with a 2xx status within their timeout
+func (s *composeService) verifyEndpoints(ctx context.Context, checks []api.EndpointCheck) error {
+	errs := make([]error, len(checks))
+	var eg errgroup.Group
+	for i, check := range checks {
+		eg.Go(func() error {
+			errs[i] = s.verifyEndpoint(ctx, check)
+			return nil
+		})
+	}
+	_ = eg.Wait()
+	return errors.Join(errs...)
+}
+
+// verifyEndpoint polls the endpoint of check until it responds with a 2xx
+// status, or its timeout elapses
+func (s *composeService) verifyEndpoint(ctx context.Context, check api.EndpointCheck) error {
+	eventName := "Endpoint " + check.URL
+	s.events(ctx).On(newEvent(eventName, api.Working, "Verifying"))
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+	httpClient := &http.Client{Timeout: verifyInterval * 4}
+	ticker := time.NewTicker(verifyInterval)
+	defer ticker.Stop()
+	var last string
+	for {
+		status, err := probeEndpoint(ctx, httpClient, check.URL)
+		switch {
+		case err == nil && status >= 200 && status < 300:
+			s.events(ctx).On(newEvent(eventName, api.Done, "Verified", http.StatusText(status)))
+			return nil
+		case err == nil:
+			last = fmt.Sprintf("responded %d %s", status, http.StatusText(status))
+		case ctx.Err() == nil:
+			last = err.Error()
+		}
+		select {
+		case <-ctx.Done():
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return ctx.Err()
+			}
+			if last == "" {
+				last = "no response"
+			}
+			s.events(ctx).On(errorEvent(eventName, last))
+			return fmt.Errorf("endpoint %s didn't respond with a 2xx status within %s: %s", check.URL, check.Timeout, last)
+		case <-ticker.C:
+		}
+	}
+}
+
+func probeEndpoint(ctx context.Context, httpClient *http.Client, endpoint string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestEndpointChecks(t *testing.T) {
+	project := &types.Project{Extensions: types.Extensions{
+		verifyExtension: []any{
+			map[string]any{"url": "http://localhost:8080/health", "timeout": "1m"},
+			map[string]any{"url": "https://example.com"},
+		},
+	}}
+	checks, err := endpointChecks(project, []api.EndpointCheck{{URL: "http://localhost:9090", Timeout: 5 * time.Second}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, checks, []api.EndpointCheck{
+		{URL: "http://localhost:8080/health", Timeout: time.Minute},
+		{URL: "https://example.com", Timeout: defaultVerifyTimeout},
+		{URL: "http://localhost:9090", Timeout: 5 * time.Second},
+	})
+
+	_, err = endpointChecks(&types.Project{}, []api.EndpointCheck{{URL: "localhost:8080"}})
+	assert.Error(t, err, `invalid endpoint "localhost:8080" to verify: must be an http or https URL`)
+
+	project.Extensions[verifyExtension] = []any{map[string]any{"url": "http://localhost", "timeout": "soon"}}
+	_, err = endpointChecks(project, nil)
+	assert.Error(t, err, `invalid x-verify timeout "soon" for http://localhost: must be a positive duration`)
+}
+
+func TestVerifyEndpoints(t *testing.T) {
+	var requests atomic.Int32
+	// responds 503 to the first request, as an application still starting
+	starting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer starting.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	svc, _ := newTestService(t)
+	err := svc.verifyEndpoints(t.Context(), []api.EndpointCheck{{URL: starting.URL, Timeout: 5 * time.Second}})
+	assert.NilError(t, err)
+	assert.Equal(t, requests.Load(), int32(2))
+
+	err = svc.verifyEndpoints(t.Context(), []api.EndpointCheck{
+		{URL: starting.URL, Timeout: 5 * time.Second},
+		{URL: broken.URL, Timeout: time.Second},
+	})
+	assert.Error(t, err, "endpoint "+broken.URL+" didn't respond with a 2xx status within 1s: responded 502 Bad Gateway")
+}
@@ -18,8 +18,19 @@ package compose
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"slices"
+	"strconv"
+	"sync"
 
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/config"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/client"
 
@@ -80,3 +91,129 @@ func (s *composeService) Volumes(ctx context.Context, project string, options ap
 
 	return volumes, nil
 }
+
+// protectedVolumesDirectory is where the volumes protected after they were
+// created are recorded, relative to the docker CLI configuration directory
+const protectedVolumesDirectory = "compose/protected-volumes"
+
+// protectedVolumesMutex serializes the updates of the protected volumes records
+var protectedVolumesMutex sync.Mutex
+
+// ProtectVolumes protects the project volumes from being removed by down. The
+// volumes not created yet are created right away with api.VolumeProtectLabel.
+// As the engine can't change the labels of an existing volume, an existing one
+// not labeled yet is recorded as protected in the docker CLI configuration
+// directory, which down consults too.
+func (s *composeService) ProtectVolumes(ctx context.Context, project *types.Project, options api.ProtectVolumesOptions) error {
+	return Run(ctx, func(ctx context.Context) error {
+		return s.protectVolumes(ctx, project, options)
+	}, "protect", s.events(ctx))
+}
+
+func (s *composeService) protectVolumes(ctx context.Context, project *types.Project, options api.ProtectVolumesOptions) error {
+	for _, key := range options.Volumes {
+		vol, ok := project.Volumes[key]
+		if !ok {
+			return fmt.Errorf("no such volume: %s", key)
+		}
+		if vol.External {
+			return fmt.Errorf("volume %q is external, down never removes it", key)
+		}
+		res, err := s.apiClient().VolumeInspect(ctx, vol.Name, client.VolumeInspectOptions{})
+		switch {
+		case errdefs.IsNotFound(err):
+			vol.CustomLabels = vol.CustomLabels.
+				Add(api.VolumeLabel, key).
+				Add(api.ProjectLabel, project.Name).
+				Add(api.VersionLabel, api.ComposeVersion).
+				Add(api.VolumeProtectLabel, "true")
+			if err := s.createVolume(ctx, vol); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case isProtectedVolume(res.Volume.Labels):
+			s.events(ctx).On(newEvent(fmt.Sprintf("Volume %s", vol.Name), api.Done, "Protected"))
+		default:
+			if err := recordProtectedVolume(project.Name, vol.Name); err != nil {
+				return err
+			}
+			s.events(ctx).On(newEvent(fmt.Sprintf("Volume %s", vol.Name), api.Done, "Protected"))
+		}
+	}
+	return nil
+}
+
+// protectedVolumesFile returns the file recording the volumes of project
+// protected after they were created
+func protectedVolumesFile(project string) string {
+	return filepath.Join(config.Dir(), protectedVolumesDirectory, project+".json")
+}
+
+// readProtectedVolumes returns the names of the volumes of project recorded
+// as protected
+func readProtectedVolumes(project string) ([]string, error) {
+	file := protectedVolumesFile(project)
+	content, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(content, &names); err != nil {
+		return nil, fmt.Errorf("invalid protected volumes record %s: %w", file, err)
+	}
+	return names, nil
+}
+
+// writeProtectedVolumes records names as the protected volumes of project
+func writeProtectedVolumes(project string, names []string) error {
+	file := protectedVolumesFile(project)
+	if len(names) == 0 {
+		err := os.Remove(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0o644)
+}
+
+// recordProtectedVolume records volume name of project as protected
+func recordProtectedVolume(project string, name string) error {
+	protectedVolumesMutex.Lock()
+	defer protectedVolumesMutex.Unlock()
+	names, err := readProtectedVolumes(project)
+	if err != nil || slices.Contains(names, name) {
+		return err
+	}
+	return writeProtectedVolumes(project, append(names, name))
+}
+
+// forgetProtectedVolume drops volume name of project from the volumes recorded
+// as protected, once it is removed
+func forgetProtectedVolume(project string, name string) error {
+	protectedVolumesMutex.Lock()
+	defer protectedVolumesMutex.Unlock()
+	names, err := readProtectedVolumes(project)
+	if err != nil || !slices.Contains(names, name) {
+		return err
+	}
+	return writeProtectedVolumes(project, slices.DeleteFunc(names, func(n string) bool { return n == name }))
+}
+
+// isProtectedVolume reports whether the labels of a volume protect it from
+// being removed by down
+func isProtectedVolume(labels map[string]string) bool {
+	protect, err := strconv.ParseBool(labels[api.VolumeProtectLabel])
+	return err == nil && protect
+}
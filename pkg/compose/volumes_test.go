@@ -17,8 +17,12 @@
 package compose
 
 import (
+	"context"
 	"testing"
 
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/errdefs"
+	"github.com/docker/cli/cli/config"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/volume"
 	"github.com/moby/moby/client"
@@ -81,3 +85,62 @@ func TestVolumes(t *testing.T) {
 	assert.NilError(t, err)
 	assert.DeepEqual(t, volumes, expected)
 }
+
+func TestProtectVolumes(t *testing.T) {
+	withConfigDir(t)
+	project := &types.Project{
+		Name: "myproject",
+		Volumes: types.Volumes{
+			"db":       {Name: "myproject_db"},
+			"cache":    {Name: "myproject_cache"},
+			"data":     {Name: "myproject_data"},
+			"external": {Name: "shared", External: true},
+		},
+	}
+	svc, apiClient := newTestService(t)
+
+	// a volume not created yet is created with the label
+	apiClient.EXPECT().VolumeInspect(gomock.Any(), "myproject_db", gomock.Any()).
+		Return(client.VolumeInspectResult{}, errdefs.ErrNotFound)
+	apiClient.EXPECT().VolumeCreate(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, opts client.VolumeCreateOptions) (client.VolumeCreateResult, error) {
+			assert.Equal(t, opts.Name, "myproject_db")
+			assert.Equal(t, opts.Labels[api.VolumeProtectLabel], "true")
+			assert.Equal(t, opts.Labels[api.VolumeLabel], "db")
+			assert.Equal(t, opts.Labels[api.ProjectLabel], "myproject")
+			return client.VolumeCreateResult{}, nil
+		})
+	// a volume already labeled is left as is
+	apiClient.EXPECT().VolumeInspect(gomock.Any(), "myproject_cache", gomock.Any()).
+		Return(client.VolumeInspectResult{Volume: volume.Volume{
+			Name:   "myproject_cache",
+			Labels: map[string]string{api.VolumeProtectLabel: "true"},
+		}}, nil)
+	err := svc.ProtectVolumes(t.Context(), project, api.ProtectVolumesOptions{Volumes: []string{"db", "cache"}})
+	assert.NilError(t, err)
+
+	// a volume created without the label is recorded as protected
+	apiClient.EXPECT().VolumeInspect(gomock.Any(), "myproject_data", gomock.Any()).
+		Return(client.VolumeInspectResult{Volume: volume.Volume{Name: "myproject_data"}}, nil).Times(2)
+	err = svc.ProtectVolumes(t.Context(), project, api.ProtectVolumesOptions{Volumes: []string{"data"}})
+	assert.NilError(t, err)
+	err = svc.ProtectVolumes(t.Context(), project, api.ProtectVolumesOptions{Volumes: []string{"data"}})
+	assert.NilError(t, err)
+	recorded, err := readProtectedVolumes("myproject")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, recorded, []string{"myproject_data"})
+
+	err = svc.ProtectVolumes(t.Context(), project, api.ProtectVolumesOptions{Volumes: []string{"external"}})
+	assert.Error(t, err, `volume "external" is external, down never removes it`)
+	err = svc.ProtectVolumes(t.Context(), project, api.ProtectVolumesOptions{Volumes: []string{"unknown"}})
+	assert.Error(t, err, "no such volume: unknown")
+}
+
+// withConfigDir sets the docker CLI configuration directory to a temporary
+// one for the duration of the test
+func withConfigDir(t *testing.T) {
+	t.Helper()
+	dir := config.Dir()
+	config.SetDir(t.TempDir())
+	t.Cleanup(func() { config.SetDir(dir) })
+}
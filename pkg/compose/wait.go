@@ -19,7 +19,9 @@ package compose
 import (
 	"context"
 	"fmt"
+	"slices"
 
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/moby/moby/client"
 	"golang.org/x/sync/errgroup"
 
@@ -64,3 +66,61 @@ func (s *composeService) Wait(ctx context.Context, projectName string, options a
 
 	return statusCode, err
 }
+
+// WaitReady runs the dependency wait phase of up alone, for services already
+// running: nothing is created or started, and each service is reported ready,
+// timed out, or failing independently of the others.
+func (s *composeService) WaitReady(ctx context.Context, project *types.Project, options api.WaitReadyOptions) ([]api.ServiceReadiness, error) {
+	condition := options.Condition
+	switch condition {
+	case "":
+		condition = ServiceConditionRunningOrHealthy
+	case ServiceConditionRunningOrHealthy, types.ServiceConditionHealthy, types.ServiceConditionCompletedSuccessfully:
+	default:
+		return nil, fmt.Errorf("unsupported condition %q, must be %s or %s", condition, types.ServiceConditionHealthy, types.ServiceConditionCompletedSuccessfully)
+	}
+	services := options.Services
+	if len(services) == 0 {
+		services = project.ServiceNames()
+		slices.Sort(services)
+	}
+	for _, name := range services {
+		if _, err := project.GetService(name); err != nil {
+			return nil, err
+		}
+	}
+
+	containers, err := s.getContainers(ctx, project.Name, oneOffExclude, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]api.ServiceReadiness, len(services))
+	var eg errgroup.Group
+	for i, name := range services {
+		results[i].Service = name
+		eg.Go(func() error {
+			waitCtx := ctx
+			if options.Timeout > 0 {
+				var cancel context.CancelFunc
+				waitCtx, cancel = context.WithTimeout(ctx, options.Timeout)
+				defer cancel()
+			}
+			dependencies := types.DependsOnConfig{name: {Condition: condition, Required: true}}
+			err := s.waitDependencies(waitCtx, project, project.Name, dependencies, containers, 0)
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case waitCtx.Err() != nil:
+				// waitDependencies gives up silently once its context is done
+				results[i].TimedOut = true
+			case err != nil:
+				results[i].Error = err
+			default:
+				results[i].Ready = true
+			}
+			return nil
+		})
+	}
+	return results, eg.Wait()
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/api"
+)
+
+func TestWaitReady(t *testing.T) {
+	svc, apiClient := newTestService(t)
+	project := &types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+		"db":    {Name: "db", Scale: intPtr(1)},
+		"cache": {Name: "cache", Scale: intPtr(1)},
+		"web":   {Name: "web", Scale: intPtr(1)},
+	}}
+	health := map[string]container.HealthStatus{
+		"db-1":    container.Healthy,
+		"cache-1": container.Unhealthy,
+		"web-1":   container.Starting,
+	}
+	var summaries []container.Summary
+	for id := range health {
+		service, _, _ := strings.Cut(id, "-")
+		summaries = append(summaries, container.Summary{ID: id, Names: []string{"/" + id}, Labels: containerLabels(service, false)})
+	}
+	apiClient.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return(client.ContainerListResult{Items: summaries}, nil)
+	apiClient.EXPECT().ContainerInspect(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, id string, _ client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+			return client.ContainerInspectResult{Container: container.InspectResponse{
+				Name:   "/" + id,
+				Config: &container.Config{Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}}},
+				State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: health[id]}},
+			}}, nil
+		}).AnyTimes()
+
+	results, err := svc.WaitReady(t.Context(), project, api.WaitReadyOptions{
+		Condition: types.ServiceConditionHealthy,
+		Timeout:   time.Second,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 3)
+
+	assert.Equal(t, results[0].Service, "cache")
+	assert.Check(t, !results[0].Ready && !results[0].TimedOut)
+	assert.ErrorContains(t, results[0].Error, "container cache-1 is unhealthy")
+	assert.DeepEqual(t, results[1], api.ServiceReadiness{Service: "db", Ready: true})
+	assert.DeepEqual(t, results[2], api.ServiceReadiness{Service: "web", TimedOut: true})
+}
+
+func TestWaitReadyInvalidOptions(t *testing.T) {
+	svc, _ := newTestService(t)
+	project := &types.Project{Name: "test", Services: types.Services{"db": {Name: "db"}}}
+
+	_, err := svc.WaitReady(t.Context(), project, api.WaitReadyOptions{Condition: types.ServiceConditionStarted})
+	assert.Error(t, err, `unsupported condition "service_started", must be service_healthy or service_completed_successfully`)
+
+	_, err = svc.WaitReady(t.Context(), project, api.WaitReadyOptions{Services: []string{"unknown"}})
+	assert.ErrorContains(t, err, "unknown")
+}
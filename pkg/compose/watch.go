@@ -138,11 +138,27 @@ func (s *composeService) Watch(ctx context.Context, project *types.Project, opti
 	return wait()
 }
 
+// watchIgnoreFromExtension lists the ignore files, relative to the watched
+// path, a watch rule loads patterns from on top of its ignore list:
+//
+//	develop:
+//	  watch:
+//	    - path: ./web
+//	      action: sync
+//	      target: /app
+//	      x-ignore_from: [.dockerignore, .gitignore]
+//
+// .gitignore files are read with the git semantics, any other file as a
+// .dockerignore. The patterns are reloaded when the files change.
+const watchIgnoreFromExtension = "x-ignore_from"
+
 type watchRule struct {
 	types.Trigger
 	include watch.PathMatcher
 	ignore  watch.PathMatcher
-	service string
+	// ignoreFrom is the part of ignore loaded from the x-ignore_from files
+	ignoreFrom *watch.IgnoreFilesMatcher
+	service    string
 }
 
 func (r watchRule) Matches(event watch.FileEvent) *sync.PathMapping {
@@ -150,6 +166,11 @@ func (r watchRule) Matches(event watch.FileEvent) *sync.PathMapping {
 	if !pathutil.IsChild(r.Path, hostPath) {
 		return nil
 	}
+	if r.ignoreFrom != nil && r.ignoreFrom.IsIgnoreFile(hostPath) {
+		if err := r.ignoreFrom.Reload(); err != nil {
+			logrus.Warnf("error reloading ignore patterns from %q: %v", hostPath, err)
+		}
+	}
 	included, err := r.include.Matches(hostPath)
 	if err != nil {
 		logrus.Warnf("error include matching %q: %v", hostPath, err)
@@ -309,7 +330,7 @@ func getWatchRules(config *types.DevelopConfig, service types.ServiceConfig) ([]
 	}
 
 	for _, trigger := range config.Watch {
-		ignore, err := watch.NewDockerPatternMatcher(trigger.Path, trigger.Ignore)
+		ignore, ignoreFrom, err := getTriggerIgnore(trigger)
 		if err != nil {
 			return nil, err
 		}
@@ -333,12 +354,35 @@ func getWatchRules(config *types.DevelopConfig, service types.ServiceConfig) ([]
 				dotGitIgnore,
 				ignore,
 			),
-			service: service.Name,
+			ignoreFrom: ignoreFrom,
+			service:    service.Name,
 		})
 	}
 	return rules, nil
 }
 
+// getTriggerIgnore returns the matcher for the ignore list of a watch rule,
+// merged with the patterns of its x-ignore_from files, along with the matcher
+// for these files to be reloaded when they change.
+func getTriggerIgnore(trigger types.Trigger) (watch.PathMatcher, *watch.IgnoreFilesMatcher, error) {
+	ignore, err := watch.NewDockerPatternMatcher(trigger.Path, trigger.Ignore)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	if _, err := trigger.Extensions.Get(watchIgnoreFromExtension, &files); err != nil {
+		return nil, nil, fmt.Errorf("watch path %s: invalid %s: %w", trigger.Path, watchIgnoreFromExtension, err)
+	}
+	if len(files) == 0 {
+		return ignore, nil, nil
+	}
+	ignoreFrom, err := watch.NewIgnoreFilesMatcher(trigger.Path, files)
+	if err != nil {
+		return nil, nil, err
+	}
+	return watch.NewCompositeMatcher(ignore, ignoreFrom), ignoreFrom, nil
+}
+
 func isSync(trigger types.Trigger) bool {
 	return trigger.Action == types.WatchActionSync || trigger.Action == types.WatchActionSyncRestart
 }
@@ -633,6 +677,8 @@ func (s *composeService) exec(ctx context.Context, project *types.Project, servi
 }
 
 func (s *composeService) rebuild(ctx context.Context, project *types.Project, services []string, options api.WatchOptions) error {
+	ctx, restarts := withSuspendedRestarts(ctx)
+	defer s.restoreSuspendedRestarts(context.WithoutCancel(ctx), project, restarts)
 	options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Rebuilding service(s) %q after changes were detected...", services))
 	// Work on a copy so concurrent watch events don't race on the shared
 	// BuildOptions pointer carried by WatchOptions.
@@ -749,7 +795,7 @@ func (s *composeService) initialSync(ctx context.Context, project *types.Project
 		return err
 	}
 
-	triggerIgnore, err := watch.NewDockerPatternMatcher(trigger.Path, trigger.Ignore)
+	triggerIgnore, _, err := getTriggerIgnore(trigger)
 	if err != nil {
 		return err
 	}
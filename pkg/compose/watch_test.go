@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 	"time"
@@ -180,6 +181,39 @@ func TestWatch_Sync(t *testing.T) {
 	// TODO: there's not a great way to assert that the rebuild attempt happened
 }
 
+func TestWatchRuleIgnoreFrom(t *testing.T) {
+	dir := t.TempDir()
+	gitignore := filepath.Join(dir, ".gitignore")
+	assert.NilError(t, os.WriteFile(gitignore, []byte("node_modules/\n"), 0o600))
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755))
+
+	rules, err := getWatchRules(&types.DevelopConfig{
+		Watch: []types.Trigger{{
+			Path:       dir,
+			Action:     types.WatchActionSync,
+			Target:     "/app",
+			Ignore:     []string{"*.tmp"},
+			Extensions: types.Extensions{watchIgnoreFromExtension: []any{".dockerignore", ".gitignore"}},
+		}},
+	}, types.ServiceConfig{Name: "web"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(rules), 1)
+	rule := rules[0]
+
+	synced := func(path string) bool {
+		return rule.Matches(watch.NewFileEvent(filepath.Join(dir, path))) != nil
+	}
+	assert.Check(t, !synced("node_modules/pkg/index.js"), "ignored by .gitignore")
+	assert.Check(t, !synced("scratch.tmp"), "ignored by the inline ignore list")
+	assert.Check(t, synced("index.js"))
+	assert.Check(t, synced("README.md"))
+
+	// the change of an ignore file reloads the patterns
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("*.md\n"), 0o600))
+	assert.Check(t, synced(".dockerignore"))
+	assert.Check(t, !synced("README.md"))
+}
+
 type fakeSyncer struct {
 	synced chan []*sync.PathMapping
 }
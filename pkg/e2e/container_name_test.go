@@ -33,7 +33,7 @@ func TestUpContainerNameConflict(t *testing.T) {
 	})
 
 	res := c.RunDockerComposeCmdNoCheck(t, "-f", "fixtures/container_name/compose.yaml", "--project-name", projectName, "up")
-	res.Assert(t, icmd.Expected{ExitCode: 1, Err: `container name "test" is already in use`})
+	res.Assert(t, icmd.Expected{ExitCode: 1, Err: `use the same container name "test"`})
 
 	c.RunDockerComposeCmd(t, "--project-name", projectName, "down")
 	c.RunDockerComposeCmd(t, "-f", "fixtures/container_name/compose.yaml", "--project-name", projectName, "up", "test")
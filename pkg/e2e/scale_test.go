@@ -45,15 +45,15 @@ func TestScaleBasicCases(t *testing.T) {
 	t.Log("scale up 2 services")
 	res = c.RunDockerComposeCmd(t, "--project-directory", "fixtures/scale", "scale", "front=3", "back=2")
 	out = res.Combined()
-	checkServiceContainer(t, out, "scale-basic-tests-front", "Running", 2)
+	checkServiceContainer(t, out, "scale-basic-tests-front", "Unchanged", 2)
 	checkServiceContainer(t, out, "scale-basic-tests-front", "Started", 1)
-	checkServiceContainer(t, out, "scale-basic-tests-back", "Running", 1)
+	checkServiceContainer(t, out, "scale-basic-tests-back", "Unchanged", 1)
 	checkServiceContainer(t, out, "scale-basic-tests-back", "Started", 1)
 
 	t.Log("scale down one service")
 	res = c.RunDockerComposeCmd(t, "--project-directory", "fixtures/scale", "scale", "dbadmin=1")
 	out = res.Combined()
-	checkServiceContainer(t, out, "scale-basic-tests-dbadmin", "Running", 1)
+	checkServiceContainer(t, out, "scale-basic-tests-dbadmin", "Unchanged", 1)
 
 	t.Log("scale to 0 a service")
 	res = c.RunDockerComposeCmd(t, "--project-directory", "fixtures/scale", "scale", "dbadmin=0")
@@ -62,9 +62,9 @@ func TestScaleBasicCases(t *testing.T) {
 	t.Log("scale down 2 services")
 	res = c.RunDockerComposeCmd(t, "--project-directory", "fixtures/scale", "scale", "front=2", "back=1")
 	out = res.Combined()
-	checkServiceContainer(t, out, "scale-basic-tests-front", "Running", 2)
-	assert.Check(t, !strings.Contains(out, "Container scale-basic-tests-front-3  Running"), res.Combined())
-	checkServiceContainer(t, out, "scale-basic-tests-back", "Running", 1)
+	checkServiceContainer(t, out, "scale-basic-tests-front", "Unchanged", 2)
+	assert.Check(t, !strings.Contains(out, "Container scale-basic-tests-front-3  Unchanged"), res.Combined())
+	checkServiceContainer(t, out, "scale-basic-tests-back", "Unchanged", 1)
 }
 
 func TestScaleWithDepsCases(t *testing.T) {
@@ -319,7 +319,7 @@ func TestWatchExec(t *testing.T) {
 		}
 		return poll.Continue("%v", out)
 	})
-	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9")
+	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9", "--yes")
 }
 
 func TestWatchMultiServices(t *testing.T) {
@@ -365,7 +365,7 @@ func TestWatchMultiServices(t *testing.T) {
 	waitRebuild("b", "updated")
 	waitRebuild("c", "updated")
 
-	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9")
+	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9", "--yes")
 }
 
 // TestWatchRebuildIgnoresDependencies verifies that when `compose up --watch`
@@ -444,7 +444,7 @@ func TestWatchRebuildIgnoresDependencies(t *testing.T) {
 	assert.Assert(t, !strings.Contains(rebuildLog, "backend Built"),
 		"backend was unexpectedly rebuilt; got:\n%s", rebuildLog)
 
-	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9")
+	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9", "--yes")
 }
 
 func TestWatchIncludes(t *testing.T) {
@@ -481,7 +481,7 @@ func TestWatchIncludes(t *testing.T) {
 		return poll.Continue("%v", cat.Combined())
 	})
 
-	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9")
+	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9", "--yes")
 }
 
 func TestCheckWarningXInitialSyn(t *testing.T) {
@@ -505,5 +505,5 @@ func TestCheckWarningXInitialSyn(t *testing.T) {
 		return poll.Continue("%v", watch.Stdout())
 	})
 
-	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9")
+	c.RunDockerComposeCmdNoCheck(t, "-p", projectName, "kill", "-s", "9", "--yes")
 }
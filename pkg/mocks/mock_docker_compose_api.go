@@ -22,6 +22,7 @@ import (
 type MockCompose struct {
 	ctrl     *gomock.Controller
 	recorder *MockComposeMockRecorder
+	isgomock struct{}
 }
 
 // MockComposeMockRecorder is the mock recorder for MockCompose.
@@ -69,6 +70,36 @@ func (mr *MockComposeMockRecorder) Build(ctx, project, options any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Build", reflect.TypeOf((*MockCompose)(nil).Build), ctx, project, options)
 }
 
+// BuildChanges mocks base method.
+func (m *MockCompose) BuildChanges(ctx context.Context, project *types.Project, options api.BuildOptions) ([]api.BuildChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BuildChanges", ctx, project, options)
+	ret0, _ := ret[0].([]api.BuildChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildChanges indicates an expected call of BuildChanges.
+func (mr *MockComposeMockRecorder) BuildChanges(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildChanges", reflect.TypeOf((*MockCompose)(nil).BuildChanges), ctx, project, options)
+}
+
+// Checkpoint mocks base method.
+func (m *MockCompose) Checkpoint(ctx context.Context, project *types.Project, options api.CheckpointOptions) (api.Snapshot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkpoint", ctx, project, options)
+	ret0, _ := ret[0].(api.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkpoint indicates an expected call of Checkpoint.
+func (mr *MockComposeMockRecorder) Checkpoint(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockCompose)(nil).Checkpoint), ctx, project, options)
+}
+
 // Commit mocks base method.
 func (m *MockCompose) Commit(ctx context.Context, projectName string, options api.CommitOptions) error {
 	m.ctrl.T.Helper()
@@ -111,6 +142,36 @@ func (mr *MockComposeMockRecorder) Create(ctx, project, options any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCompose)(nil).Create), ctx, project, options)
 }
 
+// DNS mocks base method.
+func (m *MockCompose) DNS(ctx context.Context, project *types.Project, options api.DNSOptions) ([]api.NetworkDNS, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DNS", ctx, project, options)
+	ret0, _ := ret[0].([]api.NetworkDNS)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DNS indicates an expected call of DNS.
+func (mr *MockComposeMockRecorder) DNS(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DNS", reflect.TypeOf((*MockCompose)(nil).DNS), ctx, project, options)
+}
+
+// Diff mocks base method.
+func (m *MockCompose) Diff(ctx context.Context, project *types.Project) ([]api.Drift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Diff", ctx, project)
+	ret0, _ := ret[0].([]api.Drift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Diff indicates an expected call of Diff.
+func (mr *MockComposeMockRecorder) Diff(ctx, project any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Diff", reflect.TypeOf((*MockCompose)(nil).Diff), ctx, project)
+}
+
 // Down mocks base method.
 func (m *MockCompose) Down(ctx context.Context, projectName string, options api.DownOptions) error {
 	m.ctrl.T.Helper()
@@ -168,6 +229,21 @@ func (mr *MockComposeMockRecorder) Export(ctx, projectName, options any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockCompose)(nil).Export), ctx, projectName, options)
 }
 
+// ExportSpecs mocks base method.
+func (m *MockCompose) ExportSpecs(ctx context.Context, project *types.Project, options api.ExportSpecsOptions) ([]api.ContainerSpec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportSpecs", ctx, project, options)
+	ret0, _ := ret[0].([]api.ContainerSpec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportSpecs indicates an expected call of ExportSpecs.
+func (mr *MockComposeMockRecorder) ExportSpecs(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportSpecs", reflect.TypeOf((*MockCompose)(nil).ExportSpecs), ctx, project, options)
+}
+
 // Generate mocks base method.
 func (m *MockCompose) Generate(ctx context.Context, options api.GenerateOptions) (*types.Project, error) {
 	m.ctrl.T.Helper()
@@ -198,6 +274,21 @@ func (mr *MockComposeMockRecorder) Images(ctx, projectName, options any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Images", reflect.TypeOf((*MockCompose)(nil).Images), ctx, projectName, options)
 }
 
+// Inspect mocks base method.
+func (m *MockCompose) Inspect(ctx context.Context, project *types.Project, options api.InspectOptions) (api.ServiceInspect, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Inspect", ctx, project, options)
+	ret0, _ := ret[0].(api.ServiceInspect)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Inspect indicates an expected call of Inspect.
+func (mr *MockComposeMockRecorder) Inspect(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockCompose)(nil).Inspect), ctx, project, options)
+}
+
 // Kill mocks base method.
 func (m *MockCompose) Kill(ctx context.Context, projectName string, options api.KillOptions) error {
 	m.ctrl.T.Helper()
@@ -286,6 +377,20 @@ func (mr *MockComposeMockRecorder) Port(ctx, projectName, service, port, options
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Port", reflect.TypeOf((*MockCompose)(nil).Port), ctx, projectName, service, port, options)
 }
 
+// ProtectVolumes mocks base method.
+func (m *MockCompose) ProtectVolumes(ctx context.Context, project *types.Project, options api.ProtectVolumesOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProtectVolumes", ctx, project, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProtectVolumes indicates an expected call of ProtectVolumes.
+func (mr *MockComposeMockRecorder) ProtectVolumes(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProtectVolumes", reflect.TypeOf((*MockCompose)(nil).ProtectVolumes), ctx, project, options)
+}
+
 // Ps mocks base method.
 func (m *MockCompose) Ps(ctx context.Context, projectName string, options api.PsOptions) ([]api.ContainerSummary, error) {
 	m.ctrl.T.Helper()
@@ -371,6 +476,20 @@ func (mr *MockComposeMockRecorder) Restart(ctx, projectName, options any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockCompose)(nil).Restart), ctx, projectName, options)
 }
 
+// Restore mocks base method.
+func (m *MockCompose) Restore(ctx context.Context, project *types.Project, options api.RestoreOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, project, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockComposeMockRecorder) Restore(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockCompose)(nil).Restore), ctx, project, options)
+}
+
 // RunOneOffContainer mocks base method.
 func (m *MockCompose) RunOneOffContainer(ctx context.Context, project *types.Project, opts api.RunOptions) (int, error) {
 	m.ctrl.T.Helper()
@@ -516,6 +635,21 @@ func (mr *MockComposeMockRecorder) Wait(ctx, projectName, options any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Wait", reflect.TypeOf((*MockCompose)(nil).Wait), ctx, projectName, options)
 }
 
+// WaitReady mocks base method.
+func (m *MockCompose) WaitReady(ctx context.Context, project *types.Project, options api.WaitReadyOptions) ([]api.ServiceReadiness, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitReady", ctx, project, options)
+	ret0, _ := ret[0].([]api.ServiceReadiness)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WaitReady indicates an expected call of WaitReady.
+func (mr *MockComposeMockRecorder) WaitReady(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitReady", reflect.TypeOf((*MockCompose)(nil).WaitReady), ctx, project, options)
+}
+
 // Watch mocks base method.
 func (m *MockCompose) Watch(ctx context.Context, project *types.Project, options api.WatchOptions) error {
 	m.ctrl.T.Helper()
@@ -534,6 +668,7 @@ func (mr *MockComposeMockRecorder) Watch(ctx, project, options any) *gomock.Call
 type MockLogConsumer struct {
 	ctrl     *gomock.Controller
 	recorder *MockLogConsumerMockRecorder
+	isgomock struct{}
 }
 
 // MockLogConsumerMockRecorder is the mock recorder for MockLogConsumer.
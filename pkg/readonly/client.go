@@ -0,0 +1,326 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package readonly provides an API client restricted to the calls which don't
+// change the state of the engine.
+package readonly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/moby/moby/client"
+)
+
+var _ client.APIClient = &Client{}
+
+// ErrReadOnly is returned by the methods of Client which would change the
+// state of the engine
+var ErrReadOnly = errors.New("not allowed on a read-only engine connection")
+
+// Client implements APIClient by delegating the calls inspecting or listing
+// resources to the wrapped client, while the calls which would change the
+// state of the engine fail with ErrReadOnly without reaching it. This keeps
+// the code planning a convergence honest: it can run against an engine
+// connection only granted inspect and list permissions.
+type Client struct {
+	client.APIClient
+}
+
+// NewClient wraps apiClient into a read-only Client
+func NewClient(apiClient client.APIClient) *Client {
+	return &Client{APIClient: apiClient}
+}
+
+func denied(method string) error {
+	return fmt.Errorf("%s: %w", method, ErrReadOnly)
+}
+
+// All methods which change the state of the engine, or open a raw connection
+// to it through which they could.
+
+func (c *Client) CheckpointCreate(_ context.Context, _ string, _ client.CheckpointCreateOptions) (client.CheckpointCreateResult, error) {
+	return client.CheckpointCreateResult{}, denied("CheckpointCreate")
+}
+
+func (c *Client) CheckpointRemove(_ context.Context, _ string, _ client.CheckpointRemoveOptions) (client.CheckpointRemoveResult, error) {
+	return client.CheckpointRemoveResult{}, denied("CheckpointRemove")
+}
+
+func (c *Client) ContainerCreate(_ context.Context, _ client.ContainerCreateOptions) (client.ContainerCreateResult, error) {
+	return client.ContainerCreateResult{}, denied("ContainerCreate")
+}
+
+func (c *Client) ContainerUpdate(_ context.Context, _ string, _ client.ContainerUpdateOptions) (client.ContainerUpdateResult, error) {
+	return client.ContainerUpdateResult{}, denied("ContainerUpdate")
+}
+
+func (c *Client) ContainerRemove(_ context.Context, _ string, _ client.ContainerRemoveOptions) (client.ContainerRemoveResult, error) {
+	return client.ContainerRemoveResult{}, denied("ContainerRemove")
+}
+
+func (c *Client) ContainerPrune(_ context.Context, _ client.ContainerPruneOptions) (client.ContainerPruneResult, error) {
+	return client.ContainerPruneResult{}, denied("ContainerPrune")
+}
+
+func (c *Client) ContainerStart(_ context.Context, _ string, _ client.ContainerStartOptions) (client.ContainerStartResult, error) {
+	return client.ContainerStartResult{}, denied("ContainerStart")
+}
+
+func (c *Client) ContainerStop(_ context.Context, _ string, _ client.ContainerStopOptions) (client.ContainerStopResult, error) {
+	return client.ContainerStopResult{}, denied("ContainerStop")
+}
+
+func (c *Client) ContainerRestart(_ context.Context, _ string, _ client.ContainerRestartOptions) (client.ContainerRestartResult, error) {
+	return client.ContainerRestartResult{}, denied("ContainerRestart")
+}
+
+func (c *Client) ContainerPause(_ context.Context, _ string, _ client.ContainerPauseOptions) (client.ContainerPauseResult, error) {
+	return client.ContainerPauseResult{}, denied("ContainerPause")
+}
+
+func (c *Client) ContainerUnpause(_ context.Context, _ string, _ client.ContainerUnpauseOptions) (client.ContainerUnpauseResult, error) {
+	return client.ContainerUnpauseResult{}, denied("ContainerUnpause")
+}
+
+func (c *Client) ContainerKill(_ context.Context, _ string, _ client.ContainerKillOptions) (client.ContainerKillResult, error) {
+	return client.ContainerKillResult{}, denied("ContainerKill")
+}
+
+func (c *Client) ContainerRename(_ context.Context, _ string, _ client.ContainerRenameOptions) (client.ContainerRenameResult, error) {
+	return client.ContainerRenameResult{}, denied("ContainerRename")
+}
+
+func (c *Client) ContainerResize(_ context.Context, _ string, _ client.ContainerResizeOptions) (client.ContainerResizeResult, error) {
+	return client.ContainerResizeResult{}, denied("ContainerResize")
+}
+
+func (c *Client) ContainerAttach(_ context.Context, _ string, _ client.ContainerAttachOptions) (client.ContainerAttachResult, error) {
+	return client.ContainerAttachResult{}, denied("ContainerAttach")
+}
+
+func (c *Client) ContainerCommit(_ context.Context, _ string, _ client.ContainerCommitOptions) (client.ContainerCommitResult, error) {
+	return client.ContainerCommitResult{}, denied("ContainerCommit")
+}
+
+func (c *Client) CopyToContainer(_ context.Context, _ string, _ client.CopyToContainerOptions) (client.CopyToContainerResult, error) {
+	return client.CopyToContainerResult{}, denied("CopyToContainer")
+}
+
+func (c *Client) ExecCreate(_ context.Context, _ string, _ client.ExecCreateOptions) (client.ExecCreateResult, error) {
+	return client.ExecCreateResult{}, denied("ExecCreate")
+}
+
+func (c *Client) ExecResize(_ context.Context, _ string, _ client.ExecResizeOptions) (client.ExecResizeResult, error) {
+	return client.ExecResizeResult{}, denied("ExecResize")
+}
+
+func (c *Client) ExecStart(_ context.Context, _ string, _ client.ExecStartOptions) (client.ExecStartResult, error) {
+	return client.ExecStartResult{}, denied("ExecStart")
+}
+
+func (c *Client) ExecAttach(_ context.Context, _ string, _ client.ExecAttachOptions) (client.ExecAttachResult, error) {
+	return client.ExecAttachResult{}, denied("ExecAttach")
+}
+
+func (c *Client) ImageBuild(_ context.Context, _ io.Reader, _ client.ImageBuildOptions) (client.ImageBuildResult, error) {
+	return client.ImageBuildResult{}, denied("ImageBuild")
+}
+
+func (c *Client) BuildCachePrune(_ context.Context, _ client.BuildCachePruneOptions) (client.BuildCachePruneResult, error) {
+	return client.BuildCachePruneResult{}, denied("BuildCachePrune")
+}
+
+func (c *Client) BuildCancel(_ context.Context, _ string, _ client.BuildCancelOptions) (client.BuildCancelResult, error) {
+	return client.BuildCancelResult{}, denied("BuildCancel")
+}
+
+func (c *Client) ImageImport(_ context.Context, _ client.ImageImportSource, _ string, _ client.ImageImportOptions) (client.ImageImportResult, error) {
+	return nil, denied("ImageImport")
+}
+
+func (c *Client) ImagePull(_ context.Context, _ string, _ client.ImagePullOptions) (client.ImagePullResponse, error) {
+	return nil, denied("ImagePull")
+}
+
+func (c *Client) ImagePush(_ context.Context, _ string, _ client.ImagePushOptions) (client.ImagePushResponse, error) {
+	return nil, denied("ImagePush")
+}
+
+func (c *Client) ImageRemove(_ context.Context, _ string, _ client.ImageRemoveOptions) (client.ImageRemoveResult, error) {
+	return client.ImageRemoveResult{}, denied("ImageRemove")
+}
+
+func (c *Client) ImageTag(_ context.Context, _ client.ImageTagOptions) (client.ImageTagResult, error) {
+	return client.ImageTagResult{}, denied("ImageTag")
+}
+
+func (c *Client) ImagePrune(_ context.Context, _ client.ImagePruneOptions) (client.ImagePruneResult, error) {
+	return client.ImagePruneResult{}, denied("ImagePrune")
+}
+
+func (c *Client) ImageLoad(_ context.Context, _ io.Reader, _ ...client.ImageLoadOption) (client.ImageLoadResult, error) {
+	return nil, denied("ImageLoad")
+}
+
+func (c *Client) NetworkCreate(_ context.Context, _ string, _ client.NetworkCreateOptions) (client.NetworkCreateResult, error) {
+	return client.NetworkCreateResult{}, denied("NetworkCreate")
+}
+
+func (c *Client) NetworkRemove(_ context.Context, _ string, _ client.NetworkRemoveOptions) (client.NetworkRemoveResult, error) {
+	return client.NetworkRemoveResult{}, denied("NetworkRemove")
+}
+
+func (c *Client) NetworkPrune(_ context.Context, _ client.NetworkPruneOptions) (client.NetworkPruneResult, error) {
+	return client.NetworkPruneResult{}, denied("NetworkPrune")
+}
+
+func (c *Client) NetworkConnect(_ context.Context, _ string, _ client.NetworkConnectOptions) (client.NetworkConnectResult, error) {
+	return client.NetworkConnectResult{}, denied("NetworkConnect")
+}
+
+func (c *Client) NetworkDisconnect(_ context.Context, _ string, _ client.NetworkDisconnectOptions) (client.NetworkDisconnectResult, error) {
+	return client.NetworkDisconnectResult{}, denied("NetworkDisconnect")
+}
+
+func (c *Client) NodeUpdate(_ context.Context, _ string, _ client.NodeUpdateOptions) (client.NodeUpdateResult, error) {
+	return client.NodeUpdateResult{}, denied("NodeUpdate")
+}
+
+func (c *Client) NodeRemove(_ context.Context, _ string, _ client.NodeRemoveOptions) (client.NodeRemoveResult, error) {
+	return client.NodeRemoveResult{}, denied("NodeRemove")
+}
+
+func (c *Client) PluginCreate(_ context.Context, _ io.Reader, _ client.PluginCreateOptions) (client.PluginCreateResult, error) {
+	return client.PluginCreateResult{}, denied("PluginCreate")
+}
+
+func (c *Client) PluginInstall(_ context.Context, _ string, _ client.PluginInstallOptions) (client.PluginInstallResult, error) {
+	return client.PluginInstallResult{}, denied("PluginInstall")
+}
+
+func (c *Client) PluginRemove(_ context.Context, _ string, _ client.PluginRemoveOptions) (client.PluginRemoveResult, error) {
+	return client.PluginRemoveResult{}, denied("PluginRemove")
+}
+
+func (c *Client) PluginEnable(_ context.Context, _ string, _ client.PluginEnableOptions) (client.PluginEnableResult, error) {
+	return client.PluginEnableResult{}, denied("PluginEnable")
+}
+
+func (c *Client) PluginDisable(_ context.Context, _ string, _ client.PluginDisableOptions) (client.PluginDisableResult, error) {
+	return client.PluginDisableResult{}, denied("PluginDisable")
+}
+
+func (c *Client) PluginUpgrade(_ context.Context, _ string, _ client.PluginUpgradeOptions) (client.PluginUpgradeResult, error) {
+	return nil, denied("PluginUpgrade")
+}
+
+func (c *Client) PluginPush(_ context.Context, _ string, _ client.PluginPushOptions) (client.PluginPushResult, error) {
+	return client.PluginPushResult{}, denied("PluginPush")
+}
+
+func (c *Client) PluginSet(_ context.Context, _ string, _ client.PluginSetOptions) (client.PluginSetResult, error) {
+	return client.PluginSetResult{}, denied("PluginSet")
+}
+
+func (c *Client) ServiceCreate(_ context.Context, _ client.ServiceCreateOptions) (client.ServiceCreateResult, error) {
+	return client.ServiceCreateResult{}, denied("ServiceCreate")
+}
+
+func (c *Client) ServiceUpdate(_ context.Context, _ string, _ client.ServiceUpdateOptions) (client.ServiceUpdateResult, error) {
+	return client.ServiceUpdateResult{}, denied("ServiceUpdate")
+}
+
+func (c *Client) ServiceRemove(_ context.Context, _ string, _ client.ServiceRemoveOptions) (client.ServiceRemoveResult, error) {
+	return client.ServiceRemoveResult{}, denied("ServiceRemove")
+}
+
+func (c *Client) SwarmInit(_ context.Context, _ client.SwarmInitOptions) (client.SwarmInitResult, error) {
+	return client.SwarmInitResult{}, denied("SwarmInit")
+}
+
+func (c *Client) SwarmJoin(_ context.Context, _ client.SwarmJoinOptions) (client.SwarmJoinResult, error) {
+	return client.SwarmJoinResult{}, denied("SwarmJoin")
+}
+
+func (c *Client) SwarmUpdate(_ context.Context, _ client.SwarmUpdateOptions) (client.SwarmUpdateResult, error) {
+	return client.SwarmUpdateResult{}, denied("SwarmUpdate")
+}
+
+func (c *Client) SwarmLeave(_ context.Context, _ client.SwarmLeaveOptions) (client.SwarmLeaveResult, error) {
+	return client.SwarmLeaveResult{}, denied("SwarmLeave")
+}
+
+func (c *Client) SwarmUnlock(_ context.Context, _ client.SwarmUnlockOptions) (client.SwarmUnlockResult, error) {
+	return client.SwarmUnlockResult{}, denied("SwarmUnlock")
+}
+
+func (c *Client) RegistryLogin(_ context.Context, _ client.RegistryLoginOptions) (client.RegistryLoginResult, error) {
+	return client.RegistryLoginResult{}, denied("RegistryLogin")
+}
+
+func (c *Client) VolumeCreate(_ context.Context, _ client.VolumeCreateOptions) (client.VolumeCreateResult, error) {
+	return client.VolumeCreateResult{}, denied("VolumeCreate")
+}
+
+func (c *Client) VolumeUpdate(_ context.Context, _ string, _ client.VolumeUpdateOptions) (client.VolumeUpdateResult, error) {
+	return client.VolumeUpdateResult{}, denied("VolumeUpdate")
+}
+
+func (c *Client) VolumeRemove(_ context.Context, _ string, _ client.VolumeRemoveOptions) (client.VolumeRemoveResult, error) {
+	return client.VolumeRemoveResult{}, denied("VolumeRemove")
+}
+
+func (c *Client) VolumePrune(_ context.Context, _ client.VolumePruneOptions) (client.VolumePruneResult, error) {
+	return client.VolumePruneResult{}, denied("VolumePrune")
+}
+
+func (c *Client) SecretCreate(_ context.Context, _ client.SecretCreateOptions) (client.SecretCreateResult, error) {
+	return client.SecretCreateResult{}, denied("SecretCreate")
+}
+
+func (c *Client) SecretUpdate(_ context.Context, _ string, _ client.SecretUpdateOptions) (client.SecretUpdateResult, error) {
+	return client.SecretUpdateResult{}, denied("SecretUpdate")
+}
+
+func (c *Client) SecretRemove(_ context.Context, _ string, _ client.SecretRemoveOptions) (client.SecretRemoveResult, error) {
+	return client.SecretRemoveResult{}, denied("SecretRemove")
+}
+
+func (c *Client) ConfigCreate(_ context.Context, _ client.ConfigCreateOptions) (client.ConfigCreateResult, error) {
+	return client.ConfigCreateResult{}, denied("ConfigCreate")
+}
+
+func (c *Client) ConfigUpdate(_ context.Context, _ string, _ client.ConfigUpdateOptions) (client.ConfigUpdateResult, error) {
+	return client.ConfigUpdateResult{}, denied("ConfigUpdate")
+}
+
+func (c *Client) ConfigRemove(_ context.Context, _ string, _ client.ConfigRemoveOptions) (client.ConfigRemoveResult, error) {
+	return client.ConfigRemoveResult{}, denied("ConfigRemove")
+}
+
+func (c *Client) DialHijack(_ context.Context, _, _ string, _ map[string][]string) (net.Conn, error) {
+	return nil, denied("DialHijack")
+}
+
+func (c *Client) Dialer() func(context.Context) (net.Conn, error) {
+	return func(context.Context) (net.Conn, error) {
+		return nil, denied("Dialer")
+	}
+}
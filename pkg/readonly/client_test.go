@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package readonly
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/moby/moby/client"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v5/pkg/mocks"
+)
+
+// readMethods are the methods of client.APIClient which don't change the
+// state of the engine, delegated to the wrapped client
+var readMethods = map[string]bool{
+	"CheckpointList": true, "ClientVersion": true, "Close": true, "ConfigInspect": true, "ConfigList": true,
+	"ContainerDiff": true, "ContainerExport": true, "ContainerInspect": true, "ContainerList": true,
+	"ContainerLogs": true, "ContainerStatPath": true, "ContainerStats": true, "ContainerTop": true,
+	"ContainerWait": true, "CopyFromContainer": true, "DaemonHost": true, "DiskUsage": true,
+	"DistributionInspect": true, "Events": true, "ExecInspect": true, "ImageAttestations": true,
+	"ImageHistory": true, "ImageInspect": true, "ImageList": true, "ImageSave": true, "ImageSearch": true,
+	"Info": true, "NetworkInspect": true, "NetworkList": true, "NodeInspect": true, "NodeList": true,
+	"Ping": true, "PluginInspect": true, "PluginList": true, "SecretInspect": true, "SecretList": true,
+	"ServerVersion": true, "ServiceInspect": true, "ServiceList": true, "ServiceLogs": true,
+	"SwarmGetUnlockKey": true, "SwarmInspect": true, "TaskInspect": true, "TaskList": true, "TaskLogs": true,
+	"VolumeInspect": true, "VolumeList": true,
+}
+
+// TestWriteMethodsDenied calls all the other methods of client.APIClient on a
+// Client wrapping a mock expecting no call, so a method added to the
+// interface fails the test until it is declared a read method or denied.
+func TestWriteMethodsDenied(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	c := NewClient(mocks.NewMockAPIClient(mockCtrl))
+
+	v := reflect.ValueOf(c)
+	apiClient := reflect.TypeFor[client.APIClient]()
+	for i := range apiClient.NumMethod() {
+		name := apiClient.Method(i).Name
+		if readMethods[name] {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			method := v.MethodByName(name)
+			var args []reflect.Value
+			for j := range method.Type().NumIn() {
+				in := method.Type().In(j)
+				if method.Type().IsVariadic() && j == method.Type().NumIn()-1 {
+					break
+				}
+				if in == reflect.TypeFor[context.Context]() {
+					args = append(args, reflect.ValueOf(t.Context()))
+					continue
+				}
+				args = append(args, reflect.Zero(in))
+			}
+			out := method.Call(args)
+			if name == "Dialer" {
+				dial := out[0].Interface().(func(context.Context) (net.Conn, error))
+				_, err := dial(t.Context())
+				assert.Assert(t, errors.Is(err, ErrReadOnly))
+				return
+			}
+			err, _ := out[len(out)-1].Interface().(error)
+			assert.Assert(t, errors.Is(err, ErrReadOnly), "%s returned %v", name, err)
+		})
+	}
+}
+
+func TestReadMethodsDelegated(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	apiClient.EXPECT().ContainerList(gomock.Any(), client.ContainerListOptions{All: true}).
+		Return(client.ContainerListResult{}, nil)
+
+	_, err := NewClient(apiClient).ContainerList(t.Context(), client.ContainerListOptions{All: true})
+	assert.NilError(t, err)
+}
@@ -19,6 +19,7 @@ package remote
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 func cacheDir() (string, error) {
@@ -34,3 +35,20 @@ func cacheDir() (string, error) {
 	err = os.MkdirAll(path, 0o700)
 	return path, err
 }
+
+// InCacheDir reports whether path is inside the directory remote resources are
+// downloaded to.
+func InCacheDir(path string) bool {
+	cache := ""
+	if xdg, ok := os.LookupEnv("XDG_CACHE_HOME"); ok {
+		cache = filepath.Join(xdg, "docker-compose")
+	} else {
+		dir, err := osDependentCacheDir()
+		if err != nil {
+			return false
+		}
+		cache = dir
+	}
+	rel, err := filepath.Rel(cache, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
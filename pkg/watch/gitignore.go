@@ -0,0 +1,178 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package watch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/docker/compose/v5/internal/paths"
+)
+
+// gitIgnoreMatcher matches paths with the semantics of a .gitignore file,
+// which differ from the .dockerignore ones:
+//   - a pattern without a slash matches at any depth below the root,
+//   - a pattern with a trailing slash only matches directories,
+//   - a negated pattern can't re-include a path when one of its parent
+//     directories is excluded.
+type gitIgnoreMatcher struct {
+	root     string
+	patterns []gitIgnorePattern
+}
+
+type gitIgnorePattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+var _ PathMatcher = gitIgnoreMatcher{}
+
+func newGitIgnoreMatcher(root string, r io.Reader) (gitIgnoreMatcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return gitIgnoreMatcher{}, err
+	}
+	m := gitIgnoreMatcher{root: absRoot}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := compileGitIgnorePattern(line)
+		if err != nil {
+			return gitIgnoreMatcher{}, fmt.Errorf("invalid .gitignore pattern %q: %w", line, err)
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, scanner.Err()
+}
+
+func compileGitIgnorePattern(line string) (gitIgnorePattern, error) {
+	var p gitIgnorePattern
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+	// a pattern with a slash, other than a trailing one, is relative to the
+	// root, any other one matches at any depth
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+	line = strings.TrimPrefix(line, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			expr.WriteString("/.*")
+			i += 2
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return p, err
+	}
+	p.re = re
+	return p, nil
+}
+
+func (m gitIgnoreMatcher) Matches(f string) (bool, error) {
+	if !filepath.IsAbs(f) {
+		f = filepath.Join(m.root, f)
+	}
+	if !paths.IsChild(m.root, f) || f == m.root {
+		return false, nil
+	}
+	rel, err := filepath.Rel(m.root, f)
+	if err != nil {
+		return false, err
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		isDir := i < len(parts)-1
+		if !isDir {
+			info, err := os.Stat(f)
+			isDir = err == nil && info.IsDir()
+		}
+		// once a directory is excluded, nothing below it can be re-included
+		if m.ignored(strings.Join(parts[:i+1], "/"), isDir) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MatchesEntireDir is the same as Matches, as the content of an excluded
+// directory can't be re-included.
+func (m gitIgnoreMatcher) MatchesEntireDir(f string) (bool, error) {
+	return m.Matches(f)
+}
+
+// ignored applies the patterns to a path relative to the root, the last
+// matching one deciding whether it is excluded.
+func (m gitIgnoreMatcher) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(rel) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGitIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "src", "build"), 0o755))
+
+	tests := []struct {
+		name     string
+		contents string
+		path     string
+		ignored  bool
+	}{
+		{name: "name at any depth", contents: "node_modules", path: "src/node_modules/pkg/index.js", ignored: true},
+		{name: "anchored to the root", contents: "/node_modules", path: "src/node_modules/index.js"},
+		{name: "anchored with a slash", contents: "src/*.log", path: "src/debug.log", ignored: true},
+		{name: "anchored with a slash, deeper", contents: "src/*.log", path: "src/lib/debug.log"},
+		{name: "double star", contents: "src/**/*.log", path: "src/lib/debug.log", ignored: true},
+		{name: "trailing double star", contents: "src/**", path: "src/lib/debug.log", ignored: true},
+		{name: "directory only, on a directory", contents: "build/", path: "src/build", ignored: true},
+		{name: "directory only, on a file", contents: "build/", path: "src/lib/build"},
+		{name: "directory only, on its content", contents: "build/", path: "src/build/out.js", ignored: true},
+		{name: "comment", contents: "# node_modules", path: "node_modules"},
+		{name: "last match wins", contents: "*.log\n!keep.log", path: "keep.log"},
+		{name: "negation under an excluded directory", contents: "logs/\n!logs/keep.log", path: "logs/keep.log", ignored: true},
+		{name: "character class", contents: "*.[oa]", path: "lib.a", ignored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newGitIgnoreMatcher(root, strings.NewReader(tt.contents))
+			assert.NilError(t, err)
+			ignored, err := m.Matches(filepath.Join(root, tt.path))
+			assert.NilError(t, err)
+			assert.Equal(t, ignored, tt.ignored)
+		})
+	}
+}
+
+func TestGitIgnoreNegationDiffersFromDockerIgnore(t *testing.T) {
+	root := t.TempDir()
+	const contents = "logs\n!logs/keep.log"
+	path := filepath.Join(root, "logs", "keep.log")
+
+	git, err := newGitIgnoreMatcher(root, strings.NewReader(contents))
+	assert.NilError(t, err)
+	ignored, err := git.Matches(path)
+	assert.NilError(t, err)
+	assert.Check(t, ignored, "git can't re-include a file in an excluded directory")
+
+	docker, err := DockerIgnoreTesterFromContents(root, contents)
+	assert.NilError(t, err)
+	ignored, err = docker.Matches(path)
+	assert.NilError(t, err)
+	assert.Check(t, !ignored, "dockerignore re-includes a file in an excluded directory")
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// IgnoreFilesMatcher matches the paths excluded by a set of ignore files,
+// each one read with the semantics of its format: .gitignore files as git
+// does, any other file as a .dockerignore. Missing files are ignored, and the
+// patterns can be reloaded when one of the files changes.
+type IgnoreFilesMatcher struct {
+	root  string
+	files []string
+
+	mu      sync.RWMutex
+	matcher PathMatcher
+}
+
+var _ PathMatcher = &IgnoreFilesMatcher{}
+
+// NewIgnoreFilesMatcher loads the patterns of the ignore files, relative to
+// root, which the patterns are relative to as well.
+func NewIgnoreFilesMatcher(root string, files []string) (*IgnoreFilesMatcher, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	m := &IgnoreFilesMatcher{root: absRoot}
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(absRoot, f)
+		}
+		m.files = append(m.files, filepath.Clean(f))
+	}
+	return m, m.Reload()
+}
+
+// IsIgnoreFile reports whether f is one of the files the patterns are loaded from.
+func (m *IgnoreFilesMatcher) IsIgnoreFile(f string) bool {
+	return slices.Contains(m.files, filepath.Clean(f))
+}
+
+// Reload reads the ignore files again.
+func (m *IgnoreFilesMatcher) Reload() error {
+	var matchers []PathMatcher
+	for _, f := range m.files {
+		matcher, err := m.load(f)
+		if err != nil {
+			return err
+		}
+		if matcher != nil {
+			matchers = append(matchers, matcher)
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.matcher = NewCompositeMatcher(matchers...)
+	return nil
+}
+
+func (m *IgnoreFilesMatcher) load(file string) (PathMatcher, error) {
+	r, err := os.Open(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	if filepath.Base(file) == ".gitignore" {
+		matcher, err := newGitIgnoreMatcher(m.root, r)
+		if err != nil {
+			return nil, err
+		}
+		return matcher, nil
+	}
+	patterns, err := readDockerignorePatterns(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewDockerPatternMatcher(m.root, patterns)
+}
+
+func (m *IgnoreFilesMatcher) Matches(f string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.matcher.Matches(f)
+}
+
+func (m *IgnoreFilesMatcher) MatchesEntireDir(f string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.matcher.MatchesEntireDir(f)
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright 2020 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestIgnoreFilesMatcher(t *testing.T) {
+	root := t.TempDir()
+	assert.NilError(t, os.WriteFile(filepath.Join(root, ".dockerignore"), []byte("*.md\n"), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("node_modules/\n"), 0o600))
+	assert.NilError(t, os.MkdirAll(filepath.Join(root, "web", "node_modules"), 0o755))
+
+	m, err := NewIgnoreFilesMatcher(root, []string{".dockerignore", ".gitignore", ".missingignore"})
+	assert.NilError(t, err)
+
+	matches := func(path string) bool {
+		t.Helper()
+		matched, err := m.Matches(filepath.Join(root, path))
+		assert.NilError(t, err)
+		return matched
+	}
+	assert.Check(t, matches("README.md"))
+	assert.Check(t, matches("web/node_modules/pkg/index.js"))
+	assert.Check(t, !matches("web/index.js"))
+
+	assert.Check(t, m.IsIgnoreFile(filepath.Join(root, ".gitignore")))
+	assert.Check(t, m.IsIgnoreFile(filepath.Join(root, ".missingignore")))
+	assert.Check(t, !m.IsIgnoreFile(filepath.Join(root, "web", ".gitignore")))
+
+	t.Run("reload", func(t *testing.T) {
+		assert.NilError(t, os.WriteFile(filepath.Join(root, ".missingignore"), []byte("**/*.js\n"), 0o600))
+		assert.Check(t, !matches("web/index.js"), "patterns are only read on reload")
+		assert.NilError(t, m.Reload())
+		assert.Check(t, matches("web/index.js"))
+
+		assert.NilError(t, os.Remove(filepath.Join(root, ".dockerignore")))
+		assert.NilError(t, m.Reload())
+		assert.Check(t, !matches("README.md"))
+	})
+}